@@ -2,20 +2,20 @@ package ai
 
 import (
 	"context"
-	"fmt"
 	"net/http"
 
-	"os"
 	"strings"
+	"time"
 
 	// dbFunc "business-connect/database/dbHelpFunc"
 	// cookieNul "business-connect/middleware"
+	"business-connect/logger"
+	"business-connect/metrics"
 	Data "business-connect/models"
 	// payueeTrans "business-connect/payueeTrans"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/generative-ai-go/genai"
-	"github.com/joho/godotenv"
 	"google.golang.org/api/option"
 )
 
@@ -32,9 +32,17 @@ func GetVendorProductDescriptionAI(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Failed to read body"})
 	}
 
-	description, aiErr := SendUserQuestion(aiDescriptionBody.Title)
+	jobID, queueErr := descriptionJob.Enqueue(descriptionRequest{Title: aiDescriptionBody.Title})
+	if queueErr != nil {
+		logger.Ctx(ctx).Error("error queueing ai description", "error", queueErr)
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "AI Description Generation Failed"})
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx.UserContext(), aiWait)
+	defer cancel()
+	description, aiErr := descriptionJob.Wait(waitCtx, jobID)
 	if aiErr != nil {
-		fmt.Println("this is the ai error ", aiErr)
+		logger.Ctx(ctx).Error("error generating ai description", "error", aiErr)
 		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "AI Description Generation Timed Out"})
 	}
 
@@ -57,9 +65,17 @@ func GetVendorProductTagAI(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "Failed to read body"})
 	}
 
-	tag, aiErr := SendUserQuestionTag(aiDescriptionBody.Title, aiDescriptionBody.Description)
+	jobID, queueErr := tagsJob.Enqueue(tagsRequest{Title: aiDescriptionBody.Title, Description: aiDescriptionBody.Description})
+	if queueErr != nil {
+		logger.Ctx(ctx).Error("error queueing ai tags", "error", queueErr)
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "AI Description Generation Failed"})
+	}
+
+	waitCtx, cancel := context.WithTimeout(ctx.UserContext(), aiWait)
+	defer cancel()
+	tag, aiErr := tagsJob.Wait(waitCtx, jobID)
 	if aiErr != nil {
-		fmt.Println("this is the ai error ", aiErr)
+		logger.Ctx(ctx).Error("error generating ai tags", "error", aiErr)
 		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "AI Description Generation Timed Out"})
 	}
 
@@ -69,15 +85,9 @@ func GetVendorProductTagAI(ctx *fiber.Ctx) error {
 	})
 }
 
-func SendUserQuestion(productTitle string) (response string, err error) {
-	ctx := context.Background()
-	envErr := godotenv.Load(".env")
-
-	if envErr != nil {
-		fmt.Printf("Failed to load .env file: %v\n", envErr)
-	}
-
-	apiKey := os.Getenv("AI_API_KEY")
+func SendUserQuestion(ctx context.Context, productTitle string) (response string, err error) {
+	defer metrics.AICall("description", time.Now(), &err)
+	apiKey := settings.APIKey
 
 	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
 	if err != nil {
@@ -102,7 +112,7 @@ func SendUserQuestion(productTitle string) (response string, err error) {
 		})
 	}
 
-	model := client.GenerativeModel("gemini-2.0-flash")
+	model := client.GenerativeModel(settings.Model)
 	cs := model.StartChat()
 	cs.History = chatHistory // Set chat history
 
@@ -151,15 +161,9 @@ func RegisteredTrainingDataTag(productTitle, productDescription string) string {
 	return RoleText
 }
 
-func SendUserQuestionTag(productTitle, productDescription string) (response string, err error) {
-	ctx := context.Background()
-	envErr := godotenv.Load(".env")
-
-	if envErr != nil {
-		fmt.Printf("Failed to load .env file: %v\n", envErr)
-	}
-
-	apiKey := os.Getenv("AI_API_KEY")
+func SendUserQuestionTag(ctx context.Context, productTitle, productDescription string) (response string, err error) {
+	defer metrics.AICall("tags", time.Now(), &err)
+	apiKey := settings.APIKey
 
 	client, err := genai.NewClient(ctx, option.WithAPIKey(apiKey))
 	if err != nil {
@@ -184,7 +188,7 @@ func SendUserQuestionTag(productTitle, productDescription string) (response stri
 		})
 	}
 
-	model := client.GenerativeModel("gemini-2.0-flash")
+	model := client.GenerativeModel(settings.Model)
 	cs := model.StartChat()
 	cs.History = chatHistory // Set chat history
 
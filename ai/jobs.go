@@ -0,0 +1,53 @@
+package ai
+
+import (
+	"context"
+	"time"
+
+	config "business-connect/config"
+	"business-connect/jobs"
+)
+
+// settings is the Gemini account and model, the jobs run without a request
+// to carry them
+var settings config.AIConfig
+
+// Setup gives the AI jobs the account they call the API with
+func Setup(cfg config.AIConfig) {
+	settings = cfg
+}
+
+// how long a request waits for its AI job before giving up on it
+const aiWait = 45 * time.Second
+
+// someone is waiting on these, so they fail fast rather than retry for long
+var aiJobOptions = jobs.Options{
+	MaxAttempts: 3,
+	Timeout:     20 * time.Second,
+	Backoff:     2 * time.Second,
+	MaxBackoff:  5 * time.Second,
+}
+
+type descriptionRequest struct {
+	Title string `json:"title"`
+}
+
+type tagsRequest struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+}
+
+// the AI calls run on the job workers so a burst of requests can't open
+// more connections to the API than there are workers, and a failed call is
+// retried before the seller sees an error
+var (
+	descriptionJob = jobs.DefineWithResult("ai.description", aiJobOptions,
+		func(ctx context.Context, p descriptionRequest) (string, error) {
+			return SendUserQuestion(ctx, p.Title)
+		})
+
+	tagsJob = jobs.DefineWithResult("ai.tags", aiJobOptions,
+		func(ctx context.Context, p tagsRequest) (string, error) {
+			return SendUserQuestionTag(ctx, p.Title, p.Description)
+		})
+)
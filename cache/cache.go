@@ -0,0 +1,122 @@
+// Package cache keeps rendered responses of hot read endpoints for a while.
+//
+// Every entry carries tags (products, blogs) and invalidating a tag drops
+// every entry that carries it. A tag is a version counter: an entry records
+// the versions of its tags when its request started and is stale once any
+// of them has moved on, so invalidating is one increment however many
+// entries carry the tag, and a response rendered while its data was being
+// changed never outlives the change.
+//
+// Like ratelimit the store is Redis when REDIS_URL is set and this
+// process's memory otherwise. With the memory store an invalidation only
+// reaches the process that made the change, the others serve their copy
+// until its TTL runs out, which is fine in development and nowhere else.
+// The fiber side is middleware.Cache.
+package cache
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// tags invalidated by the handlers that change what they cover
+const (
+	TagProducts = "products"
+	TagBlogs    = "blogs"
+)
+
+// Policy caches a route's responses for TTL under Tags. Name labels the
+// route in the metrics.
+type Policy struct {
+	Name string
+	TTL  time.Duration
+	Tags []string
+}
+
+// Store keeps entries and tag versions
+type Store interface {
+	// Get returns the raw entry under key, nil when there is none, and the
+	// current version of each tag in one round trip
+	Get(ctx context.Context, key string, tags []string) (entry []byte, versions []int64, err error)
+	// Set keeps entry under key for ttl
+	Set(ctx context.Context, key string, entry []byte, ttl time.Duration) error
+	// Bump moves every tag on to a new version
+	Bump(ctx context.Context, tags ...string) error
+}
+
+// Entry is one cached response
+type Entry struct {
+	// tag versions when the response was rendered
+	Versions    []int64 `json:"v"`
+	ContentType string  `json:"t"`
+	ETag        string  `json:"e"`
+	Body        []byte  `json:"b"`
+}
+
+var (
+	mu    sync.RWMutex
+	store Store = NewMemoryStore()
+)
+
+// Use makes s the store every route caches in, call it at startup before
+// serving
+func Use(s Store) {
+	mu.Lock()
+	store = s
+	mu.Unlock()
+}
+
+func current() Store {
+	mu.RLock()
+	defer mu.RUnlock()
+	return store
+}
+
+// Lookup returns the fresh entry under key if there is one, and the tag
+// versions to pass to Save when there isn't
+func Lookup(ctx context.Context, key string, tags []string) (entry Entry, hit bool, versions []int64, err error) {
+	raw, versions, err := current().Get(ctx, "cache:entry:"+key, tags)
+	if err != nil || raw == nil {
+		return Entry{}, false, versions, err
+	}
+
+	if err := json.Unmarshal(raw, &entry); err != nil {
+		// written by an older build, treat it as missing
+		return Entry{}, false, versions, nil
+	}
+	if len(entry.Versions) != len(versions) {
+		return Entry{}, false, versions, nil
+	}
+	for i := range versions {
+		if entry.Versions[i] != versions[i] {
+			return Entry{}, false, versions, nil
+		}
+	}
+	return entry, true, versions, nil
+}
+
+// Save keeps entry under key for ttl, versions are the ones Lookup returned
+func Save(ctx context.Context, key string, entry Entry, versions []int64, ttl time.Duration) error {
+	entry.Versions = versions
+	raw, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	return current().Set(ctx, "cache:entry:"+key, raw, ttl)
+}
+
+// Invalidate drops every entry carrying any of tags. A failure is logged
+// rather than returned, the change it follows has already been saved and
+// the entries still run out with their TTL.
+func Invalidate(ctx context.Context, tags ...string) {
+	// a cancelled request must not leave the cache stale
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), 2*time.Second)
+	defer cancel()
+
+	if err := current().Bump(ctx, tags...); err != nil {
+		slog.Error("error invalidating the cache", "tags", tags, "error", err)
+	}
+}
@@ -0,0 +1,85 @@
+package cache
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// most entries the memory store keeps, query strings are up to the client
+// so the number of keys is too
+const maxMemoryEntries = 10000
+
+type memoryEntry struct {
+	raw       []byte
+	expiresAt time.Time
+}
+
+// MemoryStore keeps the cache in this process, see the package doc
+type MemoryStore struct {
+	mu       sync.Mutex
+	entries  map[string]memoryEntry
+	versions map[string]int64
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		entries:  map[string]memoryEntry{},
+		versions: map[string]int64{},
+	}
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string, tags []string) ([]byte, []int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	versions := make([]int64, len(tags))
+	for i, tag := range tags {
+		versions[i] = s.versions[tag]
+	}
+
+	entry, ok := s.entries[key]
+	if !ok {
+		return nil, versions, nil
+	}
+	if !time.Now().Before(entry.expiresAt) {
+		delete(s.entries, key)
+		return nil, versions, nil
+	}
+	return entry.raw, versions, nil
+}
+
+func (s *MemoryStore) Set(_ context.Context, key string, raw []byte, ttl time.Duration) error {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, exists := s.entries[key]; !exists && len(s.entries) >= maxMemoryEntries {
+		for k, entry := range s.entries {
+			if !now.Before(entry.expiresAt) {
+				delete(s.entries, k)
+			}
+		}
+		// still full, drop whatever map order gives us first
+		for k := range s.entries {
+			if len(s.entries) < maxMemoryEntries {
+				break
+			}
+			delete(s.entries, k)
+		}
+	}
+
+	s.entries[key] = memoryEntry{raw: raw, expiresAt: now.Add(ttl)}
+	return nil
+}
+
+func (s *MemoryStore) Bump(_ context.Context, tags ...string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, tag := range tags {
+		s.versions[tag]++
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// RedisStore keeps the cache in Redis, shared by every process. Tag
+// versions have no expiry, there are only a handful of them.
+type RedisStore struct {
+	client *goredis.Client
+}
+
+func NewRedisStore(client *goredis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func tagKey(tag string) string {
+	return "cache:tag:" + tag
+}
+
+func (s *RedisStore) Get(ctx context.Context, key string, tags []string) ([]byte, []int64, error) {
+	keys := make([]string, 0, len(tags)+1)
+	for _, tag := range tags {
+		keys = append(keys, tagKey(tag))
+	}
+	keys = append(keys, key)
+
+	values, err := s.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(values) != len(keys) {
+		return nil, nil, errors.New("unexpected reply from redis")
+	}
+
+	versions := make([]int64, len(tags))
+	for i := range tags {
+		// a tag never bumped has no key, it is at version 0
+		if value, ok := values[i].(string); ok {
+			if versions[i], err = strconv.ParseInt(value, 10, 64); err != nil {
+				return nil, nil, err
+			}
+		}
+	}
+
+	raw, ok := values[len(tags)].(string)
+	if !ok {
+		return nil, versions, nil
+	}
+	return []byte(raw), versions, nil
+}
+
+func (s *RedisStore) Set(ctx context.Context, key string, raw []byte, ttl time.Duration) error {
+	return s.client.Set(ctx, key, raw, ttl).Err()
+}
+
+func (s *RedisStore) Bump(ctx context.Context, tags ...string) error {
+	_, err := s.client.Pipelined(ctx, func(pipe goredis.Pipeliner) error {
+		for _, tag := range tags {
+			pipe.Incr(ctx, tagKey(tag))
+		}
+		return nil
+	})
+	return err
+}
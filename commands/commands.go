@@ -0,0 +1,126 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	config "business-connect/config"
+	myjwt "business-connect/middleware/myjwt"
+	oidc "business-connect/oidc"
+)
+
+const usage = `usage:
+  business-connect                         start the API server
+  business-connect jwt-keys generate       create a new signing key (same as rotate)
+  business-connect jwt-keys rotate         create a new signing key, keep the old one verify-only
+  business-connect jwt-keys retire <kid>   stop accepting tokens signed with <kid>
+  business-connect jwt-keys list           show every key id and the current signing key
+  business-connect oidc-mock [addr]        run a mock OpenID Connect issuer (default :9999)
+  business-connect migrate up              apply every pending schema migration
+  business-connect migrate down [n]        revert the last n migrations (default 1)
+  business-connect migrate status          show which migrations have been applied
+  business-connect media sweep [--dry-run] delete stored images nothing uses, or only report them
+  business-connect media backfill          record images stored before they were tracked for the sweep
+  business-connect search reindex          rebuild the search index from every post`
+
+// Run executes the command named by args (os.Args without the program name)
+func Run(args []string) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	// commands only read a few variables, they don't need a valid full config
+	if err := config.LoadEnvFiles(); err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "jwt-keys":
+		return jwtKeys(args[1:])
+	case "oidc-mock":
+		return oidcMock(args[1:])
+	case "migrate":
+		return migrate(args[1:])
+	case "media":
+		return media(args[1:])
+	case "search":
+		return search(args[1:])
+	case "help", "-h", "--help":
+		fmt.Println(usage)
+		return nil
+	default:
+		return fmt.Errorf("unknown command %q\n%s", args[0], usage)
+	}
+}
+
+func jwtKeys(args []string) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	cfg, err := config.LoadJWT()
+	if err != nil {
+		return err
+	}
+	dir := cfg.KeysDir
+
+	switch args[0] {
+	case "generate", "rotate":
+		kid, err := myjwt.GenerateKey(dir)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("new signing key %s written to %s\n", kid, dir)
+		fmt.Println("restart the server (all prefork children) to start signing with it")
+		return nil
+	case "retire":
+		if len(args) < 2 {
+			return errors.New("usage: business-connect jwt-keys retire <kid>")
+		}
+		if err := myjwt.RetireKey(dir, args[1]); err != nil {
+			return err
+		}
+		fmt.Printf("key %s retired\n", args[1])
+		return nil
+	case "list":
+		kids, current, err := myjwt.ListKeys(cfg)
+		if err != nil {
+			return err
+		}
+		for _, kid := range kids {
+			if kid == current {
+				fmt.Println(kid, "(signing)")
+				continue
+			}
+			fmt.Println(kid)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown jwt-keys command %q\n%s", args[0], usage)
+	}
+}
+
+// oidcMock serves a mock OpenID Connect issuer for trying out social sign in
+// locally, see oidc.MockIssuer for the env vars the API needs
+func oidcMock(args []string) error {
+	addr := ":9999"
+	if len(args) > 0 {
+		addr = args[0]
+	}
+
+	host := addr
+	if strings.HasPrefix(host, ":") {
+		host = "localhost" + host
+	}
+
+	issuer, err := oidc.NewMockIssuer("http://" + host)
+	if err != nil {
+		return err
+	}
+
+	log.Printf("mock OIDC issuer %s listening on %s", issuer.Issuer, addr)
+	return http.ListenAndServe(addr, issuer.Handler())
+}
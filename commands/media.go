@@ -0,0 +1,86 @@
+package commands
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+
+	config "business-connect/config"
+	database "business-connect/database"
+	dbFunc "business-connect/database/dbHelpFunc"
+	"business-connect/storage"
+	"business-connect/upload"
+)
+
+// media sweeps stored images nothing uses any more, like the server does
+// every hour, and prints the report. backfill records images stored before
+// they were tracked, run it once before the first sweep.
+func media(args []string) error {
+	if len(args) == 1 && args[0] == "backfill" {
+		return backfillMedia()
+	}
+	if len(args) == 0 || args[0] != "sweep" {
+		return errors.New(usage)
+	}
+	dryRun := false
+	for _, arg := range args[1:] {
+		if arg != "--dry-run" {
+			return errors.New("usage: business-connect media sweep [--dry-run]")
+		}
+		dryRun = true
+	}
+
+	// the sweep deletes from storage, it needs the whole config
+	cfg, err := config.Load()
+	if err != nil {
+		return err
+	}
+	db, err := database.Open(cfg.Database)
+	if err != nil {
+		return err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+	dbFunc.DBHelper = dbFunc.NewDatabaseHelper(db)
+
+	store, err := storage.Open(cfg.Storage, cfg.B2)
+	if err != nil {
+		return err
+	}
+	storage.Use(store)
+	upload.Setup(cfg.Storage, cfg.Upload)
+
+	report, err := upload.Sweep(context.Background(), dryRun)
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	if encodeErr := encoder.Encode(report); encodeErr != nil {
+		return encodeErr
+	}
+	return err
+}
+
+func backfillMedia() error {
+	cfg, err := config.LoadDatabase()
+	if err != nil {
+		return err
+	}
+	db, err := database.Open(cfg)
+	if err != nil {
+		return err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+	dbFunc.DBHelper = dbFunc.NewDatabaseHelper(db)
+
+	recorded, err := upload.Backfill()
+	fmt.Printf("recorded %d images\n", recorded)
+	return err
+}
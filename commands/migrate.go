@@ -0,0 +1,120 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"text/tabwriter"
+	"time"
+
+	config "business-connect/config"
+	database "business-connect/database"
+	migrations "business-connect/database/migrations"
+)
+
+// migrate applies, reverts or lists the schema migrations
+func migrate(args []string) error {
+	if len(args) == 0 {
+		return errors.New(usage)
+	}
+
+	cfg, err := config.LoadDatabase()
+	if err != nil {
+		return err
+	}
+
+	db, err := database.Open(cfg)
+	if err != nil {
+		return err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+
+	migrator, err := migrations.New(sqlDB)
+	if err != nil {
+		return err
+	}
+
+	switch args[0] {
+	case "up":
+		ran, err := migrator.Up()
+		printMigrations("applied", ran)
+		if err != nil {
+			return err
+		}
+		if len(ran) == 0 {
+			fmt.Println("schema is up to date")
+		}
+		return nil
+	case "down":
+		steps := 1
+		if len(args) > 1 {
+			steps, err = strconv.Atoi(args[1])
+			if err != nil || steps < 1 {
+				return errors.New("usage: business-connect migrate down [n], n must be a positive number")
+			}
+		}
+		ran, err := migrator.Down(steps)
+		printMigrations("reverted", ran)
+		if err != nil {
+			return err
+		}
+		if len(ran) == 0 {
+			fmt.Println("nothing to revert")
+		}
+		return nil
+	case "status":
+		return migrationStatus(migrator)
+	default:
+		return fmt.Errorf("unknown migrate command %q\n%s", args[0], usage)
+	}
+}
+
+func printMigrations(action string, ran []migrations.Migration) {
+	for _, migration := range ran {
+		fmt.Printf("%s %04d_%s\n", action, migration.Version, migration.Name)
+	}
+}
+
+func migrationStatus(migrator *migrations.Migrator) error {
+	statuses, err := migrator.Status()
+	if err != nil {
+		return err
+	}
+	version, dirty, err := migrator.Version()
+	if err != nil {
+		return err
+	}
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "VERSION\tNAME\tSTATUS\tAPPLIED AT")
+	for _, status := range statuses {
+		state, appliedAt := "pending", ""
+		switch {
+		case status.Dirty:
+			state = "dirty"
+		case status.Applied && status.Up == "":
+			state = "unknown to this build"
+		case status.Applied:
+			state = "applied"
+		}
+		if status.Applied {
+			appliedAt = time.Unix(status.AppliedAt, 0).UTC().Format(time.RFC3339)
+		}
+		fmt.Fprintf(w, "%04d\t%s\t%s\t%s\n", status.Version, status.Name, state, appliedAt)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+
+	fmt.Printf("\nschema version %d, latest %d", version, migrator.Latest())
+	if dirty {
+		fmt.Print(" (dirty)")
+	}
+	fmt.Println()
+	return nil
+}
@@ -0,0 +1,64 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	config "business-connect/config"
+	database "business-connect/database"
+	dbFunc "business-connect/database/dbHelpFunc"
+)
+
+// search rebuilds the search index from the posts, for posts saved before
+// there was one or while indexing failed
+func search(args []string) error {
+	if len(args) != 1 || args[0] != "reindex" {
+		return errors.New(usage)
+	}
+
+	cfg, err := config.LoadDatabase()
+	if err != nil {
+		return err
+	}
+	db, err := database.Open(cfg)
+	if err != nil {
+		return err
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		return err
+	}
+	defer sqlDB.Close()
+	helper := dbFunc.NewDatabaseHelper(db)
+
+	indexed, failed := 0, 0
+	var afterID uint
+	for {
+		ids, err := helper.GetPostIDsToIndex(afterID, 500)
+		if err != nil {
+			return err
+		}
+		if len(ids) == 0 {
+			break
+		}
+		for _, id := range ids {
+			if err := helper.IndexPost(id); err != nil {
+				fmt.Printf("post %d: %v\n", id, err)
+				failed++
+				continue
+			}
+			indexed++
+		}
+		afterID = ids[len(ids)-1]
+	}
+
+	pruned, err := helper.PruneSearchIndex()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("indexed %d posts, %d failed, removed %d terms of deleted posts\n", indexed, failed, pruned)
+	if failed > 0 {
+		return fmt.Errorf("%d posts couldn't be indexed", failed)
+	}
+	return nil
+}
@@ -0,0 +1,712 @@
+// Package config loads every setting the API needs once at startup into a
+// typed Config. Values come from, highest priority first:
+//
+//  1. the process environment
+//  2. .env.<APP_ENV> (for example .env.production)
+//  3. .env
+//
+// APP_ENV defaults to "production" on Render and "development" elsewhere.
+// Load fails with the list of every missing required key so a bad deploy
+// stops at startup instead of on the first request that needs the key.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+const (
+	EnvDevelopment = "development"
+	EnvStaging     = "staging"
+	EnvProduction  = "production"
+)
+
+type Config struct {
+	Env  string
+	Port string
+	// how long a shutdown waits for in-flight requests and background work
+	// before the process exits anyway
+	ShutdownTimeout time.Duration
+
+	Log      LogConfig
+	Metrics  MetricsConfig
+	Jobs     JobsConfig
+	Database DatabaseConfig
+	Redis    RedisConfig
+	Security SecurityConfig
+	JWT      JWTConfig
+	Email    EmailConfig
+	Paystack PaystackConfig
+	Storage  StorageConfig
+	Upload   UploadConfig
+	B2       B2Config
+	AI       AIConfig
+	SMS      SMSConfig
+	OIDC     OIDCConfig
+}
+
+type LogConfig struct {
+	// debug, info, warn or error
+	Level string
+	// json or text, json is what the log drain on Render parses
+	Format string
+}
+
+type MetricsConfig struct {
+	// bearer token /metrics asks for, empty leaves it open
+	Token string
+	// where each prefork process leaves its metrics for /metrics to merge
+	Dir string
+}
+
+type JobsConfig struct {
+	// job workers in each process, every prefork child runs its own
+	Workers int
+	// how often idle workers look for due jobs enqueued by other processes
+	PollInterval time.Duration
+}
+
+type DatabaseConfig struct {
+	URL             string
+	MaxIdleConns    int
+	MaxOpenConns    int
+	ConnMaxLifetime time.Duration
+}
+
+type RedisConfig struct {
+	// redis:// or rediss:// URL shared by every prefork process, empty keeps
+	// rate limits and cached responses in each process's memory, which is
+	// only right for one process in development
+	URL string
+}
+
+type SecurityConfig struct {
+	// web origins allowed by CORS and the origin check middlewares
+	AllowedOrigins []string
+	// server IPs (Paystack webhooks) allowed without an origin or API key
+	AllowedIPs []string
+	// X-BUSCONNECT-APP-API-KEY expected from the mobile app
+	AppAPIKey string
+}
+
+type JWTConfig struct {
+	EncryptionKey string
+	KeysDir       string
+	PrivateKey    string
+	KeyID         string
+	// earlier signing keys tokens are still verified with, for deployments
+	// that set JWT_PRIVATE_KEY rather than keep a keys directory: the PEMs
+	// in JWT_PREVIOUS_PUBLIC_KEYS, named by JWT_PREVIOUS_KEY_IDS in order
+	PreviousKeyIDs     []string
+	PreviousPublicKeys string
+}
+
+type EmailConfig struct {
+	SenderName     string
+	SenderAccount  string
+	SenderPassword string
+
+	// account used for order and newsletter emails, defaults to the sender above
+	AdminSenderName     string
+	AdminSenderAccount  string
+	AdminSenderPassword string
+}
+
+type PaystackConfig struct {
+	SecretKey string
+	// where Paystack sends the customer after paying and after cancelling
+	CallbackURL string
+	CancelURL   string
+}
+
+// storage drivers
+const (
+	StorageB2    = "b2"
+	StorageS3    = "s3"
+	StorageLocal = "local"
+)
+
+type StorageConfig struct {
+	// b2, s3 (any S3 compatible service) or local
+	Driver string
+	// public base URL objects are served from, the object key is appended
+	PublicURL string
+
+	// folder prefixes inside the bucket
+	PostFolder    string
+	EmailFolder   string
+	BlogFolder    string
+	ProfileFolder string
+	// where clients upload straight to before the API processes the file
+	UploadFolder string
+	// media only its owner sees, under a folder per user ID, served by
+	// media.Serve with a signed URL
+	PrivateFolder string
+	// signs the time limited URLs media.Serve takes
+	MediaSigningKey string
+
+	S3    S3Config
+	Local LocalStorageConfig
+}
+
+type UploadConfig struct {
+	// largest request body the API reads, every file of one post together
+	MaxRequestBytes int
+	// largest single image, post types can allow less, see upload
+	MaxFileBytes int64
+	// how much storage each user's images may take up, 0 for no limit
+	QuotaBytes int64
+	// what the hourly sweep does with images nothing uses: delete, report
+	// (only log what it would delete) or off
+	MediaSweep string
+	// largest and longest video, post types can allow less. A video bigger
+	// than MaxRequestBytes can only come as a direct upload.
+	MaxVideoBytes   int64
+	MaxVideoSeconds int
+	// ffmpeg takes the poster frame of each video, without it videos have
+	// no poster
+	FFmpegPath string
+}
+
+type B2Config struct {
+	KeyID          string
+	ApplicationKey string
+	BucketName     string
+}
+
+type S3Config struct {
+	// https://host[:port] of the service, http:// only for a local stand-in
+	Endpoint        string
+	Region          string
+	Bucket          string
+	AccessKeyID     string
+	SecretAccessKey string
+}
+
+type LocalStorageConfig struct {
+	// folder the objects are written to
+	Dir string
+	// signs the time limited URLs, shared by every prefork process
+	SigningKey string
+}
+
+type AIConfig struct {
+	APIKey string
+	Model  string
+}
+
+type SMSConfig struct {
+	APIKey string
+}
+
+type OIDCConfig struct {
+	RedirectBaseURL string
+	FrontendURL     string
+	// keyed by provider name, only providers with a client id are listed
+	Providers map[string]OIDCProviderConfig
+}
+
+type OIDCProviderConfig struct {
+	ClientID     string
+	ClientSecret string
+	// empty means the provider's well known issuer
+	Issuer string
+	// Apple takes no static secret, each request signs a short lived one
+	// with the .p8 key: its PEM, its id and the team it belongs to
+	TeamID     string
+	KeyID      string
+	PrivateKey string
+}
+
+// Load reads the env files for the current APP_ENV, builds the Config and
+// validates it. The server hands it, or the part each needs, to the router
+// and the packages it starts, nothing reads it from a global
+func Load() (*Config, error) {
+	env := appEnv()
+	if err := loadEnvFiles(env); err != nil {
+		return nil, err
+	}
+
+	r := &envReader{}
+	cfg := r.config(env)
+	if err := cfg.validate(r.problems); err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// LoadEnvFiles only loads the env files into the process environment, for
+// tools that read a few variables themselves and don't need a full Config
+func LoadEnvFiles() error {
+	return loadEnvFiles(appEnv())
+}
+
+// LoadDatabase loads only the database settings, for the migrate command
+// which has to run before the rest of the config exists
+func LoadDatabase() (DatabaseConfig, error) {
+	if err := LoadEnvFiles(); err != nil {
+		return DatabaseConfig{}, err
+	}
+
+	r := &envReader{}
+	cfg := r.config(appEnv()).Database
+	if cfg.URL == "" {
+		r.problems = append(r.problems, "DATABASE_URL is required")
+	}
+	if len(r.problems) > 0 {
+		sort.Strings(r.problems)
+		return DatabaseConfig{}, errors.New("config: " + strings.Join(r.problems, "; "))
+	}
+
+	return cfg, nil
+}
+
+// LoadJWT loads only the JWT key settings, for the jwt-keys command
+func LoadJWT() (JWTConfig, error) {
+	if err := LoadEnvFiles(); err != nil {
+		return JWTConfig{}, err
+	}
+
+	r := &envReader{}
+	cfg := r.config(appEnv()).JWT
+	if len(r.problems) > 0 {
+		sort.Strings(r.problems)
+		return JWTConfig{}, errors.New("config: " + strings.Join(r.problems, "; "))
+	}
+	return cfg, nil
+}
+
+func appEnv() string {
+	if env := strings.ToLower(strings.TrimSpace(os.Getenv("APP_ENV"))); env != "" {
+		return env
+	}
+	if os.Getenv("RENDER") != "" {
+		return EnvProduction
+	}
+	return EnvDevelopment
+}
+
+// loadEnvFiles never overrides a variable that is already set, so the most
+// specific file has to be loaded first
+func loadEnvFiles(env string) error {
+	for _, file := range []string{".env." + env, ".env"} {
+		if _, err := os.Stat(file); err != nil {
+			continue
+		}
+		if err := godotenv.Load(file); err != nil {
+			return fmt.Errorf("config: error reading %s: %w", file, err)
+		}
+	}
+	return nil
+}
+
+// envReader reads typed values and remembers the ones that don't parse
+type envReader struct {
+	problems []string
+}
+
+func (r *envReader) config(env string) *Config {
+	emailName := os.Getenv("EMAIL_SENDER_NAME")
+	emailAccount := os.Getenv("EMAIL_SENDER_ACCOUNT")
+	emailPassword := os.Getenv("EMAIL_SENDER_PASSWORD")
+
+	return &Config{
+		Env:  env,
+		Port: r.getString("PORT", "8080"),
+		// Render waits 30s after SIGTERM before killing, leave some of it
+		ShutdownTimeout: r.getDuration("SHUTDOWN_TIMEOUT", 25*time.Second),
+
+		Log: LogConfig{
+			Level:  strings.ToLower(r.getString("LOG_LEVEL", defaultLogLevel(env))),
+			Format: strings.ToLower(r.getString("LOG_FORMAT", defaultLogFormat(env))),
+		},
+
+		Metrics: MetricsConfig{
+			Token: os.Getenv("METRICS_TOKEN"),
+			Dir:   r.getString("METRICS_DIR", filepath.Join(os.TempDir(), "business-connect-metrics")),
+		},
+
+		Jobs: JobsConfig{
+			Workers:      r.getInt("JOB_WORKERS", 2),
+			PollInterval: r.getDuration("JOB_POLL_INTERVAL", 2*time.Second),
+		},
+
+		Database: DatabaseConfig{
+			URL:             os.Getenv("DATABASE_URL"),
+			MaxIdleConns:    r.getInt("DB_MAX_IDLE_CONNS", 30),
+			MaxOpenConns:    r.getInt("DB_MAX_OPEN_CONNS", 200),
+			ConnMaxLifetime: r.getDuration("DB_CONN_MAX_LIFETIME", time.Hour),
+		},
+
+		Redis: RedisConfig{
+			URL: os.Getenv("REDIS_URL"),
+		},
+
+		Security: SecurityConfig{
+			AllowedOrigins: r.getList("ALLOWED_ORIGINS", []string{
+				"https://business-connect-eta.vercel.app",
+				"https://businessconnectt.com",
+			}),
+			AllowedIPs: r.getList("ALLOWED_IPS", []string{
+				"52.31.139.75",
+				"52.49.173.169",
+				"52.214.14.220",
+			}),
+			AppAPIKey: os.Getenv("PAYUEE_APP_API_KEY"),
+		},
+
+		JWT: JWTConfig{
+			EncryptionKey: os.Getenv("JWT_ENCRYPTION_KEY"),
+			KeysDir:       r.getString("JWT_KEYS_DIR", "keys"),
+			PrivateKey:    os.Getenv("JWT_PRIVATE_KEY"),
+			KeyID:         os.Getenv("JWT_KEY_ID"),
+
+			PreviousKeyIDs:     r.getList("JWT_PREVIOUS_KEY_IDS", nil),
+			PreviousPublicKeys: os.Getenv("JWT_PREVIOUS_PUBLIC_KEYS"),
+		},
+
+		Email: EmailConfig{
+			SenderName:          emailName,
+			SenderAccount:       emailAccount,
+			SenderPassword:      emailPassword,
+			AdminSenderName:     r.getString("ADMIN_EMAIL_SENDER_NAME", emailName),
+			AdminSenderAccount:  r.getString("ADMIN_EMAIL_SENDER_ACCOUNT", emailAccount),
+			AdminSenderPassword: r.getString("ADMIN_EMAIL_SENDER_PASSWORD", emailPassword),
+		},
+
+		Paystack: PaystackConfig{
+			SecretKey:   os.Getenv("PAYSTACK_LIVE_SECRET_KEY"),
+			CallbackURL: r.getString("PAYSTACK_CALLBACK_URL", "https://shopsphereafrica.com/track-order.html"),
+			CancelURL:   r.getString("PAYSTACK_CANCEL_URL", "https://shopsphereafrica.com/cancel-transaction.html"),
+		},
+
+		Storage: r.storage(),
+
+		Upload: UploadConfig{
+			MaxRequestBytes: r.getInt("UPLOAD_MAX_REQUEST_MB", 64) << 20,
+			MaxFileBytes:    int64(r.getInt("UPLOAD_MAX_FILE_MB", 10)) << 20,
+			QuotaBytes:      int64(r.getInt("UPLOAD_QUOTA_MB", 500)) << 20,
+			MediaSweep:      r.getString("UPLOAD_MEDIA_SWEEP", "delete"),
+			MaxVideoBytes:   int64(r.getInt("UPLOAD_MAX_VIDEO_MB", 50)) << 20,
+			MaxVideoSeconds: r.getInt("UPLOAD_MAX_VIDEO_SECONDS", 120),
+			FFmpegPath:      r.getString("UPLOAD_FFMPEG_PATH", "ffmpeg"),
+		},
+
+		B2: B2Config{
+			KeyID:          os.Getenv("B2_KEY_ID"),
+			ApplicationKey: os.Getenv("B2_APPLICATION_KEY"),
+			BucketName:     os.Getenv("B2_BUCKET_NAME"),
+		},
+
+		AI: AIConfig{
+			APIKey: os.Getenv("AI_API_KEY"),
+			Model:  r.getString("AI_MODEL", "gemini-2.0-flash"),
+		},
+
+		SMS: SMSConfig{
+			APIKey: os.Getenv("SMS_KEY"),
+		},
+
+		OIDC: OIDCConfig{
+			RedirectBaseURL: strings.TrimRight(os.Getenv("OIDC_REDIRECT_BASE_URL"), "/"),
+			FrontendURL:     strings.TrimRight(r.getString("OIDC_FRONTEND_URL", "https://businessconnectt.com"), "/"),
+			Providers:       oidcProviders("google", "apple", "mock"),
+		},
+	}
+}
+
+// storage reads the STORAGE_* settings, the folders and public URL fall
+// back to the B2_* names they had before there was more than one driver
+func (r *envReader) storage() StorageConfig {
+	driver := strings.ToLower(r.getString("STORAGE_DRIVER", StorageB2))
+
+	// local objects are served by the API itself, see storage.ServeLocal
+	publicURL := "https://shopsphereafrica.com/image/"
+	if driver == StorageLocal {
+		publicURL = "http://localhost:" + r.getString("PORT", "8080") + "/storage/"
+	}
+
+	return StorageConfig{
+		Driver:        driver,
+		PublicURL:     r.getString("STORAGE_PUBLIC_URL", r.getString("B2_EMAIL_IMAGE_BASE_URL", publicURL)),
+		PostFolder:    r.getString("STORAGE_POST_FOLDER", r.getString("B2_POST_FOLDER", "business-connect-store/")),
+		EmailFolder:   r.getString("STORAGE_EMAIL_FOLDER", r.getString("B2_EMAIL_FOLDER", "business-connect-email/")),
+		BlogFolder:    r.getString("STORAGE_BLOG_FOLDER", r.getString("B2_BLOG_FOLDER", "business-connect-blog/")),
+		ProfileFolder: r.getString("STORAGE_PROFILE_FOLDER", r.getString("B2_PROFILE_FOLDER", "business-connect-profile-images/")),
+		UploadFolder:  r.getString("STORAGE_UPLOAD_FOLDER", "business-connect-uploads/"),
+		PrivateFolder: r.getString("STORAGE_PRIVATE_FOLDER", "business-connect-private/"),
+
+		MediaSigningKey: os.Getenv("MEDIA_SIGNING_KEY"),
+		S3: S3Config{
+			Endpoint:        strings.TrimRight(os.Getenv("S3_ENDPOINT"), "/"),
+			Region:          os.Getenv("S3_REGION"),
+			Bucket:          os.Getenv("S3_BUCKET"),
+			AccessKeyID:     os.Getenv("S3_ACCESS_KEY_ID"),
+			SecretAccessKey: os.Getenv("S3_SECRET_ACCESS_KEY"),
+		},
+		Local: LocalStorageConfig{
+			Dir:        r.getString("STORAGE_LOCAL_DIR", ".storage"),
+			SigningKey: os.Getenv("STORAGE_SIGNING_KEY"),
+		},
+	}
+}
+
+func defaultLogLevel(env string) string {
+	if env == EnvDevelopment {
+		return "debug"
+	}
+	return "info"
+}
+
+func defaultLogFormat(env string) string {
+	if env == EnvDevelopment {
+		return "text"
+	}
+	return "json"
+}
+
+// oidcProviders reads OIDC_<NAME>_CLIENT_ID, _CLIENT_SECRET, _ISSUER and for
+// Apple _TEAM_ID, _KEY_ID and _PRIVATE_KEY for each provider
+func oidcProviders(names ...string) map[string]OIDCProviderConfig {
+	providers := map[string]OIDCProviderConfig{}
+	for _, name := range names {
+		prefix := "OIDC_" + strings.ToUpper(name) + "_"
+		clientID := os.Getenv(prefix + "CLIENT_ID")
+		if clientID == "" {
+			continue
+		}
+		providers[name] = OIDCProviderConfig{
+			ClientID:     clientID,
+			ClientSecret: os.Getenv(prefix + "CLIENT_SECRET"),
+			Issuer:       os.Getenv(prefix + "ISSUER"),
+			TeamID:       os.Getenv(prefix + "TEAM_ID"),
+			KeyID:        os.Getenv(prefix + "KEY_ID"),
+			PrivateKey:   os.Getenv(prefix + "PRIVATE_KEY"),
+		}
+	}
+	return providers
+}
+
+// Validate reports every missing or malformed setting at once
+func (c *Config) Validate() error {
+	return c.validate(nil)
+}
+
+func (c *Config) validate(problems []string) error {
+	required := map[string]string{
+		"DATABASE_URL":             c.Database.URL,
+		"PAYUEE_APP_API_KEY":       c.Security.AppAPIKey,
+		"JWT_ENCRYPTION_KEY":       c.JWT.EncryptionKey,
+		"EMAIL_SENDER_NAME":        c.Email.SenderName,
+		"EMAIL_SENDER_ACCOUNT":     c.Email.SenderAccount,
+		"EMAIL_SENDER_PASSWORD":    c.Email.SenderPassword,
+		"PAYSTACK_LIVE_SECRET_KEY": c.Paystack.SecretKey,
+		"AI_API_KEY":               c.AI.APIKey,
+		"MEDIA_SIGNING_KEY":        c.Storage.MediaSigningKey,
+	}
+	// only the chosen storage driver needs its credentials
+	switch c.Storage.Driver {
+	case StorageB2:
+		required["B2_KEY_ID"] = c.B2.KeyID
+		required["B2_APPLICATION_KEY"] = c.B2.ApplicationKey
+		required["B2_BUCKET_NAME"] = c.B2.BucketName
+	case StorageS3:
+		required["S3_ENDPOINT"] = c.Storage.S3.Endpoint
+		required["S3_BUCKET"] = c.Storage.S3.Bucket
+		required["S3_ACCESS_KEY_ID"] = c.Storage.S3.AccessKeyID
+		required["S3_SECRET_ACCESS_KEY"] = c.Storage.S3.SecretAccessKey
+	case StorageLocal:
+		required["STORAGE_SIGNING_KEY"] = c.Storage.Local.SigningKey
+	default:
+		problems = append(problems, fmt.Sprintf("STORAGE_DRIVER %q must be b2, s3 or local", c.Storage.Driver))
+	}
+	for key, value := range required {
+		if strings.TrimSpace(value) == "" {
+			problems = append(problems, key+" is required")
+		}
+	}
+
+	switch c.Env {
+	case EnvDevelopment, EnvStaging, EnvProduction:
+	default:
+		problems = append(problems, fmt.Sprintf("APP_ENV %q must be development, staging or production", c.Env))
+	}
+
+	// an empty level or format means the defaults, for configs built in code
+	switch c.Log.Level {
+	case "", "debug", "info", "warn", "error":
+	default:
+		problems = append(problems, fmt.Sprintf("LOG_LEVEL %q must be debug, info, warn or error", c.Log.Level))
+	}
+	switch c.Log.Format {
+	case "", "json", "text":
+	default:
+		problems = append(problems, fmt.Sprintf("LOG_FORMAT %q must be json or text", c.Log.Format))
+	}
+
+	if _, err := strconv.Atoi(c.Port); err != nil {
+		problems = append(problems, fmt.Sprintf("PORT %q is not a number", c.Port))
+	}
+
+	if c.ShutdownTimeout <= 0 {
+		problems = append(problems, "SHUTDOWN_TIMEOUT must be more than 0")
+	}
+
+	if c.Jobs.Workers < 0 {
+		problems = append(problems, "JOB_WORKERS can't be negative")
+	}
+	if c.Jobs.PollInterval <= 0 {
+		problems = append(problems, "JOB_POLL_INTERVAL must be more than 0")
+	}
+
+	if c.Storage.S3.Endpoint != "" && !strings.HasPrefix(c.Storage.S3.Endpoint, "https://") && !strings.HasPrefix(c.Storage.S3.Endpoint, "http://") {
+		problems = append(problems, "S3_ENDPOINT must start with https:// or http://")
+	}
+	if c.Storage.PublicURL != "" && !strings.HasSuffix(c.Storage.PublicURL, "/") {
+		problems = append(problems, "STORAGE_PUBLIC_URL must end with /")
+	}
+
+	if c.Upload.MaxFileBytes <= 0 {
+		problems = append(problems, "UPLOAD_MAX_FILE_MB must be more than 0")
+	}
+	if int64(c.Upload.MaxRequestBytes) < c.Upload.MaxFileBytes {
+		problems = append(problems, "UPLOAD_MAX_REQUEST_MB can't be less than UPLOAD_MAX_FILE_MB")
+	}
+	if c.Upload.QuotaBytes < 0 {
+		problems = append(problems, "UPLOAD_QUOTA_MB can't be negative")
+	}
+	if c.Upload.MaxVideoBytes <= 0 {
+		problems = append(problems, "UPLOAD_MAX_VIDEO_MB must be more than 0")
+	}
+	if c.Upload.MaxVideoSeconds <= 0 {
+		problems = append(problems, "UPLOAD_MAX_VIDEO_SECONDS must be more than 0")
+	}
+	switch c.Upload.MediaSweep {
+	case "delete", "report", "off":
+	default:
+		problems = append(problems, fmt.Sprintf("UPLOAD_MEDIA_SWEEP %q must be delete, report or off", c.Upload.MediaSweep))
+	}
+
+	if c.Redis.URL != "" && !strings.HasPrefix(c.Redis.URL, "redis://") && !strings.HasPrefix(c.Redis.URL, "rediss://") {
+		problems = append(problems, "REDIS_URL must start with redis:// or rediss://")
+	}
+
+	// AES needs a 16, 24 or 32 byte key
+	if n := len(c.JWT.EncryptionKey); n != 0 && n != 16 && n != 24 && n != 32 {
+		problems = append(problems, "JWT_ENCRYPTION_KEY must be 16, 24 or 32 bytes long")
+	}
+
+	if c.JWT.PrivateKey != "" && c.JWT.KeyID == "" {
+		problems = append(problems, "JWT_KEY_ID is required when JWT_PRIVATE_KEY is set")
+	}
+
+	if apple, ok := c.OIDC.Providers["apple"]; ok {
+		for key, value := range map[string]string{
+			"OIDC_APPLE_TEAM_ID":     apple.TeamID,
+			"OIDC_APPLE_KEY_ID":      apple.KeyID,
+			"OIDC_APPLE_PRIVATE_KEY": apple.PrivateKey,
+		} {
+			if strings.TrimSpace(value) == "" {
+				problems = append(problems, key+" is required when OIDC_APPLE_CLIENT_ID is set")
+			}
+		}
+	}
+
+	if len(c.Security.AllowedOrigins) == 0 {
+		problems = append(problems, "ALLOWED_ORIGINS must list at least one origin")
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	// sort for a stable message, map iteration order is random
+	sort.Strings(problems)
+	return errors.New("config: " + strings.Join(problems, "; "))
+}
+
+// IsProduction is true when running with APP_ENV=production
+func (c *Config) IsProduction() bool {
+	return c.Env == EnvProduction
+}
+
+// AllowsOrigin reports whether origin is one of the allowed web origins
+func (s SecurityConfig) AllowsOrigin(origin string) bool {
+	origin = strings.TrimRight(origin, "/")
+	for _, allowed := range s.AllowedOrigins {
+		if origin == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowsIP reports whether ip is one of the allowed server IPs
+func (s SecurityConfig) AllowsIP(ip string) bool {
+	for _, allowed := range s.AllowedIPs {
+		if ip == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *envReader) getString(key, fallback string) string {
+	if value := strings.TrimSpace(os.Getenv(key)); value != "" {
+		return value
+	}
+	return fallback
+}
+
+func (r *envReader) getInt(key string, fallback int) int {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return fallback
+	}
+	value, err := strconv.Atoi(raw)
+	if err != nil {
+		r.problems = append(r.problems, fmt.Sprintf("%s %q is not a number", key, raw))
+		return fallback
+	}
+	return value
+}
+
+func (r *envReader) getDuration(key string, fallback time.Duration) time.Duration {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return fallback
+	}
+	value, err := time.ParseDuration(raw)
+	if err != nil {
+		r.problems = append(r.problems, fmt.Sprintf("%s %q is not a duration like 1h or 30m", key, raw))
+		return fallback
+	}
+	return value
+}
+
+// getList reads a comma separated list, trailing slashes on URLs are dropped
+// so "https://a.com/" and "https://a.com" match the same Origin header
+func (r *envReader) getList(key string, fallback []string) []string {
+	raw := strings.TrimSpace(os.Getenv(key))
+	if raw == "" {
+		return fallback
+	}
+
+	var list []string
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.TrimRight(strings.TrimSpace(item), "/")
+		if item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
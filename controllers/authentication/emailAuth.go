@@ -5,16 +5,17 @@ import (
 	"crypto/rand"
 	"fmt"
 	"html/template"
-	"log"
+	"log/slog"
 	"math/big"
 	"net/smtp"
-	"os"
+	"net/url"
 	"time"
 
+	config "business-connect/config"
 	dbFunc "business-connect/database/dbHelpFunc"
+	"business-connect/metrics"
 	Data "business-connect/models"
 
-	"github.com/joho/godotenv"
 	"github.com/jordan-wright/email"
 )
 
@@ -32,7 +33,6 @@ const (
 var (
 	emailSendErr error
 	randError    error
-	envErr       error
 	otp          string
 )
 
@@ -73,36 +73,53 @@ func (sender *GmailSender) SendEmail(subject string, content string, to []string
 	}
 
 	smtpAuth := smtp.PlainAuth("", sender.Config.FromEmailAddress, sender.Config.FromEmailPassword, smtpAuthAddress)
-	return e.Send(smtpServerAddress, smtpAuth)
+	err := e.Send(smtpServerAddress, smtpAuth)
+	metrics.EmailSent(err)
+	return err
 }
 
-func mustEnv(key string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		log.Fatalf("Missing required environment variable: %s", key)
+// the accounts emails and texts are sent from, most are sent by jobs that
+// have no request to carry them
+var (
+	emailAccounts config.EmailConfig
+	smsAccount    config.SMSConfig
+)
+
+// SetupSenders gives the email and SMS senders the accounts they send from
+func SetupSenders(email config.EmailConfig, sms config.SMSConfig) {
+	emailAccounts = email
+	smsAccount = sms
+}
+
+// senderConfig is the account every account email is sent from
+func senderConfig() EmailConfig {
+	email := emailAccounts
+	return EmailConfig{
+		Name:              email.SenderName,
+		FromEmailAddress:  email.SenderAccount,
+		FromEmailPassword: email.SenderPassword,
+	}
+}
+
+// AdminSenderConfig is the account order and newsletter emails are sent from
+func AdminSenderConfig() EmailConfig {
+	email := emailAccounts
+	return EmailConfig{
+		Name:              email.AdminSenderName,
+		FromEmailAddress:  email.AdminSenderAccount,
+		FromEmailPassword: email.AdminSenderPassword,
 	}
-	return value
 }
 
-func EmailVerification(name, sendTo string) error {
+func EmailVerification(users dbFunc.UserRepo, name, sendTo string) error {
 	var (
 		newOTP Data.OTP
 	)
 
-	if os.Getenv("RENDER") == "" {
-		// Local development only
-		if err := godotenv.Load(".env"); err != nil {
-			log.Printf("Failed to load .env file: %v\n", err)
-		}
-	}
-
-	config := EmailConfig{
-		Name:              mustEnv("EMAIL_SENDER_NAME"),
-		FromEmailAddress:  mustEnv("EMAIL_SENDER_ACCOUNT"),
-		FromEmailPassword: mustEnv("EMAIL_SENDER_PASSWORD"),
-	}
+	config := senderConfig()
 
-	otp, randError = EmailOTPGeneratorNumber(6)
+	// locals, job workers run this side by side
+	otp, randError := EmailOTPGeneratorNumber(6)
 	if randError != nil {
 		return randError
 	}
@@ -148,14 +165,14 @@ func EmailVerification(name, sendTo string) error {
 	// Create a new template and parse the HTML
 	tmpl, err := template.New("email").Parse(htmlTemplate)
 	if err != nil {
-		fmt.Println(err)
+		slog.Error("error parsing email template", "error", err)
 	}
 
 	// Execute the template with the provided data
 	var body bytes.Buffer
 	err = tmpl.Execute(&body, data)
 	if err != nil {
-		fmt.Println(err)
+		slog.Error("error executing email template", "error", err)
 	}
 
 	// Convert the buffer to a string to get the final HTML content
@@ -167,14 +184,13 @@ func EmailVerification(name, sendTo string) error {
 
 	to := []string{sendTo}
 
-	emailSendErr = sender.SendEmail(subject, content, to, nil, nil, nil)
+	emailSendErr := sender.SendEmail(subject, content, to, nil, nil, nil)
 	if emailSendErr != nil {
-		fmt.Println(emailSendErr)
 		return fmt.Errorf("failed to send email: %w", emailSendErr)
 	}
 
 	// let's check if the user has a previous otp stored
-	oldOTP, getErr := dbFunc.DBHelper.GetOTPByEmail(sendTo)
+	oldOTP, getErr := users.GetOTPByEmail(sendTo)
 
 	if getErr != nil {
 		if getErr.Error() == "otp not found by email" {
@@ -185,9 +201,8 @@ func EmailVerification(name, sendTo string) error {
 			usersOTP.EmailVerification = true
 			usersOTP.PasswordReset = false
 
-			saveErr := dbFunc.DBHelper.CreateOTP(usersOTP)
+			saveErr := users.CreateOTP(usersOTP)
 			if saveErr != nil {
-				fmt.Println(saveErr)
 				return fmt.Errorf("failed to save email otp to db: %w", saveErr)
 			}
 			return nil
@@ -200,8 +215,7 @@ func EmailVerification(name, sendTo string) error {
 	newOTP.OTP = otp
 	newOTP.CreatedAT = time.Now().Add(60 * time.Minute).Unix()
 	newOTP.MaxTry = 0
-	log.Println("new otp to update: ", newOTP)
-	updateErr := dbFunc.DBHelper.UpdateExistingOTP(newOTP, oldOTP.CustomID)
+	updateErr := users.UpdateExistingOTP(newOTP, oldOTP.CustomID)
 	if updateErr != nil {
 		return fmt.Errorf("failed to save email otp to db: %w", updateErr)
 	}
@@ -209,23 +223,12 @@ func EmailVerification(name, sendTo string) error {
 	return nil
 }
 
-func ForgotPasswordEmailVerification(name, sendTo string) error {
+func ForgotPasswordEmailVerification(users dbFunc.UserRepo, name, sendTo string) error {
 	var (
 		newOTP Data.OTP
 	)
 
-	if os.Getenv("RENDER") == "" {
-		// Local development only
-		if err := godotenv.Load(".env"); err != nil {
-			log.Printf("Failed to load .env file: %v\n", err)
-		}
-	}
-
-	config := EmailConfig{
-		Name:              mustEnv("EMAIL_SENDER_NAME"),
-		FromEmailAddress:  mustEnv("EMAIL_SENDER_ACCOUNT"),
-		FromEmailPassword: mustEnv("EMAIL_SENDER_PASSWORD"),
-	}
+	config := senderConfig()
 
 	otp, randError = EmailOTPGenerator(30)
 	if randError != nil {
@@ -346,12 +349,11 @@ func ForgotPasswordEmailVerification(name, sendTo string) error {
 
 	emailSendErr = sender.SendEmail(subject, content, to, nil, nil, nil)
 	if emailSendErr != nil {
-		fmt.Println(emailSendErr)
 		return fmt.Errorf("failed to send email: %w", emailSendErr)
 	}
 
 	// let's check if the user has a previous otp stored
-	oldOTP, getErr := dbFunc.DBHelper.GetOTPByEmail(sendTo)
+	oldOTP, getErr := users.GetOTPByEmail(sendTo)
 
 	if getErr != nil {
 		if getErr.Error() == "otp not found by email" {
@@ -362,9 +364,8 @@ func ForgotPasswordEmailVerification(name, sendTo string) error {
 			usersOTP.EmailVerification = false
 			usersOTP.PasswordReset = true
 
-			saveErr := dbFunc.DBHelper.CreateOTP(usersOTP)
+			saveErr := users.CreateOTP(usersOTP)
 			if saveErr != nil {
-				fmt.Println(saveErr)
 				return fmt.Errorf("failed to save email otp to db: %w", saveErr)
 			}
 			return nil
@@ -378,7 +379,7 @@ func ForgotPasswordEmailVerification(name, sendTo string) error {
 	newOTP.CreatedAT = time.Now().Add(60 * time.Minute).Unix()
 	newOTP.MaxTry = 0
 	// log.Println("new otp to update: ", newOTP)
-	updateErr := dbFunc.DBHelper.UpdateExistingOTP(newOTP, oldOTP.CustomID)
+	updateErr := users.UpdateExistingOTP(newOTP, oldOTP.CustomID)
 	if updateErr != nil {
 		return fmt.Errorf("failed to save email otp to db: %w", updateErr)
 	}
@@ -386,23 +387,12 @@ func ForgotPasswordEmailVerification(name, sendTo string) error {
 	return nil
 }
 
-func MagicLinkEmailVerification(name, sendTo string) error {
+func MagicLinkEmailVerification(users dbFunc.UserRepo, name, sendTo string) error {
 	var (
 		newOTP Data.OTP
 	)
 
-	if os.Getenv("RENDER") == "" {
-		// Local development only
-		if err := godotenv.Load(".env"); err != nil {
-			log.Printf("Failed to load .env file: %v\n", err)
-		}
-	}
-
-	config := EmailConfig{
-		Name:              mustEnv("EMAIL_SENDER_NAME"),
-		FromEmailAddress:  mustEnv("EMAIL_SENDER_ACCOUNT"),
-		FromEmailPassword: mustEnv("EMAIL_SENDER_PASSWORD"),
-	}
+	config := senderConfig()
 
 	otp, randError = EmailOTPGenerator(30)
 	if randError != nil {
@@ -471,12 +461,11 @@ func MagicLinkEmailVerification(name, sendTo string) error {
 
 	emailSendErr = sender.SendEmail(subject, content, to, nil, nil, nil)
 	if emailSendErr != nil {
-		fmt.Println(emailSendErr)
 		return fmt.Errorf("failed to send email: %w", emailSendErr)
 	}
 
 	// let's check if the user has a previous otp stored
-	oldOTP, getErr := dbFunc.DBHelper.GetOTPByEmail(sendTo)
+	oldOTP, getErr := users.GetOTPByEmail(sendTo)
 
 	if getErr != nil {
 		if getErr.Error() == "otp not found by email" {
@@ -487,9 +476,8 @@ func MagicLinkEmailVerification(name, sendTo string) error {
 			usersOTP.EmailVerification = false
 			usersOTP.PasswordReset = true
 
-			saveErr := dbFunc.DBHelper.CreateOTP(usersOTP)
+			saveErr := users.CreateOTP(usersOTP)
 			if saveErr != nil {
-				fmt.Println(saveErr)
 				return fmt.Errorf("failed to save email otp to db: %w", saveErr)
 			}
 			return nil
@@ -502,8 +490,7 @@ func MagicLinkEmailVerification(name, sendTo string) error {
 	newOTP.OTP = otp
 	newOTP.CreatedAT = time.Now().Add(5 * time.Minute).Unix()
 	newOTP.MaxTry = 0
-	log.Println("new otp to update: ", newOTP)
-	updateErr := dbFunc.DBHelper.UpdateExistingOTP(newOTP, oldOTP.CustomID)
+	updateErr := users.UpdateExistingOTP(newOTP, oldOTP.CustomID)
 	if updateErr != nil {
 		return fmt.Errorf("failed to save email otp to db: %w", updateErr)
 	}
@@ -511,18 +498,353 @@ func MagicLinkEmailVerification(name, sendTo string) error {
 	return nil
 }
 
-func SendEmailToSubscribers(Subject, content, sendTo string) error {
+func AccountLockedEmail(name, sendTo, token string, lockedUntil time.Time) error {
+
+	config := senderConfig()
+
+	sender := NewGmailSender(config)
+	subject := "Business Connect Account Locked"
+	htmlTemplate := `
+	<!DOCTYPE html>
+	<html lang="en">
+	<head>
+		<meta charset="UTF-8">
+		<meta http-equiv="X-UA-Compatible" content="IE=edge">
+		<meta name="viewport" content="width=device-width, initial-scale=1.0">
+		<title>Account Locked</title>
+	</head>
+	<body style="font-family: Arial, sans-serif; background-color: #f4f4f4; margin: 0; padding: 0; text-align: center;">
+	<div style="max-width: 600px; margin: 0 auto; padding: 20px; background-color: #ffffff; border-radius: 8px; box-shadow: 0 0 10px rgba(0, 0, 0, 0.1); text-align: left;">
+		<img src="https://businessconnectt.com/assets/images/logo.png" alt="Business Connect Logo" style="display:block; margin:0 auto; width:120px; height:auto;">
+		<h1 style="color: #333333; margin-bottom: 20px; text-align: center;">Account Temporarily Locked</h1>
+
+		<p style="color: #777777;">Hi {{.Name}},</p>
+		<p style="color: #777777;">We noticed several failed sign in attempts on your Business Connect account, so we have locked it until {{.LockedUntil}}.</p>
+		<p style="color: #777777;">If this was you, click the button below to unlock your account right away:</p>
+
+		<a href="{{.URL}}" style="display: block; margin: 0 auto; padding: 15px; background-color: #007bff; color: #ffffff; text-decoration: none; font-size: 24px; border-radius: 6px; width: 200px; text-align: center;">Unlock</a>
+
+		<p style="color: #777777;">If you can't click the button, please copy and paste the following link into your browser:</p>
+		<textarea readonly style="display: block; margin: 0 auto; padding: 10px; background-color: #f9f9f9; border: 1px solid #ccc; border-radius: 6px; width: 100%; resize: none; font-size: 14px;">{{.URL}}</textarea>
+
+		<p style="color: #777777; margin-top: 20px;">If this wasn't you, we recommend you reset your password. Contact us at <a href="mailto:support@businessconnectt.com">support@businessconnectt.com</a> if you need help.</p>
+		<p style="color: #777777;">Thanks,<br>The Business Connect Team</p>
+	</div>
+	</body>
+	</html>
+    `
+	data := struct {
+		Name        string
+		URL         string
+		LockedUntil string
+	}{
+		Name:        name,
+		URL:         "https://businessconnectt.com/dashboard/unlock-account?email=" + url.QueryEscape(sendTo) + "&token=" + token,
+		LockedUntil: lockedUntil.UTC().Format("Jan 2, 2006 15:04 MST"),
+	}
+
+	// Create a new template and parse the HTML
+	tmpl, err := template.New("email").Parse(htmlTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to parse email template: %w", err)
+	}
+
+	// Execute the template with the provided data
+	var body bytes.Buffer
+	err = tmpl.Execute(&body, data)
+	if err != nil {
+		return fmt.Errorf("failed to execute email template: %w", err)
+	}
+
+	to := []string{sendTo}
+
+	emailSendErr := sender.SendEmail(subject, body.String(), to, nil, nil, nil)
+	if emailSendErr != nil {
+		return fmt.Errorf("failed to send email: %w", emailSendErr)
+	}
+
+	return nil
+}
+
+// EmailChangeVerification sends an OTP to the new address a user wants to
+// switch to. The email is only changed once that OTP comes back.
+func EmailChangeVerification(users dbFunc.UserRepo, name, sendTo string) error {
+
+	config := senderConfig()
+
+	code, err := EmailOTPGeneratorNumber(6)
+	if err != nil {
+		return err
+	}
+
+	sender := NewGmailSender(config)
+	subject := "Business Connect Email Change"
+	htmlTemplate := `
+	<!DOCTYPE html>
+	<html lang="en">
+	<head>
+		<meta charset="UTF-8">
+		<meta http-equiv="X-UA-Compatible" content="IE=edge">
+		<meta name="viewport" content="width=device-width, initial-scale=1.0">
+		<title>Confirm Your New Email</title>
+	</head>
+	<body style="font-family: Arial, sans-serif; background-color: #f4f4f4; margin: 0; padding: 0; text-align: center;">
+	<div style="max-width: 600px; margin: 0 auto; padding: 20px; background-color: #ffffff; border-radius: 8px; box-shadow: 0 0 10px rgba(0, 0, 0, 0.1); text-align: left;">
+		<img src="https://businessconnectt.com/assets/images/logo.png" alt="Business Connect Logo" style="display:block; margin:0 auto; width:120px; height:auto;">
+		<h1 style="color: #333333; margin-bottom: 20px; text-align: center;">Confirm Your New Email</h1>
+
+		<p style="color: #777777;">Hi {{.Name}},</p>
+		<p style="color: #777777;">You asked to use this address for your Business Connect account. Enter the code below to confirm the change:</p>
 
-	envErr := godotenv.Load(".env")
-	if envErr != nil {
-		fmt.Println(envErr)
-		return fmt.Errorf("failed to load .env file: %w", envErr)
+		<a href="#" style="display: block; margin: 0 auto; padding: 15px; background-color: #007bff; color: #ffffff; text-decoration: none; font-size: 24px; border-radius: 6px; width: 200px; text-align: center;">{{.Token}}</a>
+
+		<p style="color: #777777; margin-top: 20px;">This code expires in 60 minutes. If you didn't ask for this you can ignore this email.</p>
+		<p style="color: #777777;">Thanks,<br>The Business Connect Team</p>
+	</div>
+	</body>
+	</html>
+    `
+	data := struct {
+		Name  string
+		Token string
+	}{
+		Name:  name,
+		Token: code,
+	}
+
+	// Create a new template and parse the HTML
+	tmpl, err := template.New("email").Parse(htmlTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to parse email template: %w", err)
+	}
+
+	// Execute the template with the provided data
+	var body bytes.Buffer
+	err = tmpl.Execute(&body, data)
+	if err != nil {
+		return fmt.Errorf("failed to execute email template: %w", err)
 	}
 
+	to := []string{sendTo}
+
+	emailSendErr := sender.SendEmail(subject, body.String(), to, nil, nil, nil)
+	if emailSendErr != nil {
+		return fmt.Errorf("failed to send email: %w", emailSendErr)
+	}
+
+	// let's check if the new address has a previous otp stored
+	oldOTP, getErr := users.GetOTPByEmail(sendTo)
+	if getErr != nil {
+		if getErr.Error() != "otp not found by email" {
+			return fmt.Errorf("failed to retrieve email otp from db: %w", getErr)
+		}
+
+		usersOTP := Data.OTP{
+			Email:             sendTo,
+			OTP:               code,
+			CreatedAT:         time.Now().Unix(),
+			EmailVerification: true,
+		}
+		if saveErr := users.CreateOTP(usersOTP); saveErr != nil {
+			return fmt.Errorf("failed to save email otp to db: %w", saveErr)
+		}
+		return nil
+	}
+
+	newOTP := Data.OTP{
+		OTP:       code,
+		CreatedAT: time.Now().Unix(),
+		MaxTry:    0,
+	}
+	if updateErr := users.UpdateExistingOTP(newOTP, oldOTP.CustomID); updateErr != nil {
+		return fmt.Errorf("failed to save email otp to db: %w", updateErr)
+	}
+
+	return nil
+}
+
+// EmailChangedNotice tells the old address that the account email was changed
+// so the owner can react if it wasn't them
+func EmailChangedNotice(name, sendTo, newEmail string) error {
+
+	config := senderConfig()
+
+	sender := NewGmailSender(config)
+	subject := "Business Connect Email Changed"
+	htmlTemplate := `
+	<!DOCTYPE html>
+	<html lang="en">
+	<head>
+		<meta charset="UTF-8">
+		<meta http-equiv="X-UA-Compatible" content="IE=edge">
+		<meta name="viewport" content="width=device-width, initial-scale=1.0">
+		<title>Email Changed</title>
+	</head>
+	<body style="font-family: Arial, sans-serif; background-color: #f4f4f4; margin: 0; padding: 0; text-align: center;">
+	<div style="max-width: 600px; margin: 0 auto; padding: 20px; background-color: #ffffff; border-radius: 8px; box-shadow: 0 0 10px rgba(0, 0, 0, 0.1); text-align: left;">
+		<img src="https://businessconnectt.com/assets/images/logo.png" alt="Business Connect Logo" style="display:block; margin:0 auto; width:120px; height:auto;">
+		<h1 style="color: #333333; margin-bottom: 20px; text-align: center;">Your Email Was Changed</h1>
+
+		<p style="color: #777777;">Hi {{.Name}},</p>
+		<p style="color: #777777;">The email on your Business Connect account was changed to {{.NewEmail}}. You will no longer receive account emails at this address.</p>
+
+		<p style="color: #777777; margin-top: 20px;">If this wasn't you, contact us right away at <a href="mailto:support@businessconnectt.com">support@businessconnectt.com</a>.</p>
+		<p style="color: #777777;">Thanks,<br>The Business Connect Team</p>
+	</div>
+	</body>
+	</html>
+    `
+	data := struct {
+		Name     string
+		NewEmail string
+	}{
+		Name:     name,
+		NewEmail: newEmail,
+	}
+
+	// Create a new template and parse the HTML
+	tmpl, err := template.New("email").Parse(htmlTemplate)
+	if err != nil {
+		return fmt.Errorf("failed to parse email template: %w", err)
+	}
+
+	// Execute the template with the provided data
+	var body bytes.Buffer
+	err = tmpl.Execute(&body, data)
+	if err != nil {
+		return fmt.Errorf("failed to execute email template: %w", err)
+	}
+
+	to := []string{sendTo}
+
+	emailSendErr := sender.SendEmail(subject, body.String(), to, nil, nil, nil)
+	if emailSendErr != nil {
+		return fmt.Errorf("failed to send email: %w", emailSendErr)
+	}
+
+	return nil
+}
+
+// sendHTMLEmail renders htmlTemplate with data inside the standard Business
+// Connect email layout and sends it to sendTo
+func sendHTMLEmail(sendTo, subject, title, htmlTemplate string, data interface{}) error {
+
+	config := senderConfig()
+
+	layout := `
+	<!DOCTYPE html>
+	<html lang="en">
+	<head>
+		<meta charset="UTF-8">
+		<meta http-equiv="X-UA-Compatible" content="IE=edge">
+		<meta name="viewport" content="width=device-width, initial-scale=1.0">
+		<title>` + title + `</title>
+	</head>
+	<body style="font-family: Arial, sans-serif; background-color: #f4f4f4; margin: 0; padding: 0; text-align: center;">
+	<div style="max-width: 600px; margin: 0 auto; padding: 20px; background-color: #ffffff; border-radius: 8px; box-shadow: 0 0 10px rgba(0, 0, 0, 0.1); text-align: left;">
+		<img src="https://businessconnectt.com/assets/images/logo.png" alt="Business Connect Logo" style="display:block; margin:0 auto; width:120px; height:auto;">
+		<h1 style="color: #333333; margin-bottom: 20px; text-align: center;">` + title + `</h1>
+		` + htmlTemplate + `
+		<p style="color: #777777;">Thanks,<br>The Business Connect Team</p>
+	</div>
+	</body>
+	</html>
+    `
+
+	// Create a new template and parse the HTML
+	tmpl, err := template.New("email").Parse(layout)
+	if err != nil {
+		return fmt.Errorf("failed to parse email template: %w", err)
+	}
+
+	// Execute the template with the provided data
+	var body bytes.Buffer
+	err = tmpl.Execute(&body, data)
+	if err != nil {
+		return fmt.Errorf("failed to execute email template: %w", err)
+	}
+
+	sender := NewGmailSender(config)
+	if err := sender.SendEmail(subject, body.String(), []string{sendTo}, nil, nil, nil); err != nil {
+		return fmt.Errorf("failed to send email: %w", err)
+	}
+
+	return nil
+}
+
+// AccountDeletionScheduledEmail confirms a deletion request and carries the
+// link that cancels it
+func AccountDeletionScheduledEmail(name, sendTo, token string, scheduledFor time.Time) error {
+	htmlTemplate := `
+		<p style="color: #777777;">Hi {{.Name}},</p>
+		<p style="color: #777777;">We received a request to delete your Business Connect account. Your account and everything in it will be permanently deleted on {{.ScheduledFor}}.</p>
+		<p style="color: #777777;">Changed your mind? Click the button below before then to keep your account:</p>
+
+		<a href="{{.URL}}" style="display: block; margin: 0 auto; padding: 15px; background-color: #007bff; color: #ffffff; text-decoration: none; font-size: 24px; border-radius: 6px; width: 200px; text-align: center;">Keep my account</a>
+
+		<p style="color: #777777; margin-top: 20px;">If you didn't ask for this, cancel the deletion and reset your password, or contact us at <a href="mailto:support@businessconnectt.com">support@businessconnectt.com</a>.</p>
+	`
+	data := struct {
+		Name         string
+		URL          string
+		ScheduledFor string
+	}{
+		Name:         name,
+		URL:          "https://businessconnectt.com/dashboard/cancel-deletion?email=" + url.QueryEscape(sendTo) + "&token=" + token,
+		ScheduledFor: scheduledFor.UTC().Format("Jan 2, 2006"),
+	}
+
+	return sendHTMLEmail(sendTo, "Business Connect Account Deletion Scheduled", "Account Deletion Scheduled", htmlTemplate, data)
+}
+
+// AccountDeletionCancelledEmail confirms the account is no longer going to be
+// deleted
+func AccountDeletionCancelledEmail(name, sendTo string) error {
+	htmlTemplate := `
+		<p style="color: #777777;">Hi {{.Name}},</p>
+		<p style="color: #777777;">Your account deletion has been cancelled. Your Business Connect account is safe and nothing was removed.</p>
+	`
+	data := struct{ Name string }{Name: name}
+
+	return sendHTMLEmail(sendTo, "Business Connect Account Deletion Cancelled", "Account Deletion Cancelled", htmlTemplate, data)
+}
+
+// AccountDeletedEmail is the last email we send, after the account is purged
+func AccountDeletedEmail(name, sendTo string) error {
+	htmlTemplate := `
+		<p style="color: #777777;">Hi {{.Name}},</p>
+		<p style="color: #777777;">Your Business Connect account and its personal data have now been permanently deleted. Orders you placed are kept for the sellers' records without your personal details.</p>
+		<p style="color: #777777;">We're sorry to see you go. You're always welcome to sign up again.</p>
+	`
+	data := struct{ Name string }{Name: name}
+
+	return sendHTMLEmail(sendTo, "Business Connect Account Deleted", "Account Deleted", htmlTemplate, data)
+}
+
+// DataExportEmail lets the owner know a copy of their data was downloaded
+func DataExportEmail(name, sendTo string, exportedAt time.Time) error {
+	htmlTemplate := `
+		<p style="color: #777777;">Hi {{.Name}},</p>
+		<p style="color: #777777;">A copy of your Business Connect data was downloaded on {{.ExportedAt}}.</p>
+		<p style="color: #777777; margin-top: 20px;">If this wasn't you, reset your password and contact us at <a href="mailto:support@businessconnectt.com">support@businessconnectt.com</a>.</p>
+	`
+	data := struct {
+		Name       string
+		ExportedAt string
+	}{
+		Name:       name,
+		ExportedAt: exportedAt.UTC().Format("Jan 2, 2006 15:04 MST"),
+	}
+
+	return sendHTMLEmail(sendTo, "Business Connect Data Export", "Your Data Export", htmlTemplate, data)
+}
+
+func SendEmailToSubscribers(Subject, content, sendTo string) error {
+
 	config := EmailConfig{
 		// Name:              os.Getenv("ADMIN_EMAIL_SENDER_NAME"),
-		FromEmailAddress:  os.Getenv("EMAIL_SENDER_ACCOUNT"),
-		FromEmailPassword: os.Getenv("EMAIL_SENDER_PASSWORD"),
+		FromEmailAddress:  emailAccounts.SenderAccount,
+		FromEmailPassword: emailAccounts.SenderPassword,
 	}
 
 	sender := NewGmailSender(config)
@@ -592,7 +914,6 @@ func SendEmailToSubscribers(Subject, content, sendTo string) error {
 
 	emailSendErr := sender.SendEmail(subject, bodyContent, to, nil, nil, nil)
 	if emailSendErr != nil {
-		fmt.Println(emailSendErr)
 		return fmt.Errorf("failed to send email: %w", emailSendErr)
 	}
 
@@ -0,0 +1,51 @@
+package emails
+
+import (
+	"context"
+	"strings"
+
+	"gorm.io/gorm"
+
+	dbFunc "business-connect/database/dbHelpFunc"
+	"business-connect/jobs"
+)
+
+type orderConfirmation struct {
+	OrderID uint `json:"order_id"`
+}
+
+type welcomeEmail struct {
+	Email string `json:"email"`
+}
+
+// orderConfirmationJob emails the customer their order once it is paid, the
+// order is read when the job runs so the email shows its latest state
+var orderConfirmationJob = jobs.Define("email.order_confirmation", jobs.Options{},
+	func(ctx context.Context, p orderConfirmation) error {
+		order, err := dbFunc.DBHelper.GetOrder(p.OrderID)
+		if err != nil {
+			if strings.HasSuffix(err.Error(), gorm.ErrRecordNotFound.Error()) {
+				return jobs.Permanent(err)
+			}
+			return err
+		}
+		return ShopsphereConfirmationEmail(*order, order.ProductOrders)
+	})
+
+// welcomeEmailJob welcomes a new newsletter subscriber
+var welcomeEmailJob = jobs.Define("email.welcome", jobs.Options{},
+	func(ctx context.Context, p welcomeEmail) error {
+		return TodacWelcomeEmail(p.Email)
+	})
+
+// QueueOrderConfirmation queues the confirmation email of order orderID
+func QueueOrderConfirmation(orderID uint) error {
+	_, err := orderConfirmationJob.Enqueue(orderConfirmation{OrderID: orderID})
+	return err
+}
+
+// QueueWelcomeEmail queues the welcome email to a new subscriber
+func QueueWelcomeEmail(subscriberEmail string) error {
+	_, err := welcomeEmailJob.Enqueue(welcomeEmail{Email: subscriberEmail})
+	return err
+}
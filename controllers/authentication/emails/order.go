@@ -5,31 +5,18 @@ import (
 	Data "business-connect/models"
 	"bytes"
 	"fmt"
-	"os"
 	"strings"
 	"text/template"
 	"time"
-
-	"github.com/joho/godotenv"
 )
 
 func ShopsphereConfirmationEmail(OrderHistoryBody Data.OrderHistory, RowEmailDataProducts []Data.ProductOrder) error {
 
-	envErr := godotenv.Load(".env")
-	if envErr != nil {
-		fmt.Println(envErr)
-		return fmt.Errorf("failed to load .env file: %w", envErr)
-	}
-
 	if OrderHistoryBody.CustomerStreetAddress2 == "" {
 		OrderHistoryBody.CustomerStreetAddress2 = OrderHistoryBody.CustomerStreetAddress1
 	}
 
-	config := OrderEmail.EmailConfig{
-		Name:              os.Getenv("ADMIN_EMAIL_SENDER_NAME"),
-		FromEmailAddress:  os.Getenv("ADMIN_EMAIL_SENDER_ACCOUNT"),
-		FromEmailPassword: os.Getenv("ADMIN_EMAIL_SENDER_PASSWORD"),
-	}
+	config := OrderEmail.AdminSenderConfig()
 
 	sender := OrderEmail.NewGmailSender(config)
 	subject := "Shopsphere Africa Order Confirmation"
@@ -183,7 +170,6 @@ func ShopsphereConfirmationEmail(OrderHistoryBody Data.OrderHistory, RowEmailDat
 
 	emailSendErr := sender.SendEmail(subject, content, to, nil, nil, nil)
 	if emailSendErr != nil {
-		fmt.Println(emailSendErr)
 		return fmt.Errorf("failed to send email: %w", emailSendErr)
 	}
 
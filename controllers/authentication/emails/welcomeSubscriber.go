@@ -4,26 +4,13 @@ import (
 	OrderEmail "business-connect/controllers/authentication"
 	"bytes"
 	"fmt"
-	"os"
 	"text/template"
 	"time"
-
-	"github.com/joho/godotenv"
 )
 
 func TodacWelcomeEmail(subscriberEmail string) error {
 
-	envErr := godotenv.Load(".env")
-	if envErr != nil {
-		fmt.Println(envErr)
-		return fmt.Errorf("failed to load .env file: %w", envErr)
-	}
-
-	config := OrderEmail.EmailConfig{
-		Name:              os.Getenv("ADMIN_EMAIL_SENDER_NAME"),
-		FromEmailAddress:  os.Getenv("ADMIN_EMAIL_SENDER_ACCOUNT"),
-		FromEmailPassword: os.Getenv("ADMIN_EMAIL_SENDER_PASSWORD"),
-	}
+	config := OrderEmail.AdminSenderConfig()
 
 	sender := OrderEmail.NewGmailSender(config)
 	subject := "Welcome to Shopsphere Africa!"
@@ -105,7 +92,6 @@ func TodacWelcomeEmail(subscriberEmail string) error {
 
 	emailSendErr := sender.SendEmail(subject, content, to, nil, nil, nil)
 	if emailSendErr != nil {
-		fmt.Println(emailSendErr)
 		return fmt.Errorf("failed to send email: %w", emailSendErr)
 	}
 
@@ -0,0 +1,25 @@
+package authentication
+
+import (
+	config "business-connect/config"
+	dbFunc "business-connect/database/dbHelpFunc"
+	oidc "business-connect/oidc"
+)
+
+// Handler serves sign up, sign in and the account checks around them.
+// The router gives it the config and the database, tests a mock of each
+// repository.
+type Handler struct {
+	Config *config.Config
+	Users  dbFunc.UserRepo
+	OIDC   oidc.Providers
+}
+
+// NewHandler is a Handler on cfg and every repository of db
+func NewHandler(cfg *config.Config, db dbFunc.DatabaseHelper) *Handler {
+	return &Handler{
+		Config: cfg,
+		Users:  db,
+		OIDC:   oidc.NewProviders(cfg.OIDC),
+	}
+}
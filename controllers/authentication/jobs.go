@@ -0,0 +1,55 @@
+package authentication
+
+import (
+	"context"
+	"time"
+
+	dbFunc "business-connect/database/dbHelpFunc"
+	"business-connect/jobs"
+	Data "business-connect/models"
+)
+
+type verificationEmail struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// verificationEmailJob sends a fresh verification OTP, retried quickly since
+// someone is waiting on the verify page for it
+var verificationEmailJob = jobs.Define("email.verification",
+	jobs.Options{MaxAttempts: 4, Backoff: 10 * time.Second},
+	func(ctx context.Context, p verificationEmail) error {
+		return EmailVerification(dbFunc.DBHelper, p.Name, p.Email)
+	})
+
+type unlockLink struct {
+	Email string `json:"email"`
+}
+
+// unlockLinkJob emails the owner of a locked account the link that unlocks it
+var unlockLinkJob = jobs.Define("email.account_locked",
+	jobs.Options{MaxAttempts: 4, Backoff: 10 * time.Second},
+	func(ctx context.Context, p unlockLink) error {
+		return sendUnlockLink(dbFunc.DBHelper, p.Email)
+	})
+
+// SMSJob sends a transactional SMS through Brevo
+var SMSJob = jobs.Define("sms.transactional", jobs.Options{},
+	func(ctx context.Context, p Data.SendSMSRequest) error {
+		_, err := SendTransactionalSMS(p)
+		return err
+	})
+
+// QueueEmailVerification queues a verification email to sendTo, the OTP is
+// generated and saved when it is sent
+func QueueEmailVerification(name, sendTo string) error {
+	_, err := verificationEmailJob.Enqueue(verificationEmail{Name: name, Email: sendTo})
+	return err
+}
+
+// queueUnlockLink queues the unlock email for the locked account of email,
+// the token is generated and saved when it is sent
+func queueUnlockLink(email string) error {
+	_, err := unlockLinkJob.Enqueue(unlockLink{Email: email})
+	return err
+}
@@ -0,0 +1,276 @@
+package authentication
+
+import (
+	"fmt"
+	"log/slog"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	rand "business-connect/controllers/authentication/utils"
+	dbFunc "business-connect/database/dbHelpFunc"
+	Data "business-connect/models"
+)
+
+const (
+	// failures allowed on one email before it is locked
+	maxAccountFailures = 5
+	// failures allowed from one IP (across any accounts) before it is locked
+	maxIPFailures = 20
+	// first lock lasts this long and doubles on every lock after it
+	baseLockDuration = time.Minute
+	maxLockDuration  = 24 * time.Hour
+	// a failure counter older than this starts again from zero
+	failureWindow = time.Hour
+	// length of the token in the emailed unlock link
+	unlockTokenLength = 40
+	// how long an unlock link works once it is sent
+	unlockLinkValidTime = time.Hour
+)
+
+func accountAttemptKey(email string) string {
+	return "email:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipAttemptKey(ip string) string {
+	return "ip:" + ip
+}
+
+// lockDuration returns how long the lockCount-th lock should last:
+// 1m, 2m, 4m, 8m ... capped at maxLockDuration
+func lockDuration(lockCount int64) time.Duration {
+	if lockCount < 1 {
+		lockCount = 1
+	}
+	multiplier := math.Pow(2, float64(lockCount-1))
+	duration := time.Duration(float64(baseLockDuration) * multiplier)
+	if duration <= 0 || duration > maxLockDuration {
+		return maxLockDuration
+	}
+	return duration
+}
+
+// loginLockRemaining reports how long the caller must wait before trying to
+// sign in again, checking both the account and the client IP
+func (h *Handler) loginLockRemaining(email, ip string) time.Duration {
+	now := time.Now().Unix()
+	var remaining int64
+
+	for _, key := range []string{accountAttemptKey(email), ipAttemptKey(ip)} {
+		attempt, err := h.Users.GetLoginAttempt(key)
+		if err != nil {
+			continue
+		}
+		if attempt.LockedUntil > now && attempt.LockedUntil-now > remaining {
+			remaining = attempt.LockedUntil - now
+		}
+	}
+
+	return time.Duration(remaining) * time.Second
+}
+
+// recordLoginFailure bumps the failure counters for the account and the IP
+// and locks whichever reached its threshold. When an account gets locked the
+// owner is emailed an unlock link.
+func (h *Handler) recordLoginFailure(email, ip string) {
+	if email != "" {
+		attempt, locked := h.bumpLoginAttempt(accountAttemptKey(email), email, maxAccountFailures)
+		if locked {
+			if err := queueUnlockLink(attempt.Email); err != nil {
+				slog.Error("error queueing unlock email", "error", err)
+			}
+		}
+	}
+
+	if ip != "" {
+		h.bumpLoginAttempt(ipAttemptKey(ip), "", maxIPFailures)
+	}
+}
+
+// bumpLoginAttempt counts a failure under key and locks it once it reaches
+// threshold, reporting whether this failure took the lock
+func (h *Handler) bumpLoginAttempt(key, email string, threshold int64) (Data.LoginAttempt, bool) {
+	now := time.Now()
+
+	attempt, err := h.Users.BumpLoginAttempt(key, strings.ToLower(strings.TrimSpace(email)), now.Unix(), now.Add(-failureWindow).Unix())
+	if err != nil {
+		slog.Error("error saving login attempt", "error", err)
+		return attempt, false
+	}
+	if attempt.Failures < threshold {
+		return attempt, false
+	}
+
+	lockedUntil := now.Add(lockDuration(attempt.LockCount + 1)).Unix()
+	locked, err := h.Users.LockLoginAttempt(attempt, threshold, lockedUntil)
+	if err != nil {
+		slog.Error("error locking login attempt", "error", err)
+		return attempt, false
+	}
+	if locked {
+		attempt.LockCount++
+		attempt.LockedUntil = lockedUntil
+		attempt.Failures = 0
+	}
+
+	return attempt, locked
+}
+
+// clearLoginFailures forgets the failures of an account after a successful
+// sign in. The IP counter is left alone so one valid account can't be used to
+// reset it.
+func (h *Handler) clearLoginFailures(email string) {
+	if err := h.Users.DeleteLoginAttempt(accountAttemptKey(email)); err != nil {
+		slog.Error("error clearing login attempts", "error", err)
+	}
+}
+
+// sendUnlockLink emails the owner of a locked account a fresh unlock link,
+// nothing is sent when the account isn't locked any more
+func sendUnlockLink(repo dbFunc.UserRepo, email string) error {
+	attempt, err := repo.GetLoginAttempt(accountAttemptKey(email))
+	if err != nil {
+		if err.Error() == "login attempt not found" {
+			return nil
+		}
+		return err
+	}
+	if attempt.LockedUntil <= time.Now().Unix() {
+		return nil
+	}
+
+	user, err := repo.FindByEmail(attempt.Email)
+	if err != nil {
+		// nobody to email, the lock still expires on its own
+		return nil
+	}
+
+	token, err := rand.RandomAlphanumericString(unlockTokenLength)
+	if err != nil {
+		return err
+	}
+
+	hashedToken, err := repo.CreatePasswordHash(token)
+	if err != nil {
+		return err
+	}
+
+	expiresAt := time.Now().Add(unlockLinkValidTime).Unix()
+	if err := repo.SetLoginUnlockToken(attempt.AttemptKey, hashedToken, expiresAt); err != nil {
+		return err
+	}
+
+	return AccountLockedEmail(user.FullName, user.Email, token, time.Unix(attempt.LockedUntil, 0))
+}
+
+// rejectLockedLogin writes the 429 response used by every sign in route when
+// the account or IP is locked
+func rejectLockedLogin(ctx *fiber.Ctx, remaining time.Duration) error {
+	seconds := int64(math.Ceil(remaining.Seconds()))
+	ctx.Set(fiber.HeaderRetryAfter, strconv.FormatInt(seconds, 10))
+	return ctx.Status(http.StatusTooManyRequests).JSON(fiber.Map{
+		"error":       fmt.Sprintf("Too many failed attempts. Please try again in %s or use the unlock link sent to your email.", remaining.Round(time.Second)),
+		"retry_after": seconds,
+	})
+}
+
+// UnlockAccount clears a lock using the token emailed when it was locked
+func (h *Handler) UnlockAccount(ctx *fiber.Ctx) error {
+	var body struct {
+		Email string `json:"email"`
+		Token string `json:"token"`
+	}
+
+	if err := ctx.BodyParser(&body); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to read body",
+		})
+	}
+
+	if body.Email == "" || body.Token == "" {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "email and token are required",
+		})
+	}
+
+	attempt, err := h.Users.GetLoginAttempt(accountAttemptKey(body.Email))
+	if err != nil || attempt.UnlockToken == "" || attempt.UnlockTokenExpiresAt <= time.Now().Unix() {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid or expired unlock link",
+		})
+	}
+
+	if hashErr := h.Users.ComparePasswordHash(attempt.UnlockToken, body.Token); hashErr != nil {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid or expired unlock link",
+		})
+	}
+
+	// only the account is unlocked, like clearLoginFailures the IP counter is
+	// left alone so locking and unlocking one's own account can't reset it
+	if err := h.Users.DeleteLoginAttempt(attempt.AttemptKey); err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "an error occurred",
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(fiber.Map{
+		"success": "Account unlocked, you can now sign in",
+	})
+}
+
+// GetLockedAccounts lists the accounts that are currently locked (admin only)
+func (h *Handler) GetLockedAccounts(ctx *fiber.Ctx) error {
+	page := ctx.QueryInt("page", 1)
+	limit := ctx.QueryInt("limit", 20)
+
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 20
+	}
+
+	offset := (page - 1) * limit
+
+	accounts, hasMore, err := h.Users.GetLockedLoginAttempts(limit, offset)
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to fetch locked accounts",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"page":     page,
+		"limit":    limit,
+		"accounts": accounts,
+		"hasMore":  hasMore,
+	})
+}
+
+// AdminUnlockAccount lets an admin clear the lock on an account by email
+func (h *Handler) AdminUnlockAccount(ctx *fiber.Ctx) error {
+	var body struct {
+		Email string `json:"email"`
+	}
+
+	if err := ctx.BodyParser(&body); err != nil || body.Email == "" {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "email is required",
+		})
+	}
+
+	if err := h.Users.DeleteLoginAttempt(accountAttemptKey(body.Email)); err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "an error occurred",
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(fiber.Map{
+		"success": body.Email + " unlocked",
+	})
+}
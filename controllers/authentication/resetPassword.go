@@ -6,11 +6,10 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 
-	dbFunc "business-connect/database/dbHelpFunc"
 	Data "business-connect/models"
 )
 
-func SendEmailPasswordChange(ctx *fiber.Ctx) error {
+func (h *Handler) SendEmailPasswordChange(ctx *fiber.Ctx) error {
 	// Separate variables for different error checks
 	var (
 		otp struct {
@@ -30,7 +29,7 @@ func SendEmailPasswordChange(ctx *fiber.Ctx) error {
 	}
 
 	// let's get user by email ID
-	UserBodyReturn, EmailErr = dbFunc.DBHelper.FindByEmail(otp.Email)
+	UserBodyReturn, EmailErr = h.Users.FindByEmail(otp.Email)
 	if EmailErr != nil {
 		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
 			"error": "failed to find user by email",
@@ -45,7 +44,7 @@ func SendEmailPasswordChange(ctx *fiber.Ctx) error {
 	// }
 
 	// let's send a token with users first and last name to verify the user with email ID
-	emailUserErr = ForgotPasswordEmailVerification(UserBodyReturn.FullName, UserBodyReturn.Email)
+	emailUserErr = ForgotPasswordEmailVerification(h.Users, UserBodyReturn.FullName, UserBodyReturn.Email)
 
 	if emailUserErr != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
@@ -59,7 +58,7 @@ func SendEmailPasswordChange(ctx *fiber.Ctx) error {
 	})
 }
 
-func VerifyForgotPassword(ctx *fiber.Ctx) error {
+func (h *Handler) VerifyForgotPassword(ctx *fiber.Ctx) error {
 	var (
 		SentOTPData struct {
 			Email    string
@@ -82,14 +81,19 @@ func VerifyForgotPassword(ctx *fiber.Ctx) error {
 		})
 	}
 
-	if dbFunc.DBHelper.CheckSpecialCharacters(SentOTPData.Email) {
+	if h.Users.CheckSpecialCharacters(SentOTPData.Email) {
 		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
 			"error": "This email is invalid because it uses illegal characters. Please enter a valid email.",
 		})
 	}
 
+	// refuse early while the account or this IP is locked out
+	if remaining := h.loginLockRemaining(SentOTPData.Email, ctx.IP()); remaining > 0 {
+		return rejectLockedLogin(ctx, remaining)
+	}
+
 	// let's check if the otp exists and check it's validity
-	OTPBody, hashOTPErr = dbFunc.DBHelper.GetAndCheckOTPByEmail(SentOTPData.Email, SentOTPData.SentOTP)
+	OTPBody, hashOTPErr = h.Users.GetAndCheckOTPByEmail(SentOTPData.Email, SentOTPData.SentOTP)
 	// Check if OTPBody is the zero-value of Data.OTP so that we can check the max tries
 	if OTPBody != (Data.OTP{}) {
 		// let's check the max tries of an otp
@@ -111,7 +115,8 @@ func VerifyForgotPassword(ctx *fiber.Ctx) error {
 		case hashOTPErr.Error() == "otp not found":
 			return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "OTP not found"})
 		case hashOTPErr.Error() == "incorrect otp value":
-			updateMaxErr := dbFunc.DBHelper.UpdateMaxTry(SentOTPData.Email)
+			h.recordLoginFailure(SentOTPData.Email, ctx.IP())
+			updateMaxErr := h.Users.UpdateMaxTry(SentOTPData.Email)
 			if updateMaxErr != nil {
 				return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "an error occurred"})
 			}
@@ -132,7 +137,7 @@ func VerifyForgotPassword(ctx *fiber.Ctx) error {
 		return ctx.Status(http.StatusRequestTimeout).JSON(fiber.Map{"error": "Reset Link Expired"})
 	}
 
-	existingUser, userErr := dbFunc.DBHelper.FindByEmail(SentOTPData.Email)
+	existingUser, userErr := h.Users.FindByEmail(SentOTPData.Email)
 	if userErr != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "an error occurred",
@@ -140,7 +145,7 @@ func VerifyForgotPassword(ctx *fiber.Ctx) error {
 	}
 
 	// Create password hash
-	hashedPassword, hashErr = dbFunc.DBHelper.CreatePasswordHash(SentOTPData.Password)
+	hashedPassword, hashErr = h.Users.CreatePasswordHash(SentOTPData.Password)
 	if hashErr != nil {
 		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
 			"error": "Failed to create password hash",
@@ -150,7 +155,7 @@ func VerifyForgotPassword(ctx *fiber.Ctx) error {
 	existingUser.Password = hashedPassword
 
 	// Save the updated user to the database
-	updateErr := dbFunc.DBHelper.UpdateUser(existingUser)
+	updateErr := h.Users.UpdateUser(existingUser)
 
 	if updateErr != nil {
 		if updateErr.Error() == "user to update not found" {
@@ -167,13 +172,16 @@ func VerifyForgotPassword(ctx *fiber.Ctx) error {
 	}
 
 	// after all successful checks let's delete the otp reset link from our db
-	delOtpErr := dbFunc.DBHelper.DeleteExistingOTPByID(OTPBody.CustomID)
+	delOtpErr := h.Users.DeleteExistingOTPByID(OTPBody.CustomID)
 	if delOtpErr != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "an error occurred",
 		})
 	}
 
+	// the password was reset so any lock on the account can go too
+	h.clearLoginFailures(SentOTPData.Email)
+
 	// Returning a success message (user's password updated successfully)
 	return ctx.Status(http.StatusCreated).JSON(fiber.Map{
 		"success": "Password updated successfully",
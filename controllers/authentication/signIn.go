@@ -9,7 +9,6 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 
-	dbFunc "business-connect/database/dbHelpFunc"
 	reqAuth "business-connect/middleware"
 	myjwt "business-connect/middleware/myjwt"
 	Data "business-connect/models"
@@ -36,7 +35,7 @@ var (
 )
 
 // login function to authenticate user and create a middleware token for subsequent request authentication
-func SignIn(ctx *fiber.Ctx) error {
+func (h *Handler) SignIn(ctx *fiber.Ctx) error {
 	var (
 		dbErr   error
 		hashErr error
@@ -49,14 +48,19 @@ func SignIn(ctx *fiber.Ctx) error {
 	}
 
 	// checking emails that contains special characters for security reasons
-	if dbFunc.DBHelper.CheckSpecialCharacters(OldUser.Email) {
+	if h.Users.CheckSpecialCharacters(OldUser.Email) {
 		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
 			"error": "This email is invalid because it uses illegal characters. Please enter a valid email",
 		})
 	}
 
+	// refuse early while the account or this IP is locked out
+	if remaining := h.loginLockRemaining(OldUser.Email, ctx.IP()); remaining > 0 {
+		return rejectLockedLogin(ctx, remaining)
+	}
+
 	// check if user exists in the db by email in the data base
-	user, dbErr = dbFunc.DBHelper.FindByEmail(OldUser.Email)
+	user, dbErr = h.Users.FindByEmail(OldUser.Email)
 
 	if dbErr != nil {
 		if dbErr.Error() == "error retrieving user" {
@@ -70,6 +74,7 @@ func SignIn(ctx *fiber.Ctx) error {
 	if dbErr != nil {
 		// checking if there are any error encountered
 		if dbErr.Error() == "user not found" {
+			h.recordLoginFailure(OldUser.Email, ctx.IP())
 			// User do not exists, send an error message
 			return ctx.Status(http.StatusNotFound).JSON(fiber.Map{
 				"error": "User do not exist, please sign up",
@@ -86,10 +91,12 @@ func SignIn(ctx *fiber.Ctx) error {
 	// }
 
 	// comparing existing password with user login password in form of hash
-	hashErr = dbFunc.DBHelper.ComparePasswordHash(user.Password, OldUser.Password)
+	hashErr = h.Users.ComparePasswordHash(user.Password, OldUser.Password)
 
 	// checking if there was an error comparing the hashes
 	if hashErr != nil {
+		h.recordLoginFailure(OldUser.Email, ctx.IP())
+
 		// Check if the error is due to a password mismatch
 		if hashErr.Error() == "password does not match" {
 			// Handle password mismatch error here
@@ -103,8 +110,14 @@ func SignIn(ctx *fiber.Ctx) error {
 			})
 		}
 		// Handle other bcrypt-related errors
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid email or password",
+		})
 	}
 
+	// the password is right so the previous failures no longer count
+	h.clearLoginFailures(user.Email)
+
 	// let's check if the user's email is suspended
 	if user.Suspended {
 		// User needs to verify email address, send an error message
@@ -136,7 +149,7 @@ func SignIn(ctx *fiber.Ctx) error {
 }
 
 // login function to authenticate user and create a middleware token for subsequent request authentication using a magic link sent to the email address
-func MagicLinkSignIn(ctx *fiber.Ctx) error {
+func (h *Handler) MagicLinkSignIn(ctx *fiber.Ctx) error {
 	var (
 		dbErr error
 	)
@@ -148,14 +161,14 @@ func MagicLinkSignIn(ctx *fiber.Ctx) error {
 	}
 
 	// checking emails that contains special characters for security reasons
-	if dbFunc.DBHelper.CheckSpecialCharacters(MagicLinkOldUser.Email) {
+	if h.Users.CheckSpecialCharacters(MagicLinkOldUser.Email) {
 		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
 			"error": "This email is invalid because it uses illegal characters. Please enter a valid email",
 		})
 	}
 
 	// check if user exists in the db by email in the data base
-	user, dbErr = dbFunc.DBHelper.FindByEmail(MagicLinkOldUser.Email)
+	user, dbErr = h.Users.FindByEmail(MagicLinkOldUser.Email)
 
 	if dbErr != nil {
 		if dbErr.Error() == "error retrieving user" {
@@ -193,7 +206,7 @@ func MagicLinkSignIn(ctx *fiber.Ctx) error {
 	}
 
 	// let's send the magic link to the user's email
-	magicError := MagicLinkEmailVerification(user.FullName, user.Email)
+	magicError := MagicLinkEmailVerification(h.Users, user.FullName, user.Email)
 	if magicError != nil {
 		return ctx.Status(http.StatusConflict).JSON(fiber.Map{
 			"error": "there was an error sending mail",
@@ -209,7 +222,7 @@ func MagicLinkSignIn(ctx *fiber.Ctx) error {
 }
 
 // this is to verify the magic login link if it's correct
-func VerifySignInMagicLink(ctx *fiber.Ctx) error {
+func (h *Handler) VerifySignInMagicLink(ctx *fiber.Ctx) error {
 	var (
 		SentOTPData struct {
 			Email   string
@@ -228,14 +241,19 @@ func VerifySignInMagicLink(ctx *fiber.Ctx) error {
 		})
 	}
 
-	if dbFunc.DBHelper.CheckSpecialCharacters(SentOTPData.Email) {
+	if h.Users.CheckSpecialCharacters(SentOTPData.Email) {
 		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
 			"error": "This email is invalid because it uses illegal characters. Please enter a valid email.",
 		})
 	}
 
+	// refuse early while the account or this IP is locked out
+	if remaining := h.loginLockRemaining(SentOTPData.Email, ctx.IP()); remaining > 0 {
+		return rejectLockedLogin(ctx, remaining)
+	}
+
 	// let's check if the otp exists and check it's validity
-	OTPBody, hashOTPErr = dbFunc.DBHelper.GetAndCheckOTPByEmail(SentOTPData.Email, SentOTPData.SentOTP)
+	OTPBody, hashOTPErr = h.Users.GetAndCheckOTPByEmail(SentOTPData.Email, SentOTPData.SentOTP)
 	// Check if OTPBody is the zero-value of Data.OTP so that we can check the max tries
 	if OTPBody != (Data.OTP{}) {
 		// let's check the max tries of an otp
@@ -257,7 +275,8 @@ func VerifySignInMagicLink(ctx *fiber.Ctx) error {
 		case hashOTPErr.Error() == "otp not found":
 			return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "OTP not found"})
 		case hashOTPErr.Error() == "incorrect otp value":
-			updateMaxErr := dbFunc.DBHelper.UpdateMaxTry(SentOTPData.Email)
+			h.recordLoginFailure(SentOTPData.Email, ctx.IP())
+			updateMaxErr := h.Users.UpdateMaxTry(SentOTPData.Email)
 			if updateMaxErr != nil {
 				return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "an error occurred"})
 			}
@@ -279,7 +298,7 @@ func VerifySignInMagicLink(ctx *fiber.Ctx) error {
 	}
 
 	// after all successful checks let's delete the magic otp login link from our db
-	delOtpErr := dbFunc.DBHelper.DeleteExistingOTPByID(OTPBody.CustomID)
+	delOtpErr := h.Users.DeleteExistingOTPByID(OTPBody.CustomID)
 	if delOtpErr != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "an error occurred",
@@ -290,7 +309,7 @@ func VerifySignInMagicLink(ctx *fiber.Ctx) error {
 	// fmt.Println("this is the user email being verified for SentOTPData.Email: ", SentOTPData.Email)
 
 	// check if user exists in the db by email in the data base
-	user, dbEmailErr = dbFunc.DBHelper.FindByEmail(SentOTPData.Email)
+	user, dbEmailErr = h.Users.FindByEmail(SentOTPData.Email)
 
 	// fmt.Println("this is the user id being verified: ", user)
 
@@ -303,6 +322,8 @@ func VerifySignInMagicLink(ctx *fiber.Ctx) error {
 		}
 	}
 
+	h.clearLoginFailures(SentOTPData.Email)
+
 	role := "USER"
 
 	// fmt.Println("this is the user id id verified: ", user.ID)
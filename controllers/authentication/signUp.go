@@ -2,8 +2,6 @@ package authentication
 
 import (
 	"errors"
-	"fmt"
-	"log"
 	"math/rand"
 	"net/http"
 	"regexp"
@@ -13,15 +11,15 @@ import (
 
 	"github.com/gofiber/fiber/v2"
 
-	dbFunc "business-connect/database/dbHelpFunc"
 	emailValid "business-connect/email"
+	"business-connect/logger"
 	reqAuth "business-connect/middleware"
 	myjwt "business-connect/middleware/myjwt"
 	Data "business-connect/models"
 	helperFunc "business-connect/paystack"
 )
 
-func SignUp(ctx *fiber.Ctx) error {
+func (h *Handler) SignUp(ctx *fiber.Ctx) error {
 	var req Data.SignUpRequest
 
 	if err := ctx.BodyParser(&req); err != nil {
@@ -38,14 +36,14 @@ func SignUp(ctx *fiber.Ctx) error {
 	}
 
 	// 2️⃣ Check existing user
-	existingUser, err := dbFunc.DBHelper.FindByEmail(req.Email)
+	existingUser, err := h.Users.FindByEmail(req.Email)
 	if err == nil {
 		if !existingUser.EmailVerified {
 			// let's send token to user to verify the user with email ID
-			emailErr := EmailVerification(existingUser.FullName, existingUser.Email)
+			emailErr := QueueEmailVerification(existingUser.FullName, existingUser.Email)
 
 			if emailErr != nil {
-				fmt.Println("this is the email error:", emailErr)
+				logger.Ctx(ctx).Error("error resending verification email", "error", emailErr)
 				return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 					"error": "email verification failed",
 				})
@@ -90,7 +88,7 @@ func SignUp(ctx *fiber.Ctx) error {
 	}
 
 	// 5️⃣ Create user
-	createdUser, err := dbFunc.DBHelper.CreateNewUser(newUser)
+	createdUser, err := h.Users.CreateNewUser(newUser)
 	if err != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to create user",
@@ -98,7 +96,7 @@ func SignUp(ctx *fiber.Ctx) error {
 	}
 
 	// 6️⃣ Send verification email
-	if err := EmailVerification(createdUser.FullName, createdUser.Email); err != nil {
+	if err := QueueEmailVerification(createdUser.FullName, createdUser.Email); err != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to send verification email",
 		})
@@ -157,7 +155,7 @@ func validateSignup(req *Data.SignUpRequest) error {
 	return nil
 }
 
-func EmailAuthentication(ctx *fiber.Ctx) error {
+func (h *Handler) EmailAuthentication(ctx *fiber.Ctx) error {
 	// Separate variables for different error checks
 	var (
 		otp            struct{ Email, SentOTP string }
@@ -176,7 +174,7 @@ func EmailAuthentication(ctx *fiber.Ctx) error {
 	}
 
 	// let's check if the otp exists and check it's validity
-	OTPBody, hashOTPErr = dbFunc.DBHelper.GetAndCheckOTPByEmail(otp.Email, otp.SentOTP)
+	OTPBody, hashOTPErr = h.Users.GetAndCheckOTPByEmail(otp.Email, otp.SentOTP)
 	// Check if OTPBody is the zero-value of Data.OTP so that we can check the max tries
 	if OTPBody != (Data.OTP{}) {
 		// let's check the max tries of an otp
@@ -187,7 +185,7 @@ func EmailAuthentication(ctx *fiber.Ctx) error {
 
 	if hashOTPErr != nil {
 		// let's get this otp body to check if the max try is greater or equals to 5
-		otpMaxTryBody, err := dbFunc.DBHelper.GetOTPByEmail(otp.Email)
+		otpMaxTryBody, err := h.Users.GetOTPByEmail(otp.Email)
 		if err != nil {
 			return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "error getting otp by email for limit check"})
 		}
@@ -203,7 +201,7 @@ func EmailAuthentication(ctx *fiber.Ctx) error {
 		case hashOTPErr.Error() == "otp not found":
 			return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "OTP not found"})
 		case hashOTPErr.Error() == "incorrect otp value":
-			updateMaxErr := dbFunc.DBHelper.UpdateMaxTry(otp.Email)
+			updateMaxErr := h.Users.UpdateMaxTry(otp.Email)
 			if updateMaxErr != nil {
 				return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "an error occurred"})
 			}
@@ -225,7 +223,7 @@ func EmailAuthentication(ctx *fiber.Ctx) error {
 	}
 
 	// Activate user's email
-	UserBodyReturn, EmailErr = dbFunc.DBHelper.FindByEmail(OTPBody.Email)
+	UserBodyReturn, EmailErr = h.Users.FindByEmail(OTPBody.Email)
 	if EmailErr != nil {
 		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Failed to find user by email"})
 	}
@@ -233,22 +231,22 @@ func EmailAuthentication(ctx *fiber.Ctx) error {
 	UserBodyReturn.EmailVerified = true
 
 	// Update the user
-	updateErr := dbFunc.DBHelper.UpdateUser(UserBodyReturn)
+	updateErr := h.Users.UpdateUser(UserBodyReturn)
 	if updateErr != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to update user"})
 	}
 
 	// Delete the OTP after successful validation
-	delOtpErr := dbFunc.DBHelper.DeleteExistingOTPByID(OTPBody.CustomID)
+	delOtpErr := h.Users.DeleteExistingOTPByID(OTPBody.CustomID)
 	if delOtpErr != nil {
-		log.Println("error occurred here:", delOtpErr)
+		logger.Ctx(ctx).Error("error deleting verified otp", "error", delOtpErr)
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "An error occurred"})
 	}
 
 	// let's create a paystack visual account for this user
 
 	// let's get user by email to authenticate the user
-	userEmail, userErr := dbFunc.DBHelper.FindByEmail(otp.Email)
+	userEmail, userErr := h.Users.FindByEmail(otp.Email)
 	if userErr != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Error getting user"})
 	}
@@ -271,7 +269,7 @@ func EmailAuthentication(ctx *fiber.Ctx) error {
 	return ctx.Status(http.StatusCreated).JSON(fiber.Map{"success": "Email verification successful"})
 }
 
-func ResendEmailVerification(ctx *fiber.Ctx) error {
+func (h *Handler) ResendEmailVerification(ctx *fiber.Ctx) error {
 	// Separate variables for different error checks
 	var (
 		otp struct {
@@ -291,7 +289,7 @@ func ResendEmailVerification(ctx *fiber.Ctx) error {
 	}
 
 	// let's check if user is in our unverified email list
-	OTPBody, OtpErr = dbFunc.DBHelper.GetOTPByEmail(otp.Email)
+	OTPBody, OtpErr = h.Users.GetOTPByEmail(otp.Email)
 	if OtpErr != nil {
 		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
 			"error": "failed to get previous email OTP",
@@ -299,7 +297,7 @@ func ResendEmailVerification(ctx *fiber.Ctx) error {
 	}
 
 	// Check if the user already exists by email in the data base so we can take the users name
-	existingUser, dbErr := dbFunc.DBHelper.FindByEmail(OTPBody.Email)
+	existingUser, dbErr := h.Users.FindByEmail(OTPBody.Email)
 
 	if dbErr != nil {
 		if dbErr.Error() == "user not found" {
@@ -316,7 +314,7 @@ func ResendEmailVerification(ctx *fiber.Ctx) error {
 	}
 
 	// let's send token to user to verify the user with email ID
-	emailErr = EmailVerification(existingUser.FullName, OTPBody.Email)
+	emailErr = QueueEmailVerification(existingUser.FullName, OTPBody.Email)
 
 	if emailErr != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
@@ -380,7 +378,7 @@ func CreateNewOtp(ctx *fiber.Ctx) error {
 	})
 }
 
-func GetStatesAndCitiesByCountryCode(ctx *fiber.Ctx) error {
+func (h *Handler) GetStatesAndCitiesByCountryCode(ctx *fiber.Ctx) error {
 	countryCode := ctx.Params("countryCode")
 
 	if countryCode == "" {
@@ -389,7 +387,7 @@ func GetStatesAndCitiesByCountryCode(ctx *fiber.Ctx) error {
 		})
 	}
 
-	states, err := dbFunc.DBHelper.GetStatesAndCitiesByCountryCode(countryCode)
+	states, err := h.Users.GetStatesAndCitiesByCountryCode(countryCode)
 	if err != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to retrieve states and cities",
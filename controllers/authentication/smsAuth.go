@@ -5,12 +5,8 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
-	"log"
 	"net/http"
-	"os"
 	"time"
-
-	"github.com/joho/godotenv"
 )
 
 func SendTransactionalSMS(
@@ -29,14 +25,7 @@ func SendTransactionalSMS(
 		return nil, err
 	}
 
-	if os.Getenv("RENDER") == "" { // assume in local dev
-		if err := godotenv.Load(".env"); err != nil {
-			log.Printf("Failed to load .env file: %v\n", err)
-		}
-	}
-
-	// Then just use os.Getenv("SMS_KEY") as usual
-	apiKey := os.Getenv("SMS_KEY")
+	apiKey := smsAccount.APIKey
 
 	req.Header.Set("accept", "application/json")
 	req.Header.Set("content-type", "application/json")
@@ -0,0 +1,287 @@
+package authentication
+
+import (
+	"log/slog"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"golang.org/x/oauth2"
+
+	rand "business-connect/controllers/authentication/utils"
+	"business-connect/logger"
+	reqAuth "business-connect/middleware"
+	myjwt "business-connect/middleware/myjwt"
+	Data "business-connect/models"
+	oidc "business-connect/oidc"
+)
+
+const (
+	// how long the user has to finish logging in at the provider
+	oidcStateValidTime = 10 * time.Minute
+	// how far the provider's clock may be behind ours
+	oidcClockSkew = time.Minute
+)
+
+// safeRedirectPath only allows paths on our own frontend so the login can't
+// be used as an open redirect
+func safeRedirectPath(path string) string {
+	if path == "" || !strings.HasPrefix(path, "/") || strings.HasPrefix(path, "//") || strings.Contains(path, "\\") {
+		return "/dashboard"
+	}
+	return path
+}
+
+func (h *Handler) redirectWithOIDCError(ctx *fiber.Ctx, code string) error {
+	return ctx.Redirect(h.Config.OIDC.FrontendURL+"/dashboard/sign-in?oidc_error="+url.QueryEscape(code), fiber.StatusFound)
+}
+
+// OIDCStart sends the browser to the provider with a fresh state, nonce and
+// PKCE verifier. With reauth=true the provider makes the user sign in again,
+// which confirms changes for users who have no password of their own.
+func (h *Handler) OIDCStart(ctx *fiber.Ctx) error {
+	provider, err := h.OIDC.Get(ctx.Params("provider"))
+	if err != nil {
+		return ctx.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "unknown sign in provider",
+		})
+	}
+
+	state, stateErr := rand.RandomAlphanumericString(32)
+	nonce, nonceErr := rand.RandomAlphanumericString(32)
+	if stateErr != nil || nonceErr != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "an error occurred",
+		})
+	}
+	verifier := oauth2.GenerateVerifier()
+
+	loginState := Data.OIDCLoginState{
+		State:        state,
+		Provider:     provider.Name,
+		Nonce:        nonce,
+		CodeVerifier: verifier,
+		RedirectTo:   safeRedirectPath(ctx.Query("redirect")),
+		ExpiresAt:    time.Now().Add(oidcStateValidTime).Unix(),
+		Reauth:       ctx.QueryBool("reauth"),
+	}
+	if err := h.Users.CreateOIDCLoginState(loginState); err != nil {
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "an error occurred",
+		})
+	}
+
+	authURL, err := provider.AuthCodeURL(ctx.UserContext(), state, nonce, verifier, loginState.Reauth)
+	if err != nil {
+		logger.Ctx(ctx).Error("oidc: error building auth url", "error", err)
+		return ctx.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+			"error": "sign in provider unavailable",
+		})
+	}
+
+	// lets the mock issuer (and Google) pre-fill the account to use
+	if hint := ctx.Query("login_hint"); hint != "" {
+		authURL += "&login_hint=" + url.QueryEscape(hint)
+	}
+
+	return ctx.Redirect(authURL, fiber.StatusFound)
+}
+
+// OIDCCallback finishes the authorization code flow: it verifies the ID
+// token, finds or creates the user and sets our normal JWT cookies
+func (h *Handler) OIDCCallback(ctx *fiber.Ctx) error {
+	provider, err := h.OIDC.Get(ctx.Params("provider"))
+	if err != nil {
+		return h.redirectWithOIDCError(ctx, "unknown_provider")
+	}
+
+	// Apple posts the callback as a form, everybody else uses the query string
+	code := ctx.FormValue("code", ctx.Query("code"))
+	state := ctx.FormValue("state", ctx.Query("state"))
+	if providerErr := ctx.FormValue("error", ctx.Query("error")); providerErr != "" {
+		return h.redirectWithOIDCError(ctx, providerErr)
+	}
+	if code == "" || state == "" {
+		return h.redirectWithOIDCError(ctx, "invalid_request")
+	}
+
+	loginState, err := h.Users.ConsumeOIDCLoginState(state)
+	if err != nil || loginState.Provider != provider.Name {
+		return h.redirectWithOIDCError(ctx, "invalid_state")
+	}
+
+	claims, err := provider.Exchange(ctx.UserContext(), code, loginState.CodeVerifier, loginState.Nonce)
+	if err != nil {
+		logger.Ctx(ctx).Error("oidc: error exchanging code", "error", err)
+		return h.redirectWithOIDCError(ctx, "invalid_token")
+	}
+
+	user, err := h.findOrCreateOIDCUser(provider.Name, claims)
+	if err != nil {
+		return h.redirectWithOIDCError(ctx, err.Error())
+	}
+
+	if user.Suspended {
+		return h.redirectWithOIDCError(ctx, "account_suspended")
+	}
+
+	// only a sign in the provider says happened after we asked for it counts
+	if loginState.Reauth {
+		started := loginState.ExpiresAt - int64(oidcStateValidTime/time.Second)
+		if claims.AuthTime >= started-int64(oidcClockSkew/time.Second) {
+			if err := h.Users.MarkIdentityReauthenticated(provider.Name, claims.Subject, time.Now().Unix()); err != nil {
+				logger.Ctx(ctx).Error("oidc: error recording reauthentication", "error", err)
+			}
+		} else {
+			logger.Ctx(ctx).Warn("oidc: provider didn't make the user sign in again", "provider", provider.Name)
+		}
+	}
+
+	// the provider didn't vouch for the email so we fall back to our own OTP
+	if !user.EmailVerified {
+		if emailErr := QueueEmailVerification(user.FullName, user.Email); emailErr != nil {
+			logger.Ctx(ctx).Error("oidc: error queueing verification email", "error", emailErr)
+		}
+		return ctx.Redirect(h.Config.OIDC.FrontendURL+"/dashboard/verify-email?email="+url.QueryEscape(user.Email), fiber.StatusFound)
+	}
+
+	role := "USER"
+
+	authTokenString, refreshTokenString, csrfSecret, errJwt := myjwt.CreateNewTokens(ctx, strconv.FormatUint(uint64(user.ID), 10), role)
+	if errJwt != nil {
+		return h.redirectWithOIDCError(ctx, "server_error")
+	}
+
+	reqAuth.SetAuthAndRefreshCookies(ctx, authTokenString, refreshTokenString, csrfSecret)
+	h.clearLoginFailures(user.Email)
+
+	return ctx.Redirect(h.Config.OIDC.FrontendURL+loginState.RedirectTo, fiber.StatusFound)
+}
+
+// findOrCreateOIDCUser resolves the provider account to a User. A provider
+// account is linked to an existing User only when the provider asserts the
+// email is verified, otherwise anyone could claim someone else's account.
+func (h *Handler) findOrCreateOIDCUser(provider string, claims *oidc.Claims) (Data.User, error) {
+	identity, err := h.Users.FindUserIdentity(provider, claims.Subject)
+	if err == nil {
+		user, userErr := h.Users.FindByUuid(identity.UserID)
+		if userErr != nil {
+			return Data.User{}, oidcError("account_not_found")
+		}
+		return user, nil
+	}
+	if err.Error() != "identity not found" {
+		return Data.User{}, oidcError("server_error")
+	}
+
+	email := strings.ToLower(strings.TrimSpace(claims.Email))
+	if email == "" {
+		return Data.User{}, oidcError("email_required")
+	}
+	emailVerified := bool(claims.EmailVerified)
+
+	user, err := h.Users.FindByEmail(email)
+	switch {
+	case err == nil:
+		if !emailVerified {
+			return Data.User{}, oidcError("email_not_verified")
+		}
+		if !user.EmailVerified {
+			// the provider proved the address, so whoever signed up with it
+			// without ever verifying it may not be its owner: their password
+			// and any session go before the account is linked
+			user, err = h.claimUnverifiedUser(user)
+			if err != nil {
+				return Data.User{}, err
+			}
+		}
+	case err.Error() == "user not found":
+		user, err = h.createOIDCUser(email, emailVerified, claims)
+		if err != nil {
+			return Data.User{}, err
+		}
+	default:
+		return Data.User{}, oidcError("server_error")
+	}
+
+	linkErr := h.Users.CreateUserIdentity(Data.UserIdentity{
+		UserID:   user.ID,
+		Provider: provider,
+		Subject:  claims.Subject,
+		Email:    email,
+	})
+	if linkErr != nil {
+		return Data.User{}, oidcError("server_error")
+	}
+
+	return user, nil
+}
+
+// claimUnverifiedUser hands an account nobody verified to the provider's
+// user: a password nobody knows, no sessions and a verified email
+func (h *Handler) claimUnverifiedUser(user Data.User) (Data.User, error) {
+	password, err := rand.RandomAlphanumericString(32)
+	if err != nil {
+		return Data.User{}, oidcError("server_error")
+	}
+	hash, err := h.Users.CreatePasswordHash(password)
+	if err != nil {
+		return Data.User{}, oidcError("server_error")
+	}
+
+	user.Password = hash
+	user.EmailVerified = true
+	if err := h.Users.UpdateUser(user); err != nil {
+		return Data.User{}, oidcError("server_error")
+	}
+	// usually there are none, an unverified account can't sign in
+	if err := h.Users.GetAndDeleteRefreshToken(user.ID); err != nil {
+		slog.Debug("oidc: no sessions to revoke on the claimed account", "user_id", user.ID, "error", err)
+	}
+	return user, nil
+}
+
+func (h *Handler) createOIDCUser(email string, emailVerified bool, claims *oidc.Claims) (Data.User, error) {
+	// nobody knows this password, the user can set one with forgot password
+	password, err := rand.RandomAlphanumericString(32)
+	if err != nil {
+		return Data.User{}, oidcError("server_error")
+	}
+
+	fullName := strings.TrimSpace(claims.Name)
+	if fullName == "" {
+		fullName = strings.TrimSpace(claims.GivenName + " " + claims.FamilyName)
+	}
+	if fullName == "" {
+		fullName = strings.Split(email, "@")[0]
+	}
+
+	newUser := Data.User{
+		FullName:        fullName,
+		Email:           email,
+		Password:        password,
+		ProfilePhotoURL: claims.Picture,
+		UserType:        "USER",
+	}
+
+	createdUser, err := h.Users.CreateNewUser(newUser)
+	if err != nil {
+		return Data.User{}, oidcError("server_error")
+	}
+
+	if emailVerified {
+		createdUser.EmailVerified = true
+		if err := h.Users.UpdateUser(createdUser); err != nil {
+			return Data.User{}, oidcError("server_error")
+		}
+	}
+
+	return createdUser, nil
+}
+
+// oidcError is an error whose message is the code sent back to the frontend
+type oidcError string
+
+func (e oidcError) Error() string { return string(e) }
@@ -9,8 +9,9 @@ import (
 	"net/http"
 	"strconv"
 
-	dbFunc "business-connect/database/dbHelpFunc"
+	"business-connect/cache"
 	Data "business-connect/models"
+	upload "business-connect/upload"
 
 	SendEmail "business-connect/controllers/authentication/emails"
 
@@ -30,7 +31,7 @@ type PaginationData struct {
 	AllRecords   int64
 }
 
-func GetBlogPost(ctx *fiber.Ctx) error {
+func (h *Handler) GetBlogPost(ctx *fiber.Ctx) error {
 	// Extract order ID from path or query parameter (adjust based on your implementation)
 	blogID, err := strconv.Atoi(ctx.Params("blogID"))
 	if err != nil {
@@ -40,7 +41,7 @@ func GetBlogPost(ctx *fiber.Ctx) error {
 	}
 
 	// Call the database helper function to retrieve the order
-	orderHistory, err := dbFunc.DBHelper.GetBlogPostById(uint(blogID))
+	orderHistory, err := h.Blogs.GetBlogPostById(uint(blogID))
 	if err != nil {
 		// Handle potential errors based on your GetOrder function implementation
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -59,7 +60,7 @@ func GetBlogPost(ctx *fiber.Ctx) error {
 	})
 }
 
-func GetBlogPosts(ctx *fiber.Ctx) error {
+func (h *Handler) GetBlogPosts(ctx *fiber.Ctx) error {
 	// this is to get the page number to route to
 	pageNumber := 1
 	limitNumber := ctx.Params("idLimit")
@@ -71,7 +72,7 @@ func GetBlogPosts(ctx *fiber.Ctx) error {
 
 	offset := (pageNumber - 1) * eachPage
 
-	productRecords, totalRecords, productRecordsErr := dbFunc.DBHelper.GetBusinessConnectBlogByLimit(eachPage, offset)
+	productRecords, totalRecords, productRecordsErr := h.Blogs.GetBusinessConnectBlogByLimit(eachPage, offset)
 	if productRecordsErr != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to get product history",
@@ -95,7 +96,7 @@ func GetBlogPosts(ctx *fiber.Ctx) error {
 	})
 }
 
-func UpdateBusinessConnectBlog(ctx *fiber.Ctx) error {
+func (h *Handler) UpdateBusinessConnectBlog(ctx *fiber.Ctx) error {
 
 	// // Get stored user id from request timeline
 	// userId := ctx.Locals("user-id")
@@ -139,7 +140,7 @@ func UpdateBusinessConnectBlog(ctx *fiber.Ctx) error {
 	// UpdatedBlog.ProductStock = BlogUpdate.ProductStock
 
 	// Call the database helper function to retrieve the order
-	err := dbFunc.DBHelper.UpdateBusinessConnectBlog(UpdatedBlog, uint(BlogUpdate.BlogID))
+	err := h.Blogs.UpdateBusinessConnectBlog(UpdatedBlog, uint(BlogUpdate.BlogID))
 	if err != nil {
 		// Handle potential errors based on your GetOrder function implementation
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -151,6 +152,7 @@ func UpdateBusinessConnectBlog(ctx *fiber.Ctx) error {
 			"error": "failed to retrieve blog",
 		})
 	}
+	cache.Invalidate(ctx.UserContext(), cache.TagBlogs)
 
 	// Return successful response with order details
 	return ctx.Status(http.StatusOK).JSON(fiber.Map{
@@ -158,7 +160,7 @@ func UpdateBusinessConnectBlog(ctx *fiber.Ctx) error {
 	})
 }
 
-func DeleteBusinessConnectBlog(ctx *fiber.Ctx) error {
+func (h *Handler) DeleteBusinessConnectBlog(ctx *fiber.Ctx) error {
 
 	// // Get stored user id from request timeline
 	// userId := ctx.Locals("user-id")
@@ -186,7 +188,7 @@ func DeleteBusinessConnectBlog(ctx *fiber.Ctx) error {
 	}
 
 	// Call the database helper function to retrieve the order
-	err2 := dbFunc.DBHelper.DeleteBusinessConnectBlog(uint(blogID))
+	err2 := h.Blogs.DeleteBusinessConnectBlog(uint(blogID))
 	if err2 != nil {
 		// Handle potential errors based on your GetOrder function implementation
 		if errors.Is(err2, gorm.ErrRecordNotFound) {
@@ -198,6 +200,8 @@ func DeleteBusinessConnectBlog(ctx *fiber.Ctx) error {
 			"error": "failed to retrieve blog",
 		})
 	}
+	cache.Invalidate(ctx.UserContext(), cache.TagBlogs)
+	upload.ReleaseBlog(uint(blogID))
 
 	// Return successful response with order details
 	return ctx.Status(http.StatusOK).JSON(fiber.Map{
@@ -205,7 +209,7 @@ func DeleteBusinessConnectBlog(ctx *fiber.Ctx) error {
 	})
 }
 
-func AddBusinessConnectBlogComment(ctx *fiber.Ctx) error {
+func (h *Handler) AddBusinessConnectBlogComment(ctx *fiber.Ctx) error {
 
 	// Parse product details
 	var BlogComment Data.CustomerBlogReview
@@ -218,15 +222,17 @@ func AddBusinessConnectBlogComment(ctx *fiber.Ctx) error {
 	}
 
 	// Save the product details to the database after successful image upload
-	savedProduct, err := dbFunc.DBHelper.SaveCustomerBlogReview(BlogComment.BlogID, BlogComment.Email, BlogComment.Name, BlogComment.Review, BlogComment.Rating)
+	savedProduct, err := h.Blogs.SaveCustomerBlogReview(BlogComment.BlogID, BlogComment.Email, BlogComment.Name, BlogComment.Review, BlogComment.Rating)
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "error occurred while adding comment to database",
 		})
 	}
+	// the blog page shows its comments and their count
+	cache.Invalidate(ctx.UserContext(), cache.TagBlogs)
 
 	if BlogComment.AddEmail {
-		emailErr := dbFunc.DBHelper.AddEmailSubscriber(BlogComment.Email)
+		emailErr := h.Analytics.AddEmailSubscriber(BlogComment.Email)
 		if emailErr != nil {
 			if emailErr.Error() == "user with email already exists" {
 				return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
@@ -239,7 +245,7 @@ func AddBusinessConnectBlogComment(ctx *fiber.Ctx) error {
 		}
 
 		// send a confirmation email
-		emailErr = SendEmail.TodacWelcomeEmail(BlogComment.Email)
+		emailErr = SendEmail.QueueWelcomeEmail(BlogComment.Email)
 		if emailErr != nil {
 			return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 				"error": "failed to send subscriber email",
@@ -252,7 +258,7 @@ func AddBusinessConnectBlogComment(ctx *fiber.Ctx) error {
 	})
 }
 
-func GetBusinessConnectBlogCommentsByLimit(ctx *fiber.Ctx) error {
+func (h *Handler) GetBusinessConnectBlogCommentsByLimit(ctx *fiber.Ctx) error {
 	// this is to get the page number to route to
 	pageNumber := 1
 	productNumber := 1
@@ -269,7 +275,7 @@ func GetBusinessConnectBlogCommentsByLimit(ctx *fiber.Ctx) error {
 
 	offset := (pageNumber - 1) * eachPage
 
-	productCommentRecords, reviewCount, productRecordsErr := dbFunc.DBHelper.GetCustomerBlogReviewsByBlogPost(uint(productNumber), eachPage, offset)
+	productCommentRecords, reviewCount, productRecordsErr := h.Blogs.GetCustomerBlogReviewsByBlogPost(uint(productNumber), eachPage, offset)
 	if productRecordsErr != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to get product history",
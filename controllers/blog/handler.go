@@ -0,0 +1,20 @@
+package blog
+
+import (
+	dbFunc "business-connect/database/dbHelpFunc"
+)
+
+// Handler serves the blog pages and their comments.
+// The router gives it the database, tests a mock of each repository.
+type Handler struct {
+	Blogs     dbFunc.BlogRepo
+	Analytics dbFunc.AnalyticsRepo
+}
+
+// NewHandler is a Handler on every repository of db
+func NewHandler(db dbFunc.DatabaseHelper) *Handler {
+	return &Handler{
+		Blogs:     db,
+		Analytics: db,
+	}
+}
@@ -1,12 +1,9 @@
 package emails
 
 import (
-	email "business-connect/controllers/authentication"
-	dbFunc "business-connect/database/dbHelpFunc"
+	"business-connect/logger"
 	Data "business-connect/models"
 	upload "business-connect/upload"
-	"log"
-	"time"
 
 	// "regexp"
 	"strings"
@@ -15,7 +12,7 @@ import (
 	"github.com/gofiber/fiber/v2"
 )
 
-func SendEmails(ctx *fiber.Ctx) error {
+func (h *Handler) SendEmails(ctx *fiber.Ctx) error {
 	// log.Println("Starting SendEmails function")
 
 	// Get stored user id from request timeline
@@ -23,16 +20,16 @@ func SendEmails(ctx *fiber.Ctx) error {
 	// log.Printf("User ID: %v\n", userId)
 
 	if userId == nil {
-		log.Println("User ID is nil")
+		logger.Ctx(ctx).Warn("user id is nil")
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "failed to get user",
 		})
 	}
 
-	user, uuidErr := dbFunc.DBHelper.FindByUuidFromLocal(userId)
+	user, uuidErr := h.Users.FindByUuidFromLocal(userId)
 	// log.Printf("Retrieved user: %v\n", user)
 	if uuidErr != nil {
-		log.Printf("Error retrieving user: %v\n", uuidErr)
+		logger.Ctx(ctx).Error("error retrieving user", "error", uuidErr)
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": uuidErr.Error(),
 		})
@@ -42,7 +39,7 @@ func SendEmails(ctx *fiber.Ctx) error {
 
 	// Check if there is an error binding the request
 	if bindErr := ctx.BodyParser(&EmailToSend); bindErr != nil {
-		log.Printf("Error binding request body: %v\n", bindErr)
+		logger.Ctx(ctx).Error("error binding request body", "error", bindErr)
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Failed to read body",
 		})
@@ -50,9 +47,14 @@ func SendEmails(ctx *fiber.Ctx) error {
 	// log.Printf("Email to send: %+v\n", EmailToSend)
 
 	// Call the database helper function to retrieve the order
-	emailContent, err := upload.UploadEmailFiles(EmailToSend.Content)
+	emailContent, err := upload.UploadEmailFiles(ctx.UserContext(), EmailToSend.Content)
+	if status, ok := upload.ClientError(err); ok {
+		return ctx.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 	if err != nil {
-		log.Printf("Error uploading email files: %v\n", err)
+		logger.Ctx(ctx).Error("error uploading email files", "error", err)
 		// error uploading email images
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to upload email images",
@@ -69,49 +71,38 @@ func SendEmails(ctx *fiber.Ctx) error {
 	// 	})
 	// }
 
-	// Define a goroutine to send the emails asynchronously
-	go func() {
-		if EmailToSend.SendTo == "me" {
-			// Send to only the current user
-			emailErr := email.SendEmailToSubscribers(EmailToSend.Subject, updatedHTML, user.Email)
-			if emailErr != nil {
-				log.Printf("Failed to send email to user %s: %v\n", user.Email, emailErr)
-			}
-		} else {
-			// Send to all subscribers
-			emailSubscribers, emailErr := dbFunc.DBHelper.GetBusinessConnectEmailSubscribers()
-			if emailErr != nil {
-				log.Printf("Failed to retrieve email subscribers: %v\n", emailErr)
-				return
-			}
-
-			for i, emaill := range emailSubscribers {
-				// Wait 5 minutes between sends, except before the first one
-				if i > 0 {
-					time.Sleep(5 * time.Minute)
-				}
-
-				// Send email (in the same goroutine sequentially)
-				emailSubErr := email.SendEmailToSubscribers(EmailToSend.Subject, updatedHTML, emaill.Email)
-				if emailSubErr != nil {
-					log.Printf("Failed to send email to %s: %v\n", emaill.Email, emailSubErr)
-				}
-			}
-		}
-	}()
-
-	// Save sent emails
+	// Save sent emails, the newsletter records its progress on this row
 	var sentEmail Data.Email
 	sentEmail.Subject = EmailToSend.Subject
 	sentEmail.Content = updatedHTML
 	sentEmail.SendTo = EmailToSend.SendTo
-	saveSentEmailErr := dbFunc.DBHelper.SaveBusinessConnectSentEmail(sentEmail)
+	saveSentEmailErr := h.Analytics.SaveBusinessConnectSentEmail(&sentEmail)
 	if saveSentEmailErr != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to save email copy",
 		})
 	}
 
+	// Queue the emails, a job failing or a restart part way through picks up
+	// where it stopped
+	var queueErr error
+	if sentEmail.SendTo == "me" {
+		// Send to only the current user
+		_, queueErr = newsletterEmailJob.Enqueue(newsletterEmail{EmailID: sentEmail.ID, To: user.Email})
+		if queueErr == nil {
+			queueErr = h.Analytics.UpdateSentEmailProgress(sentEmail.ID, 0, true)
+		}
+	} else {
+		// Send to all subscribers
+		_, queueErr = newsletterJob.Enqueue(newsletter{EmailID: sentEmail.ID})
+	}
+	if queueErr != nil {
+		logger.Ctx(ctx).Error("error queueing email", "email_id", sentEmail.ID, "error", queueErr)
+		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to queue email",
+		})
+	}
+
 	// Immediately return a success response without waiting for the emails to be sent
 	// log.Println("Returning success response")
 	return ctx.Status(fiber.StatusOK).JSON(fiber.Map{
@@ -0,0 +1,20 @@
+package emails
+
+import (
+	dbFunc "business-connect/database/dbHelpFunc"
+)
+
+// Handler serves the admin emails and the newsletter.
+// The router gives it the database, tests a mock of each repository.
+type Handler struct {
+	Users     dbFunc.UserRepo
+	Analytics dbFunc.AnalyticsRepo
+}
+
+// NewHandler is a Handler on every repository of db
+func NewHandler(db dbFunc.DatabaseHelper) *Handler {
+	return &Handler{
+		Users:     db,
+		Analytics: db,
+	}
+}
@@ -0,0 +1,91 @@
+package emails
+
+import (
+	"context"
+	"time"
+
+	email "business-connect/controllers/authentication"
+	dbFunc "business-connect/database/dbHelpFunc"
+	"business-connect/jobs"
+)
+
+const (
+	// gap between two newsletter emails, keeps the SMTP account under its
+	// sending limits
+	newsletterGap = 5 * time.Minute
+	// subscribers queued per newsletter job
+	newsletterBatch = 100
+)
+
+type newsletter struct {
+	EmailID uint `json:"email_id"`
+}
+
+type newsletterEmail struct {
+	EmailID uint   `json:"email_id"`
+	To      string `json:"to"`
+}
+
+var (
+	// newsletterJob queues the next batch of subscribers of a newsletter,
+	// one newsletterEmailJob each newsletterGap apart, then queues itself
+	// for when the batch is through
+	newsletterJob *jobs.Kind[newsletter]
+	// newsletterEmailJob sends a newsletter to one address
+	newsletterEmailJob *jobs.Kind[newsletterEmail]
+)
+
+func init() {
+	newsletterJob = jobs.Define("email.newsletter", jobs.Options{}, queueNewsletterBatch)
+	newsletterEmailJob = jobs.Define("email.newsletter_recipient", jobs.Options{}, sendNewsletterEmail)
+}
+
+// queueNewsletterBatch queues the subscribers after the last one queued,
+// saving progress after each so a retry doesn't queue anyone twice
+func queueNewsletterBatch(ctx context.Context, p newsletter) error {
+	sentEmail, err := dbFunc.DBHelper.GetSentEmail(p.EmailID)
+	if err != nil {
+		if err.Error() == "email not found" {
+			return jobs.Permanent(err)
+		}
+		return err
+	}
+	if sentEmail.CompletedAt != 0 {
+		return nil
+	}
+
+	subscribers, err := dbFunc.DBHelper.GetBusinessConnectEmailSubscribersAfter(sentEmail.LastSubscriberID, newsletterBatch)
+	if err != nil {
+		return err
+	}
+	if len(subscribers) == 0 {
+		// everyone has been queued
+		return dbFunc.DBHelper.UpdateSentEmailProgress(sentEmail.ID, sentEmail.LastSubscriberID, true)
+	}
+
+	for i, subscriber := range subscribers {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if _, err := newsletterEmailJob.Enqueue(newsletterEmail{EmailID: sentEmail.ID, To: subscriber.Email}, jobs.After(time.Duration(i)*newsletterGap)); err != nil {
+			return err
+		}
+		if err := dbFunc.DBHelper.UpdateSentEmailProgress(sentEmail.ID, subscriber.ID, false); err != nil {
+			return err
+		}
+	}
+
+	_, err = newsletterJob.Enqueue(p, jobs.After(time.Duration(len(subscribers))*newsletterGap))
+	return err
+}
+
+func sendNewsletterEmail(ctx context.Context, p newsletterEmail) error {
+	sentEmail, err := dbFunc.DBHelper.GetSentEmail(p.EmailID)
+	if err != nil {
+		if err.Error() == "email not found" {
+			return jobs.Permanent(err)
+		}
+		return err
+	}
+	return email.SendEmailToSubscribers(sentEmail.Subject, sentEmail.Content, p.To)
+}
@@ -0,0 +1,115 @@
+// Package health answers the load balancer and orchestrator probes.
+//
+// /healthz only says the process is up and serving, a failing dependency
+// must not get a healthy process restarted. /readyz runs every registered
+// check (the database, object storage) and answers 503 while any of them
+// fails, so traffic is held back until they are reachable again.
+package health
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"business-connect/logger"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// how long one check may take before it counts as failed
+const checkTimeout = 2 * time.Second
+
+// Check reports whether a dependency is reachable
+type Check func(ctx context.Context) error
+
+var (
+	mu     sync.RWMutex
+	checks = map[string]Check{}
+)
+
+// Register adds a readiness check, registering a name again replaces it
+func Register(name string, check Check) {
+	mu.Lock()
+	checks[name] = check
+	mu.Unlock()
+}
+
+// Cached runs check at most once per ttl and reuses its last result, for
+// checks that cost money or API quota such as authorizing with B2
+func Cached(ttl time.Duration, check Check) Check {
+	var (
+		mu      sync.Mutex
+		checked time.Time
+		last    error
+	)
+	return func(ctx context.Context) error {
+		mu.Lock()
+		defer mu.Unlock()
+
+		if !checked.IsZero() && time.Since(checked) < ttl {
+			return last
+		}
+		last = check(ctx)
+		checked = time.Now()
+		return last
+	}
+}
+
+// Healthz answers 200 as long as the process can serve requests
+func Healthz(ctx *fiber.Ctx) error {
+	return ctx.JSON(fiber.Map{
+		"status": "ok",
+	})
+}
+
+// Readyz runs every check at once and answers 503 with the failing ones
+func Readyz(ctx *fiber.Ctx) error {
+	mu.RLock()
+	current := make(map[string]Check, len(checks))
+	for name, check := range checks {
+		current[name] = check
+	}
+	mu.RUnlock()
+
+	log := logger.Ctx(ctx)
+
+	var (
+		wg        sync.WaitGroup
+		resultsMu sync.Mutex
+		results   = map[string]string{}
+		ready     = true
+	)
+	for name, check := range current {
+		wg.Add(1)
+		go func(name string, check Check) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(context.Background(), checkTimeout)
+			defer cancel()
+			err := check(checkCtx)
+
+			resultsMu.Lock()
+			defer resultsMu.Unlock()
+			if err != nil {
+				// the error can name hosts and buckets, only the logs get it
+				log.Warn("readiness check failed", "check", name, "error", err)
+				results[name] = "unavailable"
+				ready = false
+				return
+			}
+			results[name] = "ok"
+		}(name, check)
+	}
+	wg.Wait()
+
+	status := "ok"
+	code := fiber.StatusOK
+	if !ready {
+		status = "unavailable"
+		code = fiber.StatusServiceUnavailable
+	}
+	return ctx.Status(code).JSON(fiber.Map{
+		"status": status,
+		"checks": results,
+	})
+}
@@ -0,0 +1,18 @@
+package home
+
+import (
+	dbFunc "business-connect/database/dbHelpFunc"
+)
+
+// Handler serves the admin analytics. The router gives it the database,
+// tests a mock of each repository.
+type Handler struct {
+	Analytics dbFunc.AnalyticsRepo
+}
+
+// NewHandler is a Handler on every repository of db
+func NewHandler(db dbFunc.DatabaseHelper) *Handler {
+	return &Handler{
+		Analytics: db,
+	}
+}
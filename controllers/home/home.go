@@ -3,15 +3,13 @@ package home
 import (
 	"net/http"
 
-	dbFunc "business-connect/database/dbHelpFunc"
-
 	"github.com/gofiber/fiber/v2"
 )
 
-func GetBusinessConnectAnalytics(ctx *fiber.Ctx) error {
+func (h *Handler) GetBusinessConnectAnalytics(ctx *fiber.Ctx) error {
 
 	// Call the database helper function to retrieve the order
-	siteVisits, err2 := dbFunc.DBHelper.GetLast12DaysSiteVisits()
+	siteVisits, err2 := h.Analytics.GetLast12DaysSiteVisits()
 	if err2 != nil {
 		// Handle potential errors based on the get site visits analytics function implementation
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
@@ -20,7 +18,7 @@ func GetBusinessConnectAnalytics(ctx *fiber.Ctx) error {
 	}
 
 	// Call the database helper function to retrieve the order
-	userAnalytics, err3 := dbFunc.DBHelper.GetAnalyticsData()
+	userAnalytics, err3 := h.Analytics.GetAnalyticsData()
 	if err3 != nil {
 		// Handle potential errors based on the get site visits analytics function implementation
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
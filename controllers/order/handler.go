@@ -0,0 +1,24 @@
+package order
+
+import (
+	config "business-connect/config"
+	dbFunc "business-connect/database/dbHelpFunc"
+)
+
+// Handler serves orders, shipping fees and product updates.
+// The router gives it the config and the database, tests a mock of each
+// repository.
+type Handler struct {
+	Config *config.Config
+	Posts  dbFunc.PostRepo
+	Orders dbFunc.OrderRepo
+}
+
+// NewHandler is a Handler on cfg and every repository of db
+func NewHandler(cfg *config.Config, db dbFunc.DatabaseHelper) *Handler {
+	return &Handler{
+		Config: cfg,
+		Posts:  db,
+		Orders: db,
+	}
+}
@@ -2,7 +2,7 @@ package order
 
 import (
 	"errors"
-	"fmt"
+	"log/slog"
 	"reflect"
 
 	// "strings"
@@ -13,9 +13,11 @@ import (
 	"strconv"
 
 	// SMS "business-connect/controllers/authentication"
-	dbFunc "business-connect/database/dbHelpFunc"
+	"business-connect/cache"
+	"business-connect/logger"
 	Data "business-connect/models"
 	initTrans "business-connect/paystack/initTransactionForPaystack"
+	upload "business-connect/upload"
 
 	// SendEmail "business-connect/controllers/authentication/emails"
 
@@ -40,7 +42,7 @@ type OrderBody struct {
 	ProductOrderBody []Data.ProductOrderBody `json:"product_order_body"`
 }
 
-func AddOrder(ctx *fiber.Ctx) error {
+func (h *Handler) AddOrder(ctx *fiber.Ctx) error {
 
 	var NewOrder OrderBody
 
@@ -55,11 +57,11 @@ func AddOrder(ctx *fiber.Ctx) error {
 	NewOrder.OrderHistoryBody.OrderStatus = "processing"
 
 	// adding new order history
-	orderResultID, _, _, orderErr := dbFunc.DBHelper.AddOrder(NewOrder.OrderHistoryBody, NewOrder.ProductOrderBody)
+	orderResultID, _, _, orderErr := h.Orders.AddOrder(NewOrder.OrderHistoryBody, NewOrder.ProductOrderBody)
 
 	// checking if there was an error comparing the orders
 	if orderErr != nil {
-		fmt.Println("order error: ", orderErr)
+		logger.Ctx(ctx).Error("error adding order", "error", orderErr)
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to add new order",
 		})
@@ -85,7 +87,7 @@ func AddOrder(ctx *fiber.Ctx) error {
 		})
 	}
 	// perform transaction using paystack
-	initTransURL, airtimeErr := initTrans.InitializePaystackTransaction(NewOrder.OrderHistoryBody.CustomerEmail, transIdStr, int(NewOrder.OrderHistoryBody.OrderCost), metadata)
+	initTransURL, airtimeErr := initTrans.InitializePaystackTransaction(h.Config.Paystack, NewOrder.OrderHistoryBody.CustomerEmail, transIdStr, int(NewOrder.OrderHistoryBody.OrderCost), metadata)
 	if airtimeErr != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "an error occurred",
@@ -98,7 +100,7 @@ func AddOrder(ctx *fiber.Ctx) error {
 	})
 }
 
-func GetOrder(ctx *fiber.Ctx) error {
+func (h *Handler) GetOrder(ctx *fiber.Ctx) error {
 	// Extract order ID from path or query parameter (adjust based on your implementation)
 	orderID, err := strconv.Atoi(ctx.Params("orderID"))
 	if err != nil {
@@ -108,7 +110,7 @@ func GetOrder(ctx *fiber.Ctx) error {
 	}
 
 	// Call the database helper function to retrieve the order
-	orderHistory, err := dbFunc.DBHelper.GetOrder(uint(orderID))
+	orderHistory, err := h.Orders.GetOrder(uint(orderID))
 	if err != nil {
 		// Handle potential errors based on your GetOrder function implementation
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -127,7 +129,7 @@ func GetOrder(ctx *fiber.Ctx) error {
 	})
 }
 
-func GetBusinessConnectOrdersByLimit(ctx *fiber.Ctx) error {
+func (h *Handler) GetBusinessConnectOrdersByLimit(ctx *fiber.Ctx) error {
 
 	// // Get stored user id from request timeline
 	// userId := ctx.Locals("user-id")
@@ -157,7 +159,7 @@ func GetBusinessConnectOrdersByLimit(ctx *fiber.Ctx) error {
 
 	offset := (pageNumber - 1) * eachPage
 
-	productRecords, totalRecords, productRecordsErr := dbFunc.DBHelper.GetBusinessConnectOrdersByLimit(eachPage, offset)
+	productRecords, totalRecords, productRecordsErr := h.Orders.GetBusinessConnectOrdersByLimit(eachPage, offset)
 	if productRecordsErr != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to get product history",
@@ -181,7 +183,7 @@ func GetBusinessConnectOrdersByLimit(ctx *fiber.Ctx) error {
 	})
 }
 
-func GetBusinessConnectOrder(ctx *fiber.Ctx) error {
+func (h *Handler) GetBusinessConnectOrder(ctx *fiber.Ctx) error {
 
 	// // Get stored user id from request timeline
 	// userId := ctx.Locals("user-id")
@@ -209,7 +211,7 @@ func GetBusinessConnectOrder(ctx *fiber.Ctx) error {
 	}
 
 	// Call the database helper function to retrieve the order
-	orderHistory, err := dbFunc.DBHelper.GetOrder(uint(orderID))
+	orderHistory, err := h.Orders.GetOrder(uint(orderID))
 	if err != nil {
 		// Handle potential errors based on your GetOrder function implementation
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -228,7 +230,7 @@ func GetBusinessConnectOrder(ctx *fiber.Ctx) error {
 	})
 }
 
-func UpdateBusinessConnectOrderStatus(ctx *fiber.Ctx) error {
+func (h *Handler) UpdateBusinessConnectOrderStatus(ctx *fiber.Ctx) error {
 
 	// // Get stored user id from request timeline
 	// userId := ctx.Locals("user-id")
@@ -262,7 +264,7 @@ func UpdateBusinessConnectOrderStatus(ctx *fiber.Ctx) error {
 	}
 
 	// Call the database helper function to retrieve the order
-	err := dbFunc.DBHelper.UpdateOrderStatus(uint(StatusUpdate.OrderID), StatusUpdate.Status)
+	err := h.Orders.UpdateOrderStatus(uint(StatusUpdate.OrderID), StatusUpdate.Status)
 	if err != nil {
 		// Handle potential errors based on your GetOrder function implementation
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -281,7 +283,7 @@ func UpdateBusinessConnectOrderStatus(ctx *fiber.Ctx) error {
 	})
 }
 
-func UpdateBusinessConnectProduct(ctx *fiber.Ctx) error {
+func (h *Handler) UpdateBusinessConnectProduct(ctx *fiber.Ctx) error {
 	type ProductToUpdate struct {
 		ProductID          int     `json:"product_id"`
 		ProductTitle       string  `json:"product_title"`
@@ -306,7 +308,7 @@ func UpdateBusinessConnectProduct(ctx *fiber.Ctx) error {
 	}
 
 	// Fetch the existing product first
-	existingProduct, err := dbFunc.DBHelper.GetProductByID(uint(ProductUpdate.ProductID))
+	existingProduct, err := h.Posts.GetProductByID(uint(ProductUpdate.ProductID))
 	if err != nil {
 		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{
 			"error": "Post not found",
@@ -338,7 +340,7 @@ func UpdateBusinessConnectProduct(ctx *fiber.Ctx) error {
 	// }
 
 	// Save update
-	updateErr := dbFunc.DBHelper.UpdateBusinessConnectProduct(UpdatedProduct, uint(ProductUpdate.ProductID))
+	updateErr := h.Posts.UpdateBusinessConnectProduct(UpdatedProduct, uint(ProductUpdate.ProductID))
 	if updateErr != nil {
 		if errors.Is(updateErr, gorm.ErrRecordNotFound) {
 			return ctx.Status(http.StatusNotFound).JSON(fiber.Map{
@@ -349,13 +351,14 @@ func UpdateBusinessConnectProduct(ctx *fiber.Ctx) error {
 			"error": "failed to update product",
 		})
 	}
+	cache.Invalidate(ctx.UserContext(), cache.TagProducts)
 
 	return ctx.Status(http.StatusOK).JSON(fiber.Map{
 		"success": "successfully updated product",
 	})
 }
 
-func DeleteBusinessConnectProduct(ctx *fiber.Ctx) error {
+func (h *Handler) DeleteBusinessConnectProduct(ctx *fiber.Ctx) error {
 
 	// // Get stored user id from request timeline
 	// userId := ctx.Locals("user-id")
@@ -383,7 +386,7 @@ func DeleteBusinessConnectProduct(ctx *fiber.Ctx) error {
 	}
 
 	// Call the database helper function to retrieve the order
-	err2 := dbFunc.DBHelper.DeleteBusinessConnectProduct(uint(productID))
+	err2 := h.Posts.DeleteBusinessConnectProduct(uint(productID))
 	if err2 != nil {
 		// Handle potential errors based on your GetOrder function implementation
 		if errors.Is(err2, gorm.ErrRecordNotFound) {
@@ -395,6 +398,8 @@ func DeleteBusinessConnectProduct(ctx *fiber.Ctx) error {
 			"error": "failed to retrieve product",
 		})
 	}
+	cache.Invalidate(ctx.UserContext(), cache.TagProducts)
+	upload.ReleasePost(uint(productID))
 
 	// Return successful response with order details
 	return ctx.Status(http.StatusOK).JSON(fiber.Map{
@@ -454,7 +459,7 @@ func ConvertToMetadata(responseDataType interface{}) (Data.ServiceMetaData, erro
 				case "EmailID":
 					responseDataBody.EmailID = fieldValue.(string)
 				default:
-					fmt.Println("field name do not exist: ", fieldNamesInterface[i], "this is the field value", fieldValue)
+					slog.Warn("unknown order field", "field", fieldNamesInterface[i])
 				}
 				// break
 			}
@@ -3,13 +3,12 @@ package order
 import (
 	"net/http"
 
-	dbFunc "business-connect/database/dbHelpFunc"
 	Data "business-connect/models"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func SetShippingPricePerKm(ctx *fiber.Ctx) error {
+func (h *Handler) SetShippingPricePerKm(ctx *fiber.Ctx) error {
 
 	var ShippingFee Data.ShippingFees
 
@@ -21,7 +20,7 @@ func SetShippingPricePerKm(ctx *fiber.Ctx) error {
 	}
 
 	// adding new order history
-	shippingErr := dbFunc.DBHelper.UpsertShippingFee(ShippingFee.ShippingFeePerKm,
+	shippingErr := h.Orders.UpsertShippingFee(ShippingFee.ShippingFeePerKm,
 		ShippingFee.ShippingFeeGreater, ShippingFee.ShippingFeeLess, ShippingFee.StoreLatitude, ShippingFee.StoreLongitude,
 		ShippingFee.StoreState, ShippingFee.StoreCity, ShippingFee.StateISO, ShippingFee.CalculateUsingKg)
 
@@ -37,10 +36,10 @@ func SetShippingPricePerKm(ctx *fiber.Ctx) error {
 	})
 }
 
-func GetShippingPricePerKm(ctx *fiber.Ctx) error {
+func (h *Handler) GetShippingPricePerKm(ctx *fiber.Ctx) error {
 
 	// adding new order history
-	shippingFee, shippingErr := dbFunc.DBHelper.GetShippingFee()
+	shippingFee, shippingErr := h.Orders.GetShippingFee()
 
 	// checking if there was an error comparing the orders
 	if shippingErr != nil {
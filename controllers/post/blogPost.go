@@ -1,10 +1,10 @@
 package post
 
 import (
-	"fmt"
 	"mime/multipart"
 
-	dbFunc "business-connect/database/dbHelpFunc"
+	"business-connect/cache"
+	"business-connect/logger"
 	Data "business-connect/models"
 	upload "business-connect/upload"
 
@@ -20,7 +20,7 @@ var (
 	blogFileParseError  error
 )
 
-func BlogPost(ctx *fiber.Ctx) error {
+func (h *Handler) BlogPost(ctx *fiber.Ctx) error {
 
 	// Get stored user id from request timeline
 	userId := ctx.Locals("user-id")
@@ -31,7 +31,7 @@ func BlogPost(ctx *fiber.Ctx) error {
 		})
 	}
 
-	user, uuidErr := dbFunc.DBHelper.FindByUuidFromLocal(userId)
+	user, uuidErr := h.Users.FindByUuidFromLocal(userId)
 
 	if uuidErr != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -42,7 +42,7 @@ func BlogPost(ctx *fiber.Ctx) error {
 	// Parse the form data
 	blogReceivedFiles, blogFileParseError = ctx.MultipartForm()
 	if blogFileParseError != nil {
-		fmt.Println("Error parsing multipart form:", blogFileParseError)
+		logger.Ctx(ctx).Error("error parsing multipart form", "error", blogFileParseError)
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to parse images",
 		})
@@ -71,11 +71,16 @@ func BlogPost(ctx *fiber.Ctx) error {
 		})
 	}
 
-	// Upload the images to Backblaze B2
-	blogImageUploads, blogImageUploadsErr = upload.UploadBlogFiles(files)
+	// Upload the images to storage
+	blogImageUploads, blogImageUploadsErr = upload.UploadBlogFiles(ctx.UserContext(), user.ID, files)
+	if status, ok := upload.ClientError(blogImageUploadsErr); ok {
+		return ctx.Status(status).JSON(fiber.Map{
+			"error": blogImageUploadsErr.Error(),
+		})
+	}
 	if blogImageUploadsErr != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "failed to upload image to Backblaze B2",
+			"error": "failed to upload image to storage",
 		})
 	}
 
@@ -86,16 +91,20 @@ func BlogPost(ctx *fiber.Ctx) error {
 	// }
 
 	// Save the product details to the database after successful image upload
-	savedProduct, err := dbFunc.DBHelper.AddBlog(product, user)
+	savedProduct, err := h.Blogs.AddBlog(product, user)
 	if err != nil {
+		for _, eachImage := range blogImageUploads {
+			upload.Release(eachImage.URL)
+		}
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "error occurred while adding product to database",
 		})
 	}
+	defer cache.Invalidate(ctx.UserContext(), cache.TagBlogs)
 
 	// Add uploaded images to the database
 	for _, eachImage := range blogImageUploads {
-		err := dbFunc.DBHelper.AddBlogImage(eachImage, savedProduct.ID)
+		err := h.Blogs.AddBlogImage(eachImage, savedProduct.ID)
 		if err != nil {
 			return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "error occurred while adding image to database",
@@ -0,0 +1,24 @@
+package post
+
+import (
+	dbFunc "business-connect/database/dbHelpFunc"
+)
+
+// Handler serves the post, blog and profile photo uploads.
+// The router gives it the database, tests a mock of each repository.
+type Handler struct {
+	Users dbFunc.UserRepo
+	Posts dbFunc.PostRepo
+	Blogs dbFunc.BlogRepo
+	Media dbFunc.MediaRepo
+}
+
+// NewHandler is a Handler on every repository of db
+func NewHandler(db dbFunc.DatabaseHelper) *Handler {
+	return &Handler{
+		Users: db,
+		Posts: db,
+		Blogs: db,
+		Media: db,
+	}
+}
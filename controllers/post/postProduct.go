@@ -6,7 +6,9 @@ import (
 	"strconv"
 	"time"
 
-	dbFunc "business-connect/database/dbHelpFunc"
+	"business-connect/cache"
+	"business-connect/geo"
+	"business-connect/logger"
 	Data "business-connect/models"
 	upload "business-connect/upload"
 
@@ -22,14 +24,14 @@ var (
 	fileParseError  error
 )
 
-func CreatePost(c *fiber.Ctx) error {
+func (h *Handler) CreatePost(c *fiber.Ctx) error {
 
 	userID := c.Locals("user-id")
 	if userID == nil {
 		return c.Status(401).JSON(fiber.Map{"error": "unauthorized"})
 	}
 
-	user, err := dbFunc.DBHelper.FindByUuidFromLocal(userID)
+	user, err := h.Users.FindByUuidFromLocal(userID)
 	if err != nil {
 		return c.Status(500).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -60,6 +62,24 @@ func CreatePost(c *fiber.Ctx) error {
 		post.StockAvailability = v
 	}
 
+	if v := c.FormValue("product_price"); v != "" {
+		price, err := strconv.ParseInt(v, 10, 64)
+		if err != nil || price < 0 {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid product price"})
+		}
+		post.ProductPrice = price
+	}
+
+	// where the post is, both or neither, the seller's location otherwise
+	if lat, lng := c.FormValue("latitude"), c.FormValue("longitude"); lat != "" || lng != "" {
+		latitude, latErr := strconv.ParseFloat(lat, 64)
+		longitude, lngErr := strconv.ParseFloat(lng, 64)
+		if latErr != nil || lngErr != nil || !geo.Valid(latitude, longitude) {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid latitude or longitude"})
+		}
+		post.Latitude, post.Longitude = &latitude, &longitude
+	}
+
 	if v := c.FormValue("entry_price"); v != "" {
 		price, _ := strconv.ParseInt(v, 10, 64)
 		post.EntryPrice = &price
@@ -88,24 +108,33 @@ func CreatePost(c *fiber.Ctx) error {
 		post.IsSponsored = true
 	}
 
-	// Save post first
-	savedPost, err := dbFunc.DBHelper.AddProduct(post, user)
-	if err != nil {
-		return c.Status(500).JSON(fiber.Map{"error": "failed to save post"})
-	}
-
-	// Upload images
-	files := form.File["images"]
-	if len(files) > 0 {
-		uploads, err := upload.UploadFiles(files)
+	// Process and store the images and videos before the post, a file that
+	// isn't one is turned away without leaving a post behind
+	var uploads []Data.PostImage
+	if files, videos := form.File["images"], form.File["videos"]; len(files) > 0 || len(videos) > 0 {
+		uploads, err = upload.UploadFiles(c.UserContext(), user.ID, post.PostType, files, videos)
+		if status, ok := upload.ClientError(err); ok {
+			return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+		}
 		if err != nil {
-			fmt.Println("Image upload error:", err)
+			logger.Ctx(c).Error("image upload error", "error", err)
 			return c.Status(500).JSON(fiber.Map{"error": "image upload failed"})
 		}
+	}
 
+	savedPost, err := h.Posts.AddProduct(post, user)
+	if err != nil {
+		// nothing will show the images now
 		for _, img := range uploads {
-			dbFunc.DBHelper.AddProductImage(img, savedPost.ID)
+			upload.Release(img.URL)
 		}
+		return c.Status(500).JSON(fiber.Map{"error": "failed to save post"})
+	}
+	// the post is in the feeds from here on
+	defer cache.Invalidate(c.UserContext(), cache.TagProducts)
+
+	for _, img := range uploads {
+		h.Posts.AddProductImage(img, savedPost.ID)
 	}
 
 	return c.JSON(fiber.Map{
@@ -114,7 +143,7 @@ func CreatePost(c *fiber.Ctx) error {
 	})
 }
 
-func UpdateProfilePhoto(c *fiber.Ctx) error {
+func (h *Handler) UpdateProfilePhoto(c *fiber.Ctx) error {
 	userID := c.Locals("user-id")
 	if userID == nil {
 		return c.Status(401).JSON(fiber.Map{
@@ -122,7 +151,7 @@ func UpdateProfilePhoto(c *fiber.Ctx) error {
 		})
 	}
 
-	user, err := dbFunc.DBHelper.FindByUuidFromLocal(userID)
+	user, err := h.Users.FindByUuidFromLocal(userID)
 	if err != nil {
 		return c.Status(404).JSON(fiber.Map{
 			"error": "user not found",
@@ -141,7 +170,12 @@ func UpdateProfilePhoto(c *fiber.Ctx) error {
 	}
 
 	// Upload file
-	uploads, err := upload.UploadProfileFiles([]*multipart.FileHeader{file})
+	uploads, err := upload.UploadProfileFiles(c.UserContext(), user.ID, []*multipart.FileHeader{file})
+	if status, ok := upload.ClientError(err); ok {
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
 	if err != nil || len(uploads) == 0 {
 		return c.Status(500).JSON(fiber.Map{
 			"error": "profile photo upload failed",
@@ -151,24 +185,26 @@ func UpdateProfilePhoto(c *fiber.Ctx) error {
 	photo := uploads[0]
 
 	// Save image history
-	err = dbFunc.DBHelper.AddProfileImage(
-		user.ID,
-		photo.URL,
-		photo.OriginalFilename,
-	)
+	err = h.Users.AddProfileImage(user.ID, photo)
 	if err != nil {
+		upload.Release(photo.URL)
 		return c.Status(500).JSON(fiber.Map{
 			"error": "failed to save profile image",
 		})
 	}
 
 	// Update current profile photo
-	err = dbFunc.DBHelper.UpdateUserProfilePhoto(user.ID, photo.URL)
+	err = h.Users.UpdateUserProfilePhoto(user.ID, photo.URL)
 	if err != nil {
+		upload.Release(photo.URL)
 		return c.Status(500).JSON(fiber.Map{
 			"error": "failed to update profile photo",
 		})
 	}
+	// the old photo is only history now
+	if user.ProfilePhotoURL != "" {
+		upload.Release(user.ProfilePhotoURL)
+	}
 
 	return c.JSON(fiber.Map{
 		"success": true,
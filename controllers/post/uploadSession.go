@@ -0,0 +1,206 @@
+package post
+
+import (
+	"errors"
+	"fmt"
+
+	"business-connect/cache"
+	"business-connect/logger"
+	upload "business-connect/upload"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// UploadSessionRequest is the body of /upload-sessions, TargetID is the
+// post or blog the image goes on
+type UploadSessionRequest struct {
+	Purpose     string `json:"purpose"`
+	TargetID    uint   `json:"target_id"`
+	Filename    string `json:"filename"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+}
+
+// StartUploadSession hands the client a URL to PUT one image to, straight
+// to storage instead of through the API. The image is only used once the
+// client confirms it with ConfirmUploadSession.
+func (h *Handler) StartUploadSession(c *fiber.Ctx) error {
+	userID := c.Locals("user-id")
+	if userID == nil {
+		return c.Status(401).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	user, err := h.Users.FindByUuidFromLocal(userID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "user not found"})
+	}
+
+	var req UploadSessionRequest
+	if err := c.BodyParser(&req); err != nil {
+		return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
+	}
+	if req.Size <= 0 {
+		return c.Status(400).JSON(fiber.Map{"error": "size of the file is required"})
+	}
+
+	limits, err := h.sessionTarget(user.ID, req.Purpose, req.TargetID, req.ContentType)
+	if err != nil {
+		return uploadError(c, err)
+	}
+
+	session, uploadURL, err := upload.StartSession(c.UserContext(), user.ID, req.Purpose, req.TargetID, limits, req.Filename, req.ContentType, req.Size)
+	if err != nil {
+		return uploadError(c, err)
+	}
+
+	return c.JSON(fiber.Map{
+		"session_id": session.Token,
+		"upload_url": uploadURL,
+		"method":     fiber.MethodPut,
+		"headers":    fiber.Map{fiber.HeaderContentType: session.ContentType},
+		"max_bytes":  session.MaxBytes,
+		"expires_at": session.ExpiresAt,
+	})
+}
+
+// ConfirmUploadSession processes the image the client uploaded and puts it
+// on the post, blog or profile the session was started for
+func (h *Handler) ConfirmUploadSession(c *fiber.Ctx) error {
+	userID := c.Locals("user-id")
+	if userID == nil {
+		return c.Status(401).JSON(fiber.Map{"error": "unauthorized"})
+	}
+	user, err := h.Users.FindByUuidFromLocal(userID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{"error": "user not found"})
+	}
+
+	session, err := h.Media.GetUploadSession(c.Params("sessionID"), user.ID)
+	if err != nil {
+		if err.Error() == "upload session not found" {
+			return c.Status(404).JSON(fiber.Map{"error": err.Error()})
+		}
+		return uploadError(c, err)
+	}
+
+	// the post may have filled up with other uploads since the session began
+	limits, err := h.sessionTarget(user.ID, session.Purpose, session.TargetID, session.ContentType)
+	if err != nil {
+		return uploadError(c, err)
+	}
+
+	image, err := upload.FinishSession(c.UserContext(), session, limits)
+	if err != nil {
+		return uploadError(c, err)
+	}
+
+	var saved interface{}
+	switch session.Purpose {
+	case upload.SessionPost:
+		postImage := image.PostImage()
+		err = h.Posts.AddProductImage(postImage, session.TargetID)
+		saved = postImage
+		defer cache.Invalidate(c.UserContext(), cache.TagProducts)
+	case upload.SessionBlog:
+		blogImage := image.BlogImage()
+		err = h.Blogs.AddBlogImage(blogImage, session.TargetID)
+		saved = blogImage
+		defer cache.Invalidate(c.UserContext(), cache.TagBlogs)
+	case upload.SessionProfile:
+		err = h.Users.AddProfileImage(user.ID, image.ProfileImage())
+		if err == nil {
+			err = h.Users.UpdateUserProfilePhoto(user.ID, image.URL)
+		}
+		if err == nil && user.ProfilePhotoURL != "" {
+			upload.Release(user.ProfilePhotoURL)
+		}
+		saved = image.ProfileImage()
+	case upload.SessionCover:
+		err = h.Users.AddCoverImage(user.ID, image.ProfileImage())
+		if err == nil {
+			err = h.Users.UpdateUserCoverPhoto(user.ID, image.URL)
+		}
+		if err == nil && user.CoverPhotoURL != "" {
+			upload.Release(user.CoverPhotoURL)
+		}
+		saved = image.ProfileImage()
+	}
+	if err != nil {
+		logger.Ctx(c).Error("error saving uploaded image", "session_id", session.ID, "error", err)
+		upload.Discard(c.UserContext(), image)
+		return c.Status(500).JSON(fiber.Map{"error": "failed to save image"})
+	}
+
+	return c.JSON(fiber.Map{
+		"message": "upload confirmed",
+		"image":   saved,
+	})
+}
+
+// sessionTarget checks purpose and that the post or blog is the user's and
+// has room for another image, or video when contentType is one, and
+// returns the limits the upload goes by
+func (h *Handler) sessionTarget(userID uint, purpose string, targetID uint, contentType string) (upload.Limits, error) {
+	switch purpose {
+	case upload.SessionPost:
+		post, err := h.Posts.GetProductByID(targetID)
+		if err != nil {
+			return upload.Limits{}, fiber.NewError(fiber.StatusNotFound, "post not found")
+		}
+		if post.UserID != userID {
+			return upload.Limits{}, fiber.NewError(fiber.StatusForbidden, "you can only add images to your own posts")
+		}
+		limits := upload.PostLimits(post.PostType)
+		count, err := h.Posts.CountProductImages(post.ID)
+		if err != nil {
+			return upload.Limits{}, err
+		}
+		if count >= int64(limits.MaxFiles) {
+			return upload.Limits{}, fmt.Errorf("%w, a %s post takes at most %d", upload.ErrTooManyFiles, post.PostType, limits.MaxFiles)
+		}
+		if upload.IsVideo(contentType) {
+			videos, err := h.Posts.CountProductVideos(post.ID)
+			if err != nil {
+				return upload.Limits{}, err
+			}
+			if videos >= int64(limits.MaxVideos) {
+				return upload.Limits{}, fmt.Errorf("%w, a %s post takes at most %d videos", upload.ErrTooManyFiles, post.PostType, limits.MaxVideos)
+			}
+		}
+		return limits, nil
+
+	case upload.SessionBlog:
+		blog, err := h.Blogs.GetBlogPostById(targetID)
+		if err != nil {
+			return upload.Limits{}, fiber.NewError(fiber.StatusNotFound, "blog not found")
+		}
+		if blog.UserID != userID {
+			return upload.Limits{}, fiber.NewError(fiber.StatusForbidden, "you can only add images to your own blogs")
+		}
+		limits := upload.SessionLimits(purpose)
+		count, err := h.Blogs.CountBlogImages(blog.ID)
+		if err != nil {
+			return upload.Limits{}, err
+		}
+		if count >= int64(limits.MaxFiles) {
+			return upload.Limits{}, fmt.Errorf("%w, a blog takes at most %d", upload.ErrTooManyFiles, limits.MaxFiles)
+		}
+		return limits, nil
+
+	case upload.SessionProfile, upload.SessionCover:
+		return upload.SessionLimits(purpose), nil
+	}
+	return upload.Limits{}, fiber.NewError(fiber.StatusBadRequest, "purpose must be post, blog, profile or cover")
+}
+
+// uploadError answers with the status of a refused upload, or a 500
+func uploadError(c *fiber.Ctx, err error) error {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return c.Status(fiberErr.Code).JSON(fiber.Map{"error": fiberErr.Message})
+	}
+	if status, ok := upload.ClientError(err); ok {
+		return c.Status(status).JSON(fiber.Map{"error": err.Error()})
+	}
+	logger.Ctx(c).Error("upload session error", "error", err)
+	return c.Status(500).JSON(fiber.Map{"error": "upload failed"})
+}
@@ -0,0 +1,372 @@
+package profile
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"business-connect/cache"
+	authentication "business-connect/controllers/authentication"
+	rand "business-connect/controllers/authentication/utils"
+	dbFunc "business-connect/database/dbHelpFunc"
+	"business-connect/logger"
+	Data "business-connect/models"
+	"business-connect/upload"
+)
+
+const (
+	// how long a user has to change their mind after asking for deletion
+	accountDeletionGracePeriod = 30 * 24 * time.Hour
+	// length of the token in the emailed cancel link
+	deletionCancelTokenLength = 40
+	// deletions purged per run of the worker
+	accountDeletionBatchSize = 50
+)
+
+// ExportPersonalData returns everything we hold about the signed in user as a
+// ZIP of JSON files, or as one JSON document with ?format=json
+func (h *Handler) ExportPersonalData(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("user-id")
+	if userId == nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "failed to get user",
+		})
+	}
+
+	user, uuidErr := h.Users.FindByUuidFromLocal(userId)
+	if uuidErr != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "failed to get user from request",
+		})
+	}
+
+	export, err := h.Users.GetUserDataExport(user.ID)
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to export data",
+		})
+	}
+
+	exportedAt := time.Now()
+	filename := fmt.Sprintf("business-connect-data-%d-%s", user.ID, exportedAt.UTC().Format("20060102"))
+
+	var (
+		body        []byte
+		contentType string
+	)
+	if strings.ToLower(ctx.Query("format")) == "json" {
+		body, err = json.MarshalIndent(export, "", "  ")
+		contentType = fiber.MIMEApplicationJSON
+		filename += ".json"
+	} else {
+		body, err = zipDataExport(export, exportedAt)
+		contentType = "application/zip"
+		filename += ".zip"
+	}
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to export data",
+		})
+	}
+
+	if emailErr := authentication.DataExportEmail(user.FullName, user.Email, exportedAt); emailErr != nil {
+		logger.Ctx(ctx).Error("error sending data export email", "error", emailErr)
+	}
+
+	ctx.Set(fiber.HeaderContentType, contentType)
+	ctx.Set(fiber.HeaderContentDisposition, `attachment; filename="`+filename+`"`)
+	ctx.Set(fiber.HeaderCacheControl, "no-store")
+	return ctx.Status(http.StatusOK).Send(body)
+}
+
+// zipDataExport writes one JSON file per section of the export
+func zipDataExport(export dbFunc.UserDataExport, exportedAt time.Time) ([]byte, error) {
+	files := []struct {
+		name string
+		data interface{}
+	}{
+		{"profile.json", export.Profile},
+		{"profile_images.json", export.ProfileImages},
+		{"posts.json", export.Posts},
+		{"connections.json", export.Connections},
+		{"group_memberships.json", export.GroupMemberships},
+		{"orders.json", export.Orders},
+		{"blog_reviews.json", export.BlogReviews},
+		{"linked_accounts.json", export.LinkedAccounts},
+		{"login_activity.json", export.LoginActivity},
+		{"activity.json", export.Activity},
+	}
+
+	var buf bytes.Buffer
+	archive := zip.NewWriter(&buf)
+
+	for _, file := range files {
+		w, err := archive.CreateHeader(&zip.FileHeader{
+			Name:     file.name,
+			Method:   zip.Deflate,
+			Modified: exportedAt,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(file.data); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := archive.Close(); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+// GetAccountDeletionStatus tells the user whether their account is scheduled
+// for deletion and when
+func (h *Handler) GetAccountDeletionStatus(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("user-id")
+	if userId == nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "failed to get user",
+		})
+	}
+
+	user, uuidErr := h.Users.FindByUuidFromLocal(userId)
+	if uuidErr != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "failed to get user from request",
+		})
+	}
+
+	deletion, err := h.Users.GetAccountDeletion(user.ID)
+	if err != nil {
+		if err.Error() == "account deletion not found" {
+			return ctx.Status(http.StatusOK).JSON(fiber.Map{
+				"scheduled": false,
+			})
+		}
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "an error occurred",
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(fiber.Map{
+		"scheduled":     true,
+		"scheduled_for": deletion.ScheduledFor,
+	})
+}
+
+// RequestAccountDeletion schedules the signed in user's account for deletion
+// after the grace period and emails a link to cancel it
+func (h *Handler) RequestAccountDeletion(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("user-id")
+	if userId == nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "failed to get user",
+		})
+	}
+
+	user, uuidErr := h.Users.FindByUuidFromLocal(userId)
+	if uuidErr != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "failed to get user from request",
+		})
+	}
+
+	var body struct {
+		Password string `json:"password"`
+	}
+	if bindErr := ctx.BodyParser(&body); bindErr != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to read body",
+		})
+	}
+
+	if ok, err := h.confirmOwner(ctx, user, body.Password); !ok {
+		return err
+	}
+
+	deletion, err := h.Users.GetAccountDeletion(user.ID)
+	if err == nil {
+		return ctx.Status(http.StatusConflict).JSON(fiber.Map{
+			"error":         "account deletion already scheduled",
+			"scheduled_for": deletion.ScheduledFor,
+		})
+	}
+	if err.Error() != "account deletion not found" {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "an error occurred",
+		})
+	}
+
+	token, err := rand.RandomAlphanumericString(deletionCancelTokenLength)
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "an error occurred",
+		})
+	}
+
+	hashedToken, err := h.Users.CreatePasswordHash(token)
+	if err != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "an error occurred",
+		})
+	}
+
+	scheduledFor := time.Now().Add(accountDeletionGracePeriod)
+	deletion = Data.AccountDeletion{
+		UserID:       user.ID,
+		Email:        user.Email,
+		ScheduledFor: scheduledFor.Unix(),
+		CancelToken:  hashedToken,
+	}
+	if saveErr := h.Users.SaveAccountDeletion(deletion); saveErr != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to schedule account deletion",
+		})
+	}
+
+	if emailErr := authentication.AccountDeletionScheduledEmail(user.FullName, user.Email, token, scheduledFor); emailErr != nil {
+		logger.Ctx(ctx).Error("error sending account deletion email", "error", emailErr)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(fiber.Map{
+		"success":       "account scheduled for deletion",
+		"scheduled_for": deletion.ScheduledFor,
+	})
+}
+
+// CancelAccountDeletion cancels the signed in user's pending deletion
+func (h *Handler) CancelAccountDeletion(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("user-id")
+	if userId == nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "failed to get user",
+		})
+	}
+
+	user, uuidErr := h.Users.FindByUuidFromLocal(userId)
+	if uuidErr != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "failed to get user from request",
+		})
+	}
+
+	return h.cancelAccountDeletion(ctx, user)
+}
+
+// CancelAccountDeletionByLink cancels a pending deletion with the token from
+// the confirmation email, so it works without signing in
+func (h *Handler) CancelAccountDeletionByLink(ctx *fiber.Ctx) error {
+	var body struct {
+		Email string `json:"email"`
+		Token string `json:"token"`
+	}
+
+	if err := ctx.BodyParser(&body); err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to read body",
+		})
+	}
+
+	if body.Email == "" || body.Token == "" {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "email and token are required",
+		})
+	}
+
+	user, err := h.Users.FindByEmail(strings.ToLower(strings.TrimSpace(body.Email)))
+	if err != nil {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid or expired link",
+		})
+	}
+
+	deletion, err := h.Users.GetAccountDeletion(user.ID)
+	if err != nil || deletion.CancelToken == "" {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid or expired link",
+		})
+	}
+
+	if hashErr := h.Users.ComparePasswordHash(deletion.CancelToken, body.Token); hashErr != nil {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid or expired link",
+		})
+	}
+
+	return h.cancelAccountDeletion(ctx, user)
+}
+
+func (h *Handler) cancelAccountDeletion(ctx *fiber.Ctx, user Data.User) error {
+	if err := h.Users.CancelAccountDeletion(user.ID); err != nil {
+		if err.Error() == "account deletion not found" {
+			return ctx.Status(http.StatusNotFound).JSON(fiber.Map{
+				"error": "no account deletion scheduled",
+			})
+		}
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "an error occurred",
+		})
+	}
+
+	if emailErr := authentication.AccountDeletionCancelledEmail(user.FullName, user.Email); emailErr != nil {
+		logger.Ctx(ctx).Error("error sending deletion cancelled email", "error", emailErr)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(fiber.Map{
+		"success": "account deletion cancelled",
+	})
+}
+
+// PurgeDueAccountDeletions deletes every account whose grace period has
+// ended and returns how many were purged
+func (h *Handler) PurgeDueAccountDeletions() int {
+	deletions, err := h.Users.GetDueAccountDeletions(time.Now().Unix(), accountDeletionBatchSize)
+	if err != nil {
+		slog.Error("error getting due account deletions", "error", err)
+		return 0
+	}
+
+	purged := 0
+	for _, deletion := range deletions {
+		user, err := h.Users.FindByUuid(deletion.UserID)
+		if err != nil {
+			if err.Error() == "user not found" {
+				// already gone, only the request is left
+				h.Users.CancelAccountDeletion(deletion.UserID)
+			}
+			continue
+		}
+
+		media, err := h.Users.PurgeUser(user.ID)
+		if err != nil {
+			slog.Error("error purging user", "user_id", user.ID, "error", err)
+			continue
+		}
+		purged++
+		upload.Release(media...)
+
+		if emailErr := authentication.AccountDeletedEmail(user.FullName, user.Email); emailErr != nil {
+			slog.Error("error sending account deleted email", "error", emailErr)
+		}
+	}
+
+	if purged > 0 {
+		// their posts and blog comments are gone from cached pages
+		cache.Invalidate(context.Background(), cache.TagProducts, cache.TagBlogs)
+	}
+
+	return purged
+}
@@ -2,7 +2,6 @@ package profile
 
 import (
 	SendEmail "business-connect/controllers/authentication/emails"
-	dbFunc "business-connect/database/dbHelpFunc"
 	"net/http"
 
 	"github.com/gofiber/fiber/v2"
@@ -12,7 +11,7 @@ type Email struct {
 	Email string
 }
 
-func AddEmailSubscription(ctx *fiber.Ctx) error {
+func (h *Handler) AddEmailSubscription(ctx *fiber.Ctx) error {
 
 	var email Email
 
@@ -23,7 +22,7 @@ func AddEmailSubscription(ctx *fiber.Ctx) error {
 		})
 	}
 
-	emailErr := dbFunc.DBHelper.AddEmailSubscriber(email.Email)
+	emailErr := h.Analytics.AddEmailSubscriber(email.Email)
 	if emailErr != nil {
 		if emailErr.Error() == "user with email already exists" {
 			return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
@@ -36,7 +35,7 @@ func AddEmailSubscription(ctx *fiber.Ctx) error {
 	}
 
 	// send a confirmation email
-	emailErr = SendEmail.TodacWelcomeEmail(email.Email)
+	emailErr = SendEmail.QueueWelcomeEmail(email.Email)
 	if emailErr != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to send order email",
@@ -1,13 +1,12 @@
 package profile
 
 import (
-	dbFunc "business-connect/database/dbHelpFunc"
 	helperFunc "business-connect/paystack"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func GetFriends(ctx *fiber.Ctx) error {
+func (h *Handler) GetFriends(ctx *fiber.Ctx) error {
 	// Get stored user id from request context
 	userId := ctx.Locals("user-id")
 	if userId == nil {
@@ -37,7 +36,7 @@ func GetFriends(ctx *fiber.Ctx) error {
 	offset := (page - 1) * limit
 
 	// Fetch posts using limit+1 for hasMore
-	friends, hasMore, postErr := dbFunc.DBHelper.GetUsersToConnect(user.ID, limit, offset)
+	friends, hasMore, postErr := h.Users.GetUsersToConnect(user.ID, limit, offset)
 	if postErr != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch posts",
@@ -58,7 +57,7 @@ type ConnectRequest struct {
 	UserID uint `json:"user_id"` // the user you want to connect to
 }
 
-func ConnectFriend(ctx *fiber.Ctx) error {
+func (h *Handler) ConnectFriend(ctx *fiber.Ctx) error {
 	// Get current logged in user-id
 	userId := ctx.Locals("user-id")
 	if userId == nil {
@@ -81,7 +80,7 @@ func ConnectFriend(ctx *fiber.Ctx) error {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": "user_id is required"})
 	}
 
-	err := dbFunc.DBHelper.ConnectToUser(user.ID, req.UserID)
+	err := h.Users.ConnectToUser(user.ID, req.UserID)
 	if err != nil {
 		return ctx.Status(fiber.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
@@ -1,13 +1,12 @@
 package profile
 
 import (
-	dbFunc "business-connect/database/dbHelpFunc"
 	helperFunc "business-connect/paystack"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func GetGroups(ctx *fiber.Ctx) error {
+func (h *Handler) GetGroups(ctx *fiber.Ctx) error {
 	// Get stored user id from request context
 	userId := ctx.Locals("user-id")
 	if userId == nil {
@@ -37,7 +36,7 @@ func GetGroups(ctx *fiber.Ctx) error {
 	offset := (page - 1) * limit
 
 	// Fetch posts using limit+1 for hasMore
-	groups, hasMore, postErr := dbFunc.DBHelper.GetAvailableGroups(limit, offset)
+	groups, hasMore, postErr := h.Groups.GetAvailableGroups(limit, offset)
 	if postErr != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch posts",
@@ -58,7 +57,7 @@ type JoinGroupRequest struct {
 	GroupPostID uint `json:"group_post_id"`
 }
 
-func JoinGroupHandler(ctx *fiber.Ctx) error {
+func (h *Handler) JoinGroupHandler(ctx *fiber.Ctx) error {
 	// Get current user from context
 	userId := ctx.Locals("user-id")
 	if userId == nil {
@@ -90,7 +89,7 @@ func JoinGroupHandler(ctx *fiber.Ctx) error {
 	}
 
 	// Call DB helper
-	participant, created, err := dbFunc.DBHelper.JoinGroup(user, req.GroupPostID)
+	participant, created, err := h.Groups.JoinGroup(user, req.GroupPostID)
 	if err != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to join group",
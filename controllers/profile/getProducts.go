@@ -2,14 +2,15 @@ package profile
 
 import (
 	dbFunc "business-connect/database/dbHelpFunc"
-	Data "business-connect/models"
+	"business-connect/logger"
 	helperFunc "business-connect/paystack"
-	"fmt"
 
 	// "fmt"
 	"math"
 	"net/http"
+	"slices"
 	"strconv"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -26,7 +27,7 @@ type PaginationData struct {
 	AllRecords   int64
 }
 
-func GetPostsPaginated(ctx *fiber.Ctx) error {
+func (h *Handler) GetPostsPaginated(ctx *fiber.Ctx) error {
 	// Get stored user id from request context
 	userId := ctx.Locals("user-id")
 	if userId == nil {
@@ -56,7 +57,7 @@ func GetPostsPaginated(ctx *fiber.Ctx) error {
 	offset := (page - 1) * limit
 
 	// Fetch posts using limit+1 for hasMore
-	posts, hasMore, postErr := dbFunc.DBHelper.GetBusinessConnectProductsByLimit(limit, offset)
+	posts, hasMore, postErr := h.Posts.GetBusinessConnectProductsByLimit(limit, offset)
 	if postErr != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch posts",
@@ -73,7 +74,7 @@ func GetPostsPaginated(ctx *fiber.Ctx) error {
 	})
 }
 
-func GetPostsPaginatedOpen(ctx *fiber.Ctx) error {
+func (h *Handler) GetPostsPaginatedOpen(ctx *fiber.Ctx) error {
 	// Get stored user id from request context
 	// userId := ctx.Locals("user-id")
 	// if userId == nil {
@@ -103,7 +104,7 @@ func GetPostsPaginatedOpen(ctx *fiber.Ctx) error {
 	offset := (page - 1) * limit
 
 	// Fetch posts using limit+1 for hasMore
-	posts, hasMore, postErr := dbFunc.DBHelper.GetBusinessConnectProductsByLimitOpen(limit, offset)
+	posts, hasMore, postErr := h.Posts.GetBusinessConnectProductsByLimitOpen(limit, offset)
 	if postErr != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch posts",
@@ -119,7 +120,7 @@ func GetPostsPaginatedOpen(ctx *fiber.Ctx) error {
 	})
 }
 
-func GetStatusPaginated(ctx *fiber.Ctx) error {
+func (h *Handler) GetStatusPaginated(ctx *fiber.Ctx) error {
 	// Get stored user id from request context
 	userId := ctx.Locals("user-id")
 	if userId == nil {
@@ -149,7 +150,7 @@ func GetStatusPaginated(ctx *fiber.Ctx) error {
 	offset := (page - 1) * limit
 
 	// Fetch posts using limit+1 for hasMore
-	posts, hasMore, postErr := dbFunc.DBHelper.GetStatusPostsByLimit(limit, offset)
+	posts, hasMore, postErr := h.Posts.GetStatusPostsByLimit(limit, offset)
 	if postErr != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch posts",
@@ -166,7 +167,7 @@ func GetStatusPaginated(ctx *fiber.Ctx) error {
 	})
 }
 
-func GetStatusPaginatedOpen(ctx *fiber.Ctx) error {
+func (h *Handler) GetStatusPaginatedOpen(ctx *fiber.Ctx) error {
 	// Get stored user id from request context
 	// userId := ctx.Locals("user-id")
 	// if userId == nil {
@@ -196,7 +197,7 @@ func GetStatusPaginatedOpen(ctx *fiber.Ctx) error {
 	offset := (page - 1) * limit
 
 	// Fetch posts using limit+1 for hasMore
-	posts, hasMore, postErr := dbFunc.DBHelper.GetStatusPostsByLimit(limit, offset)
+	posts, hasMore, postErr := h.Posts.GetStatusPostsByLimit(limit, offset)
 	if postErr != nil {
 		return ctx.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to fetch posts",
@@ -213,49 +214,47 @@ func GetStatusPaginatedOpen(ctx *fiber.Ctx) error {
 	})
 }
 
-func GetBusinessConnectProductsByLimit(ctx *fiber.Ctx) error {
-	var totalRecords int64
-	var productRecords []Data.Post
-	var err error
-
-	// Parse query params
-	category := ctx.Query("category", "na")
-	limit := ctx.QueryInt("limit", 12)
-	sortField := ctx.Query("sort", "created_at")
-	sortOrder := ctx.Query("order", "asc")
-	page := ctx.Params("page", "1")
-	pageNumber, _ := strconv.Atoi(page)
-
-	offset := (pageNumber - 1) * limit
-
-	if category != "na" {
-		productRecords, totalRecords, err = dbFunc.DBHelper.GetProductsByCategory(category, limit, offset, sortField, sortOrder)
-	} else {
-		productRecords, totalRecords, err = dbFunc.DBHelper.GetProductsAll(limit, offset, sortField, sortOrder)
-	}
-
+// GetBusinessConnectProductsByLimit is the listing page of /products/:page,
+// the same search, filters and facets as SearchPosts. sort also takes the
+// column names this page was sorted by before, with order asc or desc.
+func (h *Handler) GetBusinessConnectProductsByLimit(ctx *fiber.Ctx) error {
+	params, err := searchParams(ctx, listingSort(ctx.Query("sort", "created_at"), ctx.Query("order", "asc")))
 	if err != nil {
-		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "Failed to fetch products"})
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
 	}
+	// this page's "all categories"
+	params.Categories = slices.DeleteFunc(params.Categories, func(category string) bool {
+		return category == "na"
+	})
 
-	totalPages := int(math.Ceil(float64(totalRecords) / float64(limit)))
+	page, _ := strconv.Atoi(ctx.Params("page", "1"))
+	return h.searchPage(ctx, params, page, ctx.QueryInt("limit", 12))
+}
 
-	return ctx.JSON(fiber.Map{
-		"success": productRecords,
-		"pagination": PaginationData{
-			NextPage:     pageNumber + 1,
-			PreviousPage: pageNumber - 1,
-			CurrentPage:  pageNumber,
-			TotalPages:   totalPages,
-			TwoAfter:     pageNumber + 2,
-			TwoBefore:    pageNumber - 2,
-			ThreeAfter:   pageNumber + 4,
-			AllRecords:   totalRecords,
-		},
-	})
+// listingSort turns the column and direction the products page was sorted
+// by into a search sort, sorts SearchPosts knows are kept as they are and
+// anything else is created_at like before
+func listingSort(sortField, sortOrder string) string {
+	if slices.Contains(dbFunc.SearchSorts, sortField) {
+		return sortField
+	}
+	desc := strings.EqualFold(sortOrder, "desc")
+	switch sortField {
+	case "product_price":
+		if desc {
+			return dbFunc.SortPriceHigh
+		}
+		return dbFunc.SortPriceLow
+	case "views", "clicks":
+		return dbFunc.SortPopular
+	}
+	if desc {
+		return dbFunc.SortNewest
+	}
+	return dbFunc.SortOldest
 }
 
-func GetBusinessConnectAdminProductsByLimit(ctx *fiber.Ctx) error {
+func (h *Handler) GetBusinessConnectAdminProductsByLimit(ctx *fiber.Ctx) error {
 	// this is to get the page number to route to
 	pageNumber := 1
 	limitNumber := ctx.Params("idLimit")
@@ -267,7 +266,7 @@ func GetBusinessConnectAdminProductsByLimit(ctx *fiber.Ctx) error {
 
 	offset := (pageNumber - 1) * eachPage
 
-	productRecords, totalRecords, productRecordsErr := dbFunc.DBHelper.GetBusinessConnectAdminProductsByLimit(eachPage, offset)
+	productRecords, totalRecords, productRecordsErr := h.Posts.GetBusinessConnectAdminProductsByLimit(eachPage, offset)
 	if productRecordsErr != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to get product history",
@@ -291,23 +290,23 @@ func GetBusinessConnectAdminProductsByLimit(ctx *fiber.Ctx) error {
 	})
 }
 
-func GetBusinessConnectProductByID(ctx *fiber.Ctx) error {
+func (h *Handler) GetBusinessConnectProductByID(ctx *fiber.Ctx) error {
 
 	productID := ctx.Params("id")
 	convertedTransactionID, err := strconv.ParseUint(productID, 10, 64)
 	if err != nil {
 		// Handle error
-		fmt.Println(err)
+		logger.Ctx(ctx).Debug("invalid product id", "id", productID, "error", err)
 	}
 
-	productDetail, TransErr := dbFunc.DBHelper.GetBusinessConnectProductByID(convertedTransactionID)
+	productDetail, TransErr := h.Posts.GetBusinessConnectProductByID(convertedTransactionID)
 	if TransErr != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to get product history",
 		})
 	}
 
-	relatedProducts, _, CatErr := dbFunc.DBHelper.GetBusinessConnectRecommendedProductsByLimit(convertedTransactionID, *productDetail.BusinessCategory, 12)
+	relatedProducts, _, CatErr := h.Posts.GetBusinessConnectRecommendedProductsByLimit(convertedTransactionID, *productDetail.BusinessCategory, 12)
 	if CatErr != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to get recommended product",
@@ -320,16 +319,16 @@ func GetBusinessConnectProductByID(ctx *fiber.Ctx) error {
 	})
 }
 
-func GetBusinessConnectAdminProductByID(ctx *fiber.Ctx) error {
+func (h *Handler) GetBusinessConnectAdminProductByID(ctx *fiber.Ctx) error {
 
 	productID := ctx.Params("id")
 	convertedTransactionID, err := strconv.ParseUint(productID, 10, 64)
 	if err != nil {
 		// Handle error
-		fmt.Println(err)
+		logger.Ctx(ctx).Debug("invalid product id", "id", productID, "error", err)
 	}
 
-	productDetail, TransErr := dbFunc.DBHelper.GetBusinessConnectProductByID(convertedTransactionID)
+	productDetail, TransErr := h.Posts.GetBusinessConnectProductByID(convertedTransactionID)
 	if TransErr != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to get product history",
@@ -341,16 +340,16 @@ func GetBusinessConnectAdminProductByID(ctx *fiber.Ctx) error {
 	})
 }
 
-func GetNextProductID(ctx *fiber.Ctx) error {
+func (h *Handler) GetNextProductID(ctx *fiber.Ctx) error {
 
 	productID := ctx.Params("id")
 	convertedTransactionID, err := strconv.ParseUint(productID, 10, 64)
 	if err != nil {
 		// Handle error
-		fmt.Println(err)
+		logger.Ctx(ctx).Debug("invalid product id", "id", productID, "error", err)
 	}
 
-	productDetail, TransErr := dbFunc.DBHelper.GetNextProductID(uint64(convertedTransactionID))
+	productDetail, TransErr := h.Posts.GetNextProductID(uint64(convertedTransactionID))
 	if TransErr != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to get product history",
@@ -362,16 +361,16 @@ func GetNextProductID(ctx *fiber.Ctx) error {
 	})
 }
 
-func GetPreviousProductID(ctx *fiber.Ctx) error {
+func (h *Handler) GetPreviousProductID(ctx *fiber.Ctx) error {
 
 	productID := ctx.Params("id")
 	convertedTransactionID, err := strconv.ParseUint(productID, 10, 64)
 	if err != nil {
 		// Handle error
-		fmt.Println(err)
+		logger.Ctx(ctx).Debug("invalid product id", "id", productID, "error", err)
 	}
 
-	productDetail, TransErr := dbFunc.DBHelper.GetPreviousProductID(uint64(convertedTransactionID))
+	productDetail, TransErr := h.Posts.GetPreviousProductID(uint64(convertedTransactionID))
 	if TransErr != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to get product history",
@@ -383,11 +382,11 @@ func GetPreviousProductID(ctx *fiber.Ctx) error {
 	})
 }
 
-func SearchProductsByTitleAndCategory(ctx *fiber.Ctx) error {
+func (h *Handler) SearchProductsByTitleAndCategory(ctx *fiber.Ctx) error {
 	query := ctx.Query("q")
 	categorySlug := ctx.Query("category") // This will be like "household-essentials"
 
-	productDetail, TransErr := dbFunc.DBHelper.SearchProductsByTitleAndCategory(query, categorySlug)
+	productDetail, TransErr := h.Posts.SearchProductsByTitleAndCategory(query, categorySlug)
 	if TransErr != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to get product search",
@@ -399,7 +398,7 @@ func SearchProductsByTitleAndCategory(ctx *fiber.Ctx) error {
 	})
 }
 
-func SearchAdminProductsByTitle(ctx *fiber.Ctx) error {
+func (h *Handler) SearchAdminProductsByTitle(ctx *fiber.Ctx) error {
 	type RequestBody struct {
 		SearchTerm string `json:"search_term"`
 	}
@@ -411,7 +410,7 @@ func SearchAdminProductsByTitle(ctx *fiber.Ctx) error {
 		})
 	}
 
-	productDetail, TransErr := dbFunc.DBHelper.SearchAdminOrderByTitle(body.SearchTerm)
+	productDetail, TransErr := h.Orders.SearchAdminOrderByTitle(body.SearchTerm)
 	if TransErr != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to get product history",
@@ -423,7 +422,7 @@ func SearchAdminProductsByTitle(ctx *fiber.Ctx) error {
 	})
 }
 
-func SearchAdminOrderByTitle(ctx *fiber.Ctx) error {
+func (h *Handler) SearchAdminOrderByTitle(ctx *fiber.Ctx) error {
 	type RequestBody struct {
 		SearchTerm string `json:"search_term"`
 	}
@@ -435,7 +434,7 @@ func SearchAdminOrderByTitle(ctx *fiber.Ctx) error {
 		})
 	}
 
-	productDetail, TransErr := dbFunc.DBHelper.SearchAdminOrderByTitle(body.SearchTerm)
+	productDetail, TransErr := h.Orders.SearchAdminOrderByTitle(body.SearchTerm)
 	if TransErr != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to get product history",
@@ -447,7 +446,7 @@ func SearchAdminOrderByTitle(ctx *fiber.Ctx) error {
 	})
 }
 
-func GetTransactionHistoryByDate(ctx *fiber.Ctx) error {
+func (h *Handler) GetTransactionHistoryByDate(ctx *fiber.Ctx) error {
 	type RequestBody struct {
 		Date string `json:"date"`
 	}
@@ -475,7 +474,7 @@ func GetTransactionHistoryByDate(ctx *fiber.Ctx) error {
 		})
 	}
 
-	productHistory, productHistoryErr := dbFunc.DBHelper.GetTransactionsByUserAndDateWithLimit(user.ID, body.Date)
+	productHistory, productHistoryErr := h.Posts.GetTransactionsByUserAndDateWithLimit(user.ID, body.Date)
 	if productHistoryErr != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to get product history",
@@ -487,12 +486,12 @@ func GetTransactionHistoryByDate(ctx *fiber.Ctx) error {
 	})
 }
 
-func GetBusinessConnectHomePageProducts(ctx *fiber.Ctx) error {
+func (h *Handler) GetBusinessConnectHomePageProducts(ctx *fiber.Ctx) error {
 
 	eachPage := 8
 
 	// Get all products
-	allProductRecords, allProductRecordsErr := dbFunc.DBHelper.GetBusinessConnectHomeAllProductsByLimit(eachPage)
+	allProductRecords, allProductRecordsErr := h.Posts.GetBusinessConnectHomeAllProductsByLimit(eachPage)
 	if allProductRecordsErr != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to get all products",
@@ -500,7 +499,7 @@ func GetBusinessConnectHomePageProducts(ctx *fiber.Ctx) error {
 	}
 
 	// Get featured product
-	featuredProductRecords, featuredProductRecordsErr := dbFunc.DBHelper.GetBusinessConnectHomeFeaturedProductsByLimit(eachPage)
+	featuredProductRecords, featuredProductRecordsErr := h.Posts.GetBusinessConnectHomeFeaturedProductsByLimit(eachPage)
 	if featuredProductRecordsErr != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to get featured products",
@@ -508,7 +507,7 @@ func GetBusinessConnectHomePageProducts(ctx *fiber.Ctx) error {
 	}
 
 	// Get best seller (most sold products)
-	bestSellingProductRecords, bestSellingProductRecordsErr := dbFunc.DBHelper.GetBusinessConnectHomeBestSellingProductsByLimit(eachPage)
+	bestSellingProductRecords, bestSellingProductRecordsErr := h.Posts.GetBusinessConnectHomeBestSellingProductsByLimit(eachPage)
 	if bestSellingProductRecordsErr != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to get best selling products",
@@ -516,7 +515,7 @@ func GetBusinessConnectHomePageProducts(ctx *fiber.Ctx) error {
 	}
 
 	// Get sales (products on promo)
-	OnSaleProductRecords, OnSaleProductRecordsErr := dbFunc.DBHelper.GetBusinessConnectHomeOnSaleProductsByLimit(eachPage)
+	OnSaleProductRecords, OnSaleProductRecordsErr := h.Posts.GetBusinessConnectHomeOnSaleProductsByLimit(eachPage)
 	if OnSaleProductRecordsErr != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to get on sale products",
@@ -0,0 +1,30 @@
+package profile
+
+import (
+	dbFunc "business-connect/database/dbHelpFunc"
+)
+
+// Handler serves profiles, listings, search and account data.
+// The router gives it the database, tests a mock of each repository.
+type Handler struct {
+	Users     dbFunc.UserRepo
+	Posts     dbFunc.PostRepo
+	Groups    dbFunc.GroupRepo
+	Orders    dbFunc.OrderRepo
+	Analytics dbFunc.AnalyticsRepo
+	Search    dbFunc.SearchRepo
+	Geo       dbFunc.GeoRepo
+}
+
+// NewHandler is a Handler on every repository of db
+func NewHandler(db dbFunc.DatabaseHelper) *Handler {
+	return &Handler{
+		Users:     db,
+		Posts:     db,
+		Groups:    db,
+		Orders:    db,
+		Analytics: db,
+		Search:    db,
+		Geo:       db,
+	}
+}
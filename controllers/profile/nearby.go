@@ -0,0 +1,128 @@
+package profile
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"strconv"
+
+	dbFunc "business-connect/database/dbHelpFunc"
+	"business-connect/geo"
+	"business-connect/logger"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// the radius of "near me" when none is asked for, and the widest there is
+const (
+	defaultRadiusKm = 25
+	maxRadiusKm     = 500
+)
+
+// Nearby is what is around the user, nearest first, each with its
+// distance_km.
+//
+//	lat, lng    where the user is, required
+//	radius_km   how far to look, 25 by default and at most 500
+//	type        posts, the default, or businesses
+//	post_type   business, event, group... comma separated, posts only
+func (h *Handler) Nearby(ctx *fiber.Ctx) error {
+	page := ctx.QueryInt("page", 1)
+	limit := ctx.QueryInt("limit", 12)
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 12
+	}
+	page = lastPage(page, limit, dbFunc.MaxNearbyResults)
+
+	origin, err := queryPoint(ctx)
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	if origin == nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "lat and lng are required"})
+	}
+	radius, err := queryRadius(ctx, defaultRadiusKm)
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+
+	params := dbFunc.NearbyParams{
+		Origin:    *origin,
+		RadiusKm:  radius,
+		PostTypes: queryList(ctx, "post_type"),
+		Limit:     limit,
+		Offset:    (page - 1) * limit,
+	}
+
+	var found interface{}
+	var total int64
+	var truncated bool
+	switch ctx.Query("type", "posts") {
+	case "posts":
+		found, total, truncated, err = h.Geo.GetNearbyPosts(params)
+	case "businesses":
+		found, total, truncated, err = h.Geo.GetNearbyBusinesses(params)
+	default:
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": "type must be posts or businesses"})
+	}
+	if err != nil {
+		logger.Ctx(ctx).Error("error finding what is nearby", "error", err)
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to find what is nearby",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success":   found,
+		"radius_km": radius,
+		// only the nearest were counted, a smaller radius finds them all
+		"truncated": truncated,
+		"pagination": PaginationData{
+			NextPage:     page + 1,
+			PreviousPage: page - 1,
+			CurrentPage:  page,
+			TotalPages:   int(math.Ceil(float64(total) / float64(limit))),
+			TwoAfter:     page + 2,
+			TwoBefore:    page - 2,
+			ThreeAfter:   page + 3,
+			Offset:       (page - 1) * limit,
+			AllRecords:   total,
+		},
+	})
+}
+
+// lastPage caps page at the one after the last there can be when no more
+// than most rows are found, so a huge page can't overflow the offset
+func lastPage(page, limit, most int) int {
+	return min(page, most/limit+1)
+}
+
+// queryPoint reads lat and lng, nil when neither is given
+func queryPoint(ctx *fiber.Ctx) (*geo.Point, error) {
+	rawLat, rawLng := ctx.Query("lat"), ctx.Query("lng")
+	if rawLat == "" && rawLng == "" {
+		return nil, nil
+	}
+	lat, latErr := strconv.ParseFloat(rawLat, 64)
+	lng, lngErr := strconv.ParseFloat(rawLng, 64)
+	if latErr != nil || lngErr != nil || !geo.Valid(lat, lng) {
+		return nil, errors.New("lat must be between -90 and 90 and lng between -180 and 180")
+	}
+	return &geo.Point{Lat: lat, Lng: lng}, nil
+}
+
+// queryRadius reads radius_km, fallback when it isn't given
+func queryRadius(ctx *fiber.Ctx, fallback float64) (float64, error) {
+	raw := ctx.Query("radius_km")
+	if raw == "" {
+		return fallback, nil
+	}
+	radius, err := strconv.ParseFloat(raw, 64)
+	if err != nil || !(radius > 0 && radius <= maxRadiusKm) {
+		return 0, errors.New("radius_km must be more than 0 and at most " + strconv.Itoa(maxRadiusKm))
+	}
+	return radius, nil
+}
@@ -0,0 +1,133 @@
+package profile
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	dbFunc "business-connect/database/dbHelpFunc"
+	"business-connect/database/dbHelpFunc/mocks"
+	Data "business-connect/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// nearbyApp serves h.Nearby on /nearby
+func nearbyApp(h *Handler) *fiber.App {
+	app := fiber.New()
+	app.Get("/nearby", h.Nearby)
+	return app
+}
+
+func TestNearbyPassesTheQueryToTheRepository(t *testing.T) {
+	var got dbFunc.NearbyParams
+	h := &Handler{Geo: &mocks.GeoRepoMock{
+		GetNearbyPostsFunc: func(params dbFunc.NearbyParams) ([]Data.Post, int64, bool, error) {
+			got = params
+			return []Data.Post{{Title: "close by"}}, 13, false, nil
+		},
+	}}
+
+	res, err := nearbyApp(h).Test(httptest.NewRequest(http.MethodGet, "/nearby?lat=6.5&lng=3.4&radius_km=10&page=2&limit=5&post_type=event,group", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("status %d, want 200", res.StatusCode)
+	}
+	if got.Origin.Lat != 6.5 || got.Origin.Lng != 3.4 || got.RadiusKm != 10 {
+		t.Errorf("origin %v radius %v, want 6.5,3.4 within 10", got.Origin, got.RadiusKm)
+	}
+	if got.Limit != 5 || got.Offset != 5 {
+		t.Errorf("limit %d offset %d, want 5 and 5", got.Limit, got.Offset)
+	}
+	if len(got.PostTypes) != 2 {
+		t.Errorf("post types %v, want event and group", got.PostTypes)
+	}
+
+	var body struct {
+		Pagination PaginationData `json:"pagination"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body.Pagination.TotalPages != 3 || body.Pagination.AllRecords != 13 {
+		t.Errorf("pagination %+v, want 3 pages of 13", body.Pagination)
+	}
+}
+
+func TestNearbyCapsThePage(t *testing.T) {
+	var got dbFunc.NearbyParams
+	h := &Handler{Geo: &mocks.GeoRepoMock{
+		GetNearbyPostsFunc: func(params dbFunc.NearbyParams) ([]Data.Post, int64, bool, error) {
+			got = params
+			return []Data.Post{}, dbFunc.MaxNearbyResults, true, nil
+		},
+	}}
+
+	res, err := nearbyApp(h).Test(httptest.NewRequest(http.MethodGet, "/nearby?lat=6.5&lng=3.4&limit=50&page=9223372036854775807", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("status %d, want 200", res.StatusCode)
+	}
+	if got.Offset != dbFunc.MaxNearbyResults {
+		t.Errorf("offset %d, want the end of the results, %d", got.Offset, dbFunc.MaxNearbyResults)
+	}
+
+	var body struct {
+		Truncated bool `json:"truncated"`
+	}
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if !body.Truncated {
+		t.Error("truncated results aren't reported")
+	}
+}
+
+func TestNearbyRejectsABadQuery(t *testing.T) {
+	// the repository is never reached, the mock panics if it is
+	h := &Handler{Geo: &mocks.GeoRepoMock{}}
+	for _, query := range []string{
+		"",
+		"?lat=6.5",
+		"?lat=91&lng=3.4",
+		"?lat=6.5&lng=3.4&radius_km=501",
+		"?lat=6.5&lng=3.4&type=groups",
+	} {
+		res, err := nearbyApp(h).Test(httptest.NewRequest(http.MethodGet, "/nearby"+query, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != http.StatusBadRequest {
+			t.Errorf("/nearby%s: status %d, want 400", query, res.StatusCode)
+		}
+	}
+}
+
+func TestNearbyHidesRepositoryErrors(t *testing.T) {
+	h := &Handler{Geo: &mocks.GeoRepoMock{
+		GetNearbyBusinessesFunc: func(dbFunc.NearbyParams) ([]Data.NearbyBusiness, int64, bool, error) {
+			return nil, 0, false, errors.New("error finding nearby businesses: connection refused")
+		},
+	}}
+
+	res, err := nearbyApp(h).Test(httptest.NewRequest(http.MethodGet, "/nearby?lat=6.5&lng=3.4&type=businesses", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusInternalServerError {
+		t.Fatalf("status %d, want 500", res.StatusCode)
+	}
+	var body fiber.Map
+	if err := json.NewDecoder(res.Body).Decode(&body); err != nil {
+		t.Fatal(err)
+	}
+	if body["error"] != "failed to find what is nearby" {
+		t.Errorf("error %q, want the generic message", body["error"])
+	}
+}
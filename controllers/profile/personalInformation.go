@@ -0,0 +1,448 @@
+package profile
+
+import (
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	authentication "business-connect/controllers/authentication"
+	"business-connect/logger"
+	Data "business-connect/models"
+	upload "business-connect/upload"
+)
+
+const (
+	// an email change OTP is valid for this long after it is sent
+	emailChangeOTPValidTime = 60 * time.Minute
+	// a sign in again at Google or Apple confirms a change for this long
+	reauthValidTime = 5 * time.Minute
+)
+
+var (
+	emailFormat = regexp.MustCompile(`^[a-zA-Z0-9._%+\-]+@[a-zA-Z0-9.\-]+\.[a-zA-Z]{2,}$`)
+	phoneFormat = regexp.MustCompile(`^\+?[0-9]{7,14}$`)
+)
+
+// PersonalInformation is the body of /profile/update. Every field is optional,
+// only the ones that are sent are changed.
+type PersonalInformation struct {
+	FullName       *string  `json:"full_name"`
+	BusinessName   *string  `json:"business_name"`
+	BioDescription *string  `json:"bio_description"`
+	PhoneNumber    *string  `json:"phone_number"`
+	Address        *string  `json:"address"`
+	State          *string  `json:"state"`
+	Country        *string  `json:"country"`
+	Language       *string  `json:"language"`
+	Longitude      *float64 `json:"longitude"`
+	Latitude       *float64 `json:"latitude"`
+}
+
+// RetrievePersonalInformation returns the signed in user's profile
+func (h *Handler) RetrievePersonalInformation(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("user-id")
+	if userId == nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "failed to get user",
+		})
+	}
+
+	user, uuidErr := h.Users.FindByUuidFromLocal(userId)
+	if uuidErr != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "failed to get user from request",
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(fiber.Map{
+		"success": "profile retrieved",
+		"user":    user,
+	})
+}
+
+// UpdatePersonalInformation updates the editable profile fields. Email and
+// password have their own routes because they need extra checks.
+func (h *Handler) UpdatePersonalInformation(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("user-id")
+	if userId == nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "failed to get user",
+		})
+	}
+
+	user, uuidErr := h.Users.FindByUuidFromLocal(userId)
+	if uuidErr != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "failed to get user from request",
+		})
+	}
+
+	var info PersonalInformation
+	if bindErr := ctx.BodyParser(&info); bindErr != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to read body",
+		})
+	}
+
+	if validationErr := applyPersonalInformation(&user, info); validationErr != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": validationErr.Error(),
+		})
+	}
+
+	if dbErr := h.Users.UpdateUser(user); dbErr != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "error updating profile",
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(fiber.Map{
+		"success": "profile updated",
+		"user":    user,
+	})
+}
+
+// applyPersonalInformation validates info and copies the fields that were
+// sent onto user. The limits match the column sizes on Data.User.
+func applyPersonalInformation(user *Data.User, info PersonalInformation) error {
+	if info.FullName != nil {
+		value := strings.TrimSpace(*info.FullName)
+		if value == "" {
+			return errors.New("full name is required")
+		}
+		if len(value) > 100 {
+			return errors.New("full name must be at most 100 characters")
+		}
+		user.FullName = value
+	}
+
+	if info.BusinessName != nil {
+		value := strings.TrimSpace(*info.BusinessName)
+		if value == "" {
+			return errors.New("business name is required")
+		}
+		if len(value) > 100 {
+			return errors.New("business name must be at most 100 characters")
+		}
+		user.BusinessName = value
+	}
+
+	if info.BioDescription != nil {
+		value := strings.TrimSpace(*info.BioDescription)
+		if len(value) > 100 {
+			return errors.New("bio must be at most 100 characters")
+		}
+		user.BioDescription = value
+	}
+
+	if info.PhoneNumber != nil {
+		value := strings.TrimSpace(*info.PhoneNumber)
+		if value != "" && !phoneFormat.MatchString(value) {
+			return errors.New("invalid phone number")
+		}
+		user.PhoneNumber = value
+	}
+
+	if info.Address != nil {
+		value := strings.TrimSpace(*info.Address)
+		if len(value) > 255 {
+			return errors.New("address must be at most 255 characters")
+		}
+		user.Address = value
+	}
+
+	if info.State != nil {
+		user.State = strings.TrimSpace(*info.State)
+	}
+
+	if info.Country != nil {
+		user.Country = strings.TrimSpace(*info.Country)
+	}
+
+	if info.Language != nil {
+		user.Language = strings.TrimSpace(*info.Language)
+	}
+
+	// a location is only useful with both halves
+	if (info.Longitude == nil) != (info.Latitude == nil) {
+		return errors.New("longitude and latitude must be sent together")
+	}
+	if info.Longitude != nil {
+		if *info.Longitude < -180 || *info.Longitude > 180 {
+			return errors.New("invalid longitude")
+		}
+		if *info.Latitude < -90 || *info.Latitude > 90 {
+			return errors.New("invalid latitude")
+		}
+		user.Longitude = *info.Longitude
+		user.Latitude = *info.Latitude
+	}
+
+	return nil
+}
+
+// RequestEmailChange starts an email change. The new address gets an OTP and
+// the account keeps the old email until VerifyEmailChange succeeds.
+func (h *Handler) RequestEmailChange(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("user-id")
+	if userId == nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "failed to get user",
+		})
+	}
+
+	user, uuidErr := h.Users.FindByUuidFromLocal(userId)
+	if uuidErr != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "failed to get user from request",
+		})
+	}
+
+	var body struct {
+		NewEmail string `json:"new_email"`
+		Password string `json:"password"`
+	}
+	if bindErr := ctx.BodyParser(&body); bindErr != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to read body",
+		})
+	}
+
+	newEmail := strings.ToLower(strings.TrimSpace(body.NewEmail))
+	if !emailFormat.MatchString(newEmail) {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "invalid email format",
+		})
+	}
+
+	if strings.EqualFold(newEmail, user.Email) {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "this is already your email",
+		})
+	}
+
+	// a stolen session alone can't take over the account
+	if ok, err := h.confirmOwner(ctx, user, body.Password); !ok {
+		return err
+	}
+
+	if checkErr := h.Users.CheckByUserByEmail(newEmail, user.Email); checkErr != nil {
+		if checkErr.Error() == "email already in use" {
+			return ctx.Status(http.StatusConflict).JSON(fiber.Map{
+				"error": "email already in use",
+			})
+		}
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to check user existence",
+		})
+	}
+
+	user.PendingEmail = newEmail
+	if dbErr := h.Users.UpdateUser(user); dbErr != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "error updating profile",
+		})
+	}
+
+	if emailErr := authentication.EmailChangeVerification(h.Users, user.FullName, newEmail); emailErr != nil {
+		logger.Ctx(ctx).Error("error sending email change otp", "error", emailErr)
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "email verification failed",
+		})
+	}
+
+	return ctx.Status(http.StatusOK).JSON(fiber.Map{
+		"success": "verification code sent to " + newEmail,
+	})
+}
+
+// confirmOwner checks the current password, or when none is given that the
+// user just signed in with their provider again (?reauth=true): accounts made
+// through Google or Apple have a password nobody knows. A failed check has
+// already been answered, the handler returns the error as is.
+func (h *Handler) confirmOwner(ctx *fiber.Ctx, user Data.User, password string) (bool, error) {
+	if password != "" {
+		if hashErr := h.Users.ComparePasswordHash(user.Password, password); hashErr != nil {
+			return false, ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Invalid password",
+			})
+		}
+		return true, nil
+	}
+
+	reauthenticated, reauthErr := h.Users.ConsumeReauthentication(user.ID, time.Now().Add(-reauthValidTime).Unix())
+	if reauthErr != nil {
+		return false, ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "an error occurred",
+		})
+	}
+	if !reauthenticated {
+		return false, ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{
+			"error": "enter your password or sign in with your provider again",
+		})
+	}
+	return true, nil
+}
+
+// VerifyEmailChange checks the OTP sent to the pending email and makes it the
+// account email
+func (h *Handler) VerifyEmailChange(ctx *fiber.Ctx) error {
+	userId := ctx.Locals("user-id")
+	if userId == nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "failed to get user",
+		})
+	}
+
+	user, uuidErr := h.Users.FindByUuidFromLocal(userId)
+	if uuidErr != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "failed to get user from request",
+		})
+	}
+
+	var body struct {
+		OTP string `json:"otp"`
+	}
+	if bindErr := ctx.BodyParser(&body); bindErr != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "Failed to read body",
+		})
+	}
+
+	if user.PendingEmail == "" {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": "no email change in progress",
+		})
+	}
+
+	OTPBody, hashOTPErr := h.Users.GetAndCheckOTPByEmail(user.PendingEmail, body.OTP)
+	if OTPBody != (Data.OTP{}) && OTPBody.MaxTry >= 5 {
+		return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "email limit check exceeded"})
+	}
+
+	if hashOTPErr != nil {
+		switch hashOTPErr.Error() {
+		case "otp not found":
+			return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "OTP not found"})
+		case "incorrect otp value":
+			otpMaxTryBody, err := h.Users.GetOTPByEmail(user.PendingEmail)
+			if err == nil && otpMaxTryBody.MaxTry >= 5 {
+				return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "email limit check exceeded"})
+			}
+			if updateMaxErr := h.Users.UpdateMaxTry(user.PendingEmail); updateMaxErr != nil {
+				return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "an error occurred"})
+			}
+			return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "Wrong OTP"})
+		default:
+			return ctx.Status(http.StatusUnauthorized).JSON(fiber.Map{"error": "an error occurred"})
+		}
+	}
+
+	expiryTime := time.Unix(OTPBody.CreatedAT, 0).Add(emailChangeOTPValidTime)
+	if time.Now().After(expiryTime) {
+		return ctx.Status(http.StatusRequestTimeout).JSON(fiber.Map{"error": "Verification Code Expired"})
+	}
+
+	// someone may have signed up with the address since the code was sent
+	if checkErr := h.Users.CheckByUserByEmail(user.PendingEmail, user.Email); checkErr != nil {
+		return ctx.Status(http.StatusConflict).JSON(fiber.Map{
+			"error": "email already in use",
+		})
+	}
+
+	oldEmail := user.Email
+	user.Email = user.PendingEmail
+	user.PendingEmail = ""
+	user.EmailVerified = true
+
+	if dbErr := h.Users.UpdateUser(user); dbErr != nil {
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "error updating email",
+		})
+	}
+
+	if delOtpErr := h.Users.DeleteExistingOTPByID(OTPBody.CustomID); delOtpErr != nil {
+		logger.Ctx(ctx).Error("error deleting email change otp", "error", delOtpErr)
+	}
+
+	if noticeErr := authentication.EmailChangedNotice(user.FullName, oldEmail, user.Email); noticeErr != nil {
+		logger.Ctx(ctx).Error("error sending email changed notice", "error", noticeErr)
+	}
+
+	return ctx.Status(http.StatusOK).JSON(fiber.Map{
+		"success": "email updated",
+		"email":   user.Email,
+	})
+}
+
+// UpdateCoverPhoto uploads a new cover photo, it works like
+// post.UpdateProfilePhoto
+func (h *Handler) UpdateCoverPhoto(c *fiber.Ctx) error {
+	userID := c.Locals("user-id")
+	if userID == nil {
+		return c.Status(401).JSON(fiber.Map{
+			"error": "unauthorized",
+		})
+	}
+
+	user, err := h.Users.FindByUuidFromLocal(userID)
+	if err != nil {
+		return c.Status(404).JSON(fiber.Map{
+			"error": "user not found",
+		})
+	}
+
+	file, err := c.FormFile("cover_photo")
+	if err != nil {
+		return c.Status(400).JSON(fiber.Map{
+			"error": "cover_photo is required",
+		})
+	}
+
+	uploads, err := upload.UploadProfileFiles(c.UserContext(), user.ID, []*multipart.FileHeader{file})
+	if status, ok := upload.ClientError(err); ok {
+		return c.Status(status).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	if err != nil || len(uploads) == 0 {
+		return c.Status(500).JSON(fiber.Map{
+			"error": "cover photo upload failed",
+		})
+	}
+
+	photo := uploads[0]
+
+	// Save image history
+	err = h.Users.AddCoverImage(user.ID, photo)
+	if err != nil {
+		upload.Release(photo.URL)
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to save cover image",
+		})
+	}
+
+	// Update current cover photo
+	err = h.Users.UpdateUserCoverPhoto(user.ID, photo.URL)
+	if err != nil {
+		upload.Release(photo.URL)
+		return c.Status(500).JSON(fiber.Map{
+			"error": "failed to update cover photo",
+		})
+	}
+	if user.CoverPhotoURL != "" {
+		upload.Release(user.CoverPhotoURL)
+	}
+
+	return c.JSON(fiber.Map{
+		"success":         true,
+		"message":         "cover photo updated",
+		"cover_photo_url": photo.URL,
+	})
+}
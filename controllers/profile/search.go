@@ -0,0 +1,160 @@
+package profile
+
+import (
+	"errors"
+	"math"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+
+	dbFunc "business-connect/database/dbHelpFunc"
+	"business-connect/logger"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// most values one list filter takes
+const maxFilterValues = 20
+
+// the furthest a listing sorted in the database pages, well past any real
+// listing and far from overflowing the offset
+const maxListingResults = 1 << 30
+
+// SearchPosts is the search and listing page: published posts matching q,
+// or all of them without one, narrowed down by the filters and counted by
+// every facet.
+//
+//	q           what to search for, relevance is the default sort with it
+//	post_type   business, event, group... comma separated
+//	category    business categories, comma separated
+//	location    states, comma separated
+//	min_price   lowest product_price
+//	max_price   highest product_price
+//	verified    true for verified sellers only
+//	in_stock    true to leave out posts marked out of stock
+//	sort        relevance, newest, oldest, price_asc, price_desc, popular
+//	            or distance
+//	lat, lng    where the user is, for distance_km and the distance sort
+//	radius_km   only posts this close to lat, lng
+func (h *Handler) SearchPosts(ctx *fiber.Ctx) error {
+	params, err := searchParams(ctx, ctx.Query("sort"))
+	if err != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{"error": err.Error()})
+	}
+	return h.searchPage(ctx, params, ctx.QueryInt("page", 1), ctx.QueryInt("limit", 12))
+}
+
+// searchParams reads the filters SearchPosts documents, sorted by sort
+func searchParams(ctx *fiber.Ctx, sort string) (dbFunc.SearchParams, error) {
+	params := dbFunc.SearchParams{
+		Text:         ctx.Query("q"),
+		PostTypes:    queryList(ctx, "post_type"),
+		Categories:   queryList(ctx, "category"),
+		Locations:    queryList(ctx, "location"),
+		VerifiedOnly: ctx.QueryBool("verified"),
+		InStock:      ctx.QueryBool("in_stock"),
+		Sort:         sort,
+		WithFacets:   true,
+	}
+	// the search box's "all categories"
+	params.Categories = slices.DeleteFunc(params.Categories, func(category string) bool {
+		return category == "all-categories"
+	})
+
+	if params.Sort != "" && !slices.Contains(dbFunc.SearchSorts, params.Sort) {
+		return params, errors.New("sort must be one of " + strings.Join(dbFunc.SearchSorts, ", "))
+	}
+	var err error
+	if params.Near, err = queryPoint(ctx); err != nil {
+		return params, err
+	}
+	if params.Sort == dbFunc.SortDistance && params.Near == nil {
+		return params, errors.New("sort by distance needs lat and lng")
+	}
+	if params.Near != nil {
+		if params.RadiusKm, err = queryRadius(ctx, 0); err != nil {
+			return params, err
+		}
+	}
+	if params.MinPrice, err = queryPrice(ctx, "min_price"); err != nil {
+		return params, err
+	}
+	if params.MaxPrice, err = queryPrice(ctx, "max_price"); err != nil {
+		return params, err
+	}
+	if params.MinPrice != nil && params.MaxPrice != nil && *params.MinPrice > *params.MaxPrice {
+		return params, errors.New("min_price is more than max_price")
+	}
+	return params, nil
+}
+
+// searchPage answers with page of params, limit posts a page, and its facets
+func (h *Handler) searchPage(ctx *fiber.Ctx, params dbFunc.SearchParams, page, limit int) error {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 50 {
+		limit = 12
+	}
+	// a search ranks at most so many, the rest don't have a page
+	switch {
+	case params.Sort == dbFunc.SortDistance:
+		page = lastPage(page, limit, dbFunc.MaxNearbyResults)
+	case strings.TrimSpace(params.Text) != "":
+		page = lastPage(page, limit, dbFunc.MaxSearchResults)
+	default:
+		page = lastPage(page, limit, maxListingResults)
+	}
+	params.Limit = limit
+	params.Offset = (page - 1) * limit
+
+	found, err := h.Search.SearchPosts(params)
+	if err != nil {
+		logger.Ctx(ctx).Error("error searching posts", "error", err)
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "failed to search posts",
+		})
+	}
+
+	return ctx.JSON(fiber.Map{
+		"success":   found.Posts,
+		"facets":    found.Facets,
+		"truncated": found.Truncated,
+		"pagination": PaginationData{
+			NextPage:     page + 1,
+			PreviousPage: page - 1,
+			CurrentPage:  page,
+			TotalPages:   int(math.Ceil(float64(found.Total) / float64(limit))),
+			TwoAfter:     page + 2,
+			TwoBefore:    page - 2,
+			ThreeAfter:   page + 3,
+			Offset:       params.Offset,
+			AllRecords:   found.Total,
+		},
+	})
+}
+
+// queryList reads a comma separated query parameter
+func queryList(ctx *fiber.Ctx, key string) []string {
+	var values []string
+	for _, value := range strings.Split(ctx.Query(key), ",") {
+		if value = strings.TrimSpace(value); value != "" && len(values) < maxFilterValues {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// queryPrice reads a price filter, nil when it isn't given
+func queryPrice(ctx *fiber.Ctx, key string) (*int64, error) {
+	raw := ctx.Query(key)
+	if raw == "" {
+		return nil, nil
+	}
+	price, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || price < 0 {
+		return nil, errors.New(key + " must be a whole number of at least 0")
+	}
+	return &price, nil
+}
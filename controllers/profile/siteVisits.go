@@ -1,18 +1,18 @@
 package profile
 
 import (
-	dbFunc "business-connect/database/dbHelpFunc"
-	"fmt"
 	"net/http"
 
+	"business-connect/logger"
+	mid "business-connect/middleware"
 	Data "business-connect/models"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func AddSiteVisit(ctx *fiber.Ctx) error {
+func (h *Handler) AddSiteVisit(ctx *fiber.Ctx) error {
 
-	siteVisitErr := dbFunc.DBHelper.UpdateSiteVisits()
+	siteVisitErr := h.Analytics.UpdateSiteVisits()
 	if siteVisitErr != nil {
 		if siteVisitErr.Error() == "failed to update site visit" {
 			return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "site analytics error"})
@@ -22,11 +22,11 @@ func AddSiteVisit(ctx *fiber.Ctx) error {
 	return ctx.Status(http.StatusOK).JSON(fiber.Map{"": ""})
 }
 
-func GetBusinessConnectUserByFingerprint(ctx *fiber.Ctx) error {
+func (h *Handler) GetBusinessConnectUserByFingerprint(ctx *fiber.Ctx) error {
 
 	fingerprintHash := ctx.Params("fingerprint")
 
-	fingerprintHashErr := dbFunc.DBHelper.CreateBusinessConnectDeviceFingerprint(fingerprintHash)
+	fingerprintHashErr := h.Analytics.CreateBusinessConnectDeviceFingerprint(fingerprintHash)
 	if fingerprintHashErr != nil {
 		return ctx.Status(http.StatusNotFound).JSON(fiber.Map{
 			"error": fingerprintHashErr,
@@ -38,7 +38,7 @@ func GetBusinessConnectUserByFingerprint(ctx *fiber.Ctx) error {
 	})
 }
 
-func AddClickHistory(ctx *fiber.Ctx) error {
+func (h *Handler) AddClickHistory(ctx *fiber.Ctx) error {
 
 	var NewClick Data.BusinessConnectUserActivity
 
@@ -49,12 +49,13 @@ func AddClickHistory(ctx *fiber.Ctx) error {
 		})
 	}
 
-	// adding user click history
-	clickErr := dbFunc.DBHelper.LogUserClickData(NewClick.FingerprintHash, NewClick.ProductID, NewClick.ActivityType, NewClick.Category, NewClick.TitleOrSearchQuery)
+	// adding user click history, with the user when they are signed in
+	userID, _ := mid.SignedInUser(ctx)
+	clickErr := h.Analytics.LogUserClickData(NewClick.FingerprintHash, userID, NewClick.ProductID, NewClick.ActivityType, NewClick.Category, NewClick.TitleOrSearchQuery)
 
 	// checking if there was an error comparing the orders
 	if clickErr != nil {
-		fmt.Println("click error: ", clickErr)
+		logger.Ctx(ctx).Error("error logging click", "error", clickErr)
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to add new click history",
 		})
@@ -1,20 +1,26 @@
 package profile
 
 import (
+	"errors"
 	"net/http"
+	"regexp"
 
-	dbFunc "business-connect/database/dbHelpFunc"
 	helperFunc "business-connect/paystack"
 
 	"github.com/gofiber/fiber/v2"
 )
 
+var (
+	passwordUpper = regexp.MustCompile(`[A-Z]`)
+	passwordDigit = regexp.MustCompile(`[0-9]`)
+)
+
 type Password struct {
 	OldPassword string
 	NewPassword string
 }
 
-func UpdatePassword(ctx *fiber.Ctx) error {
+func (h *Handler) UpdatePassword(ctx *fiber.Ctx) error {
 	// get stored user id from request time line
 	userId := ctx.Locals("user-id")
 
@@ -41,7 +47,7 @@ func UpdatePassword(ctx *fiber.Ctx) error {
 	}
 
 	// comparing existing password with user old password in form of hash
-	hashErr := dbFunc.DBHelper.ComparePasswordHash(user.Password, password.OldPassword)
+	hashErr := h.Users.ComparePasswordHash(user.Password, password.OldPassword)
 
 	// checking if there was an error comparing the hashes
 	if hashErr != nil {
@@ -58,9 +64,18 @@ func UpdatePassword(ctx *fiber.Ctx) error {
 			})
 		}
 		// Handle other bcrypt-related errors
+		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+			"error": "error checking password",
+		})
+	}
+
+	if passwordErr := validateNewPassword(password.NewPassword); passwordErr != nil {
+		return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+			"error": passwordErr.Error(),
+		})
 	}
 
-	NewPassword, err := dbFunc.DBHelper.CreatePasswordHash(password.NewPassword)
+	NewPassword, err := h.Users.CreatePasswordHash(password.NewPassword)
 	if err != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
 			"error": "error creating new password",
@@ -71,7 +86,7 @@ func UpdatePassword(ctx *fiber.Ctx) error {
 	user.Password = string(NewPassword)
 
 	// update user's profile in the database
-	dbAddErr := dbFunc.DBHelper.UpdateUser(user)
+	dbAddErr := h.Users.UpdateUser(user)
 	if dbAddErr != nil {
 		return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{"error": "error updating user's password"})
 	}
@@ -79,3 +94,20 @@ func UpdatePassword(ctx *fiber.Ctx) error {
 	return ctx.Status(http.StatusOK).JSON(fiber.Map{"success": "successfully updated user's password"})
 
 }
+
+// validateNewPassword applies the same rules as sign up
+func validateNewPassword(password string) error {
+	if len(password) < 8 {
+		return errors.New("password must be at least 8 characters long")
+	}
+
+	if !passwordUpper.MatchString(password) {
+		return errors.New("password must contain at least one uppercase letter")
+	}
+
+	if !passwordDigit.MatchString(password) {
+		return errors.New("password must contain at least one number")
+	}
+
+	return nil
+}
@@ -2,161 +2,70 @@ package database
 
 import (
 	"fmt"
-	"log"
-	"os"
-	"time"
+	"log/slog"
 
-	"github.com/joho/godotenv"
 	"gorm.io/driver/mysql"
 
 	// "gorm.io/driver/postgres"
 	"gorm.io/gorm"
 
-	Data "business-connect/models"
+	config "business-connect/config"
+	migrations "business-connect/database/migrations"
 )
 
-var DB *gorm.DB
-
-func init() {
-	if os.Getenv("RENDER") == "" {
-		// Local development only
-		if err := godotenv.Load(".env"); err != nil {
-			log.Printf("Failed to load .env file: %v\n", err)
-		}
-	}
-
-
-	dsn := os.Getenv("DATABASE_URL")
-
-	var err error
-
-	DB, err = gorm.Open(mysql.Open(dsn), &gorm.Config{
-		PrepareStmt: true,
-	})
+// Connect opens the MySQL connection pool described by cfg and checks the
+// schema is at the latest migration. It is called once at startup, after the
+// config is loaded, and the handle is passed to the helpers from there. The
+// schema is never changed here, see `business-connect migrate`.
+func Connect(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	db, err := Open(cfg)
 	if err != nil {
-		fmt.Println(err)
-		panic("failed to connect to the database")
+		return nil, err
 	}
 
-	DbMigration()
-
-	// Get the underlying sql.DB object
-	sqlDB, err := DB.DB()
-
-	// Set the maximum number of idle connections.
-	sqlDB.SetMaxIdleConns(30)
-
-	// Set the maximum number of open connections.
-	sqlDB.SetMaxOpenConns(200)
-
-	// SetConnMaxLifetime sets the maximum amount of time a connection may be reused.
-	sqlDB.SetConnMaxLifetime(time.Hour)
-
+	sqlDB, err := db.DB()
 	if err != nil {
-		// Handle the error
-		panic("failed to create multiple connections")
+		return nil, fmt.Errorf("failed to create multiple connections: %w", err)
 	}
 
-	fmt.Println("Successfully connected to the data base")
-}
-
-func DbMigration() {
-	// AutoMigrate the user model
-	err := DB.AutoMigrate(&Data.User{})
+	migrator, err := migrations.New(sqlDB)
 	if err != nil {
-		panic("failed to migrate the User's database")
+		sqlDB.Close()
+		return nil, err
 	}
-
-	// AutoMigrate the otp model
-	otpErr := DB.AutoMigrate(&Data.OTP{})
-	if otpErr != nil {
-		panic("failed to migrate the otp database")
+	if err := migrator.Check(); err != nil {
+		sqlDB.Close()
+		return nil, err
 	}
 
-	// AutoMigrate the transaction history model
-	// err = DB.AutoMigrate(&Data.Post{})
-	// if err != nil {
-	// 	panic("failed to migrate the Post database")
-	// }
-
-	// // AutoMigrate the jti model
-	// err = DB.AutoMigrate(&Data.OrderHistory{})
-	// if err != nil {
-	// 	panic("failed to migrate the OrderHistory to the database")
-	// }
-
-	// err = DB.AutoMigrate(&Data.UserProfile{})
-	// if err != nil {
-	// 	panic("failed to migrate the UserProfile to the database")
-	// }
-
-	err = DB.AutoMigrate(&Data.Post{})
-	if err != nil {
-		panic("failed to migrate the Post database")
-	}
-
-	err = DB.AutoMigrate(&Data.PostImage{})
-	if err != nil {
-		panic("failed to migrate the PostImage database")
-	}
-
-	err = DB.AutoMigrate(&Data.ProfileImage{})
-	if err != nil {
-		panic("failed to migrate the ProfileImage database")
-	}
+	slog.Info("connected to the database")
+	return db, nil
+}
 
-	err = DB.AutoMigrate(&Data.GroupParticipant{})
+// Open opens the connection pool without checking the schema, the migrate
+// command uses it to bring the schema up to date
+func Open(cfg config.DatabaseConfig) (*gorm.DB, error) {
+	db, err := gorm.Open(mysql.Open(cfg.URL), &gorm.Config{
+		PrepareStmt: true,
+	})
 	if err != nil {
-		panic("failed to migrate the GroupParticipant database")
+		return nil, fmt.Errorf("failed to connect to the database: %w", err)
 	}
 
-	err = DB.AutoMigrate(&Data.Connection{})
+	// Get the underlying sql.DB object
+	sqlDB, err := db.DB()
 	if err != nil {
-		panic("failed to migrate the Connection database")
+		return nil, fmt.Errorf("failed to create multiple connections: %w", err)
 	}
 
-	// err = DB.AutoMigrate(&Data.SubscribeToEmail{})
-	// if err != nil {
-	// 	panic("failed to migrate the SubscribeToEmail database")
-	// }
-
-	// err = DB.AutoMigrate(&Data.SiteVisit{})
-	// if err != nil {
-	// 	panic("failed to migrate the SiteVisit database")
-	// }
-
-	// err = DB.AutoMigrate(&Data.BusinessConnectEmailSubscriber{})
-	// if err != nil {
-	// 	panic("failed to migrate the BusinessConnectEmailSubscriber database")
-	// }
-
-	// err = DB.AutoMigrate(&Data.Email{})
-	// if err != nil {
-	// 	panic("failed to migrate the Email database")
-	// }
-
-	// err = DB.AutoMigrate(&Data.ShippingFees{})
-	// if err != nil {
-	// 	panic("failed to migrate the ShippingFees database")
-	// }
-
-	// err = DB.AutoMigrate(&Data.Analytics{})
-	// if err != nil {
-	// 	panic("failed to migrate the Analytics database")
-	// }
+	// Set the maximum number of idle connections.
+	sqlDB.SetMaxIdleConns(cfg.MaxIdleConns)
 
-	err = DB.AutoMigrate(&Data.JTI{})
-	if err != nil {
-		panic("failed to migrate the JTI database")
-	}
+	// Set the maximum number of open connections.
+	sqlDB.SetMaxOpenConns(cfg.MaxOpenConns)
 
-	// err = DB.AutoMigrate(&Data.BusinessConnectDeviceFingerprint{})
-	// if err != nil {
-	// 	panic("failed to migrate the BusinessConnectDeviceFingerprint database")
-	// }
+	// SetConnMaxLifetime sets the maximum amount of time a connection may be reused.
+	sqlDB.SetConnMaxLifetime(cfg.ConnMaxLifetime)
 
-	// err = DB.AutoMigrate(&Data.BusinessConnectUserActivity{})
-	// if err != nil {
-	// 	panic("failed to migrate the BusinessConnectUserActivity database")
-	// }
+	return db, nil
 }
@@ -0,0 +1,847 @@
+package dbHelpFunc
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	Data "business-connect/models"
+)
+
+// AnalyticsRepo covers site visits, the monthly analytics, email
+// subscribers, device fingerprints and the recommendations built from them
+type AnalyticsRepo interface {
+	AddEmailSubscriber(Email string) error
+	UpdateSiteVisits() error
+	GetLast12DaysSiteVisits() (map[string]int64, error)
+	GetAnalyticsData() (*Data.Analytics, error)
+	GetBusinessConnectEmailSubscribers() ([]Data.BusinessConnectEmailSubscriber, error)
+	GetBusinessConnectEmailSubscribersAfter(afterID uint, limit int) ([]Data.BusinessConnectEmailSubscriber, error)
+	SaveBusinessConnectSentEmail(sentEmail *Data.Email) error
+	UpdateSentEmailProgress(emailID, lastSubscriberID uint, completed bool) error
+	GetSentEmail(emailID uint) (Data.Email, error)
+	GetBusinessConnectUniqueUserFingerPrintHash(fingerprintHash string) (Data.BusinessConnectDeviceFingerprint, error)
+	CreateBusinessConnectDeviceFingerprint(fingerprintHash string) error
+	RecommendProductsForUser(fingerprintHash string, limit, offset int) ([]Data.Post, error)
+	LogUserClickData(fingerprintHash string, userID, productID uint, ActivityType, Category, TitleOrSearchQuery string) error
+}
+
+// Helper function to get ordinal suffix
+func getOrdinal(n int) string {
+	if n%10 == 1 && n%100 != 11 {
+		return "st"
+	}
+	if n%10 == 2 && n%100 != 12 {
+		return "nd"
+	}
+	if n%10 == 3 && n%100 != 13 {
+		return "rd"
+	}
+	return "th"
+}
+
+func (d *DatabaseHelperImpl) AddEmailSubscriber(email string) error {
+	var user Data.SubscribeToEmail
+	result1 := d.db.First(&user, "email = ?", email)
+
+	if result1.Error != nil {
+		if errors.Is(result1.Error, gorm.ErrRecordNotFound) {
+			// The record with the specified email was not found
+			// Create a new email subscriber
+			var emailSubscriber Data.SubscribeToEmail
+			emailSubscriber.Email = email
+			result := d.db.Create(&emailSubscriber)
+
+			// Checking if an error occurred when creating the email subscriber
+			if result.Error != nil {
+				return errors.New("failed to create email subscriber")
+			}
+			return nil // Return nil to indicate success
+		} else {
+			// Some other error occurred
+			return errors.New("error retrieving user")
+		}
+	}
+
+	// User already exists, return specific error message
+	return errors.New("user with email already exists")
+}
+
+func (d *DatabaseHelperImpl) UpdateSiteVisits() error {
+
+	// Get today's date
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	// Check if there's an existing record for today
+	var visit Data.SiteVisit
+	result := d.db.Where("DATE(created_at) = ?", today.Format("2006-01-02")).First(&visit)
+	// if result != nil {
+	if result.Error != nil {
+		if result.Error == gorm.ErrRecordNotFound {
+			// If no record found for today, create a new record
+			visit = Data.SiteVisit{
+				SiteVisitNumber: 1,
+			}
+			d.db.Create(&visit)
+		} else {
+			return errors.New("failed to update site visit")
+		}
+	} else {
+		// If record found, update the visit number
+		visit.SiteVisitNumber++
+		d.db.Save(&visit)
+	}
+
+	return nil
+}
+
+func (d *DatabaseHelperImpl) GetLast12DaysSiteVisits() (map[string]int64, error) {
+	// Get today's date
+	today := time.Now().UTC().Truncate(24 * time.Hour)
+
+	// Map to hold site visits for each of the last 12 days
+	visitCounts := make(map[string]int64)
+
+	// Iterate over the last 12 days
+	for i := 0; i < 12; i++ {
+		day := today.AddDate(0, 0, -i).Format("2006-01-02")
+		dayName := today.AddDate(0, 0, -i).Weekday().String() // Get day of the week
+		ordinal := getOrdinal(i + 1)                          // Get ordinal suffix
+		dayLabel := fmt.Sprintf("%d%s %s", i+1, ordinal, dayName)
+
+		var visit Data.SiteVisit
+		result := d.db.Where("DATE(created_at) = ?", day).First(&visit)
+		if result.Error != nil {
+			if result.Error == gorm.ErrRecordNotFound {
+				// No visits for this day, continue to the next day
+				visitCounts[dayLabel] = 0
+			} else {
+				return nil, fmt.Errorf("error retrieving site visits for %s: %v", day, result.Error)
+			}
+		} else {
+			// Convert uint to int64 for consistency
+			visitCounts[dayLabel] = int64(visit.SiteVisitNumber)
+		}
+	}
+
+	return visitCounts, nil
+}
+
+func (d *DatabaseHelperImpl) CalculateMonthlyAnalytics() (*Data.Analytics, error) {
+	// Current month and year
+	currentMonth := time.Now().Month()
+	currentYear := time.Now().Year()
+	monthStart, monthEnd := monthRange(currentYear, currentMonth)
+
+	var analytics Data.Analytics
+	var previousAnalytics Data.Analytics
+	var currentAnalytics Data.Analytics
+	var orderHistories []Data.OrderHistory
+	var topProducts []struct {
+		ProducttID uint
+		Sales      int64
+	}
+
+	// Retrieve current month's order histories
+	if err := d.db.
+		Where("created_at >= ? AND created_at < ?", monthStart, monthEnd).
+		Preload("ProductOrders"). // Preload the ProductOrders relation
+		Find(&orderHistories).Error; err != nil {
+		return nil, fmt.Errorf("error retrieving order histories with product orders: %v", err)
+	}
+
+	// Check if analytics for the current month already exist
+	if err := d.db.Where("month >= ? AND month < ?", monthStart, monthEnd).
+		First(&currentAnalytics).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("error retrieving current month's analytics: %v", err)
+	}
+
+	// Initialize totals
+	totalRevenue := float64(0)
+	totalSales := int64(0)
+	totalCustomers := int64(0)
+	totalProducts := int64(0)
+	dailyVisitors := int64(0) // Depends on visitor tracking mechanism
+
+	customerEmails := make(map[string]struct{})
+	productSales := make(map[uint]int64)
+
+	// Calculate totals
+	for _, order := range orderHistories {
+		totalRevenue += order.OrderCost
+		totalSales += order.Quantity
+
+		// Track unique customers
+		if _, exists := customerEmails[order.CustomerEmail]; !exists {
+			customerEmails[order.CustomerEmail] = struct{}{}
+			totalCustomers++
+		}
+
+		// Track product sales
+		for _, productOrder := range order.ProductOrders {
+			productSales[productOrder.ProducttID] += productOrder.Quantity
+			totalProducts += productOrder.Quantity // Add to total products sold
+		}
+	}
+
+	// Get top two products by sales
+	for productID, sales := range productSales {
+		topProducts = append(topProducts, struct {
+			ProducttID uint
+			Sales      int64
+		}{ProducttID: productID, Sales: sales})
+	}
+
+	sort.Slice(topProducts, func(i, j int) bool {
+		return topProducts[i].Sales > topProducts[j].Sales
+	})
+
+	topProduct1ID := uint(0)
+	topProduct2ID := uint(0)
+	if len(topProducts) > 0 {
+		topProduct1ID = topProducts[0].ProducttID
+	}
+	if len(topProducts) > 1 {
+		topProduct2ID = topProducts[1].ProducttID
+	}
+
+	// Calculate previous month and year
+	previousMonth := currentMonth - 1
+	previousYear := currentYear
+	if previousMonth == 0 {
+		previousMonth = 12
+		previousYear--
+	}
+
+	// Retrieve previous month's analytics
+	previousStart, previousEnd := monthRange(previousYear, previousMonth)
+	if err := d.db.Where("month >= ? AND month < ?", previousStart, previousEnd).First(&previousAnalytics).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("error retrieving previous month's analytics: %v", err)
+	}
+
+	// Calculate percentage changes
+	revenueChange, salesChange, customerChange, productChange := float64(0), float64(0), float64(0), float64(0)
+	isRevenueBetter, isSalesBetter, isCustomersBetter, isProductsBetter := false, false, false, false
+
+	if previousAnalytics.ID != 0 { // If previous analytics exist
+		revenueChange = ((totalRevenue - previousAnalytics.TotalRevenue) / previousAnalytics.TotalRevenue) * 100
+		salesChange = float64(totalSales-previousAnalytics.TotalSales) / float64(previousAnalytics.TotalSales) * 100
+		customerChange = float64(totalCustomers-previousAnalytics.TotalCustomers) / float64(previousAnalytics.TotalCustomers) * 100
+		productChange = float64(totalProducts-previousAnalytics.TotalProducts) / float64(previousAnalytics.TotalProducts) * 100
+
+		// Compare each metric to determine if this month is better
+		isRevenueBetter = totalRevenue > previousAnalytics.TotalRevenue
+		isSalesBetter = totalSales > previousAnalytics.TotalSales
+		isCustomersBetter = totalCustomers > previousAnalytics.TotalCustomers
+		isProductsBetter = totalProducts > previousAnalytics.TotalProducts
+	}
+
+	// If current analytics already exist, update them
+	if currentAnalytics.ID != 0 {
+		currentAnalytics.TotalRevenue = totalRevenue
+		currentAnalytics.RevenueChange = revenueChange
+		currentAnalytics.TotalSales = totalSales
+		currentAnalytics.SalesChange = salesChange
+		currentAnalytics.TotalCustomers = totalCustomers
+		currentAnalytics.CustomerChange = customerChange
+		currentAnalytics.TotalProducts = totalProducts
+		currentAnalytics.ProductChange = productChange
+		currentAnalytics.DailyVisitors = dailyVisitors
+		currentAnalytics.TopProduct1ID = topProduct1ID
+		currentAnalytics.TopProduct2ID = topProduct2ID
+		currentAnalytics.IsRevenueBetter = isRevenueBetter
+		currentAnalytics.IsSalesBetter = isSalesBetter
+		currentAnalytics.IsCustomersBetter = isCustomersBetter
+		currentAnalytics.IsProductsBetter = isProductsBetter
+
+		// Update existing analytics
+		if err := d.db.Save(&currentAnalytics).Error; err != nil {
+			return nil, fmt.Errorf("error updating analytics: %v", err)
+		}
+		return &currentAnalytics, nil
+	}
+
+	// Create new Analytics entry if not found
+	newMonth := time.Date(currentYear, currentMonth, 1, 0, 0, 0, 0, time.UTC)
+	analytics = Data.Analytics{
+		Month:             newMonth,
+		TotalRevenue:      totalRevenue,
+		RevenueChange:     revenueChange,
+		TotalSales:        totalSales,
+		SalesChange:       salesChange,
+		TotalCustomers:    totalCustomers,
+		CustomerChange:    customerChange,
+		TotalProducts:     totalProducts,
+		ProductChange:     productChange,
+		DailyVisitors:     dailyVisitors,
+		TopProduct1ID:     topProduct1ID,
+		TopProduct2ID:     topProduct2ID,
+		IsRevenueBetter:   isRevenueBetter,
+		IsSalesBetter:     isSalesBetter,
+		IsCustomersBetter: isCustomersBetter,
+		IsProductsBetter:  isProductsBetter,
+	}
+
+	// Save new analytics
+	if err := d.db.Save(&analytics).Error; err != nil {
+		return nil, fmt.Errorf("error saving analytics: %v", err)
+	}
+
+	return &analytics, nil
+}
+
+// monthRange is the first instant of a month and of the one after, a range
+// on the column rather than EXTRACT on it so any database can use its index
+func monthRange(year int, month time.Month) (time.Time, time.Time) {
+	start := time.Date(year, month, 1, 0, 0, 0, 0, time.Local)
+	return start, start.AddDate(0, 1, 0)
+}
+
+func (d *DatabaseHelperImpl) GetAnalyticsData() (*Data.Analytics, error) {
+	var analytics Data.Analytics
+	monthStart, monthEnd := monthRange(time.Now().Year(), time.Now().Month())
+
+	// Retrieve current month's analytics data
+	if err := d.db.Where("month >= ? AND month < ?", monthStart, monthEnd).First(&analytics).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// No data found for the current month
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error retrieving analytics data: %v", err)
+	}
+
+	return &analytics, nil
+}
+
+func (d *DatabaseHelperImpl) GetBusinessConnectEmailSubscribers() ([]Data.BusinessConnectEmailSubscriber, error) {
+	var emailSubscribers []Data.BusinessConnectEmailSubscriber
+
+	// Retrieve order history with pagination
+	if err := d.db.Find(&emailSubscribers).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// No records found
+			return nil, errors.New("no email records found")
+		}
+		// Some other error occurred
+		return nil, errors.New("error retrieving email records: " + err.Error())
+	}
+
+	return emailSubscribers, nil
+}
+
+// GetBusinessConnectEmailSubscribersAfter pages through the subscribers by
+// id, a newsletter resumes from the last id it sent to
+func (d *DatabaseHelperImpl) GetBusinessConnectEmailSubscribersAfter(afterID uint, limit int) ([]Data.BusinessConnectEmailSubscriber, error) {
+	var emailSubscribers []Data.BusinessConnectEmailSubscriber
+
+	if err := d.db.Where("id > ?", afterID).Order("id ASC").Limit(limit).Find(&emailSubscribers).Error; err != nil {
+		return nil, errors.New("error retrieving email records: " + err.Error())
+	}
+
+	return emailSubscribers, nil
+}
+
+// SaveBusinessConnectSentEmail saves the email and fills in its id
+func (d *DatabaseHelperImpl) SaveBusinessConnectSentEmail(sentEmail *Data.Email) error {
+
+	// Retrieve order history with pagination
+	if err := d.db.Save(sentEmail).Error; err != nil {
+		// Some other error occurred
+		return errors.New("error saving email records: " + err.Error())
+	}
+
+	return nil
+}
+
+// UpdateSentEmailProgress records the last subscriber a newsletter went to,
+// and marks it done when completed is set
+func (d *DatabaseHelperImpl) UpdateSentEmailProgress(emailID, lastSubscriberID uint, completed bool) error {
+	updates := map[string]interface{}{
+		"last_subscriber_id": lastSubscriberID,
+	}
+	if completed {
+		updates["completed_at"] = time.Now().Unix()
+	}
+
+	if err := d.db.Model(&Data.Email{}).Where("id = ?", emailID).Updates(updates).Error; err != nil {
+		return errors.New("error saving email progress: " + err.Error())
+	}
+	return nil
+}
+
+func (d *DatabaseHelperImpl) GetSentEmail(emailID uint) (Data.Email, error) {
+	var sentEmail Data.Email
+	if err := d.db.First(&sentEmail, emailID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return sentEmail, errors.New("email not found")
+		}
+		return sentEmail, errors.New("error retrieving email records: " + err.Error())
+	}
+	return sentEmail, nil
+}
+
+func (d *DatabaseHelperImpl) GetBusinessConnectUniqueUserFingerPrintHash(fingerprintHash string) (Data.BusinessConnectDeviceFingerprint, error) {
+	var deviceFingerprint Data.BusinessConnectDeviceFingerprint
+
+	err := d.db.Where("fingerprint_hash = ?", fingerprintHash).First(&deviceFingerprint).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Data.BusinessConnectDeviceFingerprint{}, errors.New("no fingerprint record found")
+		}
+		return Data.BusinessConnectDeviceFingerprint{}, fmt.Errorf("error retrieving fingerprint record: %w", err)
+	}
+
+	return deviceFingerprint, nil
+}
+
+func (d *DatabaseHelperImpl) CreateBusinessConnectDeviceFingerprint(fingerprintHash string) error {
+	// Check if the fingerprint already exists
+	var existing Data.BusinessConnectDeviceFingerprint
+	err := d.db.Where("fingerprint_hash = ?", fingerprintHash).First(&existing).Error
+	if err == nil {
+		// Fingerprint already exists
+		return nil
+	}
+
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		// Some other error occurred while checking
+		return fmt.Errorf("error checking for existing fingerprint: %w", err)
+	}
+
+	// Fingerprint doesn't exist, proceed to create
+	fp := Data.BusinessConnectDeviceFingerprint{
+		FingerprintHash: fingerprintHash,
+	}
+
+	// Create the new fingerprint
+	if err := d.db.Create(&fp).Error; err != nil {
+		return fmt.Errorf("error creating fingerprint: %w", err)
+	}
+	return nil
+}
+
+func (d *DatabaseHelperImpl) RecommendProductsForUser2(fingerprintHash string, limit, offset int) ([]Data.Post, error) {
+	var products []Data.Post
+
+	// Check if fingerprintHash is empty for anonymous users
+	if fingerprintHash == "" {
+		slog.Debug("user is anonymous, fetching anonymous recommendations")
+		return d.RecommendForAnonymous(limit)
+	}
+
+	// Fetch viewed product IDs by the user
+	var viewedProductIDs []uint
+	err := d.db.Model(&Data.BusinessConnectUserActivity{}).
+		Where("fingerprint_hash = ? AND product_id IS NOT NULL", fingerprintHash).
+		Pluck("DISTINCT product_id", &viewedProductIDs).Error
+	if err != nil {
+		slog.Error("error fetching viewed product IDs", "error", err)
+		return nil, err
+	}
+
+	slog.Debug("user has viewed products", "product_ids", viewedProductIDs)
+
+	// Check if there are any viewed products
+	if len(viewedProductIDs) > 0 {
+		var similarFingerprints []string
+		// Fetch fingerprints of users who viewed similar products
+		err = d.db.Model(&Data.BusinessConnectUserActivity{}).
+			Where("product_id IN ?", viewedProductIDs).
+			Where("fingerprint_hash != ?", fingerprintHash).
+			Pluck("DISTINCT fingerprint_hash", &similarFingerprints).Error
+		if err != nil {
+			slog.Error("error fetching similar fingerprints", "error", err)
+			return nil, err
+		}
+
+		slog.Debug("found similar fingerprints", "count", len(similarFingerprints))
+
+		var recommendedIDs []uint
+		// Fetch recommended product IDs based on similar fingerprints
+		err = d.db.Model(&Data.BusinessConnectUserActivity{}).
+			Where("fingerprint_hash IN ?", similarFingerprints).
+			Where("product_id NOT IN ?", viewedProductIDs).
+			Pluck("DISTINCT product_id", &recommendedIDs).Error
+		if err != nil {
+			slog.Error("error fetching recommended product IDs", "error", err)
+			return nil, err
+		}
+
+		slog.Debug("recommended products", "product_ids", recommendedIDs)
+
+		// Fetch the recommended products
+		if len(recommendedIDs) > 0 {
+			err = d.db.Scopes(publishedPosts).Where("id IN ?", recommendedIDs).
+				Order(postRankOrder).
+				Limit(limit).
+				Offset(offset).
+				Find(&products).Error
+			if err != nil {
+				slog.Error("error fetching recommended products", "error", err)
+				return nil, err
+			}
+		}
+	}
+
+	// If no products found, fallback to category-based recommendations
+	if len(products) == 0 {
+		slog.Debug("no recommended products found, trying category-based recommendations")
+		var topCategories []string
+		err = d.db.
+			Model(&Data.BusinessConnectUserActivity{}).
+			Where("fingerprint_hash = ? AND category IS NOT NULL", fingerprintHash).
+			Select("DISTINCT category").
+			Limit(5).
+			Pluck("category", &topCategories).Error
+		if err != nil {
+			slog.Error("error fetching top categories", "error", err)
+			return nil, err
+		}
+
+		slog.Debug("top categories based on user activity", "categories", topCategories)
+
+		query := d.db.Model(&Data.Post{}).Scopes(publishedPosts)
+		if len(topCategories) > 0 {
+			query = query.Where("business_category IN ?", topCategories)
+		}
+
+		err = query.
+			Order(postRankOrder).
+			Limit(limit).
+			Offset(offset).
+			Find(&products).Error
+		if err != nil {
+			slog.Error("error fetching category-based products", "error", err)
+			return nil, err
+		}
+	}
+
+	// If still no products found, fallback to best sellers
+	if len(products) == 0 {
+		slog.Debug("no products found, trying best-seller fallback")
+		err = d.db.Scopes(publishedPosts).
+			Order(postRankOrder).
+			Limit(limit).
+			Offset(offset).
+			Find(&products).Error
+		if err != nil {
+			slog.Error("error fetching best-seller products", "error", err)
+			return nil, err
+		}
+	}
+
+	// Fill up the result with anonymous products if fewer than 'limit'
+	if len(products) < limit {
+		slog.Debug("fewer products found than limit, fetching more anonymous products")
+		missing := limit - len(products)
+		var topUp []Data.Post
+		err = d.db.Scopes(publishedPosts).
+			Order(postRankOrder + ", RAND()").
+			Limit(missing).
+			Find(&topUp).Error
+		if err != nil {
+			slog.Error("error fetching anonymous products", "error", err)
+			return nil, err
+		}
+
+		// Build a set of existing product IDs
+		existing := make(map[uint]bool)
+		for _, p := range products {
+			existing[p.ID] = true
+		}
+
+		// Filter out duplicates before appending
+		for _, p := range topUp {
+			if !existing[p.ID] {
+				products = append(products, p)
+				existing[p.ID] = true
+			}
+		}
+	}
+
+	// Debug: Output the final product list
+	// fmt.Printf("Returning %d products: %v\n", len(products), products)
+
+	return products, nil
+}
+
+func (d *DatabaseHelperImpl) RecommendProductsForUser(fingerprintHash string, limit, offset int) ([]Data.Post, error) {
+	var finalProducts []Data.Post
+	existing := make(map[uint]bool)
+	target := offset + limit
+
+	if fingerprintHash == "" {
+		slog.Debug("anonymous user, falling back to anonymous recommendations")
+		all, err := d.RecommendForAnonymous(target)
+		if err != nil {
+			return nil, err
+		}
+		// Deduplicate
+		for _, p := range all {
+			if !existing[p.ID] {
+				finalProducts = append(finalProducts, p)
+				existing[p.ID] = true
+				if len(finalProducts) >= target {
+					break
+				}
+			}
+		}
+		return finalProducts[offset:min(offset+limit, len(finalProducts))], nil
+	}
+
+	// Step 1: Weighted scoring
+	type WeightedProduct struct {
+		ProductID uint
+		Score     int
+	}
+	var weightedProducts []WeightedProduct
+	err := d.db.Raw(`
+		SELECT product_id,
+		       SUM(CASE 
+		            WHEN activity_type = 'search' THEN 5
+		            WHEN activity_type = 'click' THEN 3
+		            WHEN activity_type = 'view' THEN 1
+		            ELSE 0 END) AS score
+		FROM business_connect_user_activities
+		WHERE fingerprint_hash = ?
+		  AND product_id IS NOT NULL
+		  AND last_updated >= ?
+		GROUP BY product_id
+		ORDER BY score DESC
+		LIMIT 50
+	`, fingerprintHash, time.Now().AddDate(0, 0, -30)).Scan(&weightedProducts).Error
+	if err != nil {
+		return nil, err
+	}
+
+	var viewedProductIDs []uint
+	for _, wp := range weightedProducts {
+		viewedProductIDs = append(viewedProductIDs, wp.ProductID)
+		existing[wp.ProductID] = true
+	}
+
+	// Step 2: Collaborative filtering
+	if len(viewedProductIDs) > 0 {
+		var similarFingerprints []string
+		err = d.db.Model(&Data.BusinessConnectUserActivity{}).
+			Where("product_id IN ?", viewedProductIDs).
+			Where("fingerprint_hash != ?", fingerprintHash).
+			Pluck("DISTINCT fingerprint_hash", &similarFingerprints).Error
+		if err != nil {
+			return nil, err
+		}
+
+		var recommendedIDs []uint
+		err = d.db.Model(&Data.BusinessConnectUserActivity{}).
+			Where("fingerprint_hash IN ?", similarFingerprints).
+			Where("product_id NOT IN ?", viewedProductIDs).
+			Pluck("DISTINCT product_id", &recommendedIDs).Error
+		if err != nil {
+			return nil, err
+		}
+
+		if len(recommendedIDs) > 0 {
+			var collabProducts []Data.Post
+			err = d.db.
+				Scopes(publishedPosts, inStock).
+				Where("id IN ?", recommendedIDs).
+				Order(postRankOrder).
+				Find(&collabProducts).Error
+			if err != nil {
+				return nil, err
+			}
+
+			for _, p := range collabProducts {
+				if !existing[p.ID] {
+					finalProducts = append(finalProducts, p)
+					existing[p.ID] = true
+					if len(finalProducts) >= target {
+						goto RETURN_PRODUCTS
+					}
+				}
+			}
+		}
+	}
+
+	// Step 3: Category-based
+	if len(finalProducts) < target {
+		var topCategories []string
+		err = d.db.
+			Model(&Data.BusinessConnectUserActivity{}).
+			Where("fingerprint_hash = ? AND category IS NOT NULL", fingerprintHash).
+			Where("last_updated >= ?", time.Now().AddDate(0, 0, -30)).
+			Select("DISTINCT category").
+			Limit(5).
+			Pluck("category", &topCategories).Error
+		if err != nil {
+			return nil, err
+		}
+
+		if len(topCategories) > 0 {
+			var categoryProducts []Data.Post
+			err = d.db.
+				Scopes(publishedPosts, inStock).
+				Where("business_category IN ?", topCategories).
+				Order(postRankOrder).
+				Find(&categoryProducts).Error
+			if err != nil {
+				return nil, err
+			}
+
+			for _, p := range categoryProducts {
+				if !existing[p.ID] {
+					finalProducts = append(finalProducts, p)
+					existing[p.ID] = true
+					if len(finalProducts) >= target {
+						goto RETURN_PRODUCTS
+					}
+				}
+			}
+		}
+	}
+
+	// Step 4: Search-based
+	if len(finalProducts) < target {
+		var searchQueries []string
+		err = d.db.Model(&Data.BusinessConnectUserActivity{}).
+			Where("fingerprint_hash = ? AND activity_type = ?", fingerprintHash, "search").
+			Where("last_updated >= ?", time.Now().AddDate(0, 0, -30)).
+			Select("DISTINCT title_or_search_query").
+			Limit(5).
+			Pluck("title_or_search_query", &searchQueries).Error
+		if err != nil {
+			return nil, err
+		}
+
+		if len(searchQueries) > 0 {
+			var searchProducts []Data.Post
+			matches := d.db
+			for _, q := range searchQueries {
+				qLower := strings.ToLower(q)
+				matches = matches.Or("LOWER(title) LIKE ?", "%"+qLower+"%").
+					Or("LOWER(description) LIKE ?", "%"+qLower+"%")
+			}
+			query := d.db.Scopes(publishedPosts, inStock).Where(matches)
+			err = query.Order(postRankOrder).
+				Find(&searchProducts).Error
+			if err != nil {
+				return nil, err
+			}
+
+			for _, p := range searchProducts {
+				if !existing[p.ID] {
+					finalProducts = append(finalProducts, p)
+					existing[p.ID] = true
+					if len(finalProducts) >= target {
+						goto RETURN_PRODUCTS
+					}
+				}
+			}
+		}
+	}
+
+	// Step 5: Best sellers
+	if len(finalProducts) < target {
+		var bestSellers []Data.Post
+		err = d.db.
+			Scopes(publishedPosts, inStock).
+			Order(postRankOrder).
+			Find(&bestSellers).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range bestSellers {
+			if !existing[p.ID] {
+				finalProducts = append(finalProducts, p)
+				existing[p.ID] = true
+				if len(finalProducts) >= target {
+					goto RETURN_PRODUCTS
+				}
+			}
+		}
+	}
+
+	// Step 6: Random top-up
+	if len(finalProducts) < target {
+		var topUp []Data.Post
+		err = d.db.
+			Scopes(publishedPosts, inStock).
+			Order(postRankOrder + ", RAND()").
+			Find(&topUp).Error
+		if err != nil {
+			return nil, err
+		}
+		for _, p := range topUp {
+			if !existing[p.ID] {
+				finalProducts = append(finalProducts, p)
+				existing[p.ID] = true
+				if len(finalProducts) >= target {
+					break
+				}
+			}
+		}
+	}
+
+RETURN_PRODUCTS:
+	if offset >= len(finalProducts) {
+		return []Data.Post{}, nil
+	}
+	return finalProducts[offset:min(offset+limit, len(finalProducts))], nil
+}
+
+// inStock drops posts marked out of stock
+func inStock(db *gorm.DB) *gorm.DB {
+	return db.Where("stock_availability != ?", "false")
+}
+
+func min(a, b int) int {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+func (d *DatabaseHelperImpl) RecommendForAnonymous(limit int) ([]Data.Post, error) {
+	var products []Data.Post
+
+	err := d.db.
+		Scopes(publishedPosts).
+		Order(postRankOrder + ", RAND()").
+		Limit(limit).
+		Find(&products).Error
+
+	return products, err
+}
+
+// LogUserClickData counts an activity of a device, userID is the signed in
+// user on it or 0 so each user's activity is kept apart
+func (d *DatabaseHelperImpl) LogUserClickData(fingerprintHash string, userID, productID uint, ActivityType, Category, TitleOrSearchQuery string) error {
+	// Check if the activity (click) already exists for this user and product
+	var activity Data.BusinessConnectUserActivity
+	err := d.db.Where("fingerprint_hash = ? AND user_id = ? AND activity_type = ? AND product_id = ?", fingerprintHash, userID, ActivityType, productID).First(&activity).Error
+	if err == nil {
+		// If the record exists, increment the ClickCount
+		activity.ClickCount++
+		activity.LastUpdated = time.Now() // Update the timestamp
+		return d.db.Save(&activity).Error
+	} else if errors.Is(err, gorm.ErrRecordNotFound) {
+		// If the record does not exist, create a new one
+		activity = Data.BusinessConnectUserActivity{
+			FingerprintHash:    fingerprintHash,
+			UserID:             userID,
+			ActivityType:       ActivityType,
+			ClickCount:         1, // Initial click count
+			ProductID:          productID,
+			Category:           Category,           // Optional: You could add the category here if available
+			TitleOrSearchQuery: TitleOrSearchQuery, // Optional: You could add the search query if relevant
+			LastUpdated:        time.Now(),
+		}
+		return d.db.Create(&activity).Error
+	}
+	return err
+}
@@ -0,0 +1,302 @@
+package dbHelpFunc
+
+import (
+	"errors"
+	"fmt"
+
+	"gorm.io/gorm"
+
+	Data "business-connect/models"
+)
+
+// BlogRepo covers blog posts, their images and reviews
+type BlogRepo interface {
+	GetBusinessConnectBlogByLimit( /*userID uint64, */ limit, offset int) ([]Data.Blog, int64, error)
+	AddBlog(post Data.Blog, user Data.User) (Data.Blog, error)
+	AddBlogImage(image Data.BlogImage, postID uint) error
+	CountBlogImages(blogID uint) (int64, error)
+	// SaveCustomerReview(productID uint, email string, name string, reviewText string, rating int) (Data.CustomerReview, error)
+	// GetCustomerReviewsByProduct(productID uint, limit int, offset int) ([]Data.CustomerReview, int64, error)
+	SaveCustomerBlogReview(blogID uint, email string, name string, reviewText string, rating int) (Data.CustomerBlogReview, error)
+	GetCustomerBlogReviewsByBlogPost(blogID uint, limit int, offset int) ([]Data.CustomerBlogReview, int64, error)
+	GetBlogPostById(blogID uint) (*Data.Blog, error)
+	UpdateBusinessConnectBlog(Post Data.Blog, BlogID uint) error
+	DeleteBusinessConnectBlog(BlogID uint) error
+}
+
+func (d *DatabaseHelperImpl) GetBusinessConnectBlogByLimit( /*userID uint64, */ limit, offset int) ([]Data.Blog, int64, error) {
+	var productRecords []Data.Blog
+	var productRecordsCount int64
+
+	// Get the count of transaction records for the user
+	if err := d.db.Model(&Data.Blog{}).Count(&productRecordsCount).Error; err != nil {
+		return []Data.Blog{}, 0, err
+	}
+
+	// Retrieve transaction history for the user with pagination
+	if productRecordsErr := d.db. /*Where("user_id = ?", userID).*/ Order("created_at desc").Limit(limit).Offset(offset).Find(&productRecords).Error; productRecordsErr != nil {
+		if errors.Is(productRecordsErr, gorm.ErrRecordNotFound) {
+			// The record with the specified UserID was not found
+			return []Data.Blog{}, 0, errors.New("no transaction record found")
+		} else {
+			// Some other error occurred
+			return []Data.Blog{}, 0, errors.New("error retrieving transaction record")
+		}
+	}
+
+	return productRecords, productRecordsCount, nil
+}
+
+// AddProduct adds a product and updates its ProductUrlID
+func (d *DatabaseHelperImpl) AddBlog(post Data.Blog, user Data.User) (Data.Blog, error) {
+	// Create the product in the database
+	result := d.db.Create(&post)
+
+	// Check if an error occurred when creating the product
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return Data.Blog{}, fmt.Errorf("failed to create product: product not found, %w", result.Error)
+		} else if errors.Is(result.Error, gorm.ErrInvalidData) {
+			return Data.Blog{}, fmt.Errorf("failed to create product: invalid data provided, %w", result.Error)
+		} else {
+			return Data.Blog{}, fmt.Errorf("failed to create product: %w", result.Error)
+		}
+	}
+
+	// Generate the ProductUrlID
+	post.BlogUrlID = GenerateProductURL(post.Title, int(post.ID))
+
+	// Update the product with the new ProductUrlID
+	updateResult := d.db.Model(&post).Update("blog_url_id", post.BlogUrlID)
+	if updateResult.Error != nil {
+		return Data.Blog{}, fmt.Errorf("failed to update product URL ID: %w", updateResult.Error)
+	}
+
+	// Update user with posts amount
+	updateUserResult := d.db.Model(&user).Update("total_product", user.TotalProduct+1)
+	if updateUserResult.Error != nil {
+		return Data.Blog{}, fmt.Errorf("failed to update user's total products: %w", updateUserResult.Error)
+	}
+
+	// Return the updated product
+	return post, nil
+}
+
+// SaveCustomerReview saves a customer review for a product
+// func (d *DatabaseHelperImpl) SaveCustomerReview(productID uint, email string, name string, reviewText string, rating int) (Data.CustomerReview, error) {
+// 	// Check if the product exists in the database
+// 	var product Data.Post
+// 	if err := d.db.First(&product, productID).Error; err != nil {
+// 		if errors.Is(err, gorm.ErrRecordNotFound) {
+// 			return Data.CustomerReview{}, fmt.Errorf("product not found: %w", err)
+// 		}
+// 		return Data.CustomerReview{}, fmt.Errorf("failed to retrieve product: %w", err)
+// 	}
+
+// 	// Create the new customer review
+// 	customerReview := Data.CustomerReview{
+// 		ProducttID: productID,
+// 		Email:      email,
+// 		Name:       name,
+// 		Review:     reviewText,
+// 		Rating:     rating,
+// 	}
+
+// 	// Save the review to the database
+// 	if err := d.db.Create(&customerReview).Error; err != nil {
+// 		return Data.CustomerReview{}, fmt.Errorf("failed to save customer review: %w", err)
+// 	}
+
+// 	// Update the product's review count
+// 	if err := d.db.Model(&product).Update("product_reviews_count", product.ProductReviewsCount+1).Error; err != nil {
+// 		return Data.CustomerReview{}, fmt.Errorf("failed to update product review count: %w", err)
+// 	}
+
+// 	// Return the saved customer review
+// 	return customerReview, nil
+// }
+
+// GetCustomerReviewsByProduct retrieves a list of customer reviews for a given product with pagination
+// func (d *DatabaseHelperImpl) GetCustomerReviewsByProduct(productID uint, limit int, offset int) ([]Data.CustomerReview, int64, error) {
+// 	var reviews []Data.CustomerReview
+
+// 	// Check if the product exists in the database
+// 	var product Data.Post
+// 	if err := d.db.First(&product, productID).Error; err != nil {
+// 		if errors.Is(err, gorm.ErrRecordNotFound) {
+// 			return nil, 0, fmt.Errorf("product not found: %w", err)
+// 		}
+// 		return nil, 0, fmt.Errorf("failed to retrieve product: %w", err)
+// 	}
+
+// 	// Retrieve reviews with limit and offset for pagination
+// 	if err := d.db.Where("productt_id = ?", productID).
+// 		Limit(limit).
+// 		Offset(offset).
+// 		Find(&reviews).Error; err != nil {
+// 		return nil, 0, fmt.Errorf("failed to retrieve customer reviews: %w", err)
+// 	}
+
+// 	// Return the list of reviews
+// 	return reviews, product.ProductReviewsCount, nil
+// }
+
+// SaveCustomerReview saves a customer review for a product
+func (d *DatabaseHelperImpl) SaveCustomerBlogReview(blogID uint, email string, name string, reviewText string, rating int) (Data.CustomerBlogReview, error) {
+	// Check if the blog post exists in the database
+	var blog Data.Blog
+	if err := d.db.First(&blog, blogID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Data.CustomerBlogReview{}, fmt.Errorf("blog not found: %w", err)
+		}
+		return Data.CustomerBlogReview{}, fmt.Errorf("failed to retrieve product: %w", err)
+	}
+
+	// Create the new customer review
+	customerReview := Data.CustomerBlogReview{
+		BlogID: blogID,
+		Email:  email,
+		Name:   name,
+		Review: reviewText,
+		Rating: rating,
+	}
+
+	// Save the review to the database
+	if err := d.db.Create(&customerReview).Error; err != nil {
+		return Data.CustomerBlogReview{}, fmt.Errorf("failed to save customer review: %w", err)
+	}
+
+	// Update the product's review count
+	if err := d.db.Model(&blog).Update("blog_reviews_count", blog.BlogReviewsCount+1).Error; err != nil {
+		return Data.CustomerBlogReview{}, fmt.Errorf("failed to update product review count: %w", err)
+	}
+
+	// Return the saved customer review
+	return customerReview, nil
+}
+
+// GetCustomerReviewsByProduct retrieves a list of customer reviews for a given product with pagination
+func (d *DatabaseHelperImpl) GetCustomerBlogReviewsByBlogPost(blogID uint, limit int, offset int) ([]Data.CustomerBlogReview, int64, error) {
+	var reviews []Data.CustomerBlogReview
+
+	// Check if the blog post exists in the database
+	var blog Data.Blog
+	if err := d.db.First(&blog, blogID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, fmt.Errorf("blog not found: %w", err)
+		}
+		return nil, 0, fmt.Errorf("failed to retrieve blog: %w", err)
+	}
+
+	// Retrieve reviews with limit and offset for pagination
+	if err := d.db.Where("blog_id = ?", blogID).
+		Limit(limit).
+		Offset(offset).
+		Find(&reviews).Error; err != nil {
+		return nil, 0, fmt.Errorf("failed to retrieve customer reviews: %w", err)
+	}
+
+	// Return the list of reviews
+	return reviews, blog.BlogReviewsCount, nil
+}
+
+func (d *DatabaseHelperImpl) AddBlogImage(image Data.BlogImage, postID uint) error {
+	// Set the post_id for the image
+	image.BlogID = postID
+
+	result := d.db.Create(&image)
+
+	// Check if an error occurred when creating the post
+	if result.Error != nil {
+		// Some other error occurred
+		return errors.New("an unknown error occurred")
+	}
+
+	// Return the ID of the newly created post
+	return nil
+}
+
+func (d *DatabaseHelperImpl) CountBlogImages(blogID uint) (int64, error) {
+	var count int64
+	if err := d.db.Model(&Data.BlogImage{}).Where("blog_id = ?", blogID).Count(&count).Error; err != nil {
+		return 0, errors.New("error counting blog images: " + err.Error())
+	}
+	return count, nil
+}
+
+func (d *DatabaseHelperImpl) GetBlogPostById(blogID uint) (*Data.Blog, error) {
+	// Initialize variables
+	var blog Data.Blog
+
+	// Preload related data with a limit on CustomerBlogReviews
+	err := d.db.Preload("CustomerBlogReviews", func(db *gorm.DB) *gorm.DB {
+		return db.Limit(4)
+	}).First(&blog, blogID).Error
+
+	if err != nil {
+		return nil, errors.New("failed to find blog post: " + err.Error())
+	}
+
+	// Return the blog post and the associated customer reviews
+	return &blog, nil
+}
+
+func (d *DatabaseHelperImpl) UpdateBusinessConnectBlog(Blog Data.Blog, BlogID uint) error {
+	// Find the product and update it
+	returnedBlog, productErr := d.GetBlogPostById(uint(BlogID))
+
+	if productErr != nil {
+		return errors.New("error retrieving product")
+	}
+
+	returnedBlog.Title = Blog.Title
+	returnedBlog.Description1 = Blog.Description1
+	returnedBlog.Description2 = Blog.Description2
+	returnedBlog.BlogCategory = Blog.BlogCategory
+
+	// Update the blog
+	// save updated blog
+	result := d.db.Save(returnedBlog)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// The record with the specified email was not found
+			return errors.New("blog not found")
+		} else {
+			// Some other error occurred
+			return errors.New("error retrieving blog")
+		}
+	}
+
+	return nil
+}
+
+func (d *DatabaseHelperImpl) DeleteBusinessConnectBlog(BlogID uint) error {
+	var returnedBlog Data.Blog
+
+	if err := d.db.First(&returnedBlog, "id = ?", uint64(BlogID)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Post not found, find the next closest available product ID
+			// nextProductID, findErr := d.findClosestAvailableProductID(productID)
+			// if findErr != nil {
+			return errors.New("blog not found")
+			// }
+		}
+	}
+
+	// Delete the product
+	result := d.db.Delete(&returnedBlog)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return errors.New("blog not found")
+		}
+		return result.Error // Return the actual error
+	}
+
+	// Optional: Check if a row was actually deleted
+	if result.RowsAffected == 0 {
+		return errors.New("blog not found or already deleted")
+	}
+
+	return nil
+}
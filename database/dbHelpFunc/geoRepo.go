@@ -0,0 +1,202 @@
+package dbHelpFunc
+
+import (
+	"errors"
+	"sort"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	"business-connect/geo"
+	Data "business-connect/models"
+)
+
+// GeoRepo covers "near me": posts and businesses around a point, nearest
+// first. Each returns one page, how many there are in all and whether that
+// count stopped at MaxNearbyResults.
+type GeoRepo interface {
+	GetNearbyPosts(params NearbyParams) ([]Data.Post, int64, bool, error)
+	GetNearbyBusinesses(params NearbyParams) ([]Data.NearbyBusiness, int64, bool, error)
+}
+
+// NearbyParams is one page of what is within RadiusKm of Origin,
+// PostTypes narrows posts down when set
+type NearbyParams struct {
+	Origin    geo.Point
+	RadiusKm  float64
+	PostTypes []string
+	Limit     int
+	Offset    int
+}
+
+// MaxNearbyResults is the most rows a nearby query measures, the nearest by
+// a flat approximation, a wide radius in a busy city finds no more than these
+const MaxNearbyResults = 5000
+
+// geoHit is one row a nearby query found and how far it is
+type geoHit struct {
+	ID       uint
+	Lat      float64
+	Lng      float64
+	distance float64
+}
+
+// GetNearbyPosts is the published posts within the radius, nearest first,
+// each with its distance
+func (d *DatabaseHelperImpl) GetNearbyPosts(params NearbyParams) ([]Data.Post, int64, bool, error) {
+	query := d.db.Model(&Data.Post{}).Scopes(publishedPosts)
+	if len(params.PostTypes) > 0 {
+		query = query.Where("posts.post_type IN ?", params.PostTypes)
+	}
+	hits, truncated, err := nearest(query, "posts", params.Origin, params.RadiusKm)
+	if err != nil {
+		return nil, 0, false, errors.New("error finding posts nearby: " + err.Error())
+	}
+
+	page := pageOf(hits, params.Offset, params.Limit)
+	posts, err := d.postsInOrder(page)
+	if err != nil {
+		return nil, 0, false, err
+	}
+	return posts, int64(len(hits)), truncated, nil
+}
+
+// GetNearbyBusinesses is the sellers within the radius that have a
+// published business post, nearest first
+func (d *DatabaseHelperImpl) GetNearbyBusinesses(params NearbyParams) ([]Data.NearbyBusiness, int64, bool, error) {
+	selling := d.db.Model(&Data.Post{}).Select("1").Scopes(publishedPosts).
+		Where("posts.user_id = users.id AND posts.post_type = ?", PostTypeBusiness)
+	query := d.db.Model(&Data.User{}).Where("users.suspended = ? AND EXISTS (?)", false, selling)
+	hits, truncated, err := nearest(query, "users", params.Origin, params.RadiusKm)
+	if err != nil {
+		return nil, 0, false, errors.New("error finding businesses nearby: " + err.Error())
+	}
+
+	page := pageOf(hits, params.Offset, params.Limit)
+	ids := make([]uint, len(page))
+	for i, hit := range page {
+		ids[i] = hit.ID
+	}
+	var users []Data.User
+	if len(ids) > 0 {
+		if err := d.db.Where("id IN ?", ids).Find(&users).Error; err != nil {
+			return nil, 0, false, errors.New("error retrieving businesses nearby: " + err.Error())
+		}
+	}
+	byID := make(map[uint]Data.User, len(users))
+	for _, user := range users {
+		byID[user.ID] = user
+	}
+
+	businesses := make([]Data.NearbyBusiness, 0, len(page))
+	for _, hit := range page {
+		user, ok := byID[hit.ID]
+		if !ok {
+			continue
+		}
+		businesses = append(businesses, Data.NearbyBusiness{
+			UserID:          user.ID,
+			FullName:        user.FullName,
+			BusinessName:    user.BusinessName,
+			ProfilePhotoURL: user.ProfilePhotoURL,
+			State:           user.State,
+			Verified:        user.Verified,
+			DistanceKm:      roundKm(hit.distance),
+		})
+	}
+	return businesses, int64(len(hits)), truncated, nil
+}
+
+// nearest is the rows of query on table with coordinates within radiusKm
+// of origin, or at any distance when it is 0, nearest first. The bounding
+// box of the circle narrows the rows down on the index, the Haversine
+// distance of each decides. truncated is true when there were more than
+// MaxNearbyResults candidates, and the furthest were left out.
+func nearest(query *gorm.DB, table string, origin geo.Point, radiusKm float64) (hits []geoHit, truncated bool, err error) {
+	lat, lng := table+".latitude", table+".longitude"
+	query = query.Select(table + ".id AS id, " + lat + " AS lat, " + lng + " AS lng").
+		// users keep 0, 0 until they set a location
+		Where(lat + " IS NOT NULL AND " + lng + " IS NOT NULL AND NOT (" + lat + " = 0 AND " + lng + " = 0)")
+	if radiusKm > 0 {
+		box := geo.BoxAround(origin, radiusKm)
+		query = query.Where(lat+" BETWEEN ? AND ?", box.MinLat, box.MaxLat)
+		if !box.AnyLng {
+			query = query.Where(lng+" BETWEEN ? AND ?", box.MinLng, box.MaxLng)
+		}
+	}
+
+	// the candidates are the nearest on a flat map, which is close enough
+	// to pick them, their order is settled by the real distance below
+	scale := geo.LngScale(origin)
+	err = query.
+		Order(clause.OrderBy{Expression: clause.Expr{
+			SQL:                "(" + lat + " - ?) * (" + lat + " - ?) + (" + lng + " - ?) * (" + lng + " - ?) * ?",
+			Vars:               []interface{}{origin.Lat, origin.Lat, origin.Lng, origin.Lng, scale * scale},
+			WithoutParentheses: true,
+		}}).
+		Limit(MaxNearbyResults + 1).
+		Scan(&hits).Error
+	if err != nil {
+		return nil, false, err
+	}
+	if len(hits) > MaxNearbyResults {
+		hits, truncated = hits[:MaxNearbyResults], true
+	}
+
+	within := hits[:0]
+	for _, hit := range hits {
+		hit.distance = geo.Distance(origin, geo.Point{Lat: hit.Lat, Lng: hit.Lng})
+		if radiusKm > 0 && hit.distance > radiusKm {
+			continue
+		}
+		within = append(within, hit)
+	}
+	sort.SliceStable(within, func(i, j int) bool {
+		if within[i].distance != within[j].distance {
+			return within[i].distance < within[j].distance
+		}
+		return within[i].ID < within[j].ID
+	})
+	return within, truncated, nil
+}
+
+// pageOf is the limit rows of rows after offset, empty past the end however
+// big offset is
+func pageOf[T any](rows []T, offset, limit int) []T {
+	start := min(max(offset, 0), len(rows))
+	return rows[start : start+min(max(limit, 0), len(rows)-start)]
+}
+
+// postsInOrder loads the posts of hits with their images, in the order of
+// hits and with their distance
+func (d *DatabaseHelperImpl) postsInOrder(hits []geoHit) ([]Data.Post, error) {
+	posts := make([]Data.Post, 0, len(hits))
+	if len(hits) == 0 {
+		return posts, nil
+	}
+	ids := make([]uint, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.ID
+	}
+	var found []Data.Post
+	if err := d.db.Preload("Images").Where("id IN ?", ids).Find(&found).Error; err != nil {
+		return nil, errors.New("error retrieving posts nearby: " + err.Error())
+	}
+	byID := make(map[uint]Data.Post, len(found))
+	for _, post := range found {
+		byID[post.ID] = post
+	}
+	for _, hit := range hits {
+		if post, ok := byID[hit.ID]; ok {
+			distance := roundKm(hit.distance)
+			post.DistanceKm = &distance
+			posts = append(posts, post)
+		}
+	}
+	return posts, nil
+}
+
+// roundKm rounds a distance to 10 metres, no finer than a shop front
+func roundKm(km float64) float64 {
+	return float64(int64(km*100+0.5)) / 100
+}
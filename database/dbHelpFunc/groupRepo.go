@@ -0,0 +1,205 @@
+package dbHelpFunc
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	Data "business-connect/models"
+)
+
+// GroupRepo covers group posts and their members
+type GroupRepo interface {
+	GetAvailableGroups(limit, offset int) ([]GroupFeedItem, bool, error)
+	JoinGroup(user Data.User, groupPostID uint) (*Data.GroupParticipant, bool, error)
+}
+
+type GroupUserSummary struct {
+	ID              uint   `json:"id"`
+	FullName        string `json:"full_name"`
+	ProfilePhotoURL string `json:"profile_photo_url"`
+	Verified        bool   `json:"verified"`
+}
+
+type GroupFeedItem struct {
+	ID           uint               `json:"id"`
+	Title        string             `json:"title"`
+	Description  string             `json:"description"`
+	MaxMembers   int                `json:"max_members"`
+	WhatsappURL  string             `json:"whatsapp_url"`
+	CreatedAt    time.Time          `json:"created_at"`
+	Images       []Data.PostImage   `json:"images"`
+	Participants []GroupUserSummary `json:"participants"`
+}
+
+func (d *DatabaseHelperImpl) GetAvailableGroups(
+	limit, offset int,
+) ([]GroupFeedItem, bool, error) {
+
+	// 1️⃣ Fetch group posts + preload images
+	var groups []Data.Post
+
+	result := d.db.
+		Preload("Images").
+		Where(`
+			post_type = ?
+			AND is_active = ?
+			AND approved = ?
+		`, PostTypeGroup, true, true).
+		Order("created_at DESC").
+		Limit(limit + 1).
+		Offset(offset).
+		Find(&groups)
+
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+
+	// pagination flag
+	hasMore := false
+	if len(groups) > limit {
+		hasMore = true
+		groups = groups[:limit]
+	}
+
+	// 2️⃣ Collect group IDs
+	groupIDs := make([]uint, 0, len(groups))
+	for _, g := range groups {
+		groupIDs = append(groupIDs, g.ID)
+	}
+
+	// 3️⃣ Fetch participants (single query)
+	var participants []Data.GroupParticipant
+	if len(groupIDs) > 0 {
+		d.db.
+			Where("post_id IN ?", groupIDs).
+			Order("created_at ASC").
+			Find(&participants)
+	}
+
+	// 4️⃣ Group participants (limit 5 per group)
+	participantMap := make(map[uint][]GroupUserSummary)
+	for _, p := range participants {
+		if len(participantMap[p.PostID]) >= 5 {
+			continue
+		}
+
+		participantMap[p.PostID] = append(
+			participantMap[p.PostID],
+			GroupUserSummary{
+				ID:              p.UserID,
+				FullName:        p.FullName,
+				ProfilePhotoURL: p.ProfilePhotoURL,
+				Verified:        p.Verified,
+			},
+		)
+	}
+
+	// 5️⃣ Build response
+	var response []GroupFeedItem
+	for _, group := range groups {
+
+		var maxMembers int
+		if group.MaxMembers != nil {
+			maxMembers = *group.MaxMembers
+		} else {
+			maxMembers = 0
+		}
+
+		response = append(response, GroupFeedItem{
+			ID:           group.ID,
+			Title:        group.Title,
+			Description:  group.Description,
+			MaxMembers:   maxMembers,
+			WhatsappURL:  group.WhatsappURL,
+			CreatedAt:    group.CreatedAt,
+			Images:       group.Images,
+			Participants: participantMap[group.ID], // up to 5
+		})
+	}
+
+	return response, hasMore, nil
+}
+
+// DB helper
+func (d *DatabaseHelperImpl) JoinGroup(
+	user Data.User,
+	groupPostID uint,
+) (*Data.GroupParticipant, bool, error) {
+
+	// Begin transaction
+	tx := d.db.Begin()
+	if tx.Error != nil {
+		return nil, false, tx.Error
+	}
+
+	// 1️⃣ Check if user already joined
+	var existing Data.GroupParticipant
+	err := tx.
+		Where("post_id = ? AND user_id = ?", groupPostID, user.ID).
+		First(&existing).Error
+
+	if err == nil {
+		tx.Rollback()
+		return &existing, false, nil
+	} else if err != gorm.ErrRecordNotFound {
+		tx.Rollback()
+		return nil, false, err
+	}
+
+	// 2️⃣ Lock the group post row
+	var post Data.Post
+	if err := tx.
+		Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&post, groupPostID).Error; err != nil {
+		tx.Rollback()
+		return nil, false, err
+	}
+
+	// 3️⃣ Check max members safely
+	membersCount := 0
+	if post.MembersCount != nil {
+		membersCount = *post.MembersCount
+	}
+
+	maxMembers := 0
+	if post.MaxMembers != nil {
+		maxMembers = *post.MaxMembers
+	}
+
+	if maxMembers > 0 && membersCount >= maxMembers {
+		tx.Rollback()
+		return nil, false, errors.New("group is full")
+	}
+
+	// 4️⃣ Create new participant
+	participant := Data.GroupParticipant{
+		PostID:          groupPostID,
+		UserID:          user.ID,
+		FullName:        user.FullName,
+		ProfilePhotoURL: user.ProfilePhotoURL,
+		Verified:        user.Verified,
+	}
+
+	if err := tx.Create(&participant).Error; err != nil {
+		tx.Rollback()
+		return nil, false, err
+	}
+
+	// 5️⃣ Increment members_count atomically, using COALESCE to handle NULL
+	if err := tx.Model(&Data.Post{}).
+		Where("id = ?", groupPostID).
+		UpdateColumn("members_count", gorm.Expr("COALESCE(members_count,0) + 1")).Error; err != nil {
+		tx.Rollback()
+		return nil, false, err
+	}
+
+	// 6️⃣ Commit transaction
+	if err := tx.Commit().Error; err != nil {
+		return nil, false, err
+	}
+
+	return &participant, true, nil
+}
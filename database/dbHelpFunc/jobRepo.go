@@ -0,0 +1,189 @@
+package dbHelpFunc
+
+import (
+	"errors"
+	"time"
+
+	"gorm.io/gorm"
+
+	Data "business-connect/models"
+)
+
+// job statuses, a dead job ran out of attempts and waits for an admin
+const (
+	JobPending = "pending"
+	JobRunning = "running"
+	JobDone    = "done"
+	JobDead    = "dead"
+)
+
+// ErrJobLeaseLost is returned when a job is finished by a worker whose claim
+// on it ran out, it was claimed again and belongs to someone else now
+var ErrJobLeaseLost = errors.New("job was claimed again by another worker")
+
+// JobRepo covers the background job queue. A claimed job is only finished,
+// released or killed by the worker holding its claim, the job as ClaimJobs
+// returned it.
+type JobRepo interface {
+	CreateJob(job *Data.Job) error
+	ClaimJobs(now int64, limit int, lockedUntil int64, lockedBy string) ([]Data.Job, error)
+	CompleteJob(job Data.Job, result string) error
+	RetryJobLater(job Data.Job, runAt int64, lastError string) error
+	ReleaseJob(job Data.Job) error
+	KillJob(job Data.Job, lastError string) error
+	GetJob(jobID uint) (Data.Job, error)
+	GetJobsByStatus(status, jobType string, limit, offset int) ([]Data.Job, bool, error)
+	RequeueDeadJob(jobID uint) error
+}
+
+func (d *DatabaseHelperImpl) CreateJob(job *Data.Job) error {
+	if err := d.db.Create(job).Error; err != nil {
+		return errors.New("error saving job: " + err.Error())
+	}
+	return nil
+}
+
+// ClaimJobs marks up to limit due jobs as running until lockedUntil by
+// lockedBy and returns them. A running job whose lock has passed belongs to
+// a process that died and is claimed again. Each row is claimed only if its
+// attempts haven't changed since it was read, so two workers never get the
+// same job.
+func (d *DatabaseHelperImpl) ClaimJobs(now int64, limit int, lockedUntil int64, lockedBy string) ([]Data.Job, error) {
+	var due []Data.Job
+	err := d.db.
+		Where("(status = ? AND run_at <= ?) OR (status = ? AND locked_until < ?)", JobPending, now, JobRunning, now).
+		Order("run_at ASC").
+		Limit(limit).
+		Find(&due).Error
+	if err != nil {
+		return nil, errors.New("error retrieving jobs: " + err.Error())
+	}
+
+	claimed := make([]Data.Job, 0, len(due))
+	for _, job := range due {
+		result := d.db.Model(&Data.Job{}).
+			Where("id = ? AND status = ? AND attempts = ?", job.ID, job.Status, job.Attempts).
+			Updates(map[string]interface{}{
+				"status":       JobRunning,
+				"attempts":     gorm.Expr("attempts + 1"),
+				"locked_until": lockedUntil,
+				"locked_by":    lockedBy,
+			})
+		if result.Error != nil {
+			return claimed, errors.New("error claiming job: " + result.Error.Error())
+		}
+		if result.RowsAffected == 1 {
+			job.Status = JobRunning
+			job.Attempts++
+			job.LockedUntil = lockedUntil
+			job.LockedBy = lockedBy
+			claimed = append(claimed, job)
+		}
+	}
+	return claimed, nil
+}
+
+func (d *DatabaseHelperImpl) CompleteJob(job Data.Job, result string) error {
+	return d.finishJob(job, map[string]interface{}{
+		"status":       JobDone,
+		"result":       result,
+		"last_error":   "",
+		"locked_until": 0,
+		"finished_at":  time.Now().Unix(),
+	})
+}
+
+// RetryJobLater puts a failed job back in the queue to run again at runAt
+func (d *DatabaseHelperImpl) RetryJobLater(job Data.Job, runAt int64, lastError string) error {
+	return d.finishJob(job, map[string]interface{}{
+		"status":       JobPending,
+		"run_at":       runAt,
+		"last_error":   lastError,
+		"locked_until": 0,
+	})
+}
+
+// ReleaseJob puts a job that was interrupted by a shutdown back in the
+// queue without counting the attempt
+func (d *DatabaseHelperImpl) ReleaseJob(job Data.Job) error {
+	return d.finishJob(job, map[string]interface{}{
+		"status":       JobPending,
+		"attempts":     gorm.Expr("attempts - 1"),
+		"locked_until": 0,
+	})
+}
+
+// KillJob moves a job that can't succeed to the dead letters
+func (d *DatabaseHelperImpl) KillJob(job Data.Job, lastError string) error {
+	return d.finishJob(job, map[string]interface{}{
+		"status":       JobDead,
+		"last_error":   lastError,
+		"locked_until": 0,
+		"finished_at":  time.Now().Unix(),
+	})
+}
+
+// finishJob updates a claimed job only while job's claim on it still holds
+func (d *DatabaseHelperImpl) finishJob(job Data.Job, updates map[string]interface{}) error {
+	result := d.db.Model(&Data.Job{}).
+		Where("id = ? AND status = ? AND locked_by = ? AND locked_until = ?", job.ID, JobRunning, job.LockedBy, job.LockedUntil).
+		Updates(updates)
+	if result.Error != nil {
+		return errors.New("error updating job: " + result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return ErrJobLeaseLost
+	}
+	return nil
+}
+
+func (d *DatabaseHelperImpl) GetJob(jobID uint) (Data.Job, error) {
+	var job Data.Job
+	if err := d.db.First(&job, jobID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return job, errors.New("job not found")
+		}
+		return job, errors.New("error retrieving job: " + err.Error())
+	}
+	return job, nil
+}
+
+// GetJobsByStatus lists jobs newest first, jobType narrows it to one type
+// when it isn't empty
+func (d *DatabaseHelperImpl) GetJobsByStatus(status, jobType string, limit, offset int) ([]Data.Job, bool, error) {
+	query := d.db.Where("status = ?", status)
+	if jobType != "" {
+		query = query.Where("type = ?", jobType)
+	}
+
+	var jobs []Data.Job
+	// one extra row tells us whether there is another page
+	if err := query.Order("id DESC").Limit(limit + 1).Offset(offset).Find(&jobs).Error; err != nil {
+		return nil, false, errors.New("error retrieving jobs: " + err.Error())
+	}
+
+	hasMore := len(jobs) > limit
+	if hasMore {
+		jobs = jobs[:limit]
+	}
+	return jobs, hasMore, nil
+}
+
+// RequeueDeadJob gives a dead job a fresh set of attempts, starting now
+func (d *DatabaseHelperImpl) RequeueDeadJob(jobID uint) error {
+	result := d.db.Model(&Data.Job{}).
+		Where("id = ? AND status = ?", jobID, JobDead).
+		Updates(map[string]interface{}{
+			"status":      JobPending,
+			"attempts":    0,
+			"run_at":      time.Now().Unix(),
+			"finished_at": 0,
+		})
+	if result.Error != nil {
+		return errors.New("error updating job: " + result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("job not found")
+	}
+	return nil
+}
@@ -0,0 +1,295 @@
+package dbHelpFunc
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	Data "business-connect/models"
+)
+
+// upload session statuses, a confirming session is being processed
+const (
+	UploadPending    = "pending"
+	UploadConfirming = "confirming"
+	UploadConfirmed  = "confirmed"
+	UploadFailed     = "failed"
+)
+
+// ErrSessionNotInState is returned by MoveUploadSession when the session
+// has already moved on from the status it was to be moved from
+var ErrSessionNotInState = errors.New("upload session is no longer in that status")
+
+// MediaRepo covers direct upload sessions and the record of stored images
+// the sweeper deletes unused ones from
+type MediaRepo interface {
+	CreateUploadSession(session *Data.UploadSession) error
+	GetUploadSession(token string, userID uint) (Data.UploadSession, error)
+	MoveUploadSession(sessionID uint, from, to string, now int64) error
+	GetExpiredUploadSessions(before int64, limit int) ([]Data.UploadSession, error)
+	DeleteUploadSession(sessionID uint) error
+
+	TrackMedia(object *Data.MediaObject) error
+	GetUntrackedMedia(limit int) ([]Data.MediaObject, error)
+	ScheduleMediaDeletes(urls []string, after int64) error
+	SchedulePostMediaDeletes(postID uint, after int64) error
+	ScheduleBlogMediaDeletes(blogID uint, after int64) error
+	GetMediaToCheck(createdBefore time.Time, checkedBefore int64, afterID uint, limit int) ([]Data.MediaObject, error)
+	GetDueMediaDeletes(now int64, afterID uint, limit int) ([]Data.MediaObject, error)
+	ReferencedMedia(urls []string) ([]string, error)
+	SetMediaSchedule(objectIDs []uint, deleteAfter, checkedAt int64) error
+	DeleteMediaObject(objectID uint) error
+}
+
+func (d *DatabaseHelperImpl) CreateUploadSession(session *Data.UploadSession) error {
+	if err := d.db.Create(session).Error; err != nil {
+		return errors.New("error saving upload session: " + err.Error())
+	}
+	return nil
+}
+
+// GetUploadSession finds a session by its token, only for the user who
+// started it
+func (d *DatabaseHelperImpl) GetUploadSession(token string, userID uint) (Data.UploadSession, error) {
+	var session Data.UploadSession
+	err := d.db.Where("token = ? AND user_id = ?", token, userID).First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Data.UploadSession{}, errors.New("upload session not found")
+		}
+		return Data.UploadSession{}, errors.New("error retrieving upload session: " + err.Error())
+	}
+	return session, nil
+}
+
+// MoveUploadSession changes a session's status only if it is still from,
+// so two confirms of one session never both process it. Moving to
+// confirmed records when.
+func (d *DatabaseHelperImpl) MoveUploadSession(sessionID uint, from, to string, now int64) error {
+	updates := map[string]interface{}{"status": to}
+	if to == UploadConfirmed {
+		updates["confirmed_at"] = now
+	}
+	result := d.db.Model(&Data.UploadSession{}).
+		Where("id = ? AND status = ?", sessionID, from).
+		Updates(updates)
+	if result.Error != nil {
+		return errors.New("error updating upload session: " + result.Error.Error())
+	}
+	if result.RowsAffected == 0 {
+		return ErrSessionNotInState
+	}
+	return nil
+}
+
+// GetExpiredUploadSessions returns sessions that expired before before,
+// confirmed or not
+func (d *DatabaseHelperImpl) GetExpiredUploadSessions(before int64, limit int) ([]Data.UploadSession, error) {
+	var sessions []Data.UploadSession
+	err := d.db.Where("expires_at < ?", before).Order("expires_at ASC").Limit(limit).Find(&sessions).Error
+	if err != nil {
+		return nil, errors.New("error retrieving expired upload sessions: " + err.Error())
+	}
+	return sessions, nil
+}
+
+func (d *DatabaseHelperImpl) DeleteUploadSession(sessionID uint) error {
+	if err := d.db.Unscoped().Delete(&Data.UploadSession{}, sessionID).Error; err != nil {
+		return errors.New("error deleting upload session: " + err.Error())
+	}
+	return nil
+}
+
+func (d *DatabaseHelperImpl) TrackMedia(object *Data.MediaObject) error {
+	if err := d.db.Create(object).Error; err != nil {
+		return errors.New("error saving media object: " + err.Error())
+	}
+	return nil
+}
+
+// GetUntrackedMedia returns a record for images of posts, blogs and
+// profiles stored before images were tracked, deleted rows included, so
+// the sweeper can find out whether they are still used. Records keep the
+// image row's creation time. URLs with a scheme are outside our storage
+// and never tracked.
+func (d *DatabaseHelperImpl) GetUntrackedMedia(limit int) ([]Data.MediaObject, error) {
+	type image struct {
+		URL       string
+		CreatedAt time.Time
+		Data.ImageVariants
+		PosterURL string
+	}
+	sources := []struct {
+		table  string
+		poster bool
+	}{
+		{"post_images", true},
+		{"blog_images", false},
+		{"profile_images", false},
+	}
+
+	var objects []Data.MediaObject
+	seen := make(map[string]bool)
+	for _, source := range sources {
+		if len(objects) >= limit {
+			break
+		}
+		columns := "t.url, t.created_at, t.width, t.height, t.full_webp_url, t.feed_url, t.feed_webp_url, t.thumb_url, t.thumb_webp_url, t.bytes"
+		if source.poster {
+			columns += ", t.poster_url"
+		}
+		var images []image
+		err := d.db.Table(source.table+" t").
+			Select(columns).
+			Joins("LEFT JOIN media_objects mo ON mo.url = t.url").
+			Where("mo.id IS NULL AND t.url <> '' AND t.url NOT LIKE ?", "%://%").
+			Order("t.id ASC").
+			Limit(limit - len(objects)).
+			Scan(&images).Error
+		if err != nil {
+			return nil, errors.New("error retrieving untracked media: " + err.Error())
+		}
+		for _, img := range images {
+			if seen[img.URL] {
+				continue
+			}
+			seen[img.URL] = true
+			keys := []string{img.URL}
+			for _, key := range []string{img.FullWebpURL, img.FeedURL, img.FeedWebpURL, img.ThumbURL, img.ThumbWebpURL, img.PosterURL} {
+				if key != "" && key != img.URL {
+					keys = append(keys, key)
+				}
+			}
+			object := Data.MediaObject{URL: img.URL, ObjectKeys: strings.Join(keys, "\n"), Bytes: img.Bytes}
+			object.CreatedAt = img.CreatedAt
+			objects = append(objects, object)
+		}
+	}
+	return objects, nil
+}
+
+// ScheduleMediaDeletes marks images nothing should use any more to be
+// deleted after after, one already scheduled keeps its earlier time
+func (d *DatabaseHelperImpl) ScheduleMediaDeletes(urls []string, after int64) error {
+	if len(urls) == 0 {
+		return nil
+	}
+	err := d.db.Model(&Data.MediaObject{}).
+		Where("url IN ? AND delete_after = 0", urls).
+		Update("delete_after", after).Error
+	if err != nil {
+		return errors.New("error scheduling media deletes: " + err.Error())
+	}
+	return nil
+}
+
+// SchedulePostMediaDeletes schedules the images of a deleted post
+func (d *DatabaseHelperImpl) SchedulePostMediaDeletes(postID uint, after int64) error {
+	images := d.db.Unscoped().Model(&Data.PostImage{}).Select("url").Where("post_id = ?", postID)
+	err := d.db.Model(&Data.MediaObject{}).
+		Where("url IN (?) AND delete_after = 0", images).
+		Update("delete_after", after).Error
+	if err != nil {
+		return errors.New("error scheduling media deletes: " + err.Error())
+	}
+	return nil
+}
+
+// ScheduleBlogMediaDeletes schedules the images of a deleted blog
+func (d *DatabaseHelperImpl) ScheduleBlogMediaDeletes(blogID uint, after int64) error {
+	images := d.db.Unscoped().Model(&Data.BlogImage{}).Select("url").Where("blog_id = ?", blogID)
+	err := d.db.Model(&Data.MediaObject{}).
+		Where("url IN (?) AND delete_after = 0", images).
+		Update("delete_after", after).Error
+	if err != nil {
+		return errors.New("error scheduling media deletes: " + err.Error())
+	}
+	return nil
+}
+
+// GetMediaToCheck returns images not scheduled for deletion, stored before
+// createdBefore and not checked since checkedBefore, in id order after
+// afterID
+func (d *DatabaseHelperImpl) GetMediaToCheck(createdBefore time.Time, checkedBefore int64, afterID uint, limit int) ([]Data.MediaObject, error) {
+	var objects []Data.MediaObject
+	err := d.db.
+		Where("delete_after = 0 AND created_at < ? AND checked_at < ? AND id > ?", createdBefore, checkedBefore, afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&objects).Error
+	if err != nil {
+		return nil, errors.New("error retrieving media objects: " + err.Error())
+	}
+	return objects, nil
+}
+
+// GetDueMediaDeletes returns images scheduled for deletion at or before
+// now, in id order after afterID
+func (d *DatabaseHelperImpl) GetDueMediaDeletes(now int64, afterID uint, limit int) ([]Data.MediaObject, error) {
+	var objects []Data.MediaObject
+	err := d.db.
+		Where("delete_after > 0 AND delete_after <= ? AND id > ?", now, afterID).
+		Order("id ASC").
+		Limit(limit).
+		Find(&objects).Error
+	if err != nil {
+		return nil, errors.New("error retrieving media deletes: " + err.Error())
+	}
+	return objects, nil
+}
+
+// ReferencedMedia returns which of urls are still used: by an image of a
+// live post or blog, as a user's current profile or cover photo or the copy
+// of it a post or group participant keeps, or by an order. Older profile
+// images are only history and don't count.
+func (d *DatabaseHelperImpl) ReferencedMedia(urls []string) ([]string, error) {
+	if len(urls) == 0 {
+		return nil, nil
+	}
+	// every column an image URL is kept in, with the rows that count
+	sources := []string{
+		"SELECT pi.url FROM post_images pi JOIN posts p ON p.id = pi.post_id AND p.deleted_at IS NULL WHERE pi.deleted_at IS NULL AND pi.url IN @urls",
+		"SELECT bi.url FROM blog_images bi JOIN blogs b ON b.id = bi.blog_id AND b.deleted_at IS NULL WHERE bi.deleted_at IS NULL AND bi.url IN @urls",
+		"SELECT image1 FROM blogs WHERE deleted_at IS NULL AND image1 IN @urls",
+		"SELECT image2 FROM blogs WHERE deleted_at IS NULL AND image2 IN @urls",
+		"SELECT image3 FROM blogs WHERE deleted_at IS NULL AND image3 IN @urls",
+		"SELECT profile_photo_url FROM users WHERE deleted_at IS NULL AND profile_photo_url IN @urls",
+		"SELECT cover_photo_url FROM users WHERE deleted_at IS NULL AND cover_photo_url IN @urls",
+		"SELECT profile_photo_url FROM posts WHERE deleted_at IS NULL AND profile_photo_url IN @urls",
+		"SELECT profile_photo_url FROM group_participants WHERE deleted_at IS NULL AND profile_photo_url IN @urls",
+		// orders are records, a deleted product still shows in them
+		"SELECT image1 FROM product_orders WHERE image1 IN @urls",
+		"SELECT image2 FROM product_orders WHERE image2 IN @urls",
+	}
+	var referenced []string
+	err := d.db.Raw(strings.Join(sources, " UNION "), sql.Named("urls", urls)).Scan(&referenced).Error
+	if err != nil {
+		return nil, errors.New("error checking media references: " + err.Error())
+	}
+	return referenced, nil
+}
+
+// SetMediaSchedule records the sweeper checked objectIDs at checkedAt and
+// when they are to be deleted, 0 to keep them
+func (d *DatabaseHelperImpl) SetMediaSchedule(objectIDs []uint, deleteAfter, checkedAt int64) error {
+	if len(objectIDs) == 0 {
+		return nil
+	}
+	err := d.db.Model(&Data.MediaObject{}).
+		Where("id IN ?", objectIDs).
+		Updates(map[string]interface{}{"delete_after": deleteAfter, "checked_at": checkedAt}).Error
+	if err != nil {
+		return errors.New("error updating media objects: " + err.Error())
+	}
+	return nil
+}
+
+func (d *DatabaseHelperImpl) DeleteMediaObject(objectID uint) error {
+	if err := d.db.Unscoped().Delete(&Data.MediaObject{}, objectID).Error; err != nil {
+		return errors.New("error deleting media object: " + err.Error())
+	}
+	return nil
+}
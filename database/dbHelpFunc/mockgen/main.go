@@ -0,0 +1,288 @@
+// Command mockgen writes a mock for every *Repo interface in the dbHelpFunc
+// package. Each mock is a struct with one func field per method, named after
+// the method with a Func suffix, so a caller only fills in the methods it
+// expects to be called:
+//
+//	repo := &mocks.UserRepoMock{
+//		FindByEmailFunc: func(email string) (Data.User, error) {
+//			return Data.User{Email: email}, nil
+//		},
+//	}
+//
+// Calling a method whose field is nil panics with the method name.
+//
+// It is run from the dbHelpFunc directory by go generate:
+//
+//	go generate ./database/dbHelpFunc
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/printer"
+	"go/token"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+const helperPackage = "business-connect/database/dbHelpFunc"
+
+type method struct {
+	name    string
+	params  []param
+	results string
+}
+
+type param struct {
+	name     string
+	typ      string
+	variadic bool
+}
+
+type repo struct {
+	name    string
+	file    string
+	methods []method
+}
+
+func main() {
+	out := flag.String("out", "mocks", "directory to write the mocks to")
+	flag.Parse()
+
+	fset := token.NewFileSet()
+	pkgs, err := parser.ParseDir(fset, ".", func(info os.FileInfo) bool {
+		return !strings.HasSuffix(info.Name(), "_test.go")
+	}, 0)
+	if err != nil {
+		log.Fatal(err)
+	}
+	pkg, ok := pkgs["dbHelpFunc"]
+	if !ok {
+		log.Fatal("mockgen must be run from the dbHelpFunc directory")
+	}
+
+	// exported types declared in dbHelpFunc need the package name in the mocks
+	localTypes := map[string]bool{}
+	for _, file := range pkg.Files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				name := spec.(*ast.TypeSpec).Name.Name
+				if ast.IsExported(name) {
+					localTypes[name] = true
+				}
+			}
+		}
+	}
+
+	var repos []repo
+	for fileName, file := range pkg.Files {
+		for _, decl := range file.Decls {
+			gen, ok := decl.(*ast.GenDecl)
+			if !ok || gen.Tok != token.TYPE {
+				continue
+			}
+			for _, spec := range gen.Specs {
+				typeSpec := spec.(*ast.TypeSpec)
+				iface, ok := typeSpec.Type.(*ast.InterfaceType)
+				if !ok || !strings.HasSuffix(typeSpec.Name.Name, "Repo") {
+					continue
+				}
+				repos = append(repos, repo{
+					name:    typeSpec.Name.Name,
+					file:    strings.TrimSuffix(filepath.Base(fileName), ".go"),
+					methods: methods(fset, iface, localTypes),
+				})
+			}
+		}
+	}
+	sort.Slice(repos, func(i, j int) bool { return repos[i].name < repos[j].name })
+
+	if err := os.MkdirAll(*out, 0o755); err != nil {
+		log.Fatal(err)
+	}
+	for _, r := range repos {
+		if err := write(filepath.Join(*out, r.file+".go"), mockFile(r)); err != nil {
+			log.Fatal(err)
+		}
+	}
+	if err := write(filepath.Join(*out, "databaseHelper.go"), helperFile(repos)); err != nil {
+		log.Fatal(err)
+	}
+}
+
+func methods(fset *token.FileSet, iface *ast.InterfaceType, localTypes map[string]bool) []method {
+	var list []method
+	for _, field := range iface.Methods.List {
+		fn, ok := field.Type.(*ast.FuncType)
+		if !ok {
+			log.Fatalf("embedded interfaces aren't supported in repos")
+		}
+
+		m := method{name: field.Names[0].Name}
+		i := 0
+		for _, p := range fn.Params.List {
+			typ := p.Type
+			variadic := false
+			if ellipsis, ok := typ.(*ast.Ellipsis); ok {
+				typ = ellipsis.Elt
+				variadic = true
+			}
+			names := p.Names
+			if len(names) == 0 {
+				names = []*ast.Ident{nil}
+			}
+			for _, name := range names {
+				// keep the interface's parameter names, they document the Func fields
+				paramName := fmt.Sprintf("p%d", i)
+				if name != nil && name.Name != "_" && name.Name != "m" {
+					paramName = name.Name
+				}
+				m.params = append(m.params, param{
+					name:     paramName,
+					typ:      qualify(node(fset, typ), localTypes),
+					variadic: variadic,
+				})
+				i++
+			}
+		}
+
+		if fn.Results != nil {
+			var results []string
+			for _, r := range fn.Results.List {
+				typ := qualify(node(fset, r.Type), localTypes)
+				n := len(r.Names)
+				if n == 0 {
+					n = 1
+				}
+				for j := 0; j < n; j++ {
+					results = append(results, typ)
+				}
+			}
+			m.results = strings.Join(results, ", ")
+			if len(results) > 1 {
+				m.results = "(" + m.results + ")"
+			}
+		}
+
+		list = append(list, m)
+	}
+	return list
+}
+
+func node(fset *token.FileSet, n ast.Node) string {
+	var buf bytes.Buffer
+	if err := printer.Fprint(&buf, fset, n); err != nil {
+		log.Fatal(err)
+	}
+	return buf.String()
+}
+
+var ident = regexp.MustCompile(`(^|[^.\w])([A-Z]\w*)`)
+
+func qualify(typ string, localTypes map[string]bool) string {
+	return ident.ReplaceAllStringFunc(typ, func(match string) string {
+		parts := ident.FindStringSubmatch(match)
+		if !localTypes[parts[2]] {
+			return match
+		}
+		return parts[1] + "dbHelpFunc." + parts[2]
+	})
+}
+
+func (m method) signature() string {
+	params := make([]string, len(m.params))
+	for i, p := range m.params {
+		if p.variadic {
+			params[i] = p.name + " ..." + p.typ
+		} else {
+			params[i] = p.name + " " + p.typ
+		}
+	}
+	return "(" + strings.Join(params, ", ") + ") " + m.results
+}
+
+func (m method) args() string {
+	args := make([]string, len(m.params))
+	for i, p := range m.params {
+		args[i] = p.name
+		if p.variadic {
+			args[i] += "..."
+		}
+	}
+	return strings.Join(args, ", ")
+}
+
+func header(buf *bytes.Buffer, body string) {
+	buf.WriteString("// Code generated by mockgen. DO NOT EDIT.\n\n")
+	buf.WriteString("package mocks\n\nimport (\n")
+	if strings.Contains(body, "time.") {
+		buf.WriteString("\t\"time\"\n\n")
+	}
+	if strings.Contains(body, "Data.") {
+		buf.WriteString("\tData \"business-connect/models\"\n")
+	}
+	buf.WriteString("\tdbHelpFunc \"" + helperPackage + "\"\n)\n\n")
+}
+
+func mockFile(r repo) []byte {
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "// %sMock is a dbHelpFunc.%s that calls the matching Func field\n", r.name, r.name)
+	fmt.Fprintf(&body, "type %sMock struct {\n", r.name)
+	for _, m := range r.methods {
+		fmt.Fprintf(&body, "\t%sFunc func%s\n", m.name, m.signature())
+	}
+	body.WriteString("}\n\n")
+	fmt.Fprintf(&body, "var _ dbHelpFunc.%s = (*%sMock)(nil)\n", r.name, r.name)
+
+	for _, m := range r.methods {
+		fmt.Fprintf(&body, "\nfunc (m *%sMock) %s%s {\n", r.name, m.name, m.signature())
+		fmt.Fprintf(&body, "\tif m.%sFunc == nil {\n", m.name)
+		fmt.Fprintf(&body, "\t\tpanic(\"mocks: %sMock.%s called but %sFunc is nil\")\n\t}\n", r.name, m.name, m.name)
+		if m.results == "" {
+			fmt.Fprintf(&body, "\tm.%sFunc(%s)\n}\n", m.name, m.args())
+		} else {
+			fmt.Fprintf(&body, "\treturn m.%sFunc(%s)\n}\n", m.name, m.args())
+		}
+	}
+
+	var buf bytes.Buffer
+	header(&buf, body.String())
+	buf.Write(body.Bytes())
+	return buf.Bytes()
+}
+
+func helperFile(repos []repo) []byte {
+	var body bytes.Buffer
+	body.WriteString("// DatabaseHelperMock is a dbHelpFunc.DatabaseHelper made of every repo mock,\n")
+	body.WriteString("// it can stand in for dbHelpFunc.DBHelper\n")
+	body.WriteString("type DatabaseHelperMock struct {\n")
+	for _, r := range repos {
+		fmt.Fprintf(&body, "\t%sMock\n", r.name)
+	}
+	body.WriteString("}\n\nvar _ dbHelpFunc.DatabaseHelper = (*DatabaseHelperMock)(nil)\n")
+
+	var buf bytes.Buffer
+	header(&buf, body.String())
+	buf.Write(body.Bytes())
+	return buf.Bytes()
+}
+
+func write(path string, src []byte) error {
+	formatted, err := format.Source(src)
+	if err != nil {
+		return fmt.Errorf("formatting %s: %w\n%s", path, err, src)
+	}
+	return os.WriteFile(path, formatted, 0o644)
+}
@@ -0,0 +1,24 @@
+// Code generated by mockgen. DO NOT EDIT.
+
+package mocks
+
+import (
+	dbHelpFunc "business-connect/database/dbHelpFunc"
+)
+
+// DatabaseHelperMock is a dbHelpFunc.DatabaseHelper made of every repo mock,
+// it can stand in for dbHelpFunc.DBHelper
+type DatabaseHelperMock struct {
+	AnalyticsRepoMock
+	BlogRepoMock
+	GeoRepoMock
+	GroupRepoMock
+	JobRepoMock
+	MediaRepoMock
+	OrderRepoMock
+	PostRepoMock
+	SearchRepoMock
+	UserRepoMock
+}
+
+var _ dbHelpFunc.DatabaseHelper = (*DatabaseHelperMock)(nil)
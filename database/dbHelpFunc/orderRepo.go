@@ -0,0 +1,375 @@
+package dbHelpFunc
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	Data "business-connect/models"
+)
+
+// OrderRepo covers orders, their status and the shipping fees
+type OrderRepo interface {
+	SearchAdminOrderByTitle(searchTerm string) ([]Data.OrderHistory, error)
+	AddOrder(orderHistoryBody Data.OrderHistoryBody, ordersBody []Data.ProductOrderBody) (uint, *Data.OrderHistory, []Data.ProductOrder, error)
+	// UpsertShippingFee(fee int64, feesGreater, feesLess int64) (error)
+	UpsertShippingFee(fee int64, feesGreater, feesLess int64, storeLatitude, storeLongitude float64, storeState,
+		storeCity, stateISO string, calculateUsingKg bool) error
+	GetShippingFee() (Data.ShippingFees, error)
+	GetOrder(orderID uint) (*Data.OrderHistory, error)
+	GetAndUpdateOrder(orderID uint, status string) (*Data.OrderHistory, error)
+	GetBusinessConnectOrdersByLimit(limit, offset int) ([]Data.OrderHistory, int64, error)
+	UpdateOrderStatus(orderID uint, newStatus string) error
+}
+
+func (d *DatabaseHelperImpl) SearchAdminOrderByTitle(searchTerm string) ([]Data.OrderHistory, error) {
+	var results []Data.OrderHistory
+
+	query := d.db.Preload("ProductOrders").Model(&Data.OrderHistory{}).
+		Where("deleted_at IS NULL")
+
+	if searchTerm != "" {
+		slog.Debug("searching orders", "term", searchTerm)
+
+		// Try to convert searchTerm to an integer to check if it's an ID
+		if id, err := strconv.Atoi(searchTerm); err == nil {
+			// Search by ID
+			query = query.Where("id = ?", id)
+		} else {
+			// Search by title (case-insensitive)
+			query = query.Where("LOWER(customer_email) LIKE ?", "%"+strings.ToLower(searchTerm)+"%")
+		}
+
+		query = query.Limit(20)
+	}
+
+	err := query.Find(&results).Error
+	if err != nil {
+		return nil, fmt.Errorf("error searching for products: %w", err)
+	}
+
+	return results, nil
+}
+
+func ConvertToOrderHistory(orderHistoryBody Data.OrderHistoryBody) *Data.OrderHistory {
+	return &Data.OrderHistory{
+		OrderStatus:            orderHistoryBody.OrderStatus,
+		PaymentStatus:          "pending", // Default to pending
+		Quantity:               orderHistoryBody.Quantity,
+		OrderCost:              orderHistoryBody.OrderCost,
+		OrderNote:              orderHistoryBody.OrderNote,
+		OrderSubTotalCost:      orderHistoryBody.OrderSubTotalCost,
+		ShippingCost:           orderHistoryBody.ShippingCost,
+		OrderDiscount:          orderHistoryBody.OrderDiscount,
+		CustomerEmail:          orderHistoryBody.CustomerEmail,
+		CustomerFName:          orderHistoryBody.CustomerFName,
+		CustomerSName:          orderHistoryBody.CustomerSName,
+		CustomerCompanyName:    orderHistoryBody.CustomerCompanyName,
+		CustomerState:          orderHistoryBody.CustomerState,
+		CustomerCity:           orderHistoryBody.CustomerCity,
+		CustomerStreetAddress1: orderHistoryBody.CustomerStreetAddress1,
+		CustomerStreetAddress2: orderHistoryBody.CustomerStreetAddress2,
+		CustomerZipCode:        orderHistoryBody.CustomerZipCode,
+		CustomerProvince:       orderHistoryBody.CustomerProvince,
+		CustomerPhoneNumber:    orderHistoryBody.CustomerPhoneNumber,
+	}
+}
+
+func ConvertToProductOrders(orderBodies []Data.ProductOrderBody) []Data.ProductOrder {
+	var productOrders []Data.ProductOrder
+	for _, body := range orderBodies {
+		productOrders = append(productOrders, Data.ProductOrder{
+			ProducttID:   body.ID,
+			ProductUrlID: body.ProductUrlID,
+			Title:        body.Title,
+			Description:  body.Description,
+			NetWeight:    body.NetWeight,
+			OrderCost:    body.OrderCost,
+			Currency:     body.Currency,
+			Quantity:     body.Quantity,
+			Category:     body.Category,
+			Image1:       body.Image1,
+			Image2:       body.Image2,
+		})
+	}
+	return productOrders
+}
+
+func (d *DatabaseHelperImpl) AddOrder(orderHistoryBody Data.OrderHistoryBody, ordersBody []Data.ProductOrderBody) (uint, *Data.OrderHistory, []Data.ProductOrder, error) {
+	orderHistory := ConvertToOrderHistory(orderHistoryBody)
+	productOrders := ConvertToProductOrders(ordersBody)
+
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		// 1. Create the order history record
+		if err := tx.Create(&orderHistory).Error; err != nil {
+			return err
+		}
+
+		// 2. Track totals
+		var totalProducts int64
+		for i := range productOrders {
+			productOrders[i].OrderHistoryID = orderHistory.ID
+
+			var product Data.Post
+			if err := tx.Where("product_url_id = ?", ordersBody[i].ProductUrlID).First(&product).Error; err != nil {
+				return err
+			}
+
+			// if product.StockRemaining < ordersBody[i].Quantity {
+			// 	return fmt.Errorf("insufficient stock for product: %s", product.Title)
+			// }
+
+			// product.StockRemaining -= ordersBody[i].Quantity
+			// product.Sales += ordersBody[i].Quantity
+
+			totalProducts += int64(ordersBody[i].Quantity)
+
+			if err := tx.Save(&product).Error; err != nil {
+				return err
+			}
+		}
+
+		// 3. Save product orders
+		if err := tx.Create(&productOrders).Error; err != nil {
+			return err
+		}
+
+		// 4. Update analytics
+		now := time.Now()
+		currentMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+		var analytics Data.Analytics
+		var prevAnalytics Data.Analytics
+
+		// Find current month's analytics
+		if err := tx.Where("month = ?", currentMonth).First(&analytics).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				analytics = Data.Analytics{
+					Month:          currentMonth,
+					TotalRevenue:   0,
+					TotalSales:     0,
+					TotalCustomers: 0,
+					TotalProducts:  0,
+				}
+			} else {
+				return err
+			}
+		}
+
+		// Get previous month
+		prevMonth := currentMonth.AddDate(0, -1, 0)
+		tx.Where("month = ?", prevMonth).First(&prevAnalytics)
+
+		// Calculate analytics
+		analytics.TotalRevenue += orderHistory.OrderCost
+		analytics.TotalSales += 1
+		analytics.TotalCustomers += 1 // You can deduplicate by customer email if needed
+		analytics.TotalProducts += totalProducts
+
+		if prevAnalytics.TotalRevenue > 0 {
+			analytics.RevenueChange = ((analytics.TotalRevenue - prevAnalytics.TotalRevenue) / prevAnalytics.TotalRevenue) * 100
+			analytics.IsRevenueBetter = analytics.TotalRevenue > prevAnalytics.TotalRevenue
+		}
+		if prevAnalytics.TotalSales > 0 {
+			analytics.SalesChange = float64(analytics.TotalSales-prevAnalytics.TotalSales) / float64(prevAnalytics.TotalSales) * 100
+			analytics.IsSalesBetter = analytics.TotalSales > prevAnalytics.TotalSales
+		}
+		if prevAnalytics.TotalCustomers > 0 {
+			analytics.CustomerChange = float64(analytics.TotalCustomers-prevAnalytics.TotalCustomers) / float64(prevAnalytics.TotalCustomers) * 100
+			analytics.IsCustomersBetter = analytics.TotalCustomers > prevAnalytics.TotalCustomers
+		}
+		if prevAnalytics.TotalProducts > 0 {
+			analytics.ProductChange = float64(analytics.TotalProducts-prevAnalytics.TotalProducts) / float64(prevAnalytics.TotalProducts) * 100
+			analytics.IsProductsBetter = analytics.TotalProducts > prevAnalytics.TotalProducts
+		}
+
+		// Upsert analytics
+		if analytics.ID == 0 {
+			if err := tx.Create(&analytics).Error; err != nil {
+				return err
+			}
+		} else {
+			if err := tx.Save(&analytics).Error; err != nil {
+				return err
+			}
+		}
+
+		return nil
+	})
+
+	if err != nil {
+		return 0, nil, nil, errors.New("failed to create order and update analytics: " + err.Error())
+	}
+
+	return orderHistory.ID, orderHistory, productOrders, nil
+}
+
+func (d *DatabaseHelperImpl) UpsertShippingFee(fee int64, feesGreater, feesLess int64, storeLatitude, storeLongitude float64,
+	storeState, storeCity, stateISO string, calculateUsingKg bool) error {
+	var shippingFee Data.ShippingFees
+
+	// Try to find the existing shipping fee
+	result := d.db.First(&shippingFee)
+	if result.Error != nil && result.Error == gorm.ErrRecordNotFound {
+		// Record not found, create a new one
+		shippingFee := Data.ShippingFees{
+			ShippingFeePerKm:   fee,
+			ShippingFeeGreater: feesGreater,
+			ShippingFeeLess:    feesLess,
+			StoreLatitude:      storeLatitude,
+			StoreLongitude:     storeLongitude,
+			StoreState:         storeState,
+			StoreCity:          storeCity,
+			StateISO:           stateISO,
+			CalculateUsingKg:   calculateUsingKg,
+		}
+		result = d.db.Create(&shippingFee)
+	} else if result.Error != nil {
+		return result.Error
+	} else {
+		// Record found, update the existing one
+		shippingFee.ShippingFeePerKm = fee
+		shippingFee.ShippingFeePerKm = fee
+		shippingFee.ShippingFeeGreater = feesGreater
+		shippingFee.ShippingFeeLess = feesLess
+		shippingFee.StoreLatitude = storeLatitude
+		shippingFee.StoreLongitude = storeLongitude
+		shippingFee.StoreState = storeState
+		shippingFee.StoreCity = storeCity
+		shippingFee.StateISO = stateISO
+		shippingFee.CalculateUsingKg = calculateUsingKg
+		result = d.db.Save(&shippingFee)
+	}
+
+	return result.Error
+}
+
+func (d *DatabaseHelperImpl) GetShippingFee() (Data.ShippingFees, error) {
+	var shippingFee Data.ShippingFees
+	result := d.db.First(&shippingFee) // Retrieves the first entry
+	if result.Error != nil {
+		return Data.ShippingFees{}, result.Error
+	}
+	return shippingFee, nil
+}
+
+func (d *DatabaseHelperImpl) GetOrder(orderID uint) (*Data.OrderHistory, error) {
+	// Initialize variables
+	var orderHistory Data.OrderHistory
+
+	// Find the order history by ID and preload the associated ProductOrders
+	if err := d.db.Preload("ProductOrders").First(&orderHistory, orderID).Error; err != nil {
+		return nil, errors.New("failed to find order history: " + err.Error())
+	}
+
+	// Return the orderHistory and the associated productOrders
+	return &orderHistory, nil
+}
+
+func (d *DatabaseHelperImpl) GetAndUpdateOrder(orderID uint, status string) (*Data.OrderHistory, error) {
+	// Initialize variable
+	var orderHistory Data.OrderHistory
+
+	// Find the order with preloaded ProductOrders
+	if err := d.db.Preload("ProductOrders").First(&orderHistory, orderID).Error; err != nil {
+		return nil, errors.New("failed to find order history: " + err.Error())
+	}
+
+	// Update the payment status to "Successful"
+	orderHistory.PaymentStatus = status
+
+	// Save the update to the database
+	if err := d.db.Save(&orderHistory).Error; err != nil {
+		return nil, errors.New("failed to update payment status: " + err.Error())
+	}
+
+	return &orderHistory, nil
+}
+
+func (d *DatabaseHelperImpl) GetBusinessConnectOrdersByLimit(limit, offset int) ([]Data.OrderHistory, int64, error) {
+	var orderRecords []Data.OrderHistory
+	var orderRecordsCount int64
+
+	// Get the count of order records
+	if err := d.db.Model(&Data.OrderHistory{}).Count(&orderRecordsCount).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Retrieve order history with pagination
+	if err := d.db.Preload("ProductOrders").Order("created_at desc").Limit(limit).Offset(offset).Find(&orderRecords).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// No records found
+			return nil, 0, errors.New("no order records found")
+		}
+		// Some other error occurred
+		return nil, 0, errors.New("error retrieving order records: " + err.Error())
+	}
+
+	return orderRecords, orderRecordsCount, nil
+}
+
+func (d *DatabaseHelperImpl) UpdateProductSalesByOrderHistory(orderHistoryID uint) error {
+	var orderHistory Data.OrderHistory
+
+	// Step 1: Retrieve OrderHistory with associated ProductOrders
+	if err := d.db.Preload("ProductOrders").First(&orderHistory, orderHistoryID).Error; err != nil {
+		return fmt.Errorf("error retrieving order history: %v", err)
+	}
+
+	// Step 2: Iterate over each ProductOrder
+	for _, productOrder := range orderHistory.ProductOrders {
+		var product Data.Post
+
+		// Step 3: Retrieve the Post by ProducttID from ProductOrder
+		if err := d.db.First(&product, productOrder.ProducttID).Error; err != nil {
+			return fmt.Errorf("error retrieving product: %v", err)
+		}
+
+		// Step 4: Update the Sales count (increment by ProductOrder.Quantity)
+		// product.Sales += productOrder.Quantity
+
+		// Save the updated Post
+		if err := d.db.Save(&product).Error; err != nil {
+			return fmt.Errorf("error updating product sales: %v", err)
+		}
+	}
+
+	return nil
+}
+
+func (d *DatabaseHelperImpl) UpdateOrderStatus(orderID uint, newStatus string) error {
+	// Assuming your GORM model for the order history is named OrderHistory
+	// Update only the OrderStatus field where the Order ID matches
+	result := d.db.Model(&Data.OrderHistory{}).Where("id = ?", orderID).Updates(map[string]interface{}{
+		"order_status": newStatus,
+	})
+
+	// Check for errors
+	if result.Error != nil {
+		return result.Error
+	}
+
+	if newStatus == "shipped" {
+		// Update product sales
+		updateErr := d.UpdateProductSalesByOrderHistory(orderID)
+		if updateErr != nil {
+			return updateErr
+		}
+
+		// Update monthly analytics
+		if _, err := d.CalculateMonthlyAnalytics(); err != nil {
+			return fmt.Errorf("failed to update monthly analytics: %v", err)
+		}
+	}
+
+	// Check if any rows were affected (i.e., updated)
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("no record found with the given order ID")
+	}
+
+	return nil
+}
@@ -0,0 +1,918 @@
+package dbHelpFunc
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+
+	Data "business-connect/models"
+)
+
+// PostRepo covers posts and products: the feeds, product pages, search and
+// creating, editing and deleting posts
+type PostRepo interface {
+	CheckIfTransactionIDExist(ID uint) (err error)
+	GetStatusPostsByLimit(limit, offset int) ([]Data.Post, bool, error)
+	GetBusinessConnectProductsByLimit(limit, offset int) ([]Data.Post, bool, error)
+	GetBusinessConnectProductsByLimitOpen(limit, offset int) ([]Data.Post, bool, error)
+	GetBusinessConnectProductsByLimit2( /*userID uint64, */ fingerprintHash string, limit, offset int) ([]Data.Post, int64, error)
+	GetBusinessConnectAdminProductsByLimit( /*userID uint64, */ limit, offset int) ([]Data.Post, int64, error)
+	// GetBusinessConnectRecommendedProductsByLimit( /*userID uint64, */ category string, limit int) ([]Data.Post, int64, error)
+	GetBusinessConnectRecommendedProductsByLimit(currentProductID uint64, category string, limit int) ([]Data.Post, int64, error)
+	GetBusinessConnectHomeAllProductsByLimit(limit int) ([]Data.Post, error)
+	GetBusinessConnectHomeFeaturedProductsByLimit(limit int) ([]Data.Post, error)
+	GetBusinessConnectHomeBestSellingProductsByLimit(limit int) ([]Data.Post, error)
+	GetBusinessConnectHomeOnSaleProductsByLimit(limit int) ([]Data.Post, error)
+	GetBusinessConnectProductsByIDs(productIDs []uint64) ([]Data.Post, error)
+	GetBusinessConnectProductByID(productID uint64) (Data.Post, error)
+	GetProductByID(productID uint) (Data.Post, error)
+	GetNextProductID(currentID uint64) (uint64, error)
+	GetPreviousProductID(currentID uint64) (uint64, error)
+	SearchProductsByTitle(searchTerm string) ([]struct {
+		Title        string `json:"title"`
+		ProductUrlID string `json:"product_url_id"`
+		Category     string `json:"category"`
+	}, error)
+	SearchProductsByTitleAndCategory(searchTerm string, categorySlug string) ([]Data.ProductSearchResponse, error)
+	SearchAdminProductsByTitle(searchTerm string) ([]Data.Post, error)
+	GetTransactionsByUserAndDateWithLimit(userID uint, dateString string) ([]Data.Post, error)
+	GetTransactionHistoryForAi(userID uint64, limit int) ([]Data.Post, int64, error)
+	AddProduct(post Data.Post, user Data.User) (Data.Post, error)
+	AddProductImage(image Data.PostImage, postID uint) error
+	CountProductImages(postID uint) (int64, error)
+	CountProductVideos(postID uint) (int64, error)
+	UpdateBusinessConnectProduct(Post Data.Post, ProductID uint) error
+	DeleteBusinessConnectProduct(ProductID uint) error
+}
+
+const (
+	PostTypePersonal = "personal"
+	PostTypeBusiness = "business"
+	PostTypeGroup    = "group"
+	PostTypeEvent    = "event"
+	PostTypeAd       = "ad"
+	// 24 hour posts, GetStatusPostsByLimit serves them
+	PostTypeStatus = "status"
+
+	// what a post's media row holds
+	MediaImage = "image"
+	MediaVideo = "video"
+
+	EntryFree = "free"
+	EntryPaid = "paid"
+)
+
+// postRankOrder puts sponsored posts first and then the ones people engage
+// with most
+const postRankOrder = "is_sponsored DESC, clicks * 1000 + views DESC"
+
+// publishedPosts limits a query to the posts the public pages show
+func publishedPosts(db *gorm.DB) *gorm.DB {
+	return db.Where("is_active = ? AND approved = ?", true, true)
+}
+
+func (d *DatabaseHelperImpl) CheckIfTransactionIDExist(ID uint) (err error) {
+	var Details Data.Post
+	result := d.db.First(&Details, "ID = ?", ID)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// The record with the specified email was not found
+			return errors.New("user not found")
+		} else {
+			// Some other error occurred
+			return errors.New("error retrieving user")
+		}
+	}
+
+	return nil
+}
+
+func (d *DatabaseHelperImpl) GetBusinessConnectProductsByLimit(
+	limit, offset int,
+) ([]Data.Post, bool, error) {
+
+	var posts []Data.Post
+
+	allowedPostTypes := []string{
+		PostTypePersonal,
+		PostTypeBusiness,
+		PostTypeGroup,
+		PostTypeEvent,
+		PostTypeAd,
+	}
+
+	result := d.db.
+		Model(&Data.Post{}).
+		Preload("Images").
+		Where(`
+			is_active = ? 
+			AND approved = ? 
+			AND post_type IN ?
+		`, true, true, allowedPostTypes).
+		Order("created_at DESC").
+		Limit(limit + 1).
+		Find(&posts)
+
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+
+	hasMore := false
+	if len(posts) > limit {
+		hasMore = true
+		posts = posts[:limit]
+	}
+
+	return posts, hasMore, nil
+}
+
+func (d *DatabaseHelperImpl) GetBusinessConnectProductsByLimitOpen(
+	limit, offset int,
+) ([]Data.Post, bool, error) {
+
+	var posts []Data.Post
+
+	allowedPostTypes := []string{
+		PostTypePersonal,
+		PostTypeBusiness,
+		PostTypeGroup,
+		PostTypeEvent,
+		PostTypeAd,
+	}
+
+	result := d.db.
+		Model(&Data.Post{}).
+		Preload("Images").
+		Where(`
+			is_active = ? 
+			AND approved = ? 
+			AND post_type IN ?
+		`, true, true, allowedPostTypes).
+		Order("created_at DESC").
+		Limit(limit + 1).
+		Find(&posts)
+
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+
+	hasMore := false
+	if len(posts) > limit {
+		hasMore = true
+		posts = posts[:limit]
+	}
+
+	return posts, hasMore, nil
+}
+
+func (d *DatabaseHelperImpl) GetStatusPostsByLimit(
+	limit, offset int,
+) ([]Data.Post, bool, error) {
+
+	var posts []Data.Post
+
+	// Calculate time 24 hours ago
+	twentyFourHoursAgo := time.Now().Add(-24 * time.Hour)
+
+	result := d.db.
+		Model(&Data.Post{}).
+		Preload("Images").
+		Where(`
+			is_active = ?
+			AND approved = ?
+			AND post_type = ?
+			AND created_at >= ?
+		`, true, true, PostTypeStatus, twentyFourHoursAgo).
+		Order("created_at DESC").
+		Limit(limit + 1).
+		Offset(offset).
+		Find(&posts)
+
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+
+	hasMore := false
+	if len(posts) > limit {
+		hasMore = true
+		posts = posts[:limit]
+	}
+
+	return posts, hasMore, nil
+}
+
+func (d *DatabaseHelperImpl) GetBusinessConnectProductsByLimit2( /*userID uint64, */ fingerprintHash string, limit, offset int) ([]Data.Post, int64, error) {
+	var productRecords []Data.Post
+	var productRecordsCount int64
+
+	// Get the count of transaction records for the user
+	if err := d.db.Model(&Data.Post{}).Count(&productRecordsCount).Error; err != nil {
+		return []Data.Post{}, 0, err
+	}
+
+	productRecords, productErr := d.RecommendProductsForUser(fingerprintHash, limit, offset)
+	if productErr != nil {
+		// fmt.Println("product returned error: ", productErr)
+		// Retrieve transaction history for the user with pagination
+		if productRecordsErr := d.db. /*Where("user_id = ?", userID).*/ Order("created_at desc").Limit(limit).Offset(offset).Find(&productRecords).Error; productRecordsErr != nil {
+			if errors.Is(productRecordsErr, gorm.ErrRecordNotFound) {
+				// The record with the specified UserID was not found
+				return []Data.Post{}, 0, errors.New("no transaction record found")
+			} else {
+				// Some other error occurred
+				return []Data.Post{}, 0, errors.New("error retrieving transaction record")
+			}
+		}
+	}
+	// fmt.Println("product did not return an error: ", productErr)
+
+	return productRecords, productRecordsCount, nil
+}
+
+func (d *DatabaseHelperImpl) GetBusinessConnectAdminProductsByLimit( /*userID uint64, */ limit, offset int) ([]Data.Post, int64, error) {
+	var productRecords []Data.Post
+	var productRecordsCount int64
+
+	// Get the count of transaction records for the user
+	if err := d.db.Model(&Data.Post{}).Count(&productRecordsCount).Error; err != nil {
+		return []Data.Post{}, 0, err
+	}
+
+	// Retrieve transaction history for the user with pagination
+	if productRecordsErr := d.db. /*Where("user_id = ?", userID).*/ Order("created_at desc").Limit(limit).Offset(offset).Find(&productRecords).Error; productRecordsErr != nil {
+		if errors.Is(productRecordsErr, gorm.ErrRecordNotFound) {
+			// The record with the specified UserID was not found
+			return []Data.Post{}, 0, errors.New("no transaction record found")
+		} else {
+			// Some other error occurred
+			return []Data.Post{}, 0, errors.New("error retrieving transaction record")
+		}
+	}
+
+	return productRecords, productRecordsCount, nil
+}
+
+// func (d *DatabaseHelperImpl) GetBusinessConnectRecommendedProductsByLimit( /*userID uint64, */ category string, limit int) ([]Data.Post, int64, error) {
+// 	var productRecords []Data.Post
+// 	var productRecordsCount int64
+
+// 	// Get the count of transaction records for the user
+// 	if err := d.db.Model(&Data.Post{}).Count(&productRecordsCount).Error; err != nil {
+// 		return []Data.Post{}, 0, err
+// 	}
+
+// 	// Retrieve transaction history for the user with pagination
+// 	if productRecordsErr := d.db. /*Where("user_id = ?", userID).*/ Where("business_category = ?", category).Order("created_at desc").Limit(limit).Find(&productRecords).Error; productRecordsErr != nil {
+// 		if errors.Is(productRecordsErr, gorm.ErrRecordNotFound) {
+// 			// The record with the specified UserID was not found
+// 			return []Data.Post{}, 0, errors.New("no transaction record found")
+// 		} else {
+// 			// Some other error occurred
+// 			return []Data.Post{}, 0, errors.New("error retrieving transaction record")
+// 		}
+// 	}
+
+// 	return productRecords, productRecordsCount, nil
+// }
+
+func (d *DatabaseHelperImpl) GetBusinessConnectRecommendedProductsByLimit(currentProductID uint64, category string, limit int) ([]Data.Post, int64, error) {
+	var productRecordsCount int64
+	var productRecords []Data.Post
+	// var fallbackProducts []Data.Post
+	// var latestProducts []Data.Post
+
+	// 1. Try to get related products in the same category, excluding current product
+	err := d.db.Model(&Data.Post{}).
+		Scopes(publishedPosts).
+		Where("business_category = ? AND id != ?", category, currentProductID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&productRecords).Error
+
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, 0, err
+	}
+
+	// 2. If less than the limit, try to fetch products from "related" categories
+	if len(productRecords) < limit {
+		remaining := limit - len(productRecords)
+
+		var fallbackProducts []Data.Post
+		// This is where you can add custom logic to find "related" categories — for now we do a fuzzy match
+		err = d.db.Model(&Data.Post{}).
+			Scopes(publishedPosts).
+			Where("LOWER(business_category) LIKE ? AND id != ?", "%"+strings.ToLower(category)+"%", currentProductID).
+			Where("id NOT IN (?)", getProductIDs(productRecords)).
+			Order("created_at DESC").
+			Limit(remaining).
+			Find(&fallbackProducts).Error
+
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, err
+		}
+
+		productRecords = append(productRecords, fallbackProducts...)
+	}
+
+	// 3. Final fallback: fill remaining with latest published products
+	if len(productRecords) < limit {
+		remaining := limit - len(productRecords)
+
+		var latestProducts []Data.Post
+		err = d.db.Model(&Data.Post{}).
+			Scopes(publishedPosts).
+			Where("id != ?", currentProductID).
+			Where("id NOT IN (?)", getProductIDs(productRecords)).
+			Order("created_at DESC").
+			Limit(remaining).
+			Find(&latestProducts).Error
+
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, 0, err
+		}
+
+		productRecords = append(productRecords, latestProducts...)
+	}
+
+	// Get the count for reference (optional)
+	if countErr := d.db.Model(&Data.Post{}).Scopes(publishedPosts).Count(&productRecordsCount).Error; countErr != nil {
+		return productRecords, 0, countErr
+	}
+
+	return productRecords, productRecordsCount, nil
+}
+
+// Helper to extract IDs from slice
+func getProductIDs(products []Data.Post) []uint64 {
+	ids := make([]uint64, 0, len(products))
+	for _, p := range products {
+		ids = append(ids, uint64(p.ID))
+	}
+	// Avoid empty slice in SQL "IN (?)" clause
+	if len(ids) == 0 {
+		return []uint64{0} // or -1 for signed IDs
+	}
+	return ids
+}
+
+func (d *DatabaseHelperImpl) GetBusinessConnectHomeAllProductsByLimit(limit int) ([]Data.Post, error) {
+	var productRecords []Data.Post
+
+	err := d.db.
+		Preload("Images").
+		Order("created_at desc").
+		Limit(limit).
+		Find(&productRecords).Error
+
+	if err != nil {
+		return []Data.Post{}, fmt.Errorf("error retrieving latest products: %w", err)
+	}
+
+	return productRecords, nil
+}
+
+func (d *DatabaseHelperImpl) GetBusinessConnectHomeFeaturedProductsByLimit(limit int) ([]Data.Post, error) {
+	var featured []Data.Post
+
+	err := d.db.
+		Preload("Images").
+		Where("is_sponsored = ?", true).
+		Order("created_at desc").
+		Limit(limit).
+		Find(&featured).Error
+
+	if err != nil {
+		return []Data.Post{}, fmt.Errorf("error retrieving featured products: %w", err)
+	}
+
+	remaining := limit - len(featured)
+	if remaining > 0 {
+		var fallback []Data.Post
+		fallbackErr := d.db.
+			Preload("Images").
+			Where("is_sponsored = ?", false).
+			Where("id NOT IN ?", getProductIDs(featured)).
+			Order("created_at desc").
+			Limit(remaining).
+			Find(&fallback).Error
+
+		if fallbackErr == nil {
+			featured = append(featured, fallback...)
+		}
+	}
+
+	return featured, nil
+}
+
+// posts don't record sales, clicks stand in for them
+func (d *DatabaseHelperImpl) GetBusinessConnectHomeBestSellingProductsByLimit(limit int) ([]Data.Post, error) {
+	var bestSelling []Data.Post
+
+	err := d.db.
+		Preload("Images").
+		Where("clicks > ?", 0).
+		Order("clicks desc").
+		Limit(limit).
+		Find(&bestSelling).Error
+
+	if err != nil {
+		return []Data.Post{}, fmt.Errorf("error retrieving best-selling products: %w", err)
+	}
+
+	remaining := limit - len(bestSelling)
+	if remaining > 0 {
+		var fallback []Data.Post
+		fallbackErr := d.db.
+			Preload("Images").
+			Where("clicks = ?", 0).
+			Where("id NOT IN ?", getProductIDs(bestSelling)).
+			Order("created_at desc").
+			Limit(remaining).
+			Find(&fallback).Error
+
+		if fallbackErr == nil {
+			bestSelling = append(bestSelling, fallback...)
+		}
+	}
+
+	return bestSelling, nil
+}
+
+// on sale means a business post with a price
+func (d *DatabaseHelperImpl) GetBusinessConnectHomeOnSaleProductsByLimit(limit int) ([]Data.Post, error) {
+	var onSale []Data.Post
+
+	err := d.db.
+		Preload("Images").
+		Where("post_type = ? AND product_price > ?", PostTypeBusiness, 0).
+		Order("created_at desc").
+		Limit(limit).
+		Find(&onSale).Error
+
+	if err != nil {
+		return []Data.Post{}, fmt.Errorf("error retrieving on-sale products: %w", err)
+	}
+
+	remaining := limit - len(onSale)
+	if remaining > 0 {
+		var fallback []Data.Post
+		fallbackErr := d.db.
+			Preload("Images").
+			Where("post_type != ? OR product_price = ?", PostTypeBusiness, 0).
+			Where("id NOT IN ?", getProductIDs(onSale)).
+			Order("created_at desc").
+			Limit(remaining).
+			Find(&fallback).Error
+
+		if fallbackErr == nil {
+			onSale = append(onSale, fallback...)
+		}
+	}
+
+	return onSale, nil
+}
+
+// Function to retrieve multiple products by their IDs
+func (d *DatabaseHelperImpl) GetBusinessConnectProductsByIDs(productIDs []uint64) ([]Data.Post, error) {
+	var products []Data.Post
+
+	// Fetch all products with IDs in the given slice
+	if err := d.db.Where("id IN ?", productIDs).Find(&products).Error; err != nil {
+		return nil, fmt.Errorf("error retrieving products: %v", err)
+	}
+
+	// Check if no products were found
+	if len(products) == 0 {
+		return nil, errors.New("no products found for the given IDs")
+	}
+
+	return products, nil
+}
+
+func (d *DatabaseHelperImpl) GetBusinessConnectProductByID(productID uint64) (Data.Post, error) {
+	var productRecord Data.Post
+
+	// Try to retrieve the product by its ID and preload a limited number of customer reviews
+	if err := d.db.Where("id = ?", productID).
+		Preload("Images").
+		First(&productRecord).Error; err != nil {
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Post not found, find the next closest available product ID
+			nextProductID, findErr := d.findClosestAvailableProductID(productID)
+			if findErr != nil {
+				return Data.Post{}, findErr
+			}
+
+			// Retrieve the product by the closest available ID and preload a limited number of customer reviews
+			if err := d.db.Where("id = ?", nextProductID.ID).
+				Preload("Images").
+				First(&productRecord).Error; err != nil {
+				return Data.Post{}, errors.New("error retrieving product record")
+			}
+		} else {
+			// Some other error occurred
+			return Data.Post{}, errors.New("error retrieving product record")
+		}
+	}
+
+	return productRecord, nil
+}
+
+func (d *DatabaseHelperImpl) GetBusinessConnectProductByIDd(productID uint64) (Data.Post, error) {
+	var productRecord Data.Post
+
+	// Try to retrieve the product by its ID and preload a limited number of customer reviews
+	if err := d.db.Where("id = ?", productID).
+		Preload("Images").
+		First(&productRecord).Error; err != nil {
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Post not found, find the next closest available product ID
+			return Data.Post{}, errors.New("error retrieving product record")
+		} else {
+			// Some other error occurred
+			return Data.Post{}, errors.New("error retrieving product record")
+		}
+	}
+
+	return productRecord, nil
+}
+
+func (d *DatabaseHelperImpl) GetProductByID(productID uint) (Data.Post, error) {
+	var product Data.Post
+	err := d.db.First(&product, productID).Error
+	return product, err
+}
+
+func (d *DatabaseHelperImpl) findClosestAvailableProductID(targetID uint64) (Data.Post, error) {
+	var closestProduct Data.Post
+
+	// Look for the next higher product ID and preload CustomerReviews
+	if err := d.db.Where("id > ?", targetID).
+		Order("id ASC").
+		Preload("Images").
+		Limit(1).
+		First(&closestProduct).Error; err != nil {
+
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// If no higher product ID is found, look for the closest lower ID
+			if err := d.db.Where("id < ?", targetID).
+				Order("id DESC").
+				Preload("Images").
+				Limit(1).
+				First(&closestProduct).Error; err != nil {
+				return Data.Post{}, errors.New("error finding closest lower product ID")
+			}
+		} else {
+			// Some other error occurred
+			return Data.Post{}, errors.New("error finding closest higher product ID")
+		}
+	}
+
+	// If no IDs are found, return an error
+	if closestProduct.ID == 0 {
+		return Data.Post{}, errors.New("no available product found")
+	}
+
+	return closestProduct, nil
+}
+
+func (d *DatabaseHelperImpl) GetNextProductID(currentID uint64) (uint64, error) {
+	var nextID uint64
+
+	// Query for the next higher ID
+	err := d.db.Model(&Data.Post{}).Where("id > ?", currentID).Order("id ASC").Limit(1).Pluck("id", &nextID).Error
+	if err != nil {
+		return 0, fmt.Errorf("error finding next product ID: %w", err)
+	}
+
+	// Return the next ID found, even if it's not a specific number
+	return nextID, nil
+}
+
+func (d *DatabaseHelperImpl) GetPreviousProductID(currentID uint64) (uint64, error) {
+	var previousID uint64
+
+	// Query for the previous lower ID
+	err := d.db.Model(&Data.Post{}).Where("id < ?", currentID).Order("id DESC").Limit(1).Pluck("id", &previousID).Error
+	if err != nil {
+		return 0, fmt.Errorf("error finding previous product ID: %w", err)
+	}
+
+	// Return the previous ID found, even if it's not a specific number
+	return previousID, nil
+}
+
+// SearchProductsByTitleAndCategory is the search box suggestions: the 7 best
+// matches, in the category unless it is empty or "all-categories"
+func (d *DatabaseHelperImpl) SearchProductsByTitleAndCategory(searchTerm string, categorySlug string) ([]Data.ProductSearchResponse, error) {
+	if strings.TrimSpace(searchTerm) == "" {
+		return []Data.ProductSearchResponse{}, nil
+	}
+	params := SearchParams{Text: searchTerm, Limit: 7}
+	if categorySlug != "" && categorySlug != "all-categories" {
+		params.Categories = []string{categorySlug}
+	}
+	found, err := d.SearchPosts(params)
+	if err != nil {
+		return nil, fmt.Errorf("error searching for products: %w", err)
+	}
+
+	results := make([]Data.ProductSearchResponse, 0, len(found.Posts))
+	for _, product := range found.Posts {
+		imageUrl := "https://via.placeholder.com/150x150?text=No+Image"
+		if len(product.Images) > 0 {
+			imageUrl = product.Images[0].URL
+		}
+		results = append(results, Data.ProductSearchResponse{
+			Title:        product.Title,
+			ProductUrlID: product.ProductUrlID,
+			Category:     product.BusinessCategory,
+			SellingPrice: float64(product.ProductPrice),
+			ImageUrl:     imageUrl,
+		})
+	}
+
+	slog.Debug("searched products", "term", searchTerm, "category", categorySlug, "results", len(results))
+	return results, nil
+}
+
+func (d *DatabaseHelperImpl) SearchProductsByTitle(searchTerm string) ([]struct {
+	Title        string `json:"title"`
+	ProductUrlID string `json:"product_url_id"`
+	Category     string `json:"category"`
+}, error) {
+	var results []struct {
+		Title        string `json:"title"`
+		ProductUrlID string `json:"product_url_id"`
+		Category     string `json:"category"`
+	}
+
+	if strings.TrimSpace(searchTerm) == "" {
+		return nil, fmt.Errorf("error product do not exist")
+	}
+	found, err := d.SearchPosts(SearchParams{Text: searchTerm, Limit: 20})
+	if err != nil {
+		return nil, fmt.Errorf("error searching for products: %w", err)
+	}
+	for _, product := range found.Posts {
+		result := struct {
+			Title        string `json:"title"`
+			ProductUrlID string `json:"product_url_id"`
+			Category     string `json:"category"`
+		}{Title: product.Title, ProductUrlID: product.ProductUrlID}
+		if product.BusinessCategory != nil {
+			result.Category = *product.BusinessCategory
+		}
+		results = append(results, result)
+	}
+
+	return results, nil
+}
+
+func (d *DatabaseHelperImpl) SearchAdminProductsByTitle(searchTerm string) ([]Data.Post, error) {
+	var results []Data.Post
+
+	query := d.db.Model(&Data.Post{}).
+		Scopes(publishedPosts)
+
+	if searchTerm != "" {
+		slog.Debug("searching products", "term", searchTerm)
+		query = query.Where("LOWER(title) LIKE ?", "%"+strings.ToLower(searchTerm)+"%").Limit(20)
+	}
+
+	err := query.Find(&results).Error
+	if err != nil {
+		return nil, fmt.Errorf("error searching for products: %w", err)
+	}
+
+	return results, nil
+}
+
+func (d *DatabaseHelperImpl) GetTransactionsByUserAndDateWithLimit(userID uint, dateString string) ([]Data.Post, error) {
+	// Parse the date string to a time.Time object
+	parsedDate, err := time.Parse("01/02/2006", dateString)
+	if err != nil {
+		return nil, err
+	}
+
+	// Format the parsed date to match the database date format
+	formattedDate := parsedDate.Format("2006-01-02")
+
+	// Query the database for transactions for the specified user and date with a limit of 10
+	var transactions []Data.Post
+	if err := d.db.Where("user_id = ? AND DATE(created_at) = ?", userID, formattedDate).Limit(10).Find(&transactions).Error; err != nil {
+		return nil, err
+	}
+
+	return transactions, nil
+}
+
+func (d *DatabaseHelperImpl) GetTransactionHistoryForAi(userID uint64, limit int) ([]Data.Post, int64, error) {
+	var history []Data.Post
+	var transactionCount int64
+
+	// Count total number of transactions for the user
+	if err := d.db.Model(&Data.Post{}).Where("user_id = ?", userID).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Retrieve transaction history for the user with pagination
+	if transactionErr := d.db.Where("user_id = ?", userID).Order("created_at desc").Limit(limit).Find(&history).Error; transactionErr != nil {
+		if errors.Is(transactionErr, gorm.ErrRecordNotFound) {
+			// The record with the specified UserID was not found
+			return []Data.Post{}, 0, errors.New("no transaction record found")
+		} else {
+			// Some other error occurred
+			return []Data.Post{}, 0, errors.New("error retrieving transaction record")
+		}
+	}
+
+	return history, transactionCount, nil
+}
+
+// GenerateProductURL generates a product URL from the product name and ID
+func GenerateProductURL(productName string, productID int) string {
+	// Convert product name to lowercase
+	productName = strings.ToLower(productName)
+
+	// Replace spaces and underscores with hyphens
+	productName = strings.ReplaceAll(productName, " ", "-")
+	productName = strings.ReplaceAll(productName, "_", "-")
+
+	// Remove any non-alphanumeric characters (except hyphens)
+	reg := regexp.MustCompile("[^a-zA-Z0-9-]+")
+	productName = reg.ReplaceAllString(productName, "")
+
+	// Construct the final URL with the product ID at the end
+	productURL := fmt.Sprintf("%s-%d", productName, productID)
+
+	return productURL
+}
+
+// AddProduct adds a product and updates its ProductUrlID
+func (d *DatabaseHelperImpl) AddProduct(post Data.Post, user Data.User) (Data.Post, error) {
+	// Create the product in the database
+	post.UserName = user.FullName
+	post.ProfilePhotoURL = user.ProfilePhotoURL
+	post.PhoneNumber = user.PhoneNumber
+	post.Verified = user.Verified
+	post.IsActive = true
+	post.Approved = true
+	if post.Location == nil || *post.Location == "" {
+		post.Location = &user.State
+	}
+	// a post without a place of its own is where its seller is
+	if (post.Latitude == nil || post.Longitude == nil) && (user.Latitude != 0 || user.Longitude != 0) {
+		lat, lng := user.Latitude, user.Longitude
+		post.Latitude, post.Longitude = &lat, &lng
+	}
+	result := d.db.Create(&post)
+
+	// Check if an error occurred when creating the product
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return Data.Post{}, fmt.Errorf("failed to create product: product not found, %w", result.Error)
+		} else if errors.Is(result.Error, gorm.ErrInvalidData) {
+			return Data.Post{}, fmt.Errorf("failed to create product: invalid data provided, %w", result.Error)
+		} else {
+			return Data.Post{}, fmt.Errorf("failed to create product: %w", result.Error)
+		}
+	}
+
+	// Generate the ProductUrlID
+	post.ProductUrlID = GenerateProductURL(post.Title, int(post.ID))
+
+	// Update the product with the new ProductUrlID
+	updateResult := d.db.Model(&post).Update("product_url_id", post.ProductUrlID)
+	if updateResult.Error != nil {
+		return Data.Post{}, fmt.Errorf("failed to update product URL ID: %w", updateResult.Error)
+	}
+
+	// Update user with posts amount
+	updateUserResult := d.db.Model(&user).Update("total_product", user.TotalProduct+1)
+	if updateUserResult.Error != nil {
+		return Data.Post{}, fmt.Errorf("failed to update user's total products: %w", updateUserResult.Error)
+	}
+
+	// the post is up either way, a reindex picks up one that failed
+	if err := d.IndexPost(post.ID); err != nil {
+		slog.Error("error indexing new post", "post_id", post.ID, "error", err)
+	}
+
+	// Return the updated product
+	return post, nil
+}
+
+func (d *DatabaseHelperImpl) AddProductImage(image Data.PostImage, postID uint) error {
+	// Set the post_id for the image
+	image.PostID = postID
+
+	result := d.db.Create(&image)
+
+	// Check if an error occurred when creating the post
+	if result.Error != nil {
+		// Some other error occurred
+		return errors.New("an unknown error occurred")
+	}
+
+	// Return the ID of the newly created post
+	return nil
+}
+
+// CountProductImages counts every media row of a post, videos too
+func (d *DatabaseHelperImpl) CountProductImages(postID uint) (int64, error) {
+	var count int64
+	if err := d.db.Model(&Data.PostImage{}).Where("post_id = ?", postID).Count(&count).Error; err != nil {
+		return 0, errors.New("error counting post images: " + err.Error())
+	}
+	return count, nil
+}
+
+func (d *DatabaseHelperImpl) CountProductVideos(postID uint) (int64, error) {
+	var count int64
+	if err := d.db.Model(&Data.PostImage{}).Where("post_id = ? AND kind = ?", postID, MediaVideo).Count(&count).Error; err != nil {
+		return 0, errors.New("error counting post videos: " + err.Error())
+	}
+	return count, nil
+}
+
+func (d *DatabaseHelperImpl) UpdateBusinessConnectProduct(Post Data.Post, ProductID uint) error {
+	// Find the product and update it
+	returnedProduct, productErr := d.GetBusinessConnectProductByIDd(uint64(ProductID))
+
+	if productErr != nil {
+		return errors.New("error retrieving product")
+	}
+
+	returnedProduct.Title = Post.Title
+	returnedProduct.Description = Post.Description
+	// returnedProduct.InitialCost = Post.InitialCost
+	// returnedProduct.SellingPrice = Post.SellingPrice
+	// returnedProduct.ProductStock = Post.ProductStock
+	// returnedProduct.StockRemaining = Post.StockRemaining
+	// returnedProduct.NetWeight = Post.NetWeight
+	returnedProduct.BusinessCategory = Post.BusinessCategory
+	// returnedProduct.ProductRank = Post.ProductRank
+	// returnedProduct.Tags = Post.Tags
+	// returnedProduct.PublishStatus = Post.PublishStatus
+
+	// Update the product
+	// save updated user
+	result := d.db.Save(returnedProduct)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// The record with the specified email was not found
+			return errors.New("product not found")
+		} else {
+			// Some other error occurred
+			return errors.New("error retrieving product")
+		}
+	}
+
+	if err := d.IndexPost(returnedProduct.ID); err != nil {
+		slog.Error("error reindexing post", "post_id", returnedProduct.ID, "error", err)
+	}
+	return nil
+}
+
+func (d *DatabaseHelperImpl) DeleteBusinessConnectProduct(ProductID uint) error {
+	var returnedProduct Data.Post
+
+	if err := d.db.First(&returnedProduct, "id = ?", uint64(ProductID)).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			// Post not found, find the next closest available product ID
+			// nextProductID, findErr := d.findClosestAvailableProductID(productID)
+			// if findErr != nil {
+			return errors.New("product not found")
+			// }
+		}
+	}
+
+	// Delete the product
+	result := d.db.Delete(&returnedProduct)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return errors.New("product not found")
+		}
+		return result.Error // Return the actual error
+	}
+
+	// Optional: Check if a row was actually deleted
+	if result.RowsAffected == 0 {
+		return errors.New("product not found or already deleted")
+	}
+
+	if err := d.unindexPosts(d.db, returnedProduct.ID); err != nil {
+		slog.Error("error removing deleted post from the search index", "post_id", returnedProduct.ID, "error", err)
+	}
+	return nil
+}
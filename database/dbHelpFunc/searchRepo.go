@@ -0,0 +1,656 @@
+package dbHelpFunc
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"gorm.io/gorm"
+
+	"business-connect/geo"
+	Data "business-connect/models"
+	"business-connect/search"
+)
+
+// SearchRepo covers the search index of posts: keeping it in step with the
+// posts, ranking them for a query and the filters of the listing pages
+type SearchRepo interface {
+	IndexPost(postID uint) error
+	GetPostIDsToIndex(afterID uint, limit int) ([]uint, error)
+	PruneSearchIndex() (int64, error)
+	SearchPosts(params SearchParams) (SearchResult, error)
+}
+
+// SearchParams is one page of a search, or of a listing when Text is
+// empty. Every filter that is set narrows it down, a list matches any of
+// its values.
+type SearchParams struct {
+	Text         string
+	PostTypes    []string
+	Categories   []string
+	Locations    []string
+	MinPrice     *int64
+	MaxPrice     *int64
+	VerifiedOnly bool
+	InStock      bool
+	// where the user is, every post with coordinates gets its distance from
+	// here, and only those within RadiusKm are found when it is set
+	Near     *geo.Point
+	RadiusKm float64
+	// one of SearchSorts, empty is relevance for a search and newest for a
+	// listing
+	Sort   string
+	Limit  int
+	Offset int
+	// count the facets of everything found, not only the page
+	WithFacets bool
+}
+
+// SearchResult is one page of posts, how many were found in all and the
+// facets when they were asked for. Truncated is set when the search found
+// more than it ranks, MaxSearchResults or MaxNearbyResults, and Total
+// counts only those.
+type SearchResult struct {
+	Posts     []Data.Post
+	Total     int64
+	Facets    *Data.SearchFacets
+	Truncated bool
+}
+
+// the orders a search or listing can be sorted in
+const (
+	SortRelevance = "relevance"
+	SortNewest    = "newest"
+	SortOldest    = "oldest"
+	SortPriceLow  = "price_asc"
+	SortPriceHigh = "price_desc"
+	SortPopular   = "popular"
+	// nearest first, needs Near and leaves out posts without coordinates
+	SortDistance = "distance"
+)
+
+// SearchSorts are the sorts a client may ask for, anything else is refused
+// rather than put in the ORDER BY
+var SearchSorts = []string{SortRelevance, SortNewest, SortOldest, SortPriceLow, SortPriceHigh, SortPopular, SortDistance}
+
+var searchOrders = map[string]string{
+	SortNewest:    "created_at DESC, id DESC",
+	SortOldest:    "created_at ASC, id ASC",
+	SortPriceLow:  "product_price ASC, id DESC",
+	SortPriceHigh: "product_price DESC, id DESC",
+	SortPopular:   "clicks * 1000 + views DESC, id DESC",
+}
+
+// priceRanges are the price facet's buckets, 0 for no upper bound
+var priceRanges = [][2]int64{{0, 5000}, {5000, 20000}, {20000, 50000}, {50000, 200000}, {200000, 0}}
+
+// the filters a facet is counted without, its own
+const (
+	facetPostType = "post_type"
+	facetCategory = "category"
+	facetLocation = "location"
+	facetPrice    = "price"
+	facetVerified = "verified"
+	facetInStock  = "in_stock"
+	// most values a category or location facet lists
+	maxFacetValues = 20
+)
+
+const (
+	// MaxSearchResults is the most posts a search ranks, the rest of a very
+	// broad query isn't shown
+	MaxSearchResults = 500
+	// most indexed terms a misspelt or half typed word is matched to
+	maxFuzzyTerms = 20
+	// most indexed terms a misspelt word is compared with, far more than
+	// share its first two letters and length in any real vocabulary
+	maxFuzzyCandidates = 5000
+	// how long the count of indexed posts is reused, it only changes the
+	// weight of terms a little
+	searchDocumentsTTL = time.Minute
+	// how much of a match a term one or two edits away, or one the last word
+	// only starts, is worth next to the term itself
+	fuzzyOneFactor  = 0.6
+	fuzzyTwoFactor  = 0.35
+	prefixFactor    = 0.8
+	sponsoredFactor = 1.25
+	// how fast a term's weight stops adding up, as in BM25
+	termSaturation = 2
+)
+
+// searchMatch is one indexed term a query looks up, which word of the query
+// it stands for and how much it is worth
+type searchMatch struct {
+	word   int
+	factor float64
+}
+
+// searchHit is one post a search found before it is ranked
+type searchHit struct {
+	PostID    uint
+	Score     float64
+	Matched   int
+	Clicks    int64
+	Views     int64
+	Sponsored bool
+	rank      float64
+}
+
+// IndexPost replaces the terms of a post in the search index, a post that
+// is gone is taken out of it
+func (d *DatabaseHelperImpl) IndexPost(postID uint) error {
+	var post Data.Post
+	err := d.db.Select("id, title, description, business_category, location").First(&post, postID).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return d.unindexPosts(d.db, postID)
+	}
+	if err != nil {
+		return errors.New("error retrieving post to index: " + err.Error())
+	}
+
+	fields := search.Fields{Title: post.Title, Description: post.Description}
+	if post.BusinessCategory != nil {
+		fields.Category = *post.BusinessCategory
+	}
+	if post.Location != nil {
+		fields.Location = *post.Location
+	}
+	terms := make([]Data.SearchTerm, 0)
+	for term, weight := range search.Document(fields) {
+		terms = append(terms, Data.SearchTerm{PostID: post.ID, Term: term, Weight: weight})
+	}
+
+	err = d.db.Transaction(func(tx *gorm.DB) error {
+		if err := d.unindexPosts(tx, post.ID); err != nil {
+			return err
+		}
+		if len(terms) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(terms, 200).Error
+	})
+	if err != nil {
+		return errors.New("error indexing post: " + err.Error())
+	}
+	return nil
+}
+
+func (d *DatabaseHelperImpl) unindexPosts(db *gorm.DB, postIDs ...uint) error {
+	if err := db.Where("post_id IN ?", postIDs).Delete(&Data.SearchTerm{}).Error; err != nil {
+		return errors.New("error removing post from the search index: " + err.Error())
+	}
+	return nil
+}
+
+// GetPostIDsToIndex pages through every post by id, for a full reindex
+func (d *DatabaseHelperImpl) GetPostIDsToIndex(afterID uint, limit int) ([]uint, error) {
+	var ids []uint
+	err := d.db.Model(&Data.Post{}).Where("id > ?", afterID).Order("id").Limit(limit).Pluck("id", &ids).Error
+	if err != nil {
+		return nil, errors.New("error retrieving posts to index: " + err.Error())
+	}
+	return ids, nil
+}
+
+// PruneSearchIndex takes out the terms of posts that are gone
+func (d *DatabaseHelperImpl) PruneSearchIndex() (int64, error) {
+	live := d.db.Model(&Data.Post{}).Select("id")
+	result := d.db.Where("post_id NOT IN (?)", live).Delete(&Data.SearchTerm{})
+	if result.Error != nil {
+		return 0, errors.New("error pruning the search index: " + result.Error.Error())
+	}
+	return result.RowsAffected, nil
+}
+
+// SearchPosts finds the published posts that match the text and the
+// filters. A search ranks them by how well they match, then by engagement,
+// a post has to match at least half of the words and ones that match more
+// come first.
+func (d *DatabaseHelperImpl) SearchPosts(params SearchParams) (SearchResult, error) {
+	result := SearchResult{Posts: []Data.Post{}}
+	if params.WithFacets {
+		result.Facets = &Data.SearchFacets{}
+	}
+
+	// a search only looks among the posts that match the text
+	within := func(db *gorm.DB) *gorm.DB { return db }
+	var ranked []uint
+	if strings.TrimSpace(params.Text) != "" {
+		var err error
+		ranked, result.Truncated, err = d.rankPosts(params.Text)
+		if err != nil {
+			return SearchResult{}, err
+		}
+		if len(ranked) == 0 {
+			return result, nil
+		}
+		within = func(db *gorm.DB) *gorm.DB { return db.Where("posts.id IN ?", ranked) }
+	}
+
+	// and a search near the user among the posts around them
+	var nearby []uint
+	distances := map[uint]float64{}
+	if params.Near != nil {
+		hits, truncated, err := nearest(d.db.Model(&Data.Post{}).Scopes(publishedPosts, within), "posts", *params.Near, params.RadiusKm)
+		if err != nil {
+			return SearchResult{}, errors.New("error finding search results nearby: " + err.Error())
+		}
+		// the furthest only go missing when the nearest decide what is found
+		if params.RadiusKm > 0 || params.Sort == SortDistance {
+			result.Truncated = result.Truncated || truncated
+		}
+		nearby = make([]uint, len(hits))
+		for i, hit := range hits {
+			nearby[i] = hit.ID
+			distances[hit.ID] = roundKm(hit.distance)
+		}
+		if params.RadiusKm > 0 {
+			if len(nearby) == 0 {
+				return result, nil
+			}
+			around := within
+			within = func(db *gorm.DB) *gorm.DB { return around(db).Where("posts.id IN ?", nearby) }
+		}
+	}
+
+	order := params.Sort
+	if _, ok := searchOrders[order]; !ok && !(order == SortDistance && params.Near != nil) {
+		order = SortRelevance
+		if ranked == nil {
+			order = SortNewest
+		}
+	}
+
+	filtered := func() *gorm.DB {
+		return d.db.Model(&Data.Post{}).Scopes(publishedPosts, within, params.filters(""))
+	}
+	var ids []uint
+	if order == SortRelevance || order == SortDistance {
+		// ordered already, the filters only leave some out
+		ordered := ranked
+		if order == SortDistance {
+			ordered = nearby
+		}
+		var matching []uint
+		if err := filtered().Pluck("posts.id", &matching).Error; err != nil {
+			return SearchResult{}, errors.New("error filtering search results: " + err.Error())
+		}
+		keep := make(map[uint]bool, len(matching))
+		for _, id := range matching {
+			keep[id] = true
+		}
+		for _, id := range ordered {
+			if keep[id] {
+				ids = append(ids, id)
+			}
+		}
+		result.Total = int64(len(ids))
+		ids = pageOf(ids, params.Offset, params.Limit)
+	} else {
+		if err := filtered().Count(&result.Total).Error; err != nil {
+			return SearchResult{}, errors.New("error counting search results: " + err.Error())
+		}
+		err := filtered().Order(searchOrders[order]).Limit(params.Limit).Offset(params.Offset).Pluck("posts.id", &ids).Error
+		if err != nil {
+			return SearchResult{}, errors.New("error sorting search results: " + err.Error())
+		}
+	}
+
+	if len(ids) > 0 {
+		var found []Data.Post
+		if err := d.db.Preload("Images").Where("id IN ?", ids).Find(&found).Error; err != nil {
+			return SearchResult{}, errors.New("error retrieving search results: " + err.Error())
+		}
+		byID := make(map[uint]Data.Post, len(found))
+		for _, post := range found {
+			byID[post.ID] = post
+		}
+		for _, id := range ids {
+			if post, ok := byID[id]; ok {
+				if distance, ok := distances[id]; ok {
+					post.DistanceKm = &distance
+				}
+				result.Posts = append(result.Posts, post)
+			}
+		}
+	}
+
+	if params.WithFacets {
+		facets, err := d.searchFacets(params, within)
+		if err != nil {
+			return SearchResult{}, err
+		}
+		result.Facets = &facets
+	}
+	return result, nil
+}
+
+// rankPosts is the ids of the posts that match text, best first, and
+// whether more than MaxSearchResults matched
+func (d *DatabaseHelperImpl) rankPosts(text string) ([]uint, bool, error) {
+	query := search.ParseQuery(text)
+	if len(query.Terms) == 0 {
+		return nil, false, nil
+	}
+	matches, err := d.searchMatches(query)
+	if err != nil {
+		return nil, false, err
+	}
+	hits, err := d.searchHits(matches, (len(query.Terms)+1)/2)
+	if err != nil {
+		return nil, false, err
+	}
+	truncated := len(hits) > MaxSearchResults
+	if truncated {
+		hits = hits[:MaxSearchResults]
+	}
+
+	for i := range hits {
+		engagement := float64(hits[i].Clicks*10 + hits[i].Views)
+		hits[i].rank = hits[i].Score * (1 + 0.1*math.Log1p(math.Max(engagement, 0)))
+		if hits[i].Sponsored {
+			hits[i].rank *= sponsoredFactor
+		}
+	}
+	sort.SliceStable(hits, func(i, j int) bool {
+		if hits[i].Matched != hits[j].Matched {
+			return hits[i].Matched > hits[j].Matched
+		}
+		if hits[i].rank != hits[j].rank {
+			return hits[i].rank > hits[j].rank
+		}
+		return hits[i].PostID > hits[j].PostID
+	})
+
+	ids := make([]uint, len(hits))
+	for i, hit := range hits {
+		ids[i] = hit.PostID
+	}
+	return ids, truncated, nil
+}
+
+// filters narrows a query of posts down to the params, all but the one
+// facet named by except
+func (params SearchParams) filters(except string) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if len(params.PostTypes) > 0 && except != facetPostType {
+			db = db.Where("posts.post_type IN ?", params.PostTypes)
+		}
+		if len(params.Categories) > 0 && except != facetCategory {
+			db = db.Where("LOWER(posts.business_category) IN ?", lowered(params.Categories))
+		}
+		if len(params.Locations) > 0 && except != facetLocation {
+			db = db.Where("LOWER(posts.location) IN ?", lowered(params.Locations))
+		}
+		if except != facetPrice {
+			if params.MinPrice != nil {
+				db = db.Where("posts.product_price >= ?", *params.MinPrice)
+			}
+			if params.MaxPrice != nil {
+				db = db.Where("posts.product_price <= ?", *params.MaxPrice)
+			}
+		}
+		if params.VerifiedOnly && except != facetVerified {
+			db = db.Where("posts.verified = ?", true)
+		}
+		if params.InStock && except != facetInStock {
+			db = db.Scopes(inStock)
+		}
+		return db
+	}
+}
+
+func lowered(values []string) []string {
+	out := make([]string, len(values))
+	for i, value := range values {
+		out[i] = strings.ToLower(value)
+	}
+	return out
+}
+
+// searchFacets counts every facet of what params found, each without its
+// own filter so a client can show what picking another value would give
+func (d *DatabaseHelperImpl) searchFacets(params SearchParams, within func(*gorm.DB) *gorm.DB) (Data.SearchFacets, error) {
+	facet := func(except string) *gorm.DB {
+		return d.db.Model(&Data.Post{}).Scopes(publishedPosts, within, params.filters(except))
+	}
+	values := func(except, column string, limit int) ([]Data.FacetCount, error) {
+		counts := []Data.FacetCount{}
+		err := facet(except).
+			Select(column + " AS value, COUNT(*) AS count").
+			Where(column + " IS NOT NULL AND " + column + " != ''").
+			Group(column).
+			Order("count DESC, value").
+			Limit(limit).
+			Scan(&counts).Error
+		return counts, err
+	}
+
+	var facets Data.SearchFacets
+	var err error
+	if facets.PostTypes, err = values(facetPostType, "posts.post_type", maxFacetValues); err != nil {
+		return Data.SearchFacets{}, errors.New("error counting post types: " + err.Error())
+	}
+	if facets.Categories, err = values(facetCategory, "posts.business_category", maxFacetValues); err != nil {
+		return Data.SearchFacets{}, errors.New("error counting categories: " + err.Error())
+	}
+	if facets.Locations, err = values(facetLocation, "posts.location", maxFacetValues); err != nil {
+		return Data.SearchFacets{}, errors.New("error counting locations: " + err.Error())
+	}
+
+	// every bucket in one pass
+	var columns []string
+	var args []interface{}
+	for i, bucket := range priceRanges {
+		if bucket[1] == 0 {
+			columns = append(columns, "COUNT(CASE WHEN posts.product_price >= ? THEN 1 END) AS r"+strconv.Itoa(i))
+			args = append(args, bucket[0])
+			continue
+		}
+		columns = append(columns, "COUNT(CASE WHEN posts.product_price >= ? AND posts.product_price < ? THEN 1 END) AS r"+strconv.Itoa(i))
+		args = append(args, bucket[0], bucket[1])
+	}
+	counts := make([]int64, len(priceRanges))
+	dest := make([]interface{}, len(counts))
+	for i := range counts {
+		dest[i] = &counts[i]
+	}
+	if err := facet(facetPrice).Select(strings.Join(columns, ", "), args...).Row().Scan(dest...); err != nil {
+		return Data.SearchFacets{}, errors.New("error counting price ranges: " + err.Error())
+	}
+	for i, bucket := range priceRanges {
+		priceRange := Data.PriceRangeCount{Min: bucket[0], Count: counts[i]}
+		if bucket[1] != 0 {
+			priceRange.Max = &priceRanges[i][1]
+		}
+		facets.PriceRanges = append(facets.PriceRanges, priceRange)
+	}
+
+	if err := facet(facetVerified).Where("posts.verified = ?", true).Count(&facets.Verified).Error; err != nil {
+		return Data.SearchFacets{}, errors.New("error counting verified sellers: " + err.Error())
+	}
+	if err := facet(facetInStock).Scopes(inStock).Count(&facets.InStock).Error; err != nil {
+		return Data.SearchFacets{}, errors.New("error counting posts in stock: " + err.Error())
+	}
+	return facets, nil
+}
+
+// searchMatches are the indexed terms a query looks up: its own terms, the
+// ones a typo away and the ones its last word starts
+func (d *DatabaseHelperImpl) searchMatches(query search.Query) (map[string]searchMatch, error) {
+	matches := map[string]searchMatch{}
+	add := func(term string, word int, factor float64) {
+		if current, ok := matches[term]; !ok || factor > current.factor {
+			matches[term] = searchMatch{word: word, factor: factor}
+		}
+	}
+
+	for word, term := range query.Terms {
+		add(term, word, 1)
+
+		tolerance := search.Tolerance(term)
+		if tolerance == 0 {
+			continue
+		}
+		// a typo in the first two letters isn't looked for, that keeps the
+		// lookup on a narrow range of the index. Terms are at least four
+		// letters long here.
+		prefix := string([]rune(term)[:2])
+		minLength := utf8.RuneCountInString(term) - tolerance
+		maxLength := len(term) + tolerance*utf8.UTFMax
+		vocabulary, err := d.searchVocabulary(prefix, minLength, maxLength, maxFuzzyCandidates)
+		if err != nil {
+			return nil, err
+		}
+		type near struct {
+			term     string
+			distance int
+		}
+		var nearest []near
+		for _, candidate := range vocabulary {
+			if candidate == term {
+				continue
+			}
+			if distance := search.Distance(term, candidate, tolerance); distance <= tolerance {
+				nearest = append(nearest, near{candidate, distance})
+			}
+		}
+		sort.SliceStable(nearest, func(i, j int) bool { return nearest[i].distance < nearest[j].distance })
+		for _, n := range nearest[:min(len(nearest), maxFuzzyTerms)] {
+			factor := fuzzyOneFactor
+			if n.distance == 2 {
+				factor = fuzzyTwoFactor
+			}
+			add(n.term, word, factor)
+		}
+	}
+
+	// the word still being typed, "ank" finds "ankara"
+	if prefix := query.Prefix; utf8.RuneCountInString(prefix) >= 2 {
+		word := len(query.Terms) - 1
+		for i, term := range query.Terms {
+			if strings.HasPrefix(prefix, term) || strings.HasPrefix(term, prefix) {
+				word = i
+			}
+		}
+		vocabulary, err := d.searchVocabulary(prefix, utf8.RuneCountInString(prefix), search.MaxTermLength*utf8.UTFMax, maxFuzzyTerms)
+		if err != nil {
+			return nil, err
+		}
+		for _, candidate := range vocabulary {
+			add(candidate, word, prefixFactor)
+		}
+	}
+	return matches, nil
+}
+
+// searchVocabulary is the indexed terms that start with prefix and are
+// between minLength and maxLength long. LENGTH is bytes on MySQL and
+// letters on SQLite, the bounds hold for both.
+func (d *DatabaseHelperImpl) searchVocabulary(prefix string, minLength, maxLength, limit int) ([]string, error) {
+	var terms []string
+	err := d.db.Model(&Data.SearchTerm{}).
+		Distinct("term").
+		Where("term LIKE ? AND LENGTH(term) BETWEEN ? AND ?", prefix+"%", minLength, maxLength).
+		Order("term").
+		Limit(limit).
+		Pluck("term", &terms).Error
+	if err != nil {
+		return nil, errors.New("error reading the search vocabulary: " + err.Error())
+	}
+	return terms, nil
+}
+
+// searchHits scores every published post with at least need of the words
+// of the query. A term counts by its weight in the post, how rare it is and
+// how close it is to what was typed.
+func (d *DatabaseHelperImpl) searchHits(matches map[string]searchMatch, need int) ([]searchHit, error) {
+	terms := make([]string, 0, len(matches))
+	for term := range matches {
+		terms = append(terms, term)
+	}
+	sort.Strings(terms)
+
+	documents, err := d.searchDocuments.get(func() (int64, error) {
+		var count int64
+		err := d.db.Model(&Data.SearchTerm{}).Distinct("post_id").Count(&count).Error
+		return count, err
+	})
+	if err != nil {
+		return nil, errors.New("error counting indexed posts: " + err.Error())
+	}
+	var frequencies []struct {
+		Term  string
+		Posts int64
+	}
+	err = d.db.Model(&Data.SearchTerm{}).
+		Select("term, COUNT(*) AS posts").
+		Where("term IN ?", terms).
+		Group("term").
+		Scan(&frequencies).Error
+	if err != nil {
+		return nil, errors.New("error reading term frequencies: " + err.Error())
+	}
+	idf := map[string]float64{}
+	for _, f := range frequencies {
+		idf[f.Term] = math.Log(1 + (float64(documents)-float64(f.Posts)+0.5)/(float64(f.Posts)+0.5))
+	}
+
+	var score, word strings.Builder
+	var scoreArgs, wordArgs []interface{}
+	score.WriteString("SUM(st.weight * 1.0 / (st.weight + " + strconv.Itoa(termSaturation) + ") * CASE st.term")
+	word.WriteString("COUNT(DISTINCT CASE st.term")
+	for _, term := range terms {
+		score.WriteString(" WHEN ? THEN ?")
+		scoreArgs = append(scoreArgs, term, matches[term].factor*idf[term])
+		word.WriteString(" WHEN ? THEN ?")
+		wordArgs = append(wordArgs, term, matches[term].word)
+	}
+	score.WriteString(" ELSE 0 END) AS score")
+	word.WriteString(" END) AS matched")
+
+	tx := d.db.Table("search_terms AS st").
+		Select("st.post_id, "+score.String()+", "+word.String()+
+			", MAX(p.clicks) AS clicks, MAX(p.views) AS views, MAX(p.is_sponsored) AS sponsored",
+			append(scoreArgs, wordArgs...)...).
+		Joins("JOIN posts AS p ON p.id = st.post_id AND p.deleted_at IS NULL").
+		Where("st.term IN ? AND p.is_active = ? AND p.approved = ?", terms, true, true)
+
+	var hits []searchHit
+	err = tx.Group("st.post_id").
+		Having("matched >= ?", need).
+		Order("matched DESC, score DESC, st.post_id DESC").
+		Limit(MaxSearchResults + 1).
+		Scan(&hits).Error
+	if err != nil {
+		return nil, errors.New("error searching posts: " + err.Error())
+	}
+	return hits, nil
+}
+
+// cachedCount is a count reused for searchDocumentsTTL before it is read
+// again
+type cachedCount struct {
+	mu     sync.Mutex
+	value  int64
+	readAt time.Time
+}
+
+func (c *cachedCount) get(read func() (int64, error)) (int64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if !c.readAt.IsZero() && time.Since(c.readAt) < searchDocumentsTTL {
+		return c.value, nil
+	}
+	value, err := read()
+	if err != nil {
+		return 0, err
+	}
+	c.value, c.readAt = value, time.Now()
+	return value, nil
+}
@@ -0,0 +1,1324 @@
+package dbHelpFunc
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+
+	random "business-connect/controllers/authentication/utils"
+	Data "business-connect/models"
+)
+
+// UserRepo covers accounts: look ups, passwords and refresh tokens, OTPs,
+// profile photos, connections, sign in lockouts, social logins and account
+// deletion
+type UserRepo interface {
+	FindByEmail(email string) (user Data.User, err error)
+	FindByEmailExcludingUser(userToSendFunds uint, SendToEmail string) (user Data.User, err error)
+	FindByUuid(ID uint) (user Data.User, err error)
+	FindByUuidFromLocal(ID interface{}) (user Data.User, err error)
+	FindByPhoneNumber(number string) (user Data.User, err error)
+	CheckByUserByEmail(NewEmail, OldEmail string) (err error)
+	CreatePasswordHash(password string) (string, error)
+	ComparePasswordHash(hash string, password string) error
+	CreateNewUser(NewUser Data.User) (CreatedUser Data.User, err error)
+	DeleteUser(uuid uint) (err error)
+	FindByJti(jti string) (string, error)
+	StoreRefreshToken(userID uint) (jti string, err error)
+	GetAndDeleteRefreshToken(uuidStr interface{}) error
+	DeleteRefreshToken(jti string) (err error)
+	CheckRefreshToken(jti string) bool
+	UpdateUser(NewData Data.User) error
+	CheckSpecialCharacters(email string) bool
+	EditProfileText(text Data.User, userID uint) (string, error)
+	CreateOTP(OTP Data.OTP) error
+	GetOTPByEmail(email string) (emailOTPBody Data.OTP, err error)
+	GetOTPByNumber(number string) (numberOTPBody Data.OTP, err error)
+	GetAndCheckOTPByEmail(Email, OTP string) (userOTP Data.OTP, err error)
+	GetAndCheckOTPByNumber(number, OTP string) (userOTP Data.OTP, err error)
+	UpdateExistingOTP(OTPBody Data.OTP, CustomID uint) (err error)
+	DeleteExistingOTPByID(ID uint) (err error)
+	UpdateMaxTry(Email string) (err error)
+	UpdateMaxTryNumber(number string) (err error)
+	UpdateMaxTryToZero(Email string) (err error)
+	AddProfileImage(userID uint, image Data.ProfileImage) error
+	UpdateUserProfilePhoto(userID uint, photoURL string) error
+	AddCoverImage(userID uint, image Data.ProfileImage) error
+	UpdateUserCoverPhoto(userID uint, photoURL string) error
+	StorageUsedByUser(userID uint) (int64, error)
+	GetUsersToConnect(currentUserID uint, limit, offset int) ([]UserSummary, bool, error)
+	ConnectToUser(senderID, receiverID uint) error
+	GetStatesAndCitiesByCountryCode(countryCode string) ([]Data.State, error)
+	GetLoginAttempt(attemptKey string) (Data.LoginAttempt, error)
+	BumpLoginAttempt(attemptKey, email string, now, windowStart int64) (Data.LoginAttempt, error)
+	LockLoginAttempt(attempt Data.LoginAttempt, threshold, lockedUntil int64) (bool, error)
+	SetLoginUnlockToken(attemptKey, hashedToken string, expiresAt int64) error
+	DeleteLoginAttempt(attemptKey string) error
+	GetLockedLoginAttempts(limit, offset int) ([]Data.LoginAttempt, bool, error)
+	CreateOIDCLoginState(state Data.OIDCLoginState) error
+	ConsumeOIDCLoginState(state string) (Data.OIDCLoginState, error)
+	FindUserIdentity(provider, subject string) (Data.UserIdentity, error)
+	CreateUserIdentity(identity Data.UserIdentity) error
+	MarkIdentityReauthenticated(provider, subject string, at int64) error
+	ConsumeReauthentication(userID uint, since int64) (bool, error)
+	GetAccountDeletion(userID uint) (Data.AccountDeletion, error)
+	SaveAccountDeletion(deletion Data.AccountDeletion) error
+	CancelAccountDeletion(userID uint) error
+	GetDueAccountDeletions(now int64, limit int) ([]Data.AccountDeletion, error)
+	PurgeUser(userID uint) (media []string, err error)
+	GetUserDataExport(userID uint) (UserDataExport, error)
+}
+
+// the findByEmail() function accepts an email as an argument
+// and return a record of any found user else it returns an error
+// and returns an empty struct of data
+func (d *DatabaseHelperImpl) FindByEmail(email string) (user Data.User, err error) {
+	result := d.db.First(&user, "email = ?", email)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// The record with the specified email was not found
+			return Data.User{}, errors.New("user not found")
+		} else {
+			// Some other error occurred
+			return Data.User{}, errors.New("error retrieving user")
+		}
+	}
+
+	return
+}
+
+func (d *DatabaseHelperImpl) FindByEmailExcludingUser(userToSendFunds uint, SendToEmail string) (Data.User, error) {
+	var user Data.User
+	result := d.db.Where("email = ? AND id != ?", SendToEmail, userToSendFunds).First(&user)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return Data.User{}, errors.New("user not found")
+		}
+		return Data.User{}, errors.New("error retrieving user")
+	}
+
+	return user, nil
+}
+
+// the FindByUuid() function accepts an ID as an argument
+// and return a record of any found user else it returns an error
+// and returns an empty struct of data
+func (d *DatabaseHelperImpl) FindByUuid(ID uint) (user Data.User, err error) {
+	result := d.db.First(&user, "ID = ?", ID)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// The record with the specified email was not found
+			return Data.User{}, errors.New("user not found")
+		} else {
+			// Some other error occurred
+			return Data.User{}, errors.New("error retrieving user")
+		}
+	}
+
+	return user, nil
+}
+
+func (d *DatabaseHelperImpl) FindByUuidFromLocal(ID interface{}) (user Data.User, err error) {
+
+	if ID == "" || ID == nil {
+		return Data.User{}, errors.New("error getting user id from request")
+	}
+
+	// Convert userId to a uint
+	userIdUint, ok := ID.(uint)
+	if !ok {
+		return Data.User{}, errors.New("user id is not a valid string")
+	}
+
+	result := d.db.First(&user, "ID = ?", userIdUint)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// The record with the specified email was not found
+			return Data.User{}, errors.New("user not found")
+		} else {
+			// Some other error occurred
+			return Data.User{}, errors.New("error retrieving user")
+		}
+	}
+
+	return
+}
+
+func (d *DatabaseHelperImpl) FindByPhoneNumber(number string) (user Data.User, err error) {
+	result := d.db.First(&user, "phone_number = ?", number)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// The record with the specified email was not found
+			return Data.User{}, errors.New("user not found")
+		} else {
+			// Some other error occurred
+			return Data.User{}, errors.New("error retrieving user")
+		}
+	}
+
+	return
+}
+
+// CheckByUserByEmail checks that NewEmail can be used by the user that
+// currently owns OldEmail. It returns "email already in use" when another
+// account has NewEmail.
+func (d *DatabaseHelperImpl) CheckByUserByEmail(NewEmail, OldEmail string) (err error) {
+	var (
+		email1 Data.User
+		email2 Data.User
+	)
+	email1, err = d.FindByEmail(NewEmail)
+	if err != nil {
+		if err.Error() == "user not found" {
+			// nobody has the new email yet
+			return nil
+		}
+		return
+	}
+
+	email2, err = d.FindByEmail(OldEmail)
+	if err != nil {
+		return
+	}
+
+	// checking if the email belongs to someone else
+	if email1.ID != email2.ID {
+		return errors.New("email already in use")
+	}
+
+	return nil
+}
+
+func (d *DatabaseHelperImpl) CreatePasswordHash(password string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	return string(hash[:]), err
+}
+
+func (d *DatabaseHelperImpl) ComparePasswordHash(hash string, password string) error {
+	// Compare password hash to check if password is valid
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(password))
+
+	// Check if there was an error comparing the hash and password
+	if err != nil {
+		// bcrypt.ErrMismatchedHashAndPassword indicates that the password hash and password do not match
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return errors.New("password does not match")
+		} else if errors.Is(err, bcrypt.ErrHashTooShort) {
+			return errors.New("password too short to be a bcrypt password")
+		}
+		// Return the original error for any other bcrypt-related errors
+		return err
+	}
+
+	// Password matches
+	return nil
+}
+
+func (d *DatabaseHelperImpl) CreateNewUser(NewUser Data.User) (CreatedUser Data.User, err error) {
+	var (
+		NewPassword string
+	)
+
+	// ⚠️ Hash password here before save
+	NewPassword, err = d.CreatePasswordHash(NewUser.Password)
+	if err != nil {
+		return Data.User{}, err
+	}
+
+	NewUser.EmailVerified = false
+	// NewUser.GoogleAuth = false
+	NewUser.Password = NewPassword
+
+	// creating new users in the data base
+	result := d.db.Create(&NewUser)
+
+	// checks if an error occurred when creating a user
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// The record with the specified email was not found
+			return Data.User{}, errors.New("user not found")
+		} else {
+			// Some other error occurred
+			return Data.User{}, errors.New("error creating user")
+		}
+	}
+
+	// The created user is already updated in the NewUser object
+	CreatedUser = NewUser
+
+	return CreatedUser, nil
+}
+
+func (d *DatabaseHelperImpl) DeleteUser(uuid uint) (err error) {
+	var (
+		user Data.User
+	)
+
+	user, err = d.FindByUuid(uuid)
+	if err != nil {
+		return
+	}
+
+	result := d.db.Delete(&Data.User{}, user.ID)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// The record with the specified email was not found
+			return errors.New("user not found")
+		} else {
+			// Some other error occurred
+			return errors.New("error retrieving user")
+		}
+	}
+
+	return
+}
+
+func (d *DatabaseHelperImpl) FindByJti(jti string) (string, error) {
+	var oldJti Data.JTI
+	result := d.db.Where("jti = ?", jti).First(&oldJti)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return "", errors.New("jti not found")
+		}
+		return "", errors.New("error retrieving user")
+	}
+
+	return oldJti.Jti, nil
+}
+
+// the StoreRefreshToken() function records a new refresh token of userID,
+// so every session of a user can be revoked at once
+func (d *DatabaseHelperImpl) StoreRefreshToken(userID uint) (jti string, err error) {
+	jti, err = random.RandomAlphanumericString(32)
+	if err != nil {
+		return "", fmt.Errorf("error generating jti: %v", err)
+	}
+
+	// Ensure jti is unique
+	for {
+		if _, err := d.FindByJti(jti); err != nil {
+			break
+		}
+		jti, err = random.RandomAlphanumericString(32)
+		if err != nil {
+			return "", fmt.Errorf("error generating jti: %v", err)
+		}
+	}
+
+	newJti := Data.JTI{
+		Jti:    jti,
+		UserID: userID,
+	}
+
+	if err := d.db.Create(&newJti).Error; err != nil {
+		return "", fmt.Errorf("error creating jti token: %v", err)
+	}
+
+	return jti, nil
+}
+
+func (d *DatabaseHelperImpl) GetAndDeleteRefreshToken(uuidStr interface{}) error {
+	var userID uint
+
+	// Check the type of uuidStr and convert if necessary
+	switch v := uuidStr.(type) {
+	case string:
+		uintValue, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			slog.Debug("error converting user id to uint", "error", err)
+			return fmt.Errorf("error converting string to uint: %v", err)
+		}
+		userID = uint(uintValue)
+	case uint:
+		userID = v
+	default:
+		return fmt.Errorf("unsupported type for uuidStr: %T", v)
+	}
+
+	// Hard delete every JTI record of the user, JTI has no primary key to
+	// delete the found records by
+	result := d.db.Unscoped().Where("user_id = ?", userID).Delete(&Data.JTI{})
+	if result.Error != nil {
+		slog.Error("error deleting refresh tokens", "error", result.Error)
+		return fmt.Errorf("error deleting records: %v", result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		slog.Debug("no refresh tokens found for user", "user_id", userID)
+		return fmt.Errorf("no records found for UUID: %d", userID)
+	}
+
+	return nil
+}
+
+func (d *DatabaseHelperImpl) DeleteRefreshToken(jti string) error {
+	result := d.db.Unscoped().Where("jti = ?", jti).Delete(&Data.JTI{})
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return errors.New("refresh token not found")
+		}
+		return errors.New("error deleting refresh tokens")
+	}
+
+	return nil
+}
+
+func (d *DatabaseHelperImpl) CheckRefreshToken(jti string) bool {
+	_, err := d.FindByJti(jti)
+	return err == nil
+}
+
+func (d *DatabaseHelperImpl) CheckSpecialCharacters(email string) bool {
+	// Define a regular expression pattern to match special characters
+	pattern := `[!#$%&'*+/=?^_{|}~]`
+
+	// Use regexp.MatchString to check if any special characters are present in the email
+	match, err := regexp.MatchString(pattern, email)
+	if err != nil {
+		// errors.New("error while matching pattern")
+		return false
+	}
+	return match
+}
+
+func (d *DatabaseHelperImpl) EditProfileText(text Data.User, userID uint) (string, error) {
+	// save the fields that were sent
+	result := d.db.Model(&Data.User{}).Where("id = ?", userID).Updates(text)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// The record with the specified email was not found
+			return "", errors.New("user not found")
+		} else {
+			// Some other error occurred
+			return "", errors.New("error retrieving user")
+		}
+	}
+
+	return "user profile updated successfully", nil
+}
+
+func (d *DatabaseHelperImpl) UpdateUser(NewData Data.User) error {
+
+	result := d.db.Save(NewData)
+
+	// checks if an error occurred when creating a user
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// The record with the specified email was not found
+			return errors.New("user to update not found")
+		} else {
+			// Some other error occurred
+			return errors.New("error retrieving user to update")
+		}
+	}
+
+	return nil
+}
+
+func (d *DatabaseHelperImpl) CreateOTP(OTP Data.OTP) error {
+	hashOTP, otpErr := d.CreateOTPHash(OTP.OTP)
+	if otpErr != nil {
+		return errors.New("error creating otp hash")
+	}
+	// save hashed otp to the database
+	OTP.OTP = hashOTP
+	// creating new users in the data base
+	result := d.db.Create(&OTP)
+
+	// checks if an error occurred when creating a user
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// The record with the specified email was not found
+			return errors.New("otp not found")
+		} else {
+			// Some other error occurred
+			return errors.New("error retrieving otp")
+		}
+	}
+
+	return nil
+}
+
+// get the users otp by an existing email Note: email ID's cannot be the same so each
+// email ID is mapped to a otp value for verification purposes
+func (d *DatabaseHelperImpl) GetOTPByEmail(email string) (emailOTPBody Data.OTP, err error) {
+
+	result := d.db.First(&emailOTPBody, "email = ?", email)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// The record with the specified email was not found
+			slog.Debug("otp not found", "email", email)
+			return Data.OTP{}, errors.New("otp not found by email")
+		} else {
+			// Some other error occurred
+			slog.Error("error retrieving otp", "error", result.Error)
+			return Data.OTP{}, errors.New("error retrieving otp")
+		}
+	}
+
+	// log.Printf("OTP fetched from the database: %+v", emailOTPBody)
+
+	return emailOTPBody, nil
+}
+
+func (d *DatabaseHelperImpl) GetOTPByNumber(number string) (numberOTPBody Data.OTP, err error) {
+
+	result := d.db.First(&numberOTPBody, "phone_number = ?", number)
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// The record with the specified email was not found
+			slog.Debug("otp not found", "phone", number)
+			return Data.OTP{}, errors.New("otp not found by number")
+		} else {
+			// Some other error occurred
+			slog.Error("error retrieving otp", "error", result.Error)
+			return Data.OTP{}, errors.New("error retrieving otp")
+		}
+	}
+
+	return numberOTPBody, nil
+}
+
+func (d *DatabaseHelperImpl) GetAndCheckOTPByEmail(Email, OTP string) (userOTP Data.OTP, err error) {
+	userOTP, otpErr := d.GetOTPByEmail(Email)
+	if otpErr != nil {
+		if otpErr.Error() == "otp not found by email" {
+			return Data.OTP{}, errors.New("otp not found")
+		}
+		return Data.OTP{}, errors.New("an error occurred while getting otp email")
+	}
+
+	// Create a copy of the original userOTP for logging purposes
+	originalUserOTP := userOTP
+	hashErr := d.CompareOTPHash(originalUserOTP.OTP, OTP)
+
+	if hashErr != nil {
+		slog.Debug("otp does not match", "error", hashErr)
+		if hashErr.Error() == "incorrect OTP" {
+			return Data.OTP{}, errors.New("incorrect otp value")
+		} else if hashErr.Error() == "hashed OTP is too short to be a bcrypt hash" {
+			return Data.OTP{}, errors.New("hashed OTP is too short to be a bcrypt hash")
+		}
+	}
+
+	return userOTP, nil
+}
+
+func (d *DatabaseHelperImpl) GetAndCheckOTPByNumber(number, OTP string) (userOTP Data.OTP, err error) {
+	userOTP, otpErr := d.GetOTPByNumber(number)
+	if otpErr != nil {
+		if otpErr.Error() == "otp not found by email" {
+			return Data.OTP{}, errors.New("otp not found")
+		}
+		return Data.OTP{}, errors.New("an error occurred while getting otp email")
+	}
+
+	// Create a copy of the original userOTP for logging purposes
+	originalUserOTP := userOTP
+	hashErr := d.CompareOTPHash(originalUserOTP.OTP, OTP)
+
+	if hashErr != nil {
+		slog.Debug("otp does not match", "error", hashErr)
+		if hashErr.Error() == "incorrect OTP" {
+			return Data.OTP{}, errors.New("incorrect otp value")
+		} else if hashErr.Error() == "hashed OTP is too short to be a bcrypt hash" {
+			return Data.OTP{}, errors.New("hashed OTP is too short to be a bcrypt hash")
+		}
+	}
+
+	return userOTP, nil
+}
+
+func (d *DatabaseHelperImpl) UpdateExistingOTP(OTPBody Data.OTP, CustomID uint) (err error) {
+	// Find the existing record by CustomID
+	var existingOTP Data.OTP
+	if result := d.db.Where("custom_id = ?", CustomID).First(&existingOTP).Error; result != nil {
+		if errors.Is(result, gorm.ErrRecordNotFound) {
+			// The record with the specified id was not found
+			return errors.New("otp not found")
+		} else {
+			// Some other error occurred
+			return errors.New("error updating otp")
+		}
+	}
+
+	oTPBodyHash, hashErr := d.CreateOTPHash(OTPBody.OTP)
+	if hashErr != nil {
+		return errors.New("error creating otp hash")
+	}
+
+	// Update only the OTP and CreatedAt fields
+	existingOTP.OTP = oTPBodyHash
+	existingOTP.CreatedAT = OTPBody.CreatedAT
+	existingOTP.MaxTry = OTPBody.MaxTry
+
+	// Save the updated record
+	if err := d.db.Save(&existingOTP).Error; err != nil {
+		return errors.New("error saving the updated otp")
+	}
+
+	return nil
+}
+
+func (d *DatabaseHelperImpl) UpdateMaxTry(Email string) (err error) {
+
+	userOTP, otpErr := d.GetOTPByEmail(Email)
+	if otpErr != nil {
+		if otpErr.Error() == "otp not found by email" {
+			return errors.New("otp not found")
+		}
+		return errors.New("an error occurred while getting otp email")
+	}
+
+	userOTP.MaxTry += 1
+	maxOtpToUpdate := &Data.OTP{
+		CustomID: userOTP.CustomID,
+		MaxTry:   userOTP.MaxTry, // The new max_try value
+	}
+
+	// Update specific fields of the OTP record based on the email
+	result := d.db.Model(&userOTP).Updates(maxOtpToUpdate)
+
+	// Check if an error occurred
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// The record with the specified email was not found
+			return errors.New("otp not found")
+		} else {
+			// Some other error occurred
+			return errors.New("error updating max_try")
+		}
+	}
+
+	return nil
+}
+
+func (d *DatabaseHelperImpl) UpdateMaxTryNumber(number string) (err error) {
+
+	userOTP, otpErr := d.GetOTPByNumber(number)
+	if otpErr != nil {
+		if otpErr.Error() == "otp not found by email" {
+			return errors.New("otp not found")
+		}
+		return errors.New("an error occurred while getting otp email")
+	}
+
+	userOTP.MaxTry += 1
+	maxOtpToUpdate := &Data.OTP{
+		CustomID: userOTP.CustomID,
+		MaxTry:   userOTP.MaxTry, // The new max_try value
+	}
+
+	// Update specific fields of the OTP record based on the email
+	result := d.db.Model(&userOTP).Updates(maxOtpToUpdate)
+
+	// Check if an error occurred
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// The record with the specified email was not found
+			return errors.New("otp not found")
+		} else {
+			// Some other error occurred
+			return errors.New("error updating max_try")
+		}
+	}
+
+	return nil
+}
+
+func (d *DatabaseHelperImpl) UpdateMaxTryToZero(Email string) (err error) {
+
+	userOTP, otpErr := d.GetOTPByEmail(Email)
+	if otpErr != nil {
+		if otpErr.Error() == "otp not found by email" {
+			return errors.New("otp not found")
+		}
+		return errors.New("an error occurred while getting otp email")
+	}
+
+	userOTP.MaxTry = 0
+	maxOtpToUpdate := &Data.OTP{
+		MaxTry: userOTP.MaxTry, // The new max_try value
+	}
+
+	// Update specific fields of the OTP record based on the id
+	result := d.db.Model(&userOTP).Updates(maxOtpToUpdate)
+
+	// Check if an error occurred
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// The record with the specified email was not found
+			return errors.New("otp not found")
+		} else {
+			// Some other error occurred
+			return errors.New("error updating max_try")
+		}
+	}
+
+	return nil
+}
+
+func (d *DatabaseHelperImpl) DeleteExistingOTPByID(ID uint) (err error) {
+	result := d.db.Where("custom_id = ?", ID).Delete(&Data.OTP{})
+
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			// The record with the specified email was not found
+			return errors.New("otp not found to delete")
+		} else {
+			// Some other error occurred
+			return errors.New("error deleting otp")
+		}
+	}
+
+	return nil
+}
+
+func (d *DatabaseHelperImpl) CreateOTPHash(OTP string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(OTP), bcrypt.DefaultCost)
+	return string(hash[:]), err
+}
+
+func (d *DatabaseHelperImpl) CompareOTPHash(hash string, plainOTP string) error {
+
+	hash = strings.TrimSpace(hash)
+	plainOTP = strings.TrimSpace(plainOTP)
+
+	// Compare the hashed OTP to the plain OTP
+	err := bcrypt.CompareHashAndPassword([]byte(hash), []byte(plainOTP))
+
+	// Check if there was an error comparing the hash and plain OTP
+	if err != nil {
+		// bcrypt.ErrMismatchedHashAndPassword indicates that the hashed OTP and plain OTP do not match
+		if errors.Is(err, bcrypt.ErrMismatchedHashAndPassword) {
+			return errors.New("incorrect OTP")
+		} else if errors.Is(err, bcrypt.ErrHashTooShort) {
+			return errors.New("hashed OTP is too short to be a bcrypt hash")
+		}
+		// Return the original error for any other bcrypt-related errors
+		return err
+	}
+
+	// OTP matches
+	return nil
+}
+
+type UserSummary struct {
+	ID              uint   `json:"id"`
+	FullName        string `json:"full_name"`
+	BioDescription  string `json:"bio_description" gorm:"size:100;not null"`
+	BusinessName    string `json:"business_name"`
+	ProfilePhotoURL string `json:"profile_photo_url"`
+	PhoneNumber     string `json:"phone_number"`
+	Verified        bool   `json:"verified"`
+	UserType        string `json:"user_type"`
+	State           string `json:"state"`
+	City            string `json:"city"`
+	CoverPhotoURL   string `json:"cover_photo_url"`
+}
+
+func (d *DatabaseHelperImpl) GetUsersToConnect(
+	currentUserID uint,
+	limit, offset int,
+) ([]UserSummary, bool, error) {
+
+	var users []UserSummary
+
+	// Subquery: all accepted connections
+	subQuery := d.db.Table("connections").
+		Select("connected_user_id").
+		Where("user_id = ? AND status = ?", currentUserID, "accepted")
+
+	result := d.db.Model(&Data.User{}).
+		Select("id, full_name, business_name, profile_photo_url, phone_number, cover_photo_url, state, verified, user_type, bio_description").
+		Where("id NOT IN (?) AND id != ?", subQuery, currentUserID).
+		Limit(limit + 1).
+		Offset(offset).
+		Find(&users)
+
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+
+	hasMore := false
+	if len(users) > limit {
+		hasMore = true
+		users = users[:limit]
+	}
+
+	return users, hasMore, nil
+}
+
+func (d *DatabaseHelperImpl) ConnectToUser(senderID, receiverID uint) error {
+	if senderID == receiverID {
+		return fmt.Errorf("cannot connect to yourself")
+	}
+
+	return d.db.Transaction(func(tx *gorm.DB) error {
+
+		var existing Data.Connection
+		err := tx.Where(
+			"(user_id = ? AND connected_user_id = ?) OR (user_id = ? AND connected_user_id = ?)",
+			senderID, receiverID, receiverID, senderID,
+		).First(&existing).Error
+
+		if err == nil {
+			return fmt.Errorf("connection already exists")
+		}
+		if err != gorm.ErrRecordNotFound {
+			return err
+		}
+
+		connection := Data.Connection{
+			UserID:          senderID,
+			ConnectedUserID: receiverID,
+			Status:          "accepted",
+		}
+
+		if err := tx.Create(&connection).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&Data.User{}).
+			Where("id IN ?", []uint{senderID, receiverID}).
+			UpdateColumn("connections_count", gorm.Expr("connections_count + 1")).
+			Error
+	})
+}
+
+func (d *DatabaseHelperImpl) AddProfileImage(
+	userID uint,
+	image Data.ProfileImage,
+) error {
+
+	image.UserID = userID
+	image.Kind = "profile"
+
+	return d.db.Create(&image).Error
+}
+
+func (d *DatabaseHelperImpl) UpdateUserProfilePhoto(
+	userID uint,
+	photoURL string,
+) error {
+
+	return d.db.
+		Model(&Data.User{}).
+		Where("id = ?", userID).
+		Update("profile_photo_url", photoURL).
+		Error
+}
+
+func (d *DatabaseHelperImpl) AddCoverImage(
+	userID uint,
+	image Data.ProfileImage,
+) error {
+
+	image.UserID = userID
+	image.Kind = "cover"
+
+	return d.db.Create(&image).Error
+}
+
+func (d *DatabaseHelperImpl) UpdateUserCoverPhoto(
+	userID uint,
+	photoURL string,
+) error {
+
+	return d.db.
+		Model(&Data.User{}).
+		Where("id = ?", userID).
+		Update("cover_photo_url", photoURL).
+		Error
+}
+
+// StorageUsedByUser adds up the stored size of every image userID owns,
+// on their posts and blogs and as profile and cover photos
+func (d *DatabaseHelperImpl) StorageUsedByUser(userID uint) (int64, error) {
+	var used struct{ Posts, Blogs, Profile int64 }
+	err := d.db.Raw(`
+		SELECT
+			(SELECT COALESCE(SUM(pi.bytes), 0) FROM post_images pi
+				JOIN posts p ON p.id = pi.post_id AND p.deleted_at IS NULL
+				WHERE p.user_id = ? AND pi.deleted_at IS NULL) AS posts,
+			(SELECT COALESCE(SUM(bi.bytes), 0) FROM blog_images bi
+				JOIN blogs b ON b.id = bi.blog_id AND b.deleted_at IS NULL
+				WHERE b.user_id = ? AND bi.deleted_at IS NULL) AS blogs,
+			(SELECT COALESCE(SUM(bytes), 0) FROM profile_images
+				WHERE user_id = ? AND deleted_at IS NULL) AS profile
+	`, userID, userID, userID).Scan(&used).Error
+	if err != nil {
+		slog.Error("error adding up storage used", "user_id", userID, "error", err)
+		return 0, errors.New("error checking storage used")
+	}
+	return used.Posts + used.Blogs + used.Profile, nil
+}
+
+func (d *DatabaseHelperImpl) GetStatesAndCitiesByCountryCode(countryCode string) ([]Data.State, error) {
+	// Fetch all states for the country
+	var states []Data.State
+	if err := d.db. /*Preload("Cities", "country_code = ?", countryCode)*/
+			Where("country_code = ?", countryCode).Find(&states).Error; err != nil {
+		slog.Error("error fetching states", "error", err)
+		return nil, errors.New("Error fetching states")
+	}
+
+	return states, nil
+}
+
+// the GetLoginAttempt() function returns the failed sign-in counter stored
+// under attemptKey (email:<address> or ip:<address>)
+func (d *DatabaseHelperImpl) GetLoginAttempt(attemptKey string) (Data.LoginAttempt, error) {
+	var attempt Data.LoginAttempt
+
+	result := d.db.Where("attempt_key = ?", attemptKey).First(&attempt)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return Data.LoginAttempt{}, errors.New("login attempt not found")
+		}
+		return Data.LoginAttempt{}, errors.New("error retrieving login attempt")
+	}
+
+	return attempt, nil
+}
+
+// the BumpLoginAttempt() function counts one more failed sign-in under
+// attemptKey in a single statement, so concurrent failures all count. The
+// counter starts again from one when its last failure was before
+// windowStart. It returns the counter as it is after the bump.
+func (d *DatabaseHelperImpl) BumpLoginAttempt(attemptKey, email string, now, windowStart int64) (Data.LoginAttempt, error) {
+	attempt := Data.LoginAttempt{AttemptKey: attemptKey, Email: email, Failures: 1, LastFailedAt: now}
+
+	// failures is set first, while last_failed_at still holds the previous failure
+	result := d.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "attempt_key"}},
+		DoUpdates: clause.Set{
+			{Column: clause.Column{Name: "failures"}, Value: gorm.Expr("CASE WHEN last_failed_at < ? THEN 1 ELSE failures + 1 END", windowStart)},
+			{Column: clause.Column{Name: "last_failed_at"}, Value: now},
+			{Column: clause.Column{Name: "updated_at"}, Value: time.Now()},
+		},
+	}).Create(&attempt)
+	if result.Error != nil {
+		return Data.LoginAttempt{}, errors.New("error saving login attempt")
+	}
+
+	return d.GetLoginAttempt(attemptKey)
+}
+
+// the LockLoginAttempt() function locks the counter read as attempt until
+// lockedUntil, if it still has threshold failures and no lock was taken since
+// it was read. Only one of several concurrent failures gets true.
+func (d *DatabaseHelperImpl) LockLoginAttempt(attempt Data.LoginAttempt, threshold, lockedUntil int64) (bool, error) {
+	result := d.db.Model(&Data.LoginAttempt{}).
+		Where("attempt_key = ? AND failures >= ? AND lock_count = ?", attempt.AttemptKey, threshold, attempt.LockCount).
+		Updates(map[string]interface{}{
+			"failures":                0,
+			"lock_count":              attempt.LockCount + 1,
+			"locked_until":            lockedUntil,
+			"unlock_token":            "",
+			"unlock_token_expires_at": 0,
+		})
+	if result.Error != nil {
+		return false, errors.New("error locking login attempt")
+	}
+
+	return result.RowsAffected == 1, nil
+}
+
+// the SetLoginUnlockToken() function stores the hash of the token emailed to
+// unlock attemptKey, good until expiresAt
+func (d *DatabaseHelperImpl) SetLoginUnlockToken(attemptKey, hashedToken string, expiresAt int64) error {
+	result := d.db.Model(&Data.LoginAttempt{}).
+		Where("attempt_key = ?", attemptKey).
+		Updates(map[string]interface{}{
+			"unlock_token":            hashedToken,
+			"unlock_token_expires_at": expiresAt,
+		})
+	if result.Error != nil {
+		return errors.New("error saving unlock token")
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("login attempt not found")
+	}
+
+	return nil
+}
+
+func (d *DatabaseHelperImpl) DeleteLoginAttempt(attemptKey string) error {
+	result := d.db.Unscoped().Where("attempt_key = ?", attemptKey).Delete(&Data.LoginAttempt{})
+	if result.Error != nil {
+		return errors.New("error deleting login attempt")
+	}
+
+	return nil
+}
+
+// the GetLockedLoginAttempts() function lists accounts (not IPs) that are
+// currently locked, newest lock first
+func (d *DatabaseHelperImpl) GetLockedLoginAttempts(limit, offset int) ([]Data.LoginAttempt, bool, error) {
+	var attempts []Data.LoginAttempt
+
+	result := d.db.
+		Where("email <> ? AND locked_until > ?", "", time.Now().Unix()).
+		Order("locked_until DESC").
+		Limit(limit + 1).
+		Offset(offset).
+		Find(&attempts)
+
+	if result.Error != nil {
+		return nil, false, result.Error
+	}
+
+	hasMore := false
+	if len(attempts) > limit {
+		hasMore = true
+		attempts = attempts[:limit]
+	}
+
+	return attempts, hasMore, nil
+}
+
+func (d *DatabaseHelperImpl) CreateOIDCLoginState(state Data.OIDCLoginState) error {
+	// drop abandoned logins while we are here
+	d.db.Unscoped().Where("expires_at < ?", time.Now().Unix()).Delete(&Data.OIDCLoginState{})
+
+	if err := d.db.Create(&state).Error; err != nil {
+		return errors.New("error saving login state")
+	}
+
+	return nil
+}
+
+// the ConsumeOIDCLoginState() function returns the saved login state and
+// deletes it so a callback can only be used once
+func (d *DatabaseHelperImpl) ConsumeOIDCLoginState(state string) (Data.OIDCLoginState, error) {
+	var loginState Data.OIDCLoginState
+
+	err := d.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("state = ?", state).First(&loginState).Error; err != nil {
+			return err
+		}
+		return tx.Unscoped().Delete(&loginState).Error
+	})
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return Data.OIDCLoginState{}, errors.New("login state not found")
+		}
+		return Data.OIDCLoginState{}, errors.New("error retrieving login state")
+	}
+
+	if loginState.ExpiresAt < time.Now().Unix() {
+		return Data.OIDCLoginState{}, errors.New("login state expired")
+	}
+
+	return loginState, nil
+}
+
+func (d *DatabaseHelperImpl) FindUserIdentity(provider, subject string) (Data.UserIdentity, error) {
+	var identity Data.UserIdentity
+
+	result := d.db.Where("provider = ? AND subject = ?", provider, subject).First(&identity)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return Data.UserIdentity{}, errors.New("identity not found")
+		}
+		return Data.UserIdentity{}, errors.New("error retrieving identity")
+	}
+
+	return identity, nil
+}
+
+func (d *DatabaseHelperImpl) CreateUserIdentity(identity Data.UserIdentity) error {
+	if err := d.db.Create(&identity).Error; err != nil {
+		return errors.New("error linking identity")
+	}
+
+	return nil
+}
+
+// MarkIdentityReauthenticated records that the provider made the user of
+// the identity sign in again at at
+func (d *DatabaseHelperImpl) MarkIdentityReauthenticated(provider, subject string, at int64) error {
+	result := d.db.Model(&Data.UserIdentity{}).
+		Where("provider = ? AND subject = ?", provider, subject).
+		Update("reauthenticated_at", at)
+	if result.Error != nil {
+		return errors.New("error updating identity")
+	}
+
+	return nil
+}
+
+// ConsumeReauthentication uses up a sign in again through any provider of
+// userID since since, it reports false when there was none. Each one
+// confirms one change only.
+func (d *DatabaseHelperImpl) ConsumeReauthentication(userID uint, since int64) (bool, error) {
+	result := d.db.Model(&Data.UserIdentity{}).
+		Where("user_id = ? AND reauthenticated_at >= ?", userID, since).
+		Update("reauthenticated_at", 0)
+	if result.Error != nil {
+		return false, errors.New("error updating identity")
+	}
+
+	return result.RowsAffected > 0, nil
+}
+
+func (d *DatabaseHelperImpl) GetAccountDeletion(userID uint) (Data.AccountDeletion, error) {
+	var deletion Data.AccountDeletion
+
+	result := d.db.Where("user_id = ?", userID).First(&deletion)
+	if result.Error != nil {
+		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
+			return Data.AccountDeletion{}, errors.New("account deletion not found")
+		}
+		return Data.AccountDeletion{}, errors.New("error retrieving account deletion")
+	}
+
+	return deletion, nil
+}
+
+func (d *DatabaseHelperImpl) SaveAccountDeletion(deletion Data.AccountDeletion) error {
+	if err := d.db.Save(&deletion).Error; err != nil {
+		return errors.New("error saving account deletion")
+	}
+
+	return nil
+}
+
+func (d *DatabaseHelperImpl) CancelAccountDeletion(userID uint) error {
+	result := d.db.Unscoped().Where("user_id = ?", userID).Delete(&Data.AccountDeletion{})
+	if result.Error != nil {
+		return errors.New("error cancelling account deletion")
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("account deletion not found")
+	}
+
+	return nil
+}
+
+// the GetDueAccountDeletions() function returns the deletions whose grace
+// period ended before now, oldest first
+func (d *DatabaseHelperImpl) GetDueAccountDeletions(now int64, limit int) ([]Data.AccountDeletion, error) {
+	var deletions []Data.AccountDeletion
+
+	result := d.db.
+		Where("scheduled_for <= ?", now).
+		Order("scheduled_for ASC").
+		Limit(limit).
+		Find(&deletions)
+	if result.Error != nil {
+		return nil, errors.New("error retrieving account deletions")
+	}
+
+	return deletions, nil
+}
+
+// the PurgeUser() function permanently removes a user and the data that
+// belongs only to them. Orders they placed are kept for the seller's books
+// with the customer details anonymized, and the counters on other users'
+// connections, groups and blogs are decremented. It returns the URLs of the
+// images the user's posts and profile used, for the caller to release.
+func (d *DatabaseHelperImpl) PurgeUser(userID uint) ([]string, error) {
+	user, err := d.FindByUuid(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	var media []string
+	err = d.db.Transaction(func(tx *gorm.DB) error {
+		// connections: the other side loses one connection
+		var connections []Data.Connection
+		if err := tx.Where("user_id = ? OR connected_user_id = ?", userID, userID).Find(&connections).Error; err != nil {
+			return err
+		}
+		for _, c := range connections {
+			other := c.ConnectedUserID
+			if other == userID {
+				other = c.UserID
+			}
+			if c.Status == "accepted" {
+				if err := tx.Model(&Data.User{}).
+					Where("id = ? AND connections_count > 0", other).
+					UpdateColumn("connections_count", gorm.Expr("connections_count - 1")).Error; err != nil {
+					return err
+				}
+			}
+		}
+		if err := tx.Unscoped().Where("user_id = ? OR connected_user_id = ?", userID, userID).Delete(&Data.Connection{}).Error; err != nil {
+			return err
+		}
+
+		// groups they joined lose a member
+		var memberships []Data.GroupParticipant
+		if err := tx.Where("user_id = ?", userID).Find(&memberships).Error; err != nil {
+			return err
+		}
+		for _, m := range memberships {
+			if err := tx.Model(&Data.Post{}).
+				Where("id = ? AND members_count > 0", m.PostID).
+				UpdateColumn("members_count", gorm.Expr("members_count - 1")).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&Data.GroupParticipant{}).Error; err != nil {
+			return err
+		}
+
+		// their posts go with everything hanging off them
+		postIDs := tx.Unscoped().Model(&Data.Post{}).Select("id").Where("user_id = ?", userID)
+		if err := tx.Unscoped().Model(&Data.PostImage{}).Where("post_id IN (?)", postIDs).Pluck("url", &media).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("post_id IN (?)", postIDs).Delete(&Data.PostImage{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("post_id IN (?)", postIDs).Delete(&Data.GroupParticipant{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where("post_id IN (?)", postIDs).Delete(&Data.SearchTerm{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Unscoped().Where("user_id = ?", userID).Delete(&Data.Post{}).Error; err != nil {
+			return err
+		}
+
+		// comments they left on blogs, the blogs lose one comment each
+		var reviews []Data.CustomerBlogReview
+		if err := tx.Where("email = ?", user.Email).Find(&reviews).Error; err != nil {
+			return err
+		}
+		for _, r := range reviews {
+			if err := tx.Model(&Data.Blog{}).
+				Where("id = ? AND blog_reviews_count > 0", r.BlogID).
+				UpdateColumn("blog_reviews_count", gorm.Expr("blog_reviews_count - 1")).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Unscoped().Where("email = ?", user.Email).Delete(&Data.CustomerBlogReview{}).Error; err != nil {
+			return err
+		}
+
+		// orders they placed stay with the seller without the personal details
+		if err := tx.Model(&Data.OrderHistory{}).
+			Where("customer_email = ?", user.Email).
+			Updates(map[string]interface{}{
+				"customer_email":           "",
+				"customer_f_name":          "deleted",
+				"customer_s_name":          "user",
+				"customer_company_name":    "",
+				"customer_street_address1": "",
+				"customer_street_address2": "",
+				"customer_zip_code":        "",
+				"customer_phone_number":    "",
+				"order_note":               "",
+			}).Error; err != nil {
+			return err
+		}
+
+		var profileImages []string
+		if err := tx.Unscoped().Model(&Data.ProfileImage{}).Where("user_id = ?", userID).Pluck("url", &profileImages).Error; err != nil {
+			return err
+		}
+		media = append(media, profileImages...)
+		for _, url := range []string{user.ProfilePhotoURL, user.CoverPhotoURL} {
+			if url != "" {
+				media = append(media, url)
+			}
+		}
+
+		cleanups := []struct {
+			model interface{}
+			query string
+			arg   interface{}
+		}{
+			{&Data.ProfileImage{}, "user_id = ?", userID},
+			{&Data.BusinessConnectUserActivity{}, "user_id = ?", userID},
+			{&Data.UserIdentity{}, "user_id = ?", userID},
+			{&Data.JTI{}, "user_id = ?", userID},
+			{&Data.OTP{}, "email = ?", user.Email},
+			{&Data.LoginAttempt{}, "email = ?", user.Email},
+			{&Data.BusinessConnectEmailSubscriber{}, "email = ?", user.Email},
+			{&Data.AccountDeletion{}, "user_id = ?", userID},
+		}
+		for _, c := range cleanups {
+			if err := tx.Unscoped().Where(c.query, c.arg).Delete(c.model).Error; err != nil {
+				return err
+			}
+		}
+
+		return tx.Unscoped().Delete(&Data.User{}, userID).Error
+	})
+	if err != nil {
+		return nil, errors.New("error purging user")
+	}
+
+	return media, nil
+}
+
+// UserDataExport is everything we hold about one user, used for the personal
+// data export
+type UserDataExport struct {
+	Profile          Data.User                 `json:"profile"`
+	ProfileImages    []Data.ProfileImage       `json:"profile_images"`
+	Posts            []Data.Post               `json:"posts"`
+	Connections      []Data.Connection         `json:"connections"`
+	GroupMemberships []Data.GroupParticipant   `json:"group_memberships"`
+	Orders           []Data.OrderHistory       `json:"orders"`
+	BlogReviews      []Data.CustomerBlogReview `json:"blog_reviews"`
+	LinkedAccounts   []Data.UserIdentity       `json:"linked_accounts"`
+	LoginActivity    []Data.LoginAttempt       `json:"login_activity"`
+	// clicks and views made while signed in
+	Activity []Data.BusinessConnectUserActivity `json:"activity"`
+}
+
+func (d *DatabaseHelperImpl) GetUserDataExport(userID uint) (UserDataExport, error) {
+	var export UserDataExport
+
+	user, err := d.FindByUuid(userID)
+	if err != nil {
+		return UserDataExport{}, err
+	}
+	export.Profile = user
+
+	queries := []struct {
+		dest  interface{}
+		query *gorm.DB
+	}{
+		{&export.ProfileImages, d.db.Where("user_id = ?", userID)},
+		{&export.Posts, d.db.Preload("Images").Where("user_id = ?", userID)},
+		{&export.Connections, d.db.Where("user_id = ? OR connected_user_id = ?", userID, userID)},
+		{&export.GroupMemberships, d.db.Where("user_id = ?", userID)},
+		{&export.Orders, d.db.Preload("ProductOrders").Where("customer_email = ?", user.Email)},
+		{&export.BlogReviews, d.db.Where("email = ?", user.Email)},
+		{&export.LinkedAccounts, d.db.Where("user_id = ?", userID)},
+		{&export.LoginActivity, d.db.Where("email = ?", user.Email)},
+		{&export.Activity, d.db.Where("user_id = ?", userID)},
+	}
+	for _, q := range queries {
+		if err := q.query.Find(q.dest).Error; err != nil {
+			return UserDataExport{}, errors.New("error retrieving user data")
+		}
+	}
+
+	return export, nil
+}
@@ -0,0 +1,349 @@
+// Package migrations applies the versioned SQL files in sql/ to the database
+// and records them in the schema_migrations table.
+//
+// Every change to the schema is a pair of files
+//
+//	sql/<version>_<name>.up.sql
+//	sql/<version>_<name>.down.sql
+//
+// where version is a zero padded number one higher than the last one. The
+// server never changes the schema itself, it only checks that the database is
+// at the latest version; run `business-connect migrate up` before deploying
+// code that needs a new migration.
+//
+// The regions, subregions, countries, states and cities tables are loaded
+// from the countries-states-cities dump and are not managed here.
+package migrations
+
+import (
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+//go:embed sql/*.sql
+var files embed.FS
+
+const createMigrationsTable = "CREATE TABLE IF NOT EXISTS `schema_migrations` (" +
+	"`version` bigint NOT NULL, " +
+	"`name` varchar(255) NOT NULL, " +
+	"`dirty` boolean NOT NULL DEFAULT false, " +
+	"`applied_at` bigint NOT NULL, " +
+	"PRIMARY KEY (`version`))"
+
+var fileName = regexp.MustCompile(`^(\d+)_([a-z0-9_]+)\.(up|down)\.sql$`)
+
+// Migration is one version of the schema
+type Migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Status is a migration together with whether it has been applied
+type Status struct {
+	Migration
+	Applied   bool
+	Dirty     bool
+	AppliedAt int64 // unix seconds
+}
+
+// Migrator runs migrations against one database
+type Migrator struct {
+	db         *sql.DB
+	migrations []Migration
+}
+
+// New returns a Migrator for db with every embedded migration
+func New(db *sql.DB) (*Migrator, error) {
+	migrations, err := load(files)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{db: db, migrations: migrations}, nil
+}
+
+// load reads and pairs the up and down files, sorted by version
+func load(fsys fs.FS) ([]Migration, error) {
+	entries, err := fs.ReadDir(fsys, "sql")
+	if err != nil {
+		return nil, fmt.Errorf("error reading migrations: %w", err)
+	}
+
+	byVersion := map[int]*Migration{}
+	for _, entry := range entries {
+		match := fileName.FindStringSubmatch(entry.Name())
+		if match == nil {
+			return nil, fmt.Errorf("migration %s is not named <version>_<name>.up|down.sql", entry.Name())
+		}
+
+		version, _ := strconv.Atoi(match[1])
+		body, err := fs.ReadFile(fsys, path.Join("sql", entry.Name()))
+		if err != nil {
+			return nil, fmt.Errorf("error reading migration %s: %w", entry.Name(), err)
+		}
+
+		migration, ok := byVersion[version]
+		if !ok {
+			migration = &Migration{Version: version, Name: match[2]}
+			byVersion[version] = migration
+		}
+		if migration.Name != match[2] {
+			return nil, fmt.Errorf("migration %d has two names, %s and %s", version, migration.Name, match[2])
+		}
+
+		if match[3] == "up" {
+			migration.Up = string(body)
+		} else {
+			migration.Down = string(body)
+		}
+	}
+
+	migrations := make([]Migration, 0, len(byVersion))
+	for _, migration := range byVersion {
+		if migration.Up == "" || migration.Down == "" {
+			return nil, fmt.Errorf("migration %d_%s needs both an up and a down file", migration.Version, migration.Name)
+		}
+		migrations = append(migrations, *migration)
+	}
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].Version < migrations[j].Version
+	})
+
+	return migrations, nil
+}
+
+// Latest is the version the code expects the database to be at
+func (m *Migrator) Latest() int {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
+
+// Status lists every known migration and every applied one, in version order
+func (m *Migrator) Status() ([]Status, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return nil, err
+	}
+
+	var statuses []Status
+	for _, migration := range m.migrations {
+		status := Status{Migration: migration}
+		if row, ok := applied[migration.Version]; ok {
+			status.Applied = true
+			status.Dirty = row.Dirty
+			status.AppliedAt = row.AppliedAt
+			delete(applied, migration.Version)
+		}
+		statuses = append(statuses, status)
+	}
+
+	// applied by newer code that has since been rolled back
+	for _, row := range applied {
+		statuses = append(statuses, row)
+	}
+	sort.Slice(statuses, func(i, j int) bool {
+		return statuses[i].Version < statuses[j].Version
+	})
+
+	return statuses, nil
+}
+
+// Version returns the highest applied version and whether it is dirty, that
+// is it failed half way and needs fixing by hand
+func (m *Migrator) Version() (int, bool, error) {
+	applied, err := m.applied()
+	if err != nil {
+		return 0, false, err
+	}
+
+	version, dirty := 0, false
+	for _, row := range applied {
+		if row.Version > version {
+			version = row.Version
+		}
+		dirty = dirty || row.Dirty
+	}
+	return version, dirty, nil
+}
+
+// Check returns an error unless every migration has been applied cleanly and
+// the database isn't ahead of the code
+func (m *Migrator) Check() error {
+	statuses, err := m.Status()
+	if err != nil {
+		return err
+	}
+
+	var pending []string
+	for _, status := range statuses {
+		switch {
+		case status.Dirty:
+			return fmt.Errorf("migration %d_%s failed half way, fix the schema by hand and delete its row from schema_migrations", status.Version, status.Name)
+		case status.Applied && status.Up == "":
+			return fmt.Errorf("database has migration %d_%s which this build doesn't know about, deploy a newer build", status.Version, status.Name)
+		case !status.Applied:
+			pending = append(pending, fmt.Sprintf("%d_%s", status.Version, status.Name))
+		}
+	}
+
+	if len(pending) > 0 {
+		return fmt.Errorf("database schema is behind, pending migrations: %s (run `business-connect migrate up`)", strings.Join(pending, ", "))
+	}
+	return nil
+}
+
+// Up applies every pending migration in order and returns the ones it ran
+func (m *Migrator) Up() ([]Migration, error) {
+	statuses, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	var ran []Migration
+	for _, status := range statuses {
+		if status.Dirty {
+			return ran, fmt.Errorf("migration %d_%s is dirty, fix it before migrating", status.Version, status.Name)
+		}
+		if status.Applied {
+			continue
+		}
+
+		if err := m.run(status.Migration, true); err != nil {
+			return ran, err
+		}
+		ran = append(ran, status.Migration)
+	}
+
+	return ran, nil
+}
+
+// Down reverts the last steps applied migrations, newest first
+func (m *Migrator) Down(steps int) ([]Migration, error) {
+	statuses, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+
+	var ran []Migration
+	for i := len(statuses) - 1; i >= 0 && len(ran) < steps; i-- {
+		status := statuses[i]
+		if !status.Applied {
+			continue
+		}
+		if status.Dirty {
+			return ran, fmt.Errorf("migration %d_%s is dirty, fix it before migrating", status.Version, status.Name)
+		}
+		if status.Down == "" {
+			return ran, fmt.Errorf("migration %d_%s isn't in this build, it can't be reverted", status.Version, status.Name)
+		}
+
+		if err := m.run(status.Migration, false); err != nil {
+			return ran, err
+		}
+		ran = append(ran, status.Migration)
+	}
+
+	return ran, nil
+}
+
+// run applies one migration. MySQL commits every DDL statement on its own so
+// the row is marked dirty first and only cleared once every statement passed.
+func (m *Migrator) run(migration Migration, up bool) error {
+	label := fmt.Sprintf("%d_%s", migration.Version, migration.Name)
+	body := migration.Down
+	if up {
+		body = migration.Up
+		if _, err := m.db.Exec("INSERT INTO schema_migrations (version, name, dirty, applied_at) VALUES (?, ?, ?, ?)", migration.Version, migration.Name, true, time.Now().Unix()); err != nil {
+			return fmt.Errorf("error recording migration %s: %w", label, err)
+		}
+	} else {
+		if _, err := m.db.Exec("UPDATE schema_migrations SET dirty = ? WHERE version = ?", true, migration.Version); err != nil {
+			return fmt.Errorf("error recording migration %s: %w", label, err)
+		}
+	}
+
+	for _, statement := range splitStatements(body) {
+		if _, err := m.db.Exec(statement); err != nil {
+			return fmt.Errorf("migration %s failed: %w", label, err)
+		}
+	}
+
+	var err error
+	if up {
+		_, err = m.db.Exec("UPDATE schema_migrations SET dirty = ?, applied_at = ? WHERE version = ?", false, time.Now().Unix(), migration.Version)
+	} else {
+		_, err = m.db.Exec("DELETE FROM schema_migrations WHERE version = ?", migration.Version)
+	}
+	if err != nil {
+		return fmt.Errorf("error recording migration %s: %w", label, err)
+	}
+
+	return nil
+}
+
+// applied reads the schema_migrations table, creating it on first use
+func (m *Migrator) applied() (map[int]Status, error) {
+	if _, err := m.db.Exec(createMigrationsTable); err != nil {
+		return nil, fmt.Errorf("error creating schema_migrations: %w", err)
+	}
+
+	rows, err := m.db.Query("SELECT version, name, dirty, applied_at FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("error reading schema_migrations: %w", err)
+	}
+	defer rows.Close()
+
+	applied := map[int]Status{}
+	for rows.Next() {
+		status := Status{Applied: true}
+		if err := rows.Scan(&status.Version, &status.Name, &status.Dirty, &status.AppliedAt); err != nil {
+			return nil, fmt.Errorf("error reading schema_migrations: %w", err)
+		}
+		applied[status.Version] = status
+	}
+
+	return applied, rows.Err()
+}
+
+// splitStatements splits a migration into statements on semicolons at the
+// end of a line, so the DSN doesn't need multiStatements. Comment lines are
+// dropped.
+func splitStatements(body string) []string {
+	var (
+		statements []string
+		current    strings.Builder
+	)
+
+	for _, line := range strings.Split(body, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if trimmed == "" || strings.HasPrefix(trimmed, "--") {
+			continue
+		}
+
+		current.WriteString(line)
+		current.WriteString("\n")
+
+		if strings.HasSuffix(trimmed, ";") {
+			statements = append(statements, strings.TrimSuffix(strings.TrimSpace(current.String()), ";"))
+			current.Reset()
+		}
+	}
+
+	if rest := strings.TrimSpace(current.String()); rest != "" {
+		statements = append(statements, rest)
+	}
+
+	return statements
+}
@@ -0,0 +1,389 @@
+package migrations
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+
+	mysqlDriver "gorm.io/driver/mysql"
+	"gorm.io/gorm"
+	"gorm.io/gorm/schema"
+
+	sqlite "business-connect/database/sqlite"
+	Data "business-connect/models"
+)
+
+// the tables of the countries-states-cities dump, not managed here
+var dumpTables = map[string]bool{"regions": true, "subregions": true, "countries": true, "states": true, "cities": true}
+
+// table is the columns and indexes of one table, each index as its columns
+// with a "unique " prefix when it is unique
+type table struct {
+	columns map[string]bool
+	indexes map[string]string
+}
+
+func newTable() *table {
+	return &table{columns: map[string]bool{}, indexes: map[string]string{}}
+}
+
+// modelSchema is the schema the models describe, what AutoMigrate creates
+// for the integration harness
+func modelSchema(t *testing.T) map[string]*table {
+	t.Helper()
+
+	tables := map[string]*table{}
+	cache := &sync.Map{}
+	for _, model := range sqlite.Models {
+		parsed, err := schema.Parse(model, cache, schema.NamingStrategy{})
+		if err != nil {
+			t.Fatalf("parsing %T: %v", model, err)
+		}
+		if dumpTables[parsed.Table] {
+			continue
+		}
+
+		tbl := newTable()
+		for _, column := range parsed.DBNames {
+			tbl.columns[column] = true
+		}
+		for _, index := range parsed.ParseIndexes() {
+			var columns []string
+			for _, field := range index.Fields {
+				columns = append(columns, field.DBName)
+			}
+			tbl.indexes[index.Name] = indexDefinition(index.Class == "UNIQUE", columns)
+		}
+		tables[parsed.Table] = tbl
+	}
+	return tables
+}
+
+func indexDefinition(unique bool, columns []string) string {
+	definition := strings.Join(columns, ",")
+	if unique {
+		return "unique " + definition
+	}
+	return definition
+}
+
+var (
+	createTable = regexp.MustCompile("(?s)^CREATE TABLE (?:IF NOT EXISTS )?`(\\w+)` \\((.*)\\)")
+	alterTable  = regexp.MustCompile("(?s)^ALTER TABLE `(\\w+)`\\s+(.*)$")
+	createIndex = regexp.MustCompile("^CREATE (UNIQUE )?INDEX `(\\w+)` ON `(\\w+)` \\((.*)\\)$")
+	dropIndex   = regexp.MustCompile("^DROP INDEX `(\\w+)` ON `(\\w+)`$")
+	dropTable   = regexp.MustCompile("^DROP TABLE (?:IF EXISTS )?`(\\w+)`$")
+
+	columnDefinition = regexp.MustCompile("^`(\\w+)` ")
+	indexClause      = regexp.MustCompile("^(UNIQUE )?(?:INDEX|KEY) `(\\w+)` \\((.*)\\)$")
+	addColumn        = regexp.MustCompile("^ADD COLUMN `(\\w+)` ")
+	addIndex         = regexp.MustCompile("^ADD (UNIQUE )?(?:INDEX|KEY) `(\\w+)` \\((.*)\\)$")
+	dropColumn       = regexp.MustCompile("^DROP COLUMN `(\\w+)`$")
+	quoted           = regexp.MustCompile("`(\\w+)`")
+)
+
+// replaySchema follows the DDL of every up migration to the schema they leave
+// behind. Statements that only move rows are skipped, anything else this
+// doesn't understand fails the test so the check can't silently fall behind.
+func replaySchema(t *testing.T, migrations []Migration) map[string]*table {
+	t.Helper()
+
+	tables := map[string]*table{}
+	lookup := func(name string) *table {
+		tbl, ok := tables[name]
+		if !ok {
+			t.Fatalf("migration changes table %s before creating it", name)
+		}
+		return tbl
+	}
+
+	for _, migration := range migrations {
+		for _, statement := range splitStatements(migration.Up) {
+			statement = strings.TrimSpace(statement)
+			oneLine := strings.Join(strings.Fields(statement), " ")
+
+			switch {
+			case strings.HasPrefix(oneLine, "UPDATE "), strings.HasPrefix(oneLine, "INSERT "):
+			case createTable.MatchString(statement):
+				match := createTable.FindStringSubmatch(statement)
+				tbl := newTable()
+				for _, clause := range splitClauses(match[2]) {
+					switch {
+					case strings.HasPrefix(clause, "PRIMARY KEY"), strings.HasPrefix(clause, "CONSTRAINT"):
+					case columnDefinition.MatchString(clause):
+						tbl.columns[columnDefinition.FindStringSubmatch(clause)[1]] = true
+					case indexClause.MatchString(clause):
+						index := indexClause.FindStringSubmatch(clause)
+						tbl.indexes[index[2]] = indexDefinition(index[1] != "", quotedNames(index[3]))
+					default:
+						t.Fatalf("%d_%s: can't follow %q", migration.Version, migration.Name, clause)
+					}
+				}
+				tables[match[1]] = tbl
+			case alterTable.MatchString(statement):
+				match := alterTable.FindStringSubmatch(statement)
+				tbl := lookup(match[1])
+				for _, clause := range splitClauses(match[2]) {
+					switch {
+					case addColumn.MatchString(clause):
+						tbl.columns[addColumn.FindStringSubmatch(clause)[1]] = true
+					case dropColumn.MatchString(clause):
+						delete(tbl.columns, dropColumn.FindStringSubmatch(clause)[1])
+					case addIndex.MatchString(clause):
+						index := addIndex.FindStringSubmatch(clause)
+						tbl.indexes[index[2]] = indexDefinition(index[1] != "", quotedNames(index[3]))
+					default:
+						t.Fatalf("%d_%s: can't follow %q", migration.Version, migration.Name, clause)
+					}
+				}
+			case createIndex.MatchString(oneLine):
+				match := createIndex.FindStringSubmatch(oneLine)
+				lookup(match[3]).indexes[match[2]] = indexDefinition(match[1] != "", quotedNames(match[4]))
+			case dropIndex.MatchString(oneLine):
+				match := dropIndex.FindStringSubmatch(oneLine)
+				delete(lookup(match[2]).indexes, match[1])
+			case dropTable.MatchString(oneLine):
+				delete(tables, dropTable.FindStringSubmatch(oneLine)[1])
+			default:
+				t.Fatalf("%d_%s: can't follow %q", migration.Version, migration.Name, oneLine)
+			}
+		}
+	}
+	return tables
+}
+
+// splitClauses splits the body of a CREATE or ALTER TABLE on the commas
+// outside parentheses
+func splitClauses(body string) []string {
+	var (
+		clauses []string
+		depth   int
+		start   int
+	)
+	for i, r := range body {
+		switch r {
+		case '(':
+			depth++
+		case ')':
+			depth--
+		case ',':
+			if depth == 0 {
+				clauses = append(clauses, strings.Join(strings.Fields(body[start:i]), " "))
+				start = i + 1
+			}
+		}
+	}
+	if rest := strings.Join(strings.Fields(body[start:]), " "); rest != "" {
+		clauses = append(clauses, rest)
+	}
+	return clauses
+}
+
+func quotedNames(list string) []string {
+	var names []string
+	for _, match := range quoted.FindAllStringSubmatch(list, -1) {
+		names = append(names, match[1])
+	}
+	return names
+}
+
+// compareSchemas lists every table, column and index that is in one schema
+// and not the other, or differs between them
+func compareSchemas(migrated, models map[string]*table) []string {
+	var problems []string
+	for name, model := range models {
+		tbl, ok := migrated[name]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("table %s has a model but no migration creates it", name))
+			continue
+		}
+		for column := range model.columns {
+			if !tbl.columns[column] {
+				problems = append(problems, fmt.Sprintf("column %s.%s is in the model but not the migrations", name, column))
+			}
+		}
+		for column := range tbl.columns {
+			if !model.columns[column] {
+				problems = append(problems, fmt.Sprintf("column %s.%s is in the migrations but not the model", name, column))
+			}
+		}
+		for index, definition := range model.indexes {
+			if got, ok := tbl.indexes[index]; !ok {
+				problems = append(problems, fmt.Sprintf("index %s on %s is in the model but not the migrations", index, name))
+			} else if got != definition {
+				problems = append(problems, fmt.Sprintf("index %s on %s is (%s) in the migrations and (%s) in the model", index, name, got, definition))
+			}
+		}
+		for index := range tbl.indexes {
+			if _, ok := model.indexes[index]; !ok {
+				problems = append(problems, fmt.Sprintf("index %s on %s is in the migrations but not the model", index, name))
+			}
+		}
+	}
+	for name := range migrated {
+		if _, ok := models[name]; !ok {
+			problems = append(problems, fmt.Sprintf("table %s is created by the migrations but has no model", name))
+		}
+	}
+	sort.Strings(problems)
+	return problems
+}
+
+// TestMigrationsMatchModels checks the migrations leave the tables the models
+// describe, so the schema the integration tests run on is the one production
+// gets
+func TestMigrationsMatchModels(t *testing.T) {
+	migrations, err := load(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for _, problem := range compareSchemas(replaySchema(t, migrations), modelSchema(t)) {
+		t.Error(problem)
+	}
+}
+
+// TestMigrationsOnMySQL applies every migration to the empty MySQL database
+// at TEST_MYSQL_DSN, compares what they created with the models, then
+// reverts them all
+func TestMigrationsOnMySQL(t *testing.T) {
+	dsn := os.Getenv("TEST_MYSQL_DSN")
+	if dsn == "" {
+		t.Skip("TEST_MYSQL_DSN not set")
+	}
+
+	db, err := gorm.Open(mysqlDriver.Open(dsn), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	sqlDB, err := db.DB()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sqlDB.Close()
+
+	migrator, err := New(sqlDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := migrator.Up(); err != nil {
+		t.Fatal(err)
+	}
+	defer func() {
+		if _, err := migrator.Down(migrator.Latest()); err != nil {
+			t.Errorf("reverting the migrations: %v", err)
+		}
+	}()
+
+	migrated, err := readMySQLSchema(sqlDB)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, problem := range compareSchemas(migrated, modelSchema(t)) {
+		t.Error(problem)
+	}
+}
+
+// readMySQLSchema reads the columns and indexes of the current database,
+// leaving out schema_migrations and the primary keys
+func readMySQLSchema(db *sql.DB) (map[string]*table, error) {
+	tables := map[string]*table{}
+	get := func(name string) *table {
+		if tables[name] == nil {
+			tables[name] = newTable()
+		}
+		return tables[name]
+	}
+
+	columns, err := db.Query("SELECT table_name, column_name FROM information_schema.columns WHERE table_schema = DATABASE() AND table_name <> 'schema_migrations'")
+	if err != nil {
+		return nil, err
+	}
+	defer columns.Close()
+	for columns.Next() {
+		var tableName, column string
+		if err := columns.Scan(&tableName, &column); err != nil {
+			return nil, err
+		}
+		get(tableName).columns[column] = true
+	}
+	if err := columns.Err(); err != nil {
+		return nil, err
+	}
+
+	indexes, err := db.Query("SELECT table_name, index_name, non_unique, GROUP_CONCAT(column_name ORDER BY seq_in_index) " +
+		"FROM information_schema.statistics WHERE table_schema = DATABASE() AND table_name <> 'schema_migrations' AND index_name <> 'PRIMARY' " +
+		"GROUP BY table_name, index_name, non_unique")
+	if err != nil {
+		return nil, err
+	}
+	defer indexes.Close()
+	for indexes.Next() {
+		var tableName, index, indexColumns string
+		var nonUnique bool
+		if err := indexes.Scan(&tableName, &index, &nonUnique, &indexColumns); err != nil {
+			return nil, err
+		}
+		get(tableName).indexes[index] = indexDefinition(!nonUnique, strings.Split(indexColumns, ","))
+	}
+	return tables, indexes.Err()
+}
+
+// TestPostCoordinatesBackfill runs the backfill of 0015 on SQLite, its SQL
+// has to work on both
+func TestPostCoordinatesBackfill(t *testing.T) {
+	migrations, err := load(files)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var backfill string
+	for _, migration := range migrations {
+		if migration.Version != 15 {
+			continue
+		}
+		for _, statement := range splitStatements(migration.Up) {
+			if strings.HasPrefix(statement, "UPDATE") {
+				backfill = statement
+			}
+		}
+	}
+	if backfill == "" {
+		t.Fatal("0015 has no backfill")
+	}
+
+	db, err := sqlite.OpenMemory()
+	if err != nil {
+		t.Fatal(err)
+	}
+	located := Data.User{Email: "located@example.com", Latitude: 6.5, Longitude: 3.4}
+	unlocated := Data.User{Email: "unlocated@example.com"}
+	for _, user := range []*Data.User{&located, &unlocated} {
+		if err := db.Create(user).Error; err != nil {
+			t.Fatal(err)
+		}
+	}
+	posts := []Data.Post{{UserID: located.ID, Title: "located"}, {UserID: unlocated.ID, Title: "unlocated"}}
+	if err := db.Create(&posts).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	if err := db.Exec(backfill).Error; err != nil {
+		t.Fatal(err)
+	}
+
+	var got []Data.Post
+	if err := db.Order("id").Find(&got).Error; err != nil {
+		t.Fatal(err)
+	}
+	if got[0].Latitude == nil || *got[0].Latitude != 6.5 || got[0].Longitude == nil || *got[0].Longitude != 3.4 {
+		t.Errorf("post of a located seller has %v,%v, want 6.5,3.4", got[0].Latitude, got[0].Longitude)
+	}
+	if got[1].Latitude != nil || got[1].Longitude != nil {
+		t.Errorf("post of a seller without coordinates has %v,%v, want none", got[1].Latitude, got[1].Longitude)
+	}
+}
@@ -0,0 +1,8 @@
+DROP TABLE IF EXISTS `jtis`;
+DROP TABLE IF EXISTS `connections`;
+DROP TABLE IF EXISTS `group_participants`;
+DROP TABLE IF EXISTS `profile_images`;
+DROP TABLE IF EXISTS `post_images`;
+DROP TABLE IF EXISTS `posts`;
+DROP TABLE IF EXISTS `otps`;
+DROP TABLE IF EXISTS `users`;
@@ -0,0 +1,154 @@
+-- Tables that were created by AutoMigrate before versioned migrations.
+-- IF NOT EXISTS lets an existing database adopt this migration as is.
+
+CREATE TABLE IF NOT EXISTS `users` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `full_name` varchar(100) NOT NULL,
+    `business_name` varchar(100) NOT NULL,
+    `bio_description` varchar(100) NOT NULL,
+    `email` varchar(191) NOT NULL,
+    `pending_email` longtext,
+    `password` longtext,
+    `phone_number` varchar(15),
+    `profile_photo_url` longtext,
+    `cover_photo_url` longtext,
+    `email_verified` boolean DEFAULT false,
+    `verified` boolean DEFAULT false,
+    `suspended` boolean DEFAULT false,
+    `address` longtext,
+    `state` longtext,
+    `country` longtext,
+    `language` longtext,
+    `longitude` double,
+    `latitude` double,
+    `connections_count` bigint DEFAULT 0,
+    `total_revenue` double DEFAULT 0,
+    `total_sales` bigint DEFAULT 0,
+    `total_customer` bigint DEFAULT 0,
+    `total_product` bigint DEFAULT 0,
+    `user_type` varchar(20),
+    `refresh_token` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_users_deleted_at` (`deleted_at`),
+    UNIQUE INDEX `idx_users_email` (`email`),
+    INDEX `idx_users_phone_number` (`phone_number`),
+    INDEX `idx_users_user_type` (`user_type`)
+);
+
+CREATE TABLE IF NOT EXISTS `otps` (
+    `custom_id` bigint unsigned AUTO_INCREMENT,
+    `password_reset` boolean,
+    `link_whatsapp` boolean,
+    `email_verification` boolean,
+    `phone_number_verification` boolean,
+    `otp` longtext,
+    `email` longtext,
+    `phone_number` longtext,
+    `created_at` bigint,
+    `max_try` bigint,
+    PRIMARY KEY (`custom_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `posts` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `user_id` bigint unsigned,
+    `user_name` varchar(191),
+    `profile_photo_url` longtext,
+    `phone_number` varchar(15),
+    `verified` boolean DEFAULT false,
+    `post_type` varchar(20),
+    `title` varchar(200) NOT NULL,
+    `product_url_id` varchar(200) NOT NULL,
+    `description` text NOT NULL,
+    `whatsapp_url` longtext NOT NULL,
+    `is_sponsored` boolean DEFAULT false,
+    `is_active` boolean DEFAULT true,
+    `stock_availability` varchar(191) DEFAULT 'true',
+    `product_price` bigint DEFAULT 0,
+    `views` bigint DEFAULT 0,
+    `clicks` bigint DEFAULT 0,
+    `location` longtext,
+    `business_category` longtext,
+    `entry_type` longtext,
+    `entry_price` bigint,
+    `max_members` bigint,
+    `members_count` bigint,
+    `event_date` datetime(3) NULL,
+    `approved` boolean DEFAULT true,
+    PRIMARY KEY (`id`),
+    INDEX `idx_posts_deleted_at` (`deleted_at`),
+    INDEX `idx_posts_user_id` (`user_id`),
+    INDEX `idx_posts_user_name` (`user_name`),
+    INDEX `idx_posts_phone_number` (`phone_number`),
+    INDEX `idx_posts_post_type` (`post_type`),
+    CONSTRAINT `fk_users_posts` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS `post_images` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `post_id` bigint unsigned,
+    `url` longtext,
+    `original_file_name` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_post_images_deleted_at` (`deleted_at`),
+    CONSTRAINT `fk_posts_images` FOREIGN KEY (`post_id`) REFERENCES `posts`(`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS `profile_images` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `user_id` bigint unsigned,
+    `kind` varchar(20) DEFAULT 'profile',
+    `url` longtext,
+    `original_file_name` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_profile_images_deleted_at` (`deleted_at`),
+    CONSTRAINT `fk_users_images` FOREIGN KEY (`user_id`) REFERENCES `users`(`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS `group_participants` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `post_id` bigint unsigned,
+    `user_id` bigint unsigned,
+    `full_name` longtext,
+    `profile_photo_url` longtext,
+    `verified` boolean,
+    PRIMARY KEY (`id`),
+    INDEX `idx_group_participants_deleted_at` (`deleted_at`),
+    INDEX `idx_group_participants_post_id` (`post_id`),
+    INDEX `idx_group_participants_user_id` (`user_id`),
+    CONSTRAINT `fk_posts_group_participants` FOREIGN KEY (`post_id`) REFERENCES `posts`(`id`) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS `connections` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `user_id` bigint unsigned,
+    `connected_user_id` bigint unsigned,
+    `status` varchar(20) DEFAULT 'pending',
+    PRIMARY KEY (`id`),
+    INDEX `idx_connections_deleted_at` (`deleted_at`),
+    INDEX `idx_connections_user_id` (`user_id`),
+    INDEX `idx_connections_connected_user_id` (`connected_user_id`)
+);
+
+CREATE TABLE IF NOT EXISTS `jtis` (
+    `jti` varchar(255),
+    `user_id` bigint unsigned
+);
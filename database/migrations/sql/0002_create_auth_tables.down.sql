@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS `account_deletions`;
+DROP TABLE IF EXISTS `o_id_c_login_states`;
+DROP TABLE IF EXISTS `user_identities`;
+DROP TABLE IF EXISTS `login_attempts`;
@@ -0,0 +1,67 @@
+-- Sign-in lockout, social login and account deletion.
+
+CREATE TABLE IF NOT EXISTS `login_attempts` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `attempt_key` varchar(255) NOT NULL,
+    `email` varchar(255),
+    `failures` bigint DEFAULT 0,
+    `lock_count` bigint DEFAULT 0,
+    `last_failed_at` bigint,
+    `locked_until` bigint,
+    `unlock_token` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_login_attempts_deleted_at` (`deleted_at`),
+    UNIQUE INDEX `idx_login_attempts_attempt_key` (`attempt_key`),
+    INDEX `idx_login_attempts_email` (`email`),
+    INDEX `idx_login_attempts_locked_until` (`locked_until`)
+);
+
+CREATE TABLE IF NOT EXISTS `user_identities` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `user_id` bigint unsigned,
+    `provider` varchar(20),
+    `subject` varchar(255),
+    `email` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_user_identities_deleted_at` (`deleted_at`),
+    INDEX `idx_user_identities_user_id` (`user_id`),
+    UNIQUE INDEX `idx_provider_subject` (`provider`,`subject`)
+);
+
+CREATE TABLE IF NOT EXISTS `o_id_c_login_states` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `state` varchar(64) NOT NULL,
+    `provider` varchar(20),
+    `nonce` varchar(64),
+    `code_verifier` varchar(128),
+    `redirect_to` longtext,
+    `expires_at` bigint,
+    PRIMARY KEY (`id`),
+    INDEX `idx_o_id_c_login_states_deleted_at` (`deleted_at`),
+    UNIQUE INDEX `idx_o_id_c_login_states_state` (`state`),
+    INDEX `idx_o_id_c_login_states_expires_at` (`expires_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `account_deletions` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `user_id` bigint unsigned,
+    `email` varchar(255),
+    `scheduled_for` bigint,
+    `cancel_token` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_account_deletions_deleted_at` (`deleted_at`),
+    UNIQUE INDEX `idx_account_deletions_user_id` (`user_id`),
+    INDEX `idx_account_deletions_scheduled_for` (`scheduled_for`)
+);
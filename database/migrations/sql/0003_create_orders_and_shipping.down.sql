@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS `shipping_fees`;
+DROP TABLE IF EXISTS `product_orders`;
+DROP TABLE IF EXISTS `order_histories`;
@@ -0,0 +1,74 @@
+-- Orders and store shipping fees, queried by the order and Paystack code
+-- but never migrated before.
+
+CREATE TABLE IF NOT EXISTS `order_histories` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `productt_id` bigint unsigned,
+    `order_status` longtext,
+    `payment_status` longtext,
+    `quantity` bigint,
+    `order_cost` double,
+    `order_note` longtext,
+    `order_sub_total_cost` double,
+    `shipping_cost` double,
+    `order_discount` double,
+    `customer_email` longtext,
+    `customer_f_name` longtext,
+    `customer_s_name` longtext,
+    `customer_company_name` longtext,
+    `customer_state` longtext,
+    `customer_city` longtext,
+    `customer_street_address1` longtext,
+    `customer_street_address2` longtext,
+    `customer_zip_code` longtext,
+    `customer_province` longtext,
+    `customer_phone_number` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_order_histories_deleted_at` (`deleted_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `product_orders` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `order_history_id` bigint unsigned,
+    `productt_id` bigint unsigned,
+    `product_url_id` longtext,
+    `title` longtext,
+    `description` longtext,
+    `net_weight` bigint,
+    `order_cost` double,
+    `currency` longtext,
+    `quantity` bigint,
+    `category` longtext,
+    `image1` longtext,
+    `image2` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_product_orders_deleted_at` (`deleted_at`),
+    CONSTRAINT `fk_order_histories_product_orders` FOREIGN KEY (`order_history_id`) REFERENCES `order_histories`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `shipping_fees` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `eshop_user_id` bigint unsigned,
+    `store_name` longtext,
+    `store_email` longtext,
+    `shipping_fee_per_km` bigint,
+    `shipping_fee_greater` bigint,
+    `shipping_fee_less` bigint,
+    `store_latitude` double,
+    `store_longitude` double,
+    `store_state` longtext,
+    `store_city` longtext,
+    `state_iso` longtext,
+    `calculate_using_kg` boolean,
+    PRIMARY KEY (`id`),
+    INDEX `idx_shipping_fees_deleted_at` (`deleted_at`)
+);
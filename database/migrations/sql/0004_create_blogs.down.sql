@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS `blog_images`;
+DROP TABLE IF EXISTS `customer_blog_reviews`;
+DROP TABLE IF EXISTS `blogs`;
@@ -0,0 +1,49 @@
+-- Blog posts with their reviews and images.
+
+CREATE TABLE IF NOT EXISTS `blogs` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `user_id` bigint unsigned,
+    `blog_url_id` longtext,
+    `title` longtext,
+    `description1` text,
+    `description2` text,
+    `blog_category` longtext,
+    `image1` longtext,
+    `image2` longtext,
+    `image3` longtext,
+    `blog_reviews_count` bigint,
+    PRIMARY KEY (`id`),
+    INDEX `idx_blogs_deleted_at` (`deleted_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `customer_blog_reviews` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `blog_id` bigint unsigned,
+    `email` longtext,
+    `name` longtext,
+    `review` longtext,
+    `rating` bigint,
+    `add_email` boolean,
+    PRIMARY KEY (`id`),
+    INDEX `idx_customer_blog_reviews_deleted_at` (`deleted_at`),
+    CONSTRAINT `fk_blogs_customer_blog_reviews` FOREIGN KEY (`blog_id`) REFERENCES `blogs`(`id`)
+);
+
+CREATE TABLE IF NOT EXISTS `blog_images` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `blog_id` bigint unsigned,
+    `url` longtext,
+    `original_file_name` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_blog_images_deleted_at` (`deleted_at`),
+    CONSTRAINT `fk_blogs_blog_images` FOREIGN KEY (`blog_id`) REFERENCES `blogs`(`id`)
+);
@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS `emails`;
+DROP TABLE IF EXISTS `business_connect_email_subscribers`;
+DROP TABLE IF EXISTS `subscribe_to_emails`;
@@ -0,0 +1,33 @@
+-- Newsletter subscribers and the emails sent to them.
+
+CREATE TABLE IF NOT EXISTS `subscribe_to_emails` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `email` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_subscribe_to_emails_deleted_at` (`deleted_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `business_connect_email_subscribers` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `email` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_business_connect_email_subscribers_deleted_at` (`deleted_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `emails` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `subject` longtext,
+    `content` text,
+    `send_to` longtext,
+    PRIMARY KEY (`id`),
+    INDEX `idx_emails_deleted_at` (`deleted_at`)
+);
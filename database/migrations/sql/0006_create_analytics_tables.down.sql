@@ -0,0 +1,4 @@
+DROP TABLE IF EXISTS `business_connect_user_activities`;
+DROP TABLE IF EXISTS `business_connect_device_fingerprints`;
+DROP TABLE IF EXISTS `site_visits`;
+DROP TABLE IF EXISTS `analytics`;
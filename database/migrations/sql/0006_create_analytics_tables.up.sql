@@ -0,0 +1,64 @@
+-- Monthly analytics, site visits and anonymous device activity.
+
+CREATE TABLE IF NOT EXISTS `analytics` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `month` datetime(3) NULL,
+    `total_revenue` double,
+    `revenue_change` double,
+    `total_sales` bigint,
+    `sales_change` double,
+    `total_customers` bigint,
+    `customer_change` double,
+    `total_products` bigint,
+    `product_change` double,
+    `daily_visitors` bigint,
+    `top_product1_id` bigint unsigned,
+    `top_product2_id` bigint unsigned,
+    `is_revenue_better` boolean,
+    `is_sales_better` boolean,
+    `is_customers_better` boolean,
+    `is_products_better` boolean,
+    PRIMARY KEY (`id`),
+    INDEX `idx_analytics_deleted_at` (`deleted_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `site_visits` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `site_visit_number` bigint unsigned,
+    PRIMARY KEY (`id`),
+    INDEX `idx_site_visits_deleted_at` (`deleted_at`)
+);
+
+CREATE TABLE IF NOT EXISTS `business_connect_device_fingerprints` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `fingerprint_hash` varchar(64) NOT NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_business_connect_device_fingerprints_deleted_at` (`deleted_at`),
+    UNIQUE INDEX `idx_business_connect_device_fingerprints_fingerprint_hash` (`fingerprint_hash`)
+);
+
+CREATE TABLE IF NOT EXISTS `business_connect_user_activities` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `fingerprint_hash` varchar(64),
+    `activity_type` longtext,
+    `click_count` bigint unsigned,
+    `product_id` bigint unsigned,
+    `category` longtext,
+    `title_or_search_query` longtext,
+    `last_updated` datetime(3) NULL,
+    PRIMARY KEY (`id`),
+    INDEX `idx_business_connect_user_activities_deleted_at` (`deleted_at`),
+    INDEX `idx_business_connect_user_activities_fingerprint_hash` (`fingerprint_hash`)
+);
@@ -0,0 +1,4 @@
+ALTER TABLE `emails`
+    DROP INDEX `idx_emails_completed_at`,
+    DROP COLUMN `completed_at`,
+    DROP COLUMN `last_subscriber_id`;
@@ -0,0 +1,10 @@
+-- Newsletter progress, so a send cut off by a restart carries on from the
+-- last subscriber instead of starting over or stopping.
+
+ALTER TABLE `emails`
+    ADD COLUMN `last_subscriber_id` bigint unsigned DEFAULT 0,
+    ADD COLUMN `completed_at` bigint DEFAULT 0,
+    ADD INDEX `idx_emails_completed_at` (`completed_at`);
+
+-- emails sent before this have no progress to resume
+UPDATE `emails` SET `completed_at` = UNIX_TIMESTAMP(COALESCE(`updated_at`, `created_at`, NOW()));
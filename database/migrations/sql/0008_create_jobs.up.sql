@@ -0,0 +1,29 @@
+-- Background jobs: emails, SMS and AI calls, retried until they succeed or
+-- run out of attempts.
+
+CREATE TABLE IF NOT EXISTS `jobs` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `type` varchar(64),
+    `payload` text,
+    `status` varchar(16) DEFAULT 'pending',
+    `attempts` bigint DEFAULT 0,
+    `max_attempts` bigint DEFAULT 5,
+    `run_at` bigint,
+    `locked_until` bigint,
+    `last_error` text,
+    `result` text,
+    `finished_at` bigint,
+    PRIMARY KEY (`id`),
+    INDEX `idx_jobs_deleted_at` (`deleted_at`),
+    INDEX `idx_jobs_type` (`type`),
+    INDEX `idx_jobs_status_run_at` (`status`,`run_at`)
+);
+
+-- newsletters a restart cut off carry on as jobs
+INSERT INTO `jobs` (`created_at`, `updated_at`, `type`, `payload`, `status`, `attempts`, `max_attempts`, `run_at`, `locked_until`, `finished_at`)
+SELECT NOW(3), NOW(3), 'email.newsletter', CONCAT('{"email_id":', `id`, '}'), 'pending', 0, 5, UNIX_TIMESTAMP(), 0, 0
+FROM `emails`
+WHERE `completed_at` = 0 AND `send_to` <> 'me' AND `deleted_at` IS NULL;
@@ -0,0 +1,26 @@
+ALTER TABLE `post_images`
+    DROP COLUMN `width`,
+    DROP COLUMN `height`,
+    DROP COLUMN `full_webp_url`,
+    DROP COLUMN `feed_url`,
+    DROP COLUMN `feed_webp_url`,
+    DROP COLUMN `thumb_url`,
+    DROP COLUMN `thumb_webp_url`;
+
+ALTER TABLE `profile_images`
+    DROP COLUMN `width`,
+    DROP COLUMN `height`,
+    DROP COLUMN `full_webp_url`,
+    DROP COLUMN `feed_url`,
+    DROP COLUMN `feed_webp_url`,
+    DROP COLUMN `thumb_url`,
+    DROP COLUMN `thumb_webp_url`;
+
+ALTER TABLE `blog_images`
+    DROP COLUMN `width`,
+    DROP COLUMN `height`,
+    DROP COLUMN `full_webp_url`,
+    DROP COLUMN `feed_url`,
+    DROP COLUMN `feed_webp_url`,
+    DROP COLUMN `thumb_url`,
+    DROP COLUMN `thumb_webp_url`;
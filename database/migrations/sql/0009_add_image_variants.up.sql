@@ -0,0 +1,29 @@
+-- Image variants: the sizes every uploaded image is stored in, JPEG and
+-- WebP, beside the full size JPEG in `url`. Images from before have none.
+
+ALTER TABLE `post_images`
+    ADD COLUMN `width` bigint DEFAULT 0,
+    ADD COLUMN `height` bigint DEFAULT 0,
+    ADD COLUMN `full_webp_url` varchar(512),
+    ADD COLUMN `feed_url` varchar(512),
+    ADD COLUMN `feed_webp_url` varchar(512),
+    ADD COLUMN `thumb_url` varchar(512),
+    ADD COLUMN `thumb_webp_url` varchar(512);
+
+ALTER TABLE `profile_images`
+    ADD COLUMN `width` bigint DEFAULT 0,
+    ADD COLUMN `height` bigint DEFAULT 0,
+    ADD COLUMN `full_webp_url` varchar(512),
+    ADD COLUMN `feed_url` varchar(512),
+    ADD COLUMN `feed_webp_url` varchar(512),
+    ADD COLUMN `thumb_url` varchar(512),
+    ADD COLUMN `thumb_webp_url` varchar(512);
+
+ALTER TABLE `blog_images`
+    ADD COLUMN `width` bigint DEFAULT 0,
+    ADD COLUMN `height` bigint DEFAULT 0,
+    ADD COLUMN `full_webp_url` varchar(512),
+    ADD COLUMN `feed_url` varchar(512),
+    ADD COLUMN `feed_webp_url` varchar(512),
+    ADD COLUMN `thumb_url` varchar(512),
+    ADD COLUMN `thumb_webp_url` varchar(512);
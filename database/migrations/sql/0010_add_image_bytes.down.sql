@@ -0,0 +1,3 @@
+ALTER TABLE `post_images` DROP COLUMN `bytes`;
+ALTER TABLE `profile_images` DROP COLUMN `bytes`;
+ALTER TABLE `blog_images` DROP COLUMN `bytes`;
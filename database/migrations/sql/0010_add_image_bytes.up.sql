@@ -0,0 +1,6 @@
+-- Stored size of each image's variants, added up per user for the storage
+-- quota. Images from before count as 0.
+
+ALTER TABLE `post_images` ADD COLUMN `bytes` bigint DEFAULT 0;
+ALTER TABLE `profile_images` ADD COLUMN `bytes` bigint DEFAULT 0;
+ALTER TABLE `blog_images` ADD COLUMN `bytes` bigint DEFAULT 0;
@@ -0,0 +1,26 @@
+-- Direct uploads: the client PUTs an image straight to storage and confirms,
+-- sessions never confirmed are deleted once they expire.
+
+CREATE TABLE IF NOT EXISTS `upload_sessions` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `token` varchar(64),
+    `user_id` bigint unsigned,
+    `purpose` varchar(20),
+    `target_id` bigint unsigned,
+    `object_key` varchar(512),
+    `filename` varchar(100),
+    `content_type` varchar(50),
+    `max_bytes` bigint,
+    `status` varchar(20),
+    `expires_at` bigint,
+    `confirmed_at` bigint,
+    PRIMARY KEY (`id`),
+    INDEX `idx_upload_sessions_deleted_at` (`deleted_at`),
+    UNIQUE INDEX `idx_upload_sessions_token` (`token`),
+    INDEX `idx_upload_sessions_user_id` (`user_id`),
+    INDEX `idx_upload_sessions_status` (`status`),
+    INDEX `idx_upload_sessions_expires_at` (`expires_at`)
+);
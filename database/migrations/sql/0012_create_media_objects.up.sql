@@ -0,0 +1,20 @@
+-- Every stored image is recorded so the sweeper can find and delete the ones
+-- no post, blog or profile uses. Images stored before this aren't recorded
+-- and are left alone.
+
+CREATE TABLE IF NOT EXISTS `media_objects` (
+    `id` bigint unsigned AUTO_INCREMENT,
+    `created_at` datetime(3) NULL,
+    `updated_at` datetime(3) NULL,
+    `deleted_at` datetime(3) NULL,
+    `url` varchar(512),
+    `object_keys` text,
+    `bytes` bigint,
+    `checked_at` bigint,
+    `delete_after` bigint,
+    PRIMARY KEY (`id`),
+    INDEX `idx_media_objects_deleted_at` (`deleted_at`),
+    UNIQUE INDEX `idx_media_objects_url` (`url`),
+    INDEX `idx_media_objects_checked_at` (`checked_at`),
+    INDEX `idx_media_objects_delete_after` (`delete_after`)
+);
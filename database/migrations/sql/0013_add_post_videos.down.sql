@@ -0,0 +1,4 @@
+ALTER TABLE `post_images` DROP COLUMN `poster_url`;
+ALTER TABLE `post_images` DROP COLUMN `duration_ms`;
+ALTER TABLE `post_images` DROP COLUMN `content_type`;
+ALTER TABLE `post_images` DROP COLUMN `kind`;
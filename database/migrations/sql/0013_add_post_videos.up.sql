@@ -0,0 +1,7 @@
+-- Post media can be a video: the kind of each row, and for videos the
+-- content type, length and poster frame. Every row from before is an image.
+
+ALTER TABLE `post_images` ADD COLUMN `kind` varchar(10) DEFAULT 'image';
+ALTER TABLE `post_images` ADD COLUMN `content_type` varchar(50);
+ALTER TABLE `post_images` ADD COLUMN `duration_ms` bigint;
+ALTER TABLE `post_images` ADD COLUMN `poster_url` varchar(512);
@@ -0,0 +1,11 @@
+-- The search index: the stemmed terms of every post and how much each counts.
+-- It is filled by the API as posts are saved, run
+-- `business-connect search reindex` once to index the posts already there.
+
+CREATE TABLE IF NOT EXISTS `search_terms` (
+    `post_id` bigint unsigned,
+    `term` varchar(64),
+    `weight` bigint,
+    PRIMARY KEY (`post_id`, `term`),
+    INDEX `idx_search_terms_term` (`term`)
+);
@@ -0,0 +1,4 @@
+DROP INDEX `idx_users_coordinates` ON `users`;
+DROP INDEX `idx_posts_coordinates` ON `posts`;
+ALTER TABLE `posts` DROP COLUMN `longitude`;
+ALTER TABLE `posts` DROP COLUMN `latitude`;
@@ -0,0 +1,17 @@
+-- Posts carry coordinates for the "near me" queries, NULL where unknown.
+-- Posts from before take their seller's coordinates where the seller has
+-- set them. Users get the same index for finding businesses nearby.
+
+ALTER TABLE `posts` ADD COLUMN `latitude` double NULL;
+ALTER TABLE `posts` ADD COLUMN `longitude` double NULL;
+CREATE INDEX `idx_posts_coordinates` ON `posts` (`latitude`, `longitude`);
+CREATE INDEX `idx_users_coordinates` ON `users` (`latitude`, `longitude`);
+
+-- correlated subqueries rather than UPDATE ... JOIN, so SQLite runs it too
+UPDATE `posts`
+SET `latitude` = (SELECT `users`.`latitude` FROM `users` WHERE `users`.`id` = `posts`.`user_id`),
+    `longitude` = (SELECT `users`.`longitude` FROM `users` WHERE `users`.`id` = `posts`.`user_id`)
+WHERE EXISTS (
+    SELECT 1 FROM `users`
+    WHERE `users`.`id` = `posts`.`user_id` AND NOT (`users`.`latitude` = 0 AND `users`.`longitude` = 0)
+);
@@ -0,0 +1 @@
+ALTER TABLE `login_attempts` DROP COLUMN `unlock_token_expires_at`;
@@ -0,0 +1,4 @@
+-- Unlock links expire. Tokens from before never got an expiry and stop
+-- working, the lock they were for still runs out on its own.
+
+ALTER TABLE `login_attempts` ADD COLUMN `unlock_token_expires_at` bigint;
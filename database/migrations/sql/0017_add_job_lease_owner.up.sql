@@ -0,0 +1,4 @@
+-- Which worker process holds a job's claim, a worker whose claim ran out
+-- can't record the outcome of a job another worker has claimed since.
+
+ALTER TABLE `jobs` ADD COLUMN `locked_by` varchar(64);
@@ -0,0 +1,2 @@
+DROP INDEX `idx_business_connect_user_activities_user_id` ON `business_connect_user_activities`;
+ALTER TABLE `business_connect_user_activities` DROP COLUMN `user_id`;
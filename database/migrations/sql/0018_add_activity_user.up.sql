@@ -0,0 +1,6 @@
+-- Clicks and views remember the signed in user who made them, so they are
+-- part of that user's data export and go when the account is deleted.
+-- Activity from before stays with the device only.
+
+ALTER TABLE `business_connect_user_activities` ADD COLUMN `user_id` bigint unsigned;
+CREATE INDEX `idx_business_connect_user_activities_user_id` ON `business_connect_user_activities` (`user_id`);
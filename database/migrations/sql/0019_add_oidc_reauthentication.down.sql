@@ -0,0 +1,2 @@
+ALTER TABLE `user_identities` DROP COLUMN `reauthenticated_at`;
+ALTER TABLE `o_id_c_login_states` DROP COLUMN `reauth`;
@@ -0,0 +1,5 @@
+-- A user whose password they never set (signed up through Google or Apple)
+-- confirms changes like a new email by signing in at the provider again.
+
+ALTER TABLE `o_id_c_login_states` ADD COLUMN `reauth` boolean;
+ALTER TABLE `user_identities` ADD COLUMN `reauthenticated_at` bigint;
@@ -0,0 +1,47 @@
+// Package redis opens the Redis connection the prefork processes share for
+// state that has to be the same in all of them, such as rate limit counters.
+package redis
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+
+	config "business-connect/config"
+)
+
+// how long startup waits for the first ping
+const pingTimeout = 5 * time.Second
+
+// Connect opens a client for cfg.URL and checks Redis answers. It returns a
+// nil client when no URL is set, callers fall back to per process state.
+func Connect(cfg config.RedisConfig) (*goredis.Client, error) {
+	if cfg.URL == "" {
+		return nil, nil
+	}
+
+	opts, err := goredis.ParseURL(cfg.URL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid REDIS_URL: %w", err)
+	}
+	// a request waiting on Redis is worse than a request let through, keep
+	// every call short
+	opts.DialTimeout = 2 * time.Second
+	opts.ReadTimeout = 500 * time.Millisecond
+	opts.WriteTimeout = 500 * time.Millisecond
+
+	client := goredis.NewClient(opts)
+
+	ctx, cancel := context.WithTimeout(context.Background(), pingTimeout)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		client.Close()
+		return nil, fmt.Errorf("failed to connect to redis: %w", err)
+	}
+
+	slog.Info("connected to redis")
+	return client, nil
+}
@@ -0,0 +1,89 @@
+// Package sqlite opens a SQLite database with the same tables as MySQL, for
+// the integration harness and for running the API without a MySQL server.
+//
+// The versioned migrations are written for MySQL so the schema is created
+// with AutoMigrate from the models instead. The migrations tests check both
+// give the same columns and indexes. Queries that use MySQL only SQL
+// (DATE_FORMAT, FIELD, MATCH ... AGAINST and friends) fail here.
+package sqlite
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	sqliteDriver "gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	Data "business-connect/models"
+)
+
+// Models is every table the API reads or writes, in an order that satisfies
+// the foreign keys
+var Models = []interface{}{
+	// loaded from the countries-states-cities dump on MySQL
+	&Data.Region{},
+	&Data.Subregion{},
+	&Data.Country{},
+	&Data.State{},
+	&Data.City{},
+
+	&Data.User{},
+	&Data.OTP{},
+	&Data.Post{},
+	&Data.PostImage{},
+	&Data.ProfileImage{},
+	&Data.GroupParticipant{},
+	&Data.Connection{},
+	&Data.JTI{},
+	&Data.LoginAttempt{},
+	&Data.UserIdentity{},
+	&Data.OIDCLoginState{},
+	&Data.AccountDeletion{},
+	&Data.OrderHistory{},
+	&Data.ProductOrder{},
+	&Data.ShippingFees{},
+	&Data.Blog{},
+	&Data.CustomerBlogReview{},
+	&Data.BlogImage{},
+	&Data.SubscribeToEmail{},
+	&Data.BusinessConnectEmailSubscriber{},
+	&Data.Email{},
+	&Data.Analytics{},
+	&Data.SiteVisit{},
+	&Data.BusinessConnectDeviceFingerprint{},
+	&Data.BusinessConnectUserActivity{},
+	&Data.Job{},
+	&Data.UploadSession{},
+	&Data.MediaObject{},
+	&Data.SearchTerm{},
+}
+
+var memoryDatabases atomic.Int64
+
+// Open opens the SQLite database file at path and creates the tables
+func Open(path string) (*gorm.DB, error) {
+	return open(path + "?_foreign_keys=on&_busy_timeout=5000")
+}
+
+// OpenMemory opens a fresh in-memory database. Every call gets its own
+// database so harnesses running side by side don't see each other's rows.
+// The database lives as long as the pool keeps a connection to it open.
+func OpenMemory() (*gorm.DB, error) {
+	return open(fmt.Sprintf("file:business-connect-%d?mode=memory&cache=shared&_foreign_keys=on", memoryDatabases.Add(1)))
+}
+
+func open(dsn string) (*gorm.DB, error) {
+	db, err := gorm.Open(sqliteDriver.Open(dsn), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite database: %w", err)
+	}
+
+	if err := db.AutoMigrate(Models...); err != nil {
+		return nil, fmt.Errorf("failed to create sqlite tables: %w", err)
+	}
+
+	return db, nil
+}
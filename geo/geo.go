@@ -0,0 +1,70 @@
+// Package geo measures distances between coordinates for the "near me"
+// queries. A query first narrows rows down with a bounding box, which an
+// index on latitude and longitude can answer, then measures each with
+// Distance.
+package geo
+
+import "math"
+
+// earthRadiusKm is the mean radius of the Earth
+const earthRadiusKm = 6371.0088
+
+// Point is a latitude and longitude in degrees
+type Point struct {
+	Lat float64 `json:"latitude"`
+	Lng float64 `json:"longitude"`
+}
+
+// Valid reports whether p is on the map
+func Valid(lat, lng float64) bool {
+	return lat >= -90 && lat <= 90 && lng >= -180 && lng <= 180 &&
+		!math.IsNaN(lat) && !math.IsNaN(lng)
+}
+
+// Distance is the great circle distance from a to b in km, by the
+// Haversine formula
+func Distance(a, b Point) float64 {
+	lat1, lat2 := radians(a.Lat), radians(b.Lat)
+	dLat := lat2 - lat1
+	dLng := radians(b.Lng - a.Lng)
+	h := math.Sin(dLat/2)*math.Sin(dLat/2) + math.Cos(lat1)*math.Cos(lat2)*math.Sin(dLng/2)*math.Sin(dLng/2)
+	return 2 * earthRadiusKm * math.Asin(math.Min(1, math.Sqrt(h)))
+}
+
+// Box is the smallest latitude and longitude range holding every point
+// within radiusKm of p. AnyLng is true when the circle reaches a pole or
+// the antimeridian, any longitude can be in it then.
+type Box struct {
+	MinLat, MaxLat float64
+	MinLng, MaxLng float64
+	AnyLng         bool
+}
+
+// BoxAround is the Box of the circle of radiusKm around p
+func BoxAround(p Point, radiusKm float64) Box {
+	dLat := degrees(radiusKm / earthRadiusKm)
+	box := Box{MinLat: p.Lat - dLat, MaxLat: p.Lat + dLat}
+	if box.MinLat <= -90 || box.MaxLat >= 90 {
+		box.MinLat, box.MaxLat = math.Max(box.MinLat, -90), math.Min(box.MaxLat, 90)
+		box.AnyLng = true
+		return box
+	}
+
+	// a degree of longitude shrinks towards the poles
+	dLng := degrees(math.Asin(math.Min(1, math.Sin(radiusKm/earthRadiusKm)/math.Cos(radians(p.Lat)))))
+	box.MinLng, box.MaxLng = p.Lng-dLng, p.Lng+dLng
+	if box.MinLng < -180 || box.MaxLng > 180 {
+		box.AnyLng = true
+	}
+	return box
+}
+
+// LngScale is how long a degree of longitude is next to one of latitude
+// at p, for ordering rows by an approximate distance in SQL without trig
+func LngScale(p Point) float64 {
+	return math.Cos(radians(p.Lat))
+}
+
+func radians(deg float64) float64 { return deg * math.Pi / 180 }
+
+func degrees(rad float64) float64 { return rad * 180 / math.Pi }
@@ -0,0 +1,232 @@
+// Package imaging turns an uploaded image into the sizes it is served in.
+//
+// Process decodes the upload, which is the check that it really is an image
+// whatever its name or declared type says, turns it upright as its EXIF
+// orientation asks and encodes every variant afresh from the pixels. Only
+// pixels are re-encoded, so EXIF (GPS position, camera serial), ICC and any
+// other metadata the phone wrote never reaches storage. Each variant is
+// made in JPEG, which every client shows, and WebP, which is a fraction of
+// the size for the clients that take it.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"io"
+
+	// formats Process accepts, registered with image.Decode
+	_ "image/gif"
+	_ "image/png"
+
+	_ "golang.org/x/image/bmp"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+var (
+	// ErrUnsupported is returned for anything that doesn't decode as a
+	// JPEG, PNG, GIF, WebP or BMP
+	ErrUnsupported = errors.New("file is not a supported image, use JPEG, PNG, GIF, WebP or BMP")
+	// ErrTooLarge is returned for an image too big to process safely
+	ErrTooLarge = errors.New("image is too large")
+)
+
+const (
+	// largest upload Process reads, a phone photo is well under this
+	MaxInputBytes = 25 << 20
+	// most pixels Process decodes, the header is checked before decoding
+	// so a small file claiming a huge image is refused up front
+	maxPixels = 50_000_000
+
+	jpegQuality = 82
+	webpQuality = 80
+)
+
+// Size is one variant, fit inside MaxEdge by MaxEdge without upscaling
+type Size struct {
+	Name    string
+	MaxEdge int
+}
+
+// Sizes are the variants Process makes, largest first
+var Sizes = []Size{
+	{Name: "full", MaxEdge: 2048},
+	{Name: "feed", MaxEdge: 1080},
+	{Name: "thumb", MaxEdge: 320},
+}
+
+// Variant is one encoded size in one format
+type Variant struct {
+	Size        string
+	Ext         string
+	ContentType string
+	Data        []byte
+	Width       int
+	Height      int
+}
+
+// Result is every variant of one image
+type Result struct {
+	// format the upload was in, jpeg, png, gif, webp or bmp
+	Format string
+	// size of the upright original
+	Width    int
+	Height   int
+	Variants []Variant
+}
+
+// Process reads an upload and makes every variant of it
+func Process(r io.Reader) (*Result, error) {
+	img, format, err := decode(r)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		Format: format,
+		Width:  img.Rect.Dx(),
+		Height: img.Rect.Dy(),
+	}
+
+	// each size is scaled down from the one before it, cheaper than going
+	// from the original every time and just as sharp
+	current := img
+	for _, size := range Sizes {
+		current = fit(current, size.MaxEdge)
+		jpegVariant, err := encodeJPEG(current, size.Name)
+		if err != nil {
+			return nil, err
+		}
+		result.Variants = append(result.Variants, jpegVariant)
+		if !WebPSupported {
+			continue
+		}
+		webpVariant, err := encodeWebPVariant(current, size.Name)
+		if err != nil {
+			return nil, err
+		}
+		result.Variants = append(result.Variants, webpVariant)
+	}
+	return result, nil
+}
+
+// Resize reads an image and makes only the variant of one size, in WebP
+// when webp is set and WebPSupported, JPEG otherwise
+func Resize(r io.Reader, size Size, webp bool) (*Variant, error) {
+	img, _, err := decode(r)
+	if err != nil {
+		return nil, err
+	}
+	img = fit(img, size.MaxEdge)
+
+	var variant Variant
+	if webp && WebPSupported {
+		variant, err = encodeWebPVariant(img, size.Name)
+	} else {
+		variant, err = encodeJPEG(img, size.Name)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &variant, nil
+}
+
+// decode reads an upload into upright pixels, refusing anything that isn't
+// an image or is too big
+func decode(r io.Reader) (*image.RGBA, string, error) {
+	data, err := io.ReadAll(io.LimitReader(r, MaxInputBytes+1))
+	if err != nil {
+		return nil, "", err
+	}
+	if len(data) > MaxInputBytes {
+		return nil, "", ErrTooLarge
+	}
+
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrUnsupported
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 {
+		return nil, "", ErrUnsupported
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, "", ErrTooLarge
+	}
+
+	decoded, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, "", ErrUnsupported
+	}
+
+	img := toRGBA(decoded)
+	if format == "jpeg" {
+		img = orient(img, jpegOrientation(data))
+	}
+	return img, format, nil
+}
+
+func encodeJPEG(img *image.RGBA, size string) (Variant, error) {
+	var data bytes.Buffer
+	if err := jpeg.Encode(&data, flatten(img), &jpeg.Options{Quality: jpegQuality}); err != nil {
+		return Variant{}, fmt.Errorf("error encoding %s jpeg: %w", size, err)
+	}
+	return Variant{
+		Size: size, Ext: ".jpg", ContentType: "image/jpeg",
+		Data: data.Bytes(), Width: img.Rect.Dx(), Height: img.Rect.Dy(),
+	}, nil
+}
+
+func encodeWebPVariant(img *image.RGBA, size string) (Variant, error) {
+	data, err := encodeWebP(img, webpQuality)
+	if err != nil {
+		return Variant{}, fmt.Errorf("error encoding %s webp: %w", size, err)
+	}
+	return Variant{
+		Size: size, Ext: ".webp", ContentType: "image/webp",
+		Data: data, Width: img.Rect.Dx(), Height: img.Rect.Dy(),
+	}, nil
+}
+
+func toRGBA(src image.Image) *image.RGBA {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(dst, dst.Rect, src, bounds.Min, draw.Src)
+	return dst
+}
+
+// fit scales img down to fit in maxEdge by maxEdge, a smaller image is
+// returned as it is
+func fit(img *image.RGBA, maxEdge int) *image.RGBA {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	if width <= maxEdge && height <= maxEdge {
+		return img
+	}
+
+	if width >= height {
+		height = max(1, height*maxEdge/width)
+		width = maxEdge
+	} else {
+		width = max(1, width*maxEdge/height)
+		height = maxEdge
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Rect, img, img.Rect, draw.Src, nil)
+	return dst
+}
+
+// flatten puts a transparent image on white, JPEG has no alpha and would
+// show transparent parts black
+func flatten(img *image.RGBA) image.Image {
+	if img.Opaque() {
+		return img
+	}
+	dst := image.NewRGBA(img.Rect)
+	draw.Draw(dst, dst.Rect, image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.Draw(dst, dst.Rect, img, img.Rect.Min, draw.Over)
+	return dst
+}
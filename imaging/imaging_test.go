@@ -0,0 +1,75 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"hash/crc32"
+	"image"
+	"image/png"
+	"testing"
+)
+
+// pngClaiming is a 1x1 PNG whose header says it is width by height
+func pngClaiming(t *testing.T, width, height uint32) []byte {
+	t.Helper()
+
+	var encoded bytes.Buffer
+	if err := png.Encode(&encoded, image.NewRGBA(image.Rect(0, 0, 1, 1))); err != nil {
+		t.Fatal(err)
+	}
+	data := encoded.Bytes()
+
+	// signature, then the IHDR chunk: length, type, width, height...
+	ihdr := data[8:]
+	binary.BigEndian.PutUint32(ihdr[8:], width)
+	binary.BigEndian.PutUint32(ihdr[12:], height)
+	length := binary.BigEndian.Uint32(ihdr[0:4])
+	binary.BigEndian.PutUint32(ihdr[8+length:], crc32.ChecksumIEEE(ihdr[4:8+length]))
+	return data
+}
+
+func TestProcessRefusesWhatItShouldNotDecode(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want error
+	}{
+		{"more pixels than maxPixels", pngClaiming(t, 10_000, 5_001), ErrTooLarge},
+		{"wider than it could ever be", pngClaiming(t, 1<<30, 1), ErrTooLarge},
+		{"more bytes than MaxInputBytes", make([]byte, MaxInputBytes+1), ErrTooLarge},
+		{"not an image", []byte("%PDF-1.7"), ErrUnsupported},
+		{"empty", nil, ErrUnsupported},
+	}
+	for _, test := range tests {
+		if _, err := Process(bytes.NewReader(test.data)); !errors.Is(err, test.want) {
+			t.Errorf("%s: %v, want %v", test.name, err, test.want)
+		}
+	}
+}
+
+func TestFit(t *testing.T) {
+	tests := []struct {
+		width, height, maxEdge int
+		wantW, wantH           int
+	}{
+		{400, 300, 200, 200, 150},
+		{300, 400, 200, 150, 200},
+		{320, 320, 100, 100, 100},
+		{5000, 1, 320, 320, 1},
+		{1, 5000, 320, 1, 320},
+		{300, 200, 320, 300, 200},
+		{320, 100, 320, 320, 100},
+	}
+	for _, test := range tests {
+		img := image.NewRGBA(image.Rect(0, 0, test.width, test.height))
+		got := fit(img, test.maxEdge)
+		if got.Rect.Dx() != test.wantW || got.Rect.Dy() != test.wantH {
+			t.Errorf("%dx%d in %d: %dx%d, want %dx%d", test.width, test.height, test.maxEdge,
+				got.Rect.Dx(), got.Rect.Dy(), test.wantW, test.wantH)
+		}
+		if test.width <= test.maxEdge && test.height <= test.maxEdge && got != img {
+			t.Errorf("%dx%d in %d: a small image was copied, not returned as it is", test.width, test.height, test.maxEdge)
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+)
+
+// jpegOrientation reads the EXIF orientation (1 to 8) of a JPEG, 1 (already
+// upright) when there is none or it can't be read
+func jpegOrientation(data []byte) int {
+	// SOI, then segments of marker, length and payload up to the image data
+	if len(data) < 4 || data[0] != 0xFF || data[1] != 0xD8 {
+		return 1
+	}
+	pos := 2
+	for pos+4 <= len(data) {
+		if data[pos] != 0xFF {
+			return 1
+		}
+		marker := data[pos+1]
+		// start of scan, the metadata segments are all before it
+		if marker == 0xDA {
+			return 1
+		}
+		length := int(binary.BigEndian.Uint16(data[pos+2 : pos+4]))
+		if length < 2 || pos+2+length > len(data) {
+			return 1
+		}
+		segment := data[pos+4 : pos+2+length]
+		if marker == 0xE1 && bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			return exifOrientation(segment[6:])
+		}
+		pos += 2 + length
+	}
+	return 1
+}
+
+// exifOrientation finds tag 0x0112 in IFD0 of a TIFF structure
+func exifOrientation(tiff []byte) int {
+	if len(tiff) < 8 {
+		return 1
+	}
+	var order binary.ByteOrder
+	switch string(tiff[:2]) {
+	case "II":
+		order = binary.LittleEndian
+	case "MM":
+		order = binary.BigEndian
+	default:
+		return 1
+	}
+
+	ifd := int(order.Uint32(tiff[4:8]))
+	if ifd < 8 || ifd+2 > len(tiff) {
+		return 1
+	}
+	count := int(order.Uint16(tiff[ifd : ifd+2]))
+	for i := 0; i < count; i++ {
+		entry := ifd + 2 + i*12
+		if entry+12 > len(tiff) {
+			return 1
+		}
+		if order.Uint16(tiff[entry:entry+2]) != 0x0112 {
+			continue
+		}
+		// a SHORT, stored in the first two bytes of the value field
+		value := int(order.Uint16(tiff[entry+8 : entry+10]))
+		if value < 1 || value > 8 {
+			return 1
+		}
+		return value
+	}
+	return 1
+}
+
+// orient turns img upright for an EXIF orientation. Orientations 5 to 8
+// swap width and height.
+func orient(img *image.RGBA, orientation int) *image.RGBA {
+	if orientation <= 1 || orientation > 8 {
+		return img
+	}
+
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	dstWidth, dstHeight := width, height
+	if orientation >= 5 {
+		dstWidth, dstHeight = height, width
+	}
+	dst := image.NewRGBA(image.Rect(0, 0, dstWidth, dstHeight))
+
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			var dx, dy int
+			switch orientation {
+			case 2: // mirrored
+				dx, dy = width-1-x, y
+			case 3: // upside down
+				dx, dy = width-1-x, height-1-y
+			case 4: // upside down and mirrored
+				dx, dy = x, height-1-y
+			case 5: // on its side and mirrored
+				dx, dy = y, x
+			case 6: // needs a quarter turn clockwise
+				dx, dy = height-1-y, x
+			case 7: // on its other side and mirrored
+				dx, dy = height-1-y, width-1-x
+			case 8: // needs a quarter turn anticlockwise
+				dx, dy = y, width-1-x
+			}
+			src := img.PixOffset(x, y)
+			copy(dst.Pix[dst.PixOffset(dx, dy):dst.PixOffset(dx, dy)+4], img.Pix[src:src+4])
+		}
+	}
+	return dst
+}
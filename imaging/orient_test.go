@@ -0,0 +1,183 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"testing"
+)
+
+// exifSegment is an APP1 segment holding a TIFF structure whose IFD0 has
+// one entry, tag 0x0112 set to orientation
+func exifSegment(order binary.ByteOrder, orientation uint16) []byte {
+	tiff := make([]byte, 8+2+12+4)
+	if order == binary.LittleEndian {
+		copy(tiff, "II")
+	} else {
+		copy(tiff, "MM")
+	}
+	order.PutUint16(tiff[2:], 42)
+	order.PutUint32(tiff[4:], 8)
+	order.PutUint16(tiff[8:], 1)
+	entry := tiff[10:]
+	order.PutUint16(entry[0:], 0x0112)
+	order.PutUint16(entry[2:], 3) // SHORT
+	order.PutUint32(entry[4:], 1)
+	order.PutUint16(entry[8:], orientation)
+
+	return app1(append([]byte("Exif\x00\x00"), tiff...))
+}
+
+// app1 wraps payload in an APP1 marker and its length
+func app1(payload []byte) []byte {
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	return append(segment, payload...)
+}
+
+// withSegment is a real JPEG of img with segment right after SOI
+func withSegment(t *testing.T, img image.Image, segment []byte) []byte {
+	t.Helper()
+
+	var encoded bytes.Buffer
+	if err := jpeg.Encode(&encoded, img, nil); err != nil {
+		t.Fatal(err)
+	}
+	data := encoded.Bytes()
+	out := append([]byte{}, data[:2]...)
+	out = append(out, segment...)
+	return append(out, data[2:]...)
+}
+
+func TestJPEGOrientation(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+
+	for orientation := uint16(1); orientation <= 8; orientation++ {
+		for _, order := range []binary.ByteOrder{binary.LittleEndian, binary.BigEndian} {
+			data := withSegment(t, img, exifSegment(order, orientation))
+			if got := jpegOrientation(data); got != int(orientation) {
+				t.Errorf("%v orientation %d read as %d", order, orientation, got)
+			}
+		}
+	}
+
+	exif := exifSegment(binary.BigEndian, 6)
+	tiff := exif[4+6:]
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"no EXIF", withSegment(t, img, nil)},
+		{"not a JPEG", []byte("\x89PNG\r\n\x1a\n")},
+		{"empty", nil},
+		{"out of range value", withSegment(t, img, exifSegment(binary.BigEndian, 9))},
+		{"zero value", withSegment(t, img, exifSegment(binary.LittleEndian, 0))},
+		{"APP1 that isn't EXIF", withSegment(t, img, app1([]byte("http://ns.adobe.com/xap/1.0/\x00")))},
+		{"segment longer than the file", append([]byte{0xFF, 0xD8}, exif[:len(exif)-4]...)},
+		{"segment length under 2", []byte{0xFF, 0xD8, 0xFF, 0xE1, 0x00, 0x01, 0x00, 0x00}},
+		{"garbage between segments", []byte{0xFF, 0xD8, 0x00, 0xE1, 0x00, 0x04, 0x00, 0x00}},
+		{"TIFF header cut short", withSegment(t, img, app1(append([]byte("Exif\x00\x00"), tiff[:6]...)))},
+		{"unknown byte order", withSegment(t, img, app1(append([]byte("Exif\x00\x00XX"), tiff[2:]...)))},
+		{"IFD past the end", withSegment(t, img, app1(append([]byte("Exif\x00\x00"), withUint32(tiff, 4, 0xFFFF)...)))},
+		{"IFD inside the header", withSegment(t, img, app1(append([]byte("Exif\x00\x00"), withUint32(tiff, 4, 2)...)))},
+		{"more entries than fit", withSegment(t, img, app1(append([]byte("Exif\x00\x00"), withUint16(withUint16(tiff, 8, 40), 10, 0x0100)...)))},
+		{"entry cut short", withSegment(t, img, app1(append([]byte("Exif\x00\x00"), tiff[:len(tiff)-10]...)))},
+	}
+	for _, test := range tests {
+		if got := jpegOrientation(test.data); got != 1 {
+			t.Errorf("%s: orientation %d, want 1", test.name, got)
+		}
+	}
+}
+
+// withUint32 and withUint16 are a copy of tiff, big endian, with one field
+// changed
+func withUint32(tiff []byte, at int, value uint32) []byte {
+	changed := append([]byte{}, tiff...)
+	binary.BigEndian.PutUint32(changed[at:], value)
+	return changed
+}
+
+func withUint16(tiff []byte, at int, value uint16) []byte {
+	changed := append([]byte{}, tiff...)
+	binary.BigEndian.PutUint16(changed[at:], value)
+	return changed
+}
+
+// pixel is a color telling every position of a small image apart
+func pixel(x, y int) color.RGBA {
+	return color.RGBA{R: uint8(x * 40), G: uint8(y * 40), B: 200, A: 255}
+}
+
+func TestOrientTurnsEveryOrientationUpright(t *testing.T) {
+	const width, height = 3, 2
+
+	// how a camera stores the upright image for each orientation, from the
+	// EXIF definitions rather than from orient
+	stored := map[int]func(x, y int) (int, int){
+		1: func(x, y int) (int, int) { return x, y },
+		2: func(x, y int) (int, int) { return width - 1 - x, y },
+		3: func(x, y int) (int, int) { return width - 1 - x, height - 1 - y },
+		4: func(x, y int) (int, int) { return x, height - 1 - y },
+		5: func(x, y int) (int, int) { return y, x },
+		6: func(x, y int) (int, int) { return width - 1 - y, x },
+		7: func(x, y int) (int, int) { return width - 1 - y, height - 1 - x },
+		8: func(x, y int) (int, int) { return y, height - 1 - x },
+	}
+
+	for orientation := 1; orientation <= 8; orientation++ {
+		storedWidth, storedHeight := width, height
+		if orientation >= 5 {
+			storedWidth, storedHeight = height, width
+		}
+		img := image.NewRGBA(image.Rect(0, 0, storedWidth, storedHeight))
+		for y := 0; y < storedHeight; y++ {
+			for x := 0; x < storedWidth; x++ {
+				img.SetRGBA(x, y, pixel(stored[orientation](x, y)))
+			}
+		}
+
+		upright := orient(img, orientation)
+		if upright.Rect.Dx() != width || upright.Rect.Dy() != height {
+			t.Errorf("orientation %d: %dx%d, want %dx%d", orientation, upright.Rect.Dx(), upright.Rect.Dy(), width, height)
+			continue
+		}
+	pixels:
+		for y := 0; y < height; y++ {
+			for x := 0; x < width; x++ {
+				if got := upright.RGBAAt(x, y); got != pixel(x, y) {
+					t.Errorf("orientation %d: pixel %d,%d is %v, want %v", orientation, x, y, got, pixel(x, y))
+					break pixels
+				}
+			}
+		}
+	}
+
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for _, orientation := range []int{0, 9, -1} {
+		if orient(img, orientation) != img {
+			t.Errorf("orientation %d changed the image", orientation)
+		}
+	}
+}
+
+func TestProcessTurnsAJPEGUpright(t *testing.T) {
+	// stored on its side, 4 wide and 2 high, upright it is 2 by 4
+	img := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	data := withSegment(t, img, exifSegment(binary.LittleEndian, 6))
+
+	result, err := Process(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if result.Format != "jpeg" || result.Width != 2 || result.Height != 4 {
+		t.Errorf("%s %dx%d, want an upright 2x4 jpeg", result.Format, result.Width, result.Height)
+	}
+	for _, variant := range result.Variants {
+		if variant.Width != 2 || variant.Height != 4 {
+			t.Errorf("%s %s is %dx%d, want 2x4", variant.Size, variant.Ext, variant.Width, variant.Height)
+		}
+	}
+}
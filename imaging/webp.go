@@ -0,0 +1,17 @@
+//go:build cgo
+
+package imaging
+
+import (
+	"image"
+
+	"github.com/chai2010/webp"
+)
+
+// WebPSupported is true in a build with cgo, libwebp encodes the WebP
+// variants next to the JPEG ones
+const WebPSupported = true
+
+func encodeWebP(img image.Image, quality float32) ([]byte, error) {
+	return webp.EncodeRGBA(img, quality)
+}
@@ -0,0 +1,16 @@
+//go:build !cgo
+
+package imaging
+
+import (
+	"errors"
+	"image"
+)
+
+// WebPSupported is false in a build without cgo, there is no lossy WebP
+// encoder in pure Go and the variants are JPEG only
+const WebPSupported = false
+
+func encodeWebP(image.Image, float32) ([]byte, error) {
+	return nil, errors.New("webp needs a build with cgo")
+}
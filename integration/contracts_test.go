@@ -0,0 +1,571 @@
+package integration
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"gorm.io/gorm"
+
+	dbFunc "business-connect/database/dbHelpFunc"
+	sqlite "business-connect/database/sqlite"
+	"business-connect/geo"
+	Data "business-connect/models"
+)
+
+// Contract runs one repository method against the real schema. It only checks
+// that every statement the method sends is valid for the tables the models
+// create, so a query naming a column or relation that doesn't exist fails
+// here instead of in production. What the method returns is not checked,
+// that is what the scenarios are for.
+type Contract struct {
+	Repo   string
+	Method string
+	Run    func(repo dbFunc.DatabaseHelper, seed Seed)
+}
+
+// Seed is the rows every contract starts with
+type Seed struct {
+	User     Data.User
+	Other    Data.User
+	Product  Data.Post
+	Group    Data.Post
+	Blog     Data.Blog
+	Order    Data.OrderHistory
+	Device   string
+	Category string
+}
+
+// mysqlOnly are the SQLite errors for the MySQL functions the repositories
+// use on purpose, a contract that hits one is skipped. Any other error,
+// a syntax error included, fails the contract.
+var mysqlOnly = []string{"no such function: RAND"}
+
+// TestContracts runs every contract on a fresh database
+func TestContracts(t *testing.T) {
+	for _, contract := range Contracts {
+		t.Run(contract.Repo+"."+contract.Method, func(t *testing.T) {
+			statements, err := runContract(contract)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var broken, unsupported []string
+			for _, statement := range statements {
+				if isMySQLOnly(statement.err) {
+					unsupported = append(unsupported, statement.String())
+					continue
+				}
+				broken = append(broken, statement.String())
+			}
+			if len(broken) > 0 {
+				t.Fatalf("\n%s", strings.Join(broken, "\n"))
+			}
+			if len(unsupported) > 0 {
+				t.Skipf("MySQL only SQL\n%s", strings.Join(unsupported, "\n"))
+			}
+		})
+	}
+}
+
+func isMySQLOnly(err error) bool {
+	for _, message := range mysqlOnly {
+		if strings.Contains(err.Error(), message) {
+			return true
+		}
+	}
+	return false
+}
+
+// failedStatement is a statement the database rejected
+type failedStatement struct {
+	sql string
+	err error
+}
+
+func (s failedStatement) String() string {
+	if s.sql == "" {
+		return s.err.Error()
+	}
+	return fmt.Sprintf("%v\n       in %s", s.err, s.sql)
+}
+
+// runContract seeds a fresh database, runs the contract and returns every
+// statement that failed on the way, whatever the method did with the error
+func runContract(contract Contract) ([]failedStatement, error) {
+	db, err := sqlite.OpenMemory()
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	}()
+
+	seed, err := seedContract(db)
+	if err != nil {
+		return nil, fmt.Errorf("error seeding: %w", err)
+	}
+
+	var (
+		mu     sync.Mutex
+		failed []failedStatement
+	)
+	record := func(tx *gorm.DB) {
+		if tx.Error == nil || errors.Is(tx.Error, gorm.ErrRecordNotFound) {
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		failed = append(failed, failedStatement{sql: tx.Statement.SQL.String(), err: tx.Error})
+	}
+	callbacks := db.Callback()
+	for _, register := range []func(string, func(*gorm.DB)) error{
+		callbacks.Create().Register,
+		callbacks.Query().Register,
+		callbacks.Update().Register,
+		callbacks.Delete().Register,
+		callbacks.Row().Register,
+		callbacks.Raw().Register,
+	} {
+		if err := register("contracts:record", record); err != nil {
+			return nil, err
+		}
+	}
+
+	repo := dbFunc.NewDatabaseHelper(db)
+	if err := runGuarded(func() { contract.Run(repo, seed) }); err != nil {
+		return nil, err
+	}
+
+	return failed, nil
+}
+
+// runGuarded turns a panic into an error so one broken method doesn't stop
+// the rest of the contracts
+func runGuarded(run func()) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+	run()
+	return nil
+}
+
+func seedContract(db *gorm.DB) (Seed, error) {
+	category := "fashion"
+	location := "Lagos"
+	lat, lng := 6.5244, 3.3792
+	seed := Seed{Device: "contract-device", Category: category}
+
+	seed.User = Data.User{FullName: "Ada Obi", BusinessName: "Obi Fabrics", Email: "ada@example.com", PhoneNumber: "2348000000001", UserType: "USER", EmailVerified: true, Latitude: lat, Longitude: lng}
+	seed.Other = Data.User{FullName: "Bayo Ade", BusinessName: "Ade Foods", Email: "bayo@example.com", PhoneNumber: "2348000000002", UserType: "USER", EmailVerified: true}
+	if err := db.Create(&seed.User).Error; err != nil {
+		return seed, err
+	}
+	if err := db.Create(&seed.Other).Error; err != nil {
+		return seed, err
+	}
+
+	seed.Product = Data.Post{
+		UserID: seed.User.ID, PostType: dbFunc.PostTypeBusiness, Title: "Ankara fabric", ProductUrlID: "ankara-fabric-1",
+		Description: "six yards", WhatsappURL: "https://wa.me/1", BusinessCategory: &category, Location: &location,
+		ProductPrice: 5000, IsActive: true, Approved: true, Latitude: &lat, Longitude: &lng,
+		Images: []Data.PostImage{{URL: "https://cdn.example.com/ankara.jpg", OriginalFilename: "ankara.jpg"}},
+	}
+	if err := db.Create(&seed.Product).Error; err != nil {
+		return seed, err
+	}
+
+	entry, maxMembers, members := dbFunc.EntryFree, 10, 0
+	seed.Group = Data.Post{
+		UserID: seed.Other.ID, PostType: dbFunc.PostTypeGroup, Title: "Lagos traders", ProductUrlID: "lagos-traders-2",
+		Description: "traders in Lagos", WhatsappURL: "https://wa.me/2", EntryType: &entry, MaxMembers: &maxMembers,
+		MembersCount: &members, IsActive: true, Approved: true,
+	}
+	if err := db.Create(&seed.Group).Error; err != nil {
+		return seed, err
+	}
+
+	seed.Blog = Data.Blog{UserID: seed.User.ID, BlogUrlID: "first-blog-1", Title: "First blog", Description1: "hello", BlogCategory: category}
+	if err := db.Create(&seed.Blog).Error; err != nil {
+		return seed, err
+	}
+
+	seed.Order = Data.OrderHistory{
+		ProducttID: seed.Product.ID, OrderStatus: "pending", PaymentStatus: "paid", Quantity: 1, OrderCost: 5000,
+		CustomerEmail: seed.User.Email, CustomerFName: "Ada",
+		ProductOrders: []Data.ProductOrder{{ProducttID: seed.Product.ID, ProductUrlID: seed.Product.ProductUrlID, Title: seed.Product.Title, Quantity: 1, OrderCost: 5000, Category: category}},
+	}
+	if err := db.Create(&seed.Order).Error; err != nil {
+		return seed, err
+	}
+
+	rows := []interface{}{
+		&Data.ShippingFees{ShippingFeePerKm: 100, ShippingFeeGreater: 5000, ShippingFeeLess: 2000},
+		&Data.BusinessConnectDeviceFingerprint{FingerprintHash: seed.Device},
+		&Data.BusinessConnectUserActivity{FingerprintHash: seed.Device, ActivityType: "click", ClickCount: 1, ProductID: seed.Product.ID, Category: category, LastUpdated: time.Now()},
+		&Data.BusinessConnectUserActivity{FingerprintHash: "other-device", ActivityType: "view", ClickCount: 1, ProductID: seed.Product.ID, Category: category, LastUpdated: time.Now()},
+		&Data.BusinessConnectUserActivity{FingerprintHash: seed.Device, ActivityType: "search", ClickCount: 1, TitleOrSearchQuery: "ankara", LastUpdated: time.Now()},
+		&Data.BusinessConnectEmailSubscriber{Email: "reader@example.com"},
+	}
+	for _, row := range rows {
+		if err := db.Create(row).Error; err != nil {
+			return seed, err
+		}
+	}
+
+	return seed, nil
+}
+
+// Contracts covers every method of every repository. Methods that delete are
+// last in each group only for readability, every contract gets its own
+// database.
+var Contracts = []Contract{
+	// UserRepo
+	{"UserRepo", "FindByEmail", func(r dbFunc.DatabaseHelper, s Seed) { r.FindByEmail(s.User.Email) }},
+	{"UserRepo", "FindByEmailExcludingUser", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.FindByEmailExcludingUser(s.User.ID, s.Other.Email)
+	}},
+	{"UserRepo", "FindByUuid", func(r dbFunc.DatabaseHelper, s Seed) { r.FindByUuid(s.User.ID) }},
+	{"UserRepo", "FindByUuidFromLocal", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.FindByUuidFromLocal(float64(s.User.ID))
+	}},
+	{"UserRepo", "FindByPhoneNumber", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.FindByPhoneNumber(s.User.PhoneNumber)
+	}},
+	{"UserRepo", "CheckByUserByEmail", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.CheckByUserByEmail("new@example.com", s.User.Email)
+	}},
+	{"UserRepo", "CreateNewUser", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.CreateNewUser(Data.User{FullName: "Chi Eze", Email: "chi@example.com", Password: "Password1!", UserType: "USER"})
+	}},
+	{"UserRepo", "UpdateUser", func(r dbFunc.DatabaseHelper, s Seed) {
+		s.User.State = "Lagos"
+		r.UpdateUser(s.User)
+	}},
+	{"UserRepo", "EditProfileText", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.EditProfileText(Data.User{BusinessName: "Obi Textiles"}, s.User.ID)
+	}},
+	{"UserRepo", "StoreRefreshToken", func(r dbFunc.DatabaseHelper, s Seed) {
+		jti, err := r.StoreRefreshToken(s.User.ID)
+		if err != nil {
+			return
+		}
+		r.FindByJti(jti)
+		r.CheckRefreshToken(jti)
+		r.DeleteRefreshToken(jti)
+	}},
+	{"UserRepo", "GetAndDeleteRefreshToken", func(r dbFunc.DatabaseHelper, s Seed) {
+		if _, err := r.StoreRefreshToken(s.User.ID); err != nil {
+			return
+		}
+		r.GetAndDeleteRefreshToken(s.User.ID)
+	}},
+	{"UserRepo", "OTP", func(r dbFunc.DatabaseHelper, s Seed) {
+		otp := Data.OTP{Email: s.User.Email, PhoneNumber: s.User.PhoneNumber, OTP: "123456"}
+		if err := r.CreateOTP(otp); err != nil {
+			return
+		}
+		saved, _ := r.GetOTPByEmail(s.User.Email)
+		r.GetOTPByNumber(s.User.PhoneNumber)
+		r.GetAndCheckOTPByEmail(s.User.Email, "123456")
+		r.GetAndCheckOTPByNumber(s.User.PhoneNumber, "123456")
+		r.UpdateExistingOTP(otp, saved.CustomID)
+		r.UpdateMaxTry(s.User.Email)
+		r.UpdateMaxTryNumber(s.User.PhoneNumber)
+		r.UpdateMaxTryToZero(s.User.Email)
+		r.DeleteExistingOTPByID(saved.CustomID)
+	}},
+	{"UserRepo", "ProfileImages", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.AddProfileImage(s.User.ID, Data.ProfileImage{URL: "https://cdn.example.com/me.jpg", OriginalFilename: "me.jpg"})
+		r.UpdateUserProfilePhoto(s.User.ID, "https://cdn.example.com/me.jpg")
+		r.AddCoverImage(s.User.ID, Data.ProfileImage{URL: "https://cdn.example.com/cover.jpg", OriginalFilename: "cover.jpg"})
+		r.UpdateUserCoverPhoto(s.User.ID, "https://cdn.example.com/cover.jpg")
+	}},
+	{"UserRepo", "StorageUsedByUser", func(r dbFunc.DatabaseHelper, s Seed) { r.StorageUsedByUser(s.User.ID) }},
+	{"UserRepo", "GetUsersToConnect", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.GetUsersToConnect(s.User.ID, 10, 0)
+	}},
+	{"UserRepo", "ConnectToUser", func(r dbFunc.DatabaseHelper, s Seed) { r.ConnectToUser(s.User.ID, s.Other.ID) }},
+	{"UserRepo", "GetStatesAndCitiesByCountryCode", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.GetStatesAndCitiesByCountryCode("NG")
+	}},
+	{"UserRepo", "LoginAttempts", func(r dbFunc.DatabaseHelper, s Seed) {
+		now := time.Now()
+		key := "email:" + s.User.Email
+		r.BumpLoginAttempt(key, s.User.Email, now.Unix(), now.Add(-time.Hour).Unix())
+		attempt, _ := r.BumpLoginAttempt(key, s.User.Email, now.Unix(), now.Add(-time.Hour).Unix())
+		r.LockLoginAttempt(attempt, 2, now.Add(time.Hour).Unix())
+		r.SetLoginUnlockToken(key, "hashed", now.Add(time.Hour).Unix())
+		r.GetLoginAttempt(attempt.AttemptKey)
+		r.GetLockedLoginAttempts(10, 0)
+		r.DeleteLoginAttempt(attempt.AttemptKey)
+	}},
+	{"UserRepo", "OIDC", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.CreateOIDCLoginState(Data.OIDCLoginState{State: "state", Provider: "google", Nonce: "nonce", CodeVerifier: "verifier", ExpiresAt: time.Now().Add(time.Minute).Unix()})
+		r.ConsumeOIDCLoginState("state")
+		r.CreateUserIdentity(Data.UserIdentity{UserID: s.User.ID, Provider: "google", Subject: "subject"})
+		r.FindUserIdentity("google", "subject")
+		r.MarkIdentityReauthenticated("google", "subject", time.Now().Unix())
+		r.ConsumeReauthentication(s.User.ID, time.Now().Add(-time.Minute).Unix())
+	}},
+	{"UserRepo", "AccountDeletion", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.SaveAccountDeletion(Data.AccountDeletion{UserID: s.User.ID, Email: s.User.Email, ScheduledFor: time.Now().Unix()})
+		r.GetAccountDeletion(s.User.ID)
+		r.GetDueAccountDeletions(time.Now().Add(time.Hour).Unix(), 10)
+		r.CancelAccountDeletion(s.User.ID)
+	}},
+	{"UserRepo", "GetUserDataExport", func(r dbFunc.DatabaseHelper, s Seed) { r.GetUserDataExport(s.User.ID) }},
+	{"UserRepo", "PurgeUser", func(r dbFunc.DatabaseHelper, s Seed) { r.PurgeUser(s.User.ID) }},
+	{"UserRepo", "DeleteUser", func(r dbFunc.DatabaseHelper, s Seed) { r.DeleteUser(s.Other.ID) }},
+
+	// PostRepo
+	{"PostRepo", "CheckIfTransactionIDExist", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.CheckIfTransactionIDExist(s.Product.ID)
+	}},
+	{"PostRepo", "GetStatusPostsByLimit", func(r dbFunc.DatabaseHelper, s Seed) { r.GetStatusPostsByLimit(10, 0) }},
+	{"PostRepo", "GetBusinessConnectProductsByLimit", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.GetBusinessConnectProductsByLimit(10, 0)
+	}},
+	{"PostRepo", "GetBusinessConnectProductsByLimitOpen", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.GetBusinessConnectProductsByLimitOpen(10, 0)
+	}},
+	{"PostRepo", "GetBusinessConnectProductsByLimit2", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.GetBusinessConnectProductsByLimit2(s.Device, 10, 0)
+	}},
+	{"PostRepo", "GetBusinessConnectAdminProductsByLimit", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.GetBusinessConnectAdminProductsByLimit(10, 0)
+	}},
+	{"PostRepo", "GetBusinessConnectRecommendedProductsByLimit", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.GetBusinessConnectRecommendedProductsByLimit(uint64(s.Product.ID), s.Category, 12)
+	}},
+	{"PostRepo", "GetBusinessConnectHomeAllProductsByLimit", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.GetBusinessConnectHomeAllProductsByLimit(10)
+	}},
+	{"PostRepo", "GetBusinessConnectHomeFeaturedProductsByLimit", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.GetBusinessConnectHomeFeaturedProductsByLimit(10)
+	}},
+	{"PostRepo", "GetBusinessConnectHomeBestSellingProductsByLimit", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.GetBusinessConnectHomeBestSellingProductsByLimit(10)
+	}},
+	{"PostRepo", "GetBusinessConnectHomeOnSaleProductsByLimit", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.GetBusinessConnectHomeOnSaleProductsByLimit(10)
+	}},
+	{"PostRepo", "GetBusinessConnectProductsByIDs", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.GetBusinessConnectProductsByIDs([]uint64{uint64(s.Product.ID)})
+	}},
+	{"PostRepo", "GetBusinessConnectProductByID", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.GetBusinessConnectProductByID(uint64(s.Product.ID))
+		r.GetBusinessConnectProductByID(999)
+	}},
+	{"PostRepo", "GetProductByID", func(r dbFunc.DatabaseHelper, s Seed) { r.GetProductByID(s.Product.ID) }},
+	{"PostRepo", "GetNextProductID", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.GetNextProductID(uint64(s.Product.ID))
+		r.GetPreviousProductID(uint64(s.Group.ID))
+	}},
+	{"PostRepo", "SearchProductsByTitle", func(r dbFunc.DatabaseHelper, s Seed) { r.SearchProductsByTitle("ankara") }},
+	{"PostRepo", "SearchProductsByTitleAndCategory", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.SearchProductsByTitleAndCategory("ankara", s.Category)
+	}},
+	{"PostRepo", "SearchAdminProductsByTitle", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.SearchAdminProductsByTitle("ankara")
+	}},
+	{"PostRepo", "GetTransactionsByUserAndDateWithLimit", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.GetTransactionsByUserAndDateWithLimit(s.User.ID, time.Now().Format("01/02/2006"))
+	}},
+	{"PostRepo", "GetTransactionHistoryForAi", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.GetTransactionHistoryForAi(uint64(s.User.ID), 10)
+	}},
+	{"PostRepo", "AddProduct", func(r dbFunc.DatabaseHelper, s Seed) {
+		post, err := r.AddProduct(Data.Post{UserID: s.User.ID, PostType: dbFunc.PostTypeBusiness, Title: "Aso oke", Description: "woven", WhatsappURL: "https://wa.me/1"}, s.User)
+		if err != nil {
+			return
+		}
+		r.AddProductImage(Data.PostImage{URL: "https://cdn.example.com/aso-oke.jpg"}, post.ID)
+		r.CountProductImages(post.ID)
+		r.CountProductVideos(post.ID)
+	}},
+	{"PostRepo", "UpdateBusinessConnectProduct", func(r dbFunc.DatabaseHelper, s Seed) {
+		s.Product.Title = "Ankara fabric, 6 yards"
+		r.UpdateBusinessConnectProduct(s.Product, s.Product.ID)
+	}},
+	{"PostRepo", "DeleteBusinessConnectProduct", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.DeleteBusinessConnectProduct(s.Product.ID)
+	}},
+
+	// GroupRepo
+	{"GroupRepo", "GetAvailableGroups", func(r dbFunc.DatabaseHelper, s Seed) { r.GetAvailableGroups(10, 0) }},
+	{"GroupRepo", "JoinGroup", func(r dbFunc.DatabaseHelper, s Seed) { r.JoinGroup(s.User, s.Group.ID) }},
+
+	// BlogRepo
+	{"BlogRepo", "GetBusinessConnectBlogByLimit", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.GetBusinessConnectBlogByLimit(10, 0)
+	}},
+	{"BlogRepo", "AddBlog", func(r dbFunc.DatabaseHelper, s Seed) {
+		blog, err := r.AddBlog(Data.Blog{Title: "Second blog", Description1: "hi", BlogCategory: s.Category}, s.User)
+		if err != nil {
+			return
+		}
+		r.AddBlogImage(Data.BlogImage{URL: "https://cdn.example.com/blog.jpg"}, blog.ID)
+		r.CountBlogImages(blog.ID)
+	}},
+	{"BlogRepo", "CustomerBlogReviews", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.SaveCustomerBlogReview(s.Blog.ID, "reader@example.com", "Reader", "great read", 5)
+		r.GetCustomerBlogReviewsByBlogPost(s.Blog.ID, 10, 0)
+	}},
+	{"BlogRepo", "GetBlogPostById", func(r dbFunc.DatabaseHelper, s Seed) { r.GetBlogPostById(s.Blog.ID) }},
+	{"BlogRepo", "UpdateBusinessConnectBlog", func(r dbFunc.DatabaseHelper, s Seed) {
+		s.Blog.Title = "First blog, edited"
+		r.UpdateBusinessConnectBlog(s.Blog, s.Blog.ID)
+	}},
+	{"BlogRepo", "DeleteBusinessConnectBlog", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.DeleteBusinessConnectBlog(s.Blog.ID)
+	}},
+
+	// OrderRepo
+	{"OrderRepo", "SearchAdminOrderByTitle", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.SearchAdminOrderByTitle("ada")
+		r.SearchAdminOrderByTitle(fmt.Sprint(s.Order.ID))
+	}},
+	{"OrderRepo", "AddOrder", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.AddOrder(
+			Data.OrderHistoryBody{OrderStatus: "pending", Quantity: 1, OrderCost: 5000, CustomerEmail: s.Other.Email},
+			[]Data.ProductOrderBody{{ID: s.Product.ID, ProductUrlID: s.Product.ProductUrlID, Title: s.Product.Title, Quantity: 1, OrderCost: 5000, Category: s.Category}},
+		)
+	}},
+	{"OrderRepo", "ShippingFee", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.UpsertShippingFee(150, 6000, 2500, 6.5244, 3.3792, "Lagos", "Ikeja", "LA", true)
+		r.GetShippingFee()
+	}},
+	{"OrderRepo", "GetOrder", func(r dbFunc.DatabaseHelper, s Seed) { r.GetOrder(s.Order.ID) }},
+	{"OrderRepo", "GetAndUpdateOrder", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.GetAndUpdateOrder(s.Order.ID, "paid")
+	}},
+	{"OrderRepo", "GetBusinessConnectOrdersByLimit", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.GetBusinessConnectOrdersByLimit(10, 0)
+	}},
+	{"OrderRepo", "UpdateOrderStatus", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.UpdateOrderStatus(s.Order.ID, "delivered")
+	}},
+
+	// AnalyticsRepo
+	{"AnalyticsRepo", "AddEmailSubscriber", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.AddEmailSubscriber("new-reader@example.com")
+	}},
+	{"AnalyticsRepo", "SiteVisits", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.UpdateSiteVisits()
+		r.GetLast12DaysSiteVisits()
+	}},
+	{"AnalyticsRepo", "GetAnalyticsData", func(r dbFunc.DatabaseHelper, s Seed) { r.GetAnalyticsData() }},
+	{"AnalyticsRepo", "EmailSubscribers", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.GetBusinessConnectEmailSubscribers()
+		r.GetBusinessConnectEmailSubscribersAfter(0, 100)
+	}},
+	{"AnalyticsRepo", "SentEmailProgress", func(r dbFunc.DatabaseHelper, s Seed) {
+		sentEmail := Data.Email{Subject: "Hello", Content: "news", SendTo: "all"}
+		r.SaveBusinessConnectSentEmail(&sentEmail)
+		r.UpdateSentEmailProgress(sentEmail.ID, 1, false)
+		r.GetSentEmail(sentEmail.ID)
+		r.UpdateSentEmailProgress(sentEmail.ID, 1, true)
+	}},
+	{"AnalyticsRepo", "DeviceFingerprints", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.GetBusinessConnectUniqueUserFingerPrintHash(s.Device)
+		r.CreateBusinessConnectDeviceFingerprint("new-device")
+	}},
+	{"AnalyticsRepo", "RecommendProductsForUser", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.RecommendProductsForUser(s.Device, 10, 0)
+		r.RecommendProductsForUser("", 10, 0)
+	}},
+	{"AnalyticsRepo", "LogUserClickData", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.LogUserClickData(s.Device, s.User.ID, s.Product.ID, "click", s.Category, s.Product.Title)
+	}},
+
+	// JobRepo
+	{"JobRepo", "ClaimAndFinishJobs", func(r dbFunc.DatabaseHelper, s Seed) {
+		job := Data.Job{Type: "email.welcome", Payload: `{"email":"reader@example.com"}`, Status: dbFunc.JobPending, MaxAttempts: 5, RunAt: time.Now().Unix()}
+		r.CreateJob(&job)
+		claimed, _ := r.ClaimJobs(time.Now().Unix(), 5, time.Now().Add(time.Minute).Unix(), "worker-1")
+		for _, job := range claimed {
+			r.ReleaseJob(job)
+			r.RetryJobLater(job, time.Now().Unix(), "smtp: connection refused")
+			r.CompleteJob(job, "")
+		}
+	}},
+	{"JobRepo", "DeadJobs", func(r dbFunc.DatabaseHelper, s Seed) {
+		job := Data.Job{Type: "email.welcome", Payload: `{}`, Status: dbFunc.JobPending, MaxAttempts: 1, RunAt: time.Now().Unix()}
+		r.CreateJob(&job)
+		claimed, _ := r.ClaimJobs(time.Now().Unix(), 5, time.Now().Add(time.Minute).Unix(), "worker-1")
+		for _, job := range claimed {
+			r.KillJob(job, "bad payload")
+		}
+		r.GetJobsByStatus(dbFunc.JobDead, "", 20, 0)
+		r.GetJobsByStatus(dbFunc.JobDead, "email.welcome", 20, 0)
+		r.RequeueDeadJob(job.ID)
+		r.GetJob(job.ID)
+	}},
+
+	// MediaRepo
+	{"MediaRepo", "UploadSessions", func(r dbFunc.DatabaseHelper, s Seed) {
+		session := Data.UploadSession{
+			Token: "0123456789abcdef0123456789abcdef", UserID: s.User.ID, Purpose: "post", TargetID: s.Product.ID,
+			ObjectKey: "uploads/0123456789abcdef0123456789abcdef", Filename: "photo.jpg", ContentType: "image/jpeg",
+			MaxBytes: 1 << 20, Status: dbFunc.UploadPending, ExpiresAt: time.Now().Add(time.Minute).Unix(),
+		}
+		r.CreateUploadSession(&session)
+		r.GetUploadSession(session.Token, s.User.ID)
+		r.MoveUploadSession(session.ID, dbFunc.UploadPending, dbFunc.UploadConfirming, 0)
+		r.MoveUploadSession(session.ID, dbFunc.UploadConfirming, dbFunc.UploadConfirmed, time.Now().Unix())
+		r.GetExpiredUploadSessions(time.Now().Add(time.Hour).Unix(), 10)
+		r.DeleteUploadSession(session.ID)
+	}},
+	{"MediaRepo", "MediaObjects", func(r dbFunc.DatabaseHelper, s Seed) {
+		object := Data.MediaObject{URL: "posts/1_photo_full.jpg", ObjectKeys: "posts/1_photo_full.jpg\nposts/1_photo_thumb.jpg", Bytes: 2048}
+		r.GetUntrackedMedia(10)
+		r.TrackMedia(&object)
+		r.ReferencedMedia([]string{object.URL, "https://cdn.example.com/me.jpg"})
+		r.GetMediaToCheck(time.Now().Add(time.Hour), time.Now().Unix(), 0, 10)
+		r.SetMediaSchedule([]uint{object.ID}, 0, time.Now().Unix())
+		r.ScheduleMediaDeletes([]string{object.URL}, time.Now().Unix())
+		r.SchedulePostMediaDeletes(s.Product.ID, time.Now().Unix())
+		r.ScheduleBlogMediaDeletes(s.Blog.ID, time.Now().Unix())
+		r.GetDueMediaDeletes(time.Now().Add(time.Hour).Unix(), 0, 10)
+		r.DeleteMediaObject(object.ID)
+	}},
+
+	// SearchRepo
+	{"SearchRepo", "SearchIndex", func(r dbFunc.DatabaseHelper, s Seed) {
+		r.GetPostIDsToIndex(0, 10)
+		r.IndexPost(s.Product.ID)
+		r.IndexPost(s.Group.ID)
+		r.SearchPosts(dbFunc.SearchParams{Text: "ankra fabrics lag", Limit: 10})
+		low, high := int64(1000), int64(90000)
+		r.SearchPosts(dbFunc.SearchParams{
+			Text: "traders", Categories: []string{s.Category}, PostTypes: []string{dbFunc.PostTypeBusiness}, Locations: []string{"Lagos"},
+			MinPrice: &low, MaxPrice: &high, VerifiedOnly: true, InStock: true, Limit: 10, Offset: 10, WithFacets: true,
+		})
+		for _, sort := range dbFunc.SearchSorts {
+			r.SearchPosts(dbFunc.SearchParams{Sort: sort, Limit: 10, WithFacets: true})
+		}
+		r.SearchPosts(dbFunc.SearchParams{Text: "ankara", Sort: dbFunc.SortDistance, Near: &geo.Point{Lat: 6.45, Lng: 3.4}, RadiusKm: 25, Limit: 10})
+		// a page far past the end is empty, not a panic
+		r.SearchPosts(dbFunc.SearchParams{Text: "ankara", Limit: 10, Offset: math.MaxInt})
+		r.IndexPost(s.Product.ID + 1000)
+		r.PruneSearchIndex()
+	}},
+
+	// GeoRepo
+	{"GeoRepo", "Nearby", func(r dbFunc.DatabaseHelper, s Seed) {
+		lagos := geo.Point{Lat: 6.45, Lng: 3.4}
+		r.GetNearbyPosts(dbFunc.NearbyParams{Origin: lagos, RadiusKm: 25, Limit: 10})
+		r.GetNearbyPosts(dbFunc.NearbyParams{Origin: lagos, RadiusKm: 25, PostTypes: []string{dbFunc.PostTypeBusiness}, Limit: 10, Offset: 10})
+		r.GetNearbyBusinesses(dbFunc.NearbyParams{Origin: lagos, RadiusKm: 25, Limit: 10})
+		r.GetNearbyBusinesses(dbFunc.NearbyParams{Origin: lagos, RadiusKm: 25, Limit: 10, Offset: math.MaxInt})
+	}},
+}
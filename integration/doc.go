@@ -0,0 +1,15 @@
+// Package integration drives the real Fiber app from router.Routers against
+// an in-memory SQLite database, end to end from HTTP request to database row
+// and back. Nothing outside the process is needed: no MySQL, no key files
+// and no network, as long as a scenario stays away from the handlers that
+// send email, SMS or call Paystack or the AI API. Uploads go to a temp
+// folder through the local storage driver.
+//
+// Contracts run every repository method in dbHelpFunc against the same
+// schema and fail on any statement that names a column, table or relation
+// the models don't have.
+//
+// Every contract and scenario runs with the other tests
+//
+//	go test ./integration
+package integration
@@ -0,0 +1,346 @@
+package integration
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
+
+	"business-connect/cache"
+	config "business-connect/config"
+	dbFunc "business-connect/database/dbHelpFunc"
+	sqlite "business-connect/database/sqlite"
+	"business-connect/logger"
+	myjwt "business-connect/middleware/myjwt"
+	Data "business-connect/models"
+	"business-connect/ratelimit"
+	"business-connect/router"
+	"business-connect/server"
+	"business-connect/storage"
+)
+
+// Origin is the web origin every harness request is sent from
+const Origin = "https://businessconnectt.com"
+
+// Harness is one app with its own database and cookie jar
+type Harness struct {
+	App    *fiber.App
+	DB     *gorm.DB
+	Config *config.Config
+
+	keysDir    string
+	storageDir string
+	cookies    map[string]string
+}
+
+// Response is a finished request
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+}
+
+// New builds the app on a fresh in-memory database with a throwaway JWT
+// signing key
+func New() (*Harness, error) {
+	db, err := sqlite.OpenMemory()
+	if err != nil {
+		return nil, err
+	}
+
+	keysDir, err := os.MkdirTemp("", "business-connect-keys-")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := myjwt.GenerateKey(keysDir); err != nil {
+		os.RemoveAll(keysDir)
+		return nil, err
+	}
+
+	// uploads land on local disk, nothing is sent to a bucket
+	storageDir, err := os.MkdirTemp("", "business-connect-storage-")
+	if err != nil {
+		os.RemoveAll(keysDir)
+		return nil, err
+	}
+
+	cfg := testConfig(keysDir, storageDir)
+	server.UseConfig(cfg)
+	// only warnings and errors, the access log would drown the report
+	slog.SetDefault(logger.New(os.Stderr, cfg.Log))
+	repos := server.UseDatabase(db)
+	// limits and cached responses start empty like the database, earlier
+	// scenarios in the same process don't count
+	ratelimit.Use(ratelimit.NewMemoryStore())
+	cache.Use(cache.NewMemoryStore())
+
+	if err := myjwt.InitJWT(cfg.JWT); err != nil {
+		os.RemoveAll(keysDir)
+		os.RemoveAll(storageDir)
+		return nil, err
+	}
+
+	store, err := storage.Open(cfg.Storage, cfg.B2)
+	if err != nil {
+		os.RemoveAll(keysDir)
+		os.RemoveAll(storageDir)
+		return nil, err
+	}
+	storage.Use(store)
+
+	return &Harness{
+		App:        router.Routers(cfg, repos),
+		DB:         db,
+		Config:     cfg,
+		keysDir:    keysDir,
+		storageDir: storageDir,
+		cookies:    map[string]string{},
+	}, nil
+}
+
+// testConfig has every required setting filled with a dummy value so nothing
+// is read from the environment
+func testConfig(keysDir, storageDir string) *config.Config {
+	return &config.Config{
+		Env:  config.EnvDevelopment,
+		Port: "0",
+		Log: config.LogConfig{
+			Level:  "warn",
+			Format: "text",
+		},
+		Security: config.SecurityConfig{
+			AllowedOrigins: []string{Origin},
+			AppAPIKey:      "integration-app-key",
+		},
+		JWT: config.JWTConfig{
+			EncryptionKey: "0123456789abcdef0123456789abcdef",
+			KeysDir:       keysDir,
+		},
+		Email: config.EmailConfig{
+			SenderName:          "Business Connect",
+			SenderAccount:       "no-reply@example.com",
+			AdminSenderName:     "Business Connect",
+			AdminSenderAccount:  "no-reply@example.com",
+			SenderPassword:      "unused",
+			AdminSenderPassword: "unused",
+		},
+		Paystack: config.PaystackConfig{
+			SecretKey:   "unused",
+			CallbackURL: Origin + "/track-order.html",
+			CancelURL:   Origin + "/cancel-transaction.html",
+		},
+		Storage: config.StorageConfig{
+			Driver:        config.StorageLocal,
+			PublicURL:     Origin + "/storage/",
+			PostFolder:    "posts/",
+			EmailFolder:   "emails/",
+			BlogFolder:    "blogs/",
+			ProfileFolder: "profiles/",
+			UploadFolder:  "uploads/",
+			PrivateFolder: "private/",
+
+			MediaSigningKey: "integration-media-key",
+			Local: config.LocalStorageConfig{
+				Dir:        storageDir,
+				SigningKey: "integration-signing-key",
+			},
+		},
+		Upload: config.UploadConfig{
+			MaxRequestBytes: 8 << 20,
+			MaxFileBytes:    2 << 20,
+			QuotaBytes:      50 << 20,
+			MediaSweep:      "delete",
+			MaxVideoBytes:   4 << 20,
+			MaxVideoSeconds: 120,
+			FFmpegPath:      "ffmpeg",
+		},
+		AI: config.AIConfig{
+			APIKey: "unused",
+			Model:  "gemini-2.0-flash",
+		},
+		OIDC: config.OIDCConfig{
+			FrontendURL: Origin,
+			Providers:   map[string]config.OIDCProviderConfig{},
+		},
+	}
+}
+
+// Reload rebuilds the app on h.Config, for a scenario that changed it
+func (h *Harness) Reload() {
+	server.UseConfig(h.Config)
+	h.App = router.Routers(h.Config, dbFunc.DBHelper)
+}
+
+// Close releases the database and the signing key
+func (h *Harness) Close() error {
+	defer os.RemoveAll(h.keysDir)
+	defer os.RemoveAll(h.storageDir)
+
+	sqlDB, err := h.DB.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Close()
+}
+
+// Do sends a request from the allowed web origin with the cookies collected
+// so far. A non nil body is sent as JSON.
+func (h *Harness) Do(method, path string, body interface{}) (*Response, error) {
+	return h.DoWithHeaders(method, path, body, map[string]string{"Origin": Origin})
+}
+
+// DoWithHeaders is Do with exactly the given headers, so a scenario can leave
+// out or change the origin
+func (h *Harness) DoWithHeaders(method, path string, body interface{}, headers map[string]string) (*Response, error) {
+	var reader io.Reader
+	if body != nil {
+		raw, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(raw)
+	}
+
+	req := httptest.NewRequest(method, path, reader)
+	if body != nil {
+		req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	}
+	return h.send(req, headers)
+}
+
+// File is one file of a multipart upload
+type File struct {
+	Field    string
+	Filename string
+	Content  []byte
+}
+
+// DoMultipart posts fields and files as a multipart form from the allowed
+// web origin, the way the web client uploads media
+func (h *Harness) DoMultipart(path string, fields map[string]string, files []File) (*Response, error) {
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	for name, value := range fields {
+		if err := form.WriteField(name, value); err != nil {
+			return nil, err
+		}
+	}
+	for _, file := range files {
+		part, err := form.CreateFormFile(file.Field, file.Filename)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := part.Write(file.Content); err != nil {
+			return nil, err
+		}
+	}
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+
+	req := httptest.NewRequest(http.MethodPost, path, &body)
+	req.Header.Set(fiber.HeaderContentType, form.FormDataContentType())
+	return h.send(req, map[string]string{"Origin": Origin})
+}
+
+// Upload PUTs body to an upload URL, the way a client sends a file straight
+// to storage
+func (h *Harness) Upload(uploadURL, contentType string, body []byte) (*Response, error) {
+	req := httptest.NewRequest(http.MethodPut, uploadURL, bytes.NewReader(body))
+	req.Header.Set(fiber.HeaderContentType, contentType)
+	return h.send(req, map[string]string{"Origin": Origin})
+}
+
+func (h *Harness) send(req *http.Request, headers map[string]string) (*Response, error) {
+	for key, value := range headers {
+		req.Header.Set(key, value)
+	}
+	for name, value := range h.cookies {
+		req.AddCookie(&http.Cookie{Name: name, Value: value})
+	}
+
+	resp, err := h.App.Test(req, -1)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	raw, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// keep cookies like a browser would, ignoring domain and Secure
+	for _, cookie := range resp.Cookies() {
+		if cookie.Value == "" || cookie.MaxAge < 0 {
+			delete(h.cookies, cookie.Name)
+			continue
+		}
+		h.cookies[cookie.Name] = cookie.Value
+	}
+
+	return &Response{Status: resp.StatusCode, Header: resp.Header, Body: raw}, nil
+}
+
+// ClearCookies signs the harness out
+func (h *Harness) ClearCookies() {
+	h.cookies = map[string]string{}
+}
+
+// CreateUser stores a user with a verified email, as if sign up and the OTP
+// had already happened
+func (h *Harness) CreateUser(fullName, email, password string) (Data.User, error) {
+	user, err := dbFunc.DBHelper.CreateNewUser(Data.User{
+		FullName:     fullName,
+		BusinessName: fullName + " Ventures",
+		Email:        email,
+		Password:     password,
+		UserType:     "USER",
+	})
+	if err != nil {
+		return Data.User{}, err
+	}
+
+	user.EmailVerified = true
+	if err := dbFunc.DBHelper.UpdateUser(user); err != nil {
+		return Data.User{}, err
+	}
+	return user, nil
+}
+
+// SignIn signs in through /sign-in and keeps the auth cookies
+func (h *Harness) SignIn(email, password string) error {
+	resp, err := h.Do(http.MethodPost, "/sign-in", map[string]string{
+		"email":    email,
+		"password": password,
+	})
+	if err != nil {
+		return err
+	}
+	return resp.Expect(http.StatusOK)
+}
+
+// Expect returns an error unless the response has the given status
+func (r *Response) Expect(status int) error {
+	if r.Status != status {
+		return fmt.Errorf("expected status %d, got %d: %s", status, r.Status, strings.TrimSpace(string(r.Body)))
+	}
+	return nil
+}
+
+// JSON decodes the body into v
+func (r *Response) JSON(v interface{}) error {
+	if err := json.Unmarshal(r.Body, v); err != nil {
+		return fmt.Errorf("error decoding %q: %w", r.Body, err)
+	}
+	return nil
+}
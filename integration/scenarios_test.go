@@ -0,0 +1,2524 @@
+package integration
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/jpeg"
+	"image/png"
+	"io"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	config "business-connect/config"
+	"business-connect/controllers/profile"
+	dbFunc "business-connect/database/dbHelpFunc"
+	"business-connect/geo"
+	"business-connect/imaging"
+	"business-connect/media"
+	myjwt "business-connect/middleware/myjwt"
+	Data "business-connect/models"
+	"business-connect/oidc"
+	"business-connect/storage"
+	"business-connect/upload"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Scenario is one end to end check, it gets a fresh harness of its own
+type Scenario struct {
+	Name string
+	Run  func(h *Harness) error
+}
+
+// Scenarios is every scenario TestScenarios runs, in order
+var Scenarios = []Scenario{
+	{"requests without an allowed origin are rejected", originIsChecked},
+	{"sign in sets cookies that open the profile", signInAndReadProfile},
+	{"wrong password is rejected and counted", wrongPasswordIsRejected},
+	{"profile update is saved", profileUpdateIsSaved},
+	{"open feed lists active posts newest first", openFeedListsPosts},
+	{"signed out requests can't read the profile", signedOutProfileIsRejected},
+	{"published post images are stored in every size", publishedImagesAreStored},
+	{"uploads past their limits are refused", uploadLimitsAreEnforced},
+	{"direct uploads are processed once confirmed", directUploadsAreConfirmed},
+	{"images nothing uses are swept", unusedImagesAreSwept},
+	{"images stored before tracking are backfilled and swept", untrackedImagesAreBackfilled},
+	{"cached pages answer with an ETag and are dropped when what they show changes", cachedPagesAreInvalidated},
+	{"metrics are only served with the scrape token once one is set", metricsNeedTheToken},
+	{"a strict route policy answers 429 with its limit headers once used up", strictRateLimitsAreEnforced},
+	{"videos are published with their length", videosArePublished},
+	{"media is served by key, private media only when signed", mediaIsServed},
+	{"search finds posts through typos and word forms, best match first", searchRanksPosts},
+	{"listings are filtered, sorted and counted by facet", listingsAreFaceted},
+	{"posts and businesses nearby are found nearest first", nearbyIsByDistance},
+	{"tokens signed with the previous key still verify after a rotation", rotatedKeysStillVerify},
+	{"social sign in claims an unverified account without keeping its password", socialSignInClaimsAccounts},
+	{"repeated failures lock the account until an unexpired unlock link is used", lockedAccountsAreUnlocked},
+	{"a worker whose claim ran out can't finish the job", staleJobClaimsAreRefused},
+	{"social accounts change their email after signing in at the provider again", socialAccountsReauthenticate},
+	{"the data export has the user's activity, the purge after the grace period their media", deletedAccountsArePurged},
+	{"accounts made through a provider can delete themselves after signing in there again", socialAccountsCanBeDeleted},
+}
+
+// TestScenarios runs every scenario on its own harness
+func TestScenarios(t *testing.T) {
+	for _, scenario := range Scenarios {
+		t.Run(scenario.Name, func(t *testing.T) {
+			h, err := New()
+			if err != nil {
+				t.Fatalf("error building harness: %v", err)
+			}
+			defer h.Close()
+
+			if err := scenario.Run(h); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func originIsChecked(h *Harness) error {
+	body := map[string]string{"email": "nobody@example.com", "password": "Password1!"}
+
+	resp, err := h.DoWithHeaders(http.MethodPost, "/sign-in", body, nil)
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusUnauthorized); err != nil {
+		return fmt.Errorf("no origin: %w", err)
+	}
+
+	resp, err = h.DoWithHeaders(http.MethodPost, "/sign-in", body, map[string]string{"Origin": "https://evil.example.com"})
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusForbidden); err != nil {
+		return fmt.Errorf("unknown origin: %w", err)
+	}
+
+	resp, err = h.DoWithHeaders(http.MethodPost, "/sign-in", body, map[string]string{"X-BUSCONNECT-APP-API-KEY": "wrong"})
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusForbidden); err != nil {
+		return fmt.Errorf("wrong api key: %w", err)
+	}
+
+	return nil
+}
+
+func signInAndReadProfile(h *Harness) error {
+	if _, err := h.CreateUser("Ada Obi", "ada@example.com", "Password1!"); err != nil {
+		return err
+	}
+	if err := h.SignIn("ada@example.com", "Password1!"); err != nil {
+		return err
+	}
+
+	resp, err := h.Do(http.MethodGet, "/profile", nil)
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+
+	var profile struct {
+		User Data.User `json:"user"`
+	}
+	if err := resp.JSON(&profile); err != nil {
+		return err
+	}
+	if profile.User.Email != "ada@example.com" {
+		return fmt.Errorf("expected ada@example.com, got %q", profile.User.Email)
+	}
+
+	return nil
+}
+
+func wrongPasswordIsRejected(h *Harness) error {
+	if _, err := h.CreateUser("Ada Obi", "ada@example.com", "Password1!"); err != nil {
+		return err
+	}
+
+	resp, err := h.Do(http.MethodPost, "/sign-in", map[string]string{
+		"email":    "ada@example.com",
+		"password": "not-the-password",
+	})
+	if err != nil {
+		return err
+	}
+	if resp.Status == http.StatusOK {
+		return errors.New("signed in with the wrong password")
+	}
+
+	var attempt Data.LoginAttempt
+	if err := h.DB.Where("attempt_key = ?", "email:ada@example.com").First(&attempt).Error; err != nil {
+		return fmt.Errorf("failed attempt wasn't recorded: %w", err)
+	}
+	if attempt.Failures != 1 {
+		return fmt.Errorf("expected 1 failure, got %d", attempt.Failures)
+	}
+
+	return nil
+}
+
+func profileUpdateIsSaved(h *Harness) error {
+	user, err := h.CreateUser("Ada Obi", "ada@example.com", "Password1!")
+	if err != nil {
+		return err
+	}
+	if err := h.SignIn("ada@example.com", "Password1!"); err != nil {
+		return err
+	}
+
+	resp, err := h.Do(http.MethodPost, "/profile/update", map[string]string{
+		"business_name": "Obi Fabrics",
+		"state":         "Lagos",
+	})
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+
+	var saved Data.User
+	if err := h.DB.First(&saved, user.ID).Error; err != nil {
+		return err
+	}
+	if saved.BusinessName != "Obi Fabrics" || saved.State != "Lagos" {
+		return fmt.Errorf("update not saved, got business %q state %q", saved.BusinessName, saved.State)
+	}
+	if saved.FullName != "Ada Obi" {
+		return fmt.Errorf("fields left out of the update changed, full name is %q", saved.FullName)
+	}
+
+	return nil
+}
+
+func openFeedListsPosts(h *Harness) error {
+	user, err := h.CreateUser("Ada Obi", "ada@example.com", "Password1!")
+	if err != nil {
+		return err
+	}
+
+	posts := []Data.Post{
+		{UserID: user.ID, PostType: "business", Title: "Older", ProductUrlID: "older", Description: "older post", WhatsappURL: "https://wa.me/1", IsActive: true, Approved: true},
+		{UserID: user.ID, PostType: "business", Title: "Newer", ProductUrlID: "newer", Description: "newer post", WhatsappURL: "https://wa.me/1", IsActive: true, Approved: true},
+		{UserID: user.ID, PostType: "business", Title: "Hidden", ProductUrlID: "hidden", Description: "inactive post", WhatsappURL: "https://wa.me/1", IsActive: true, Approved: true},
+	}
+	for i := range posts {
+		posts[i].CreatedAt = time.Now().Add(time.Duration(i) * time.Minute)
+		if err := h.DB.Create(&posts[i]).Error; err != nil {
+			return err
+		}
+	}
+	// false is a zero value so Create would use the column default
+	if err := h.DB.Model(&posts[2]).Update("is_active", false).Error; err != nil {
+		return err
+	}
+
+	resp, err := h.Do(http.MethodGet, "/posts-open?page=1&limit=10", nil)
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+
+	var feed struct {
+		Posts   []Data.Post `json:"posts"`
+		HasMore bool        `json:"hasMore"`
+	}
+	if err := resp.JSON(&feed); err != nil {
+		return err
+	}
+	if len(feed.Posts) != 2 || feed.Posts[0].Title != "Newer" || feed.Posts[1].Title != "Older" {
+		titles := make([]string, len(feed.Posts))
+		for i, post := range feed.Posts {
+			titles[i] = post.Title
+		}
+		return fmt.Errorf("expected [Newer Older], got %v", titles)
+	}
+	if feed.HasMore {
+		return errors.New("expected hasMore to be false")
+	}
+
+	return nil
+}
+
+func signedOutProfileIsRejected(h *Harness) error {
+	resp, err := h.Do(http.MethodGet, "/profile", nil)
+	if err != nil {
+		return err
+	}
+	return resp.Expect(http.StatusUnauthorized)
+}
+
+func publishedImagesAreStored(h *Harness) error {
+	if _, err := h.CreateUser("Ada Obi", "ada@example.com", "Password1!"); err != nil {
+		return err
+	}
+	if err := h.SignIn("ada@example.com", "Password1!"); err != nil {
+		return err
+	}
+
+	photo, err := testPNG()
+	if err != nil {
+		return err
+	}
+	resp, err := h.DoMultipart("/publish-product", map[string]string{
+		"post_type":    "business",
+		"title":        "Aso oke",
+		"description":  "hand woven",
+		"whatsapp_url": "https://wa.me/1",
+	}, []File{{Field: "images", Filename: "aso-oke.png", Content: photo}})
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+
+	var images []Data.PostImage
+	if err := h.DB.Find(&images).Error; err != nil {
+		return err
+	}
+	if len(images) != 1 {
+		return fmt.Errorf("expected 1 post image, got %d", len(images))
+	}
+	if !strings.HasPrefix(images[0].URL, h.Config.Storage.PostFolder) {
+		return fmt.Errorf("image key %q isn't in the post folder", images[0].URL)
+	}
+
+	// every variant is stored and the full size is a fresh JPEG, not the
+	// bytes that were uploaded
+	variants := images[0].ImageVariants
+	if variants.Width != 64 || variants.Height != 48 {
+		return fmt.Errorf("expected a 64x48 image, got %dx%d", variants.Width, variants.Height)
+	}
+	keys := []string{images[0].URL, variants.FeedURL, variants.ThumbURL}
+	if imaging.WebPSupported {
+		keys = append(keys, variants.FullWebpURL, variants.FeedWebpURL, variants.ThumbWebpURL)
+	}
+	for _, key := range keys {
+		if key == "" {
+			return errors.New("image variant missing")
+		}
+		obj, err := storage.Current().Get(context.Background(), key)
+		if err != nil {
+			return fmt.Errorf("stored image %q can't be read: %w", key, err)
+		}
+		obj.Body.Close()
+	}
+
+	obj, err := storage.Current().Get(context.Background(), images[0].URL)
+	if err != nil {
+		return err
+	}
+	defer obj.Body.Close()
+	stored, err := io.ReadAll(obj.Body)
+	if err != nil {
+		return err
+	}
+	if bytes.Equal(stored, photo) {
+		return errors.New("stored image is the upload as it was sent")
+	}
+	if _, format, err := image.DecodeConfig(bytes.NewReader(stored)); err != nil || format != "jpeg" {
+		return fmt.Errorf("stored image isn't a JPEG: %v", err)
+	}
+
+	// a file that only claims to be an image is turned away
+	resp, err = h.DoMultipart("/publish-product", map[string]string{
+		"post_type":    "business",
+		"title":        "Not a photo",
+		"description":  "a script named like one",
+		"whatsapp_url": "https://wa.me/1",
+	}, []File{{Field: "images", Filename: "photo.png", Content: []byte("#!/bin/sh\necho hi\n")}})
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusUnsupportedMediaType); err != nil {
+		return err
+	}
+	var posts int64
+	if err := h.DB.Model(&Data.Post{}).Count(&posts).Error; err != nil {
+		return err
+	}
+	if posts != 1 {
+		return fmt.Errorf("expected the rejected upload to leave no post, got %d posts", posts)
+	}
+
+	return nil
+}
+
+func uploadLimitsAreEnforced(h *Harness) error {
+	if _, err := h.CreateUser("Ada Obi", "ada@example.com", "Password1!"); err != nil {
+		return err
+	}
+	if err := h.SignIn("ada@example.com", "Password1!"); err != nil {
+		return err
+	}
+	photo, err := testPNG()
+	if err != nil {
+		return err
+	}
+	publish := func(files ...File) (*Response, error) {
+		return h.DoMultipart("/publish-product", map[string]string{
+			"post_type":    "personal",
+			"title":        "Weekend market",
+			"description":  "stalls",
+			"whatsapp_url": "https://wa.me/1",
+		}, files)
+	}
+
+	// a personal post takes four images
+	var five []File
+	for i := 0; i < 5; i++ {
+		five = append(five, File{Field: "images", Filename: fmt.Sprintf("stall-%d.png", i), Content: photo})
+	}
+	resp, err := publish(five...)
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusBadRequest); err != nil {
+		return err
+	}
+
+	// bigger than the 2 MB the harness allows a file
+	big := append(append([]byte{}, photo...), make([]byte, 3<<20)...)
+	resp, err = publish(File{Field: "images", Filename: "big.png", Content: big})
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusRequestEntityTooLarge); err != nil {
+		return err
+	}
+
+	// the name can't steer the key out of the folder
+	resp, err = publish(File{Field: "images", Filename: `..\../etc/market day.png`, Content: photo})
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+	var image Data.PostImage
+	if err := h.DB.First(&image).Error; err != nil {
+		return err
+	}
+	if strings.Contains(image.URL, "..") || !strings.HasSuffix(image.URL, "_market-day_full.jpg") {
+		return fmt.Errorf("unexpected key %q for an unsafe file name", image.URL)
+	}
+	if image.Bytes == 0 {
+		return errors.New("stored size of the image wasn't recorded")
+	}
+
+	// everything stored so far is more than the quota now allows
+	h.Config.Upload.QuotaBytes = image.Bytes
+	h.Reload()
+	resp, err = publish(File{Field: "images", Filename: "more.png", Content: photo})
+	if err != nil {
+		return err
+	}
+	return resp.Expect(http.StatusForbidden)
+}
+
+func directUploadsAreConfirmed(h *Harness) error {
+	if _, err := h.CreateUser("Ada Obi", "ada@example.com", "Password1!"); err != nil {
+		return err
+	}
+	if err := h.SignIn("ada@example.com", "Password1!"); err != nil {
+		return err
+	}
+	photo, err := testPNG()
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.DoMultipart("/publish-product", map[string]string{
+		"post_type":    "business",
+		"title":        "Aso oke",
+		"description":  "hand woven",
+		"whatsapp_url": "https://wa.me/1",
+	}, nil)
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+	var post struct {
+		PostID uint `json:"post_id"`
+	}
+	if err := resp.JSON(&post); err != nil {
+		return err
+	}
+
+	type started struct {
+		SessionID string `json:"session_id"`
+		UploadURL string `json:"upload_url"`
+	}
+	start := func() (started, error) {
+		resp, err := h.Do(http.MethodPost, "/upload-sessions", map[string]interface{}{
+			"purpose":      "post",
+			"target_id":    post.PostID,
+			"filename":     "aso-oke.png",
+			"content_type": "image/png",
+			"size":         len(photo),
+		})
+		if err != nil {
+			return started{}, err
+		}
+		if err := resp.Expect(http.StatusOK); err != nil {
+			return started{}, err
+		}
+		var session started
+		return session, resp.JSON(&session)
+	}
+	confirm := func(sessionID string) (*Response, error) {
+		return h.Do(http.MethodPost, "/upload-sessions/"+sessionID+"/confirm", nil)
+	}
+
+	session, err := start()
+	if err != nil {
+		return err
+	}
+
+	// nothing to process until the file is there
+	resp, err = confirm(session.SessionID)
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusConflict); err != nil {
+		return err
+	}
+
+	// the URL only takes the upload it was signed for
+	resp, err = h.Upload(strings.Replace(session.UploadURL, "signature=", "signature=0", 1), "image/png", photo)
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusForbidden); err != nil {
+		return err
+	}
+	resp, err = h.Upload(session.UploadURL, "image/png", photo)
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+
+	resp, err = confirm(session.SessionID)
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+	var image Data.PostImage
+	if err := h.DB.Where("post_id = ?", post.PostID).First(&image).Error; err != nil {
+		return fmt.Errorf("confirmed image isn't on the post: %w", err)
+	}
+	if image.FeedURL == "" || image.ThumbURL == "" {
+		return errors.New("confirmed image has no variants")
+	}
+	var stored Data.UploadSession
+	if err := h.DB.Where("token = ?", session.SessionID).First(&stored).Error; err != nil {
+		return err
+	}
+	if _, err := storage.Current().Get(context.Background(), stored.ObjectKey); !errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("processed upload is still stored: %v", err)
+	}
+
+	// a session is used once
+	resp, err = confirm(session.SessionID)
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusConflict); err != nil {
+		return err
+	}
+
+	// one left unconfirmed is deleted with its upload once it expires
+	abandoned, err := start()
+	if err != nil {
+		return err
+	}
+	resp, err = h.Upload(abandoned.UploadURL, "image/png", photo)
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+	if err := h.DB.Model(&Data.UploadSession{}).Where("token = ?", abandoned.SessionID).
+		Update("expires_at", time.Now().Add(-time.Hour).Unix()).Error; err != nil {
+		return err
+	}
+	if deleted := upload.ExpireSessions(context.Background()); deleted != 1 {
+		return fmt.Errorf("expected the abandoned session deleted, got %d", deleted)
+	}
+	if _, err := storage.Current().Get(context.Background(), "uploads/"+abandoned.SessionID); !errors.Is(err, storage.ErrNotFound) {
+		return fmt.Errorf("abandoned upload is still stored: %v", err)
+	}
+	return nil
+}
+
+func unusedImagesAreSwept(h *Harness) error {
+	if _, err := h.CreateUser("Ada Obi", "ada@example.com", "Password1!"); err != nil {
+		return err
+	}
+	if err := h.SignIn("ada@example.com", "Password1!"); err != nil {
+		return err
+	}
+	photo, err := testPNG()
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+
+	// a replaced profile photo and the images of a deleted post are released
+	var photoURLs []string
+	for i := 0; i < 2; i++ {
+		resp, err := h.DoMultipart("/upload-profile-photo", nil, []File{{Field: "profile_photo", Filename: "me.png", Content: photo}})
+		if err != nil {
+			return err
+		}
+		if err := resp.Expect(http.StatusOK); err != nil {
+			return err
+		}
+		var uploaded struct {
+			URL string `json:"profile_photo_url"`
+		}
+		if err := resp.JSON(&uploaded); err != nil {
+			return err
+		}
+		photoURLs = append(photoURLs, uploaded.URL)
+	}
+	publish := func(title string) (uint, error) {
+		resp, err := h.DoMultipart("/publish-product", map[string]string{
+			"post_type":    "business",
+			"title":        title,
+			"description":  "hand woven",
+			"whatsapp_url": "https://wa.me/1",
+		}, []File{{Field: "images", Filename: "aso-oke.png", Content: photo}})
+		if err != nil {
+			return 0, err
+		}
+		if err := resp.Expect(http.StatusOK); err != nil {
+			return 0, err
+		}
+		var post struct {
+			PostID uint `json:"post_id"`
+		}
+		return post.PostID, resp.JSON(&post)
+	}
+	postID, err := publish("Aso oke")
+	if err != nil {
+		return err
+	}
+	var postImage Data.PostImage
+	if err := h.DB.Where("post_id = ?", postID).First(&postImage).Error; err != nil {
+		return err
+	}
+	resp, err := h.Do(http.MethodGet, fmt.Sprintf("/delete-dorng-product/%d", postID), nil)
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+
+	var scheduled int64
+	if err := h.DB.Model(&Data.MediaObject{}).Where("delete_after > 0").Count(&scheduled).Error; err != nil {
+		return err
+	}
+	if scheduled != 2 {
+		return fmt.Errorf("expected the old photo and the post image to be scheduled, got %d", scheduled)
+	}
+
+	// make them due, a dry run only reports them
+	if err := h.DB.Model(&Data.MediaObject{}).Where("delete_after > 0").Update("delete_after", 1).Error; err != nil {
+		return err
+	}
+	report, err := upload.Sweep(ctx, true)
+	if err != nil {
+		return err
+	}
+	if report.Due != 2 || report.Deleted != 0 || len(report.URLs) != 2 || report.Bytes == 0 {
+		return fmt.Errorf("unexpected dry run report %+v", report)
+	}
+	obj, err := storage.Current().Get(ctx, photoURLs[0])
+	if err != nil {
+		return fmt.Errorf("dry run deleted an image: %w", err)
+	}
+	obj.Body.Close()
+
+	report, err = upload.Sweep(ctx, false)
+	if err != nil {
+		return err
+	}
+	if report.Deleted != 2 {
+		return fmt.Errorf("expected 2 images deleted, got %+v", report)
+	}
+	for _, key := range []string{photoURLs[0], postImage.URL, postImage.ThumbURL} {
+		if _, err := storage.Current().Get(ctx, key); !errors.Is(err, storage.ErrNotFound) {
+			return fmt.Errorf("swept image %q is still stored: %v", key, err)
+		}
+	}
+	obj, err = storage.Current().Get(ctx, photoURLs[1])
+	if err != nil {
+		return fmt.Errorf("current profile photo was deleted: %w", err)
+	}
+	obj.Body.Close()
+
+	// an image left behind without a row is found by the sweep, not
+	// deleted straight away
+	postID, err = publish("Adire")
+	if err != nil {
+		return err
+	}
+	if err := h.DB.Unscoped().Where("post_id = ?", postID).Delete(&Data.PostImage{}).Error; err != nil {
+		return err
+	}
+	if err := h.DB.Model(&Data.MediaObject{}).Where("1 = 1").Update("created_at", time.Now().Add(-2*time.Hour)).Error; err != nil {
+		return err
+	}
+	report, err = upload.Sweep(ctx, false)
+	if err != nil {
+		return err
+	}
+	if report.Checked != 2 || report.Orphaned != 1 || report.Deleted != 0 {
+		return fmt.Errorf("expected 1 orphan of the 2 images checked, got %+v", report)
+	}
+	var objects []Data.MediaObject
+	if err := h.DB.Order("id ASC").Find(&objects).Error; err != nil {
+		return err
+	}
+	if len(objects) != 2 || objects[0].URL != photoURLs[1] || objects[0].DeleteAfter != 0 || objects[1].DeleteAfter == 0 {
+		return fmt.Errorf("expected only the orphan to be scheduled, got %+v", objects)
+	}
+
+	return nil
+}
+
+func untrackedImagesAreBackfilled(h *Harness) error {
+	if _, err := h.CreateUser("Ada Obi", "ada@example.com", "Password1!"); err != nil {
+		return err
+	}
+	if err := h.SignIn("ada@example.com", "Password1!"); err != nil {
+		return err
+	}
+	photo, err := testPNG()
+	if err != nil {
+		return err
+	}
+	var postIDs []uint
+	for _, title := range []string{"Aso oke", "Adire"} {
+		resp, err := h.DoMultipart("/publish-product", map[string]string{
+			"post_type":    "business",
+			"title":        title,
+			"description":  "hand woven",
+			"whatsapp_url": "https://wa.me/1",
+		}, []File{{Field: "images", Filename: "aso-oke.png", Content: photo}})
+		if err != nil {
+			return err
+		}
+		if err := resp.Expect(http.StatusOK); err != nil {
+			return err
+		}
+		var post struct {
+			PostID uint `json:"post_id"`
+		}
+		if err := resp.JSON(&post); err != nil {
+			return err
+		}
+		postIDs = append(postIDs, post.PostID)
+	}
+
+	// as if both were stored before images were tracked, then one post was
+	// deleted
+	if err := h.DB.Unscoped().Where("1 = 1").Delete(&Data.MediaObject{}).Error; err != nil {
+		return err
+	}
+	resp, err := h.Do(http.MethodGet, fmt.Sprintf("/delete-dorng-product/%d", postIDs[1]), nil)
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+	var deleted Data.PostImage
+	if err := h.DB.Unscoped().Where("post_id = ?", postIDs[1]).First(&deleted).Error; err != nil {
+		return err
+	}
+	if err := h.DB.Unscoped().Model(&Data.PostImage{}).Where("1 = 1").Update("created_at", time.Now().Add(-2*time.Hour)).Error; err != nil {
+		return err
+	}
+
+	recorded, err := upload.Backfill()
+	if err != nil {
+		return err
+	}
+	if recorded != 2 {
+		return fmt.Errorf("backfill recorded %d images, want 2", recorded)
+	}
+	if recorded, err = upload.Backfill(); err != nil || recorded != 0 {
+		return fmt.Errorf("second backfill recorded %d images, want 0: %v", recorded, err)
+	}
+
+	report, err := upload.Sweep(context.Background(), false)
+	if err != nil {
+		return err
+	}
+	if report.Checked != 2 || report.Orphaned != 1 || len(report.URLs) != 1 || report.URLs[0] != deleted.URL {
+		return fmt.Errorf("expected the deleted post's image to be the one orphan, got %+v", report)
+	}
+	var orphan Data.MediaObject
+	if err := h.DB.Where("url = ?", deleted.URL).First(&orphan).Error; err != nil {
+		return err
+	}
+	if orphan.DeleteAfter == 0 || !strings.Contains(orphan.ObjectKeys, deleted.ThumbURL) {
+		return fmt.Errorf("orphan isn't scheduled with every variant: %+v", orphan)
+	}
+	return nil
+}
+
+func cachedPagesAreInvalidated(h *Harness) error {
+	user, err := h.CreateUser("Ada Obi", "ada@example.com", "Password1!")
+	if err != nil {
+		return err
+	}
+	blog := Data.Blog{UserID: user.ID, Title: "Dyeing adire", Description1: "indigo first"}
+	if err := h.DB.Create(&blog).Error; err != nil {
+		return err
+	}
+	path := fmt.Sprintf("/get-blog/%d", blog.ID)
+	type blogPage struct {
+		Success Data.Blog `json:"success"`
+	}
+	get := func(want string) (*Response, blogPage, error) {
+		var page blogPage
+		resp, err := h.Do(http.MethodGet, path, nil)
+		if err != nil {
+			return nil, page, err
+		}
+		if err := resp.Expect(http.StatusOK); err != nil {
+			return nil, page, err
+		}
+		if got := resp.Header.Get("X-Cache"); got != want {
+			return nil, page, fmt.Errorf("X-Cache %q, want %q", got, want)
+		}
+		return resp, page, resp.JSON(&page)
+	}
+
+	first, _, err := get("MISS")
+	if err != nil {
+		return err
+	}
+	etag := first.Header.Get("ETag")
+	if etag == "" {
+		return errors.New("cached page has no ETag")
+	}
+	if _, _, err := get("HIT"); err != nil {
+		return err
+	}
+	resp, err := h.DoWithHeaders(http.MethodGet, path, nil, map[string]string{"Origin": Origin, "If-None-Match": etag})
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusNotModified); err != nil {
+		return err
+	}
+	if len(resp.Body) != 0 {
+		return errors.New("304 carried a body")
+	}
+
+	// a comment shows on the page straight away
+	resp, err = h.Do(http.MethodPost, "/blog-comment", map[string]interface{}{
+		"blog_id": blog.ID, "email": "bola@example.com", "name": "Bola", "review": "lovely", "rating": 5,
+	})
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+	resp, page, err := get("MISS")
+	if err != nil {
+		return err
+	}
+	if page.Success.BlogReviewsCount != 1 {
+		return fmt.Errorf("page after the comment counts %d comments, want 1", page.Success.BlogReviewsCount)
+	}
+	if resp.Header.Get("ETag") == etag {
+		return errors.New("ETag didn't change with the page")
+	}
+
+	// so does an edit
+	if err := h.SignIn("ada@example.com", "Password1!"); err != nil {
+		return err
+	}
+	resp, err = h.Do(http.MethodPost, "/update-dorng-blog", map[string]interface{}{
+		"blog_id": blog.ID, "blog_title": "Dyeing adire at home", "blog_description1": "indigo first",
+	})
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+	if _, page, err = get("MISS"); err != nil {
+		return err
+	}
+	if page.Success.Title != "Dyeing adire at home" {
+		return fmt.Errorf("page after the edit has title %q", page.Success.Title)
+	}
+	return nil
+}
+
+func metricsNeedTheToken(h *Harness) error {
+	scrape := func(authorization string) (*Response, error) {
+		headers := map[string]string{}
+		if authorization != "" {
+			headers["Authorization"] = authorization
+		}
+		return h.DoWithHeaders(http.MethodGet, "/metrics", nil, headers)
+	}
+
+	// without a token the endpoint is open
+	if _, err := h.Do(http.MethodGet, "/posts-open", nil); err != nil {
+		return err
+	}
+	resp, err := scrape("")
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+	if !strings.Contains(string(resp.Body), `http_requests_total{method="GET",route="/posts-open",status="200"}`) {
+		return errors.New("metrics don't count the request made")
+	}
+
+	h.Config.Metrics.Token = "scrape-token"
+	h.Reload()
+	for _, authorization := range []string{"", "Bearer wrong-token", "scrape-token"} {
+		resp, err := scrape(authorization)
+		if err != nil {
+			return err
+		}
+		if err := resp.Expect(http.StatusUnauthorized); err != nil {
+			return fmt.Errorf("authorization %q: %w", authorization, err)
+		}
+	}
+	resp, err = scrape("Bearer scrape-token")
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+	if !strings.HasPrefix(resp.Header.Get("Content-Type"), "text/plain") {
+		return fmt.Errorf("metrics served as %q", resp.Header.Get("Content-Type"))
+	}
+	return nil
+}
+
+func strictRateLimitsAreEnforced(h *Harness) error {
+	resend := func() (*Response, error) {
+		return h.Do(http.MethodPost, "/resend-otp", map[string]string{"email": "ada@example.com"})
+	}
+
+	// the strict policy is closer to its limit than the global one, its
+	// headers are the ones that show
+	for want := 2; want >= 0; want-- {
+		resp, err := resend()
+		if err != nil {
+			return err
+		}
+		if resp.Status == http.StatusTooManyRequests {
+			return fmt.Errorf("limited with %d requests left", want+1)
+		}
+		if got := resp.Header.Get("RateLimit-Policy"); got != "3;w=600" {
+			return fmt.Errorf("RateLimit-Policy %q, want the resend policy", got)
+		}
+		if got := resp.Header.Get("RateLimit-Remaining"); got != strconv.Itoa(want) {
+			return fmt.Errorf("RateLimit-Remaining %q, want %d", got, want)
+		}
+	}
+
+	resp, err := resend()
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusTooManyRequests); err != nil {
+		return err
+	}
+	if resp.Header.Get("RateLimit-Limit") != "3" || resp.Header.Get("RateLimit-Remaining") != "0" {
+		return fmt.Errorf("429 carries limit %q remaining %q, want 3 and 0", resp.Header.Get("RateLimit-Limit"), resp.Header.Get("RateLimit-Remaining"))
+	}
+	retryAfter, err := strconv.Atoi(resp.Header.Get("Retry-After"))
+	if err != nil || retryAfter <= 0 || retryAfter > 600 {
+		return fmt.Errorf("Retry-After %q, want the seconds until the window resets", resp.Header.Get("Retry-After"))
+	}
+
+	// other routes only count against the global policy
+	resp, err = h.Do(http.MethodGet, "/posts-open", nil)
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+	if got := resp.Header.Get("RateLimit-Limit"); got != "300" {
+		return fmt.Errorf("RateLimit-Limit %q on a read, want the global 300", got)
+	}
+	return nil
+}
+
+func videosArePublished(h *Harness) error {
+	if _, err := h.CreateUser("Ada Obi", "ada@example.com", "Password1!"); err != nil {
+		return err
+	}
+	if err := h.SignIn("ada@example.com", "Password1!"); err != nil {
+		return err
+	}
+	publish := func(files []File) (*Response, error) {
+		return h.DoMultipart("/publish-product", map[string]string{
+			"post_type":    "status",
+			"title":        "New stock",
+			"description":  "just arrived",
+			"whatsapp_url": "https://wa.me/1",
+		}, files)
+	}
+
+	clip := testMP4(10*time.Second, 640, 360)
+	resp, err := publish([]File{{Field: "videos", Filename: "clip.mp4", Content: clip}})
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+
+	var media Data.PostImage
+	if err := h.DB.First(&media).Error; err != nil {
+		return err
+	}
+	if media.Kind != "video" || media.DurationMs != 10000 || media.Width != 640 || media.Height != 360 || media.ContentType != "video/mp4" {
+		return fmt.Errorf("unexpected video %+v", media)
+	}
+	if !strings.HasSuffix(media.URL, "_clip.mp4") || media.Bytes < int64(len(clip)) {
+		return fmt.Errorf("unexpected video key %q or size %d", media.URL, media.Bytes)
+	}
+	obj, err := storage.Current().Get(context.Background(), media.URL)
+	if err != nil {
+		return fmt.Errorf("stored video can't be read: %w", err)
+	}
+	obj.Body.Close()
+	if _, err := exec.LookPath(h.Config.Upload.FFmpegPath); err == nil && media.PosterURL == "" {
+		return errors.New("ffmpeg is installed but the video has no poster")
+	}
+	var tracked int64
+	if err := h.DB.Model(&Data.MediaObject{}).Where("url = ?", media.URL).Count(&tracked).Error; err != nil {
+		return err
+	}
+	if tracked != 1 {
+		return errors.New("video wasn't recorded for the sweeper")
+	}
+
+	// the status feed carries the video and what players need to know
+	resp, err = h.Do(http.MethodGet, "/status-open", nil)
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+	var feed struct {
+		Status []Data.Post `json:"status"`
+	}
+	if err := resp.JSON(&feed); err != nil {
+		return err
+	}
+	if len(feed.Status) != 1 || len(feed.Status[0].Images) != 1 {
+		return fmt.Errorf("expected 1 status with 1 video, got %+v", feed.Status)
+	}
+	if got := feed.Status[0].Images[0]; got.Kind != "video" || got.URL != media.URL || got.DurationMs != 10000 {
+		return fmt.Errorf("status feed has %+v", got)
+	}
+
+	// a status takes one clip of at most 30 seconds, and only a real video
+	resp, err = publish([]File{{Field: "videos", Filename: "long.mp4", Content: testMP4(45*time.Second, 640, 360)}})
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusRequestEntityTooLarge); err != nil {
+		return fmt.Errorf("45 second status: %w", err)
+	}
+	photo, err := testPNG()
+	if err != nil {
+		return err
+	}
+	resp, err = publish([]File{{Field: "videos", Filename: "clip.mp4", Content: photo}})
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusUnsupportedMediaType); err != nil {
+		return fmt.Errorf("image named like a video: %w", err)
+	}
+	resp, err = publish([]File{
+		{Field: "images", Filename: "photo.png", Content: photo},
+		{Field: "videos", Filename: "clip.mp4", Content: clip},
+	})
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusBadRequest); err != nil {
+		return fmt.Errorf("photo and video on one status: %w", err)
+	}
+
+	var posts int64
+	if err := h.DB.Model(&Data.Post{}).Count(&posts).Error; err != nil {
+		return err
+	}
+	if posts != 1 {
+		return fmt.Errorf("expected the refused uploads to leave no post, got %d posts", posts)
+	}
+
+	return nil
+}
+
+func mediaIsServed(h *Harness) error {
+	ada, err := h.CreateUser("Ada Obi", "ada@example.com", "Password1!")
+	if err != nil {
+		return err
+	}
+	if _, err := h.CreateUser("Bola Ade", "bola@example.com", "Password1!"); err != nil {
+		return err
+	}
+	if err := h.SignIn("ada@example.com", "Password1!"); err != nil {
+		return err
+	}
+	photo, err := testPNG()
+	if err != nil {
+		return err
+	}
+	resp, err := h.DoMultipart("/publish-product", map[string]string{
+		"post_type":    "business",
+		"title":        "Aso oke",
+		"description":  "hand woven",
+		"whatsapp_url": "https://wa.me/1",
+	}, []File{{Field: "images", Filename: "aso-oke.png", Content: photo}})
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+	var image Data.PostImage
+	if err := h.DB.First(&image).Error; err != nil {
+		return err
+	}
+	stored := func(key string) ([]byte, error) {
+		obj, err := storage.Current().Get(context.Background(), key)
+		if err != nil {
+			return nil, err
+		}
+		defer obj.Body.Close()
+		return io.ReadAll(obj.Body)
+	}
+	get := func(path string, headers map[string]string) (*Response, error) {
+		return h.DoWithHeaders(http.MethodGet, path, nil, headers)
+	}
+
+	// the key the post keeps is served as it is, cached for good
+	resp, err = get(media.Path(image.URL), nil)
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+	full, err := stored(image.URL)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(resp.Body, full) {
+		return errors.New("served image isn't the stored one")
+	}
+	if !strings.Contains(resp.Header.Get("Cache-Control"), "immutable") {
+		return fmt.Errorf("public media isn't cached for good: %q", resp.Header.Get("Cache-Control"))
+	}
+
+	// a direct upload isn't checked or stripped yet, and a key outside the
+	// media folders was never meant to be served
+	for _, key := range []string{h.Config.Storage.UploadFolder + "unchecked-token", "elsewhere/photo.png"} {
+		if err := storage.Current().Put(context.Background(), key, bytes.NewReader(photo), int64(len(photo)), "image/png"); err != nil {
+			return err
+		}
+		unserved, err := get(media.Path(key), nil)
+		if err != nil {
+			return err
+		}
+		if err := unserved.Expect(http.StatusNotFound); err != nil {
+			return fmt.Errorf("%s: %w", key, err)
+		}
+	}
+
+	// the client already has it
+	resp, err = get(media.Path(image.URL), map[string]string{"If-None-Match": resp.Header.Get("ETag")})
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusNotModified); err != nil {
+		return err
+	}
+
+	// a small width is the thumbnail, in WebP for a client that takes it
+	want, wantType := image.ThumbURL, "image/jpeg"
+	if imaging.WebPSupported {
+		want, wantType = image.ThumbWebpURL, "image/webp"
+	}
+	resp, err = get(media.Path(image.URL)+"?w=100", map[string]string{"Accept": "image/webp,image/*"})
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+	thumb, err := stored(want)
+	if err != nil {
+		return err
+	}
+	if !bytes.Equal(resp.Body, thumb) || resp.Header.Get("Content-Type") != wantType {
+		return fmt.Errorf("expected the %s thumbnail, got %s", wantType, resp.Header.Get("Content-Type"))
+	}
+	if resp.Header.Get("Vary") != "Accept" {
+		return errors.New("negotiated image doesn't vary by Accept")
+	}
+
+	// emails link images under /image/
+	resp, err = get(strings.Replace(media.Path(image.URL), "/media/", "/image/", 1), nil)
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+
+	// ranges, two that fit and one past the end
+	for _, part := range [][2]int{{0, 9}, {10, 29}} {
+		resp, err = get(media.Path(image.URL), map[string]string{"Range": fmt.Sprintf("bytes=%d-%d", part[0], part[1])})
+		if err != nil {
+			return err
+		}
+		if err := resp.Expect(http.StatusPartialContent); err != nil {
+			return err
+		}
+		if !bytes.Equal(resp.Body, full[part[0]:part[1]+1]) || resp.Header.Get("Content-Range") != fmt.Sprintf("bytes %d-%d/%d", part[0], part[1], len(full)) {
+			return fmt.Errorf("wrong part served: %q", resp.Header.Get("Content-Range"))
+		}
+	}
+	resp, err = get(media.Path(image.URL), map[string]string{"Range": fmt.Sprintf("bytes=%d-", len(full))})
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusRequestedRangeNotSatisfiable); err != nil {
+		return err
+	}
+
+	// an image stored before there were variants is resized on the way out
+	legacy := h.Config.Storage.PostFolder + "legacy.png"
+	if err := storage.Current().Put(context.Background(), legacy, bytes.NewReader(photo), int64(len(photo)), "image/png"); err != nil {
+		return err
+	}
+	resp, err = get(media.Path(legacy)+"?w=100", nil)
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+	if resp.Header.Get("Content-Type") != "image/jpeg" {
+		return fmt.Errorf("resized legacy image is %q, not a JPEG", resp.Header.Get("Content-Type"))
+	}
+
+	// private media needs a signed link, which only its owner gets
+	document := []byte("%PDF-1.4 id card")
+	private := media.PrivateKey(h.Config.Storage, ada.ID, "kyc/id-card.pdf")
+	if err := storage.Current().Put(context.Background(), private, bytes.NewReader(document), int64(len(document)), "application/pdf"); err != nil {
+		return err
+	}
+	// unsigned, through the API and straight from the local driver
+	for _, path := range []string{media.Path(private), "/storage/" + private} {
+		resp, err = get(path, nil)
+		if err != nil {
+			return err
+		}
+		if err := resp.Expect(http.StatusForbidden); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	resp, err = h.Do(http.MethodPost, "/media/sign", map[string]interface{}{"key": private, "expires_in": 60})
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+	var signed struct {
+		URL string `json:"url"`
+	}
+	if err := resp.JSON(&signed); err != nil {
+		return err
+	}
+	resp, err = get(signed.URL, nil)
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+	if !bytes.Equal(resp.Body, document) || !strings.HasPrefix(resp.Header.Get("Cache-Control"), "private") {
+		return fmt.Errorf("signed document served wrong, cache control %q", resp.Header.Get("Cache-Control"))
+	}
+	resp, err = get(strings.Replace(signed.URL, "signature=", "signature=0", 1), nil)
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusForbidden); err != nil {
+		return err
+	}
+
+	h.ClearCookies()
+	if err := h.SignIn("bola@example.com", "Password1!"); err != nil {
+		return err
+	}
+	resp, err = h.Do(http.MethodPost, "/media/sign", map[string]interface{}{"key": private})
+	if err != nil {
+		return err
+	}
+	return resp.Expect(http.StatusForbidden)
+}
+
+func searchRanksPosts(h *Harness) error {
+	if _, err := h.CreateUser("Ada Obi", "ada@example.com", "Password1!"); err != nil {
+		return err
+	}
+	if err := h.SignIn("ada@example.com", "Password1!"); err != nil {
+		return err
+	}
+	publish := func(title, description, category string) (uint, error) {
+		resp, err := h.DoMultipart("/publish-product", map[string]string{
+			"post_type":         "business",
+			"title":             title,
+			"description":       description,
+			"business_category": category,
+			"location":          "Lagos",
+			"whatsapp_url":      "https://wa.me/1",
+		}, nil)
+		if err != nil {
+			return 0, err
+		}
+		if err := resp.Expect(http.StatusOK); err != nil {
+			return 0, err
+		}
+		var post struct {
+			PostID uint `json:"post_id"`
+		}
+		return post.PostID, resp.JSON(&post)
+	}
+	gowns, err := publish("Ankara gowns", "Tailored ankara gowns for weddings", "fashion")
+	if err != nil {
+		return err
+	}
+	shoes, err := publish("Leather shoes", "Handmade leather shoes with ankara laces", "fashion")
+	if err != nil {
+		return err
+	}
+	fabric, err := publish("Ankara fabric", "Six yards of cotton print", "fabrics")
+	if err != nil {
+		return err
+	}
+
+	search := func(query string) ([]uint, error) {
+		resp, err := h.Do(http.MethodGet, "/search?"+query, nil)
+		if err != nil {
+			return nil, err
+		}
+		if err := resp.Expect(http.StatusOK); err != nil {
+			return nil, err
+		}
+		var found struct {
+			Success []Data.Post `json:"success"`
+		}
+		if err := resp.JSON(&found); err != nil {
+			return nil, err
+		}
+		ids := make([]uint, len(found.Success))
+		for i, post := range found.Success {
+			ids[i] = post.ID
+		}
+		return ids, nil
+	}
+	expect := func(query string, want ...uint) error {
+		got, err := search(query)
+		if err != nil {
+			return err
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			return fmt.Errorf("search %q found posts %v, expected %v", query, got, want)
+		}
+		return nil
+	}
+
+	// a word in the title counts for more than one in the description
+	if err := expect("q=ankara", gowns, fabric, shoes); err != nil {
+		return err
+	}
+	// engagement breaks the tie between posts that match as well
+	if err := h.DB.Model(&Data.Post{}).Where("id = ?", fabric).Update("clicks", 100).Error; err != nil {
+		return err
+	}
+	if err := expect("q=ankara", fabric, gowns, shoes); err != nil {
+		return err
+	}
+	// typos, word forms and a word still being typed, posts that match only
+	// some of the words come after the ones that match them all
+	for _, query := range []string{"q=ankra+gown", "q=tailoring", "q=weding", "q=ankara+go"} {
+		got, err := search(query)
+		if err != nil {
+			return err
+		}
+		if len(got) == 0 || got[0] != gowns {
+			return fmt.Errorf("search %q found posts %v, expected %d first", query, got, gowns)
+		}
+	}
+	if err := expect("q=ankara&category=fashion&limit=1&page=2", shoes); err != nil {
+		return err
+	}
+
+	// the index follows edits and deletes
+	resp, err := h.Do(http.MethodPost, "/update-dorng-product", map[string]interface{}{
+		"product_id":          shoes,
+		"product_title":       "Leather sandals",
+		"product_description": "Handmade leather sandals",
+	})
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+	if err := expect("q=ankara", fabric, gowns); err != nil {
+		return err
+	}
+	if err := expect("q=sandal", shoes); err != nil {
+		return err
+	}
+	resp, err = h.Do(http.MethodGet, fmt.Sprintf("/delete-dorng-product/%d", gowns), nil)
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+	if err := expect("q=ankara", fabric); err != nil {
+		return err
+	}
+	var terms int64
+	if err := h.DB.Model(&Data.SearchTerm{}).Where("post_id = ?", gowns).Count(&terms).Error; err != nil {
+		return err
+	}
+	if terms != 0 {
+		return fmt.Errorf("deleted post still has %d terms in the index", terms)
+	}
+	return nil
+}
+
+func listingsAreFaceted(h *Harness) error {
+	if _, err := h.CreateUser("Ada Obi", "ada@example.com", "Password1!"); err != nil {
+		return err
+	}
+	if err := h.SignIn("ada@example.com", "Password1!"); err != nil {
+		return err
+	}
+	publish := func(fields map[string]string) (uint, error) {
+		fields["whatsapp_url"] = "https://wa.me/1"
+		resp, err := h.DoMultipart("/publish-product", fields, nil)
+		if err != nil {
+			return 0, err
+		}
+		if err := resp.Expect(http.StatusOK); err != nil {
+			return 0, err
+		}
+		var post struct {
+			PostID uint `json:"post_id"`
+		}
+		return post.PostID, resp.JSON(&post)
+	}
+	gown, err := publish(map[string]string{"post_type": "business", "title": "Ankara gown", "description": "tailored",
+		"business_category": "fashion", "location": "Lagos", "product_price": "15000"})
+	if err != nil {
+		return err
+	}
+	bag, err := publish(map[string]string{"post_type": "business", "title": "Leather bag", "description": "handmade",
+		"business_category": "fashion", "location": "Abuja", "product_price": "60000", "stock_availability": "false"})
+	if err != nil {
+		return err
+	}
+	show, err := publish(map[string]string{"post_type": "event", "title": "Fashion week", "description": "runway shows",
+		"location": "Lagos"})
+	if err != nil {
+		return err
+	}
+	rice, err := publish(map[string]string{"post_type": "business", "title": "Jollof rice", "description": "party packs",
+		"business_category": "food", "location": "Lagos", "product_price": "3000"})
+	if err != nil {
+		return err
+	}
+	if err := h.DB.Model(&Data.Post{}).Where("id = ?", rice).Update("verified", true).Error; err != nil {
+		return err
+	}
+
+	type listing struct {
+		Success    []Data.Post       `json:"success"`
+		Facets     Data.SearchFacets `json:"facets"`
+		Pagination struct {
+			AllRecords int64
+		} `json:"pagination"`
+	}
+	listAt := func(path, query string, want ...uint) (listing, error) {
+		resp, err := h.Do(http.MethodGet, path+"?"+query, nil)
+		if err != nil {
+			return listing{}, err
+		}
+		if err := resp.Expect(http.StatusOK); err != nil {
+			return listing{}, err
+		}
+		var found listing
+		if err := resp.JSON(&found); err != nil {
+			return listing{}, err
+		}
+		got := make([]uint, len(found.Success))
+		for i, post := range found.Success {
+			got[i] = post.ID
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			return listing{}, fmt.Errorf("listing %s?%s has posts %v, expected %v", path, query, got, want)
+		}
+		return found, nil
+	}
+	list := func(query string, want ...uint) (listing, error) {
+		return listAt("/search", query, want...)
+	}
+	counts := func(facet []Data.FacetCount) string {
+		var out []string
+		for _, value := range facet {
+			out = append(out, fmt.Sprintf("%s=%d", value.Value, value.Count))
+		}
+		return strings.Join(out, " ")
+	}
+
+	// every post, newest first, or in the order asked for
+	found, err := list("", rice, show, bag, gown)
+	if err != nil {
+		return err
+	}
+	if found.Pagination.AllRecords != 4 {
+		return fmt.Errorf("expected 4 posts in all, got %d", found.Pagination.AllRecords)
+	}
+	if got := counts(found.Facets.PostTypes); got != "business=3 event=1" {
+		return fmt.Errorf("post type facet is %q", got)
+	}
+	if _, err := list("sort=price_desc", bag, gown, rice, show); err != nil {
+		return err
+	}
+	if _, err := list("q=fashion&sort=price_asc", show, gown, bag); err != nil {
+		return err
+	}
+
+	// a facet is counted without its own filter, with all the others
+	found, err = list("category=fashion&location=lagos", gown)
+	if err != nil {
+		return err
+	}
+	if got := counts(found.Facets.Categories); got != "fashion=1 food=1" {
+		return fmt.Errorf("category facet is %q", got)
+	}
+	if got := counts(found.Facets.Locations); got != "Abuja=1 Lagos=1" {
+		return fmt.Errorf("location facet is %q", got)
+	}
+
+	found, err = list("min_price=5000&max_price=20000", gown)
+	if err != nil {
+		return err
+	}
+	var ranges []string
+	for _, priceRange := range found.Facets.PriceRanges {
+		ranges = append(ranges, fmt.Sprintf("%d:%d", priceRange.Min, priceRange.Count))
+	}
+	if got := strings.Join(ranges, " "); got != "0:2 5000:1 20000:0 50000:1 200000:0" {
+		return fmt.Errorf("price facet is %q", got)
+	}
+
+	found, err = list("in_stock=true", rice, show, gown)
+	if err != nil {
+		return err
+	}
+	if found.Facets.InStock != 3 || found.Facets.Verified != 1 {
+		return fmt.Errorf("expected 3 in stock and 1 verified, got %d and %d", found.Facets.InStock, found.Facets.Verified)
+	}
+	if _, err := list("verified=true&post_type=business,event", rice); err != nil {
+		return err
+	}
+
+	// the products pages filter and count the same, oldest first unless
+	// asked otherwise like they always were
+	if _, err := listAt("/products/1", "", gown, bag, show, rice); err != nil {
+		return err
+	}
+	if _, err := listAt("/products/1", "sort=product_price&order=desc", bag, gown, rice, show); err != nil {
+		return err
+	}
+	if _, err := listAt("/products/1", "sort=newest&category=na&in_stock=true", rice, show, gown); err != nil {
+		return err
+	}
+	found, err = listAt("/products/1", "category=fashion&location=lagos", gown)
+	if err != nil {
+		return err
+	}
+	if got := counts(found.Facets.Categories); got != "fashion=1 food=1" {
+		return fmt.Errorf("products page category facet is %q", got)
+	}
+	if _, err := listAt("/products/1", "min_price=1000&max_price=20000&verified=true", rice); err != nil {
+		return err
+	}
+	if _, err := listAt("/products/2", "limit=3", rice); err != nil {
+		return err
+	}
+
+	// a sort or price it doesn't know is refused, not put in the query
+	for _, query := range []string{
+		"/search?sort=title;DROP TABLE posts", "/search?min_price=cheap", "/search?min_price=10&max_price=5",
+		"/products/1?min_price=cheap", "/products/1?sort=distance",
+	} {
+		resp, err := h.Do(http.MethodGet, strings.ReplaceAll(query, " ", "+"), nil)
+		if err != nil {
+			return err
+		}
+		if err := resp.Expect(http.StatusBadRequest); err != nil {
+			return fmt.Errorf("%q: %w", query, err)
+		}
+	}
+	return nil
+}
+
+func nearbyIsByDistance(h *Harness) error {
+	// Ada sells on Lagos Island, Bayo in Abuja
+	sellers := []struct {
+		name, email string
+		lat, lng    float64
+	}{{"Ada Obi", "ada@example.com", 6.5244, 3.3792}, {"Bayo Ade", "bayo@example.com", 9.0765, 7.3986}}
+	for _, seller := range sellers {
+		user, err := h.CreateUser(seller.name, seller.email, "Password1!")
+		if err != nil {
+			return err
+		}
+		err = h.DB.Model(&Data.User{}).Where("id = ?", user.ID).
+			Updates(map[string]interface{}{"latitude": seller.lat, "longitude": seller.lng}).Error
+		if err != nil {
+			return err
+		}
+	}
+	publish := func(fields map[string]string) (uint, error) {
+		fields["post_type"], fields["description"], fields["whatsapp_url"] = "business", "for sale", "https://wa.me/1"
+		resp, err := h.DoMultipart("/publish-product", fields, nil)
+		if err != nil {
+			return 0, err
+		}
+		if err := resp.Expect(http.StatusOK); err != nil {
+			return 0, err
+		}
+		var post struct {
+			PostID uint `json:"post_id"`
+		}
+		return post.PostID, resp.JSON(&post)
+	}
+
+	if err := h.SignIn("ada@example.com", "Password1!"); err != nil {
+		return err
+	}
+	// the stall is where Ada is, the others where they say
+	stall, err := publish(map[string]string{"title": "Market stall"})
+	if err != nil {
+		return err
+	}
+	shoes, err := publish(map[string]string{"title": "Ikeja shoes", "latitude": "6.6018", "longitude": "3.3515"})
+	if err != nil {
+		return err
+	}
+	cakes, err := publish(map[string]string{"title": "Lekki cakes", "latitude": "6.4698", "longitude": "3.5852"})
+	if err != nil {
+		return err
+	}
+	resp, err := h.DoMultipart("/publish-product", map[string]string{"post_type": "business", "title": "Nowhere",
+		"description": "for sale", "whatsapp_url": "https://wa.me/1", "latitude": "91", "longitude": "3"}, nil)
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusBadRequest); err != nil {
+		return fmt.Errorf("latitude 91: %w", err)
+	}
+	h.ClearCookies()
+	if err := h.SignIn("bayo@example.com", "Password1!"); err != nil {
+		return err
+	}
+	suits, err := publish(map[string]string{"title": "Abuja suits"})
+	if err != nil {
+		return err
+	}
+
+	origin := geo.Point{Lat: 6.45, Lng: 3.40}
+	at := map[uint]geo.Point{
+		stall: {Lat: 6.5244, Lng: 3.3792}, shoes: {Lat: 6.6018, Lng: 3.3515},
+		cakes: {Lat: 6.4698, Lng: 3.5852}, suits: {Lat: 9.0765, Lng: 7.3986},
+	}
+	list := func(path string, want ...uint) (int64, error) {
+		resp, err := h.Do(http.MethodGet, path, nil)
+		if err != nil {
+			return 0, err
+		}
+		if err := resp.Expect(http.StatusOK); err != nil {
+			return 0, err
+		}
+		var found struct {
+			Success    []Data.Post `json:"success"`
+			Pagination struct {
+				AllRecords int64
+			} `json:"pagination"`
+		}
+		if err := resp.JSON(&found); err != nil {
+			return 0, err
+		}
+		got := make([]uint, len(found.Success))
+		for i, post := range found.Success {
+			got[i] = post.ID
+			if post.DistanceKm == nil {
+				return 0, fmt.Errorf("%s: post %d has no distance", path, post.ID)
+			}
+			if want := geo.Distance(origin, at[post.ID]); math.Abs(*post.DistanceKm-want) > 0.01 {
+				return 0, fmt.Errorf("%s: post %d is %.2f km away, expected %.2f", path, post.ID, *post.DistanceKm, want)
+			}
+		}
+		if fmt.Sprint(got) != fmt.Sprint(want) {
+			return 0, fmt.Errorf("%s has posts %v, expected %v", path, got, want)
+		}
+		return found.Pagination.AllRecords, nil
+	}
+
+	near := "lat=6.45&lng=3.40"
+	if _, err := list("/nearby?"+near, stall, shoes, cakes); err != nil {
+		return err
+	}
+	if _, err := list("/nearby?"+near+"&radius_km=10", stall); err != nil {
+		return err
+	}
+	total, err := list("/nearby?"+near+"&limit=1&page=2", shoes)
+	if err != nil {
+		return err
+	}
+	if total != 3 {
+		return fmt.Errorf("expected 3 posts nearby in all, got %d", total)
+	}
+	if _, err := list("/search?sort=distance&"+near, stall, shoes, cakes, suits); err != nil {
+		return err
+	}
+	if _, err := list("/search?q=shoes&sort=distance&radius_km=18&"+near, shoes); err != nil {
+		return err
+	}
+
+	// only the sellers around are businesses nearby
+	for point, want := range map[string]string{near: "Ada Obi", "lat=9.05&lng=7.40": "Bayo Ade"} {
+		resp, err := h.Do(http.MethodGet, "/nearby?type=businesses&"+point, nil)
+		if err != nil {
+			return err
+		}
+		if err := resp.Expect(http.StatusOK); err != nil {
+			return err
+		}
+		var found struct {
+			Success []Data.NearbyBusiness `json:"success"`
+		}
+		if err := resp.JSON(&found); err != nil {
+			return err
+		}
+		if len(found.Success) != 1 || found.Success[0].FullName != want {
+			return fmt.Errorf("businesses near %s are %+v, expected %s", point, found.Success, want)
+		}
+	}
+
+	for _, path := range []string{"/nearby", "/nearby?lat=95&lng=3", "/nearby?" + near + "&radius_km=600",
+		"/nearby?" + near + "&type=people", "/search?sort=distance"} {
+		resp, err := h.Do(http.MethodGet, path, nil)
+		if err != nil {
+			return err
+		}
+		if err := resp.Expect(http.StatusBadRequest); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	return nil
+}
+
+func rotatedKeysStillVerify(h *Harness) error {
+	if _, err := h.CreateUser("Ada Obi", "ada@example.com", "Password1!"); err != nil {
+		return err
+	}
+	if err := h.SignIn("ada@example.com", "Password1!"); err != nil {
+		return err
+	}
+	oldKid, err := h.tokenKid()
+	if err != nil {
+		return err
+	}
+	if kids, err := h.jwksKids(); err != nil {
+		return err
+	} else if strings.Join(kids, ",") != oldKid {
+		return fmt.Errorf("JWKS has %v, want only the signing key %s", kids, oldKid)
+	}
+
+	// the key pair committed before rotation existed, anyone can sign with it
+	committedPEM, err := os.ReadFile(filepath.Join(h.keysDir, oldKid+".private.pem"))
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(h.keysDir, "private_key.pem"), committedPEM, 0o600); err != nil {
+		return err
+	}
+	committedPub, err := os.ReadFile(filepath.Join(h.keysDir, oldKid+".public.pem"))
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(h.keysDir, "public_key.pem"), committedPub, 0o644); err != nil {
+		return err
+	}
+
+	// rotate the way jwt-keys rotate and a restart would
+	newKid, err := myjwt.GenerateKey(h.keysDir)
+	if err != nil {
+		return err
+	}
+	for _, name := range []string{"private_key.pem", "public_key.pem"} {
+		if _, err := os.Stat(filepath.Join(h.keysDir, name)); !os.IsNotExist(err) {
+			return fmt.Errorf("%s is still there after the rotation", name)
+		}
+	}
+	if err := myjwt.InitJWT(h.Config.JWT); err != nil {
+		return err
+	}
+	if kids, err := h.jwksKids(); err != nil {
+		return err
+	} else if strings.Join(kids, ",") != strings.Join(sortedStrings(oldKid, newKid), ",") {
+		return fmt.Errorf("JWKS has %v after the rotation, want %s and %s", kids, oldKid, newKid)
+	}
+
+	resp, err := h.Do(http.MethodGet, "/profile", nil)
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return fmt.Errorf("token signed with the previous key: %w", err)
+	}
+
+	h.ClearCookies()
+	if err := h.SignIn("ada@example.com", "Password1!"); err != nil {
+		return err
+	}
+	if kid, err := h.tokenKid(); err != nil {
+		return err
+	} else if kid != newKid {
+		return fmt.Errorf("new token signed with %s, want %s", kid, newKid)
+	}
+
+	// tokens from before rotation carry no kid, even signed with a key we
+	// verify with they are refused, so a forged one can't be refreshed
+	signed := h.cookies
+	h.cookies = map[string]string{}
+	for name, value := range signed {
+		h.cookies[name] = value
+	}
+	newPEM, err := os.ReadFile(filepath.Join(h.keysDir, newKid+".private.pem"))
+	if err != nil {
+		return err
+	}
+	newKey, err := jwt.ParseRSAPrivateKeyFromPEM(newPEM)
+	if err != nil {
+		return err
+	}
+	for _, name := range []string{"__BusinessConnect-Auth-Token", "__BusinessConnect-Refresh-Token"} {
+		claims := jwt.MapClaims{}
+		if _, _, err := jwt.NewParser().ParseUnverified(h.cookies[name], claims); err != nil {
+			return err
+		}
+		forged, err := jwt.NewWithClaims(jwt.SigningMethodRS256, claims).SignedString(newKey)
+		if err != nil {
+			return err
+		}
+		h.cookies[name] = forged
+	}
+	resp, err = h.Do(http.MethodGet, "/profile", nil)
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusUnauthorized); err != nil {
+		return fmt.Errorf("token without a kid: %w", err)
+	}
+	h.cookies = signed
+
+	// a deployment keeping its keys in the environment rotates the same way,
+	// the key it replaced moves to the previous keys
+	previousPEM, err := os.ReadFile(filepath.Join(h.keysDir, newKid+".public.pem"))
+	if err != nil {
+		return err
+	}
+	envKeysDir, err := os.MkdirTemp("", "business-connect-env-keys-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(envKeysDir)
+	envKid, err := myjwt.GenerateKey(envKeysDir)
+	if err != nil {
+		return err
+	}
+	envPEM, err := os.ReadFile(filepath.Join(envKeysDir, envKid+".private.pem"))
+	if err != nil {
+		return err
+	}
+	emptyDir, err := os.MkdirTemp("", "business-connect-no-keys-")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(emptyDir)
+
+	envConfig := config.JWTConfig{KeysDir: emptyDir}
+	if err := myjwt.InitJWT(envConfig); err == nil {
+		return errors.New("started without a signing key")
+	}
+	envConfig.PrivateKey = string(envPEM)
+	envConfig.KeyID = envKid
+	envConfig.PreviousKeyIDs = []string{newKid}
+	envConfig.PreviousPublicKeys = string(previousPEM)
+	if err := myjwt.InitJWT(envConfig); err != nil {
+		return err
+	}
+	resp, err = h.Do(http.MethodGet, "/profile", nil)
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return fmt.Errorf("token signed with JWT_PREVIOUS_PUBLIC_KEYS' key: %w", err)
+	}
+	return nil
+}
+
+// useMockProvider runs a mock OpenID Connect issuer and signs in through it
+// as the "mock" provider until the returned func stops it
+func (h *Harness) useMockProvider() (func(), error) {
+	// the client secret is a JWT signed with this key, the way Apple wants it
+	clientKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, err
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(clientKey)
+	if err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	issuerServer := httptest.NewServer(mux)
+	issuer, err := oidc.NewMockIssuer(issuerServer.URL)
+	if err != nil {
+		issuerServer.Close()
+		return nil, err
+	}
+	issuer.ClientKey = &clientKey.PublicKey
+	mux.Handle("/", issuer.Handler())
+
+	h.Config.OIDC.RedirectBaseURL = "https://api.example.com"
+	h.Config.OIDC.Providers["mock"] = config.OIDCProviderConfig{
+		ClientID:   "mock-client",
+		Issuer:     issuerServer.URL,
+		TeamID:     "MOCKTEAM",
+		KeyID:      "MOCKKEY",
+		PrivateKey: string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der})),
+	}
+	h.Reload()
+	return issuerServer.Close, nil
+}
+
+func socialSignInClaimsAccounts(h *Harness) error {
+	stop, err := h.useMockProvider()
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	// someone signs up with an address that isn't theirs and never verifies it
+	squatter, err := dbFunc.DBHelper.CreateNewUser(Data.User{
+		FullName: "Not Ada",
+		Email:    "ada@example.com",
+		Password: "Squatter1!",
+		UserType: "USER",
+	})
+	if err != nil {
+		return err
+	}
+
+	// an address the provider didn't verify is never linked
+	location, err := h.socialSignIn("ada@example.com", false)
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(location, "oidc_error=email_not_verified") {
+		return fmt.Errorf("unverified provider email redirected to %s", location)
+	}
+
+	location, err = h.socialSignIn("ada@example.com", true)
+	if err != nil {
+		return err
+	}
+	if location != Origin+"/dashboard" {
+		return fmt.Errorf("signed in through the provider but redirected to %s", location)
+	}
+	resp, err := h.Do(http.MethodGet, "/profile", nil)
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+	var profile struct {
+		User Data.User `json:"user"`
+	}
+	if err := resp.JSON(&profile); err != nil {
+		return err
+	}
+	if profile.User.ID != squatter.ID || !profile.User.EmailVerified {
+		return fmt.Errorf("signed in as %d verified %v, want the claimed account %d verified", profile.User.ID, profile.User.EmailVerified, squatter.ID)
+	}
+
+	h.ClearCookies()
+	resp, err = h.Do(http.MethodPost, "/sign-in", map[string]string{"email": "ada@example.com", "password": "Squatter1!"})
+	if err != nil {
+		return err
+	}
+	if resp.Status == http.StatusOK {
+		return errors.New("the squatter's password still signs in to the claimed account")
+	}
+	return nil
+}
+
+func socialAccountsReauthenticate(h *Harness) error {
+	stop, err := h.useMockProvider()
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	if _, err := h.socialSignIn("ada@example.com", true); err != nil {
+		return err
+	}
+	changeEmail := func() (*Response, error) {
+		return h.Do(http.MethodPost, "/profile/update/email", map[string]string{"new_email": "ada.obi@example.com"})
+	}
+
+	// signed in, but not asked to prove it's them again
+	resp, err := changeEmail()
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusUnauthorized); err != nil {
+		return fmt.Errorf("email change without a password or sign in again: %w", err)
+	}
+
+	location, err := h.socialLogin("/auth/oidc/mock/start?reauth=true&redirect=/dashboard/settings&login_hint=ada%40example.com", true)
+	if err != nil {
+		return err
+	}
+	if location != Origin+"/dashboard/settings" {
+		return fmt.Errorf("signed in again but redirected to %s", location)
+	}
+	if resp, err = changeEmail(); err != nil {
+		return err
+	}
+	// the OTP email can't be sent from here, the change got past the check
+	if resp.Status == http.StatusUnauthorized {
+		return fmt.Errorf("email change refused after signing in again: %s", resp.Body)
+	}
+	var user Data.User
+	if err := h.DB.Where("email = ?", "ada@example.com").First(&user).Error; err != nil {
+		return err
+	}
+	if user.PendingEmail != "ada.obi@example.com" {
+		return fmt.Errorf("pending email %q, want the new one", user.PendingEmail)
+	}
+
+	// one sign in again confirms one change
+	if resp, err = changeEmail(); err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusUnauthorized); err != nil {
+		return fmt.Errorf("second email change on one sign in again: %w", err)
+	}
+	return nil
+}
+
+func socialAccountsCanBeDeleted(h *Harness) error {
+	stop, err := h.useMockProvider()
+	if err != nil {
+		return err
+	}
+	defer stop()
+
+	if _, err := h.socialSignIn("ada@example.com", true); err != nil {
+		return err
+	}
+	deleteAccount := func() (*Response, error) {
+		return h.Do(http.MethodPost, "/profile/delete", map[string]string{})
+	}
+
+	// nobody knows the password of an account made through the provider
+	resp, err := deleteAccount()
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusUnauthorized); err != nil {
+		return fmt.Errorf("deletion without a password or sign in again: %w", err)
+	}
+
+	if _, err := h.socialLogin("/auth/oidc/mock/start?reauth=true&redirect=/dashboard/settings&login_hint=ada%40example.com", true); err != nil {
+		return err
+	}
+	if resp, err = deleteAccount(); err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return fmt.Errorf("deletion after signing in again: %w", err)
+	}
+
+	var user Data.User
+	if err := h.DB.Where("email = ?", "ada@example.com").First(&user).Error; err != nil {
+		return err
+	}
+	var scheduled int64
+	h.DB.Model(&Data.AccountDeletion{}).Where("user_id = ?", user.ID).Count(&scheduled)
+	if scheduled != 1 {
+		return fmt.Errorf("%d deletions scheduled, want 1", scheduled)
+	}
+	return nil
+}
+
+func lockedAccountsAreUnlocked(h *Harness) error {
+	if _, err := h.CreateUser("Ada Obi", "ada@example.com", "Password1!"); err != nil {
+		return err
+	}
+	signIn := func() (*Response, error) {
+		return h.Do(http.MethodPost, "/sign-in", map[string]string{
+			"email":    "ada@example.com",
+			"password": "not-the-password",
+		})
+	}
+	for i := 0; i < 5; i++ {
+		if _, err := signIn(); err != nil {
+			return err
+		}
+	}
+	resp, err := signIn()
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusTooManyRequests); err != nil {
+		return fmt.Errorf("account wasn't locked after 5 failures: %w", err)
+	}
+
+	var attempt Data.LoginAttempt
+	if err := h.DB.Where("attempt_key = ?", "email:ada@example.com").First(&attempt).Error; err != nil {
+		return err
+	}
+	if attempt.LockCount != 1 {
+		return fmt.Errorf("lock count %d, want 1", attempt.LockCount)
+	}
+	var queued int64
+	h.DB.Model(&Data.Job{}).Where("type = ?", "email.account_locked").Count(&queued)
+	if queued != 1 {
+		return fmt.Errorf("%d unlock emails queued, want 1", queued)
+	}
+
+	// the job would email this token, the harness sets it itself
+	hashed, err := dbFunc.DBHelper.CreatePasswordHash("unlock-token")
+	if err != nil {
+		return err
+	}
+	unlock := map[string]string{"email": "ada@example.com", "token": "unlock-token"}
+
+	expired := time.Now().Add(-time.Minute).Unix()
+	if err := dbFunc.DBHelper.SetLoginUnlockToken(attempt.AttemptKey, hashed, expired); err != nil {
+		return err
+	}
+	resp, err = h.Do(http.MethodPost, "/unlock-account", unlock)
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusUnauthorized); err != nil {
+		return fmt.Errorf("expired unlock link: %w", err)
+	}
+
+	if err := dbFunc.DBHelper.SetLoginUnlockToken(attempt.AttemptKey, hashed, time.Now().Add(time.Hour).Unix()); err != nil {
+		return err
+	}
+	resp, err = h.Do(http.MethodPost, "/unlock-account", unlock)
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+
+	// the account is cleared, the IP keeps counting so unlocking one's own
+	// account can't be used to keep spraying others
+	var left int64
+	h.DB.Model(&Data.LoginAttempt{}).Where("attempt_key = ?", attempt.AttemptKey).Count(&left)
+	if left != 0 {
+		return fmt.Errorf("account still has its login attempts after unlocking")
+	}
+	var ip Data.LoginAttempt
+	if err := h.DB.Where("attempt_key LIKE ?", "ip:%").First(&ip).Error; err != nil {
+		return fmt.Errorf("the IP's login attempts went with the unlock: %w", err)
+	}
+	if ip.Failures < 5 {
+		return fmt.Errorf("IP has %d failures after the unlock, want the 5 it made", ip.Failures)
+	}
+	return nil
+}
+
+func deletedAccountsArePurged(h *Harness) error {
+	user, err := h.CreateUser("Ada Obi", "ada@example.com", "Password1!")
+	if err != nil {
+		return err
+	}
+	if err := h.SignIn("ada@example.com", "Password1!"); err != nil {
+		return err
+	}
+	photo, err := testPNG()
+	if err != nil {
+		return err
+	}
+
+	resp, err := h.DoMultipart("/upload-profile-photo", nil, []File{{Field: "profile_photo", Filename: "me.png", Content: photo}})
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+	resp, err = h.DoMultipart("/publish-product", map[string]string{
+		"post_type":         "business",
+		"title":             "Aso oke",
+		"description":       "hand woven",
+		"whatsapp_url":      "https://wa.me/1",
+		"business_category": "fashion",
+	}, []File{{Field: "images", Filename: "aso-oke.png", Content: photo}})
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+	var post struct {
+		PostID uint `json:"post_id"`
+	}
+	if err := resp.JSON(&post); err != nil {
+		return err
+	}
+
+	// a click while signed in is the user's, one signed out only the device's
+	click := map[string]interface{}{
+		"fingerprint_hash": "device-1",
+		"activity_type":    "click",
+		"product_id":       post.PostID,
+	}
+	if resp, err = h.Do(http.MethodPost, "/dorng-user-analytics", click); err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+	h.ClearCookies()
+	if resp, err = h.Do(http.MethodPost, "/dorng-user-analytics", click); err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+	if err := h.SignIn("ada@example.com", "Password1!"); err != nil {
+		return err
+	}
+
+	resp, err = h.Do(http.MethodGet, "/profile/export?format=json", nil)
+	if err != nil {
+		return err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return err
+	}
+	var export dbFunc.UserDataExport
+	if err := resp.JSON(&export); err != nil {
+		return err
+	}
+	if len(export.Activity) != 1 || export.Activity[0].ProductID != post.PostID {
+		return fmt.Errorf("export has activity %+v, want the one signed in click", export.Activity)
+	}
+
+	// the product page is cached before the account goes
+	productPath := fmt.Sprintf("/product/%d", post.PostID)
+	for i := 0; i < 2; i++ {
+		if resp, err = h.Do(http.MethodGet, productPath, nil); err != nil {
+			return err
+		}
+		if err := resp.Expect(http.StatusOK); err != nil {
+			return err
+		}
+	}
+	if resp.Header.Get("X-Cache") != "HIT" {
+		return fmt.Errorf("product page wasn't cached")
+	}
+
+	// the grace period is over
+	deletion := Data.AccountDeletion{UserID: user.ID, Email: user.Email, ScheduledFor: time.Now().Add(-time.Minute).Unix()}
+	if err := dbFunc.DBHelper.SaveAccountDeletion(deletion); err != nil {
+		return err
+	}
+	if purged := profile.NewHandler(dbFunc.DBHelper).PurgeDueAccountDeletions(); purged != 1 {
+		return fmt.Errorf("purged %d accounts, want 1", purged)
+	}
+
+	var users, activity int64
+	h.DB.Model(&Data.User{}).Where("id = ?", user.ID).Count(&users)
+	h.DB.Model(&Data.BusinessConnectUserActivity{}).Count(&activity)
+	if users != 0 || activity != 1 {
+		return fmt.Errorf("%d users and %d activities left, want 0 and the signed out one", users, activity)
+	}
+	var released int64
+	h.DB.Model(&Data.MediaObject{}).Where("delete_after > 0").Count(&released)
+	if released != 2 {
+		return fmt.Errorf("%d images released, want the profile photo and the post image", released)
+	}
+
+	resp, err = h.Do(http.MethodGet, productPath, nil)
+	if err != nil {
+		return err
+	}
+	if resp.Status == http.StatusOK {
+		return fmt.Errorf("purged product is still served, X-Cache %q", resp.Header.Get("X-Cache"))
+	}
+	return nil
+}
+
+func staleJobClaimsAreRefused(h *Harness) error {
+	repo := dbFunc.DBHelper
+	job := Data.Job{Type: "email.welcome", Payload: `{}`, Status: dbFunc.JobPending, MaxAttempts: 5, RunAt: time.Now().Unix()}
+	if err := repo.CreateJob(&job); err != nil {
+		return err
+	}
+
+	// the first claim runs out at once, so the second worker takes it over
+	now := time.Now().Unix()
+	first, err := repo.ClaimJobs(now, 1, now-1, "worker-1")
+	if err != nil || len(first) != 1 {
+		return fmt.Errorf("first claim: %d jobs, %v", len(first), err)
+	}
+	second, err := repo.ClaimJobs(now, 1, now+60, "worker-2")
+	if err != nil || len(second) != 1 {
+		return fmt.Errorf("second claim: %d jobs, %v", len(second), err)
+	}
+
+	if err := repo.RetryJobLater(first[0], now+60, "too slow"); !errors.Is(err, dbFunc.ErrJobLeaseLost) {
+		return fmt.Errorf("stale worker's retry: %v, want the lease lost", err)
+	}
+	if err := repo.CompleteJob(second[0], "sent"); err != nil {
+		return err
+	}
+	if err := repo.KillJob(first[0], "too slow"); !errors.Is(err, dbFunc.ErrJobLeaseLost) {
+		return fmt.Errorf("stale worker's kill: %v, want the lease lost", err)
+	}
+
+	saved, err := repo.GetJob(job.ID)
+	if err != nil {
+		return err
+	}
+	if saved.Status != dbFunc.JobDone || saved.Result != "sent" {
+		return fmt.Errorf("job is %s with %q, want done with the second worker's result", saved.Status, saved.Result)
+	}
+	return nil
+}
+
+// socialSignIn goes through the mock provider's sign in as email and
+// returns where the API sent the browser afterwards
+func (h *Harness) socialSignIn(email string, verified bool) (string, error) {
+	return h.socialLogin("/auth/oidc/mock/start?login_hint="+url.QueryEscape(email), verified)
+}
+
+// socialLogin goes through the mock provider from start back to our
+// callback and returns where the callback sent the browser
+func (h *Harness) socialLogin(start string, verified bool) (string, error) {
+	resp, err := h.Do(http.MethodGet, start, nil)
+	if err != nil {
+		return "", err
+	}
+	if err := resp.Expect(http.StatusFound); err != nil {
+		return "", err
+	}
+
+	// the provider approves straight away and sends the browser back
+	authorize := resp.Header.Get("Location") + "&email_verified=" + strconv.FormatBool(verified)
+	browser := &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error {
+		return http.ErrUseLastResponse
+	}}
+	approved, err := browser.Get(authorize)
+	if err != nil {
+		return "", err
+	}
+	approved.Body.Close()
+	callback, err := url.Parse(approved.Header.Get("Location"))
+	if err != nil || callback.Path == "" {
+		return "", fmt.Errorf("provider didn't redirect back: %d %q", approved.StatusCode, approved.Header.Get("Location"))
+	}
+
+	resp, err = h.Do(http.MethodGet, callback.RequestURI(), nil)
+	if err != nil {
+		return "", err
+	}
+	if err := resp.Expect(http.StatusFound); err != nil {
+		return "", err
+	}
+	return resp.Header.Get("Location"), nil
+}
+
+// tokenKid is the kid header of the auth token the harness holds
+func (h *Harness) tokenKid() (string, error) {
+	token := h.cookies["__BusinessConnect-Auth-Token"]
+	header, _, _ := strings.Cut(token, ".")
+	raw, err := base64.RawURLEncoding.DecodeString(header)
+	if err != nil {
+		return "", fmt.Errorf("auth token %q isn't a JWT: %w", token, err)
+	}
+	var decoded struct {
+		Kid string `json:"kid"`
+	}
+	if err := json.Unmarshal(raw, &decoded); err != nil {
+		return "", err
+	}
+	return decoded.Kid, nil
+}
+
+// jwksKids are the key ids /.well-known/jwks.json publishes, sorted
+func (h *Harness) jwksKids() ([]string, error) {
+	resp, err := h.Do(http.MethodGet, "/.well-known/jwks.json", nil)
+	if err != nil {
+		return nil, err
+	}
+	if err := resp.Expect(http.StatusOK); err != nil {
+		return nil, err
+	}
+	var jwks struct {
+		Keys []struct {
+			Kid string `json:"kid"`
+		} `json:"keys"`
+	}
+	if err := resp.JSON(&jwks); err != nil {
+		return nil, err
+	}
+	var kids []string
+	for _, key := range jwks.Keys {
+		kids = append(kids, key.Kid)
+	}
+	return sortedStrings(kids...), nil
+}
+
+func sortedStrings(values ...string) []string {
+	sort.Strings(values)
+	return values
+}
+
+// testPNG is a small photo-sized image, real enough for any check on
+// uploaded image content
+func testPNG() ([]byte, error) {
+	img := image.NewRGBA(image.Rect(0, 0, 64, 48))
+	for x := 0; x < 64; x++ {
+		for y := 0; y < 48; y++ {
+			img.Set(x, y, color.RGBA{R: uint8(x * 4), G: uint8(y * 5), B: 120, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// testMP4 is the smallest MP4 Probe accepts: the header boxes of one video
+// track of the given length and size, and no frames
+func testMP4(duration time.Duration, width, height int) []byte {
+	box := func(kind string, payload ...[]byte) []byte {
+		body := bytes.Join(payload, nil)
+		out := binary.BigEndian.AppendUint32(nil, uint32(8+len(body)))
+		return append(append(out, kind...), body...)
+	}
+	u32 := func(v uint32) []byte { return binary.BigEndian.AppendUint32(nil, v) }
+	identity := bytes.Join([][]byte{
+		u32(0x10000), u32(0), u32(0),
+		u32(0), u32(0x10000), u32(0),
+		u32(0), u32(0), u32(0x40000000),
+	}, nil)
+	ms := uint32(duration.Milliseconds())
+
+	mvhd := box("mvhd", u32(0), u32(0), u32(0), u32(1000), u32(ms),
+		u32(0x10000), []byte{1, 0}, make([]byte, 10), identity, make([]byte, 24), u32(2))
+	tkhd := box("tkhd", u32(3), u32(0), u32(0), u32(1), u32(0), u32(ms),
+		make([]byte, 8), make([]byte, 8), identity, u32(uint32(width)<<16), u32(uint32(height)<<16))
+	hdlr := box("hdlr", u32(0), u32(0), []byte("vide"), make([]byte, 12), []byte{0})
+
+	return bytes.Join([][]byte{
+		box("ftyp", []byte("isom"), u32(0x200), []byte("isommp41")),
+		box("moov", mvhd, box("trak", tkhd, box("mdia", hdlr))),
+		box("mdat", make([]byte, 1024)),
+	}, nil)
+}
@@ -0,0 +1,79 @@
+package jobs
+
+import (
+	"net/http"
+	"strconv"
+
+	dbFunc "business-connect/database/dbHelpFunc"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ListJobs lists the jobs with one status, dead by default (admin only)
+func ListJobs(repo dbFunc.JobRepo) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		page := ctx.QueryInt("page", 1)
+		limit := ctx.QueryInt("limit", 20)
+
+		if page < 1 {
+			page = 1
+		}
+		if limit < 1 || limit > 50 {
+			limit = 20
+		}
+
+		status := ctx.Query("status", dbFunc.JobDead)
+		switch status {
+		case dbFunc.JobPending, dbFunc.JobRunning, dbFunc.JobDone, dbFunc.JobDead:
+		default:
+			return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"error": "status must be pending, running, done or dead",
+			})
+		}
+
+		offset := (page - 1) * limit
+
+		jobs, hasMore, err := repo.GetJobsByStatus(status, ctx.Query("type"), limit, offset)
+		if err != nil {
+			return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to fetch jobs",
+			})
+		}
+
+		return ctx.JSON(fiber.Map{
+			"page":    page,
+			"limit":   limit,
+			"jobs":    jobs,
+			"hasMore": hasMore,
+		})
+	}
+}
+
+// RetryJob puts a dead job back in the queue with a fresh set of attempts
+// (admin only)
+func RetryJob(repo dbFunc.JobRepo) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		jobID, err := strconv.ParseUint(ctx.Params("id"), 10, 64)
+		if err != nil {
+			return ctx.Status(http.StatusBadRequest).JSON(fiber.Map{
+				"error": "invalid job id",
+			})
+		}
+
+		if err := repo.RequeueDeadJob(uint(jobID)); err != nil {
+			if err.Error() == "job not found" {
+				return ctx.Status(http.StatusNotFound).JSON(fiber.Map{
+					"error": "no dead job with that id",
+				})
+			}
+			return ctx.Status(http.StatusInternalServerError).JSON(fiber.Map{
+				"error": "an error occurred",
+			})
+		}
+		wake()
+
+		return ctx.Status(http.StatusOK).JSON(fiber.Map{
+			"success": "job queued again",
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package jobs
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	dbFunc "business-connect/database/dbHelpFunc"
+	"business-connect/database/dbHelpFunc/mocks"
+	Data "business-connect/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestListJobsDefaultsToDeadJobs(t *testing.T) {
+	var gotStatus string
+	var gotLimit, gotOffset int
+	repo := &mocks.JobRepoMock{
+		GetJobsByStatusFunc: func(status string, jobType string, limit int, offset int) ([]Data.Job, bool, error) {
+			gotStatus, gotLimit, gotOffset = status, limit, offset
+			return nil, false, nil
+		},
+	}
+	app := fiber.New()
+	app.Get("/jobs", ListJobs(repo))
+
+	res, err := app.Test(httptest.NewRequest(http.MethodGet, "/jobs?page=3&limit=10", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusOK {
+		t.Fatalf("status %d, want 200", res.StatusCode)
+	}
+	if gotStatus != dbFunc.JobDead || gotLimit != 10 || gotOffset != 20 {
+		t.Errorf("status %q limit %d offset %d, want dead, 10 and 20", gotStatus, gotLimit, gotOffset)
+	}
+
+	res, err = app.Test(httptest.NewRequest(http.MethodGet, "/jobs?status=lost", nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.StatusCode != http.StatusBadRequest {
+		t.Errorf("unknown status: status %d, want 400", res.StatusCode)
+	}
+}
+
+func TestRetryJob(t *testing.T) {
+	repo := &mocks.JobRepoMock{
+		RequeueDeadJobFunc: func(jobID uint) error {
+			if jobID != 7 {
+				return errors.New("job not found")
+			}
+			return nil
+		},
+	}
+	app := fiber.New()
+	app.Post("/jobs/:id/retry", RetryJob(repo))
+
+	for path, want := range map[string]int{
+		"/jobs/7/retry":   http.StatusOK,
+		"/jobs/8/retry":   http.StatusNotFound,
+		"/jobs/one/retry": http.StatusBadRequest,
+	} {
+		res, err := app.Test(httptest.NewRequest(http.MethodPost, path, nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if res.StatusCode != want {
+			t.Errorf("%s: status %d, want %d", path, res.StatusCode, want)
+		}
+	}
+}
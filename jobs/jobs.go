@@ -0,0 +1,248 @@
+// Package jobs is a background job queue kept in the jobs table.
+//
+// A job type is declared once with Define, which ties a name to a payload
+// type and the function that runs it:
+//
+//	var welcomeJob = jobs.Define("email.welcome", jobs.Options{},
+//		func(ctx context.Context, p Welcome) error { return sendWelcome(p.Email) })
+//
+//	welcomeJob.Enqueue(Welcome{Email: email})
+//
+// Every process runs a small worker pool (see Start) that claims due jobs
+// from the table, so a job enqueued by one prefork child may run in another.
+// A job that fails is tried again with exponential backoff until it runs out
+// of attempts, then it is left as dead for an admin to look at and retry
+// (see ListJobs and RetryJob). A job cut off by a shutdown goes back in the
+// queue without using up an attempt, so a handler should return soon after
+// its ctx is cancelled.
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	dbFunc "business-connect/database/dbHelpFunc"
+	Data "business-connect/models"
+)
+
+// Options tune how a job type is run, zero values take the defaults
+type Options struct {
+	// attempts before the job is dead, 5 by default
+	MaxAttempts int64
+	// how long one attempt may take, a minute by default
+	Timeout time.Duration
+	// wait before the first retry, doubled for each later one, 30s by default
+	Backoff time.Duration
+	// longest wait between two attempts, an hour by default
+	MaxBackoff time.Duration
+}
+
+func (o Options) withDefaults() Options {
+	if o.MaxAttempts <= 0 {
+		o.MaxAttempts = 5
+	}
+	if o.Timeout <= 0 {
+		o.Timeout = time.Minute
+	}
+	if o.Backoff <= 0 {
+		o.Backoff = 30 * time.Second
+	}
+	if o.MaxBackoff <= 0 {
+		o.MaxBackoff = time.Hour
+	}
+	return o
+}
+
+// handler runs one job type on the raw payload and returns the raw result
+type handler struct {
+	opts Options
+	run  func(ctx context.Context, payload string) (string, error)
+}
+
+var (
+	registryMu sync.RWMutex
+	registry   = map[string]handler{}
+)
+
+func register(name string, h handler) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+
+	if _, exists := registry[name]; exists {
+		panic("jobs: job type " + name + " defined twice")
+	}
+	registry[name] = h
+}
+
+func lookup(name string) (handler, bool) {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	h, ok := registry[name]
+	return h, ok
+}
+
+// Kind is a job type whose payload is a P
+type Kind[P any] struct {
+	name string
+	opts Options
+}
+
+// Define declares the job type name, fn runs it. Call it from a package
+// level var so every process knows every type before its workers start.
+func Define[P any](name string, opts Options, fn func(ctx context.Context, payload P) error) *Kind[P] {
+	opts = opts.withDefaults()
+	register(name, handler{
+		opts: opts,
+		run: func(ctx context.Context, raw string) (string, error) {
+			var payload P
+			if err := json.Unmarshal([]byte(raw), &payload); err != nil {
+				return "", Permanent(fmt.Errorf("bad payload: %w", err))
+			}
+			return "", fn(ctx, payload)
+		},
+	})
+	return &Kind[P]{name: name, opts: opts}
+}
+
+// Name is the job type stored with each job
+func (k *Kind[P]) Name() string {
+	return k.name
+}
+
+// EnqueueOption changes when or how often a job is run
+type EnqueueOption func(job *Data.Job)
+
+// At runs the job no earlier than t
+func At(t time.Time) EnqueueOption {
+	return func(job *Data.Job) {
+		job.RunAt = t.Unix()
+	}
+}
+
+// After runs the job no earlier than d from now
+func After(d time.Duration) EnqueueOption {
+	return At(time.Now().Add(d))
+}
+
+// Enqueue saves a job to run payload as soon as a worker is free, or when
+// At or After say, and returns its id
+func (k *Kind[P]) Enqueue(payload P, opts ...EnqueueOption) (uint, error) {
+	raw, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("jobs: error encoding %s payload: %w", k.name, err)
+	}
+
+	job := Data.Job{
+		Type:        k.name,
+		Payload:     string(raw),
+		Status:      dbFunc.JobPending,
+		MaxAttempts: k.opts.MaxAttempts,
+		RunAt:       time.Now().Unix(),
+	}
+	for _, opt := range opts {
+		opt(&job)
+	}
+
+	if err := dbFunc.DBHelper.CreateJob(&job); err != nil {
+		return 0, err
+	}
+	if job.RunAt <= time.Now().Unix() {
+		wake()
+	}
+	return job.ID, nil
+}
+
+// ResultKind is a job type whose payload is a P and whose result is an R,
+// for work a request waits on such as an AI call
+type ResultKind[P, R any] struct {
+	*Kind[P]
+}
+
+// DefineWithResult is Define for a job whose result is kept, see Wait
+func DefineWithResult[P, R any](name string, opts Options, fn func(ctx context.Context, payload P) (R, error)) *ResultKind[P, R] {
+	opts = opts.withDefaults()
+	register(name, handler{
+		opts: opts,
+		run: func(ctx context.Context, raw string) (string, error) {
+			var payload P
+			if err := json.Unmarshal([]byte(raw), &payload); err != nil {
+				return "", Permanent(fmt.Errorf("bad payload: %w", err))
+			}
+			result, err := fn(ctx, payload)
+			if err != nil {
+				return "", err
+			}
+			encoded, err := json.Marshal(result)
+			if err != nil {
+				return "", Permanent(fmt.Errorf("bad result: %w", err))
+			}
+			return string(encoded), nil
+		},
+	})
+	return &ResultKind[P, R]{Kind: &Kind[P]{name: name, opts: opts}}
+}
+
+// how often Wait checks on a job
+const waitPoll = 200 * time.Millisecond
+
+// ErrJobDead is returned by Wait for a job that ran out of attempts
+var ErrJobDead = errors.New("job failed")
+
+// Wait blocks until job jobID is done and returns its result. It returns
+// ErrJobDead once the job has run out of attempts, or ctx's error when ctx
+// is done first, the job itself carries on either way.
+func (k *ResultKind[P, R]) Wait(ctx context.Context, jobID uint) (R, error) {
+	var result R
+
+	ticker := time.NewTicker(waitPoll)
+	defer ticker.Stop()
+
+	for {
+		job, err := dbFunc.DBHelper.GetJob(jobID)
+		if err != nil {
+			return result, err
+		}
+		switch job.Status {
+		case dbFunc.JobDone:
+			if err := json.Unmarshal([]byte(job.Result), &result); err != nil {
+				return result, fmt.Errorf("jobs: error decoding %s result: %w", k.name, err)
+			}
+			return result, nil
+		case dbFunc.JobDead:
+			return result, fmt.Errorf("%w: %s", ErrJobDead, job.LastError)
+		}
+
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return result, ctx.Err()
+		}
+	}
+}
+
+// permanentError is a failure retrying won't fix
+type permanentError struct {
+	err error
+}
+
+func (e *permanentError) Error() string { return e.err.Error() }
+func (e *permanentError) Unwrap() error { return e.err }
+
+// Permanent marks err as one retrying won't fix, such as a missing order, so
+// the job is dead straight away instead of using up its attempts
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+func isPermanent(err error) bool {
+	var permanent *permanentError
+	return errors.As(err, &permanent)
+}
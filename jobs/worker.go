@@ -0,0 +1,201 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/rand"
+	"os"
+	"runtime/debug"
+	"time"
+
+	config "business-connect/config"
+	dbFunc "business-connect/database/dbHelpFunc"
+	"business-connect/lifecycle"
+	"business-connect/metrics"
+	Data "business-connect/models"
+)
+
+// how long past its timeout a claimed job stays locked, after that another
+// process takes it for crashed
+const lockMargin = time.Minute
+
+// workerID tells this process's claims from those of every other process,
+// a job is only finished by the process that claimed it
+var workerID = func() string {
+	host, _ := os.Hostname()
+	return fmt.Sprintf("%s:%d:%08x", host, os.Getpid(), rand.Uint32())
+}()
+
+// wakeup tells this process's poller a job is due now, so a job enqueued
+// here doesn't wait for the next poll
+var wakeup = make(chan struct{}, 1)
+
+func wake() {
+	select {
+	case wakeup <- struct{}{}:
+	default:
+	}
+}
+
+// Start runs cfg.Workers job workers in this process until it shuts down
+func Start(cfg config.JobsConfig) error {
+	if cfg.Workers <= 0 {
+		return nil
+	}
+
+	queue := make(chan Data.Job)
+	// one token per idle worker, the poller never claims more jobs than
+	// there are workers to run them
+	idle := make(chan struct{}, cfg.Workers)
+
+	for i := 0; i < cfg.Workers; i++ {
+		idle <- struct{}{}
+		if err := lifecycle.Go("job worker", func(ctx context.Context) {
+			for {
+				select {
+				case job := <-queue:
+					run(ctx, job)
+					idle <- struct{}{}
+					wake()
+				case <-ctx.Done():
+					return
+				}
+			}
+		}); err != nil {
+			return err
+		}
+	}
+
+	return lifecycle.Go("job poller", func(ctx context.Context) {
+		ticker := time.NewTicker(cfg.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			if free := len(idle); free > 0 {
+				claimed, err := dbFunc.DBHelper.ClaimJobs(time.Now().Unix(), free, time.Now().Add(longestTimeout()+lockMargin).Unix(), workerID)
+				if err != nil {
+					slog.Error("error claiming jobs", "error", err)
+				}
+				for i, job := range claimed {
+					<-idle
+					select {
+					case queue <- job:
+					case <-ctx.Done():
+						// the workers have stopped, hand the rest back
+						for _, left := range claimed[i:] {
+							if err := dbFunc.DBHelper.ReleaseJob(left); err != nil {
+								slog.Error("error releasing job", "job_id", left.ID, "error", err)
+							}
+						}
+						return
+					}
+				}
+				// a full batch means there may be more waiting
+				if len(claimed) == free {
+					continue
+				}
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-wakeup:
+			case <-ticker.C:
+			}
+		}
+	})
+}
+
+// longestTimeout is the lock every claim takes, a claim can be of any type
+func longestTimeout() time.Duration {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+
+	longest := time.Duration(0)
+	for _, h := range registry {
+		if h.opts.Timeout > longest {
+			longest = h.opts.Timeout
+		}
+	}
+	return longest
+}
+
+// run runs one claimed job and records how it went
+func run(ctx context.Context, job Data.Job) {
+	log := slog.With("job_id", job.ID, "job_type", job.Type, "attempt", job.Attempts)
+	start := time.Now()
+
+	h, ok := lookup(job.Type)
+	if !ok {
+		log.Error("unknown job type")
+		if err := dbFunc.DBHelper.KillJob(job, "unknown job type"); err != nil {
+			log.Error("error saving job", "error", err)
+		}
+		metrics.JobRun(job.Type, "dead", time.Since(start))
+		return
+	}
+
+	jobCtx, cancel := context.WithTimeout(ctx, h.opts.Timeout)
+	result, err := safeRun(jobCtx, h, job.Payload)
+	cancel()
+
+	var outcome string
+	var saveErr error
+	switch {
+	case err == nil:
+		outcome = "done"
+		saveErr = dbFunc.DBHelper.CompleteJob(job, result)
+	case ctx.Err() != nil:
+		// cut off by a shutdown, not the job's fault
+		outcome = "released"
+		log.Info("job interrupted by shutdown, requeued")
+		saveErr = dbFunc.DBHelper.ReleaseJob(job)
+	case isPermanent(err) || job.Attempts >= job.MaxAttempts:
+		outcome = "dead"
+		log.Error("job failed for good", "error", err)
+		saveErr = dbFunc.DBHelper.KillJob(job, err.Error())
+	default:
+		outcome = "retry"
+		delay := backoff(h.opts, job.Attempts)
+		log.Warn("job failed, will retry", "error", err, "retry_in", delay.String())
+		saveErr = dbFunc.DBHelper.RetryJobLater(job, time.Now().Add(delay).Unix(), err.Error())
+	}
+	switch {
+	case errors.Is(saveErr, dbFunc.ErrJobLeaseLost):
+		// it ran past its lock and another worker has it now, that one
+		// records how it went
+		log.Warn("job outcome not saved, its claim ran out", "outcome", outcome)
+	case saveErr != nil:
+		// the lock runs out and the job is tried again
+		log.Error("error saving job", "error", saveErr)
+	}
+	metrics.JobRun(job.Type, outcome, time.Since(start))
+}
+
+// safeRun turns a panic in a job into an error so the worker survives it
+func safeRun(ctx context.Context, h handler, payload string) (result string, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			slog.Error("job panicked", "panic", fmt.Sprint(r), "stack", string(debug.Stack()))
+			err = Permanent(fmt.Errorf("panic: %v", r))
+		}
+	}()
+	return h.run(ctx, payload)
+}
+
+// backoff is the wait after the given failed attempt: Backoff doubled for
+// every attempt before it, capped at MaxBackoff, give or take a fifth so
+// jobs that failed together don't all retry together
+func backoff(opts Options, attempt int64) time.Duration {
+	delay := opts.Backoff
+	for i := int64(1); i < attempt && delay < opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > opts.MaxBackoff {
+		delay = opts.MaxBackoff
+	}
+	jitter := time.Duration(rand.Int63n(int64(delay)/5+1)) - delay/10
+	return delay + jitter
+}
@@ -0,0 +1,193 @@
+// Package lifecycle tracks the background work of a process so a shutdown
+// can wait for it.
+//
+// Workers are started with Go instead of a bare go statement. They get a
+// context that is cancelled when the process starts shutting down, and are
+// expected to return soon after, saving where they got to if they can't
+// finish. Shutdown cancels that context, waits for the workers until its own
+// deadline, then runs the OnShutdown hooks (closing the database and so on)
+// in the reverse order they were added.
+package lifecycle
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+	"sort"
+	"sync"
+	"time"
+)
+
+// ErrShuttingDown is returned by Go once Shutdown has started
+var ErrShuttingDown = errors.New("shutting down")
+
+// Manager tracks the workers and shutdown hooks of one process
+type Manager struct {
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	mu      sync.Mutex
+	wg      sync.WaitGroup
+	running map[string]int
+	hooks   []hook
+	closed  bool
+}
+
+type hook struct {
+	name string
+	fn   func(ctx context.Context) error
+}
+
+// NewManager returns a Manager with no workers
+func NewManager() *Manager {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Manager{
+		ctx:     ctx,
+		cancel:  cancel,
+		running: map[string]int{},
+	}
+}
+
+// Go runs fn in a tracked goroutine. ctx is cancelled when shutdown starts,
+// a panic in fn is logged instead of taking the process down.
+func (m *Manager) Go(name string, fn func(ctx context.Context)) error {
+	m.mu.Lock()
+	if m.closed {
+		m.mu.Unlock()
+		return ErrShuttingDown
+	}
+	m.wg.Add(1)
+	m.running[name]++
+	m.mu.Unlock()
+
+	go func() {
+		defer func() {
+			if r := recover(); r != nil {
+				slog.Error("background worker panicked",
+					"worker", name,
+					"panic", fmt.Sprint(r),
+					"stack", string(debug.Stack()),
+				)
+			}
+
+			m.mu.Lock()
+			if m.running[name]--; m.running[name] == 0 {
+				delete(m.running, name)
+			}
+			m.mu.Unlock()
+			m.wg.Done()
+		}()
+		fn(m.ctx)
+	}()
+	return nil
+}
+
+// OnShutdown adds a hook run by Shutdown after the workers have stopped,
+// hooks run last added first so a resource opened early is closed late
+func (m *Manager) OnShutdown(name string, fn func(ctx context.Context) error) {
+	m.mu.Lock()
+	m.hooks = append(m.hooks, hook{name: name, fn: fn})
+	m.mu.Unlock()
+}
+
+// Context is cancelled when shutdown starts
+func (m *Manager) Context() context.Context {
+	return m.ctx
+}
+
+// ShuttingDown reports whether Shutdown has been called
+func (m *Manager) ShuttingDown() bool {
+	return m.ctx.Err() != nil
+}
+
+// Shutdown stops new workers, cancels the running ones and waits for them
+// until ctx is done, then runs the hooks. The workers still running at the
+// deadline are logged and reported in the error, the hooks run anyway.
+func (m *Manager) Shutdown(ctx context.Context) error {
+	m.mu.Lock()
+	m.closed = true
+	hooks := m.hooks
+	m.hooks = nil
+	m.mu.Unlock()
+
+	m.cancel()
+
+	var errs []error
+
+	done := make(chan struct{})
+	go func() {
+		m.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-ctx.Done():
+		left := m.stillRunning()
+		slog.Error("background workers didn't stop in time", "workers", left)
+		errs = append(errs, fmt.Errorf("workers still running: %v", left))
+	}
+
+	for i := len(hooks) - 1; i >= 0; i-- {
+		if err := hooks[i].fn(ctx); err != nil {
+			slog.Error("shutdown hook failed", "hook", hooks[i].name, "error", err)
+			errs = append(errs, fmt.Errorf("%s: %w", hooks[i].name, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (m *Manager) stillRunning() []string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	names := make([]string, 0, len(m.running))
+	for name := range m.running {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// the process wide manager the package functions use
+var std = NewManager()
+
+// Go runs fn in a goroutine tracked by the process wide manager
+func Go(name string, fn func(ctx context.Context)) error {
+	return std.Go(name, fn)
+}
+
+// OnShutdown adds a hook to the process wide manager
+func OnShutdown(name string, fn func(ctx context.Context) error) {
+	std.OnShutdown(name, fn)
+}
+
+// Context is cancelled when the process starts shutting down
+func Context() context.Context {
+	return std.Context()
+}
+
+// ShuttingDown reports whether the process is shutting down
+func ShuttingDown() bool {
+	return std.ShuttingDown()
+}
+
+// Shutdown shuts the process wide manager down, see Manager.Shutdown
+func Shutdown(ctx context.Context) error {
+	return std.Shutdown(ctx)
+}
+
+// Sleep waits for d or until ctx is done, whichever comes first, and
+// reports whether the full wait happened
+func Sleep(ctx context.Context, d time.Duration) bool {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
@@ -0,0 +1,85 @@
+// Package logger sets up the structured logger every package writes to.
+//
+// Setup makes it the slog default, so slog.Info and friends and the standard
+// log package both end up as leveled, structured records. Every record goes
+// through a redacting handler first, see redact.go, so a secret or a
+// customer's email that reaches a log call doesn't reach the log drain.
+//
+// Handlers log through Ctx(c) to get the request id and signed in user on
+// every line.
+package logger
+
+import (
+	"io"
+	"log/slog"
+	"os"
+
+	config "business-connect/config"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// RequestIDHeader carries the request id in and out of the API
+	RequestIDHeader = "X-Request-ID"
+	// RequestIDLocal is where the request id middleware keeps the id
+	RequestIDLocal = "request-id"
+)
+
+// Setup makes a logger built from cfg the default for slog and the standard
+// log package, and returns it
+func Setup(cfg config.LogConfig) *slog.Logger {
+	l := New(os.Stdout, cfg)
+	// this also routes the log package through l at info level, so the
+	// remaining log.Println calls come out as structured, redacted records
+	slog.SetDefault(l)
+	return l
+}
+
+// New builds a redacting logger writing to w
+func New(w io.Writer, cfg config.LogConfig) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: ParseLevel(cfg.Level)}
+
+	var h slog.Handler
+	if cfg.Format == "text" {
+		h = slog.NewTextHandler(w, opts)
+	} else {
+		h = slog.NewJSONHandler(w, opts)
+	}
+	return slog.New(NewRedactHandler(h))
+}
+
+// ParseLevel turns debug, info, warn or error into a level, anything else is
+// info
+func ParseLevel(level string) slog.Level {
+	switch level {
+	case "debug":
+		return slog.LevelDebug
+	case "warn":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// RequestID returns the id the request id middleware gave c, or "" outside a
+// request
+func RequestID(c *fiber.Ctx) string {
+	id, _ := c.Locals(RequestIDLocal).(string)
+	return id
+}
+
+// Ctx returns the default logger with the request id and, once the auth
+// middleware has run, the signed in user's id
+func Ctx(c *fiber.Ctx) *slog.Logger {
+	l := slog.Default()
+	if id := RequestID(c); id != "" {
+		l = l.With("request_id", id)
+	}
+	if userID, ok := c.Locals("user-id").(uint); ok {
+		l = l.With("user_id", userID)
+	}
+	return l
+}
@@ -0,0 +1,151 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// an attribute whose key contains one of these is never logged. A bare
+// "key" is a storage or cache key, only named kinds of key are secret.
+var secretKeyParts = []string{
+	"password", "secret", "token", "authorization", "cookie", "api_key",
+	"apikey", "api-key", "access_key", "accesskey", "signing_key",
+	"encryption_key", "signature", "private", "csrf", "jwt", "otp", "cvv",
+}
+
+// short keys only matched whole, "pin" is also in "shipping"
+var secretKeys = map[string]bool{"pin": true, "code": true}
+
+var (
+	emailPattern    = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	bearerPattern   = regexp.MustCompile(`(?i)bearer\s+[A-Za-z0-9\-._~+/]+=*`)
+	jwtPattern      = regexp.MustCompile(`eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+\.[A-Za-z0-9_-]*`)
+	paystackPattern = regexp.MustCompile(`\b[sp]k_(?:live|test)_[A-Za-z0-9]+`)
+	// Nigerian numbers, 08012345678 or +2348012345678
+	phonePattern = regexp.MustCompile(`\+?\b(?:234|0)[789][01]\d{8}\b`)
+)
+
+type redactHandler struct {
+	next slog.Handler
+}
+
+// NewRedactHandler wraps next so secrets never reach it and emails and phone
+// numbers only reach it masked. Attributes are matched by key (password,
+// token, otp, email, phone...), messages and string values are scrubbed of
+// anything that looks like an email, phone number, JWT, bearer token or
+// Paystack key.
+func NewRedactHandler(next slog.Handler) slog.Handler {
+	return &redactHandler{next: next}
+}
+
+func (h *redactHandler) Enabled(ctx context.Context, level slog.Level) bool {
+	return h.next.Enabled(ctx, level)
+}
+
+func (h *redactHandler) Handle(ctx context.Context, r slog.Record) error {
+	out := slog.NewRecord(r.Time, r.Level, Scrub(r.Message), r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		out.AddAttrs(redactAttr(a))
+		return true
+	})
+	return h.next.Handle(ctx, out)
+}
+
+func (h *redactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clean := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		clean[i] = redactAttr(a)
+	}
+	return &redactHandler{next: h.next.WithAttrs(clean)}
+}
+
+func (h *redactHandler) WithGroup(name string) slog.Handler {
+	return &redactHandler{next: h.next.WithGroup(name)}
+}
+
+func redactAttr(a slog.Attr) slog.Attr {
+	a.Value = a.Value.Resolve()
+	key := strings.ToLower(a.Key)
+
+	if a.Value.Kind() == slog.KindGroup {
+		group := a.Value.Group()
+		clean := make([]slog.Attr, len(group))
+		for i, ga := range group {
+			clean[i] = redactAttr(ga)
+		}
+		return slog.Attr{Key: a.Key, Value: slog.GroupValue(clean...)}
+	}
+
+	switch {
+	case isSecretKey(key):
+		return slog.String(a.Key, redacted)
+	case strings.Contains(key, "email"):
+		return slog.String(a.Key, MaskEmail(a.Value.String()))
+	case strings.Contains(key, "phone"):
+		return slog.String(a.Key, MaskPhone(a.Value.String()))
+	}
+
+	switch a.Value.Kind() {
+	case slog.KindString:
+		return slog.String(a.Key, Scrub(a.Value.String()))
+	case slog.KindAny:
+		// structs and maps can hold anything, they are logged as scrubbed text
+		if err, ok := a.Value.Any().(error); ok {
+			return slog.String(a.Key, Scrub(err.Error()))
+		}
+		return slog.String(a.Key, Scrub(fmt.Sprintf("%+v", a.Value.Any())))
+	}
+	return a
+}
+
+func isSecretKey(key string) bool {
+	if secretKeys[key] {
+		return true
+	}
+	for _, part := range secretKeyParts {
+		if strings.Contains(key, part) {
+			return true
+		}
+	}
+	return false
+}
+
+// Scrub masks the emails and phone numbers in s and removes JWTs, bearer
+// tokens and Paystack keys
+func Scrub(s string) string {
+	s = bearerPattern.ReplaceAllString(s, "Bearer "+redacted)
+	s = jwtPattern.ReplaceAllString(s, redacted)
+	s = paystackPattern.ReplaceAllString(s, redacted)
+	s = emailPattern.ReplaceAllStringFunc(s, MaskEmail)
+	s = phonePattern.ReplaceAllStringFunc(s, MaskPhone)
+	return s
+}
+
+// MaskEmail keeps the first letter and the domain, jane@example.com becomes
+// j***@example.com
+func MaskEmail(email string) string {
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		if email == "" {
+			return ""
+		}
+		return redacted
+	}
+	return email[:1] + "***" + email[at:]
+}
+
+// MaskPhone keeps the last four digits
+func MaskPhone(phone string) string {
+	if len(phone) <= 4 {
+		if phone == "" {
+			return ""
+		}
+		return redacted
+	}
+	return strings.Repeat("*", len(phone)-4) + phone[len(phone)-4:]
+}
@@ -1,9 +1,21 @@
 package main
 
 import (
+	"log"
+	"os"
+
+	commands "business-connect/commands"
 	server "business-connect/server"
 )
 
 func main() {
+	// anything after the program name is a maintenance command, not the server
+	if len(os.Args) > 1 {
+		if err := commands.Run(os.Args[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
 	server.StartServer()
 }
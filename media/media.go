@@ -0,0 +1,228 @@
+// Package media serves stored objects from the API, by the key the models
+// keep.
+//
+// Serve reads the key through the storage backend, so it works the same on
+// B2, S3 and local disk and in front of a private bucket. Public media,
+// post, blog, profile and email images, is served to anyone and cached for
+// good, every upload gets a key of its own and is never overwritten.
+// Private media lives in a folder per user under the private folder and is
+// only served with a signature from SignedURL, which Sign hands the owner.
+// Nothing else is served, direct uploads in particular haven't been checked
+// or stripped of their metadata yet.
+//
+// An image asked for with ?w= is served from the stored variant of that
+// width, and in WebP when the client accepts it. Images stored before
+// there were variants are resized on the fly instead. Videos and
+// documents answer Range requests so players can seek.
+package media
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	config "business-connect/config"
+	dbFunc "business-connect/database/dbHelpFunc"
+	"business-connect/imaging"
+	"business-connect/logger"
+	"business-connect/storage"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// public media never changes under its key
+const publicCacheControl = "public, max-age=31536000, immutable"
+
+// Serve serves the object at the key after /media/, cfg has the private
+// folder and the key its links are signed with
+func Serve(cfg config.StorageConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key, err := url.PathUnescape(c.Params("*"))
+		if err != nil || storage.ValidKey(key) != nil {
+			return c.SendStatus(fiber.StatusNotFound)
+		}
+
+		cacheControl := publicCacheControl
+		if _, isPrivate := private(cfg, key); isPrivate {
+			expiresAt, ok := verify(cfg, key, c.Query("expires"), c.Query("signature"))
+			if !ok {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "link expired or invalid"})
+			}
+			// no shared cache keeps it, the browser only until the link expires
+			cacheControl = fmt.Sprintf("private, max-age=%d", max(expiresAt-time.Now().Unix(), 0))
+		} else if !public(cfg, key) {
+			return c.SendStatus(fiber.StatusNotFound)
+		}
+
+		width := c.QueryInt("w", 0)
+		if width < 0 {
+			width = 0
+		}
+		webp := acceptsWebP(c.Get(fiber.HeaderAccept))
+
+		store := storage.Current()
+		keys, negotiated := candidates(key, width, webp)
+		obj, served, err := open(c.UserContext(), store, keys)
+		if err != nil {
+			if errors.Is(err, storage.ErrNotFound) {
+				return c.SendStatus(fiber.StatusNotFound)
+			}
+			logger.Ctx(c).Error("error reading media", "key", key, "error", err)
+			return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": "media unavailable"})
+		}
+
+		contentType := obj.ContentType
+		if contentType == "" || contentType == "application/octet-stream" {
+			if byExt := mime.TypeByExtension(filepath.Ext(served)); byExt != "" {
+				contentType = byExt
+			}
+		}
+
+		c.Set(fiber.HeaderCacheControl, cacheControl)
+		if negotiated {
+			c.Set(fiber.HeaderVary, fiber.HeaderAccept)
+		}
+
+		// an image from before there were variants is resized to the width
+		// asked for, those are few and the CDN keeps the result
+		if width > 0 && served == key && !variantKey.MatchString(key) && strings.HasPrefix(contentType, "image/") {
+			c.Set(fiber.HeaderVary, fiber.HeaderAccept)
+			etag := etag(served, obj, strconv.Itoa(width), strconv.FormatBool(webp))
+			if match(c, etag) {
+				obj.Body.Close()
+				return c.SendStatus(fiber.StatusNotModified)
+			}
+			data, resizedType, err := resize(c.UserContext(), obj, width, webp && imaging.WebPSupported)
+			if err != nil {
+				logger.Ctx(c).Warn("error resizing media", "key", key, "error", err)
+				return c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{"error": "image can't be resized"})
+			}
+			c.Set(fiber.HeaderETag, etag)
+			c.Set(fiber.HeaderContentType, resizedType)
+			return c.Send(data)
+		}
+
+		etag := etag(served, obj)
+		if match(c, etag) {
+			obj.Body.Close()
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+		c.Set(fiber.HeaderETag, etag)
+		c.Set(fiber.HeaderContentType, contentType)
+		c.Set(fiber.HeaderAcceptRanges, "bytes")
+		if !obj.ModTime.IsZero() {
+			c.Set(fiber.HeaderLastModified, obj.ModTime.UTC().Format(http.TimeFormat))
+		}
+
+		rangeHeader := c.Get(fiber.HeaderRange)
+		if ifRange := c.Get(fiber.HeaderIfRange); ifRange != "" && ifRange != etag {
+			// the object changed since the client got its first part
+			rangeHeader = ""
+		}
+		start, length, err := parseRange(rangeHeader, obj.Size)
+		switch {
+		case errors.Is(err, errUnsatisfiable):
+			obj.Body.Close()
+			c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes */%d", obj.Size))
+			return c.SendStatus(fiber.StatusRequestedRangeNotSatisfiable)
+		case err != nil:
+			// fasthttp closes the body once it is sent
+			return c.SendStream(obj.Body, int(obj.Size))
+		}
+
+		// the range is read on its own, a seek into a long video doesn't
+		// download everything before it
+		obj.Body.Close()
+		body, err := store.GetRange(c.UserContext(), served, start, length)
+		if err != nil {
+			logger.Ctx(c).Error("error reading media range", "key", served, "error", err)
+			return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{"error": "media unavailable"})
+		}
+		c.Set(fiber.HeaderContentRange, fmt.Sprintf("bytes %d-%d/%d", start, start+length-1, obj.Size))
+		c.Status(fiber.StatusPartialContent)
+		return c.SendStream(body, int(length))
+	}
+}
+
+// public reports whether key is in one of the folders served to anyone
+func public(cfg config.StorageConfig, key string) bool {
+	for _, folder := range []string{cfg.PostFolder, cfg.BlogFolder, cfg.ProfileFolder, cfg.EmailFolder} {
+		if folder != "" && strings.HasPrefix(key, folder) {
+			return true
+		}
+	}
+	return false
+}
+
+// SignRequest is the body of /media/sign, ExpiresIn is in seconds
+type SignRequest struct {
+	Key       string `json:"key"`
+	ExpiresIn int64  `json:"expires_in"`
+}
+
+// Sign hands the owner of a private object, or an admin, a URL it can be
+// served from for a while. A public object's URL needs no signature.
+func Sign(cfg config.StorageConfig, users dbFunc.UserRepo) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID := c.Locals("user-id")
+		if userID == nil {
+			return c.Status(401).JSON(fiber.Map{"error": "unauthorized"})
+		}
+		user, err := users.FindByUuidFromLocal(userID)
+		if err != nil {
+			return c.Status(404).JSON(fiber.Map{"error": "user not found"})
+		}
+
+		var req SignRequest
+		if err := c.BodyParser(&req); err != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid request body"})
+		}
+		if storage.ValidKey(req.Key) != nil {
+			return c.Status(400).JSON(fiber.Map{"error": "invalid key"})
+		}
+
+		owner, isPrivate := private(cfg, req.Key)
+		if !isPrivate {
+			return c.JSON(fiber.Map{"url": Path(req.Key)})
+		}
+		if owner != user.ID && user.UserType != "ADMIN" {
+			return c.Status(403).JSON(fiber.Map{"error": "you can only share your own files"})
+		}
+
+		expires := defaultSignedExpiry
+		if req.ExpiresIn > 0 {
+			expires = min(time.Duration(req.ExpiresIn)*time.Second, maxSignedExpiry)
+		}
+		signedURL, expiresAt := SignedURL(cfg, req.Key, expires)
+		return c.JSON(fiber.Map{
+			"url":        signedURL,
+			"expires_at": expiresAt,
+		})
+	}
+}
+
+// etag identifies what is served for key, parts add whatever else the
+// response depends on
+func etag(key string, obj *storage.Object, parts ...string) string {
+	sum := sha256.Sum256([]byte(strings.Join(append([]string{key, strconv.FormatInt(obj.Size, 10), strconv.FormatInt(obj.ModTime.Unix(), 10)}, parts...), "\n")))
+	return `"` + hex.EncodeToString(sum[:12]) + `"`
+}
+
+// match reports whether the client already has etag
+func match(c *fiber.Ctx, etag string) bool {
+	for _, candidate := range strings.Split(c.Get(fiber.HeaderIfNoneMatch), ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,62 @@
+package media
+
+import (
+	"errors"
+	"strconv"
+	"strings"
+)
+
+var (
+	// errNoRange means the whole object is served, there is no Range or it
+	// asks for several ranges, which a server may answer in full
+	errNoRange = errors.New("no single range")
+	// errUnsatisfiable means the range starts past the end, a 416
+	errUnsatisfiable = errors.New("range not satisfiable")
+)
+
+// parseRange reads a Range header of one bytes range of an object of size
+// bytes and returns where it starts and how long it is
+func parseRange(header string, size int64) (int64, int64, error) {
+	spec, ok := strings.CutPrefix(header, "bytes=")
+	if !ok || strings.Contains(spec, ",") {
+		return 0, 0, errNoRange
+	}
+	first, last, ok := strings.Cut(strings.TrimSpace(spec), "-")
+	if !ok {
+		return 0, 0, errNoRange
+	}
+
+	if first == "" {
+		// the last n bytes
+		n, err := strconv.ParseInt(last, 10, 64)
+		if err != nil || n <= 0 {
+			return 0, 0, errNoRange
+		}
+		if n > size {
+			n = size
+		}
+		if n == 0 {
+			return 0, 0, errUnsatisfiable
+		}
+		return size - n, n, nil
+	}
+
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil || start < 0 {
+		return 0, 0, errNoRange
+	}
+	if start >= size {
+		return 0, 0, errUnsatisfiable
+	}
+	end := size - 1
+	if last != "" {
+		end, err = strconv.ParseInt(last, 10, 64)
+		if err != nil || end < start {
+			return 0, 0, errNoRange
+		}
+		if end > size-1 {
+			end = size - 1
+		}
+	}
+	return start, end - start + 1, nil
+}
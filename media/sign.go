@@ -0,0 +1,77 @@
+package media
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	config "business-connect/config"
+)
+
+const (
+	// how long a signed URL lasts unless asked otherwise, and the longest
+	// one that is handed out
+	defaultSignedExpiry = 15 * time.Minute
+	maxSignedExpiry     = 24 * time.Hour
+)
+
+// Path is where Serve serves key, relative to the API
+func Path(key string) string {
+	parts := strings.Split(key, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return "/media/" + strings.Join(parts, "/")
+}
+
+// SignedURL is the path Serve serves key from until expires has passed,
+// what a private object needs to be served at all
+func SignedURL(cfg config.StorageConfig, key string, expires time.Duration) (string, int64) {
+	expiresAt := time.Now().Add(expires).Unix()
+	query := url.Values{}
+	query.Set("expires", strconv.FormatInt(expiresAt, 10))
+	query.Set("signature", sign(cfg, key, expiresAt))
+	return Path(key) + "?" + query.Encode(), expiresAt
+}
+
+func sign(cfg config.StorageConfig, key string, expiresAt int64) string {
+	mac := hmac.New(sha256.New, []byte(cfg.MediaSigningKey))
+	mac.Write([]byte(key + "\n" + strconv.FormatInt(expiresAt, 10)))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verify checks a signature made by SignedURL and returns when it expires,
+// ok is false once it has
+func verify(cfg config.StorageConfig, key, expires, signature string) (int64, bool) {
+	expiresAt, err := strconv.ParseInt(expires, 10, 64)
+	if err != nil || time.Now().Unix() > expiresAt {
+		return 0, false
+	}
+	return expiresAt, hmac.Equal([]byte(sign(cfg, key, expiresAt)), []byte(signature))
+}
+
+// PrivateKey is the key of a private object of userID, such as a KYC
+// document or a message attachment. name is the rest of the key inside
+// the user's folder.
+func PrivateKey(cfg config.StorageConfig, userID uint, name string) string {
+	return cfg.PrivateFolder + strconv.FormatUint(uint64(userID), 10) + "/" + name
+}
+
+// private reports whether key is private media and whose it is
+func private(cfg config.StorageConfig, key string) (uint, bool) {
+	folder := cfg.PrivateFolder
+	if folder == "" || !strings.HasPrefix(key, folder) {
+		return 0, false
+	}
+	owner, _, _ := strings.Cut(strings.TrimPrefix(key, folder), "/")
+	userID, err := strconv.ParseUint(owner, 10, 64)
+	if err != nil {
+		// private all the same, only an admin can share it
+		return 0, true
+	}
+	return uint(userID), true
+}
@@ -0,0 +1,90 @@
+package media
+
+import (
+	"context"
+	"errors"
+	"regexp"
+	"strings"
+
+	"business-connect/imaging"
+	"business-connect/storage"
+)
+
+// variantKey splits the key of a stored image variant into the key every
+// variant of the image shares, its size and its format
+var variantKey = regexp.MustCompile(`^(.+)_(full|feed|thumb)\.(jpg|webp)$`)
+
+// most images resized at once, an image from before there were variants
+// is decoded whole to serve a smaller size
+var resizing = make(chan struct{}, 4)
+
+// sizeFor is the smallest variant at least width across, or full
+func sizeFor(width int) imaging.Size {
+	for i := len(imaging.Sizes) - 1; i >= 0; i-- {
+		if imaging.Sizes[i].MaxEdge >= width {
+			return imaging.Sizes[i]
+		}
+	}
+	return imaging.Sizes[0]
+}
+
+// candidates are the keys to serve a request for key from, best first: the
+// variant of the width asked for, in WebP when the client takes it, then
+// the key itself. negotiated is true when the format went by the Accept
+// header.
+func candidates(key string, width int, webp bool) (keys []string, negotiated bool) {
+	m := variantKey.FindStringSubmatch(key)
+	if m == nil {
+		return []string{key}, false
+	}
+	base, size, ext := m[1], m[2], m[3]
+	if width > 0 {
+		size = sizeFor(width).Name
+	}
+
+	negotiated = ext == "jpg"
+	if webp || ext == "webp" {
+		keys = append(keys, base+"_"+size+".webp")
+	}
+	keys = append(keys, base+"_"+size+".jpg")
+	if keys[len(keys)-1] != key && keys[0] != key {
+		keys = append(keys, key)
+	}
+	return keys, negotiated
+}
+
+// open opens the first of keys that is stored
+func open(ctx context.Context, store storage.Storage, keys []string) (*storage.Object, string, error) {
+	for _, key := range keys {
+		obj, err := store.Get(ctx, key)
+		if errors.Is(err, storage.ErrNotFound) {
+			continue
+		}
+		return obj, key, err
+	}
+	return nil, "", storage.ErrNotFound
+}
+
+// resize makes the variant of an image stored before there were variants,
+// from the object itself, in the size width needs
+func resize(ctx context.Context, obj *storage.Object, width int, webp bool) ([]byte, string, error) {
+	defer obj.Body.Close()
+
+	select {
+	case resizing <- struct{}{}:
+		defer func() { <-resizing }()
+	case <-ctx.Done():
+		return nil, "", ctx.Err()
+	}
+
+	variant, err := imaging.Resize(obj.Body, sizeFor(width), webp)
+	if err != nil {
+		return nil, "", err
+	}
+	return variant.Data, variant.ContentType, nil
+}
+
+// acceptsWebP reports whether an Accept header takes WebP
+func acceptsWebP(accept string) bool {
+	return strings.Contains(accept, "image/webp")
+}
@@ -0,0 +1,154 @@
+// Package metrics holds the Prometheus metrics the API exports on /metrics.
+//
+// The app runs with Prefork, so every request lands in one of several child
+// processes, each with its own counters. Every process writes its metrics to
+// a shared folder every few seconds (see prefork.go) and /metrics merges the
+// snapshots of every live process, whichever child serves it: counters and
+// histograms are added up, gauges report the highest of any process.
+package metrics
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+// Registry has every metric of this process, the default registry is left
+// alone so a library registering its own metrics can't break /metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	requestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "Time taken to answer a request, by route.",
+		Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+	}, []string{"method", "route"})
+
+	requestsTotal = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "Requests answered, by route and status code.",
+	}, []string{"method", "route", "status"})
+
+	paystackWebhooks = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "paystack_webhooks_total",
+		Help: "Paystack webhooks received, by event and result.",
+	}, []string{"event", "result"})
+
+	emailsSent = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "emails_sent_total",
+		Help: "Emails handed to the SMTP server, by result.",
+	}, []string{"result"})
+
+	aiCalls = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "ai_calls_total",
+		Help: "Calls to the AI API, by operation and result.",
+	}, []string{"operation", "result"})
+
+	aiCallDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "ai_call_duration_seconds",
+		Help:    "Time taken by the AI API, by operation.",
+		Buckets: []float64{.25, .5, 1, 2.5, 5, 10, 20, 30},
+	}, []string{"operation"})
+
+	jobRuns = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "jobs_total",
+		Help: "Background job attempts, by type and outcome.",
+	}, []string{"type", "outcome"})
+
+	jobDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "job_duration_seconds",
+		Help:    "Time taken by one background job attempt, by type.",
+		Buckets: []float64{.05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60},
+	}, []string{"type"})
+
+	rateLimited = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "rate_limited_total",
+		Help: "Requests turned away with a 429, by rate limit policy.",
+	}, []string{"policy"})
+
+	cacheLookups = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "cache_lookups_total",
+		Help: "Response cache lookups, by cached route and result.",
+	}, []string{"cache", "result"})
+)
+
+func init() {
+	Registry.MustRegister(
+		requestDuration,
+		requestsTotal,
+		paystackWebhooks,
+		emailsSent,
+		aiCalls,
+		aiCallDuration,
+		jobRuns,
+		jobDuration,
+		rateLimited,
+		cacheLookups,
+	)
+}
+
+// RegisterDB exports the connection pool stats of db, call it once per
+// process with the database the handlers use
+func RegisterDB(db *sql.DB) {
+	Registry.MustRegister(collectors.NewDBStatsCollector(db, "business_connect"))
+}
+
+// ObserveRequest records a finished request, route is the route pattern
+// (/product/:id) rather than the path so every product shares one series
+func ObserveRequest(method, route string, status int, took time.Duration) {
+	requestDuration.WithLabelValues(method, route).Observe(took.Seconds())
+	requestsTotal.WithLabelValues(method, route, strconv.Itoa(status)).Inc()
+}
+
+// PaystackWebhook counts a webhook, result is processed, failed or ignored
+func PaystackWebhook(event, result string) {
+	if event == "" {
+		event = "unknown"
+	}
+	paystackWebhooks.WithLabelValues(event, result).Inc()
+}
+
+// EmailSent counts an email send attempt
+func EmailSent(err error) {
+	emailsSent.WithLabelValues(result(err)).Inc()
+}
+
+// AICall counts a call to the AI API that started at start, it is meant to
+// be deferred with a pointer to the caller's named error:
+//
+//	defer metrics.AICall("description", time.Now(), &err)
+func AICall(operation string, start time.Time, err *error) {
+	aiCallDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+	aiCalls.WithLabelValues(operation, result(*err)).Inc()
+}
+
+// JobRun records one attempt at a background job, outcome is done, retry,
+// dead or released
+func JobRun(jobType, outcome string, took time.Duration) {
+	jobRuns.WithLabelValues(jobType, outcome).Inc()
+	jobDuration.WithLabelValues(jobType).Observe(took.Seconds())
+}
+
+// RateLimited counts a request refused by the named rate limit policy
+func RateLimited(policy string) {
+	rateLimited.WithLabelValues(policy).Inc()
+}
+
+// CacheLookup counts a response cache lookup for the named route
+func CacheLookup(name string, hit bool) {
+	outcome := "miss"
+	if hit {
+		outcome = "hit"
+	}
+	cacheLookups.WithLabelValues(name, outcome).Inc()
+}
+
+func result(err error) string {
+	if err != nil {
+		return "error"
+	}
+	return "ok"
+}
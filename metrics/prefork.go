@@ -0,0 +1,334 @@
+package metrics
+
+import (
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	config "business-connect/config"
+	"business-connect/lifecycle"
+
+	"github.com/gofiber/fiber/v2"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/prometheus/common/expfmt"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	snapshotEvery = 5 * time.Second
+	// a process that hasn't written for this long has exited, its snapshot
+	// is dropped and deleted
+	snapshotStale = 30 * time.Second
+)
+
+var (
+	mu          sync.Mutex
+	snapshotDir string
+)
+
+// Start writes this process's metrics to cfg.Dir every few seconds, every
+// prefork process calls it so /metrics can merge them all. The writer stops
+// with one last snapshot when the process shuts down.
+func Start(cfg config.MetricsConfig) error {
+	if err := os.MkdirAll(cfg.Dir, 0o755); err != nil {
+		return err
+	}
+
+	mu.Lock()
+	snapshotDir = cfg.Dir
+	mu.Unlock()
+
+	return lifecycle.Go("metrics snapshots", func(ctx context.Context) {
+		ticker := time.NewTicker(snapshotEvery)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ticker.C:
+			case <-ctx.Done():
+				snapshot(cfg.Dir)
+				return
+			}
+			snapshot(cfg.Dir)
+		}
+	})
+}
+
+// ClearSnapshots removes the snapshots a previous run left behind, the
+// prefork master calls it before starting the children
+func ClearSnapshots(cfg config.MetricsConfig) error {
+	entries, err := os.ReadDir(cfg.Dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), ".prom") {
+			os.Remove(filepath.Join(cfg.Dir, entry.Name()))
+		}
+	}
+	return nil
+}
+
+func snapshot(dir string) {
+	families, err := Registry.Gather()
+	if err == nil {
+		err = writeSnapshot(dir, families)
+	}
+	if err != nil {
+		slog.Warn("error writing metrics snapshot", "error", err)
+	}
+}
+
+// Handler serves the metrics of every live process in the Prometheus text
+// format, behind a bearer token when cfg.Token is set
+func Handler(cfg config.MetricsConfig) fiber.Handler {
+	want := []byte("Bearer " + cfg.Token)
+
+	return func(c *fiber.Ctx) error {
+		if cfg.Token != "" && subtle.ConstantTimeCompare([]byte(c.Get(fiber.HeaderAuthorization)), want) != 1 {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Unauthorized",
+			})
+		}
+
+		families, err := gatherAll()
+		if err != nil {
+			return err
+		}
+
+		var body bytes.Buffer
+		for _, family := range families {
+			if _, err := expfmt.MetricFamilyToText(&body, family); err != nil {
+				return err
+			}
+		}
+
+		c.Set(fiber.HeaderContentType, string(expfmt.NewFormat(expfmt.TypeTextPlain)))
+		return c.Send(body.Bytes())
+	}
+}
+
+func snapshotName() string {
+	return strconv.Itoa(os.Getpid()) + ".prom"
+}
+
+// writeSnapshot replaces this process's snapshot in one rename so a reader
+// never sees half a file
+func writeSnapshot(dir string, families []*dto.MetricFamily) error {
+	tmp, err := os.CreateTemp(dir, ".snapshot-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	for _, family := range families {
+		if _, err := expfmt.MetricFamilyToText(tmp, family); err != nil {
+			tmp.Close()
+			return err
+		}
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(dir, snapshotName()))
+}
+
+// gatherAll is this process's metrics merged with the latest snapshot of
+// every other live process, see addMetric
+func gatherAll() ([]*dto.MetricFamily, error) {
+	own, err := Registry.Gather()
+	if err != nil {
+		return nil, err
+	}
+
+	mu.Lock()
+	dir := snapshotDir
+	mu.Unlock()
+	if dir == "" {
+		return own, nil
+	}
+
+	// keep ours fresh for the other processes' next scrape
+	if err := writeSnapshot(dir, own); err != nil {
+		slog.Warn("error writing metrics snapshot", "error", err)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+
+	m := newMerger()
+	m.add(own)
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, ".prom") || name == snapshotName() {
+			continue
+		}
+		path := filepath.Join(dir, name)
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+		if time.Since(info.ModTime()) > snapshotStale {
+			os.Remove(path)
+			continue
+		}
+
+		families, err := readSnapshot(path)
+		if err != nil {
+			slog.Warn("error reading metrics snapshot", "file", name, "error", err)
+			continue
+		}
+		m.add(families)
+	}
+	return m.families(), nil
+}
+
+func readSnapshot(path string) ([]*dto.MetricFamily, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var parser expfmt.TextParser
+	parsed, err := parser.TextToMetricFamilies(file)
+	if err != nil {
+		return nil, err
+	}
+
+	families := make([]*dto.MetricFamily, 0, len(parsed))
+	for _, family := range parsed {
+		families = append(families, family)
+	}
+	return families, nil
+}
+
+// merger merges metric families from several processes
+type merger struct {
+	byName map[string]*dto.MetricFamily
+	series map[string]map[string]*dto.Metric
+}
+
+func newMerger() *merger {
+	return &merger{
+		byName: map[string]*dto.MetricFamily{},
+		series: map[string]map[string]*dto.Metric{},
+	}
+}
+
+func (m *merger) add(families []*dto.MetricFamily) {
+	for _, family := range families {
+		name := family.GetName()
+		dst, ok := m.byName[name]
+		if !ok {
+			dst = &dto.MetricFamily{Name: family.Name, Help: family.Help, Type: family.Type}
+			m.byName[name] = dst
+			m.series[name] = map[string]*dto.Metric{}
+		}
+		if dst.GetType() != family.GetType() {
+			continue
+		}
+
+		for _, metric := range family.Metric {
+			if metric.Histogram != nil {
+				dropInfBucket(metric.Histogram)
+			}
+			key := labelKey(metric.Label)
+			existing, ok := m.series[name][key]
+			if !ok {
+				m.series[name][key] = metric
+				dst.Metric = append(dst.Metric, metric)
+				continue
+			}
+			addMetric(existing, metric)
+		}
+	}
+}
+
+func (m *merger) families() []*dto.MetricFamily {
+	list := make([]*dto.MetricFamily, 0, len(m.byName))
+	for _, family := range m.byName {
+		list = append(list, family)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].GetName() < list[j].GetName() })
+	return list
+}
+
+func labelKey(labels []*dto.LabelPair) string {
+	var key strings.Builder
+	for _, label := range labels {
+		key.WriteString(label.GetName())
+		key.WriteByte('=')
+		key.WriteString(label.GetValue())
+		key.WriteByte(0xff)
+	}
+	return key.String()
+}
+
+// addMetric merges src into dst, both are the same series of the same
+// type. Counters and histograms count events and are added up. Gauges are
+// a level each process has on its own, memory or goroutines, adding them
+// would report more than any process ever had, so the highest is kept.
+func addMetric(dst, src *dto.Metric) {
+	switch {
+	case dst.Counter != nil && src.Counter != nil:
+		dst.Counter.Value = proto.Float64(dst.Counter.GetValue() + src.Counter.GetValue())
+	case dst.Gauge != nil && src.Gauge != nil:
+		dst.Gauge.Value = proto.Float64(math.Max(dst.Gauge.GetValue(), src.Gauge.GetValue()))
+	case dst.Untyped != nil && src.Untyped != nil:
+		dst.Untyped.Value = proto.Float64(math.Max(dst.Untyped.GetValue(), src.Untyped.GetValue()))
+	case dst.Histogram != nil && src.Histogram != nil:
+		addHistogram(dst.Histogram, src.Histogram)
+	}
+}
+
+// dropInfBucket removes the +Inf bucket a parsed snapshot has and a gathered
+// histogram doesn't, the text format writes it from the sample count
+func dropInfBucket(h *dto.Histogram) {
+	buckets := h.Bucket[:0]
+	for _, bucket := range h.Bucket {
+		if !math.IsInf(bucket.GetUpperBound(), 1) {
+			buckets = append(buckets, bucket)
+		}
+	}
+	h.Bucket = buckets
+}
+
+func addHistogram(dst, src *dto.Histogram) {
+	dst.SampleCount = proto.Uint64(dst.GetSampleCount() + src.GetSampleCount())
+	dst.SampleSum = proto.Float64(dst.GetSampleSum() + src.GetSampleSum())
+
+	for _, bucket := range src.Bucket {
+		matched := false
+		for _, existing := range dst.Bucket {
+			if existing.GetUpperBound() == bucket.GetUpperBound() {
+				existing.CumulativeCount = proto.Uint64(existing.GetCumulativeCount() + bucket.GetCumulativeCount())
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			dst.Bucket = append(dst.Bucket, bucket)
+		}
+	}
+	sort.Slice(dst.Bucket, func(i, j int) bool {
+		return dst.Bucket[i].GetUpperBound() < dst.Bucket[j].GetUpperBound()
+	})
+}
@@ -0,0 +1,47 @@
+package metrics
+
+import (
+	"testing"
+
+	dto "github.com/prometheus/client_model/go"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestMergeAddsCountersAndKeepsHighestGauge(t *testing.T) {
+	process := func(requests, connections float64) []*dto.MetricFamily {
+		return []*dto.MetricFamily{
+			{
+				Name:   proto.String("http_requests_total"),
+				Type:   dto.MetricType_COUNTER.Enum(),
+				Metric: []*dto.Metric{{Counter: &dto.Counter{Value: proto.Float64(requests)}}},
+			},
+			{
+				Name:   proto.String("business_connect_open_connections"),
+				Type:   dto.MetricType_GAUGE.Enum(),
+				Metric: []*dto.Metric{{Gauge: &dto.Gauge{Value: proto.Float64(connections)}}},
+			},
+		}
+	}
+
+	m := newMerger()
+	m.add(process(3, 4))
+	m.add(process(5, 7))
+	m.add(process(1, 2))
+
+	got := map[string]float64{}
+	for _, family := range m.families() {
+		metric := family.Metric[0]
+		switch {
+		case metric.Counter != nil:
+			got[family.GetName()] = metric.Counter.GetValue()
+		case metric.Gauge != nil:
+			got[family.GetName()] = metric.Gauge.GetValue()
+		}
+	}
+	if got["http_requests_total"] != 9 {
+		t.Errorf("requests %v, want the 9 of every process", got["http_requests_total"])
+	}
+	if got["business_connect_open_connections"] != 7 {
+		t.Errorf("open connections %v, want the highest, 7", got["business_connect_open_connections"])
+	}
+}
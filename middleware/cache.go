@@ -0,0 +1,111 @@
+package middleware
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"business-connect/cache"
+	"business-connect/logger"
+	"business-connect/metrics"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// how long a request waits on the cache store before it goes to the handler
+const cacheTimeout = 250 * time.Millisecond
+
+// Cache serves GET requests from the cache when it has a fresh copy and
+// keeps successful responses for policy.TTL otherwise. Every response it
+// handles carries an ETag, a request whose If-None-Match still matches gets
+// a 304 without the body. It must come after the middlewares that decide
+// whether the request may see the route at all, and only fits routes whose
+// response is the same for every caller.
+func Cache(policy cache.Policy) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if c.Method() != fiber.MethodGet && c.Method() != fiber.MethodHead {
+			return c.Next()
+		}
+
+		key := policy.Name + ":" + c.Path() + "?" + string(c.Request().URI().QueryString())
+
+		ctx, cancel := context.WithTimeout(c.UserContext(), cacheTimeout)
+		entry, hit, versions, err := cache.Lookup(ctx, key, policy.Tags)
+		cancel()
+		if err != nil {
+			// Redis is down, the database still answers
+			logger.Ctx(c).Warn("cache unavailable, request not cached", "cache", policy.Name, "error", err)
+			return c.Next()
+		}
+		metrics.CacheLookup(policy.Name, hit)
+
+		if hit {
+			c.Set("X-Cache", "HIT")
+			return sendCached(c, entry)
+		}
+
+		if err := c.Next(); err != nil {
+			return err
+		}
+
+		c.Set("X-Cache", "MISS")
+		// only a full answer that belongs to nobody in particular is kept
+		if c.Method() != fiber.MethodGet || c.Response().StatusCode() != fiber.StatusOK ||
+			len(c.Response().Header.Peek(fiber.HeaderSetCookie)) != 0 {
+			return nil
+		}
+
+		entry = cache.Entry{
+			ContentType: string(c.Response().Header.ContentType()),
+			// the response buffer is reused once the request is done
+			Body: append([]byte(nil), c.Response().Body()...),
+		}
+		entry.ETag = etag(entry.Body)
+
+		ctx, cancel = context.WithTimeout(c.UserContext(), cacheTimeout)
+		if err := cache.Save(ctx, key, entry, versions, policy.TTL); err != nil {
+			logger.Ctx(c).Warn("error caching response", "cache", policy.Name, "error", err)
+		}
+		cancel()
+
+		return sendCached(c, entry)
+	}
+}
+
+func sendCached(c *fiber.Ctx, entry cache.Entry) error {
+	c.Set(fiber.HeaderETag, entry.ETag)
+	// clients keep their copy but check it with If-None-Match every time,
+	// an invalidated entry must not live on in a browser
+	c.Set(fiber.HeaderCacheControl, "public, no-cache")
+
+	if etagMatches(c.Get(fiber.HeaderIfNoneMatch), entry.ETag) {
+		c.Response().ResetBody()
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	c.Status(fiber.StatusOK)
+	c.Set(fiber.HeaderContentType, entry.ContentType)
+	return c.Send(entry.Body)
+}
+
+func etag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header lists etag, weak
+// comparison as RFC 9110 asks for
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -7,11 +7,7 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"errors"
-	"fmt"
 	"io"
-	"os"
-
-	"github.com/joho/godotenv"
 )
 
 // Pad data to make its length a multiple of blockSize
@@ -33,16 +29,8 @@ func UnpadJwtToken(data []byte) ([]byte, error) {
 	return data[:len(data)-padding], nil
 }
 
-func EncryptJwtToken(data string) (string, error) {
-	envErr := godotenv.Load(".env")
-
-	if envErr != nil {
-		fmt.Printf("Failed to load .env file: %v\n", envErr)
-		return "", errors.New("error loading .env file")
-	}
-
-	key := os.Getenv("JWT_ENCRYPTION_KEY")
-
+// EncryptJwtToken encrypts data with key, the JWT encryption key
+func EncryptJwtToken(key, data string) (string, error) {
 	block, err := aes.NewCipher([]byte(key))
 	if err != nil {
 		return "", err
@@ -66,16 +54,8 @@ func EncryptJwtToken(data string) (string, error) {
 	return encryptedString, nil
 }
 
-func DecryptJwtToken(binaryText string) ([]byte, error) {
-	envErr := godotenv.Load(".env")
-
-	if envErr != nil {
-		fmt.Printf("Failed to load .env file: %v\n", envErr)
-		return nil, errors.New("error loading .env file")
-	}
-
-	key := os.Getenv("JWT_ENCRYPTION_KEY")
-
+// DecryptJwtToken decrypts what EncryptJwtToken encrypted with key
+func DecryptJwtToken(key, binaryText string) ([]byte, error) {
 	// Decode the Base64 string to get the ciphertext
 	ciphertext, err := base64.StdEncoding.DecodeString(binaryText)
 	if err != nil {
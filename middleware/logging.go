@@ -0,0 +1,113 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"runtime/debug"
+	"time"
+
+	"business-connect/logger"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// ids we accept from a proxy or client, anything else gets a fresh one
+var validRequestID = regexp.MustCompile(`^[A-Za-z0-9._-]{8,64}$`)
+
+// RequestID gives every request an id, taken from X-Request-ID when the
+// caller sent a sane one, and echoes it in the response header so a client
+// report can be matched to the logs
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(logger.RequestIDHeader)
+		if !validRequestID.MatchString(id) {
+			id = newRequestID()
+		}
+		c.Locals(logger.RequestIDLocal, id)
+		c.Set(logger.RequestIDHeader, id)
+		return c.Next()
+	}
+}
+
+func newRequestID() string {
+	b := make([]byte, 12)
+	if _, err := rand.Read(b); err != nil {
+		return fmt.Sprintf("%x", time.Now().UnixNano())
+	}
+	return hex.EncodeToString(b)
+}
+
+// AccessLog writes one line per request once the response is ready. Errors
+// returned by the handlers are turned into their response here, so the
+// logged status is the one the client got.
+func AccessLog() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+
+		if err := c.Next(); err != nil {
+			if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+				_ = c.SendStatus(fiber.StatusInternalServerError)
+			}
+		}
+
+		status := c.Response().StatusCode()
+		level := slog.LevelInfo
+		if status >= fiber.StatusInternalServerError {
+			level = slog.LevelError
+		}
+
+		logger.Ctx(c).Log(c.UserContext(), level, "request",
+			"method", c.Method(),
+			"path", c.Path(),
+			"route", c.Route().Path,
+			"status", status,
+			"duration_ms", time.Since(start).Milliseconds(),
+			"bytes", len(c.Response().Body()),
+			"ip", c.IP(),
+			"user_agent", c.Get(fiber.HeaderUserAgent),
+		)
+		return nil
+	}
+}
+
+// Recover turns a panic in a handler into a 500. The panic and its stack
+// are logged, the client only gets the request id to quote.
+func Recover() fiber.Handler {
+	return func(c *fiber.Ctx) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Ctx(c).Error("panic recovered",
+					"panic", fmt.Sprint(r),
+					"stack", string(debug.Stack()),
+				)
+				err = c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+					"error":      "Internal Server Error",
+					"request_id": logger.RequestID(c),
+				})
+			}
+		}()
+		return c.Next()
+	}
+}
+
+// ErrorHandler answers errors the handlers return instead of writing a
+// response. Fiber errors (404, 405, 413...) keep their status and message,
+// anything else is logged and answered with a generic 500.
+func ErrorHandler(c *fiber.Ctx, err error) error {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return c.Status(fiberErr.Code).JSON(fiber.Map{
+			"error": fiberErr.Message,
+		})
+	}
+
+	logger.Ctx(c).Error("unhandled error", "error", err)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error":      "Internal Server Error",
+		"request_id": logger.RequestID(c),
+	})
+}
@@ -0,0 +1,28 @@
+package middleware
+
+import (
+	"time"
+
+	"business-connect/metrics"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Metrics records the latency and status of every request by route. It has
+// to sit outside AccessLog, which turns returned errors into the response,
+// so the status recorded is the one the client got.
+func Metrics() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		err := c.Next()
+
+		// a request no route matched ends on a middleware, its path would
+		// give every scanner probe a series of its own
+		route := c.Route().Path
+		if c.Route().Method == "USE" {
+			route = "unmatched"
+		}
+		metrics.ObserveRequest(c.Method(), route, c.Response().StatusCode(), time.Since(start))
+		return err
+	}
+}
@@ -0,0 +1,329 @@
+package myjwt
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	config "business-connect/config"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// Key layout inside the keys directory (JWT_KEYS_DIR, "keys" by default):
+//
+//	current_kid            id of the key used to sign new tokens
+//	<kid>.private.pem      private key, only needed for the current kid
+//	<kid>.public.pem       public key, kept for every kid we still verify
+//
+// private_key.pem / public_key.pem from before rotation existed were
+// committed to the repository, so anyone can sign with them. They neither
+// sign nor verify, the first jwt-keys generate deletes them and a token
+// without a kid header is refused, so everyone signed in before signs in
+// again.
+//
+// On hosts without a writable keys directory the signing key can come from
+// the environment instead: JWT_PRIVATE_KEY holds the PEM and JWT_KEY_ID its
+// kid, JWT_PREVIOUS_PUBLIC_KEYS and JWT_PREVIOUS_KEY_IDS the keys it replaced.
+
+const (
+	currentKidFile = "current_kid"
+	privateSuffix  = ".private.pem"
+	publicSuffix   = ".public.pem"
+	rsaKeyBits     = 2048
+	// written by earlier versions while the committed key still verified
+	legacyUntilFile = "legacy_until"
+)
+
+type signingKey struct {
+	ID      string
+	Private *rsa.PrivateKey
+}
+
+var (
+	currentKey *signingKey
+	verifyKeys = map[string]*rsa.PublicKey{}
+)
+
+func loadKeys(cfg config.JWTConfig) error {
+	dir := cfg.KeysDir
+	keys := map[string]*rsa.PublicKey{}
+	var signer *signingKey
+
+	// every public key in the directory can verify tokens
+	entries, err := os.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("error reading keys directory: %w", err)
+	}
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasSuffix(name, publicSuffix) {
+			continue
+		}
+		pub, err := readPublicKey(filepath.Join(dir, name))
+		if err != nil {
+			return err
+		}
+		keys[strings.TrimSuffix(name, publicSuffix)] = pub
+	}
+
+	previous, err := previousKeys(cfg)
+	if err != nil {
+		return err
+	}
+	for kid, pub := range previous {
+		keys[kid] = pub
+	}
+
+	switch {
+	case cfg.PrivateKey != "":
+		kid := cfg.KeyID
+		if kid == "" {
+			return errors.New("JWT_KEY_ID is required when JWT_PRIVATE_KEY is set")
+		}
+		priv, err := jwt.ParseRSAPrivateKeyFromPEM([]byte(cfg.PrivateKey))
+		if err != nil {
+			return fmt.Errorf("error parsing JWT_PRIVATE_KEY: %w", err)
+		}
+		signer = &signingKey{ID: kid, Private: priv}
+	default:
+		kid, err := readCurrentKid(dir)
+		if err != nil {
+			return err
+		}
+		priv, err := readPrivateKey(filepath.Join(dir, kid+privateSuffix))
+		if err != nil {
+			return err
+		}
+		signer = &signingKey{ID: kid, Private: priv}
+	}
+	// the signing key must always be able to verify its own tokens
+	keys[signer.ID] = &signer.Private.PublicKey
+
+	currentKey = signer
+	verifyKeys = keys
+	return nil
+}
+
+// previousKeys parses JWT_PREVIOUS_PUBLIC_KEYS, one PEM per id in
+// JWT_PREVIOUS_KEY_IDS
+func previousKeys(cfg config.JWTConfig) (map[string]*rsa.PublicKey, error) {
+	keys := map[string]*rsa.PublicKey{}
+	rest := []byte(cfg.PreviousPublicKeys)
+	for _, kid := range cfg.PreviousKeyIDs {
+		var block *pem.Block
+		block, rest = pem.Decode(rest)
+		if block == nil {
+			return nil, fmt.Errorf("JWT_PREVIOUS_PUBLIC_KEYS has no PEM for key %s", kid)
+		}
+		pub, err := jwt.ParseRSAPublicKeyFromPEM(pem.EncodeToMemory(block))
+		if err != nil {
+			return nil, fmt.Errorf("error parsing the previous public key %s: %w", kid, err)
+		}
+		keys[kid] = pub
+	}
+	if block, _ := pem.Decode(rest); block != nil {
+		return nil, errors.New("JWT_PREVIOUS_PUBLIC_KEYS has more keys than JWT_PREVIOUS_KEY_IDS names")
+	}
+	return keys, nil
+}
+
+// readCurrentKid reads the kid jwt-keys generate made the signing key
+func readCurrentKid(dir string) (string, error) {
+	raw, err := os.ReadFile(filepath.Join(dir, currentKidFile))
+	if os.IsNotExist(err) {
+		return "", fmt.Errorf("no signing key found in %s, run `jwt-keys generate` first", dir)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading current kid: %w", err)
+	}
+	kid := strings.TrimSpace(string(raw))
+	if kid == "" {
+		return "", errors.New("current_kid file is empty")
+	}
+	return kid, nil
+}
+
+func readPrivateKey(path string) (*rsa.PrivateKey, error) {
+	signBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading private key file: %w", err)
+	}
+	key, err := jwt.ParseRSAPrivateKeyFromPEM(signBytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing private key: %w", err)
+	}
+	return key, nil
+}
+
+func readPublicKey(path string) (*rsa.PublicKey, error) {
+	verifyBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading public key file: %w", err)
+	}
+	key, err := jwt.ParseRSAPublicKeyFromPEM(verifyBytes)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing public key %s: %w", path, err)
+	}
+	return key, nil
+}
+
+// keyFunc picks the verify key named by the token's kid header. Tokens
+// issued before rotation have no kid, they were signed with the committed
+// key and are refused.
+func keyFunc(token *jwt.Token) (interface{}, error) {
+	if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+		return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+	}
+
+	kid, _ := token.Header["kid"].(string)
+	if kid == "" {
+		return nil, errors.New("token has no key id")
+	}
+
+	key, ok := verifyKeys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+// signClaims signs claims with the current key and stamps its kid
+func signClaims(claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = currentKey.ID
+	return token.SignedString(currentKey.Private)
+}
+
+type jwk struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+// JWKS serves every verify key so other services can check our tokens
+func JWKS(ctx *fiber.Ctx) error {
+	kids := make([]string, 0, len(verifyKeys))
+	for kid := range verifyKeys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	keys := make([]jwk, 0, len(kids))
+	for _, kid := range kids {
+		pub := verifyKeys[kid]
+		keys = append(keys, jwk{
+			Kty: "RSA",
+			Use: "sig",
+			Alg: "RS256",
+			Kid: kid,
+			N:   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		})
+	}
+
+	ctx.Set(fiber.HeaderCacheControl, "public, max-age=300")
+	return ctx.JSON(fiber.Map{"keys": keys})
+}
+
+// GenerateKey writes a new key pair into dir and makes it the signing key.
+// The previous signing key is kept as verify-only so tokens it issued stay
+// valid until they expire.
+func GenerateKey(dir string) (string, error) {
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return "", fmt.Errorf("error creating keys directory: %w", err)
+	}
+
+	previousKid, _ := readCurrentKid(dir)
+
+	key, err := rsa.GenerateKey(rand.Reader, rsaKeyBits)
+	if err != nil {
+		return "", fmt.Errorf("error generating key: %w", err)
+	}
+
+	suffix, err := GenerateCSRFSecrete()
+	if err != nil {
+		return "", err
+	}
+	kid := time.Now().UTC().Format("20060102150405") + "-" + strings.ToLower(suffix[:6])
+
+	privPEM := pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)})
+	pubDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return "", fmt.Errorf("error encoding public key: %w", err)
+	}
+	pubPEM := pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: pubDER})
+
+	if err := os.WriteFile(filepath.Join(dir, kid+privateSuffix), privPEM, 0o600); err != nil {
+		return "", fmt.Errorf("error writing private key: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, kid+publicSuffix), pubPEM, 0o644); err != nil {
+		return "", fmt.Errorf("error writing public key: %w", err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, currentKidFile), []byte(kid+"\n"), 0o644); err != nil {
+		return "", fmt.Errorf("error writing current kid: %w", err)
+	}
+
+	// the old private key can no longer sign anything, only its public half is kept
+	if previousKid != "" {
+		if err := os.Remove(filepath.Join(dir, previousKid+privateSuffix)); err != nil && !os.IsNotExist(err) {
+			return kid, fmt.Errorf("new key %s is active but removing the old private key failed: %w", kid, err)
+		}
+	}
+
+	if err := removeCommittedKeys(dir); err != nil {
+		return kid, fmt.Errorf("new key %s is active but removing the committed keys failed: %w", kid, err)
+	}
+
+	return kid, nil
+}
+
+// removeCommittedKeys deletes the key pair that was committed to the
+// repository, nothing it signed is accepted anymore
+func removeCommittedKeys(dir string) error {
+	for _, name := range []string{privKeyPath, pubKeyPath, legacyUntilFile} {
+		if err := os.Remove(filepath.Join(dir, name)); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// RetireKey removes a verify-only key. Tokens signed with it stop working.
+func RetireKey(dir, kid string) error {
+	current, _ := readCurrentKid(dir)
+	if kid == current {
+		return errors.New("cannot retire the current signing key, rotate first")
+	}
+
+	if err := os.Remove(filepath.Join(dir, kid+publicSuffix)); err != nil {
+		return fmt.Errorf("error removing key %s: %w", kid, err)
+	}
+	return nil
+}
+
+// ListKeys returns every kid cfg verifies with and which one signs new tokens
+func ListKeys(cfg config.JWTConfig) (kids []string, current string, err error) {
+	if err = loadKeys(cfg); err != nil {
+		return nil, "", err
+	}
+	for kid := range verifyKeys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+	return kids, currentKey.ID, nil
+}
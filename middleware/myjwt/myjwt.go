@@ -1,94 +1,57 @@
 package myjwt
 
 import (
-	"crypto/rsa"
 	"errors"
 	"fmt"
-	"io"
-	"log"
-	"os"
+	"log/slog"
+	"strconv"
 	"time"
 
+	config "business-connect/config"
 	Data "business-connect/models"
 
 	rand "business-connect/controllers/authentication/utils"
 	dbFunc "business-connect/database/dbHelpFunc"
+	"business-connect/logger"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/golang-jwt/jwt/v4"
 )
 
-var (
-	signKey   *rsa.PrivateKey
-	verifyKey *rsa.PublicKey
-)
-
 const (
 	RefreshTokenValidTime = time.Hour * 24 * 14 // 14 days
 	AuthTokenValidTime    = time.Hour * 24 * 14 // 14 hours
-	// use environmental variable to get private key
-	privKeyPath = "keys/private_key.pem"
-	pubKeyPath  = "keys/public_key.pem"
+	// key pair file names used before key rotation, see keys.go
+	privKeyPath = "private_key.pem"
+	pubKeyPath  = "public_key.pem"
 )
 
 func GenerateCSRFSecrete() (string, error) {
 	return rand.RandomAlphanumericString(32)
 }
 
-func InitJWT() error {
-	privKeyFilePath, err := os.Open(privKeyPath)
-	if err != nil {
-		return fmt.Errorf("error opening private key file: %w", err)
-	}
-	defer privKeyFilePath.Close()
-
-	signBytes, err := io.ReadAll(privKeyFilePath)
-	if err != nil {
-		return fmt.Errorf("error reading private key file: %w", err)
-	}
-
-	signKey, err = jwt.ParseRSAPrivateKeyFromPEM(signBytes)
-	if err != nil {
-		return fmt.Errorf("error parsing private key: %w", err)
-	}
-
-	pubKeyFilePath, err := os.Open(pubKeyPath)
-	if err != nil {
-		return fmt.Errorf("error opening public key file: %w", err)
-	}
-	defer pubKeyFilePath.Close()
-
-	verifyBytes, err := io.ReadAll(pubKeyFilePath)
-	if err != nil {
-		return fmt.Errorf("error reading public key file: %w", err)
-	}
-	verifyKey, err = jwt.ParseRSAPublicKeyFromPEM(verifyBytes)
-	if err != nil {
-		return fmt.Errorf("error parsing public key: %w", err)
-	}
-
-	// fmt.Println("signKey:", signKey)
-
-	return nil
+// InitJWT loads the signing key and every verify key, see keys.go for the layout
+func InitJWT(cfg config.JWTConfig) error {
+	return loadKeys(cfg)
 }
 
 func CreateNewTokens(ctx *fiber.Ctx, uuid, role string) (authTokenString, refreshTokenString, csrfSecrete string, err error) {
 	// generate the csrf token
 	csrfSecrete, err = GenerateCSRFSecrete()
 	if err != nil {
-		fmt.Println("Error generating the csrf secrete")
+		logger.Ctx(ctx).Error("error generating the csrf secret", "error", err)
 	}
 
 	// generating the refresh token
 	refreshTokenString, err = CreateRefreshTokenString(uuid, role, csrfSecrete)
 	if err != nil {
-		fmt.Println("Error generating the crate refresh token string")
+		logger.Ctx(ctx).Error("error creating the refresh token string", "error", err)
 	}
 
 	// generating the auth token
 	authTokenString, err = CreateAuthTokenString(uuid, role, csrfSecrete)
 	if err != nil {
-		fmt.Println("Error generating the create authentication token string")
+		logger.Ctx(ctx).Error("error creating the auth token string", "error", err)
 	}
 
 	return
@@ -97,33 +60,31 @@ func CreateNewTokens(ctx *fiber.Ctx, uuid, role string) (authTokenString, refres
 func CheckAndRefreshTokens(oldAuthTokenString string, oldRefreshTokenString string, oldCsrfSecrete string) (newAuthTokenString, newRefreshTokenString, newCsrfSecret string, err error) {
 
 	if oldCsrfSecrete == "" {
-		log.Println("No CSRF Token!")
+		slog.Debug("no csrf token")
 		// err = errors.New("Unauthorized")
 	}
 
-	// Assuming verifyKey is defined and is the correct key used to verify the tokens.
+	// keyFunc picks the verify key matching the token kid, see keys.go
 
-	authToken, err := jwt.ParseWithClaims(oldAuthTokenString, &Data.TokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return verifyKey, nil
-	})
+	authToken, err := jwt.ParseWithClaims(oldAuthTokenString, &Data.TokenClaims{}, keyFunc)
 	// fmt.Println("err1", err)
 
 	// Check for parsing errors
 	// log.Println("auth token:", authToken)
 	if err != nil {
-		log.Println("Error parsing old auth token:", err)
+		slog.Debug("error parsing old auth token", "error", err)
 		err = errors.New("Unauthorized")
 	}
 
 	authTokenClaims, ok := authToken.Claims.(*Data.TokenClaims)
 	if !ok || !authToken.Valid {
-		log.Println("Invalid auth token")
+		slog.Debug("invalid auth token")
 		err = errors.New("Unauthorized")
 	}
 
 	// Verify CSRF token
 	if oldCsrfSecrete != authTokenClaims.Csrf {
-		log.Println("CSRF Token does not match")
+		slog.Warn("csrf token does not match the auth token")
 		err = errors.New("Unauthorized")
 	}
 
@@ -160,12 +121,12 @@ func CheckAndRefreshTokens(oldAuthTokenString string, oldRefreshTokenString stri
 			newRefreshTokenString, err = UpdateRefreshTokenCsrf(newRefreshTokenString, newCsrfSecret)
 			return
 		} else {
-			log.Println("Error in auth token")
+			slog.Debug("error in auth token")
 			err = errors.New("error in auth token")
 			return
 		}
 	} else {
-		log.Println("Error in auth token")
+		slog.Debug("error in auth token")
 		// err = errors.New("error in auth token")
 	}
 
@@ -183,12 +144,10 @@ func CreateAuthTokenString(uuid string, role string, csrfSecrete string) (authTo
 		Role: role,
 		Csrf: csrfSecrete,
 	}
-	authJwt := jwt.NewWithClaims(jwt.SigningMethodRS256, authClaims)
-
 	// Check for errors during signing
-	if authTokenString, err = authJwt.SignedString(signKey); err != nil {
+	if authTokenString, err = signClaims(authClaims); err != nil {
 		// Add more detailed error logging here to see the actual error
-		log.Println("Error signing auth token:", err)
+		slog.Error("error signing auth token", "error", err)
 		return "", err
 	}
 
@@ -197,9 +156,13 @@ func CreateAuthTokenString(uuid string, role string, csrfSecrete string) (authTo
 
 func CreateRefreshTokenString(uuid string, role string, csrfString string) (refreshTokenString string, err error) {
 	refreshTokenExp := time.Now().Add(RefreshTokenValidTime)
-	refreshJti, err := dbFunc.DBHelper.StoreRefreshToken()
+	userID, err := strconv.ParseUint(uuid, 10, 64)
+	if err != nil {
+		return "", fmt.Errorf("error reading the user id: %w", err)
+	}
+	refreshJti, err := dbFunc.DBHelper.StoreRefreshToken(uint(userID))
 	if err != nil {
-		fmt.Println("this is the Refresh Token Error: ", err)
+		slog.Error("error creating refresh token", "error", err)
 		return
 	}
 
@@ -213,15 +176,12 @@ func CreateRefreshTokenString(uuid string, role string, csrfString string) (refr
 		Csrf: csrfString,
 	}
 
-	refreshJwt := jwt.NewWithClaims(jwt.SigningMethodRS256, refreshClaims)
-	refreshTokenString, err = refreshJwt.SignedString(signKey)
+	refreshTokenString, err = signClaims(refreshClaims)
 	return
 }
 
 func UpdateRefreshTokenExp(oldRefreshTokenString string) (newRefreshTokenString string, err error) {
-	refreshToken, _ := jwt.ParseWithClaims(oldRefreshTokenString, &Data.TokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return verifyKey, nil
-	})
+	refreshToken, _ := jwt.ParseWithClaims(oldRefreshTokenString, &Data.TokenClaims{}, keyFunc)
 
 	oldRefreshTokenClaims, ok := refreshToken.Claims.(*Data.TokenClaims)
 	if !ok {
@@ -240,16 +200,13 @@ func UpdateRefreshTokenExp(oldRefreshTokenString string) (newRefreshTokenString
 		Csrf: oldRefreshTokenClaims.Csrf,
 	}
 
-	refreshJwt := jwt.NewWithClaims(jwt.SigningMethodRS256, refreshClaims)
-	newRefreshTokenString, err = refreshJwt.SignedString(signKey)
+	newRefreshTokenString, err = signClaims(refreshClaims)
 	return
 }
 
 func UpdateAuthTokenString(refreshTokenString string, oldAuthTokenString string) (newAuthTokenString, csrfSecrete string, err error) {
 
-	refreshToken, err := jwt.ParseWithClaims(refreshTokenString, &Data.TokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return verifyKey, nil
-	})
+	refreshToken, err := jwt.ParseWithClaims(refreshTokenString, &Data.TokenClaims{}, keyFunc)
 
 	if err != nil {
 		return
@@ -263,9 +220,7 @@ func UpdateAuthTokenString(refreshTokenString string, oldAuthTokenString string)
 	// i need to call the check refresh token function to check for this users refresh token in the database i haven't written the function.
 	if dbFunc.DBHelper.CheckRefreshToken(refreshTokenClaims.RegisteredClaims.ID) {
 		if refreshToken.Valid {
-			authToken, _ := jwt.ParseWithClaims(oldAuthTokenString, &Data.TokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-				return verifyKey, nil
-			})
+			authToken, _ := jwt.ParseWithClaims(oldAuthTokenString, &Data.TokenClaims{}, keyFunc)
 			oldAuthTokenClaims, ok := authToken.Claims.(*Data.TokenClaims)
 			if !ok {
 				err = errors.New("errors reading jwt claims")
@@ -280,14 +235,14 @@ func UpdateAuthTokenString(refreshTokenString string, oldAuthTokenString string)
 			CreateAuthTokenString(oldAuthTokenClaims.RegisteredClaims.Subject, oldAuthTokenClaims.Role, csrfSecrete)
 			return
 		} else {
-			log.Println("Refresh token has expired")
+			slog.Debug("refresh token has expired")
 			// still need to write this database function to delete refresh token from the database
 			dbFunc.DBHelper.DeleteRefreshToken(refreshTokenClaims.RegisteredClaims.ID)
 			err = errors.New("Unauthorized")
 			return
 		}
 	} else {
-		log.Println("Refresh token has been revoked")
+		slog.Warn("refresh token has been revoked")
 		err = errors.New("Unauthorized")
 	}
 	return
@@ -295,9 +250,7 @@ func UpdateAuthTokenString(refreshTokenString string, oldAuthTokenString string)
 
 func RevokeRefreshToken(refreshTokenString string) error {
 	// use the refresh token string that this function would receive to get your refresh token
-	refreshToken, err := jwt.ParseWithClaims(refreshTokenString, &Data.TokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return verifyKey, nil
-	})
+	refreshToken, err := jwt.ParseWithClaims(refreshTokenString, &Data.TokenClaims{}, keyFunc)
 
 	if err != nil {
 		return errors.New("could not parse refresh token with claims")
@@ -318,9 +271,7 @@ func RevokeRefreshToken(refreshTokenString string) error {
 
 func UpdateRefreshTokenCsrf(oldRefreshTokenString string, newCsrfString string) (newRefreshTokenString string, err error) {
 	// get access to the old refresh token by using the parse token function
-	refreshToken, err := jwt.ParseWithClaims(oldRefreshTokenString, &Data.TokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return verifyKey, nil
-	})
+	refreshToken, err := jwt.ParseWithClaims(oldRefreshTokenString, &Data.TokenClaims{}, keyFunc)
 	// get access to the refresh token claims.
 	oldRefreshTokenClaims, ok := refreshToken.Claims.(*Data.TokenClaims)
 	if !ok {
@@ -337,17 +288,13 @@ func UpdateRefreshTokenCsrf(oldRefreshTokenString string, newCsrfString string)
 		Role: oldRefreshTokenClaims.Role,
 		Csrf: newCsrfString,
 	}
-	// new refresh jwt
-	refreshJwt := jwt.NewWithClaims(jwt.SigningMethodRS256, refreshClaims)
-	// new refresh token string
-	newRefreshTokenString, err = refreshJwt.SignedString(signKey)
+	// new refresh token string signed with the current key
+	newRefreshTokenString, err = signClaims(refreshClaims)
 	return
 }
 
 func GrabUUID(authTokenString string) (string, error) {
-	authToken, err := jwt.ParseWithClaims(authTokenString, &Data.TokenClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return verifyKey, nil
-	})
+	authToken, err := jwt.ParseWithClaims(authTokenString, &Data.TokenClaims{}, keyFunc)
 
 	if err != nil {
 		return "", errors.New("error parsing auth token")
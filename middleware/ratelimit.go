@@ -0,0 +1,94 @@
+package middleware
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"business-connect/logger"
+	"business-connect/metrics"
+	myjwt "business-connect/middleware/myjwt"
+	"business-connect/ratelimit"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// how long a request waits on the limiter store before it is let through
+// uncounted
+const rateLimitTimeout = 250 * time.Millisecond
+
+// RateLimit counts requests against policy, per user when the request is
+// signed in and per IP otherwise, and answers 429 once the policy is used
+// up. A nil policy func or one returning false skips the request. Every
+// response carries the RateLimit-* headers of the policy closest to its
+// limit, so a route's strict policy shows over the global relaxed one. When
+// the store fails the request is let through, an outage in Redis must not
+// take the API down with it.
+func RateLimit(policy func(c *fiber.Ctx) (ratelimit.Policy, bool)) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		p, ok := policy(c)
+		if !ok {
+			return c.Next()
+		}
+
+		ctx, cancel := context.WithTimeout(c.UserContext(), rateLimitTimeout)
+		result, err := ratelimit.Allow(ctx, p, rateLimitSubject(c))
+		cancel()
+		if err != nil {
+			logger.Ctx(c).Warn("rate limiter unavailable, request let through", "policy", p.Name, "error", err)
+			return c.Next()
+		}
+
+		setRateLimitHeaders(c, result)
+
+		if !result.Allowed {
+			metrics.RateLimited(p.Name)
+			c.Set(fiber.HeaderRetryAfter, strconv.FormatInt(seconds(result.ResetIn), 10))
+			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
+				"error": "Too Many Requests",
+			})
+		}
+		return c.Next()
+	}
+}
+
+// Limit is RateLimit with one policy for every request
+func Limit(p ratelimit.Policy) fiber.Handler {
+	return RateLimit(func(*fiber.Ctx) (ratelimit.Policy, bool) {
+		return p, true
+	})
+}
+
+// rateLimitSubject is who a request counts against. Behind WebRequireAuth
+// the user is in Locals, before it (the global limiter) a valid auth cookie
+// is enough, a forged one fails the signature check and counts by IP.
+func rateLimitSubject(c *fiber.Ctx) string {
+	if userID, ok := c.Locals("user-id").(uint); ok {
+		return "user:" + strconv.FormatUint(uint64(userID), 10)
+	}
+	if token := c.Cookies("__BusinessConnect-Auth-Token"); token != "" {
+		if userID, err := myjwt.GrabUUID(token); err == nil && userID != "" {
+			return "user:" + userID
+		}
+	}
+	return "ip:" + c.IP()
+}
+
+func setRateLimitHeaders(c *fiber.Ctx, result ratelimit.Result) {
+	// an earlier policy with fewer requests left keeps its headers
+	if previous := c.GetRespHeader("RateLimit-Remaining"); previous != "" {
+		if left, err := strconv.ParseInt(previous, 10, 64); err == nil && left <= result.Remaining {
+			return
+		}
+	}
+	c.Set("RateLimit-Policy", result.Policy.Header())
+	c.Set("RateLimit-Limit", strconv.FormatInt(result.Policy.Limit, 10))
+	c.Set("RateLimit-Remaining", strconv.FormatInt(result.Remaining, 10))
+	c.Set("RateLimit-Reset", strconv.FormatInt(seconds(result.ResetIn), 10))
+}
+
+// seconds rounds d up, a client told 0 would retry straight away
+func seconds(d time.Duration) int64 {
+	return int64(math.Ceil(d.Seconds()))
+}
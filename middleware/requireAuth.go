@@ -4,6 +4,7 @@ import (
 	"strconv"
 	"time"
 
+	dbFunc "business-connect/database/dbHelpFunc"
 	myjwt "business-connect/middleware/myjwt"
 
 	"github.com/gofiber/fiber/v2"
@@ -163,3 +164,43 @@ func WebRequireAuth(ctx *fiber.Ctx) error {
 	// continue
 	return ctx.Next()
 }
+
+// SignedInUser returns the user a request's auth cookie belongs to, for
+// routes anyone may call that remember who did when it is known. Nothing is
+// refreshed, an expired token is the same as none.
+func SignedInUser(ctx *fiber.Ctx) (uint, bool) {
+	authCookie := ctx.Cookies("__BusinessConnect-Auth-Token")
+	if authCookie == "" {
+		return 0, false
+	}
+	uuid, err := myjwt.GrabUUID(authCookie)
+	if err != nil {
+		return 0, false
+	}
+	userID, err := strconv.ParseUint(uuid, 10, 64)
+	if err != nil {
+		return 0, false
+	}
+	return uint(userID), true
+}
+
+// RequireAdmin must run after WebRequireAuth, it only lets ADMIN users through
+func RequireAdmin(users dbFunc.UserRepo) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		userId := ctx.Locals("user-id")
+		if userId == nil {
+			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+		}
+
+		user, err := users.FindByUuidFromLocal(userId)
+		if err != nil {
+			return ctx.Status(fiber.StatusUnauthorized).JSON(fiber.Map{"error": "unauthorized"})
+		}
+
+		if user.UserType != "ADMIN" {
+			return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{"error": "Forbidden"})
+		}
+
+		return ctx.Next()
+	}
+}
@@ -27,8 +27,9 @@ type User struct {
 	FullName        string `json:"full_name" gorm:"size:100;not null"`
 	BusinessName    string `json:"business_name" gorm:"size:100;not null"`
 	BioDescription  string `json:"bio_description" gorm:"size:100;not null"`
-	Email           string `json:"email" gorm:"uniqueIndex;not null"`
-	Password        string `json:"-"` // store HASHED password only
+	Email           string `json:"email" gorm:"size:191;uniqueIndex;not null"`
+	PendingEmail    string `json:"pending_email"` // new email waiting for OTP verification
+	Password        string `json:"-"`             // store HASHED password only
 	PhoneNumber     string `json:"phone_number" gorm:"size:15;index"`
 	ProfilePhotoURL string `json:"profile_photo_url"`
 	CoverPhotoURL   string `json:"cover_photo_url"`
@@ -40,8 +41,8 @@ type User struct {
 	State         string  `json:"state"`
 	Country       string  `json:"country"`
 	Language      string  `json:"language"`
-	Longitude     float64 `json:"longitude"`
-	Latitude      float64 `json:"latitude"`
+	Longitude     float64 `json:"longitude" gorm:"index:idx_users_coordinates,priority:2"`
+	Latitude      float64 `json:"latitude" gorm:"index:idx_users_coordinates,priority:1"`
 
 	// 🔥 Connections
 	ConnectionsCount int64 `json:"connections_count" gorm:"default:0"`
@@ -63,6 +64,76 @@ type User struct {
 	Images []ProfileImage `json:"images,omitempty" gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE"`
 }
 
+// LoginAttempt tracks failed sign-in attempts for one email address or one
+// client IP so we can back off and temporarily lock the account.
+type LoginAttempt struct {
+	gorm.Model
+	AttemptKey   string `json:"attempt_key" gorm:"size:255;uniqueIndex;not null"` // email:<address> | ip:<address>
+	Email        string `json:"email" gorm:"size:255;index"`
+	Failures     int64  `json:"failures" gorm:"default:0"`
+	LockCount    int64  `json:"lock_count" gorm:"default:0"`
+	LastFailedAt int64  `json:"last_failed_at"`
+	LockedUntil  int64  `json:"locked_until" gorm:"index"`
+	UnlockToken  string `json:"-"` // store HASHED unlock token only
+	// the unlock token stops working after this
+	UnlockTokenExpiresAt int64 `json:"-"`
+}
+
+// UserIdentity links a User to an account at an OpenID Connect provider
+type UserIdentity struct {
+	gorm.Model
+	UserID   uint   `json:"user_id" gorm:"index"`
+	Provider string `json:"provider" gorm:"size:20;uniqueIndex:idx_provider_subject"` // google | apple | mock
+	Subject  string `json:"subject" gorm:"size:255;uniqueIndex:idx_provider_subject"`
+	Email    string `json:"email"`
+	// when the provider last made the user sign in again at our request,
+	// 0 once that was used to confirm a change
+	ReauthenticatedAt int64 `json:"-"`
+}
+
+// OIDCLoginState holds the state, nonce and PKCE verifier of a social login
+// between the redirect to the provider and its callback
+type OIDCLoginState struct {
+	gorm.Model
+	State        string `gorm:"size:64;uniqueIndex;not null"`
+	Provider     string `gorm:"size:20"`
+	Nonce        string `gorm:"size:64"`
+	CodeVerifier string `gorm:"size:128"`
+	RedirectTo   string
+	ExpiresAt    int64 `gorm:"index"`
+	// the signed in user asked to prove it's them again
+	Reauth bool
+}
+
+// AccountDeletion is a pending request to delete a user. The account is
+// purged once ScheduledFor passes unless the user cancels first.
+type AccountDeletion struct {
+	gorm.Model
+	UserID       uint   `json:"user_id" gorm:"uniqueIndex"`
+	Email        string `json:"email" gorm:"size:255"`
+	ScheduledFor int64  `json:"scheduled_for" gorm:"index"`
+	CancelToken  string `json:"-"` // store HASHED cancel token only
+}
+
+// Job is one unit of background work, an email or SMS to send or an AI call
+// to make. Workers claim pending jobs whose RunAt has passed, a failed one is
+// tried again later until MaxAttempts, then left as dead for an admin to
+// look at and retry.
+type Job struct {
+	gorm.Model
+	Type        string `json:"type" gorm:"size:64;index"`
+	Payload     string `json:"payload" gorm:"type:text"`
+	Status      string `json:"status" gorm:"size:16;index:idx_jobs_status_run_at;default:'pending'"` // pending | running | done | dead
+	Attempts    int64  `json:"attempts" gorm:"default:0"`
+	MaxAttempts int64  `json:"max_attempts" gorm:"default:5"`
+	RunAt       int64  `json:"run_at" gorm:"index:idx_jobs_status_run_at"`
+	LockedUntil int64  `json:"locked_until"`
+	LockedBy    string `json:"locked_by" gorm:"size:64"` // the worker process that claimed it last
+	LastError   string `json:"last_error" gorm:"type:text"`
+	Result      string `json:"result" gorm:"type:text"`
+	FinishedAt  int64  `json:"finished_at"`
+}
+
 type Connection struct {
 	gorm.Model
 	UserID          uint   `json:"user_id" gorm:"index"`                    // who initiated the connection
@@ -73,8 +144,120 @@ type Connection struct {
 type ProfileImage struct {
 	gorm.Model
 	UserID           uint   `json:"user_id"`
+	Kind             string `json:"kind" gorm:"size:20;default:profile"` // profile | cover
 	URL              string `json:"url" gorm:"column:url"`
 	OriginalFilename string `json:"original_file_name" gorm:"column:original_file_name"`
+	ImageVariants
+}
+
+// ImageVariants are the sizes an uploaded image is stored in besides URL,
+// which is the full size JPEG. Feeds pick the size they show, thumb for
+// grids, feed for the timeline and full for the single post. Images stored
+// before there were variants have none and only URL.
+type ImageVariants struct {
+	Width        int    `json:"width"`
+	Height       int    `json:"height"`
+	FullWebpURL  string `json:"full_webp_url" gorm:"size:512"`
+	FeedURL      string `json:"feed_url" gorm:"size:512"`
+	FeedWebpURL  string `json:"feed_webp_url" gorm:"size:512"`
+	ThumbURL     string `json:"thumb_url" gorm:"size:512"`
+	ThumbWebpURL string `json:"thumb_webp_url" gorm:"size:512"`
+	// every variant together, counted against the owner's storage quota
+	Bytes int64 `json:"bytes"`
+}
+
+// VideoDetails are set on post media that is a video. URL is then the
+// video as uploaded, PosterURL the full size JPEG of its poster frame and
+// the image variants are the poster's, so feeds show it like an image
+// until it plays. Bytes counts the video and the poster together.
+type VideoDetails struct {
+	ContentType string `json:"content_type,omitempty" gorm:"size:50"`
+	DurationMs  int64  `json:"duration_ms,omitempty"`
+	PosterURL   string `json:"poster_url,omitempty" gorm:"size:512"`
+}
+
+// UploadSession is a direct upload: the client PUTs one image to storage
+// at ObjectKey and confirms, the API then processes it and puts it on the
+// post, blog or profile it was started for. Sessions never confirmed are
+// deleted with whatever was uploaded once they expire.
+type UploadSession struct {
+	gorm.Model
+	// what the client knows the session by
+	Token  string `json:"session_id" gorm:"size:64;uniqueIndex"`
+	UserID uint   `json:"-" gorm:"index"`
+	// post | blog | profile | cover
+	Purpose string `json:"purpose" gorm:"size:20"`
+	// post or blog the image goes on
+	TargetID    uint   `json:"target_id"`
+	ObjectKey   string `json:"-" gorm:"size:512"`
+	Filename    string `json:"filename" gorm:"size:100"`
+	ContentType string `json:"content_type" gorm:"size:50"`
+	// size the client declared, a bigger upload is refused
+	MaxBytes    int64  `json:"max_bytes"`
+	Status      string `json:"status" gorm:"size:20;index"` // pending | confirming | confirmed | failed
+	ExpiresAt   int64  `json:"expires_at" gorm:"index"`
+	ConfirmedAt int64  `json:"confirmed_at"`
+}
+
+// MediaObject records an image that was stored, so objects nothing uses
+// any more can be found and deleted. URL is what the image rows keep,
+// ObjectKeys every variant stored for it.
+type MediaObject struct {
+	gorm.Model
+	URL        string `json:"url" gorm:"size:512;uniqueIndex"`
+	ObjectKeys string `json:"-" gorm:"type:text"` // one key per line
+	Bytes      int64  `json:"bytes"`
+	// when the sweeper last found out whether it is used
+	CheckedAt int64 `json:"checked_at" gorm:"index"`
+	// when it is deleted unless something uses it again, 0 while in use
+	DeleteAfter int64 `json:"delete_after" gorm:"index"`
+}
+
+// SearchTerm is one stemmed term of a post in the search index and how much
+// it counts, see search.Document. The rows of a post are replaced whenever
+// the post is saved.
+type SearchTerm struct {
+	PostID uint   `json:"post_id" gorm:"primaryKey;autoIncrement:false"`
+	Term   string `json:"term" gorm:"primaryKey;size:64;index"`
+	Weight int    `json:"weight"`
+}
+
+// NearbyBusiness is a seller with published business posts near where a
+// nearby query was made. Their coordinates stay private, only how far they
+// are is shown.
+type NearbyBusiness struct {
+	UserID          uint    `json:"user_id"`
+	FullName        string  `json:"full_name"`
+	BusinessName    string  `json:"business_name"`
+	ProfilePhotoURL string  `json:"profile_photo_url"`
+	State           string  `json:"state"`
+	Verified        bool    `json:"verified"`
+	DistanceKm      float64 `json:"distance_km"`
+}
+
+// SearchFacets are how many of the posts a search or listing found have
+// each value of a filter, counted with every other filter applied
+type SearchFacets struct {
+	PostTypes   []FacetCount      `json:"post_types"`
+	Categories  []FacetCount      `json:"categories"`
+	Locations   []FacetCount      `json:"locations"`
+	PriceRanges []PriceRangeCount `json:"price_ranges"`
+	Verified    int64             `json:"verified"`
+	InStock     int64             `json:"in_stock"`
+}
+
+// FacetCount is one value of a filter and how many posts have it
+type FacetCount struct {
+	Value string `json:"value"`
+	Count int64  `json:"count"`
+}
+
+// PriceRangeCount is how many posts cost from Min up to but not including
+// Max, a range without Max has no upper bound
+type PriceRangeCount struct {
+	Min   int64  `json:"min"`
+	Max   *int64 `json:"max,omitempty"`
+	Count int64  `json:"count"`
 }
 
 type SignUpRequest struct {
@@ -119,6 +302,11 @@ type (
 
 		// Location (used by business + event)
 		Location *string `json:"location,omitempty"`
+		// where the post is, for "near me", nil when it isn't known
+		Latitude  *float64 `json:"latitude,omitempty" gorm:"index:idx_posts_coordinates,priority:1"`
+		Longitude *float64 `json:"longitude,omitempty" gorm:"index:idx_posts_coordinates,priority:2"`
+		// how far it is from where a nearby query was made, never stored
+		DistanceKm *float64 `json:"distance_km,omitempty" gorm:"-"`
 
 		// BUSINESS FIELDS
 		BusinessCategory *string `json:"business_category,omitempty"`
@@ -143,6 +331,10 @@ type (
 		PostID           uint   `json:"post_id"`
 		URL              string `json:"url" gorm:"column:url"`
 		OriginalFilename string `json:"original_file_name" gorm:"column:original_file_name"`
+		// image | video
+		Kind string `json:"kind" gorm:"size:10;default:image"`
+		ImageVariants
+		VideoDetails
 	}
 	GroupParticipant struct {
 		gorm.Model
@@ -354,6 +546,7 @@ type (
 		BlogID           uint   `json:"blog_id"`
 		URL              string `json:"url" gorm:"column:url"`
 		OriginalFilename string `json:"original_file_name" gorm:"column:original_file_name"`
+		ImageVariants
 	}
 )
 
@@ -367,6 +560,10 @@ type (
 		Subject string `json:"subject"`
 		Content string `json:"content" gorm:"type:text"`
 		SendTo  string `json:"send_to"`
+		// newsletter progress, the id of the last subscriber it went to and
+		// when the last one was sent (0 while sending)
+		LastSubscriberID uint  `json:"last_subscriber_id" gorm:"default:0"`
+		CompletedAt      int64 `json:"completed_at" gorm:"default:0;index"`
 	}
 )
 
@@ -494,6 +691,7 @@ type BusinessConnectDeviceFingerprint struct {
 type BusinessConnectUserActivity struct {
 	gorm.Model
 	FingerprintHash    string    `gorm:"size:64;index" json:"fingerprint_hash"` // Link to the device
+	UserID             uint      `gorm:"index" json:"-"`                        // Signed in user on the device, 0 when nobody was
 	ActivityType       string    `json:"activity_type"`                         // "search", "click", "view", etc.
 	ClickCount         uint      `json:"click_count"`                           // Increment this field for clicks
 	ProductID          uint      `json:"product_id"`                            // Optional: for product-specific activities
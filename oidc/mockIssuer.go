@@ -0,0 +1,241 @@
+package oidc
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+)
+
+// MockIssuer is a tiny OpenID Connect provider for local development and
+// integration tests. It approves every login straight away: the email of the
+// signed in user comes from the login_hint query parameter (default
+// mock.user@example.com) and email_verified from email_verified=false|true.
+//
+// Point the API at it with
+//
+//	OIDC_MOCK_ISSUER=http://localhost:9999
+//	OIDC_MOCK_CLIENT_ID=mock-client
+//
+// With ClientKey set it checks the client secret the way Apple does: an
+// ES256 JWT for the client, signed with the key ClientKey is the public half of.
+type MockIssuer struct {
+	Issuer    string
+	ClientKey *ecdsa.PublicKey
+
+	key   *rsa.PrivateKey
+	mu    sync.Mutex
+	codes map[string]mockGrant
+}
+
+type mockGrant struct {
+	ClientID      string
+	RedirectURI   string
+	Challenge     string
+	Nonce         string
+	Email         string
+	EmailVerified bool
+	// set when max_age was asked for, the mock signs the user in every time
+	AuthTime int64
+}
+
+const mockKid = "mock-key"
+
+// NewMockIssuer creates a mock issuer whose URLs start with issuer
+func NewMockIssuer(issuer string) (*MockIssuer, error) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		return nil, err
+	}
+
+	return &MockIssuer{
+		Issuer: strings.TrimRight(issuer, "/"),
+		key:    key,
+		codes:  map[string]mockGrant{},
+	}, nil
+}
+
+// Handler serves the discovery document, authorize, token and jwks endpoints
+func (m *MockIssuer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", m.discovery)
+	mux.HandleFunc("/authorize", m.authorize)
+	mux.HandleFunc("/token", m.token)
+	mux.HandleFunc("/jwks", m.jwks)
+	return mux
+}
+
+func (m *MockIssuer) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, discoveryDocument{
+		Issuer:                m.Issuer,
+		AuthorizationEndpoint: m.Issuer + "/authorize",
+		TokenEndpoint:         m.Issuer + "/token",
+		JwksURI:               m.Issuer + "/jwks",
+	})
+}
+
+func (m *MockIssuer) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	if q.Get("response_type") != "code" || q.Get("code_challenge_method") != "S256" || q.Get("code_challenge") == "" {
+		http.Error(w, "authorization code flow with S256 PKCE required", http.StatusBadRequest)
+		return
+	}
+
+	email := q.Get("login_hint")
+	if email == "" {
+		email = "mock.user@example.com"
+	}
+
+	grant := mockGrant{
+		ClientID:      q.Get("client_id"),
+		RedirectURI:   q.Get("redirect_uri"),
+		Challenge:     q.Get("code_challenge"),
+		Nonce:         q.Get("nonce"),
+		Email:         email,
+		EmailVerified: q.Get("email_verified") != "false",
+	}
+	if q.Get("max_age") != "" {
+		grant.AuthTime = time.Now().Unix()
+	}
+
+	code := randomString()
+	m.mu.Lock()
+	m.codes[code] = grant
+	m.mu.Unlock()
+
+	redirect, err := url.Parse(q.Get("redirect_uri"))
+	if err != nil {
+		http.Error(w, "invalid redirect_uri", http.StatusBadRequest)
+		return
+	}
+	values := redirect.Query()
+	values.Set("code", code)
+	values.Set("state", q.Get("state"))
+	redirect.RawQuery = values.Encode()
+
+	http.Redirect(w, r, redirect.String(), http.StatusFound)
+}
+
+func (m *MockIssuer) token(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_request"})
+		return
+	}
+
+	code := r.PostForm.Get("code")
+	m.mu.Lock()
+	grant, ok := m.codes[code]
+	delete(m.codes, code)
+	m.mu.Unlock()
+
+	if !ok || grant.RedirectURI != r.PostForm.Get("redirect_uri") {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant"})
+		return
+	}
+
+	sum := sha256.Sum256([]byte(r.PostForm.Get("code_verifier")))
+	if base64.RawURLEncoding.EncodeToString(sum[:]) != grant.Challenge {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid_grant", "error_description": "PKCE verification failed"})
+		return
+	}
+
+	clientID := r.PostForm.Get("client_id")
+	if user, _, ok := r.BasicAuth(); ok {
+		clientID, _ = url.QueryUnescape(user)
+	}
+	if clientID != grant.ClientID {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client"})
+		return
+	}
+	if m.ClientKey != nil && !m.validClientSecret(r, clientID) {
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "invalid_client", "error_description": "client secret isn't a valid JWT"})
+		return
+	}
+
+	now := time.Now()
+	claims := Claims{
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    m.Issuer,
+			Subject:   "mock|" + grant.Email,
+			Audience:  jwt.ClaimStrings{grant.ClientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(10 * time.Minute)),
+		},
+		Nonce:         grant.Nonce,
+		Email:         grant.Email,
+		EmailVerified: flexBool(grant.EmailVerified),
+		Name:          strings.Split(grant.Email, "@")[0],
+		AuthTime:      grant.AuthTime,
+	}
+
+	idToken := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	idToken.Header["kid"] = mockKid
+	signed, err := idToken.SignedString(m.key)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "server_error"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"access_token": randomString(),
+		"token_type":   "Bearer",
+		"expires_in":   600,
+		"id_token":     signed,
+	})
+}
+
+// validClientSecret checks the secret is a live JWT for clientID signed with
+// ClientKey
+func (m *MockIssuer) validClientSecret(r *http.Request, clientID string) bool {
+	secret := r.PostForm.Get("client_secret")
+	if _, password, ok := r.BasicAuth(); ok {
+		secret, _ = url.QueryUnescape(password)
+	}
+
+	claims := jwt.MapClaims{}
+	_, err := jwt.ParseWithClaims(secret, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodECDSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		return m.ClientKey, nil
+	})
+	return err == nil && claims["sub"] == clientID && claims.VerifyAudience(m.Issuer, true) && claims["exp"] != nil
+}
+
+func (m *MockIssuer) jwks(w http.ResponseWriter, r *http.Request) {
+	pub := m.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]interface{}{
+		"keys": []map[string]string{{
+			"kty": "RSA",
+			"use": "sig",
+			"alg": "RS256",
+			"kid": mockKid,
+			"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+			"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+		}},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, body interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(body)
+}
+
+func randomString() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
@@ -0,0 +1,373 @@
+package oidc
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v4"
+	"golang.org/x/oauth2"
+
+	config "business-connect/config"
+)
+
+// Provider is one OpenID Connect identity provider (Google, Apple or the
+// local mock issuer) configured from the environment:
+//
+//	OIDC_<NAME>_CLIENT_ID      enables the provider
+//	OIDC_<NAME>_CLIENT_SECRET
+//	OIDC_<NAME>_ISSUER         optional for google and apple
+//	OIDC_<NAME>_TEAM_ID        Apple: the client secret is a JWT signed
+//	OIDC_<NAME>_KEY_ID         with the .p8 key in _PRIVATE_KEY, a fresh
+//	OIDC_<NAME>_PRIVATE_KEY    one for every request
+//	OIDC_REDIRECT_BASE_URL     public base URL of this API, the callback is
+//	                           <base>/auth/oidc/<name>/callback
+type Provider struct {
+	Name         string
+	Issuer       string
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+	Scopes       []string
+
+	// signs the client secret when there is no static one
+	TeamID     string
+	KeyID      string
+	SigningKey *ecdsa.PrivateKey
+
+	// Apple only returns the email when the callback is a form post
+	FormPost bool
+
+	mu        sync.Mutex
+	discovery *discoveryDocument
+	keys      map[string]*rsa.PublicKey
+	keysAt    time.Time
+}
+
+// Claims are the ID token claims we use to find or create a user
+type Claims struct {
+	jwt.RegisteredClaims
+	Nonce         string   `json:"nonce"`
+	Email         string   `json:"email"`
+	EmailVerified flexBool `json:"email_verified"`
+	Name          string   `json:"name"`
+	GivenName     string   `json:"given_name"`
+	FamilyName    string   `json:"family_name"`
+	Picture       string   `json:"picture"`
+	// when the user last signed in at the provider, sent when max_age was
+	// asked for
+	AuthTime int64 `json:"auth_time,omitempty"`
+}
+
+// flexBool accepts both true and "true", Apple sends email_verified as a string
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	*b = flexBool(value == "true")
+	return nil
+}
+
+type discoveryDocument struct {
+	Issuer                string `json:"issuer"`
+	AuthorizationEndpoint string `json:"authorization_endpoint"`
+	TokenEndpoint         string `json:"token_endpoint"`
+	JwksURI               string `json:"jwks_uri"`
+}
+
+var defaultIssuers = map[string]string{
+	"google": "https://accounts.google.com",
+	"apple":  "https://appleid.apple.com",
+}
+
+var httpClient = &http.Client{Timeout: 10 * time.Second}
+
+// how long a signed client secret is accepted, it is only sent once
+const clientSecretValidTime = 5 * time.Minute
+
+// Providers are the enabled providers by name
+type Providers map[string]*Provider
+
+// Get returns the configured provider called name
+func (providers Providers) Get(name string) (*Provider, error) {
+	provider, ok := providers[strings.ToLower(name)]
+	if !ok {
+		return nil, errors.New("unknown or disabled provider")
+	}
+	return provider, nil
+}
+
+// NewProviders builds every provider cfg has a client id for, nothing is
+// fetched from them until the first sign in
+func NewProviders(cfg config.OIDCConfig) Providers {
+	providers := Providers{}
+	for name, settings := range cfg.Providers {
+		issuer := settings.Issuer
+		if issuer == "" {
+			issuer = defaultIssuers[name]
+		}
+		if issuer == "" {
+			continue
+		}
+
+		provider := &Provider{
+			Name:         name,
+			Issuer:       strings.TrimRight(issuer, "/"),
+			ClientID:     settings.ClientID,
+			ClientSecret: settings.ClientSecret,
+			RedirectURL:  cfg.RedirectBaseURL + "/auth/oidc/" + name + "/callback",
+			Scopes:       []string{"openid", "email", "profile"},
+			FormPost:     name == "apple",
+			TeamID:       settings.TeamID,
+			KeyID:        settings.KeyID,
+		}
+		if settings.PrivateKey != "" {
+			key, err := jwt.ParseECPrivateKeyFromPEM([]byte(settings.PrivateKey))
+			if err != nil {
+				slog.Error("oidc: provider disabled, its private key doesn't parse", "provider", name, "error", err)
+				continue
+			}
+			provider.SigningKey = key
+		}
+		providers[name] = provider
+		if name == "apple" {
+			// Apple has no profile scope
+			providers[name].Scopes = []string{"openid", "email", "name"}
+		}
+	}
+	return providers
+}
+
+func (p *Provider) config(ctx context.Context) (*oauth2.Config, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := p.clientSecret()
+	if err != nil {
+		return nil, err
+	}
+
+	return &oauth2.Config{
+		ClientID:     p.ClientID,
+		ClientSecret: secret,
+		RedirectURL:  p.RedirectURL,
+		Scopes:       p.Scopes,
+		Endpoint: oauth2.Endpoint{
+			AuthURL:  doc.AuthorizationEndpoint,
+			TokenURL: doc.TokenEndpoint,
+		},
+	}, nil
+}
+
+// clientSecret is the static secret, or with a signing key a short lived
+// ES256 JWT the way Apple wants it
+func (p *Provider) clientSecret() (string, error) {
+	if p.SigningKey == nil {
+		return p.ClientSecret, nil
+	}
+
+	now := time.Now()
+	token := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+		"iss": p.TeamID,
+		"sub": p.ClientID,
+		"aud": p.Issuer,
+		"iat": now.Unix(),
+		"exp": now.Add(clientSecretValidTime).Unix(),
+	})
+	token.Header["kid"] = p.KeyID
+	secret, err := token.SignedString(p.SigningKey)
+	if err != nil {
+		return "", fmt.Errorf("error signing the client secret: %w", err)
+	}
+	return secret, nil
+}
+
+// AuthCodeURL builds the provider login URL for the authorization code flow
+// with a PKCE S256 challenge derived from verifier. reauth asks the provider
+// to make the user sign in again even when they have a session there.
+func (p *Provider) AuthCodeURL(ctx context.Context, state, nonce, verifier string, reauth bool) (string, error) {
+	conf, err := p.config(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	opts := []oauth2.AuthCodeOption{
+		oauth2.S256ChallengeOption(verifier),
+		oauth2.SetAuthURLParam("nonce", nonce),
+	}
+	if p.FormPost {
+		opts = append(opts, oauth2.SetAuthURLParam("response_mode", "form_post"))
+	}
+	if reauth {
+		opts = append(opts, oauth2.SetAuthURLParam("prompt", "login"), oauth2.SetAuthURLParam("max_age", "0"))
+	}
+
+	return conf.AuthCodeURL(state, opts...), nil
+}
+
+// Exchange swaps the authorization code for tokens and returns the verified
+// ID token claims
+func (p *Provider) Exchange(ctx context.Context, code, verifier, nonce string) (*Claims, error) {
+	conf, err := p.config(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = context.WithValue(ctx, oauth2.HTTPClient, httpClient)
+	token, err := conf.Exchange(ctx, code, oauth2.VerifierOption(verifier))
+	if err != nil {
+		return nil, fmt.Errorf("error exchanging code: %w", err)
+	}
+
+	rawIDToken, ok := token.Extra("id_token").(string)
+	if !ok || rawIDToken == "" {
+		return nil, errors.New("token response has no id_token")
+	}
+
+	return p.Verify(ctx, rawIDToken, nonce)
+}
+
+// Verify checks the ID token signature, issuer, audience, expiry and nonce
+func (p *Provider) Verify(ctx context.Context, rawIDToken, nonce string) (*Claims, error) {
+	doc, err := p.discover(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	claims := &Claims{}
+	_, err = jwt.ParseWithClaims(rawIDToken, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
+		}
+		kid, _ := token.Header["kid"].(string)
+		return p.publicKey(ctx, kid)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid id token: %w", err)
+	}
+
+	if claims.Issuer != doc.Issuer {
+		return nil, errors.New("id token issuer mismatch")
+	}
+	if !claims.VerifyAudience(p.ClientID, true) {
+		return nil, errors.New("id token audience mismatch")
+	}
+	if claims.Nonce != nonce {
+		return nil, errors.New("id token nonce mismatch")
+	}
+	if claims.Subject == "" {
+		return nil, errors.New("id token has no subject")
+	}
+
+	return claims, nil
+}
+
+func (p *Provider) discover(ctx context.Context) (*discoveryDocument, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.discovery != nil {
+		return p.discovery, nil
+	}
+
+	doc := &discoveryDocument{}
+	if err := getJSON(ctx, p.Issuer+"/.well-known/openid-configuration", doc); err != nil {
+		return nil, fmt.Errorf("error loading %s discovery document: %w", p.Name, err)
+	}
+	if doc.AuthorizationEndpoint == "" || doc.TokenEndpoint == "" || doc.JwksURI == "" {
+		return nil, fmt.Errorf("%s discovery document is incomplete", p.Name)
+	}
+
+	p.discovery = doc
+	return doc, nil
+}
+
+// publicKey returns the provider key for kid, refreshing the cached JWKS
+// when the kid is unknown (the provider rotated its keys)
+func (p *Provider) publicKey(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	p.mu.Lock()
+	key, ok := p.keys[kid]
+	fresh := time.Since(p.keysAt) < time.Minute
+	jwksURI := ""
+	if p.discovery != nil {
+		jwksURI = p.discovery.JwksURI
+	}
+	p.mu.Unlock()
+
+	if ok {
+		return key, nil
+	}
+	if fresh {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := getJSON(ctx, jwksURI, &set); err != nil {
+		return nil, fmt.Errorf("error loading %s keys: %w", p.Name, err)
+	}
+
+	keys := map[string]*rsa.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, nErr := base64.RawURLEncoding.DecodeString(k.N)
+		e, eErr := base64.RawURLEncoding.DecodeString(k.E)
+		if nErr != nil || eErr != nil {
+			continue
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	p.mu.Lock()
+	p.keys = keys
+	p.keysAt = time.Now()
+	p.mu.Unlock()
+
+	key, ok = keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("unknown key id %q", kid)
+	}
+	return key, nil
+}
+
+func getJSON(ctx context.Context, url string, target interface{}) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected status %d from %s", resp.StatusCode, url)
+	}
+
+	return json.NewDecoder(resp.Body).Decode(target)
+}
@@ -1,41 +1,33 @@
 package fundAccount
 
 import (
+	config "business-connect/config"
 	"encoding/json"
 	"fmt"
-	"log"
 	"net/http"
-	"os"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/joho/godotenv"
 )
 
-func VerifyAccountNumberPayuee(c *fiber.Ctx) error {
+func VerifyAccountNumberPayuee(cfg config.PaystackConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
 
-	accountNumber := c.Params("accountNumber")
-	bankCode := c.Params("bankCode")
+		accountNumber := c.Params("accountNumber")
+		bankCode := c.Params("bankCode")
 
-	paystackResponse, err := verifyAccountNumberPaystack(accountNumber, bankCode)
-	if err != nil {
-		return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
-			"status":  false,
-			"message": "Error verifying account number",
-		})
-	}
-
-	return c.JSON(paystackResponse)
-}
+		paystackResponse, err := verifyAccountNumberPaystack(cfg.SecretKey, accountNumber, bankCode)
+		if err != nil {
+			return c.Status(http.StatusInternalServerError).JSON(fiber.Map{
+				"status":  false,
+				"message": "Error verifying account number",
+			})
+		}
 
-func verifyAccountNumberPaystack(accountNumber, bankCode string) (fiber.Map, error) {
-
-	envErr := godotenv.Load(".env")
-
-	if envErr != nil {
-		log.Printf("Failed to load .env file: %v\n", envErr)
+		return c.JSON(paystackResponse)
 	}
+}
 
-	SECRET_KEY := os.Getenv("PAYSTACK_LIVE_SECRET_KEY")
+func verifyAccountNumberPaystack(SECRET_KEY, accountNumber, bankCode string) (fiber.Map, error) {
 
 	url := fmt.Sprintf("https://api.paystack.co/bank/resolve?account_number=%s&bank_code=%s", accountNumber, bankCode)
 	authorization := "Bearer " + SECRET_KEY
@@ -1,7 +1,6 @@
 package paystack
 
 import (
-	conn "business-connect/database"
 	Dataa "business-connect/models"
 	"errors"
 	"fmt"
@@ -18,10 +17,17 @@ type PaystackImpl interface {
 }
 
 // Define a struct that implements the interface
-type PaystackHelperImpl struct{}
+type PaystackHelperImpl struct {
+	db *gorm.DB
+}
+
+// NewPaystackHelper returns a helper that runs every query on db
+func NewPaystackHelper(db *gorm.DB) *PaystackHelperImpl {
+	return &PaystackHelperImpl{db: db}
+}
 
-// Create an instance of the struct to use as your Database helper
-var PaystackHelper PaystackImpl = &PaystackHelperImpl{}
+// PaystackHelper is set at startup once the database is open
+var PaystackHelper PaystackImpl
 
 func (d *PaystackHelperImpl) FindByUuidFromLocal(ID interface{}) (user Dataa.User, err error) {
 
@@ -35,7 +41,7 @@ func (d *PaystackHelperImpl) FindByUuidFromLocal(ID interface{}) (user Dataa.Use
 		return Dataa.User{}, errors.New("user id is not a valid string")
 	}
 
-	result := conn.DB.First(&user, "ID = ?", userIdUint)
+	result := d.db.First(&user, "ID = ?", userIdUint)
 
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -66,7 +72,7 @@ func (d *PaystackHelperImpl) FindByUuidFromLocalPaystack(ID interface{}) (user D
 	}
 
 	// Proceed with the uint userIdUint
-	result := conn.DB.First(&user, "ID = ?", userIdUint)
+	result := d.db.First(&user, "ID = ?", userIdUint)
 
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -82,7 +88,7 @@ func (d *PaystackHelperImpl) FindByUuidFromLocalPaystack(ID interface{}) (user D
 }
 
 func (d *PaystackHelperImpl) FindByEmail(Email string) (user Dataa.User, err error) {
-	result := conn.DB.First(&user, "email = ?", Email)
+	result := d.db.First(&user, "email = ?", Email)
 
 	if result.Error != nil {
 		if errors.Is(result.Error, gorm.ErrRecordNotFound) {
@@ -6,11 +6,11 @@ import (
 	"errors"
 	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
-	"os"
 	"regexp"
 
+	config "business-connect/config"
 	// EmailsVer "business-connect/controllers/authentication/emails"
 	helperFunc "business-connect/paystack"
 	// paystackBuyServices "business-connect/paystack/buyServicesPaystack"
@@ -23,33 +23,26 @@ import (
 	"time"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/joho/godotenv"
 )
 
 // Replace with the actual Paystack API endpoint for verification
 const paystackVerifyURL = "https://api.paystack.co/transaction/verify/%s"
 
-func InitializePaystackTransaction(Email string, TransactionID string, Amount int, Metadata Data.ServiceMetaData) (map[string]interface{}, error) {
+func InitializePaystackTransaction(cfg config.PaystackConfig, Email string, TransactionID string, Amount int, Metadata Data.ServiceMetaData) (map[string]interface{}, error) {
 
-	envErr := godotenv.Load(".env")
-
-	if envErr != nil {
-		log.Printf("Failed to load .env file: %v\n", envErr)
-	}
-
-	SECRET_KEY := os.Getenv("PAYSTACK_LIVE_SECRET_KEY")
+	SECRET_KEY := cfg.SecretKey
 	// SECRET_KEY := os.Getenv("PAYSTACK_TEST_SECRET_KEY")
 
 	// let's send the amount in the currency's sub unit to paystack
 	amount := Amount * 100
-	Metadata.CancelAction = "https://shopsphereafrica.com/cancel-transaction.html"
+	Metadata.CancelAction = cfg.CancelURL
 	Metadata.TransactionID = TransactionID
 
 	// fmt.Println("this is the type for the metadata price 1: ", reflect.TypeOf(Metadata.Price))
 
 	method := "POST"
 	// Add a callback URL to the payload
-	callbackURL := "https://shopsphereafrica.com/track-order.html"
+	callbackURL := cfg.CallbackURL
 	payload := map[string]interface{}{
 		"email":        Email,
 		"amount":       strconv.Itoa(amount),
@@ -87,78 +80,74 @@ func InitializePaystackTransaction(Email string, TransactionID string, Amount in
 	return data, nil
 }
 
-func PaystackCallbackHandler(ctx *fiber.Ctx) error {
-	// reference := ctx.Query("reference")
-
-	// referenceErr := helperFunc.PaystackHelper.FindByReference(reference)
-	// if referenceErr == nil {
-	// 	// fmt.Println("response error:", referenceErr)
-	// 	return ctx.Redirect("https://shopsphereafrica.com/successful.html")
-	// }
-
-	// Verify the transaction
-	// verificationResponse, err := VerifyPaystackTransaction(reference)
-	// fmt.Println("response data 1 this is the error message: ", err)
-	// if err != nil {
-	// 	// fmt.Println("response data 2: ")
-	// 	// Handle the error
-	// 	if strings.Contains(err.Error(), "response data is empty") {
-	// 		// Response data is empty, handle accordingly
-	// 		// fmt.Println("response data 3: ")
-	// 		fmt.Println("response error 1:")
-	// 		return ctx.Redirect("https://shopsphereafrica.com/halfSuccessful.html")
-	// 	} else {
-	// 		// Some other error occurred, handle accordingly
-	// 		// fmt.Println("response data 4: ")
-	// 		fmt.Println("response error 2:")
-	// 		return ctx.Redirect("https://shopsphereafrica.com/halfSuccessful.html")
-	// 	}
-	// }
-
-	// get stored user id from request time line
-	// userId := ctx.Locals("user-id")
-	// StringConvertedToUint, stringToUintErr := StringToUint(verificationResponse.Data.Metadata.UserId)
-	// if stringToUintErr != nil {
-	// 	// Handle error
-	// 	fmt.Println("response error string to uint:", stringToUintErr)
-	// 	return ctx.Redirect("https://shopsphereafrica.com/halfSuccessful.html")
-	// }
-
-	// user, uuidErr := helperFunc.PaystackHelper.FindByUuidFromLocalPaystack(verificationResponse.Data.Metadata.UserId)
-	// fmt.Println("this is the user id:", verificationResponse.Data.Metadata.UserId)
-	// if uuidErr != nil {
-	// 	fmt.Println("response error:", uuidErr)
-	// 	return ctx.Redirect("https://shopsphereafrica.com/halfSuccessful.html")
-	// }
-
-	// if !verificationResponse.Status {
-	// 	fmt.Println("response error 4:")
-	// 	return ctx.Redirect("https://shopsphereafrica.com/halfSuccessful.html")
-	// }
-
-	// fmt.Println("response data2: ", verificationResponse)
-	// Transaction successful let's register the user to the database
-	// addTransErr := PaystackSaveToDbCallbackHandler(user, verificationResponse)
-	// if addTransErr != nil {
-	// 	// fmt.Println("error occurring: ", addTransErr)
-	// 	// this only runs when there is an error updating the database
-	// 	fmt.Println("response error 5:")
-	// 	return ctx.Redirect("https://shopsphereafrica.com/halfSuccessful.html")
-	// }
-
-	// Redirect to success page
-	return ctx.Redirect("https://shopsphereafrica.com/track-order.html")
+func PaystackCallbackHandler(cfg config.PaystackConfig) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		// reference := ctx.Query("reference")
+
+		// referenceErr := helperFunc.PaystackHelper.FindByReference(reference)
+		// if referenceErr == nil {
+		// 	// fmt.Println("response error:", referenceErr)
+		// 	return ctx.Redirect("https://shopsphereafrica.com/successful.html")
+		// }
+
+		// Verify the transaction
+		// verificationResponse, err := VerifyPaystackTransaction(reference)
+		// fmt.Println("response data 1 this is the error message: ", err)
+		// if err != nil {
+		// 	// fmt.Println("response data 2: ")
+		// 	// Handle the error
+		// 	if strings.Contains(err.Error(), "response data is empty") {
+		// 		// Response data is empty, handle accordingly
+		// 		// fmt.Println("response data 3: ")
+		// 		fmt.Println("response error 1:")
+		// 		return ctx.Redirect("https://shopsphereafrica.com/halfSuccessful.html")
+		// 	} else {
+		// 		// Some other error occurred, handle accordingly
+		// 		// fmt.Println("response data 4: ")
+		// 		fmt.Println("response error 2:")
+		// 		return ctx.Redirect("https://shopsphereafrica.com/halfSuccessful.html")
+		// 	}
+		// }
+
+		// get stored user id from request time line
+		// userId := ctx.Locals("user-id")
+		// StringConvertedToUint, stringToUintErr := StringToUint(verificationResponse.Data.Metadata.UserId)
+		// if stringToUintErr != nil {
+		// 	// Handle error
+		// 	fmt.Println("response error string to uint:", stringToUintErr)
+		// 	return ctx.Redirect("https://shopsphereafrica.com/halfSuccessful.html")
+		// }
+
+		// user, uuidErr := helperFunc.PaystackHelper.FindByUuidFromLocalPaystack(verificationResponse.Data.Metadata.UserId)
+		// fmt.Println("this is the user id:", verificationResponse.Data.Metadata.UserId)
+		// if uuidErr != nil {
+		// 	fmt.Println("response error:", uuidErr)
+		// 	return ctx.Redirect("https://shopsphereafrica.com/halfSuccessful.html")
+		// }
+
+		// if !verificationResponse.Status {
+		// 	fmt.Println("response error 4:")
+		// 	return ctx.Redirect("https://shopsphereafrica.com/halfSuccessful.html")
+		// }
+
+		// fmt.Println("response data2: ", verificationResponse)
+		// Transaction successful let's register the user to the database
+		// addTransErr := PaystackSaveToDbCallbackHandler(user, verificationResponse)
+		// if addTransErr != nil {
+		// 	// fmt.Println("error occurring: ", addTransErr)
+		// 	// this only runs when there is an error updating the database
+		// 	fmt.Println("response error 5:")
+		// 	return ctx.Redirect("https://shopsphereafrica.com/halfSuccessful.html")
+		// }
+
+		// Redirect to success page
+		return ctx.Redirect(cfg.CallbackURL)
+	}
 }
 
 // Function to verify Paystack transaction
-func VerifyPaystackTransaction(reference string) (helperFunc.PaystackVerificationResponse, error) {
-	envErr := godotenv.Load(".env")
-
-	if envErr != nil {
-		log.Printf("Failed to load .env file: %v\n", envErr)
-	}
-
-	SECRET_KEY := os.Getenv("PAYSTACK_LIVE_SECRET_KEY")
+func VerifyPaystackTransaction(cfg config.PaystackConfig, reference string) (helperFunc.PaystackVerificationResponse, error) {
+	SECRET_KEY := cfg.SecretKey
 
 	url := fmt.Sprintf(paystackVerifyURL, reference)
 
@@ -188,11 +177,9 @@ func VerifyPaystackTransaction(reference string) (helperFunc.PaystackVerificatio
 	key2 := "Amount"
 	key3 := "TranCharge"
 
-	fmt.Println(" this is the response from paystack: ", string(responseBody))
-
 	PaystackJsonStr1, err := ConvertKeyToInt(string(responseBody), key)
 	if err != nil {
-		fmt.Println("Error converting key value to int:", err)
+		slog.Error("error converting key value to int", "error", err)
 		return helperFunc.PaystackVerificationResponse{}, err
 	}
 
@@ -217,7 +204,7 @@ func VerifyPaystackTransaction(reference string) (helperFunc.PaystackVerificatio
 
 	// fmt.Println("response data here 7: ")
 	if err != nil {
-		fmt.Println("Error un-marshaling Paystack response:", err)
+		slog.Error("error un-marshaling Paystack response", "error", err)
 		return helperFunc.PaystackVerificationResponse{}, err
 	}
 
@@ -436,19 +423,18 @@ func ConvertKeyToInt(responseBody string, key string) (string, error) {
 	match := re.FindStringSubmatch(responseBody)
 	if match == nil {
 		// Key not found, handle the error
-		fmt.Println("Key not found in JSON.")
+		slog.Debug("key not found in the paystack response", "key", key)
 		return "", fmt.Errorf("key not found: %s", key)
 	}
 
 	// Extract the value associated with the key
 	valueStr := match[1]
-	fmt.Println("Original value:", valueStr)
 
 	// Convert the value to an integer
 	valueInt, err := strconv.Atoi(valueStr)
 	if err != nil {
 		// Conversion error, handle the error
-		fmt.Println("Error converting value to int:", err)
+		slog.Error("error converting value to int", "error", err)
 		return "", err
 	}
 
@@ -466,19 +452,18 @@ func ConvertToBool(responseBody, boolValue string) (string, error) {
 	match := re.FindStringSubmatch(responseBody)
 	if match == nil {
 		// Key not found, handle the error
-		fmt.Println("AutoRenew key not found in JSON.")
+		slog.Debug("key not found in the paystack response", "key", boolValue)
 		return "", fmt.Errorf("AutoRenew key not found")
 	}
 
 	// Extract the value associated with the "AutoRenew" key
 	valueStr := match[1]
-	fmt.Println("Original value:", valueStr)
 
 	// Convert the value to a bool
 	valueBool, err := strconv.ParseBool(valueStr)
 	if err != nil {
 		// Conversion error, handle the error
-		fmt.Println("Error converting value to bool:", err)
+		slog.Error("error converting value to bool", "error", err)
 		// return "", err
 	}
 
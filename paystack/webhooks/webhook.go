@@ -4,6 +4,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"log/slog"
 	"regexp"
 	"strconv"
 
@@ -13,6 +14,8 @@ import (
 	// EmailsVer "business-connect/controllers/authentication/emails"
 	SendEmail "business-connect/controllers/authentication/emails"
 	dbFunc "business-connect/database/dbHelpFunc"
+	"business-connect/logger"
+	"business-connect/metrics"
 
 	// conn "business-connect/database"
 	// Dataa "business-connect/models"
@@ -28,186 +31,173 @@ import (
 )
 
 // Webhook for getting transaction status
-func WebHookStatus(ctx *fiber.Ctx) error {
-	// Read the request body
-	fmt.Println(" this is the response from paystack web hook origin: ", ctx.Get("Origin"))
-	responseBody := ctx.Body()
-
-	// Key to be converted to an int
-	key := "price"
-	// key2 := "Amount"
-	// key3 := "TranCharge"
-	// key4 := "AutoRenew"
-	// key5 := "TransNumb"
-
-	fmt.Println(" this is the response from paystack: ", string(responseBody))
-
-	PaystackJsonStr1, err := ConvertKeyToIntSafe(responseBody, key)
-	// PaystackJsonStr1, err := ConvertKeyToInt(string(responseBody), key)
-	if err != nil {
-		fmt.Println("Error converting key value to int:", err)
+func WebHookStatus(orders dbFunc.OrderRepo) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		// Read the request body, it holds the customer's details so it is never logged
+		responseBody := ctx.Body()
+
+		// Key to be converted to an int
+		key := "price"
+		// key2 := "Amount"
+		// key3 := "TranCharge"
+		// key4 := "AutoRenew"
+		// key5 := "TransNumb"
+
+		PaystackJsonStr1, err := ConvertKeyToIntSafe(responseBody, key)
+		// PaystackJsonStr1, err := ConvertKeyToInt(string(responseBody), key)
+		if err != nil {
+			logger.Ctx(ctx).Error("error converting key value to int", "error", err)
+			// return err
+		}
+
+		// PaystackJsonStr2, err := ConvertKeyToInt(PaystackJsonStr1, key2)
+		// if err != nil {
 		// return err
-	}
+		// }
 
-	// PaystackJsonStr2, err := ConvertKeyToInt(PaystackJsonStr1, key2)
-	// if err != nil {
-	// return err
-	// }
-
-	// PaystackJsonStr3, err := ConvertKeyToInt(PaystackJsonStr2, key3)
-	// if err != nil {
-	// 	// return err
-	// }
-
-	// PaystackJsonStr4, err := ConvertToBool(PaystackJsonStr3, key4)
-	// if err != nil {
-	// 	// return err
-	// }
-
-	// PaystackJsonStr5, err := ConvertKeyToUint(PaystackJsonStr4, key5)
-	// if err != nil {
-	// 	// return err
-	// }
-
-	transactionType := "empty"
-
-	// Attempt to unmarshal into WebhookData
-	var webhookData WebhookData
-	err1 := json.Unmarshal([]byte(PaystackJsonStr1), &webhookData)
-	if err1 == nil {
-		transactionType = "charge.success"
-		// Successfully unmarshaled into WebhookData
-		fmt.Println("Parsed using WebhookData:", webhookData)
-		// return nil
-	}
+		// PaystackJsonStr3, err := ConvertKeyToInt(PaystackJsonStr2, key3)
+		// if err != nil {
+		// 	// return err
+		// }
 
-	if err1 != nil {
-		fmt.Println("transaction type err 1 :-----------------------------------------:", err1)
-	}
+		// PaystackJsonStr4, err := ConvertToBool(PaystackJsonStr3, key4)
+		// if err != nil {
+		// 	// return err
+		// }
+
+		// PaystackJsonStr5, err := ConvertKeyToUint(PaystackJsonStr4, key5)
+		// if err != nil {
+		// 	// return err
+		// }
+
+		// Attempt to unmarshal into WebhookData
+		var webhookData WebhookData
+		err1 := json.Unmarshal([]byte(PaystackJsonStr1), &webhookData)
+		if err1 != nil {
+			logger.Ctx(ctx).Error("error parsing paystack webhook", "error", err1)
+		}
 
-	fmt.Println("transaction type 0 :-----------------------------------------:", transactionType)
-
-	// Attempt to unmarshal into helperFunc.TransferEventPayload
-	// var webhookDataTF helperFunc.TransferEventPayload
-	// err2 := json.Unmarshal(responseBody, &webhookDataTF)
-	// if err2 == nil {
-	// 	transactionType = "transfer.success"
-	// 	// Successfully unmarshaled into TransferEventPayload
-	// 	fmt.Println("Parsed using TransferEventPayload:", webhookDataTF)
-	// 	// return nil
-	// }
-
-	// if err2 != nil {
-	// 	fmt.Println("transaction type err 2 :-----------------------------------------:", err2)
-	// }
-
-	fmt.Println("transaction type 1 :-----------------------------------------:", transactionType)
-	// Print the raw JSON data
-	fmt.Println("Raw WebHook Data:", PaystackJsonStr1)
-
-	fmt.Println("Parsed WebHook Data:", string(responseBody))
-
-	// if transactionType == "charge.success" {
-	fmt.Println("transaction type 01 :-----------------------------------------:", transactionType)
-	// Check the type of event
-	switch webhookData.Event {
-	case "charge.success":
-		fmt.Println("Charge successful event")
-		// Access data specific to charge.success event
-		// fmt.Println("WebHook Data:", webhookData)
-		// fmt.Println("Amount:", webhookData.Data.Amount)
-		// fmt.Println("Recipient Reference:", webhookData.Data.Reference)
-		// user, uuidErr := helperFunc.PaystackHelper.FindByUuidFromLocalPaystack(webhookData.Data.Metadata.TransactionID)
-		// // fmt.Println("this is the user id:", webhookData.Data.Metadata.UserId)
-		// if uuidErr != nil {
-		// 	fmt.Println("response error: ", uuidErr)
-		// 	return ctx.SendStatus(http.StatusNotAcceptable)
+		// Attempt to unmarshal into helperFunc.TransferEventPayload
+		// var webhookDataTF helperFunc.TransferEventPayload
+		// err2 := json.Unmarshal(responseBody, &webhookDataTF)
+		// if err2 == nil {
+		// 	transactionType = "transfer.success"
+		// 	// Successfully unmarshaled into TransferEventPayload
+		// 	fmt.Println("Parsed using TransferEventPayload:", webhookDataTF)
+		// 	// return nil
 		// }
-		PaystackWebHookSaveToDbCallbackHandler(webhookData)
-		// ... (access other fields as needed)
-	default:
-		fmt.Println("Unknown event")
+
+		// if err2 != nil {
+		// 	fmt.Println("transaction type err 2 :-----------------------------------------:", err2)
+		// }
+
+		log := logger.Ctx(ctx).With("event", webhookData.Event, "reference", webhookData.Data.Reference)
+		log.Info("paystack webhook received")
+
+		// if transactionType == "charge.success" {
+		// Check the type of event
+		switch webhookData.Event {
+		case "charge.success":
+			// Access data specific to charge.success event
+			// fmt.Println("WebHook Data:", webhookData)
+			// fmt.Println("Amount:", webhookData.Data.Amount)
+			// fmt.Println("Recipient Reference:", webhookData.Data.Reference)
+			// user, uuidErr := helperFunc.PaystackHelper.FindByUuidFromLocalPaystack(webhookData.Data.Metadata.TransactionID)
+			// // fmt.Println("this is the user id:", webhookData.Data.Metadata.UserId)
+			// if uuidErr != nil {
+			// 	fmt.Println("response error: ", uuidErr)
+			// 	return ctx.SendStatus(http.StatusNotAcceptable)
+			// }
+			if saveErr := PaystackWebHookSaveToDbCallbackHandler(orders, webhookData); saveErr != nil {
+				log.Error("error saving paystack charge", "error", saveErr)
+				metrics.PaystackWebhook(webhookData.Event, "failed")
+			} else {
+				metrics.PaystackWebhook(webhookData.Event, "processed")
+			}
+			// ... (access other fields as needed)
+		default:
+			log.Warn("unknown paystack webhook event")
+			metrics.PaystackWebhook(webhookData.Event, "ignored")
+		}
+		// } else if transactionType == "transfer.success" {
+		// 	// Check the type of event
+		// 	switch webhookDataTF.EventName {
+		// 	case "transfer.success":
+		// 		// fmt.Println("Started the transfer")
+
+		// 		// fmt.Println("User ID:", webhookDataTF.EventData.RecipientData.Metadata.UserId)
+
+		// 		user, uuidErr := helperFunc.PaystackHelper.FindByUuidFromLocalPaystack(webhookDataTF.EventData.RecipientData.Metadata.UserId)
+		// 		if uuidErr != nil {
+		// 			fmt.Println("Response error:", uuidErr)
+		// 			return ctx.SendStatus(http.StatusNotAcceptable)
+		// 		}
+
+		// 		// fmt.Println("Transfer successful event")
+		// 		// fmt.Println("Webhook Data:", webhookDataTF)
+		// 		// fmt.Println("Amount:", webhookDataTF.EventData.RecipientData.Metadata.Amount)
+		// 		// fmt.Println("Recipient Name:", webhookDataTF.EventData.TransactionRef)
+
+		// 		addSendFundErr := PayueeHelper.PaystackHelper.AddSendFundsSuccessTransactionHistoryP(user, webhookDataTF, webhookDataTF.EventData.RecipientData.Metadata.TransNumb)
+		// 		if addSendFundErr != nil {
+		// 			return errors.New("an error occurred while sending funds")
+		// 		}
+
+		// 		emailErr := EmailsVer.SendFundsConfirmationEmail(
+		// 			user.FirstName+" "+user.LastName,
+		// 			user.Email,
+		// 			webhookDataTF.EventData.RecipientData.Metadata.AccountName,
+		// 			webhookDataTF.EventData.RecipientData.Metadata.Bank,
+		// 			webhookDataTF.EventData.RecipientData.Metadata.AccountNumber,
+		// 			"₦"+strconv.Itoa(webhookDataTF.EventData.RecipientData.Metadata.Amount),
+		// 			"₦"+strconv.Itoa(int(user.WalletBalance)),
+		// 			"₦"+strconv.Itoa(int(user.WalletBalance)+webhookDataTF.EventData.RecipientData.Metadata.Amount),
+		// 		)
+
+		// 		if emailErr != nil {
+		// 			return errors.New("an error occurred while sending verification email for fund wallet")
+		// 		}
+
+		// 	case "transfer.failed":
+		// 		// fmt.Println("Started the transfer")
+
+		// 		// fmt.Println("User ID:", webhookDataTF.EventData.RecipientData.Metadata.UserId)
+
+		// 		user, uuidErr := helperFunc.PaystackHelper.FindByUuidFromLocalPaystack(webhookDataTF.EventData.RecipientData.Metadata.UserId)
+		// 		if uuidErr != nil {
+		// 			fmt.Println("Response error:", uuidErr)
+		// 			return ctx.SendStatus(http.StatusNotAcceptable)
+		// 		}
+
+		// 		addSendFundErr := PayueeHelper.PaystackHelper.AddSendFundsFailedTransactionHistoryWP(user, webhookDataTF, webhookDataTF.EventData.RecipientData.Metadata.TransNumb)
+		// 		if addSendFundErr != nil {
+		// 			return errors.New("an error occurred while sending funds")
+		// 		}
+		// 	case "transfer.reversed":
+		// 		fmt.Println("Transfer reversed event")
+		// 		// Access data specific to transfer.reversed event
+		// 		fmt.Println("Amount:", webhookData.Data.Amount)
+		// 		// fmt.Println("Started the transfer")
+
+		// 		// fmt.Println("User ID:", webhookDataTF.EventData.RecipientData.Metadata.UserId)
+
+		// 		user, uuidErr := helperFunc.PaystackHelper.FindByUuidFromLocalPaystack(webhookDataTF.EventData.RecipientData.Metadata.UserId)
+		// 		if uuidErr != nil {
+		// 			fmt.Println("Response error:", uuidErr)
+		// 			return ctx.SendStatus(http.StatusNotAcceptable)
+		// 		}
+
+		// 		addSendFundErr := PayueeHelper.PaystackHelper.AddSendFundsFailedTransactionHistoryWP(user, webhookDataTF, webhookDataTF.EventData.RecipientData.Metadata.TransNumb)
+		// 		if addSendFundErr != nil {
+		// 			return errors.New("an error occurred while sending funds")
+		// 		}
+		// 	default:
+		// 		fmt.Println("Unknown event")
+		// 	}
+		// }
+
+		return ctx.SendStatus(http.StatusOK)
 	}
-	// } else if transactionType == "transfer.success" {
-	// 	// Check the type of event
-	// 	switch webhookDataTF.EventName {
-	// 	case "transfer.success":
-	// 		// fmt.Println("Started the transfer")
-
-	// 		// fmt.Println("User ID:", webhookDataTF.EventData.RecipientData.Metadata.UserId)
-
-	// 		user, uuidErr := helperFunc.PaystackHelper.FindByUuidFromLocalPaystack(webhookDataTF.EventData.RecipientData.Metadata.UserId)
-	// 		if uuidErr != nil {
-	// 			fmt.Println("Response error:", uuidErr)
-	// 			return ctx.SendStatus(http.StatusNotAcceptable)
-	// 		}
-
-	// 		// fmt.Println("Transfer successful event")
-	// 		// fmt.Println("Webhook Data:", webhookDataTF)
-	// 		// fmt.Println("Amount:", webhookDataTF.EventData.RecipientData.Metadata.Amount)
-	// 		// fmt.Println("Recipient Name:", webhookDataTF.EventData.TransactionRef)
-
-	// 		addSendFundErr := PayueeHelper.PaystackHelper.AddSendFundsSuccessTransactionHistoryP(user, webhookDataTF, webhookDataTF.EventData.RecipientData.Metadata.TransNumb)
-	// 		if addSendFundErr != nil {
-	// 			return errors.New("an error occurred while sending funds")
-	// 		}
-
-	// 		emailErr := EmailsVer.SendFundsConfirmationEmail(
-	// 			user.FirstName+" "+user.LastName,
-	// 			user.Email,
-	// 			webhookDataTF.EventData.RecipientData.Metadata.AccountName,
-	// 			webhookDataTF.EventData.RecipientData.Metadata.Bank,
-	// 			webhookDataTF.EventData.RecipientData.Metadata.AccountNumber,
-	// 			"₦"+strconv.Itoa(webhookDataTF.EventData.RecipientData.Metadata.Amount),
-	// 			"₦"+strconv.Itoa(int(user.WalletBalance)),
-	// 			"₦"+strconv.Itoa(int(user.WalletBalance)+webhookDataTF.EventData.RecipientData.Metadata.Amount),
-	// 		)
-
-	// 		if emailErr != nil {
-	// 			return errors.New("an error occurred while sending verification email for fund wallet")
-	// 		}
-
-	// 	case "transfer.failed":
-	// 		// fmt.Println("Started the transfer")
-
-	// 		// fmt.Println("User ID:", webhookDataTF.EventData.RecipientData.Metadata.UserId)
-
-	// 		user, uuidErr := helperFunc.PaystackHelper.FindByUuidFromLocalPaystack(webhookDataTF.EventData.RecipientData.Metadata.UserId)
-	// 		if uuidErr != nil {
-	// 			fmt.Println("Response error:", uuidErr)
-	// 			return ctx.SendStatus(http.StatusNotAcceptable)
-	// 		}
-
-	// 		addSendFundErr := PayueeHelper.PaystackHelper.AddSendFundsFailedTransactionHistoryWP(user, webhookDataTF, webhookDataTF.EventData.RecipientData.Metadata.TransNumb)
-	// 		if addSendFundErr != nil {
-	// 			return errors.New("an error occurred while sending funds")
-	// 		}
-	// 	case "transfer.reversed":
-	// 		fmt.Println("Transfer reversed event")
-	// 		// Access data specific to transfer.reversed event
-	// 		fmt.Println("Amount:", webhookData.Data.Amount)
-	// 		// fmt.Println("Started the transfer")
-
-	// 		// fmt.Println("User ID:", webhookDataTF.EventData.RecipientData.Metadata.UserId)
-
-	// 		user, uuidErr := helperFunc.PaystackHelper.FindByUuidFromLocalPaystack(webhookDataTF.EventData.RecipientData.Metadata.UserId)
-	// 		if uuidErr != nil {
-	// 			fmt.Println("Response error:", uuidErr)
-	// 			return ctx.SendStatus(http.StatusNotAcceptable)
-	// 		}
-
-	// 		addSendFundErr := PayueeHelper.PaystackHelper.AddSendFundsFailedTransactionHistoryWP(user, webhookDataTF, webhookDataTF.EventData.RecipientData.Metadata.TransNumb)
-	// 		if addSendFundErr != nil {
-	// 			return errors.New("an error occurred while sending funds")
-	// 		}
-	// 	default:
-	// 		fmt.Println("Unknown event")
-	// 	}
-	// }
-
-	fmt.Println("transaction type 2 :-----------------------------------------:", transactionType)
-
-	return ctx.SendStatus(http.StatusOK)
 }
 
 // ConvertKeyToInt converts a specified key's value to an integer if it's a string.
@@ -221,13 +211,13 @@ func ConvertKeyToInt2(responseBody string, key string) (string, error) {
 	// Check if the key exists
 	value, exists := jsonData[key]
 	if !exists {
-		fmt.Printf("Key %s not found in the response, skipping conversion.\n", key)
+		slog.Debug("key not found in the response, skipping conversion", "key", key)
 		return responseBody, nil // Return unchanged JSON
 	}
 
 	// If the value is already an integer, skip conversion
 	if _, ok := value.(float64); ok {
-		fmt.Printf("Key %s is already an integer, skipping conversion.\n", key)
+		slog.Debug("key is already an integer, skipping conversion", "key", key)
 		return responseBody, nil // Return unchanged JSON
 	}
 
@@ -271,13 +261,13 @@ func ConvertNestedKeyToInt(responseBody string, nestedKeys ...string) (string, e
 			// Check if the key exists
 			value, exists := current[key]
 			if !exists {
-				fmt.Printf("Key %s not found in the response, skipping conversion.\n", key)
+				slog.Debug("key not found in the response, skipping conversion", "key", key)
 				return responseBody, nil // Return unchanged JSON
 			}
 
 			// If the value is already an integer, skip conversion
 			if _, ok := value.(float64); ok {
-				fmt.Printf("Key %s is already an integer, skipping conversion.\n", key)
+				slog.Debug("key is already an integer, skipping conversion", "key", key)
 				return responseBody, nil // Return unchanged JSON
 			}
 
@@ -321,13 +311,13 @@ func ConvertToBool2(responseBody, boolKey string) (string, error) {
 	// Check if the key exists
 	value, exists := jsonData[boolKey]
 	if !exists {
-		fmt.Printf("Key %s not found in the response, skipping conversion.\n", boolKey)
+		slog.Debug("key not found in the response, skipping conversion", "key", boolKey)
 		return responseBody, nil // Return unchanged JSON
 	}
 
 	// If the value is already a boolean, skip conversion
 	if _, ok := value.(bool); ok {
-		fmt.Printf("Key %s is already a boolean, skipping conversion.\n", boolKey)
+		slog.Debug("key is already a boolean, skipping conversion", "key", boolKey)
 		return responseBody, nil // Return unchanged JSON
 	}
 
@@ -366,13 +356,13 @@ func ConvertNestedKeyToBool(responseBody string, nestedKeys ...string) (string,
 			// Check if the key exists
 			value, exists := current[key]
 			if !exists {
-				fmt.Printf("Key %s not found in the response, skipping conversion.\n", key)
+				slog.Debug("key not found in the response, skipping conversion", "key", key)
 				return responseBody, nil // Return unchanged JSON
 			}
 
 			// If the value is already a boolean, skip conversion
 			if _, ok := value.(bool); ok {
-				fmt.Printf("Key %s is already a boolean, skipping conversion.\n", key)
+				slog.Debug("key is already a boolean, skipping conversion", "key", key)
 				return responseBody, nil // Return unchanged JSON
 			}
 
@@ -413,19 +403,18 @@ func ConvertKeyToInt(responseBody string, key string) (string, error) {
 	match := re.FindStringSubmatch(responseBody)
 	if match == nil {
 		// Key not found, handle the error
-		fmt.Println("Key not found in JSON.")
+		slog.Debug("key not found in the response", "key", key)
 		return "", fmt.Errorf("key not found: %s", key)
 	}
 
 	// Extract the value associated with the key
 	valueStr := match[1]
-	fmt.Println("Original value:", valueStr)
 
 	// Convert the value to an integer
 	valueInt, err := strconv.Atoi(valueStr)
 	if err != nil {
 		// Conversion error, handle the error
-		fmt.Println("Error converting value to int:", err)
+		slog.Error("error converting value to int", "error", err)
 		return "", err
 	}
 
@@ -486,19 +475,18 @@ func ConvertToBool(responseBody, boolValue string) (string, error) {
 	match := re.FindStringSubmatch(responseBody)
 	if match == nil {
 		// Key not found, handle the error
-		fmt.Println("AutoRenew key not found in JSON.")
+		slog.Debug("key not found in the response", "key", boolValue)
 		return "", fmt.Errorf("AutoRenew key not found")
 	}
 
 	// Extract the value associated with the "AutoRenew" key
 	valueStr := match[1]
-	fmt.Println("Original value:", valueStr)
 
 	// Convert the value to a bool
 	valueBool, err := strconv.ParseBool(valueStr)
 	if err != nil {
 		// Conversion error, handle the error
-		fmt.Println("Error converting value to bool:", err)
+		slog.Error("error converting value to bool", "error", err)
 		// return "", err
 	}
 
@@ -508,7 +496,7 @@ func ConvertToBool(responseBody, boolValue string) (string, error) {
 	return paystackJsonStr, nil
 }
 
-func PaystackWebHookSaveToDbCallbackHandler(data WebhookData) error {
+func PaystackWebHookSaveToDbCallbackHandler(orders dbFunc.OrderRepo, data WebhookData) error {
 
 	metadataString := data.Data.Metadata
 	// fmt.Println("this is the service id: ", data.Data.Metadata.ServiceID)
@@ -519,7 +507,7 @@ func PaystackWebHookSaveToDbCallbackHandler(data WebhookData) error {
 	}
 
 	// Call the database helper function to retrieve the order and update payment status
-	orderHistory, err := dbFunc.DBHelper.GetAndUpdateOrder(uint(num), data.Data.Status)
+	orderHistory, err := orders.GetAndUpdateOrder(uint(num), data.Data.Status)
 	if err != nil {
 		// Handle potential errors based on your GetOrder function implementation
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -528,10 +516,10 @@ func PaystackWebHookSaveToDbCallbackHandler(data WebhookData) error {
 		return errors.New("failed to retrieve order")
 	}
 
-	// send a confirmation email
-	emailErr := SendEmail.ShopsphereConfirmationEmail(*orderHistory, orderHistory.ProductOrders)
+	// send a confirmation email, retried by the job queue if SMTP is down
+	emailErr := SendEmail.QueueOrderConfirmation(orderHistory.ID)
 	if emailErr != nil {
-		return errors.New("failed to send order email")
+		return errors.New("failed to queue order email")
 	}
 
 	return nil
@@ -0,0 +1,54 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// how often the memory store drops windows that have ended
+const sweepEvery = time.Minute
+
+type window struct {
+	count   int64
+	resetAt time.Time
+}
+
+// MemoryStore keeps the counters in this process, see the package doc
+type MemoryStore struct {
+	mu        sync.Mutex
+	windows   map[string]*window
+	lastSweep time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		windows:   map[string]*window{},
+		lastSweep: time.Now(),
+	}
+}
+
+func (s *MemoryStore) Hit(_ context.Context, key string, length time.Duration) (int64, time.Duration, error) {
+	now := time.Now()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// ended windows are dropped as we go, no goroutine to stop
+	if now.Sub(s.lastSweep) >= sweepEvery {
+		for k, w := range s.windows {
+			if !now.Before(w.resetAt) {
+				delete(s.windows, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	w, ok := s.windows[key]
+	if !ok || !now.Before(w.resetAt) {
+		w = &window{resetAt: now.Add(length)}
+		s.windows[key] = w
+	}
+	w.count++
+	return w.count, w.resetAt.Sub(now), nil
+}
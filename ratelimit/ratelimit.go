@@ -0,0 +1,83 @@
+// Package ratelimit counts requests against fixed window policies.
+//
+// The app runs with Prefork, so the counters have to live outside the
+// processes for a limit to mean the same thing whichever child a request
+// lands in. With REDIS_URL set every process counts in Redis, without it
+// each process keeps its own counters in memory, which multiplies every
+// limit by the number of processes and is only meant for development and
+// tests. The fiber side is middleware.RateLimit.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// Policy allows Limit requests per Window for each key. Name keeps the
+// counters of different policies apart, two routes sharing a policy share
+// its counters.
+type Policy struct {
+	Name   string
+	Limit  int64
+	Window time.Duration
+}
+
+// Header is the RateLimit-Policy value for p, "10;w=60" for ten a minute
+func (p Policy) Header() string {
+	return fmt.Sprintf("%d;w=%d", p.Limit, int64(p.Window/time.Second))
+}
+
+// Store counts hits per key in fixed windows
+type Store interface {
+	// Hit counts one request for key in the window starting at its first
+	// hit and returns the count so far and how long until the window resets
+	Hit(ctx context.Context, key string, window time.Duration) (count int64, resetIn time.Duration, err error)
+}
+
+// Result is where a key stands against a policy after a hit
+type Result struct {
+	Policy    Policy
+	Allowed   bool
+	Remaining int64
+	ResetIn   time.Duration
+}
+
+var (
+	mu    sync.RWMutex
+	store Store = NewMemoryStore()
+)
+
+// Use makes s the store every policy counts in, call it at startup before
+// serving
+func Use(s Store) {
+	mu.Lock()
+	store = s
+	mu.Unlock()
+}
+
+func current() Store {
+	mu.RLock()
+	defer mu.RUnlock()
+	return store
+}
+
+// Allow counts a request by subject (a user or an IP) against p
+func Allow(ctx context.Context, p Policy, subject string) (Result, error) {
+	count, resetIn, err := current().Hit(ctx, "ratelimit:"+p.Name+":"+subject, p.Window)
+	if err != nil {
+		return Result{}, err
+	}
+
+	remaining := p.Limit - count
+	if remaining < 0 {
+		remaining = 0
+	}
+	return Result{
+		Policy:    p,
+		Allowed:   count <= p.Limit,
+		Remaining: remaining,
+		ResetIn:   resetIn,
+	}, nil
+}
@@ -0,0 +1,46 @@
+package ratelimit
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	goredis "github.com/redis/go-redis/v9"
+)
+
+// hit counts and reads the window in one round trip, and atomically, so two
+// processes can't both see the first hit and both start a window. A key
+// found without an expiry gets one, otherwise it would block its subject
+// for good.
+var hit = goredis.NewScript(`
+local count = redis.call('INCR', KEYS[1])
+if count == 1 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+end
+local ttl = redis.call('PTTL', KEYS[1])
+if ttl < 0 then
+	redis.call('PEXPIRE', KEYS[1], ARGV[1])
+	ttl = tonumber(ARGV[1])
+end
+return {count, ttl}
+`)
+
+// RedisStore keeps the counters in Redis, shared by every process
+type RedisStore struct {
+	client *goredis.Client
+}
+
+func NewRedisStore(client *goredis.Client) *RedisStore {
+	return &RedisStore{client: client}
+}
+
+func (s *RedisStore) Hit(ctx context.Context, key string, window time.Duration) (int64, time.Duration, error) {
+	values, err := hit.Run(ctx, s.client, []string{key}, window.Milliseconds()).Int64Slice()
+	if err != nil {
+		return 0, 0, err
+	}
+	if len(values) != 2 {
+		return 0, 0, errors.New("unexpected reply from the rate limit script")
+	}
+	return values[0], time.Duration(values[1]) * time.Millisecond, nil
+}
@@ -0,0 +1,19 @@
+package router
+
+import (
+	"time"
+
+	"business-connect/cache"
+)
+
+// hot public reads, the TTL only bounds how long a missed invalidation
+// could show, every change to products or blogs drops their entries
+var (
+	homeCache     = cache.Policy{Name: "home", TTL: time.Minute, Tags: []string{cache.TagProducts}}
+	productCache  = cache.Policy{Name: "product", TTL: 5 * time.Minute, Tags: []string{cache.TagProducts}}
+	openFeedCache = cache.Policy{Name: "posts-open", TTL: 30 * time.Second, Tags: []string{cache.TagProducts}}
+	blogCache     = cache.Policy{Name: "blog", TTL: 10 * time.Minute, Tags: []string{cache.TagBlogs}}
+	blogListCache = cache.Policy{Name: "blog-list", TTL: 10 * time.Minute, Tags: []string{cache.TagBlogs}}
+	// the country list only changes with a deploy
+	statesCache = cache.Policy{Name: "states-cities", TTL: 24 * time.Hour}
+)
@@ -0,0 +1,44 @@
+package router
+
+import (
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+
+	"business-connect/ratelimit"
+)
+
+// every request counts against one of the global policies, reads get more
+// room than writes
+var (
+	readLimit  = ratelimit.Policy{Name: "read", Limit: 300, Window: time.Minute}
+	writeLimit = ratelimit.Policy{Name: "write", Limit: 60, Window: time.Minute}
+)
+
+// routes that cost money or invite abuse count against a strict policy of
+// their own on top of the global one
+var (
+	// password guessing
+	signInLimit = ratelimit.Policy{Name: "sign-in", Limit: 10, Window: 5 * time.Minute}
+	// every resend is an email we pay for
+	resendOTPLimit = ratelimit.Policy{Name: "resend-otp", Limit: 3, Window: 10 * time.Minute}
+	// description and tags share one budget of AI calls
+	aiLimit = ratelimit.Policy{Name: "ai", Limit: 10, Window: time.Minute}
+	// each order opens a Paystack transaction
+	placeOrderLimit = ratelimit.Policy{Name: "place-order", Limit: 5, Window: time.Minute}
+)
+
+// globalLimit picks the global policy for a request, probes and scrapes come
+// from one IP every few seconds and are left alone
+func globalLimit(c *fiber.Ctx) (ratelimit.Policy, bool) {
+	switch c.Path() {
+	case "/healthz", "/readyz", "/metrics":
+		return ratelimit.Policy{}, false
+	}
+
+	switch c.Method() {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions:
+		return readLimit, true
+	}
+	return writeLimit, true
+}
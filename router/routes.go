@@ -1,186 +1,147 @@
 package router
 
 import (
-	"fmt"
-	"log"
-	"os"
-	"time"
+	"strings"
 
 	"github.com/gofiber/fiber/v2"
-	"github.com/gofiber/fiber/v2/middleware/cache"
 	"github.com/gofiber/fiber/v2/middleware/compress"
 	"github.com/gofiber/fiber/v2/middleware/cors"
 
-	// "github.com/gofiber/storage/redis"
-	"github.com/gofiber/fiber/v2/middleware/limiter"
-
 	ai "business-connect/ai"
+	config "business-connect/config"
 	"business-connect/controllers/authentication"
 	"business-connect/controllers/blog"
 	email "business-connect/controllers/emails"
+	"business-connect/controllers/health"
 	"business-connect/controllers/home"
 	"business-connect/controllers/order"
 	upload "business-connect/controllers/post"
 	"business-connect/controllers/profile"
+	dbFunc "business-connect/database/dbHelpFunc"
+	"business-connect/jobs"
+	"business-connect/media"
+	"business-connect/metrics"
 	initTrans "business-connect/paystack/initTransactionForPaystack"
 	webHook "business-connect/paystack/webhooks"
+	"business-connect/storage"
 
 	mid "business-connect/middleware"
-
-	"github.com/joho/godotenv"
+	myjwt "business-connect/middleware/myjwt"
 )
 
-func NotAuthMiddleware(c *fiber.Ctx) error {
-
-	envErr := godotenv.Load(".env")
-
-	if envErr != nil {
-		fmt.Printf("Failed to load .env file: %v\n", envErr)
-	}
-
-	allowedOrigins := map[string]bool{
-		"https://business-connect-eta.vercel.app/": true,
-		"https://businessconnectt.com":             true,
-	}
-
-	allowedIPs := map[string]bool{
-		"52.31.139.75":  true,
-		"52.49.173.169": true,
-		"52.214.14.220": true,
-	}
-
-	origin := c.Get("Origin")
-	apiKey := c.Get("X-BUSCONNECT-APP-API-KEY")
-	ip := c.IP() // Extract the IP address of the request
-
-	// fmt.Println("this is the origin: ", origin)
-	// fmt.Println("this is the apiKey: ", apiKey)
-	// fmt.Println("this is the IP: ", ip)
-
-	if origin != "" {
-		// Web client request
-		if !allowedOrigins[origin] {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error": "Forbidden",
-			})
-		}
-	} else if apiKey != "" {
-		// App client request
-		if apiKey != os.Getenv("PAYUEE_APP_API_KEY") {
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error": "Forbidden",
+// NewNotAuthMiddleware only lets through requests from our web origins, the
+// mobile app (by API key) or the allowed server IPs
+func NewNotAuthMiddleware(security config.SecurityConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		origin := c.Get("Origin")
+		apiKey := c.Get("X-BUSCONNECT-APP-API-KEY")
+		ip := c.IP() // Extract the IP address of the request
+
+		if origin != "" {
+			// Web client request
+			if !security.AllowsOrigin(origin) {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error": "Forbidden",
+				})
+			}
+		} else if apiKey != "" {
+			// App client request
+			if apiKey != security.AppAPIKey {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error": "Forbidden",
+				})
+			}
+		} else if !security.AllowsIP(ip) {
+			// Check if the IP address is in the allowed list
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Unauthorized",
 			})
 		}
-	} else if !allowedIPs[ip] {
-		// Check if the IP address is in the allowed list
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized",
-		})
-	} else {
-		// No valid Origin, API Key, or IP found
-		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
-			"error": "Unauthorized",
-		})
-	}
 
-	return c.Next()
+		return c.Next()
+	}
 }
 
-func AuthMiddleware(c *fiber.Ctx) error {
-	envErr := godotenv.Load(".env")
+// NewAuthMiddleware is NewNotAuthMiddleware plus a signed in user for web
+// requests
+func NewAuthMiddleware(security config.SecurityConfig) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		origin := c.Get("Origin")
+		apiKey := c.Get("X-BUSCONNECT-APP-API-KEY")
+
+		if origin != "" {
+			// Web client request
+			if !security.AllowsOrigin(origin) {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error": "Forbidden",
+				})
+			}
 
-	if envErr != nil {
-		fmt.Printf("Failed to load .env file: %v\n", envErr)
-	}
-	allowedOrigins := map[string]bool{
-		"https://business-connect-eta.vercel.app/": true,
-		"https://businessconnectt.com":             true,
-	}
+			// Perform authentication and authorization check for web client
+			return mid.WebRequireAuth(c)
+		} else if apiKey != "" {
+			// App client request
+			if apiKey != security.AppAPIKey {
+				return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error": "Forbidden",
+				})
+			}
 
-	origin := c.Get("Origin")
-	apiKey := c.Get("X-BUSCONNECT-APP-API-KEY")
-	fmt.Println("AuthMiddleware invoked")
-	fmt.Println("this is the origin: ", origin)
-	fmt.Println("this is the apiKey: ", apiKey)
-
-	if origin != "" {
-		// Web client request
-		fmt.Println("Processing web client request")
-		if !allowedOrigins[origin] {
-			fmt.Println("Origin not allowed: ", origin)
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error": "Forbidden",
-			})
+			// Perform authentication and authorization check for app client
+			// err := mid.AppRequireAuth(c)
+			// if err != nil {
+			// 	fmt.Println("AppRequireAuth failed: ", err)
+			// 	return err // Return the error if authentication fails
+			// }
+			return c.Next()
 		}
 
-		// Perform authentication and authorization check for web client
-		err := mid.WebRequireAuth(c)
-		if err != nil {
-			fmt.Println("WebRequireAuth failed: ", err)
-			return err // Return the error if authentication fails
-		}
-	} else if apiKey != "" {
-		// App client request
-		fmt.Println("Processing app client request")
-		expectedApiKey := os.Getenv("PAYUEE_APP_API_KEY")
-		if apiKey != expectedApiKey {
-			fmt.Println("API key mismatch: ", apiKey)
-			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
-				"error": "Forbidden",
-			})
-		}
-
-		// Perform authentication and authorization check for app client
-		// err := mid.AppRequireAuth(c)
-		// if err != nil {
-		// 	fmt.Println("AppRequireAuth failed: ", err)
-		// 	return err // Return the error if authentication fails
-		// }
-	} else {
 		// No valid Origin or API Key found
-		fmt.Println("No valid Origin or API Key found")
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": "Unauthorized",
 		})
 	}
-
-	fmt.Println("AuthMiddleware completed successfully")
-	return c.Next()
 }
 
-func Routers() *fiber.App {
+// Routers builds the app, every handler queries db through the repository
+// it needs
+func Routers(cfg *config.Config, db dbFunc.DatabaseHelper) *fiber.App {
+	NotAuthMiddleware := NewNotAuthMiddleware(cfg.Security)
+	requireAdmin := mid.RequireAdmin(db)
+
+	auth := authentication.NewHandler(cfg, db)
+	blogs := blog.NewHandler(db)
+	emails := email.NewHandler(db)
+	analytics := home.NewHandler(db)
+	orders := order.NewHandler(cfg, db)
+	posts := upload.NewHandler(db)
+	profiles := profile.NewHandler(db)
 	// Create a new Fiber application
 	router := fiber.New(fiber.Config{
-		// Adjust the maximum header size limit (default is 8 KB)
-		// You can increase it as needed
-		// BodyLimit: 4 * 1024 * 1024, // 16 KB, for example
+		// a bigger body is refused with 413 before any handler runs, upload
+		// checks each file against its own limits
+		BodyLimit:       cfg.Upload.MaxRequestBytes,
 		ReadBufferSize:  50 * 4096,
 		WriteBufferSize: 2 * 4096,
 		Prefork:         true, // Enable prefork mode for better performance
 		AppName:         "Business Connect API",
+		ErrorHandler:    mid.ErrorHandler,
 	})
 
-	// Configure the rate limiter
-	limiterConfig := limiter.Config{
-		Max:        60,              // Maximum number of requests
-		Expiration: 1 * time.Minute, // Time duration before the rate limit is reset
-		KeyGenerator: func(c *fiber.Ctx) string {
-			return c.IP() // Limit based on client IP
-		},
-		LimitReached: func(c *fiber.Ctx) error {
-			return c.Status(fiber.StatusTooManyRequests).JSON(fiber.Map{
-				"error": "Too Many Requests",
-			})
-		},
-	}
+	// request id first so every later middleware logs with it, metrics and the
+	// access log wrap Recover so a recovered panic is counted as the 500 it became
+	router.Use(mid.RequestID())
+	router.Use(mid.Metrics())
+	router.Use(mid.AccessLog())
+	router.Use(mid.Recover())
 
-	// Use the rate limiter middleware
-	router.Use(limiter.New(limiterConfig))
+	// shared by every prefork process when Redis is configured, see ratelimit
+	router.Use(mid.RateLimit(globalLimit))
 
 	// Configure CORS.
 	CORSconfig := cors.Config{
 		// AllowOrigins:     "*", // Use a single string, not an array
-		AllowOrigins:     `https://business-connect-eta.vercel.app/, https://businessconnectt.com`,
+		AllowOrigins:     strings.Join(cfg.Security.AllowedOrigins, ", "),
 		AllowCredentials: true,
 		AllowMethods:     "GET, POST, PUT, DELETE",
 		// AllowHeaders:     "Content-Type, X-DORNG-APP-API-KEY",
@@ -192,80 +153,94 @@ func Routers() *fiber.App {
 		Level: compress.LevelBestCompression, // 2
 	}))
 
-	router.Use(func(c *fiber.Ctx) error {
-		defer func() {
-			if r := recover(); r != nil {
-				log.Println("Panic recovered:", r)
-				c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{"error": "Internal Server Error", "panic": r})
-			}
-		}()
-		return c.Next()
-	})
+	// securing all the web endpoint from being accessible to app cause of the origin is not included in the app requests
 
-	// Create Redis storage
-	// Redis storage configured in code
-	// redisStore := redis.New(redis.Config{
-	//     Host:     "127.0.0.1",  // e.g., "127.0.0.1" or Render Redis host
-	//     Port:     6379,               // default Redis port
-	//     Password: "",  // leave empty if none
-	//     Database: 0,                   // Redis DB index
-	//     PoolSize: 10,                  // number of connections
-	// })
+	// probes and the Prometheus scrape, no origin or API key to check
+	router.Get("/healthz", health.Healthz)
+	router.Get("/readyz", health.Readyz)
+	router.Get("/metrics", metrics.Handler(cfg.Metrics))
 
-	// securing all the web endpoint from being accessible to app cause of the origin is not included in the app requests
+	// public keys other services use to verify our JWTs
+	router.Get("/.well-known/jwks.json", myjwt.JWKS)
+
+	// objects written by the local storage driver, a bucket serves them otherwise
+	if cfg.Storage.Driver == config.StorageLocal {
+		router.Get("/storage/*", storage.ServeLocal(cfg.Storage.PrivateFolder))
+		router.Put("/storage/*", storage.ReceiveLocal)
+	}
+
+	// media by the key the models keep, on any storage driver. /image/ is
+	// where emails have always linked images.
+	router.Get("/media/*", media.Serve(cfg.Storage))
+	router.Get("/image/*", media.Serve(cfg.Storage))
+	router.Post("/media/sign", NotAuthMiddleware, mid.WebRequireAuth, media.Sign(cfg.Storage, db))
 
 	// payuee web authentication using email and password
-	router.Post("/sign-up", NotAuthMiddleware, authentication.SignUp)
+	router.Post("/sign-up", NotAuthMiddleware, auth.SignUp)
 	// CACHED ROUTE
-	router.Get("/get-states-cities/:countryCode", cache.New(cache.Config{
-		// Storage: redisStore,
-		Expiration: 0, // 0 means it never expires
-		KeyGenerator: func(c *fiber.Ctx) string {
-			return c.OriginalURL() // cache by URL
-		},
-		CacheControl: true,
-	}), NotAuthMiddleware, authentication.GetStatesAndCitiesByCountryCode)
-	router.Get("/get-states-cities/:countryCode", NotAuthMiddleware, authentication.GetStatesAndCitiesByCountryCode)
-	router.Post("/email-verification", NotAuthMiddleware, authentication.EmailAuthentication)
-	router.Post("/resend-otp", NotAuthMiddleware, authentication.ResendEmailVerification)
-	router.Post("/sign-in", NotAuthMiddleware, authentication.SignIn)
-	router.Post("/forgotten-password-email", NotAuthMiddleware, authentication.SendEmailPasswordChange)
-	router.Post("/forgotten-password-verification", NotAuthMiddleware, authentication.VerifyForgotPassword)
+	router.Get("/get-states-cities/:countryCode", NotAuthMiddleware, mid.Cache(statesCache), auth.GetStatesAndCitiesByCountryCode)
+	router.Post("/email-verification", NotAuthMiddleware, auth.EmailAuthentication)
+	router.Post("/resend-otp", NotAuthMiddleware, mid.Limit(resendOTPLimit), auth.ResendEmailVerification)
+	router.Post("/sign-in", NotAuthMiddleware, mid.Limit(signInLimit), auth.SignIn)
+	router.Post("/forgotten-password-email", NotAuthMiddleware, auth.SendEmailPasswordChange)
+	router.Post("/forgotten-password-verification", NotAuthMiddleware, auth.VerifyForgotPassword)
 	router.Get("/log-out", NotAuthMiddleware, authentication.Logout)
 
 	// this is the magic login routes
-	router.Post("/magic-link", NotAuthMiddleware, authentication.MagicLinkSignIn)
-	router.Post("/verify/magic-link", NotAuthMiddleware, authentication.VerifySignInMagicLink)
+	router.Post("/magic-link", NotAuthMiddleware, auth.MagicLinkSignIn)
+	router.Post("/verify/magic-link", NotAuthMiddleware, auth.VerifySignInMagicLink)
+
+	// unlock an account locked after too many failed sign in attempts
+	router.Post("/unlock-account", NotAuthMiddleware, auth.UnlockAccount)
+
+	// sign in with Google / Apple. These are browser redirects to and from the
+	// provider so they can't carry our origin or API key, the single use state
+	// stored by OIDCStart protects the callback instead
+	router.Get("/auth/oidc/:provider/start", auth.OIDCStart)
+	router.Get("/auth/oidc/:provider/callback", auth.OIDCCallback)
+	router.Post("/auth/oidc/:provider/callback", auth.OIDCCallback)
 
-	// web := router.Group("/web", AuthMiddleware)
+	// web := router.Group("/web", NewAuthMiddleware(cfg.Security))
 	// get and update profile information
-	// router.Get("/profile", mid.WebRequireAuth, profile.RetrievePersonalInformation)
-	// router.Post("/profile/update", mid.WebRequireAuth, profile.UpdatePersonalInformation)
-	// router.Post("/profile/update/password", mid.WebRequireAuth, profile.UpdatePassword)
+	router.Get("/profile", NotAuthMiddleware, mid.WebRequireAuth, profiles.RetrievePersonalInformation)
+	router.Post("/profile/update", NotAuthMiddleware, mid.WebRequireAuth, profiles.UpdatePersonalInformation)
+	router.Post("/profile/update/password", NotAuthMiddleware, mid.WebRequireAuth, profiles.UpdatePassword)
+	router.Post("/profile/update/email", NotAuthMiddleware, mid.WebRequireAuth, profiles.RequestEmailChange)
+	router.Post("/profile/update/email/verify", NotAuthMiddleware, mid.WebRequireAuth, profiles.VerifyEmailChange)
+	router.Post("/profile/update/cover-photo", NotAuthMiddleware, mid.WebRequireAuth, profiles.UpdateCoverPhoto)
+
+	// personal data export and account deletion
+	router.Get("/profile/export", NotAuthMiddleware, mid.WebRequireAuth, profiles.ExportPersonalData)
+	router.Get("/profile/delete", NotAuthMiddleware, mid.WebRequireAuth, profiles.GetAccountDeletionStatus)
+	router.Post("/profile/delete", NotAuthMiddleware, mid.WebRequireAuth, profiles.RequestAccountDeletion)
+	router.Post("/profile/delete/cancel", NotAuthMiddleware, mid.WebRequireAuth, profiles.CancelAccountDeletion)
+	router.Post("/cancel-account-deletion", NotAuthMiddleware, profiles.CancelAccountDeletionByLink)
 
 	// all this are open routes to get products and make order
-	router.Get("/next-product/:id", NotAuthMiddleware, profile.GetNextProductID)
-	router.Get("/previous-product/:id", NotAuthMiddleware, profile.GetPreviousProductID)
-	router.Get("/search-products", NotAuthMiddleware, profile.SearchProductsByTitleAndCategory)
-	router.Post("/admin-product-search", NotAuthMiddleware, profile.SearchAdminProductsByTitle)
-	router.Post("/admin-order-search", NotAuthMiddleware, profile.SearchAdminOrderByTitle)
-	router.Post("/transaction/date", NotAuthMiddleware, profile.GetTransactionHistoryByDate)
-	router.Post("/place-order", NotAuthMiddleware, order.AddOrder)
-	router.Get("/get-order/:orderID", NotAuthMiddleware, order.GetOrder)
+	router.Get("/next-product/:id", NotAuthMiddleware, profiles.GetNextProductID)
+	router.Get("/previous-product/:id", NotAuthMiddleware, profiles.GetPreviousProductID)
+	router.Get("/search-products", NotAuthMiddleware, profiles.SearchProductsByTitleAndCategory)
+	router.Get("/search", NotAuthMiddleware, profiles.SearchPosts)
+	router.Get("/nearby", NotAuthMiddleware, profiles.Nearby)
+	router.Post("/admin-product-search", NotAuthMiddleware, profiles.SearchAdminProductsByTitle)
+	router.Post("/admin-order-search", NotAuthMiddleware, profiles.SearchAdminOrderByTitle)
+	router.Post("/transaction/date", NotAuthMiddleware, profiles.GetTransactionHistoryByDate)
+	router.Post("/place-order", NotAuthMiddleware, mid.Limit(placeOrderLimit), orders.AddOrder)
+	router.Get("/get-order/:orderID", NotAuthMiddleware, orders.GetOrder)
 
 	// get dorng home products
-	router.Get("/business-connect-product-home", NotAuthMiddleware, profile.GetBusinessConnectHomePageProducts)
+	router.Get("/business-connect-product-home", NotAuthMiddleware, mid.Cache(homeCache), profiles.GetBusinessConnectHomePageProducts)
 
 	// get blog post by id
-	router.Get("/get-blog/:blogID", NotAuthMiddleware, blog.GetBlogPost)
-	router.Get("/get-blog-posts/:idLimit", NotAuthMiddleware, blog.GetBlogPosts)
+	router.Get("/get-blog/:blogID", NotAuthMiddleware, mid.Cache(blogCache), blogs.GetBlogPost)
+	router.Get("/get-blog-posts/:idLimit", NotAuthMiddleware, mid.Cache(blogListCache), blogs.GetBlogPosts)
 
 	// make and group payments with paystack
 	paystackGroup := router.Group("/paystack")
 
 	// initialize transaction & and webhook
-	paystackGroup.Get("/init-transaction/call-back", initTrans.PaystackCallbackHandler)
-	paystackGroup.Post("/webhook/call-back", webHook.WebHookStatus)
+	paystackGroup.Get("/init-transaction/call-back", initTrans.PaystackCallbackHandler(cfg.Paystack))
+	paystackGroup.Post("/webhook/call-back", webHook.WebHookStatus(db))
 
 	// get all subscriptions for auto renewal and update
 	// router.Get("/subscription/:idLimit", mid.WebRequireAuth, profile.GetSubscriptionHistoryByLimit)           //get subscriptions
@@ -273,78 +248,85 @@ func Routers() *fiber.App {
 	// router.Get("/recharge-subscription/:subscriptionID", mid.WebRequireAuth, profile.RechargeSubscriptionNow) //recharge subscriptions now
 
 	// analytics and add email subscribers
-	router.Post("/email-subscriber", NotAuthMiddleware, profile.AddEmailSubscription)
+	router.Post("/email-subscriber", NotAuthMiddleware, profiles.AddEmailSubscription)
 
-	// router.Get("/dorng-analytics", NotAuthMiddleware, profile.AddSiteVisit)
+	// router.Get("/dorng-analytics", NotAuthMiddleware, profiles.AddSiteVisit)
 
 	// ADMIN ROUTES
 
+	// accounts locked after too many failed sign in attempts
+	router.Get("/admin/locked-accounts", mid.WebRequireAuth, requireAdmin, auth.GetLockedAccounts)
+	router.Post("/admin/unlock-account", mid.WebRequireAuth, requireAdmin, auth.AdminUnlockAccount)
+
+	// background jobs, dead ones by default, and retrying a dead one
+	router.Get("/admin/jobs", mid.WebRequireAuth, requireAdmin, jobs.ListJobs(db))
+	router.Post("/admin/jobs/:id/retry", mid.WebRequireAuth, requireAdmin, jobs.RetryJob(db))
+
 	// Get BusinessConnect Users Analytics
-	router.Get("/get-dorng-analytics", mid.WebRequireAuth, home.GetBusinessConnectAnalytics)
+	router.Get("/get-dorng-analytics", mid.WebRequireAuth, analytics.GetBusinessConnectAnalytics)
 
 	// post a product on BusinessConnect
-	router.Post("/publish-product", NotAuthMiddleware, mid.WebRequireAuth, upload.CreatePost)
-	router.Post("/upload-profile-photo", NotAuthMiddleware, mid.WebRequireAuth, upload.UpdateProfilePhoto)
+	router.Post("/publish-product", NotAuthMiddleware, mid.WebRequireAuth, posts.CreatePost)
+	router.Post("/upload-profile-photo", NotAuthMiddleware, mid.WebRequireAuth, posts.UpdateProfilePhoto)
+	// direct uploads: the client PUTs to storage and confirms, see upload.StartSession
+	router.Post("/upload-sessions", NotAuthMiddleware, mid.WebRequireAuth, posts.StartUploadSession)
+	router.Post("/upload-sessions/:sessionID/confirm", NotAuthMiddleware, mid.WebRequireAuth, posts.ConfirmUploadSession)
 
 	// set shipping fee
-	router.Post("/set-shipping-fee", mid.WebRequireAuth, order.SetShippingPricePerKm)
-	router.Get("/get-shipping-fee", NotAuthMiddleware, order.GetShippingPricePerKm)
+	router.Post("/set-shipping-fee", mid.WebRequireAuth, orders.SetShippingPricePerKm)
+	router.Get("/get-shipping-fee", NotAuthMiddleware, orders.GetShippingPricePerKm)
 
 	// AI GENERATION FOR PAYUEE VENDORS
-	router.Post("/ai-description", NotAuthMiddleware, mid.WebRequireAuth, ai.GetVendorProductDescriptionAI)
-	router.Post("/ai-tag", NotAuthMiddleware, mid.WebRequireAuth, ai.GetVendorProductTagAI)
+	router.Post("/ai-description", NotAuthMiddleware, mid.WebRequireAuth, mid.Limit(aiLimit), ai.GetVendorProductDescriptionAI)
+	router.Post("/ai-tag", NotAuthMiddleware, mid.WebRequireAuth, mid.Limit(aiLimit), ai.GetVendorProductTagAI)
 
 	// update BusinessConnect product and status
-	router.Post("/update-dorng-product", mid.WebRequireAuth, order.UpdateBusinessConnectProduct)
-	router.Post("/update-dorng-status", mid.WebRequireAuth, order.UpdateBusinessConnectOrderStatus)
+	router.Post("/update-dorng-product", mid.WebRequireAuth, orders.UpdateBusinessConnectProduct)
+	router.Post("/update-dorng-status", mid.WebRequireAuth, orders.UpdateBusinessConnectOrderStatus)
 
 	// get all products and product by id
-	router.Get("/product/:id", NotAuthMiddleware, profile.GetBusinessConnectProductByID)
-	router.Get("/admin-product/:id", NotAuthMiddleware, profile.GetBusinessConnectAdminProductByID)
-	router.Get("/products/:page", NotAuthMiddleware, profile.GetBusinessConnectProductsByLimit)
-	router.Get("/admin-products/:idLimit", NotAuthMiddleware, profile.GetBusinessConnectAdminProductsByLimit)
+	router.Get("/product/:id", NotAuthMiddleware, mid.Cache(productCache), profiles.GetBusinessConnectProductByID)
+	router.Get("/admin-product/:id", NotAuthMiddleware, profiles.GetBusinessConnectAdminProductByID)
+	router.Get("/products/:page", NotAuthMiddleware, profiles.GetBusinessConnectProductsByLimit)
+	router.Get("/admin-products/:idLimit", NotAuthMiddleware, profiles.GetBusinessConnectAdminProductsByLimit)
 	router.Post("/post-comment", NotAuthMiddleware, upload.AddBusinessConnectProductComment)
 	router.Get("/get-comment/:idLimit/:proId", NotAuthMiddleware, upload.GetBusinessConnectProductCommentsByLimit)
 
 	// Business Connect
-	router.Get("/posts", NotAuthMiddleware, mid.WebRequireAuth, profile.GetPostsPaginated)
-	router.Get("/posts-open", NotAuthMiddleware, profile.GetPostsPaginatedOpen)
-	router.Get("/status", NotAuthMiddleware, mid.WebRequireAuth, profile.GetStatusPaginated)
-	router.Get("/status-open", NotAuthMiddleware, profile.GetStatusPaginatedOpen)
-	router.Get("/get-friends", NotAuthMiddleware, mid.WebRequireAuth, profile.GetFriends)
-	router.Post("/connect-friends", NotAuthMiddleware, mid.WebRequireAuth, profile.ConnectFriend)
-	router.Get("/get-groups", NotAuthMiddleware, mid.WebRequireAuth, profile.GetGroups)
-	router.Post("/join-groups", NotAuthMiddleware, mid.WebRequireAuth, profile.JoinGroupHandler)
+	router.Get("/posts", NotAuthMiddleware, mid.WebRequireAuth, profiles.GetPostsPaginated)
+	router.Get("/posts-open", NotAuthMiddleware, mid.Cache(openFeedCache), profiles.GetPostsPaginatedOpen)
+	router.Get("/status", NotAuthMiddleware, mid.WebRequireAuth, profiles.GetStatusPaginated)
+	router.Get("/status-open", NotAuthMiddleware, profiles.GetStatusPaginatedOpen)
+	router.Get("/get-friends", NotAuthMiddleware, mid.WebRequireAuth, profiles.GetFriends)
+	router.Post("/connect-friends", NotAuthMiddleware, mid.WebRequireAuth, profiles.ConnectFriend)
+	router.Get("/get-groups", NotAuthMiddleware, mid.WebRequireAuth, profiles.GetGroups)
+	router.Post("/join-groups", NotAuthMiddleware, mid.WebRequireAuth, profiles.JoinGroupHandler)
 
 	// blog post, retrieval and updating
-	router.Post("/publish-blog", mid.WebRequireAuth, upload.BlogPost)
-	router.Post("/update-dorng-blog", mid.WebRequireAuth, blog.UpdateBusinessConnectBlog)
-	router.Get("/delete-dorng-blog/:blogID", mid.WebRequireAuth, blog.DeleteBusinessConnectBlog)
-	router.Post("/blog-comment", NotAuthMiddleware, blog.AddBusinessConnectBlogComment)
-	router.Get("/get-blog-comment/:idLimit/:proId", NotAuthMiddleware, blog.GetBusinessConnectBlogCommentsByLimit)
+	router.Post("/publish-blog", mid.WebRequireAuth, posts.BlogPost)
+	router.Post("/update-dorng-blog", mid.WebRequireAuth, blogs.UpdateBusinessConnectBlog)
+	router.Get("/delete-dorng-blog/:blogID", mid.WebRequireAuth, blogs.DeleteBusinessConnectBlog)
+	router.Post("/blog-comment", NotAuthMiddleware, blogs.AddBusinessConnectBlogComment)
+	router.Get("/get-blog-comment/:idLimit/:proId", NotAuthMiddleware, blogs.GetBusinessConnectBlogCommentsByLimit)
 
 	// send emails and analytics
-	router.Get("/dorng-analytics", NotAuthMiddleware, profile.AddSiteVisit)
-	router.Get("/dorng-user-fingerprint/:fingerprint", NotAuthMiddleware, profile.GetBusinessConnectUserByFingerprint)
-	router.Post("/dorng-user-analytics", NotAuthMiddleware, profile.AddClickHistory)
-	router.Post("/send-dorng-email", mid.WebRequireAuth, email.SendEmails)
+	router.Get("/dorng-analytics", NotAuthMiddleware, profiles.AddSiteVisit)
+	router.Get("/dorng-user-fingerprint/:fingerprint", NotAuthMiddleware, profiles.GetBusinessConnectUserByFingerprint)
+	router.Post("/dorng-user-analytics", NotAuthMiddleware, profiles.AddClickHistory)
+	router.Post("/send-dorng-email", mid.WebRequireAuth, emails.SendEmails)
 
 	// delete dorng product
-	router.Get("/delete-dorng-product/:productID", mid.WebRequireAuth, order.DeleteBusinessConnectProduct)
+	router.Get("/delete-dorng-product/:productID", mid.WebRequireAuth, orders.DeleteBusinessConnectProduct)
 
 	// get dorng order
-	router.Get("/get-dorng-order/:orderID", NotAuthMiddleware, order.GetBusinessConnectOrder)
-	router.Get("/get-orders/:orderLimit", NotAuthMiddleware, order.GetBusinessConnectOrdersByLimit)
+	router.Get("/get-dorng-order/:orderID", NotAuthMiddleware, orders.GetBusinessConnectOrder)
+	router.Get("/get-orders/:orderLimit", NotAuthMiddleware, orders.GetBusinessConnectOrdersByLimit)
 
 	// router.Get("/send-sms/:phone", NotAuthMiddleware, order.SendSmsBusinessConnect)
 
 	// check if user is authenticated
 	router.Get("/auth-status", mid.WebRequireAuth, profile.CheckAuthStatus)
 
-	// AI GENERATION FOR DORNG GLOBAL
-	router.Post("/ai-description", NotAuthMiddleware, mid.WebRequireAuth, ai.GetVendorProductDescriptionAI)
-	router.Post("/ai-tag", NotAuthMiddleware, mid.WebRequireAuth, ai.GetVendorProductTagAI)
-
 	// Handle preflight requests (OPTIONS)
 	router.Options("/*", func(c *fiber.Ctx) error {
 		return c.SendStatus(fiber.StatusNoContent)
@@ -0,0 +1,167 @@
+// Package search turns post text into the terms the search index keeps and
+// a query into the terms it looks up.
+//
+// Words are lowercased, stop words dropped and the rest stemmed with the
+// Snowball English stemmer, so "shoes" finds "shoe" and "tailoring" finds
+// "tailored". Typos are matched by edit distance against the terms that are
+// indexed, see Distance and Tolerance. The index itself is a table every
+// prefork process shares, see dbFunc.SearchRepo.
+package search
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/kljensen/snowball/english"
+)
+
+// how much a term counts by the field it is in, a word of the title says
+// more about a post than one in the middle of its description
+const (
+	TitleWeight       = 6
+	CategoryWeight    = 4
+	LocationWeight    = 3
+	DescriptionWeight = 1
+)
+
+const (
+	// longest term kept in letters, the column is this wide
+	MaxTermLength = 64
+	// most terms a query is looked up by, the rest are ignored
+	MaxQueryTerms = 8
+)
+
+// Fields are the parts of a post that are searched
+type Fields struct {
+	Title       string
+	Description string
+	Category    string
+	Location    string
+}
+
+// Document is the weight of every term of a post, what the index keeps
+func Document(fields Fields) map[string]int {
+	weights := map[string]int{}
+	add := func(text string, weight int) {
+		for _, term := range Terms(text) {
+			weights[term] += weight
+		}
+	}
+	add(fields.Title, TitleWeight)
+	add(fields.Category, CategoryWeight)
+	add(fields.Location, LocationWeight)
+	add(fields.Description, DescriptionWeight)
+	return weights
+}
+
+// Terms are the stemmed words of text, stop words left out, in order and
+// repeated as often as they appear
+func Terms(text string) []string {
+	var terms []string
+	for _, word := range words(text) {
+		if term := stem(word); term != "" {
+			terms = append(terms, term)
+		}
+	}
+	return terms
+}
+
+// Query is what a search looks up
+type Query struct {
+	// stemmed terms, each once, at most MaxQueryTerms
+	Terms []string
+	// the last word as typed, matched as a prefix while the user is still
+	// typing it, empty when it is a stop word
+	Prefix string
+}
+
+// ParseQuery reads what the user typed
+func ParseQuery(text string) Query {
+	var query Query
+	seen := map[string]bool{}
+	all := words(text)
+	for _, word := range all {
+		term := stem(word)
+		if term == "" || seen[term] {
+			continue
+		}
+		seen[term] = true
+		query.Terms = append(query.Terms, term)
+		if len(query.Terms) == MaxQueryTerms {
+			break
+		}
+	}
+	if n := len(all); n > 0 && stem(all[n-1]) != "" {
+		query.Prefix = all[n-1]
+	}
+	return query
+}
+
+// words splits text on anything that isn't a letter or a digit
+func words(text string) []string {
+	return strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// stem is the term of a lowercase word, empty for a stop word
+func stem(word string) string {
+	if english.IsStopWord(word) {
+		return ""
+	}
+	term := english.Stem(word, true)
+	if runes := []rune(term); len(runes) > MaxTermLength {
+		term = string(runes[:MaxTermLength])
+	}
+	return term
+}
+
+// Tolerance is how many edits away a term may be from one that is indexed
+// and still match it, none for short terms where one edit is another word
+func Tolerance(term string) int {
+	switch n := len([]rune(term)); {
+	case n <= 3:
+		return 0
+	case n <= 7:
+		return 1
+	default:
+		return 2
+	}
+}
+
+// Distance is how many single letter insertions, deletions, substitutions
+// or swaps of neighbours turn a into b, or max+1 once it is more than max
+func Distance(a, b string, max int) int {
+	ra, rb := []rune(a), []rune(b)
+	if diff := len(ra) - len(rb); diff > max || -diff > max {
+		return max + 1
+	}
+
+	// three rows of the optimal string alignment table
+	prev2 := make([]int, len(rb)+1)
+	prev := make([]int, len(rb)+1)
+	cur := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(ra); i++ {
+		cur[0] = i
+		best := cur[0]
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+			if i > 1 && j > 1 && ra[i-1] == rb[j-2] && ra[i-2] == rb[j-1] {
+				cur[j] = min(cur[j], prev2[j-2]+1)
+			}
+			best = min(best, cur[j])
+		}
+		if best > max {
+			return max + 1
+		}
+		prev2, prev, cur = prev, cur, prev2
+	}
+	return min(prev[len(rb)], max+1)
+}
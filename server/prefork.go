@@ -0,0 +1,110 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+	"os"
+	"os/exec"
+	"os/signal"
+	"runtime"
+	"syscall"
+	"time"
+)
+
+// fiber starts a prefork child when it sees this in the environment
+const preforkChildEnv = "FIBER_PREFORK_CHILD=1"
+
+// how long past the shutdown timeout a child gets before it is killed, the
+// child itself gives up at the timeout
+const childExitMargin = 5 * time.Second
+
+// superviseChildren replaces fiber's prefork master. Fiber's master returns
+// as soon as one child exits and kills the others, which would cut off the
+// requests they are still draining. This one forwards SIGINT and SIGTERM to
+// every child and waits for all of them, killing the ones still running
+// timeout after the signal. It returns the exit code for the master: 0 after
+// a clean shutdown, 1 when a child failed or had to be killed.
+func superviseChildren(port string, timeout time.Duration) int {
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+	defer signal.Stop(signals)
+
+	type exit struct {
+		pid int
+		err error
+	}
+
+	count := runtime.GOMAXPROCS(0)
+	children := make(map[int]*exec.Cmd, count)
+	exits := make(chan exit, count)
+	code := 0
+
+	for i := 0; i < count; i++ {
+		cmd := exec.Command(os.Args[0], os.Args[1:]...)
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+		cmd.Env = append(os.Environ(), preforkChildEnv)
+		if err := cmd.Start(); err != nil {
+			slog.Error("error starting a prefork child", "error", err)
+			code = 1
+			break
+		}
+		children[cmd.Process.Pid] = cmd
+		go func(cmd *exec.Cmd) {
+			exits <- exit{pid: cmd.Process.Pid, err: cmd.Wait()}
+		}(cmd)
+	}
+
+	if code == 0 {
+		slog.Info("server started", "port", port, "processes", len(children))
+
+		// run until we're told to stop or a child dies, a dead child means
+		// the port or the database is gone and the platform should restart us
+		select {
+		case sig := <-signals:
+			slog.Info("shutting down", "signal", sig.String())
+		case dead := <-exits:
+			delete(children, dead.pid)
+			slog.Error("prefork child exited, shutting down", "pid", dead.pid, "error", dead.err)
+			code = 1
+		}
+	}
+
+	for _, cmd := range children {
+		if err := cmd.Process.Signal(syscall.SIGTERM); err != nil && !errors.Is(err, os.ErrProcessDone) {
+			slog.Warn("error signalling prefork child", "pid", cmd.Process.Pid, "error", err)
+		}
+	}
+
+	deadline := time.NewTimer(timeout + childExitMargin)
+	defer deadline.Stop()
+
+	for len(children) > 0 {
+		select {
+		case done := <-exits:
+			delete(children, done.pid)
+			if done.err != nil {
+				slog.Error("prefork child didn't shut down cleanly", "pid", done.pid, "error", done.err)
+				code = 1
+			}
+		case sig := <-signals:
+			// a second signal means whoever sent it is done waiting
+			slog.Warn("killing prefork children", "signal", sig.String())
+			killChildren(children)
+			return 1
+		case <-deadline.C:
+			slog.Error("prefork children didn't shut down in time, killing them", "count", len(children))
+			killChildren(children)
+			return 1
+		}
+	}
+	return code
+}
+
+func killChildren(children map[int]*exec.Cmd) {
+	for _, cmd := range children {
+		if err := cmd.Process.Kill(); err != nil && !errors.Is(err, os.ErrProcessDone) {
+			slog.Warn("error killing prefork child", "pid", cmd.Process.Pid, "error", err)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// the test binary is its own prefork child, superviseChildren starts it
+// again with preforkChildEnv set
+const (
+	childDirEnv   = "PREFORK_TEST_DIR"
+	childCountEnv = "PREFORK_TEST_CHILDREN"
+)
+
+func TestMain(m *testing.M) {
+	if os.Getenv("FIBER_PREFORK_CHILD") == "1" {
+		os.Exit(runTestChild(os.Getenv(childDirEnv)))
+	}
+	os.Exit(m.Run())
+}
+
+// runTestChild stands in for runChild: it reports itself ready, waits for
+// the master's SIGTERM, takes a while draining and records that it finished.
+// The first child to claim the crash file exits early instead, once the
+// others are ready.
+func runTestChild(dir string) int {
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	pid := strconv.Itoa(os.Getpid())
+	if _, err := os.Stat(filepath.Join(dir, "crash")); err == nil {
+		if f, err := os.OpenFile(filepath.Join(dir, "crashed"), os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600); err == nil {
+			f.Close()
+			count, _ := strconv.Atoi(os.Getenv(childCountEnv))
+			waitForFiles(dir, "ready-*", count-1)
+			return 1
+		}
+	}
+
+	if err := os.WriteFile(filepath.Join(dir, "ready-"+pid), nil, 0o600); err != nil {
+		return 1
+	}
+	<-signals
+	time.Sleep(300 * time.Millisecond)
+	if err := os.WriteFile(filepath.Join(dir, "drained-"+pid), nil, 0o600); err != nil {
+		return 1
+	}
+	return 0
+}
+
+func waitForFiles(dir, pattern string, count int) bool {
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if matches, _ := filepath.Glob(filepath.Join(dir, pattern)); len(matches) >= count {
+			return true
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	return false
+}
+
+// supervise runs superviseChildren with n children and returns the
+// directory they report in and the master's exit code once it returns
+func supervise(t *testing.T, n int, setup func(dir string)) (string, <-chan int) {
+	t.Helper()
+
+	dir := t.TempDir()
+	t.Setenv(childDirEnv, dir)
+	t.Setenv(childCountEnv, strconv.Itoa(n))
+	if setup != nil {
+		setup(dir)
+	}
+	// superviseChildren starts one child per GOMAXPROCS
+	procs := runtime.GOMAXPROCS(n)
+	t.Cleanup(func() { runtime.GOMAXPROCS(procs) })
+
+	code := make(chan int, 1)
+	go func() { code <- superviseChildren("0", 5*time.Second) }()
+	return dir, code
+}
+
+func waitForExit(t *testing.T, code <-chan int) int {
+	t.Helper()
+
+	select {
+	case c := <-code:
+		return c
+	case <-time.After(15 * time.Second):
+		t.Fatal("the master never returned")
+		return -1
+	}
+}
+
+func TestShutdownWaitsForEveryChildToDrain(t *testing.T) {
+	const children = 3
+	dir, code := supervise(t, children, nil)
+
+	if !waitForFiles(dir, "ready-*", children) {
+		t.Fatal("the children never started")
+	}
+	self, err := os.FindProcess(os.Getpid())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := self.Signal(syscall.SIGTERM); err != nil {
+		t.Fatal(err)
+	}
+
+	if c := waitForExit(t, code); c != 0 {
+		t.Errorf("exit code %d after a clean shutdown, want 0", c)
+	}
+	// the master only returns once the children have, every one drained
+	if drained, _ := filepath.Glob(filepath.Join(dir, "drained-*")); len(drained) != children {
+		t.Errorf("%d children drained before the master returned, want %d", len(drained), children)
+	}
+}
+
+func TestDeadChildShutsTheOthersDown(t *testing.T) {
+	const children = 3
+	dir, code := supervise(t, children, func(dir string) {
+		if err := os.WriteFile(filepath.Join(dir, "crash"), nil, 0o600); err != nil {
+			t.Fatal(err)
+		}
+	})
+
+	if c := waitForExit(t, code); c != 1 {
+		t.Errorf("exit code %d after a child died, want 1", c)
+	}
+	if drained, _ := filepath.Glob(filepath.Join(dir, "drained-*")); len(drained) != children-1 {
+		t.Errorf("%d children drained, want the %d left running", len(drained), children-1)
+	}
+}
@@ -2,39 +2,248 @@ package server
 
 import (
 	// "fmt"
+	"context"
 	"log"
+	"log/slog"
 	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
 	"business-connect/router"
 
-	"github.com/joho/godotenv"
+	"github.com/gofiber/fiber/v2"
+	"gorm.io/gorm"
 
+	"business-connect/ai"
+	"business-connect/cache"
+	config "business-connect/config"
+	authentication "business-connect/controllers/authentication"
+	"business-connect/controllers/health"
+	profile "business-connect/controllers/profile"
+	database "business-connect/database"
+	dbFunc "business-connect/database/dbHelpFunc"
+	redisdb "business-connect/database/redis"
+	"business-connect/jobs"
+	"business-connect/lifecycle"
+	"business-connect/logger"
+	"business-connect/metrics"
 	myjwt "business-connect/middleware/myjwt"
+	paystack "business-connect/paystack"
+	"business-connect/ratelimit"
+	"business-connect/storage"
+	"business-connect/upload"
 )
 
-func LoadEnv() {
-	if os.Getenv("RENDER") == "" {
-		if err := godotenv.Load(".env"); err != nil {
-			log.Println("No .env file found, using system env")
+func StartServer() {
+	// load and validate every setting up front, a missing key stops the deploy here
+	cfg, cfgErr := config.Load()
+	if cfgErr != nil {
+		log.Fatal(cfgErr)
+	}
+	logger.Setup(cfg.Log)
+	UseConfig(cfg)
+
+	db, dbErr := database.Connect(cfg.Database)
+	if dbErr != nil {
+		log.Fatal(dbErr)
+	}
+	repos := UseDatabase(db)
+
+	sqlDB, sqlErr := db.DB()
+	if sqlErr != nil {
+		log.Fatal(sqlErr)
+	}
+	metrics.RegisterDB(sqlDB)
+	health.Register("database", sqlDB.PingContext)
+
+	store, storeErr := storage.Open(cfg.Storage, cfg.B2)
+	if storeErr != nil {
+		log.Fatal(storeErr)
+	}
+	storage.Use(store)
+	// B2 bills authorizations, probes every few seconds share one a minute
+	health.Register("storage", health.Cached(time.Minute, store.Ping))
+
+	// rate limits and cache invalidations only reach every prefork process
+	// through Redis
+	redisClient, redisErr := redisdb.Connect(cfg.Redis)
+	if redisErr != nil {
+		log.Fatal(redisErr)
+	}
+	if redisClient != nil {
+		ratelimit.Use(ratelimit.NewRedisStore(redisClient))
+		cache.Use(cache.NewRedisStore(redisClient))
+		health.Register("redis", func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		})
+		lifecycle.OnShutdown("redis", func(context.Context) error {
+			return redisClient.Close()
+		})
+	} else if cfg.IsProduction() && !fiber.IsChild() {
+		slog.Warn("REDIS_URL not set, rate limits and the response cache are per process")
+	}
+
+	// the master clears the last run's snapshots before any child writes one
+	if !fiber.IsChild() {
+		if clearErr := metrics.ClearSnapshots(cfg.Metrics); clearErr != nil {
+			slog.Warn("error clearing old metrics snapshots", "error", clearErr)
 		}
 	}
-}
+	// every prefork process leaves its metrics for /metrics to add up
+	if metricsErr := metrics.Start(cfg.Metrics); metricsErr != nil {
+		slog.Warn("metrics from other processes won't be included", "error", metricsErr)
+	}
 
-func StartServer() {
-	LoadEnv()
+	// closed last, after every worker that might still write is done
+	lifecycle.OnShutdown("database", func(context.Context) error {
+		return sqlDB.Close()
+	})
 
 	// init the JWTs
-	jwtErr := myjwt.InitJWT()
+	jwtErr := myjwt.InitJWT(cfg.JWT)
 	if jwtErr != nil {
-		log.Println("Error initializing the JWT's!")
-		log.Fatal(jwtErr)
+		log.Fatal("error initializing the JWTs: ", jwtErr)
+	}
+
+	// every process works the job queue, a job may run in any of them
+	if jobsErr := jobs.Start(cfg.Jobs); jobsErr != nil {
+		log.Fatal("error starting the job workers: ", jobsErr)
 	}
 
-	PORT := os.Getenv("PORT")
+	if !fiber.IsChild() {
+		os.Exit(runMaster(cfg, repos))
+	}
+	os.Exit(runChild(cfg, repos))
+}
+
+// runMaster starts the prefork children and runs the background jobs, which
+// only run once, here. It returns once every child has exited.
+func runMaster(cfg *config.Config, repos dbFunc.DatabaseHelper) int {
+	accounts := profile.NewHandler(repos)
+	lifecycle.Go("account deletions", func(ctx context.Context) {
+		runAccountDeletions(ctx, accounts)
+	})
+	lifecycle.Go("upload sessions", runUploadSessionCleanup)
+	if cfg.Upload.MediaSweep != "off" {
+		lifecycle.Go("media sweep", func(ctx context.Context) {
+			runMediaSweep(ctx, cfg.Upload.MediaSweep == "report")
+		})
+	}
 
+	code := superviseChildren(cfg.Port, cfg.ShutdownTimeout)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := lifecycle.Shutdown(ctx); err != nil {
+		slog.Error("shutdown didn't finish cleanly", "error", err)
+		code = 1
+	}
+	return code
+}
+
+// runChild serves requests until the master forwards SIGINT or SIGTERM,
+// then drains the requests in flight and stops its background work, both
+// within the shutdown timeout
+func runChild(cfg *config.Config, repos dbFunc.DatabaseHelper) int {
 	// running all routers in the Routers() function
-	routes := router.Routers()
+	routes := router.Routers(cfg, repos)
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGINT, syscall.SIGTERM)
+
+	listenErr := make(chan error, 1)
+	go func() {
+		// running on port "port" local host
+		listenErr <- routes.Listen(":" + cfg.Port)
+	}()
 
-	// running on port "port" local host
-	routes.Listen(":" + PORT)
+	code := 0
+	select {
+	case err := <-listenErr:
+		// nothing to drain, the server never served or already stopped
+		slog.Error("server stopped", "error", err)
+		code = 1
+	case <-signals:
+		// later signals are the master's business, it kills us if needed
+		signal.Ignore(syscall.SIGINT, syscall.SIGTERM)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	if code == 0 {
+		if err := routes.ShutdownWithContext(ctx); err != nil {
+			slog.Error("error draining requests", "error", err)
+			code = 1
+		}
+		if err := <-listenErr; err != nil {
+			slog.Error("server stopped", "error", err)
+			code = 1
+		}
+	}
+	if err := lifecycle.Shutdown(ctx); err != nil {
+		slog.Error("shutdown didn't finish cleanly", "error", err)
+		code = 1
+	}
+	return code
+}
+
+// UseConfig hands the packages that also run outside a request, in the job
+// workers and the sweeps, the parts of cfg they need. The handlers get
+// theirs from the router.
+func UseConfig(cfg *config.Config) {
+	ai.Setup(cfg.AI)
+	authentication.SetupSenders(cfg.Email, cfg.SMS)
+	upload.Setup(cfg.Storage, cfg.Upload)
+}
+
+// UseDatabase hands db to the helpers background work queries through and
+// returns the repositories the router gives the handlers
+func UseDatabase(db *gorm.DB) dbFunc.DatabaseHelper {
+	dbFunc.DBHelper = dbFunc.NewDatabaseHelper(db)
+	paystack.PaystackHelper = paystack.NewPaystackHelper(db)
+	return dbFunc.DBHelper
+}
+
+// runAccountDeletions purges accounts whose deletion grace period has ended
+func runAccountDeletions(ctx context.Context, accounts *profile.Handler) {
+	for {
+		if purged := accounts.PurgeDueAccountDeletions(); purged > 0 {
+			slog.Info("purged deleted accounts", "count", purged)
+		}
+		if !lifecycle.Sleep(ctx, time.Hour) {
+			return
+		}
+	}
+}
+
+// runUploadSessionCleanup deletes direct uploads that were never confirmed
+func runUploadSessionCleanup(ctx context.Context) {
+	for {
+		if deleted := upload.ExpireSessions(ctx); deleted > 0 {
+			slog.Info("deleted expired upload sessions", "count", deleted)
+		}
+		if !lifecycle.Sleep(ctx, 15*time.Minute) {
+			return
+		}
+	}
+}
+
+// runMediaSweep deletes stored images nothing uses any more, or in report
+// mode only logs what it would delete
+func runMediaSweep(ctx context.Context, dryRun bool) {
+	for {
+		report, err := upload.Sweep(ctx, dryRun)
+		if err != nil {
+			slog.Error("error sweeping media", "error", err)
+		}
+		if report.Orphaned > 0 || report.Due > 0 {
+			slog.Info("swept media", "dry_run", dryRun, "checked", report.Checked, "orphaned", report.Orphaned,
+				"due", report.Due, "deleted", report.Deleted, "kept", report.Kept, "bytes", report.Bytes, "urls", report.URLs)
+		}
+		if !lifecycle.Sleep(ctx, time.Hour) {
+			return
+		}
+	}
 }
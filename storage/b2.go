@@ -0,0 +1,158 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	config "business-connect/config"
+
+	"github.com/kurin/blazer/b2"
+)
+
+// B2 keeps objects in a Backblaze B2 bucket. The client authorizes once,
+// on first use, and renews its own authorization from there.
+type B2 struct {
+	cfg config.B2Config
+
+	mu     sync.Mutex
+	bucket *b2.Bucket
+}
+
+func NewB2(cfg config.B2Config) *B2 {
+	return &B2{cfg: cfg}
+}
+
+func (s *B2) open(ctx context.Context) (*b2.Bucket, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// a failed authorization isn't kept, the next call tries again
+	if s.bucket != nil {
+		return s.bucket, nil
+	}
+	client, err := b2.NewClient(ctx, s.cfg.KeyID, s.cfg.ApplicationKey)
+	if err != nil {
+		return nil, fmt.Errorf("error setting up B2 client: %w", err)
+	}
+	bucket, err := client.Bucket(ctx, s.cfg.BucketName)
+	if err != nil {
+		return nil, fmt.Errorf("error getting B2 bucket: %w", err)
+	}
+	s.bucket = bucket
+	return bucket, nil
+}
+
+func (s *B2) Put(ctx context.Context, key string, body io.Reader, _ int64, contentType string) error {
+	if err := ValidKey(key); err != nil {
+		return err
+	}
+	bucket, err := s.open(ctx)
+	if err != nil {
+		return err
+	}
+
+	writer := bucket.Object(key).NewWriter(ctx).WithAttrs(&b2.Attrs{ContentType: contentType})
+	if _, err := io.Copy(writer, body); err != nil {
+		writer.Close()
+		return fmt.Errorf("error uploading %s to B2: %w", key, err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("error finishing upload of %s to B2: %w", key, err)
+	}
+	return nil
+}
+
+func (s *B2) Get(ctx context.Context, key string) (*Object, error) {
+	if err := ValidKey(key); err != nil {
+		return nil, err
+	}
+	bucket, err := s.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	obj := bucket.Object(key)
+	attrs, err := obj.Attrs(ctx)
+	if err != nil {
+		if b2.IsNotExist(err) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("error reading %s from B2: %w", key, err)
+	}
+	return &Object{
+		Body:        obj.NewReader(ctx),
+		ContentType: attrs.ContentType,
+		Size:        attrs.Size,
+		ModTime:     attrs.UploadTimestamp,
+	}, nil
+}
+
+// GetRange reads only the range from B2, the object's existence isn't
+// checked until the first read
+func (s *B2) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	if err := ValidKey(key); err != nil {
+		return nil, err
+	}
+	bucket, err := s.open(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return bucket.Object(key).NewRangeReader(ctx, offset, length), nil
+}
+
+func (s *B2) Delete(ctx context.Context, key string) error {
+	if err := ValidKey(key); err != nil {
+		return err
+	}
+	bucket, err := s.open(ctx)
+	if err != nil {
+		return err
+	}
+
+	if err := bucket.Object(key).Delete(ctx); err != nil && !b2.IsNotExist(err) {
+		return fmt.Errorf("error deleting %s from B2: %w", key, err)
+	}
+	return nil
+}
+
+// SignedURL is the object's download URL with a download authorization for
+// exactly that key
+func (s *B2) SignedURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	if err := ValidKey(key); err != nil {
+		return "", err
+	}
+	bucket, err := s.open(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	token, err := bucket.AuthToken(ctx, key, expires)
+	if err != nil {
+		return "", fmt.Errorf("error authorizing download of %s: %w", key, err)
+	}
+	rawURL := bucket.Object(key).URL()
+	separator := "?"
+	if strings.Contains(rawURL, "?") {
+		separator = "&"
+	}
+	return rawURL + separator + "Authorization=" + url.QueryEscape(token), nil
+}
+
+// Ping authorizes afresh rather than reusing the client, it is the check
+// that B2 and the credentials still work. B2 bills authorizations, so the
+// readiness check wraps it in health.Cached.
+func (s *B2) Ping(ctx context.Context) error {
+	client, err := b2.NewClient(ctx, s.cfg.KeyID, s.cfg.ApplicationKey)
+	if err != nil {
+		return fmt.Errorf("error setting up B2 client: %w", err)
+	}
+	if _, err := client.Bucket(ctx, s.cfg.BucketName); err != nil {
+		return fmt.Errorf("error getting B2 bucket: %w", err)
+	}
+	return nil
+}
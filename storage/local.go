@@ -0,0 +1,265 @@
+package storage
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"mime"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Local keeps objects as files under a folder, for development and tests.
+// The API serves them itself on /storage/ (see ServeLocal), which is what
+// the default public URL of the local driver points at.
+type Local struct {
+	dir        string
+	publicURL  string
+	signingKey []byte
+}
+
+func NewLocal(dir, publicURL, signingKey string) (*Local, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, fmt.Errorf("error creating storage folder: %w", err)
+	}
+	return &Local{dir: dir, publicURL: publicURL, signingKey: []byte(signingKey)}, nil
+}
+
+func (s *Local) path(key string) string {
+	return filepath.Join(s.dir, filepath.FromSlash(key))
+}
+
+// Put writes to a file next to the final one and renames it into place, a
+// reader never sees half an object
+func (s *Local) Put(_ context.Context, key string, body io.Reader, _ int64, _ string) error {
+	if err := ValidKey(key); err != nil {
+		return err
+	}
+	path := s.path(key)
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("error creating folder for %s: %w", key, err)
+	}
+
+	file, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("error writing %s: %w", key, err)
+	}
+	if _, err := io.Copy(file, body); err != nil {
+		file.Close()
+		os.Remove(file.Name())
+		return fmt.Errorf("error writing %s: %w", key, err)
+	}
+	if err := file.Close(); err != nil {
+		os.Remove(file.Name())
+		return fmt.Errorf("error writing %s: %w", key, err)
+	}
+	if err := os.Rename(file.Name(), path); err != nil {
+		os.Remove(file.Name())
+		return fmt.Errorf("error writing %s: %w", key, err)
+	}
+	return nil
+}
+
+func (s *Local) Get(_ context.Context, key string) (*Object, error) {
+	if err := ValidKey(key); err != nil {
+		return nil, err
+	}
+	file, err := os.Open(s.path(key))
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("error reading %s: %w", key, err)
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error reading %s: %w", key, err)
+	}
+
+	// there is nowhere to keep the type given to Put, the extension is
+	// all the upload paths set it from anyway
+	contentType := mime.TypeByExtension(filepath.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	return &Object{
+		Body:        file,
+		ContentType: contentType,
+		Size:        info.Size(),
+		ModTime:     info.ModTime(),
+	}, nil
+}
+
+// section is part of a file that still closes it
+type section struct {
+	io.Reader
+	io.Closer
+}
+
+func (s *Local) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	obj, err := s.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	file := obj.Body.(*os.File)
+	if _, err := file.Seek(offset, io.SeekStart); err != nil {
+		file.Close()
+		return nil, fmt.Errorf("error reading %s: %w", key, err)
+	}
+	return section{Reader: io.LimitReader(file, length), Closer: file}, nil
+}
+
+func (s *Local) Delete(_ context.Context, key string) error {
+	if err := ValidKey(key); err != nil {
+		return err
+	}
+	if err := os.Remove(s.path(key)); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("error deleting %s: %w", key, err)
+	}
+	return nil
+}
+
+// SignedURL is the public URL of key with an expiry and an HMAC of both,
+// checked by ServeLocal
+func (s *Local) SignedURL(_ context.Context, key string, expires time.Duration) (string, error) {
+	if err := ValidKey(key); err != nil {
+		return "", err
+	}
+	expiresAt := strconv.FormatInt(time.Now().Add(expires).Unix(), 10)
+
+	query := url.Values{}
+	query.Set("expires", expiresAt)
+	query.Set("signature", s.sign(http.MethodGet, key, expiresAt))
+	return s.publicURL + escapeKey(key) + "?" + query.Encode(), nil
+}
+
+// UploadURL is the public URL of key signed for a PUT, which ReceiveLocal
+// takes in place of a bucket
+func (s *Local) UploadURL(_ context.Context, key, _ string, expires time.Duration) (string, error) {
+	if err := ValidKey(key); err != nil {
+		return "", err
+	}
+	expiresAt := strconv.FormatInt(time.Now().Add(expires).Unix(), 10)
+
+	query := url.Values{}
+	query.Set("expires", expiresAt)
+	query.Set("signature", s.sign(http.MethodPut, key, expiresAt))
+	return s.publicURL + escapeKey(key) + "?" + query.Encode(), nil
+}
+
+// sign is an HMAC of the method too, a URL signed for reading can't be
+// used to write
+func (s *Local) sign(method, key, expiresAt string) string {
+	mac := hmac.New(sha256.New, s.signingKey)
+	mac.Write([]byte(method + "\n" + key + "\n" + expiresAt))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// verify checks a signature made by SignedURL or UploadURL and that it
+// hasn't expired
+func (s *Local) verify(method, key, expiresAt, signature string) bool {
+	expires, err := strconv.ParseInt(expiresAt, 10, 64)
+	if err != nil || time.Now().Unix() > expires {
+		return false
+	}
+	return hmac.Equal([]byte(s.sign(method, key, expiresAt)), []byte(signature))
+}
+
+func (s *Local) Ping(context.Context) error {
+	info, err := os.Stat(s.dir)
+	if err != nil {
+		return fmt.Errorf("error reaching storage folder: %w", err)
+	}
+	if !info.IsDir() {
+		return fmt.Errorf("storage folder %s is not a folder", s.dir)
+	}
+	return nil
+}
+
+func escapeKey(key string) string {
+	parts := strings.Split(key, "/")
+	for i, part := range parts {
+		parts[i] = url.PathEscape(part)
+	}
+	return strings.Join(parts, "/")
+}
+
+// ServeLocal serves objects of the local driver on /storage/*. Objects are
+// public like the bucket behind the CDN, except those under privateFolder
+// which need a signature from SignedURL. A request carrying a signature is
+// checked and refused once it has expired.
+func ServeLocal(privateFolder string) fiber.Handler {
+	return func(ctx *fiber.Ctx) error {
+		local, ok := Current().(*Local)
+		if !ok {
+			return ctx.SendStatus(fiber.StatusNotFound)
+		}
+
+		key, err := url.PathUnescape(ctx.Params("*"))
+		if err != nil || ValidKey(key) != nil {
+			return ctx.SendStatus(fiber.StatusNotFound)
+		}
+
+		signature := ctx.Query("signature")
+		isPrivate := privateFolder != "" && strings.HasPrefix(key, privateFolder)
+		if signature != "" || isPrivate {
+			if !local.verify(http.MethodGet, key, ctx.Query("expires"), signature) {
+				return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+					"error": "link expired or invalid",
+				})
+			}
+		}
+
+		obj, err := local.Get(ctx.UserContext(), key)
+		if err != nil {
+			if errors.Is(err, ErrNotFound) {
+				return ctx.SendStatus(fiber.StatusNotFound)
+			}
+			return err
+		}
+		ctx.Set(fiber.HeaderContentType, obj.ContentType)
+		ctx.Set(fiber.HeaderLastModified, obj.ModTime.UTC().Format(http.TimeFormat))
+		// fasthttp closes the body once it is sent
+		return ctx.SendStream(obj.Body, int(obj.Size))
+	}
+}
+
+// ReceiveLocal takes the PUT of an upload URL from UploadURL, the local
+// stand-in for a client uploading straight to the bucket. Unlike reads, a
+// write always needs a signature.
+func ReceiveLocal(ctx *fiber.Ctx) error {
+	local, ok := Current().(*Local)
+	if !ok {
+		return ctx.SendStatus(fiber.StatusNotFound)
+	}
+
+	key, err := url.PathUnescape(ctx.Params("*"))
+	if err != nil || ValidKey(key) != nil {
+		return ctx.SendStatus(fiber.StatusNotFound)
+	}
+	if !local.verify(http.MethodPut, key, ctx.Query("expires"), ctx.Query("signature")) {
+		return ctx.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "link expired or invalid",
+		})
+	}
+
+	body := ctx.Body()
+	if err := local.Put(ctx.UserContext(), key, bytes.NewReader(body), int64(len(body)), ctx.Get(fiber.HeaderContentType)); err != nil {
+		return err
+	}
+	return ctx.SendStatus(fiber.StatusOK)
+}
@@ -0,0 +1,150 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"time"
+
+	config "business-connect/config"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3 keeps objects in a bucket of any S3 compatible service
+type S3 struct {
+	client *minio.Client
+	bucket string
+}
+
+func NewS3(cfg config.S3Config) (*S3, error) {
+	endpoint, err := url.Parse(cfg.Endpoint)
+	if err != nil || endpoint.Host == "" {
+		return nil, fmt.Errorf("invalid S3_ENDPOINT %q", cfg.Endpoint)
+	}
+
+	client, err := minio.New(endpoint.Host, &minio.Options{
+		Creds:  credentials.NewStaticV4(cfg.AccessKeyID, cfg.SecretAccessKey, ""),
+		Secure: endpoint.Scheme == "https",
+		Region: cfg.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error setting up S3 client: %w", err)
+	}
+	return &S3{client: client, bucket: cfg.Bucket}, nil
+}
+
+func (s *S3) Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error {
+	if err := ValidKey(key); err != nil {
+		return err
+	}
+	_, err := s.client.PutObject(ctx, s.bucket, key, body, size, minio.PutObjectOptions{ContentType: contentType})
+	if err != nil {
+		return fmt.Errorf("error uploading %s to S3: %w", key, err)
+	}
+	return nil
+}
+
+func (s *S3) Get(ctx context.Context, key string) (*Object, error) {
+	if err := ValidKey(key); err != nil {
+		return nil, err
+	}
+
+	// GetObject doesn't touch the network, Stat is the request that fails
+	// for a missing key
+	obj, err := s.client.GetObject(ctx, s.bucket, key, minio.GetObjectOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s from S3: %w", key, err)
+	}
+	info, err := obj.Stat()
+	if err != nil {
+		obj.Close()
+		if isS3NotFound(err) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("error reading %s from S3: %w", key, err)
+	}
+	return &Object{
+		Body:        obj,
+		ContentType: info.ContentType,
+		Size:        info.Size,
+		ModTime:     info.LastModified,
+	}, nil
+}
+
+func (s *S3) GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error) {
+	if err := ValidKey(key); err != nil {
+		return nil, err
+	}
+	var opts minio.GetObjectOptions
+	if err := opts.SetRange(offset, offset+length-1); err != nil {
+		return nil, err
+	}
+	obj, err := s.client.GetObject(ctx, s.bucket, key, opts)
+	if err != nil {
+		return nil, fmt.Errorf("error reading %s from S3: %w", key, err)
+	}
+	if _, err := obj.Stat(); err != nil {
+		obj.Close()
+		if isS3NotFound(err) {
+			return nil, ErrNotFound
+		}
+		return nil, fmt.Errorf("error reading %s from S3: %w", key, err)
+	}
+	return obj, nil
+}
+
+func (s *S3) Delete(ctx context.Context, key string) error {
+	if err := ValidKey(key); err != nil {
+		return err
+	}
+	// S3 answers a delete of a missing key with success already
+	if err := s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{}); err != nil {
+		return fmt.Errorf("error deleting %s from S3: %w", key, err)
+	}
+	return nil
+}
+
+func (s *S3) SignedURL(ctx context.Context, key string, expires time.Duration) (string, error) {
+	if err := ValidKey(key); err != nil {
+		return "", err
+	}
+	signed, err := s.client.PresignedGetObject(ctx, s.bucket, key, expires, nil)
+	if err != nil {
+		return "", fmt.Errorf("error signing URL for %s: %w", key, err)
+	}
+	return signed.String(), nil
+}
+
+// UploadURL presigns a PUT with the content type among the signed headers,
+// the client has to send the same one
+func (s *S3) UploadURL(ctx context.Context, key, contentType string, expires time.Duration) (string, error) {
+	if err := ValidKey(key); err != nil {
+		return "", err
+	}
+	headers := http.Header{}
+	headers.Set("Content-Type", contentType)
+	signed, err := s.client.PresignHeader(ctx, http.MethodPut, s.bucket, key, expires, nil, headers)
+	if err != nil {
+		return "", fmt.Errorf("error signing upload URL for %s: %w", key, err)
+	}
+	return signed.String(), nil
+}
+
+func (s *S3) Ping(ctx context.Context) error {
+	exists, err := s.client.BucketExists(ctx, s.bucket)
+	if err != nil {
+		return fmt.Errorf("error reaching S3: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("S3 bucket %q doesn't exist", s.bucket)
+	}
+	return nil
+}
+
+func isS3NotFound(err error) bool {
+	return minio.ToErrorResponse(err).StatusCode == http.StatusNotFound
+}
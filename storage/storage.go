@@ -0,0 +1,122 @@
+// Package storage keeps uploaded media in an object store.
+//
+// Storage is what every upload path writes through. Open picks the backend
+// from the config: Backblaze B2 in production, any S3 compatible service,
+// or a folder on local disk so development and tests run without a bucket.
+// Objects are addressed by key, the folder prefix and file name inside the
+// bucket, and that key is what the models store. Where a key is served from
+// is the config's PublicURL, a CDN in front of the bucket in production.
+package storage
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	config "business-connect/config"
+)
+
+// ErrNotFound is returned for a key with no object
+var ErrNotFound = errors.New("object not found")
+
+// Storage puts, reads and deletes objects by key
+type Storage interface {
+	// Put streams body to key, size is -1 when it isn't known up front
+	Put(ctx context.Context, key string, body io.Reader, size int64, contentType string) error
+	// Get opens the object at key, the caller closes its Body
+	Get(ctx context.Context, key string) (*Object, error)
+	// GetRange opens length bytes of the object at key from offset, for a
+	// Range request. The caller closes it.
+	GetRange(ctx context.Context, key string, offset, length int64) (io.ReadCloser, error)
+	// Delete removes the object at key, a missing object is not an error
+	Delete(ctx context.Context, key string) error
+	// SignedURL is a URL anyone holding it can read key from until expires
+	// has passed, for objects that aren't public
+	SignedURL(ctx context.Context, key string, expires time.Duration) (string, error)
+	// Ping checks the backend is reachable, the readiness check for storage
+	Ping(ctx context.Context) error
+}
+
+// Uploader is a Storage clients can upload to directly, so large files
+// don't pass through the API. B2's own API has no plain presigned PUT, the
+// B2 driver isn't one, B2 works through its S3 compatible endpoint instead.
+type Uploader interface {
+	// UploadURL is a URL that takes one PUT of the object at key until
+	// expires has passed
+	UploadURL(ctx context.Context, key, contentType string, expires time.Duration) (string, error)
+}
+
+// Object is an open object
+type Object struct {
+	Body        io.ReadCloser
+	ContentType string
+	Size        int64
+	ModTime     time.Time
+}
+
+// Open builds the backend cfg.Driver names
+func Open(cfg config.StorageConfig, b2Config config.B2Config) (Storage, error) {
+	switch cfg.Driver {
+	case config.StorageB2:
+		return NewB2(b2Config), nil
+	case config.StorageS3:
+		return NewS3(cfg.S3)
+	case config.StorageLocal:
+		return NewLocal(cfg.Local.Dir, cfg.PublicURL, cfg.Local.SigningKey)
+	}
+	return nil, fmt.Errorf("unknown storage driver %q", cfg.Driver)
+}
+
+var (
+	mu      sync.RWMutex
+	current Storage
+)
+
+// Use makes s the storage Current returns, call it at startup before serving
+func Use(s Storage) {
+	mu.Lock()
+	current = s
+	mu.Unlock()
+}
+
+// Current is the storage set with Use. Until then it is a local store in
+// the temp dir, so tools and tests that never call Use still work.
+func Current() Storage {
+	mu.RLock()
+	s := current
+	mu.RUnlock()
+	if s != nil {
+		return s
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	if current == nil {
+		local, err := NewLocal(filepath.Join(os.TempDir(), "business-connect-storage"), "/storage/", "development")
+		if err != nil {
+			panic("storage: " + err.Error())
+		}
+		current = local
+	}
+	return current
+}
+
+// ValidKey rejects keys that could escape a folder on disk or confuse a
+// bucket, keys are built by us but the file name part comes from users
+func ValidKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("invalid object key %q", key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == ".." || part == "." {
+			return fmt.Errorf("invalid object key %q", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,231 @@
+package upload
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	dbFunc "business-connect/database/dbHelpFunc"
+	Data "business-connect/models"
+	"business-connect/storage"
+)
+
+// what a direct upload is for, each has its own folder and limits
+const (
+	SessionPost    = "post"
+	SessionBlog    = "blog"
+	SessionProfile = "profile"
+	SessionCover   = "cover"
+)
+
+const (
+	// how long the client has to PUT the file
+	uploadURLExpiry = 15 * time.Minute
+	// and to confirm it, after that the session and the file are deleted
+	sessionExpiry = 30 * time.Minute
+	// a session confirmed just before it expired may still be processing,
+	// the cleanup leaves it this long
+	sessionCleanupGrace = 10 * time.Minute
+
+	sessionCleanupBatch = 100
+)
+
+var (
+	ErrDirectUploadUnsupported = errors.New("direct uploads aren't available, upload through the API instead")
+	ErrSessionExpired          = errors.New("upload session expired, start a new one")
+	ErrSessionUsed             = errors.New("upload session was already confirmed or refused, start a new one")
+	ErrNothingUploaded         = errors.New("nothing was uploaded for this session yet")
+)
+
+// SessionLimits are the limits for a direct upload other than to a post,
+// posts go by PostLimits
+func SessionLimits(purpose string) Limits {
+	if purpose == SessionBlog {
+		return capped(blogLimits)
+	}
+	return capped(profileLimits)
+}
+
+func sessionFolder(purpose string) string {
+	switch purpose {
+	case SessionPost:
+		return folders.PostFolder
+	case SessionBlog:
+		return folders.BlogFolder
+	}
+	return folders.ProfileFolder
+}
+
+// StartSession checks one image or video of size bytes may be uploaded
+// within limits and userID's quota, and returns the session with the URL
+// the client PUTs it to. The caller has checked the target is the user's
+// and has room for it.
+func StartSession(ctx context.Context, userID uint, purpose string, targetID uint, limits Limits, filename, contentType string, size int64) (Data.UploadSession, string, error) {
+	uploader, ok := storage.Current().(storage.Uploader)
+	if !ok {
+		return Data.UploadSession{}, "", ErrDirectUploadUnsupported
+	}
+
+	switch {
+	case videoTypes[contentType]:
+		if err := checkVideoCount(limits, "this upload", 1); err != nil {
+			return Data.UploadSession{}, "", err
+		}
+		if err := checkVideoSize(limits, filename, size); err != nil {
+			return Data.UploadSession{}, "", err
+		}
+	case imageTypes[contentType]:
+		if err := checkSize(limits, filename, size); err != nil {
+			return Data.UploadSession{}, "", err
+		}
+	default:
+		return Data.UploadSession{}, "", ErrNotImage
+	}
+	if err := checkQuota(userID, size); err != nil {
+		return Data.UploadSession{}, "", err
+	}
+
+	token, err := sessionToken()
+	if err != nil {
+		return Data.UploadSession{}, "", err
+	}
+	session := Data.UploadSession{
+		Token:       token,
+		UserID:      userID,
+		Purpose:     purpose,
+		TargetID:    targetID,
+		ObjectKey:   folders.UploadFolder + token,
+		Filename:    cleanFilename(filename),
+		ContentType: contentType,
+		MaxBytes:    size,
+		Status:      dbFunc.UploadPending,
+		ExpiresAt:   time.Now().Add(sessionExpiry).Unix(),
+	}
+
+	uploadURL, err := uploader.UploadURL(ctx, session.ObjectKey, contentType, uploadURLExpiry)
+	if err != nil {
+		return Data.UploadSession{}, "", err
+	}
+	if err := dbFunc.DBHelper.CreateUploadSession(&session); err != nil {
+		return Data.UploadSession{}, "", err
+	}
+	return session, uploadURL, nil
+}
+
+// FinishSession processes what the client uploaded for session like any
+// other image or video, within limits, and deletes the upload. A file that
+// isn't acceptable refuses the session for good, a server side failure
+// leaves it to be confirmed again.
+func FinishSession(ctx context.Context, session Data.UploadSession, limits Limits) (Image, error) {
+	if time.Now().Unix() > session.ExpiresAt {
+		return Image{}, ErrSessionExpired
+	}
+	if session.Status != dbFunc.UploadPending {
+		return Image{}, ErrSessionUsed
+	}
+	if err := dbFunc.DBHelper.MoveUploadSession(session.ID, dbFunc.UploadPending, dbFunc.UploadConfirming, 0); err != nil {
+		if errors.Is(err, dbFunc.ErrSessionNotInState) {
+			return Image{}, ErrSessionUsed
+		}
+		return Image{}, err
+	}
+
+	store := storage.Current()
+	image, err := processSession(ctx, store, session, limits)
+	if _, invalid := ClientError(err); invalid && !errors.Is(err, ErrNothingUploaded) {
+		refuseSession(ctx, store, session)
+		return Image{}, err
+	}
+	if err != nil {
+		// the client may upload or confirm again
+		if moveErr := dbFunc.DBHelper.MoveUploadSession(session.ID, dbFunc.UploadConfirming, dbFunc.UploadPending, 0); moveErr != nil {
+			slog.Error("error reopening upload session", "session_id", session.ID, "error", moveErr)
+		}
+		return Image{}, err
+	}
+
+	if err := store.Delete(ctx, session.ObjectKey); err != nil {
+		// the cleanup deletes it with the session
+		slog.Warn("error deleting processed upload", "key", session.ObjectKey, "error", err)
+	}
+	if err := dbFunc.DBHelper.MoveUploadSession(session.ID, dbFunc.UploadConfirming, dbFunc.UploadConfirmed, time.Now().Unix()); err != nil {
+		Discard(ctx, image)
+		return Image{}, err
+	}
+	return image, nil
+}
+
+func processSession(ctx context.Context, store storage.Storage, session Data.UploadSession, limits Limits) (Image, error) {
+	obj, err := store.Get(ctx, session.ObjectKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return Image{}, ErrNothingUploaded
+	}
+	if err != nil {
+		return Image{}, err
+	}
+	defer obj.Body.Close()
+
+	// the URL can't stop a bigger file, the size is checked here instead
+	if obj.Size > session.MaxBytes {
+		return Image{}, fmt.Errorf("%s: %w, it is bigger than the %s declared", session.Filename, ErrFileTooLarge, megabytes(session.MaxBytes))
+	}
+	if videoTypes[session.ContentType] {
+		return storeVideo(ctx, store, sessionFolder(session.Purpose), session.Filename, obj.Body, limits)
+	}
+	return storeImage(ctx, store, sessionFolder(session.Purpose), session.Filename, obj.Body)
+}
+
+// refuseSession deletes an unacceptable upload and closes its session
+func refuseSession(ctx context.Context, store storage.Storage, session Data.UploadSession) {
+	if err := store.Delete(ctx, session.ObjectKey); err != nil {
+		slog.Warn("error deleting refused upload", "key", session.ObjectKey, "error", err)
+	}
+	if err := dbFunc.DBHelper.MoveUploadSession(session.ID, dbFunc.UploadConfirming, dbFunc.UploadFailed, 0); err != nil {
+		slog.Error("error refusing upload session", "session_id", session.ID, "error", err)
+	}
+}
+
+// Discard deletes every variant of an image that won't be used after all
+func Discard(ctx context.Context, image Image) {
+	deleteImages(ctx, storage.Current(), []Image{image})
+}
+
+// ExpireSessions deletes sessions past their expiry and anything uploaded
+// for them, it returns how many were deleted
+func ExpireSessions(ctx context.Context) int {
+	before := time.Now().Add(-sessionCleanupGrace).Unix()
+	sessions, err := dbFunc.DBHelper.GetExpiredUploadSessions(before, sessionCleanupBatch)
+	if err != nil {
+		slog.Error("error getting expired upload sessions", "error", err)
+		return 0
+	}
+
+	store := storage.Current()
+	deleted := 0
+	for _, session := range sessions {
+		// confirmed sessions had their upload deleted already, a missing
+		// object isn't an error
+		if err := store.Delete(ctx, session.ObjectKey); err != nil {
+			slog.Warn("error deleting expired upload", "key", session.ObjectKey, "error", err)
+			continue
+		}
+		if err := dbFunc.DBHelper.DeleteUploadSession(session.ID); err != nil {
+			slog.Error("error deleting upload session", "session_id", session.ID, "error", err)
+			continue
+		}
+		deleted++
+	}
+	return deleted
+}
+
+func sessionToken() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,224 @@
+package upload
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+	"time"
+
+	dbFunc "business-connect/database/dbHelpFunc"
+	Data "business-connect/models"
+	"business-connect/storage"
+)
+
+const (
+	// how long a released image is kept before it is deleted, pages and
+	// caches still point at it for a while
+	mediaDeleteDelay = 24 * time.Hour
+	// an image stored this recently may not be on its post yet
+	mediaSweepGrace = time.Hour
+	// how often an image in use is checked again
+	mediaRecheckInterval = 24 * time.Hour
+
+	mediaSweepBatch = 200
+	// most images one sweep looks at, the rest wait for the next
+	mediaSweepMax = 5000
+	// most URLs a report lists
+	mediaReportURLs = 50
+)
+
+// SweepReport is what a sweep found. Bytes and URLs are the images nothing
+// uses, whether deleted now, scheduled or, in a dry run, neither.
+type SweepReport struct {
+	DryRun bool `json:"dry_run"`
+	// images in use or not yet scheduled that were checked
+	Checked int `json:"checked"`
+	// of those, the ones nothing uses, scheduled for deletion
+	Orphaned int `json:"orphaned"`
+	// scheduled images whose time had come
+	Due int `json:"due"`
+	// of those, the ones deleted and the ones in use again
+	Deleted int      `json:"deleted"`
+	Kept    int      `json:"kept"`
+	Bytes   int64    `json:"bytes"`
+	URLs    []string `json:"urls"`
+}
+
+func (report *SweepReport) unused(object Data.MediaObject) {
+	report.Bytes += object.Bytes
+	if len(report.URLs) < mediaReportURLs {
+		report.URLs = append(report.URLs, object.URL)
+	}
+}
+
+// Release schedules images that were replaced or whose upload was undone
+// for deletion, the sweep deletes them unless something uses them again
+func Release(urls ...string) {
+	after := time.Now().Add(mediaDeleteDelay).Unix()
+	if err := dbFunc.DBHelper.ScheduleMediaDeletes(urls, after); err != nil {
+		slog.Error("error releasing images", "count", len(urls), "error", err)
+	}
+}
+
+// ReleasePost schedules the images of a deleted post for deletion
+func ReleasePost(postID uint) {
+	after := time.Now().Add(mediaDeleteDelay).Unix()
+	if err := dbFunc.DBHelper.SchedulePostMediaDeletes(postID, after); err != nil {
+		slog.Error("error releasing post images", "post_id", postID, "error", err)
+	}
+}
+
+// ReleaseBlog schedules the images of a deleted blog for deletion
+func ReleaseBlog(blogID uint) {
+	after := time.Now().Add(mediaDeleteDelay).Unix()
+	if err := dbFunc.DBHelper.ScheduleBlogMediaDeletes(blogID, after); err != nil {
+		slog.Error("error releasing blog images", "blog_id", blogID, "error", err)
+	}
+}
+
+// Sweep reconciles stored images against the posts, blogs and profiles
+// using them. Images whose deletion is due are deleted from storage unless
+// something uses them again, and images nothing uses are scheduled, so an
+// image is only deleted after two checks a day apart. A dry run changes
+// nothing and only reports.
+func Sweep(ctx context.Context, dryRun bool) (SweepReport, error) {
+	report := SweepReport{DryRun: dryRun, URLs: []string{}}
+	now := time.Now()
+	store := storage.Current()
+
+	var afterID uint
+	for report.Due < mediaSweepMax {
+		objects, err := dbFunc.DBHelper.GetDueMediaDeletes(now.Unix(), afterID, mediaSweepBatch)
+		if err != nil {
+			return report, err
+		}
+		if len(objects) == 0 {
+			break
+		}
+		afterID = objects[len(objects)-1].ID
+
+		used, err := referenced(objects)
+		if err != nil {
+			return report, err
+		}
+		var kept []uint
+		for _, object := range objects {
+			report.Due++
+			if used[object.URL] {
+				kept = append(kept, object.ID)
+				continue
+			}
+			report.unused(object)
+			if !dryRun && deleteObject(ctx, store, object) {
+				report.Deleted++
+			}
+		}
+		report.Kept += len(kept)
+		if !dryRun {
+			if err := dbFunc.DBHelper.SetMediaSchedule(kept, 0, now.Unix()); err != nil {
+				return report, err
+			}
+		}
+		if len(objects) < mediaSweepBatch {
+			break
+		}
+	}
+
+	afterID = 0
+	for report.Checked < mediaSweepMax {
+		objects, err := dbFunc.DBHelper.GetMediaToCheck(now.Add(-mediaSweepGrace), now.Add(-mediaRecheckInterval).Unix(), afterID, mediaSweepBatch)
+		if err != nil {
+			return report, err
+		}
+		if len(objects) == 0 {
+			break
+		}
+		afterID = objects[len(objects)-1].ID
+
+		used, err := referenced(objects)
+		if err != nil {
+			return report, err
+		}
+		var orphans, inUse []uint
+		for _, object := range objects {
+			report.Checked++
+			if used[object.URL] {
+				inUse = append(inUse, object.ID)
+				continue
+			}
+			orphans = append(orphans, object.ID)
+			report.unused(object)
+		}
+		report.Orphaned += len(orphans)
+		if !dryRun {
+			if err := dbFunc.DBHelper.SetMediaSchedule(orphans, now.Add(mediaDeleteDelay).Unix(), now.Unix()); err != nil {
+				return report, err
+			}
+			if err := dbFunc.DBHelper.SetMediaSchedule(inUse, 0, now.Unix()); err != nil {
+				return report, err
+			}
+		}
+		if len(objects) < mediaSweepBatch {
+			break
+		}
+	}
+	return report, nil
+}
+
+// Backfill records the images of posts, blogs and profiles stored before
+// images were tracked, so sweeps check them too. It returns how many were
+// recorded.
+func Backfill() (int, error) {
+	recorded := 0
+	for {
+		objects, err := dbFunc.DBHelper.GetUntrackedMedia(mediaSweepBatch)
+		if err != nil {
+			return recorded, err
+		}
+		for i := range objects {
+			if err := dbFunc.DBHelper.TrackMedia(&objects[i]); err != nil {
+				return recorded, err
+			}
+			recorded++
+		}
+		if len(objects) < mediaSweepBatch {
+			break
+		}
+	}
+	return recorded, nil
+}
+
+func referenced(objects []Data.MediaObject) (map[string]bool, error) {
+	urls := make([]string, 0, len(objects))
+	for _, object := range objects {
+		urls = append(urls, object.URL)
+	}
+	found, err := dbFunc.DBHelper.ReferencedMedia(urls)
+	if err != nil {
+		return nil, err
+	}
+	used := make(map[string]bool, len(found))
+	for _, url := range found {
+		used[url] = true
+	}
+	return used, nil
+}
+
+// deleteObject deletes every variant of object and its record, when a
+// variant can't be deleted the record stays for the next sweep to retry
+func deleteObject(ctx context.Context, store storage.Storage, object Data.MediaObject) bool {
+	for _, key := range strings.Split(object.ObjectKeys, "\n") {
+		if key == "" {
+			continue
+		}
+		if err := store.Delete(ctx, key); err != nil {
+			slog.Warn("error deleting unused image", "key", key, "error", err)
+			return false
+		}
+	}
+	if err := dbFunc.DBHelper.DeleteMediaObject(object.ID); err != nil {
+		slog.Error("error deleting media object", "url", object.URL, "error", err)
+		return false
+	}
+	return true
+}
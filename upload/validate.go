@@ -0,0 +1,241 @@
+package upload
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode"
+
+	dbFunc "business-connect/database/dbHelpFunc"
+	"business-connect/imaging"
+	"business-connect/video"
+)
+
+var (
+	ErrTooManyFiles  = errors.New("too many files")
+	ErrFileTooLarge  = errors.New("image file is too large")
+	ErrNotImage      = errors.New("file is not an image, upload a JPEG, PNG, GIF, WebP or BMP")
+	ErrQuotaExceeded = errors.New("storage quota exceeded, delete some posts to make room")
+	ErrVideoTooLong  = errors.New("video is too long")
+)
+
+// ClientError reports whether err is about the upload rather than the
+// server and the status to answer with, its message is for the user
+func ClientError(err error) (int, bool) {
+	switch {
+	case err == nil:
+		return 0, false
+	case errors.Is(err, ErrTooManyFiles):
+		return 400, true
+	case errors.Is(err, ErrFileTooLarge), errors.Is(err, imaging.ErrTooLarge), errors.Is(err, ErrVideoTooLong):
+		return 413, true
+	case errors.Is(err, ErrNotImage), errors.Is(err, imaging.ErrUnsupported), errors.Is(err, video.ErrUnsupported):
+		return 415, true
+	case errors.Is(err, ErrQuotaExceeded):
+		return 403, true
+	case errors.Is(err, ErrNothingUploaded), errors.Is(err, ErrSessionUsed):
+		return 409, true
+	case errors.Is(err, ErrSessionExpired):
+		return 410, true
+	case errors.Is(err, ErrDirectUploadUnsupported):
+		return 501, true
+	}
+	return 0, false
+}
+
+// Limits is how many files one upload takes and how big each may be, the
+// size is capped by UPLOAD_MAX_FILE_MB. Videos count as files and have
+// limits of their own, capped by UPLOAD_MAX_VIDEO_MB and
+// UPLOAD_MAX_VIDEO_SECONDS, none are allowed when MaxVideos is 0.
+type Limits struct {
+	MaxFiles        int
+	MaxFileBytes    int64
+	MaxVideos       int
+	MaxVideoBytes   int64
+	MaxVideoSeconds int
+}
+
+var (
+	// posts sell things, business posts show a product from every side and
+	// statuses are one short clip or photo
+	postLimits = map[string]Limits{
+		dbFunc.PostTypePersonal: {MaxFiles: 4, MaxFileBytes: 8 << 20, MaxVideos: 1, MaxVideoBytes: 25 << 20, MaxVideoSeconds: 60},
+		dbFunc.PostTypeBusiness: {MaxFiles: 10, MaxFileBytes: 10 << 20, MaxVideos: 2, MaxVideoBytes: 50 << 20, MaxVideoSeconds: 120},
+		dbFunc.PostTypeGroup:    {MaxFiles: 4, MaxFileBytes: 8 << 20, MaxVideos: 1, MaxVideoBytes: 25 << 20, MaxVideoSeconds: 60},
+		dbFunc.PostTypeEvent:    {MaxFiles: 4, MaxFileBytes: 8 << 20, MaxVideos: 1, MaxVideoBytes: 25 << 20, MaxVideoSeconds: 60},
+		dbFunc.PostTypeAd:       {MaxFiles: 6, MaxFileBytes: 5 << 20, MaxVideos: 1, MaxVideoBytes: 25 << 20, MaxVideoSeconds: 30},
+		dbFunc.PostTypeStatus:   {MaxFiles: 1, MaxFileBytes: 8 << 20, MaxVideos: 1, MaxVideoBytes: 25 << 20, MaxVideoSeconds: 30},
+	}
+	defaultPostLimits = Limits{MaxFiles: 4, MaxFileBytes: 8 << 20, MaxVideos: 1, MaxVideoBytes: 25 << 20, MaxVideoSeconds: 60}
+
+	blogLimits    = Limits{MaxFiles: 3, MaxFileBytes: 10 << 20}
+	profileLimits = Limits{MaxFiles: 1, MaxFileBytes: 5 << 20}
+)
+
+// PostLimits are the limits for a post type, an unknown type gets the
+// personal ones
+func PostLimits(postType string) Limits {
+	limits, ok := postLimits[postType]
+	if !ok {
+		limits = defaultPostLimits
+	}
+	return capped(limits)
+}
+
+func capped(limits Limits) Limits {
+	if ceiling := settings.MaxFileBytes; ceiling > 0 && limits.MaxFileBytes > ceiling {
+		limits.MaxFileBytes = ceiling
+	}
+	if ceiling := settings.MaxVideoBytes; ceiling > 0 && limits.MaxVideoBytes > ceiling {
+		limits.MaxVideoBytes = ceiling
+	}
+	if ceiling := settings.MaxVideoSeconds; ceiling > 0 && limits.MaxVideoSeconds > ceiling {
+		limits.MaxVideoSeconds = ceiling
+	}
+	return limits
+}
+
+// check turns away an upload with too many files or videos, a file over
+// the size limit or one that would take userID over their quota, before
+// anything is read or stored
+func check(userID uint, limits Limits, what string, images, videos []*multipart.FileHeader) error {
+	if len(images)+len(videos) > limits.MaxFiles {
+		return fmt.Errorf("%w, %s takes at most %d", ErrTooManyFiles, what, limits.MaxFiles)
+	}
+	if err := checkVideoCount(limits, what, len(videos)); err != nil {
+		return err
+	}
+
+	var incoming int64
+	for _, fileHeader := range images {
+		if err := checkSize(limits, fileHeader.Filename, fileHeader.Size); err != nil {
+			return err
+		}
+		incoming += fileHeader.Size
+	}
+	for _, fileHeader := range videos {
+		if err := checkVideoSize(limits, fileHeader.Filename, fileHeader.Size); err != nil {
+			return err
+		}
+		incoming += fileHeader.Size
+	}
+	return checkQuota(userID, incoming)
+}
+
+func checkVideoCount(limits Limits, what string, count int) error {
+	if count > 0 && limits.MaxVideos == 0 {
+		return fmt.Errorf("%w, %s can't have videos", ErrTooManyFiles, what)
+	}
+	if count > limits.MaxVideos {
+		return fmt.Errorf("%w, %s takes at most %d videos", ErrTooManyFiles, what, limits.MaxVideos)
+	}
+	return nil
+}
+
+func checkSize(limits Limits, filename string, size int64) error {
+	if size > limits.MaxFileBytes {
+		return fmt.Errorf("%s: %w, the limit is %s", cleanFilename(filename), ErrFileTooLarge, megabytes(limits.MaxFileBytes))
+	}
+	return nil
+}
+
+func checkVideoSize(limits Limits, filename string, size int64) error {
+	if size > limits.MaxVideoBytes {
+		return fmt.Errorf("%s: %w, the limit for a video is %s", cleanFilename(filename), ErrFileTooLarge, megabytes(limits.MaxVideoBytes))
+	}
+	return nil
+}
+
+// checkDuration refuses a video longer than limits allow
+func checkDuration(limits Limits, filename string, duration time.Duration) error {
+	if duration > time.Duration(limits.MaxVideoSeconds)*time.Second {
+		return fmt.Errorf("%s: %w, the limit is %d seconds", filename, ErrVideoTooLong, limits.MaxVideoSeconds)
+	}
+	return nil
+}
+
+// imageTypes are the content types an image may be uploaded as
+var imageTypes = map[string]bool{"image/jpeg": true, "image/png": true, "image/gif": true, "image/webp": true, "image/bmp": true}
+
+// videoTypes are the content types a video may be uploaded as
+var videoTypes = map[string]bool{"video/mp4": true, "video/quicktime": true}
+
+// IsVideo reports whether contentType is one a video is uploaded as
+func IsVideo(contentType string) bool {
+	return videoTypes[contentType]
+}
+
+// checkQuota refuses incoming more bytes for userID once they would go
+// over UPLOAD_QUOTA_MB
+func checkQuota(userID uint, incoming int64) error {
+	quota := settings.QuotaBytes
+	if quota <= 0 {
+		return nil
+	}
+	used, err := dbFunc.DBHelper.StorageUsedByUser(userID)
+	if err != nil {
+		return err
+	}
+	// the stored variants are usually smaller than the upload, counting the
+	// upload errs on the side of the quota
+	if used+incoming > quota {
+		return fmt.Errorf("%w, %s of %s used", ErrQuotaExceeded, megabytes(used), megabytes(quota))
+	}
+	return nil
+}
+
+// sniff reads the start of r and checks the bytes are an image, whatever
+// the name or declared type says. The returned reader still has every byte.
+func sniff(r io.Reader) (io.Reader, error) {
+	head := make([]byte, 512)
+	n, err := io.ReadFull(r, head)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	head = head[:n]
+
+	if !imageTypes[http.DetectContentType(head)] {
+		return nil, ErrNotImage
+	}
+	return io.MultiReader(strings.NewReader(string(head)), r), nil
+}
+
+// cleanFilename makes a user's file name safe to put in a storage key and
+// show back: no folders, only letters, digits, dots, dashes and
+// underscores, and not too long
+func cleanFilename(name string) string {
+	// browsers on Windows have sent full paths
+	name = filepath.Base(strings.ReplaceAll(name, `\`, "/"))
+
+	var b strings.Builder
+	for _, r := range name {
+		switch {
+		case r < unicode.MaxASCII && (unicode.IsLetter(r) || unicode.IsDigit(r)), r == '.', r == '-', r == '_':
+			b.WriteRune(r)
+		case r == ' ':
+			b.WriteRune('-')
+		}
+	}
+
+	cleaned := strings.Trim(b.String(), ".-_")
+	if len(cleaned) > 64 {
+		ext := filepath.Ext(cleaned)
+		if len(ext) > 10 {
+			ext = ""
+		}
+		cleaned = cleaned[:64-len(ext)] + ext
+	}
+	if cleaned == "" || strings.TrimSuffix(cleaned, filepath.Ext(cleaned)) == "" {
+		return "image" + filepath.Ext(cleaned)
+	}
+	return cleaned
+}
+
+func megabytes(n int64) string {
+	return fmt.Sprintf("%.1f MB", float64(n)/(1<<20))
+}
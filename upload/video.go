@@ -0,0 +1,107 @@
+package upload
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+
+	dbFunc "business-connect/database/dbHelpFunc"
+	"business-connect/imaging"
+	Data "business-connect/models"
+	"business-connect/storage"
+	"business-connect/video"
+)
+
+// storeVideo checks r is an MP4 or MOV within limits and stores it as it
+// is, with the variants of its poster frame when ffmpeg can take one. Like
+// an image it is recorded for the sweeper.
+func storeVideo(ctx context.Context, store storage.Storage, folder, filename string, r io.Reader, limits Limits) (Image, error) {
+	filename = cleanFilename(filename)
+
+	// the box structure is read by offset and ffmpeg wants a file, the
+	// upload is spooled to disk either way
+	tmp, err := os.CreateTemp("", "video-*"+filepath.Ext(filename))
+	if err != nil {
+		return Image{}, err
+	}
+	defer os.Remove(tmp.Name())
+	defer tmp.Close()
+
+	size, err := io.Copy(tmp, io.LimitReader(r, limits.MaxVideoBytes+1))
+	if err != nil {
+		return Image{}, err
+	}
+	if err := checkVideoSize(limits, filename, size); err != nil {
+		return Image{}, err
+	}
+	info, err := video.Probe(tmp, size)
+	if err != nil {
+		return Image{}, err
+	}
+	if err := checkDuration(limits, filename, info.Duration); err != nil {
+		return Image{}, err
+	}
+
+	base := objectBase(folder, filename)
+	image := Image{
+		URL:              base + info.Ext,
+		OriginalFilename: filename,
+		Kind:             dbFunc.MediaVideo,
+		Variants:         Data.ImageVariants{Width: info.Width, Height: info.Height, Bytes: size},
+		Video:            Data.VideoDetails{ContentType: info.ContentType, DurationMs: info.Duration.Milliseconds()},
+	}
+	if _, err := tmp.Seek(0, io.SeekStart); err != nil {
+		return Image{}, err
+	}
+	if err := store.Put(ctx, image.URL, tmp, size, info.ContentType); err != nil {
+		return Image{}, err
+	}
+	image.keys = append(image.keys, image.URL)
+
+	// a video without a poster still plays, feeds show a placeholder
+	if err := putPoster(ctx, store, base+"_poster", tmp.Name(), info, &image); err != nil {
+		if errors.Is(err, video.ErrNoFFmpeg) {
+			slog.Warn("video stored without a poster", "key", image.URL, "error", err)
+		} else {
+			slog.Error("error making video poster", "key", image.URL, "error", err)
+		}
+	}
+
+	if err := track(ctx, store, image); err != nil {
+		return Image{}, err
+	}
+	return image, nil
+}
+
+// putPoster takes the poster frame of the video at path and stores it in
+// every size, the video keeps its own width and height
+func putPoster(ctx context.Context, store storage.Storage, base, path string, info *video.Info, image *Image) error {
+	frame, err := video.Poster(ctx, settings.FFmpegPath, path, video.PosterAt(info.Duration))
+	if err != nil {
+		return err
+	}
+	processed, err := imaging.Process(bytes.NewReader(frame))
+	if err != nil {
+		return fmt.Errorf("poster frame: %w", err)
+	}
+	var poster Image
+	full, err := putVariants(ctx, store, base, processed, &poster)
+	if err != nil {
+		return err
+	}
+
+	image.keys = append(image.keys, poster.keys...)
+	image.Video.PosterURL = full
+	image.Variants.FullWebpURL = poster.Variants.FullWebpURL
+	image.Variants.FeedURL = poster.Variants.FeedURL
+	image.Variants.FeedWebpURL = poster.Variants.FeedWebpURL
+	image.Variants.ThumbURL = poster.Variants.ThumbURL
+	image.Variants.ThumbWebpURL = poster.Variants.ThumbWebpURL
+	image.Variants.Bytes += poster.Variants.Bytes
+	return nil
+}
@@ -0,0 +1,57 @@
+package video
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"time"
+)
+
+// ErrNoFFmpeg is returned by Poster when ffmpeg isn't installed
+var ErrNoFFmpeg = errors.New("ffmpeg isn't installed, videos get no poster")
+
+// how long Poster lets ffmpeg run
+const posterTimeout = 30 * time.Second
+
+// PosterAt is the moment the poster frame is taken from, a second in so it
+// isn't the black or blurred first frame, or halfway through a shorter
+// video
+func PosterAt(duration time.Duration) time.Duration {
+	if duration < 2*time.Second {
+		return duration / 2
+	}
+	return time.Second
+}
+
+// Poster decodes the frame at at from the video file at path with ffmpeg,
+// found at ffmpegPath, and returns it as a PNG. ffmpeg turns the frame
+// upright as the video's rotation says.
+func Poster(ctx context.Context, ffmpegPath, path string, at time.Duration) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, posterTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, ffmpegPath,
+		"-v", "error",
+		"-ss", fmt.Sprintf("%.3f", at.Seconds()),
+		"-i", path,
+		"-frames:v", "1",
+		"-f", "image2pipe",
+		"-c:v", "png",
+		"pipe:1",
+	)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, ErrNoFFmpeg
+		}
+		return nil, fmt.Errorf("ffmpeg: %w: %s", err, bytes.TrimSpace(stderr.Bytes()))
+	}
+	if stdout.Len() == 0 {
+		return nil, errors.New("ffmpeg returned no frame")
+	}
+	return stdout.Bytes(), nil
+}
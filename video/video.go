@@ -0,0 +1,318 @@
+// Package video reads what the API needs to know about an uploaded video
+// and takes the poster frame feeds show before it plays.
+//
+// Probe reads the MP4 or QuickTime (MOV) box structure, which is every
+// video a phone records, so the duration and size are known without
+// decoding a frame and a file that isn't a video is refused whatever its
+// name says. The video itself is stored as it was uploaded. Poster needs
+// ffmpeg, the only frame decoder the server has.
+package video
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+	"time"
+)
+
+var (
+	// ErrUnsupported is returned for anything that isn't an MP4 or MOV with
+	// a video track
+	ErrUnsupported = errors.New("file is not a supported video, use MP4 or MOV")
+)
+
+// formats Probe accepts
+const (
+	FormatMP4 = "mp4"
+	FormatMOV = "mov"
+)
+
+const (
+	// most boxes Probe looks at, a real file has a few dozen
+	maxBoxes = 10_000
+	// largest box payload Probe reads into memory, the ones it reads are
+	// tens of bytes
+	maxPayload = 1 << 10
+)
+
+// Info is what Probe found
+type Info struct {
+	Format      string
+	ContentType string
+	Ext         string
+	Duration    time.Duration
+	Width       int
+	Height      int
+}
+
+// Probe reads the header boxes of the size bytes in r
+func Probe(r io.ReaderAt, size int64) (*Info, error) {
+	p := &prober{r: r}
+
+	first, err := p.box(0, size)
+	if err != nil || first.kind != "ftyp" {
+		return nil, ErrUnsupported
+	}
+	brand, err := p.payload(first, 4)
+	if err != nil {
+		return nil, ErrUnsupported
+	}
+	info := &Info{Format: FormatMP4, ContentType: "video/mp4", Ext: ".mp4"}
+	if string(brand) == "qt  " {
+		info = &Info{Format: FormatMOV, ContentType: "video/quicktime", Ext: ".mov"}
+	}
+
+	moov, ok, err := p.find(first.end, size, "moov")
+	if err != nil || !ok {
+		return nil, ErrUnsupported
+	}
+	if err := p.movie(moov, info); err != nil {
+		return nil, err
+	}
+	if info.Duration <= 0 || info.Width <= 0 || info.Height <= 0 {
+		return nil, ErrUnsupported
+	}
+	return info, nil
+}
+
+type box struct {
+	kind string
+	// where the payload starts and the box ends
+	start, end int64
+}
+
+type prober struct {
+	r     io.ReaderAt
+	boxes int
+}
+
+// box reads the header of the box at off, which must end by limit
+func (p *prober) box(off, limit int64) (box, error) {
+	p.boxes++
+	if p.boxes > maxBoxes {
+		return box{}, ErrUnsupported
+	}
+
+	var header [16]byte
+	if _, err := p.r.ReadAt(header[:8], off); err != nil {
+		return box{}, ErrUnsupported
+	}
+	size := int64(binary.BigEndian.Uint32(header[:4]))
+	b := box{kind: string(header[4:8]), start: off + 8}
+	switch size {
+	case 0:
+		// runs to the end of its parent
+		size = limit - off
+	case 1:
+		if _, err := p.r.ReadAt(header[8:16], off+8); err != nil {
+			return box{}, ErrUnsupported
+		}
+		size = int64(binary.BigEndian.Uint64(header[8:16]))
+		b.start += 8
+	}
+	b.end = off + size
+	if size < b.start-off || b.end > limit {
+		return box{}, ErrUnsupported
+	}
+	return b, nil
+}
+
+// find returns the first box of kind between off and end
+func (p *prober) find(off, end int64, kind string) (box, bool, error) {
+	for off < end {
+		b, err := p.box(off, end)
+		if err != nil {
+			return box{}, false, err
+		}
+		if b.kind == kind {
+			return b, true, nil
+		}
+		off = b.end
+	}
+	return box{}, false, nil
+}
+
+// payload reads the first n bytes of b's payload
+func (p *prober) payload(b box, n int) ([]byte, error) {
+	if n > maxPayload || int64(n) > b.end-b.start {
+		return nil, ErrUnsupported
+	}
+	buf := make([]byte, n)
+	if _, err := p.r.ReadAt(buf, b.start); err != nil {
+		return nil, ErrUnsupported
+	}
+	return buf, nil
+}
+
+// movie reads the duration from mvhd, or mehd for a fragmented file, and
+// the size from the first video track
+func (p *prober) movie(moov box, info *Info) error {
+	for off := moov.start; off < moov.end; {
+		b, err := p.box(off, moov.end)
+		if err != nil {
+			return err
+		}
+		off = b.end
+
+		switch b.kind {
+		case "mvhd":
+			duration, err := p.movieHeader(b)
+			if err != nil {
+				return err
+			}
+			if duration > 0 {
+				info.Duration = duration
+			}
+		case "mvex":
+			// fragmented files may leave mvhd at 0 and give the length here
+			mehd, ok, err := p.find(b.start, b.end, "mehd")
+			if err != nil {
+				return err
+			}
+			if ok && info.Duration == 0 {
+				if info.Duration, err = p.fragmentDuration(moov, mehd); err != nil {
+					return err
+				}
+			}
+		case "trak":
+			if info.Width > 0 {
+				continue
+			}
+			if err := p.track(b, info); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// movieHeader reads the duration from mvhd, 0 when it isn't given.
+// Version 1 widens the times before the timescale and the duration to 64
+// bits.
+func (p *prober) movieHeader(mvhd box) (time.Duration, error) {
+	version, err := p.payload(mvhd, 1)
+	if err != nil {
+		return 0, err
+	}
+	if version[0] == 1 {
+		buf, err := p.payload(mvhd, 32)
+		if err != nil {
+			return 0, err
+		}
+		return toDuration(binary.BigEndian.Uint64(buf[24:]), binary.BigEndian.Uint32(buf[20:])), nil
+	}
+	buf, err := p.payload(mvhd, 20)
+	if err != nil {
+		return 0, err
+	}
+	duration := binary.BigEndian.Uint32(buf[16:])
+	if duration == 1<<32-1 {
+		// all ones is unknown
+		return 0, nil
+	}
+	return toDuration(uint64(duration), binary.BigEndian.Uint32(buf[12:])), nil
+}
+
+// fragmentDuration reads the duration of a fragmented file from mehd, in
+// the timescale of mvhd
+func (p *prober) fragmentDuration(moov, mehd box) (time.Duration, error) {
+	mvhd, ok, err := p.find(moov.start, moov.end, "mvhd")
+	if err != nil || !ok {
+		return 0, ErrUnsupported
+	}
+	version, err := p.payload(mvhd, 1)
+	if err != nil {
+		return 0, err
+	}
+	timescaleAt := 12
+	if version[0] == 1 {
+		timescaleAt = 20
+	}
+	buf, err := p.payload(mvhd, timescaleAt+4)
+	if err != nil {
+		return 0, err
+	}
+	timescale := binary.BigEndian.Uint32(buf[timescaleAt:])
+
+	version, err = p.payload(mehd, 1)
+	if err != nil {
+		return 0, err
+	}
+	if version[0] == 1 {
+		buf, err = p.payload(mehd, 12)
+		if err != nil {
+			return 0, err
+		}
+		return toDuration(binary.BigEndian.Uint64(buf[4:]), timescale), nil
+	}
+	buf, err = p.payload(mehd, 8)
+	if err != nil {
+		return 0, err
+	}
+	return toDuration(uint64(binary.BigEndian.Uint32(buf[4:])), timescale), nil
+}
+
+// toDuration turns units of 1/timescale seconds into a duration, capped at
+// a day since nothing longer is uploaded
+func toDuration(units uint64, timescale uint32) time.Duration {
+	if timescale == 0 {
+		return 0
+	}
+	seconds := units / uint64(timescale)
+	if seconds >= 24*60*60 {
+		return 24 * time.Hour
+	}
+	rest := units % uint64(timescale) * uint64(time.Second) / uint64(timescale)
+	return time.Duration(seconds)*time.Second + time.Duration(rest)
+}
+
+// track takes the size of trak if it is a video track, from tkhd
+func (p *prober) track(trak box, info *Info) error {
+	mdia, ok, err := p.find(trak.start, trak.end, "mdia")
+	if err != nil || !ok {
+		return err
+	}
+	hdlr, ok, err := p.find(mdia.start, mdia.end, "hdlr")
+	if err != nil || !ok {
+		return err
+	}
+	// version and flags, pre_defined, then the handler type
+	handler, err := p.payload(hdlr, 12)
+	if err != nil {
+		return err
+	}
+	if string(handler[8:12]) != "vide" {
+		return nil
+	}
+
+	tkhd, ok, err := p.find(trak.start, trak.end, "tkhd")
+	if err != nil || !ok {
+		return ErrUnsupported
+	}
+	// the matrix then width and height in 16.16 fixed point end the box,
+	// version 1 widens three fields before them
+	n := int(tkhd.end - tkhd.start)
+	buf, err := p.payload(tkhd, n)
+	if err != nil {
+		return err
+	}
+	matrixAt := 40
+	if buf[0] == 1 {
+		matrixAt = 52
+	}
+	if n < matrixAt+44 {
+		return ErrUnsupported
+	}
+	width := int(binary.BigEndian.Uint32(buf[matrixAt+36:]) >> 16)
+	height := int(binary.BigEndian.Uint32(buf[matrixAt+40:]) >> 16)
+
+	// phones store portrait video landscape with a quarter turn in the
+	// matrix, which players apply
+	a := int32(binary.BigEndian.Uint32(buf[matrixAt:]))
+	b := int32(binary.BigEndian.Uint32(buf[matrixAt+4:]))
+	if a == 0 && b != 0 {
+		width, height = height, width
+	}
+	info.Width, info.Height = width, height
+	return nil
+}